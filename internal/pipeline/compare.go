@@ -0,0 +1,151 @@
+// Copyright 2025 ByteDance Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/abcoder/llm/tool"
+)
+
+// Files written to the output dir by the comparison with a baseline run
+const (
+	CompareDiffFile   = "abcoder-translation-diff.patch"
+	CompareReportFile = "abcoder-translation-diff-report.json"
+)
+
+// CompareReport summarizes the differences between a translation and a baseline run
+type CompareReport struct {
+	Added        []string `json:"added,omitempty"`
+	Removed      []string `json:"removed,omitempty"`
+	Changed      []string `json:"changed,omitempty"`
+	LinesAdded   int      `json:"lines_added"`
+	LinesRemoved int      `json:"lines_removed"`
+}
+
+// Summary returns the report in one line, e.g. "1 files added, 0 files removed, 2 files changed (+10 -3 lines)"
+func (r *CompareReport) Summary() string {
+	return fmt.Sprintf("%d files added, %d files removed, %d files changed (+%d -%d lines)",
+		len(r.Added), len(r.Removed), len(r.Changed), r.LinesAdded, r.LinesRemoved)
+}
+
+// CompareDirs diffs the files of outputDir against the ones of baselineDir, a previous translation run.
+// It returns the unified diff of all the files in patch format, baselineDir being a/ and outputDir b/,
+// and its summary. Hidden dirs and the abcoder-* files written by abcoder (reports, checkpoint) are skipped.
+func CompareDirs(outputDir, baselineDir string) (string, *CompareReport, error) {
+	outFiles, err := compareFiles(outputDir)
+	if err != nil {
+		return "", nil, err
+	}
+	baseFiles, err := compareFiles(baselineDir)
+	if err != nil {
+		return "", nil, err
+	}
+	rels := make([]string, 0, len(outFiles)+len(baseFiles))
+	for rel := range outFiles {
+		rels = append(rels, rel)
+	}
+	for rel := range baseFiles {
+		if !outFiles[rel] {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+
+	report := &CompareReport{}
+	var patch strings.Builder
+	for _, rel := range rels {
+		var before, after []byte
+		beforeName, afterName := "a/"+rel, "b/"+rel
+		if baseFiles[rel] {
+			if before, err = os.ReadFile(filepath.Join(baselineDir, rel)); err != nil {
+				return "", nil, err
+			}
+		} else {
+			beforeName = "/dev/null"
+		}
+		if outFiles[rel] {
+			if after, err = os.ReadFile(filepath.Join(outputDir, rel)); err != nil {
+				return "", nil, err
+			}
+		} else {
+			afterName = "/dev/null"
+		}
+		if baseFiles[rel] && outFiles[rel] && bytes.Equal(before, after) {
+			continue
+		}
+		switch {
+		case !baseFiles[rel]:
+			report.Added = append(report.Added, rel)
+		case !outFiles[rel]:
+			report.Removed = append(report.Removed, rel)
+		default:
+			report.Changed = append(report.Changed, rel)
+		}
+
+		if bytes.IndexByte(before, 0) >= 0 || bytes.IndexByte(after, 0) >= 0 {
+			fmt.Fprintf(&patch, "Binary files %s and %s differ\n", beforeName, afterName)
+			continue
+		}
+		diff := tool.UnifiedDiff(beforeName, afterName, string(before), string(after))
+		// skip the ---/+++ header when counting the lines
+		for i, line := range strings.Split(diff, "\n") {
+			switch {
+			case i < 2:
+			case strings.HasPrefix(line, "+"):
+				report.LinesAdded++
+			case strings.HasPrefix(line, "-"):
+				report.LinesRemoved++
+			}
+		}
+		patch.WriteString(diff)
+	}
+	return patch.String(), report, nil
+}
+
+// compareFiles returns the relative paths of the files under dir to compare
+func compareFiles(dir string) (map[string]bool, error) {
+	files := map[string]bool{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), "abcoder-") || !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list files of %s: %w", dir, err)
+	}
+	return files, nil
+}
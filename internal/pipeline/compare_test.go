@@ -0,0 +1,81 @@
+// Copyright 2025 ByteDance Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareDirs(t *testing.T) {
+	write := func(dir string, files map[string]string) {
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	baseline, output := t.TempDir(), t.TempDir()
+	write(baseline, map[string]string{
+		"go.mod":                       "module m\n",
+		"a/a.go":                       "package a\n\nfunc A() int {\n\treturn 1\n}\n",
+		"old.go":                       "package m\n",
+		"abcoder-pipeline-report.json": "{}",
+		".git/HEAD":                    "ref\n",
+	})
+	write(output, map[string]string{
+		"go.mod":                       "module m\n",
+		"a/a.go":                       "package a\n\nfunc A() int {\n\treturn 2\n}\n",
+		"b/b.go":                       "package b\n\nvar B = 1\n",
+		"abcoder-pipeline-report.json": "{\"run_id\":\"2\"}",
+	})
+
+	patch, report, err := CompareDirs(output, baseline)
+	if err != nil {
+		t.Fatalf("CompareDirs() error = %v", err)
+	}
+	want := &CompareReport{
+		Added:        []string{"b/b.go"},
+		Removed:      []string{"old.go"},
+		Changed:      []string{"a/a.go"},
+		LinesAdded:   4,
+		LinesRemoved: 2,
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("CompareDirs() report = %+v, want %+v", report, want)
+	}
+	for _, s := range []string{
+		"--- a/a/a.go\n+++ b/a/a.go\n@@ -1,5 +1,5 @@\n", "-\treturn 1\n+\treturn 2\n",
+		"--- /dev/null\n+++ b/b/b.go\n@@ -0,0 +1,3 @@\n",
+		"--- a/old.go\n+++ /dev/null\n@@ -1,1 +0,0 @@\n-package m\n",
+	} {
+		if !strings.Contains(patch, s) {
+			t.Errorf("patch misses %q:\n%s", s, patch)
+		}
+	}
+	if strings.Contains(patch, "abcoder-") || strings.Contains(patch, ".git") {
+		t.Errorf("patch contains skipped files:\n%s", patch)
+	}
+	if got := report.Summary(); got != "1 files added, 1 files removed, 1 files changed (+4 -2 lines)" {
+		t.Errorf("Summary() = %q", got)
+	}
+}
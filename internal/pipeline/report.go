@@ -0,0 +1,137 @@
+// Copyright 2025 ByteDance Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Formats of the pipeline report
+const (
+	ReportFormatJSON  = "json"
+	ReportFormatText  = "text"
+	ReportFormatJUnit = "junit"
+)
+
+// ReportFileName returns the name of the pipeline report file in format
+func ReportFileName(format string) string {
+	switch format {
+	case ReportFormatText:
+		return "abcoder-pipeline-report.txt"
+	case ReportFormatJUnit:
+		return "abcoder-pipeline-report.xml"
+	default:
+		return "abcoder-pipeline-report.json"
+	}
+}
+
+// StepDurations returns the duration of each step of history, from the end of the previous step
+// or from start for the first one. A duration is 0 if start is unknown.
+func StepDurations(start time.Time, history []StepRecord) []time.Duration {
+	durations := make([]time.Duration, len(history))
+	prev := start
+	for i, rec := range history {
+		if !prev.IsZero() && rec.Time.After(prev) {
+			durations[i] = rec.Time.Sub(prev)
+		}
+		prev = rec.Time
+	}
+	return durations
+}
+
+// TextReport returns the steps of the run as a human-readable table
+func (st *PipelineState) TextReport() []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Run %s: %s -> %s\n\n", st.RunID, st.SourceLang, st.TargetLang)
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tATTEMPT\tSTATUS\tDURATION\tERROR")
+	durations := StepDurations(st.StartTime, st.History)
+	for i, rec := range st.History {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", rec.StepName, rec.Attempt, rec.Status,
+			durations[i].Round(time.Millisecond), strings.ReplaceAll(rec.Error, "\n", " "))
+	}
+	tw.Flush()
+	return []byte(sb.String())
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitReport returns the steps of the run as JUnit XML, to be shown by CI systems:
+// a testsuite of the run with a testcase for each step, failed steps having a failure.
+func (st *PipelineState) JUnitReport() ([]byte, error) {
+	suite := junitTestSuite{Name: "abcoder-translate-" + st.RunID, Tests: len(st.History)}
+	var total time.Duration
+	durations := StepDurations(st.StartTime, st.History)
+	for i, rec := range st.History {
+		name := rec.StepName
+		if rec.Attempt > 1 {
+			name = fmt.Sprintf("%s (attempt %d)", rec.StepName, rec.Attempt)
+		}
+		tc := junitTestCase{
+			Name:      name,
+			ClassName: fmt.Sprintf("abcoder.translate.%s_to_%s", st.SourceLang, st.TargetLang),
+			Time:      junitSeconds(durations[i]),
+		}
+		if rec.Status == StepFailed {
+			suite.Failures++
+			msg := rec.Error
+			if msg == "" {
+				msg = "step failed"
+			}
+			tc.Failure = &junitFailure{Message: strings.SplitN(msg, "\n", 2)[0], Text: msg}
+		}
+		total += durations[i]
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Time = junitSeconds(total)
+
+	out, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// junitSeconds formats d in seconds as JUnit does
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 ByteDance Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestReports(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := &PipelineState{
+		RunID:      "run-1",
+		SourceLang: uniast.Java,
+		TargetLang: uniast.Golang,
+		StartTime:  start,
+		History: []StepRecord{
+			{StepName: "parse", Attempt: 1, Status: StepOK, Time: start.Add(2 * time.Second)},
+			{StepName: "transform", Attempt: 1, Status: StepOK, Time: start.Add(12 * time.Second)},
+			{StepName: "write", Attempt: 2, Status: StepFailed, Error: "permission denied\nmore", Time: start.Add(12500 * time.Millisecond)},
+		},
+	}
+
+	data, err := st.JUnitReport()
+	if err != nil {
+		t.Fatalf("JUnitReport() error = %v", err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		t.Fatalf("invalid JUnit XML: %v\n%s", err, data)
+	}
+	if len(suites.Suites) != 1 {
+		t.Fatalf("got %d test suites, want 1", len(suites.Suites))
+	}
+	suite := suites.Suites[0]
+	if suite.Tests != 3 || suite.Failures != 1 || suite.Time != "12.500" {
+		t.Errorf("suite = %+v, want 3 tests, 1 failure in 12.500s", suite)
+	}
+	for i, want := range []junitTestCase{
+		{Name: "parse", Time: "2.000"},
+		{Name: "transform", Time: "10.000"},
+		{Name: "write (attempt 2)", Time: "0.500", Failure: &junitFailure{Message: "permission denied", Text: "permission denied\nmore"}},
+	} {
+		got := suite.Cases[i]
+		if got.Name != want.Name || got.Time != want.Time || (got.Failure == nil) != (want.Failure == nil) ||
+			got.Failure != nil && *got.Failure != *want.Failure {
+			t.Errorf("testcase %d = %+v, want %+v", i, got, want)
+		}
+	}
+
+	text := string(st.TextReport())
+	for _, want := range []string{"STEP", "transform  1        ok      10s", "write      2        failed  500ms     permission denied more"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("TextReport() = %q, want it to contain %q", text, want)
+		}
+	}
+
+	if got := ReportFileName(ReportFormatJUnit); got != "abcoder-pipeline-report.xml" {
+		t.Errorf("ReportFileName(junit) = %q", got)
+	}
+}
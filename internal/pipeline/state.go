@@ -29,6 +29,8 @@ type PipelineState struct {
 
 	SourceCodePath string
 	OutputPath     string
+	// StartTime is when the run started, used to compute the duration of the first step
+	StartTime time.Time
 
 	SourceAST    *Snapshot // optional; nil when going straight to UniAST
 	SourceUniAST *Snapshot
@@ -20,8 +20,10 @@ import (
 	"maps"
 	"os"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
+	"sync"
 	"unicode"
 
 	sitter "github.com/smacker/go-tree-sitter"
@@ -42,8 +44,15 @@ type CollectOption struct {
 	NeedStdSymbol      bool
 	NoNeedComment      bool
 	NotNeedTest        bool
+	TestFileSuffix     []string // suffixes of the test files, default to ["_test.go"] (only works for Go now)
+	ModulePath         string   // path of the module at the root of the repo, overriding go.mod (only works for Go now)
+	ResolveGenerics    bool     // add a type for each concrete instantiation of a generic type, ex: Result_User (only works for Go now)
 	Excludes           []string
+	Includes           []string // if not empty, only paths with one of these prefixes are collected
 	LoadByPackages     bool
+	FileConcurrency    int  // max number of files scanned in parallel, 0 means GOMAXPROCS
+	PackageConcurrency int  // max number of packages loaded in parallel (only works for Go with LoadByPackages), 0 means 1
+	PreserveDirectives bool // keep //go:generate, //nolint, //go:embed and // Code generated comments in File.Directives (only works for Go now)
 }
 
 type Collector struct {
@@ -63,6 +72,9 @@ type Collector struct {
 	// variable (or const) => type
 	vars map[*DocumentSymbol]dependency
 
+	// symbol => leading doc comment, only collected by tree-sitter (Java) now
+	docs map[*DocumentSymbol]string
+
 	files map[string]*uniast.File
 
 	localLSPSymbol map[DocumentURI]map[Range]*DocumentSymbol
@@ -89,6 +101,7 @@ type functionInfo struct {
 	Outputs          map[int]dependency `json:"outputs,omitempty"`
 	OutputsSorted    []dependency       `json:"-"`
 	Signature        string             `json:"signature,omitempty"`
+	IsConstructor    bool               `json:"isConstructor,omitempty"` // java constructor_declaration
 }
 
 func switchSpec(l uniast.Language, repo string) LanguageSpec {
@@ -115,6 +128,7 @@ func NewCollector(repo string, cli *LSPClient) *Collector {
 		funcs: map[*DocumentSymbol]functionInfo{},
 		deps:  map[*DocumentSymbol][]dependency{},
 		vars:  map[*DocumentSymbol]dependency{},
+		docs:  map[*DocumentSymbol]string{},
 		files: map[string]*uniast.File{},
 	}
 	// if cli.Language == uniast.Rust {
@@ -284,19 +298,48 @@ func (c *Collector) Collect(ctx context.Context) error {
 	return nil
 }
 
-func (c *Collector) ScannerFile(ctx context.Context) []*DocumentSymbol {
-	c.configureLSP(ctx)
-	excludes := make([]string, len(c.Excludes))
-	for i, e := range c.Excludes {
+// absPatterns joins relative path patterns with the repo dir
+func (c *Collector) absPatterns(patterns []string) []string {
+	ret := make([]string, len(patterns))
+	for i, e := range patterns {
 		if !filepath.IsAbs(e) {
-			excludes[i] = filepath.Join(c.repo, e)
+			ret[i] = filepath.Join(c.repo, e)
 		} else {
-			excludes[i] = e
+			ret[i] = e
+		}
+	}
+	return ret
+}
+
+// shouldSkipPath tells if the path doesn't have any of the include prefixes (when given), or has one of the exclude prefixes
+func shouldSkipPath(path string, includes, excludes []string) bool {
+	if len(includes) > 0 {
+		included := false
+		for _, in := range includes {
+			if strings.HasPrefix(path, in) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return true
+		}
+	}
+	for _, e := range excludes {
+		if strings.HasPrefix(path, e) {
+			return true
 		}
 	}
+	return false
+}
+
+func (c *Collector) ScannerFile(ctx context.Context) []*DocumentSymbol {
+	c.configureLSP(ctx)
+	excludes := c.absPatterns(c.Excludes)
+	includes := c.absPatterns(c.Includes)
 
 	// scan all files
-	root_syms := make([]*DocumentSymbol, 0, 1024)
+	var paths []string
 	scanner := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -304,68 +347,117 @@ func (c *Collector) ScannerFile(ctx context.Context) []*DocumentSymbol {
 		if info.IsDir() {
 			return nil
 		}
-		for _, e := range excludes {
-			if strings.HasPrefix(path, e) {
-				return nil
-			}
+		if shouldSkipPath(path, includes, excludes) {
+			return nil
 		}
 
 		if c.spec.ShouldSkip(path) {
 			return nil
 		}
 
-		file := c.files[path]
-		if file == nil {
+		if c.files[path] == nil {
 			rel, err := filepath.Rel(c.repo, path)
 			if err != nil {
 				return err
 			}
-			file = uniast.NewFile(rel)
-			c.files[path] = file
+			c.files[path] = uniast.NewFile(rel)
 		}
+		paths = append(paths, path)
+		return nil
+	}
+	if err := filepath.Walk(c.repo, scanner); err != nil {
+		log.Error("scan files failed: %v", err)
+	}
 
-		// 解析use语句
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
-		uses, err := c.spec.FileImports(content)
-		if err != nil {
-			log.Error("parse file %s use statements failed: %v", path, err)
-		} else {
-			file.Imports = uses
-		}
+	// scan the files in parallel, then merge the results in walking order to keep the output stable
+	results := make([]scannedFile, len(paths))
+	sem := make(chan struct{}, c.fileConcurrency())
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, path string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = c.scanFile(ctx, path)
+		}(i, path)
+	}
+	wg.Wait()
 
-		// collect symbols
-		uri := NewURI(path)
-		symbols, err := c.cli.DocumentSymbols(ctx, uri)
-		if err != nil {
-			return err
+	root_syms := make([]*DocumentSymbol, 0, 1024)
+	for i, res := range results {
+		if res.err != nil {
+			log.Error("scan files failed: %v", res.err)
+			break
 		}
-		// file := filepath.Base(path)
-		for _, sym := range symbols {
-			// collect content
-			content, err := c.cli.Locate(sym.Location)
-			if err != nil {
-				return err
-			}
-			// collect tokens
-			tokens, err := c.cli.SemanticTokens(ctx, sym.Location)
-			if err != nil {
-				return err
-			}
-			sym.Text = content
-			sym.Tokens = tokens
+		if res.imports != nil {
+			c.files[paths[i]].Imports = res.imports
+		}
+		for _, sym := range res.symbols {
 			c.syms[sym.Location] = sym
 			root_syms = append(root_syms, sym)
 		}
+	}
+	return root_syms
+}
 
-		return nil
+// scannedFile is the result of scanning a file
+type scannedFile struct {
+	imports []uniast.Import
+	symbols []*DocumentSymbol
+	err     error
+}
+
+func (c *Collector) fileConcurrency() int {
+	if c.FileConcurrency > 0 {
+		return c.FileConcurrency
 	}
-	if err := filepath.Walk(c.repo, scanner); err != nil {
-		log.Error("scan files failed: %v", err)
+	return runtime.GOMAXPROCS(0)
+}
+
+// scanFile collects the imports and the root symbols (with content and tokens) of the file.
+// It only reads the collector, so it can be called concurrently.
+func (c *Collector) scanFile(ctx context.Context, path string) (ret scannedFile) {
+	// 解析use语句
+	content, err := os.ReadFile(path)
+	if err != nil {
+		ret.err = err
+		return
 	}
-	return root_syms
+	uses, err := c.spec.FileImports(content)
+	if err != nil {
+		log.Error("parse file %s use statements failed: %v", path, err)
+	} else {
+		ret.imports = uses
+	}
+
+	// collect symbols
+	uri := NewURI(path)
+	symbols, err := c.cli.DocumentSymbols(ctx, uri)
+	if err != nil {
+		ret.err = err
+		return
+	}
+	for _, sym := range symbols {
+		// collect content
+		content, err := c.cli.Locate(sym.Location)
+		if err != nil {
+			ret.err = err
+			return
+		}
+		// collect tokens
+		tokens, err := c.cli.SemanticTokens(ctx, sym.Location)
+		if err != nil {
+			ret.err = err
+			return
+		}
+		sym.Text = content
+		sym.Tokens = tokens
+		ret.symbols = append(ret.symbols, sym)
+	}
+	return
 }
 
 func (c *Collector) ScannerByTreeSitter(ctx context.Context) ([]*DocumentSymbol, error) {
@@ -384,14 +476,8 @@ func (c *Collector) ScannerByTreeSitter(ctx context.Context) ([]*DocumentSymbol,
 	}
 
 	c.configureLSP(ctx)
-	excludes := make([]string, len(c.Excludes))
-	for i, e := range c.Excludes {
-		if !filepath.IsAbs(e) {
-			excludes[i] = filepath.Join(c.repo, e)
-		} else {
-			excludes[i] = e
-		}
-	}
+	excludes := c.absPatterns(c.Excludes)
+	includes := c.absPatterns(c.Includes)
 
 	scanner := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -400,10 +486,8 @@ func (c *Collector) ScannerByTreeSitter(ctx context.Context) ([]*DocumentSymbol,
 		if info.IsDir() {
 			return nil
 		}
-		for _, e := range excludes {
-			if strings.HasPrefix(path, e) {
-				return nil
-			}
+		if shouldSkipPath(path, includes, excludes) {
+			return nil
 		}
 
 		if c.spec.ShouldSkip(path) {
@@ -643,6 +727,9 @@ func (c *Collector) walk(node *sitter.Node, uri DocumentURI, content []byte, fil
 			Node: node,
 			Role: DEFINITION,
 		}
+		if doc := javaDocString(node, content); doc != "" {
+			c.docs[sym] = doc
+		}
 
 		symbols := c.findLocalLSPSymbol(sym.Location.URI)
 		for _, symbol := range symbols {
@@ -699,10 +786,10 @@ func (c *Collector) walk(node *sitter.Node, uri DocumentURI, content []byte, fil
 		}
 		return // children already walked
 
-	case "method_declaration":
+	case "method_declaration", "constructor_declaration":
 		nameNode := node.ChildByFieldName("name")
 		if nameNode == nil {
-			return // Can be a constructor
+			return
 		}
 		name := nameNode.Content(content)
 		start := node.StartPoint()
@@ -732,6 +819,9 @@ func (c *Collector) walk(node *sitter.Node, uri DocumentURI, content []byte, fil
 			Node: node,
 			Role: DEFINITION,
 		}
+		if doc := javaDocString(node, content); doc != "" {
+			c.docs[sym] = doc
+		}
 
 		symbols := c.findLocalLSPSymbol(sym.Location.URI)
 		signature := c.parseMethodSignature(node, content)
@@ -743,9 +833,10 @@ func (c *Collector) walk(node *sitter.Node, uri DocumentURI, content []byte, fil
 		}
 
 		info := functionInfo{
-			TypeParams: make(map[int]dependency),
-			Inputs:     make(map[int]dependency),
-			Outputs:    make(map[int]dependency),
+			TypeParams:    make(map[int]dependency),
+			Inputs:        make(map[int]dependency),
+			Outputs:       make(map[int]dependency),
+			IsConstructor: node.Type() == "constructor_declaration",
 		}
 
 		// Parse type parameters
@@ -1000,6 +1091,15 @@ func isStaticMethod(node *sitter.Node, content []byte) bool {
 	return strings.Contains(modifiersString, "static")
 }
 
+// javaDocString returns the javadoc `/** */` block right before the declaration node
+func javaDocString(node *sitter.Node, content []byte) string {
+	prev := node.PrevSibling()
+	if prev == nil || !strings.HasSuffix(prev.Type(), "comment") {
+		return ""
+	}
+	return java.DocString(prev.Content(content))
+}
+
 func (c *Collector) internal(loc Location) bool {
 	return strings.HasPrefix(loc.URI.File(), c.repo)
 }
@@ -1313,6 +1413,15 @@ func (c *Collector) processSymbol(ctx context.Context, sym *DocumentSymbol, dept
 				log.Error("get receiver symbol for token %v failed: %v\n", rec, err)
 			}
 		}
+		// NOTICE: methods defined inside their class (C++), the class is the receiver
+		if !hasImpl && rsym == nil && sym.Kind == SKMethod {
+			if p := c.cli.GetParent(sym); p != nil && (p.Kind == SKClass || p.Kind == SKStruct) {
+				// use the class name as the location, the class range includes the method itself
+				if i := c.spec.DeclareTokenOfSymbol(*p); i >= 0 {
+					rsym = &dependency{Location: p.Tokens[i].Location, Symbol: p}
+				}
+			}
+		}
 		tsyms, ts := c.getDepsWithLimit(ctx, sym, tps, depth-1)
 		ipsyms, is := c.getDepsWithLimit(ctx, sym, ips, depth-1)
 		opsyms, os := c.getDepsWithLimit(ctx, sym, ops, depth-1)
@@ -18,7 +18,9 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/abcoder/lang/java"
@@ -62,6 +64,78 @@ func TestCollector_CollectByTreeSitter_Java(t *testing.T) {
 		}
 	})
 }
+func TestCollector_Collect_JavaConstructor(t *testing.T) {
+	if _, err := exec.LookPath("java"); err != nil {
+		t.Skip("java is not installed")
+	}
+	repo := t.TempDir()
+	src := filepath.Join(repo, "src/main/java/org/example")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	code := `package org.example;
+
+public class User {
+    private String name;
+
+    public User(String name) {
+        this.name = name;
+    }
+
+    public String getName() {
+        return name;
+    }
+}
+`
+	if err := os.WriteFile(filepath.Join(src, "User.java"), []byte(code), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	lsp.RegisterProvider(uniast.Java, &javaLsp.JavaProvider{})
+	openfile, wait := java.CheckRepo(repo)
+	l, s := java.GetDefaultLSP(make(map[string]string))
+	client, err := lsp.NewLSPClient(repo, openfile, wait, lsp.ClientOptions{
+		Server:   s,
+		Language: l,
+	})
+	if err != nil {
+		t.Fatalf("NewLSPClient() failed = %v", err)
+	}
+	defer client.Close()
+
+	c := NewCollector(repo, client)
+	c.Language = uniast.Java
+	if err := c.Collect(context.Background()); err != nil {
+		t.Fatalf("Collector.Collect() failed = %v", err)
+	}
+	ast, err := c.Export(context.Background())
+	if err != nil {
+		t.Fatalf("Collector.Export() failed = %v", err)
+	}
+
+	var ctor, getter *uniast.Function
+	for _, f := range ast.AllFunctions {
+		if f.Receiver == nil || f.Receiver.Type.Name != "User" {
+			continue
+		}
+		switch {
+		case strings.Contains(f.Content, "public User("):
+			ctor = f
+		case strings.Contains(f.Content, "getName()"):
+			getter = f
+		}
+	}
+	if ctor == nil {
+		t.Fatalf("constructor User(String) not collected")
+	}
+	if !ctor.IsConstructor {
+		t.Errorf("%s: IsConstructor = false, want true", ctor.Name)
+	}
+	if getter == nil || getter.IsConstructor {
+		t.Errorf("getName() = %+v, want a non-constructor method", getter)
+	}
+}
+
 func TestCollector_Collect(t *testing.T) {
 	log.SetLogLevel(log.DebugLevel)
 	rustLSP, rustTestCase, err := lsp.InitLSPForFirstTest(uniast.Rust, "rust-analyzer")
@@ -100,3 +174,93 @@ func TestCollector_Collect(t *testing.T) {
 		}
 	})
 }
+
+func TestShouldSkipPath(t *testing.T) {
+	includes := []string{"/repo/pkg/api", "/repo/pkg/model"}
+	excludes := []string{"/repo/pkg/api/internal"}
+	tests := []struct {
+		path     string
+		includes []string
+		want     bool
+	}{
+		{"/repo/pkg/api/user.java", includes, false},
+		{"/repo/pkg/model/user.java", includes, false},
+		{"/repo/pkg/api/internal/impl.java", includes, true},
+		{"/repo/pkg/service/svc.java", includes, true},
+		{"/repo/pkg/service/svc.java", nil, false},
+		{"/repo/pkg/api/internal/impl.java", nil, true},
+	}
+	for _, tt := range tests {
+		if got := shouldSkipPath(tt.path, tt.includes, excludes); got != tt.want {
+			t.Errorf("shouldSkipPath(%q, %v) = %v, want %v", tt.path, tt.includes, got, tt.want)
+		}
+	}
+}
+
+func TestCollector_SymbolTags(t *testing.T) {
+	c := &Collector{CollectOption: CollectOption{Language: uniast.Java}}
+	content := "@RestController\n@RequestMapping(\"/api\")\n@Deprecated\npublic class UserController {\n\t@GetMapping(\"/users\")\n\tpublic List<User> list() { return null; }\n}"
+	tags := c.symbolTags(content)
+	if want := `@RestController @RequestMapping("/api")`; tags[uniast.TagSpring] != want {
+		t.Errorf("symbolTags() = %q, want %q", tags[uniast.TagSpring], want)
+	}
+
+	if tags := c.symbolTags("public class Plain {}"); tags != nil {
+		t.Errorf("symbolTags() of plain class = %v, want nil", tags)
+	}
+
+	c.Language = uniast.Rust
+	if tags := c.symbolTags(content); tags != nil {
+		t.Errorf("symbolTags() of non-Java = %v, want nil", tags)
+	}
+}
+
+func TestCollector_SymbolDocString(t *testing.T) {
+	fn := &lsp.DocumentSymbol{Kind: lsp.SKFunction}
+	c := &Collector{CollectOption: CollectOption{Language: uniast.Python}}
+	content := "@cache\ndef load(path: str) -> str:  # noqa\n    \"\"\"Load a file.\n\n    Args:\n        path: the file path\n    \"\"\"\n    return open(path).read()"
+	if got, want := c.symbolDocString(fn, content), "Load a file.\n\nArgs:\n    path: the file path"; got != want {
+		t.Errorf("symbolDocString() = %q, want %q", got, want)
+	}
+	if got := c.symbolDocString(fn, "def f():\n    return '''not a doc'''"); got != "" {
+		t.Errorf("symbolDocString() without docstring = %q, want empty", got)
+	}
+
+	c.Language = uniast.Java
+	c.docs = map[*lsp.DocumentSymbol]string{fn: java.DocString("/**\n * Loads a file.\n *\n * @param path the file path\n */")}
+	if got, want := c.symbolDocString(fn, "public static String load(String path) {}"), "Loads a file.\n\n@param path the file path"; got != want {
+		t.Errorf("symbolDocString() = %q, want %q", got, want)
+	}
+	if got := java.DocString("/* not a javadoc */"); got != "" {
+		t.Errorf("DocString() of block comment = %q, want empty", got)
+	}
+}
+
+func TestIsConstructor(t *testing.T) {
+	user := uniast.Identity{ModPath: "m", PkgPath: "p", Name: "User"}
+	method := &uniast.Function{IsMethod: true, Receiver: &uniast.Receiver{Type: user}}
+	tests := []struct {
+		lang    uniast.Language
+		name    string
+		content string
+		f       *uniast.Function
+		want    bool
+	}{
+		{uniast.Java, "User", "public User(String name) {}", method, true},
+		{uniast.Java, "<init>", "", method, true},
+		{uniast.Java, "getName", "public String getName() {}", method, false},
+		{uniast.Python, "__init__", "def __init__(self, name):", method, true},
+		{uniast.Python, "__init__", "def __init__(self, name):", &uniast.Function{}, false},
+		{uniast.Rust, "new", "pub fn new(name: String) -> Self {", method, true},
+		{uniast.Rust, "new", "pub fn new<T>(name: T) -> Self\nwhere T: Into<String> {", method, true},
+		{uniast.Rust, "new", "pub fn new(name: String) -> User {", &uniast.Function{IsMethod: true, Receiver: &uniast.Receiver{Type: user}, Results: []uniast.Dependency{{Identity: user}}}, true},
+		{uniast.Rust, "new", "pub fn new() -> Option<Self> {", method, false},
+		{uniast.Rust, "new", "pub fn new() -> Self {", &uniast.Function{}, false},
+		{uniast.Golang, "NewUser", "func NewUser() *User {", &uniast.Function{}, false},
+	}
+	for _, tt := range tests {
+		if got := isConstructor(tt.lang, tt.name, tt.content, tt.f); got != tt.want {
+			t.Errorf("isConstructor(%s, %q, %q) = %v, want %v", tt.lang, tt.name, tt.content, got, tt.want)
+		}
+	}
+}
@@ -19,10 +19,13 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"strings"
 
+	"github.com/cloudwego/abcoder/lang/java"
 	"github.com/cloudwego/abcoder/lang/log"
 	. "github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/python"
 	"github.com/cloudwego/abcoder/lang/uniast"
 	"github.com/cloudwego/abcoder/lang/utils"
 )
@@ -82,6 +85,9 @@ func (c *Collector) Export(ctx context.Context) (*uniast.Repository, error) {
 			return nil, err
 		}
 		repo.Modules[name] = newModule(name, rel, c.Language)
+		if ds, ok := c.spec.(ModuleDependencySpec); ok {
+			repo.Modules[name].ExternalDependencies = ds.ModuleDependencies(name)
+		}
 	}
 
 	// not allow local symbols inside another symbol
@@ -263,9 +269,11 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 			break
 		}
 		obj := &uniast.Function{
-			FileLine: fileLine,
-			Content:  content,
-			Exported: public,
+			FileLine:  fileLine,
+			Content:   content,
+			Exported:  public,
+			Tags:      c.symbolTags(content),
+			DocString: c.symbolDocString(symbol, content),
 		}
 		info := c.funcs[symbol]
 		obj.Signature = info.Signature
@@ -335,6 +343,7 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 				}
 			}
 		}
+		obj.IsConstructor = info.IsConstructor || isConstructor(c.Language, name, content, obj)
 		// collect deps
 		if deps := c.deps[symbol]; deps != nil {
 			for _, dep := range deps {
@@ -374,10 +383,16 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 	// Type
 	case SKStruct, SKTypeParameter, SKInterface, SKEnum, SKClass:
 		obj := &uniast.Type{
-			FileLine: fileLine,
-			Content:  content,
-			TypeKind: mapKind(k),
-			Exported: public,
+			FileLine:  fileLine,
+			Content:   content,
+			TypeKind:  mapKind(k),
+			Exported:  public,
+			Tags:      c.symbolTags(content),
+			DocString: c.symbolDocString(symbol, content),
+		}
+		if k == SKTypeParameter && typeAliasRegex.MatchString(content) {
+			// rust-analyzer reports `type A = B;` as a type parameter
+			obj.TypeKind = uniast.TypeKindAlias
 		}
 		// collect deps
 		if deps := c.deps[symbol]; deps != nil {
@@ -418,6 +433,8 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 			Content:    content,
 			IsExported: public,
 			IsConst:    k == SKConstant,
+			Tags:       c.symbolTags(content),
+			DocString:  c.symbolDocString(symbol, content),
 		}
 		if ty, ok := c.vars[symbol]; ok {
 			tok, _ := c.cli.Locate(ty.Location)
@@ -435,6 +452,73 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 	return
 }
 
+// symbolTags extracts the language-specific metadata annotations from the symbol content
+func (c *Collector) symbolTags(content string) map[string]string {
+	if c.Language == uniast.Java {
+		if spring := java.SpringAnnotations(content); spring != "" {
+			return map[string]string{uniast.TagSpring: spring}
+		}
+	}
+	return nil
+}
+
+// symbolDocString extracts the leading doc comment of the symbol, without comment markers
+func (c *Collector) symbolDocString(symbol *DocumentSymbol, content string) string {
+	switch c.Language {
+	case uniast.Java:
+		return c.docs[symbol]
+	case uniast.Python:
+		// only defs and classes have docstrings
+		if symbol.Kind != SKConstant && symbol.Kind != SKVariable {
+			return python.DocString(content)
+		}
+	}
+	return ""
+}
+
+// isConstructor tells if the function named name, whose source is content, is a constructor of its receiver type:
+// <init> (or the name of the class) in Java, __init__ in Python, and new returning Self or the receiver type in Rust
+func isConstructor(lang uniast.Language, name, content string, f *uniast.Function) bool {
+	switch lang {
+	case uniast.Java:
+		return name == "<init>" || (f.Receiver != nil && name == f.Receiver.Type.Name)
+	case uniast.Python:
+		return name == "__init__" && f.IsMethod
+	case uniast.Rust:
+		if name != "new" || f.Receiver == nil {
+			return false
+		}
+		for _, r := range f.Results {
+			if r.Identity == f.Receiver.Type {
+				return true
+			}
+		}
+		return rustReturnsSelf(content)
+	default:
+		return false
+	}
+}
+
+// rustReturnsSelf tells if the signature of the rust function returns Self
+func rustReturnsSelf(content string) bool {
+	sig := content
+	if i := strings.Index(sig, "{"); i >= 0 {
+		sig = sig[:i]
+	}
+	i := strings.LastIndex(sig, "->")
+	if i < 0 {
+		return false
+	}
+	ret := strings.TrimSpace(sig[i+2:])
+	if j := strings.Index(ret, "where"); j >= 0 {
+		ret = strings.TrimSpace(ret[:j])
+	}
+	return ret == "Self"
+}
+
+// typeAliasRegex matches the declaration of a type alias, e.g. `pub type Result<T> = ...` or `export type ID = ...`
+var typeAliasRegex = regexp.MustCompile(`^\s*(?:export\s+|pub(?:\([^)]*\))?\s+)?type\s+\w+[^=;{]*=`)
+
 func mapKind(kind SymbolKind) uniast.TypeKind {
 	switch kind {
 	case SKStruct:
@@ -37,7 +37,11 @@ func CheckRepo(repo string) (string, time.Duration) {
 	// TODO: check if the project compiles.
 
 	// NOTICE: wait for Rust projects based on code files
-	_, size := utils.CountFiles(repo, ".c", "build/")
+	size := 0
+	for _, ext := range []string{".c", ".cc", ".cpp", ".cxx"} {
+		_, n := utils.CountFiles(repo, ext, "build/")
+		size += n
+	}
 	wait := 2*time.Second + time.Second*time.Duration(size/1024)
 	if wait > MaxWaitDuration {
 		wait = MaxWaitDuration
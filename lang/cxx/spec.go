@@ -16,8 +16,11 @@ package cxx
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 
 	lsp "github.com/cloudwego/abcoder/lang/lsp"
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -26,18 +29,42 @@ import (
 
 type CxxSpec struct {
 	repo string
+
+	// namespaces caches the C++ namespace of each file, file path => namespace
+	nsMu       sync.Mutex
+	namespaces map[string]string
 }
 
+// sourceExts are the extensions of the C/C++ source and header files
+var sourceExts = []string{".c", ".h", ".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx"}
+
+var (
+	includeRegex   = regexp.MustCompile(`(?m)^\s*#\s*include\s*([<"][^>"]+[>"])`)
+	namespaceRegex = regexp.MustCompile(`(?m)^\s*(?:inline\s+)?namespace\s+([A-Za-z_][\w:]*)\s*\{`)
+	// nestedNamespaceRegex matches a namespace declared right after the previous one
+	nestedNamespaceRegex = regexp.MustCompile(`^\s*(?:inline\s+)?namespace\s+([A-Za-z_][\w:]*)\s*\{`)
+	// commentRegex matches the line and block comments, which may contain fake namespaces
+	commentRegex = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+)
+
+// symbols defined in a namespace or a class are nested in their document symbols,
+// keep them from being filtered as local symbols
 func (c *CxxSpec) ProtectedSymbolKinds() []lsp.SymbolKind {
-	return []lsp.SymbolKind{}
+	return []lsp.SymbolKind{lsp.SKClass, lsp.SKStruct, lsp.SKEnum, lsp.SKFunction, lsp.SKMethod, lsp.SKVariable, lsp.SKConstant}
 }
 
 func NewCxxSpec() *CxxSpec {
-	return &CxxSpec{}
+	return &CxxSpec{namespaces: map[string]string{}}
 }
 
+// FileImports collects the #include directives, the path keeps the brackets or quotes,
+// e.g. <stdio.h> or "foo.h"
 func (c *CxxSpec) FileImports(content []byte) ([]uniast.Import, error) {
-	return nil, nil
+	var ret []uniast.Import
+	for _, m := range includeRegex.FindAllSubmatch(content, -1) {
+		ret = append(ret, uniast.Import{Path: string(m[1])})
+	}
+	return ret, nil
 }
 
 // XXX: maybe multi module support for C++?
@@ -55,6 +82,7 @@ func (c *CxxSpec) WorkSpace(root string) (map[string]string, error) {
 // returns: modname, pathpath, error
 // Multiple symbols with the same name could occur (for example in the Linux kernel).
 // The identify is mod::pkg::name. So we use the pkg (the file name) to distinguish them.
+// For C++ files declaring a namespace, the pkg is the namespace instead, e.g. foo::bar
 func (c *CxxSpec) NameSpace(path string, file *uniast.File) (string, string, error) {
 	// external lib: only standard library (system headers), in /usr/
 	if !strings.HasPrefix(path, c.repo) {
@@ -65,13 +93,50 @@ func (c *CxxSpec) NameSpace(path string, file *uniast.File) (string, string, err
 		panic(fmt.Sprintf("external lib: %s\n", path))
 	}
 
+	if ns := c.fileNamespace(path); ns != "" {
+		return "current", ns, nil
+	}
 	relpath, _ := filepath.Rel(c.repo, path)
 	return "current", relpath, nil
 }
 
+// fileNamespace returns the first (nested) namespace declared in the file, or "" if none
+func (c *CxxSpec) fileNamespace(path string) string {
+	c.nsMu.Lock()
+	defer c.nsMu.Unlock()
+	if ns, ok := c.namespaces[path]; ok {
+		return ns
+	}
+	var ns string
+	if content, err := os.ReadFile(path); err == nil {
+		ns = parseNamespace(string(content))
+	}
+	if c.namespaces == nil {
+		c.namespaces = map[string]string{}
+	}
+	c.namespaces[path] = ns
+	return ns
+}
+
+// parseNamespace joins the directly nested namespaces of the first namespace declaration,
+// e.g. `namespace foo { namespace bar {` => foo::bar
+func parseNamespace(content string) string {
+	content = commentRegex.ReplaceAllString(content, "")
+	var parts []string
+	m := namespaceRegex.FindStringSubmatchIndex(content)
+	for m != nil {
+		parts = append(parts, content[m[2]:m[3]])
+		content = content[m[1]:]
+		m = nestedNamespaceRegex.FindStringSubmatchIndex(content)
+	}
+	return strings.Join(parts, "::")
+}
+
 func (c *CxxSpec) ShouldSkip(path string) bool {
-	if strings.HasSuffix(path, ".c") || strings.HasSuffix(path, ".h") {
-		return false
+	for _, ext := range sourceExts {
+		if strings.HasSuffix(path, ext) {
+			return false
+		}
 	}
 	return true
 }
@@ -95,7 +160,7 @@ func (c *CxxSpec) DeclareTokenOfSymbol(sym lsp.DocumentSymbol) int {
 }
 
 func (c *CxxSpec) IsEntityToken(tok lsp.Token) bool {
-	return tok.Type == "class" || tok.Type == "function" || tok.Type == "variable"
+	return tok.Type == "class" || tok.Type == "function" || tok.Type == "method" || tok.Type == "variable"
 }
 
 func (c *CxxSpec) IsStdToken(tok lsp.Token) bool {
@@ -112,15 +177,19 @@ func (c *CxxSpec) TokenKind(tok lsp.Token) lsp.SymbolKind {
 		return lsp.SKEnumMember
 	case "function", "macro":
 		return lsp.SKFunction
+	case "method":
+		return lsp.SKMethod
 	// rust spec does not treat parameter as a variable
 	case "parameter":
 		return lsp.SKVariable
 	case "typeParameter":
 		return lsp.SKTypeParameter
-	// type: TODO
-	case "interface", "concept", "method", "modifier", "namespace", "type":
-		panic(fmt.Sprintf("Unsupported token type: %s at %+v\n", tok.Type, tok.Location))
-	case "bracket", "comment", "label", "operator", "property", "unknown":
+	case "interface":
+		return lsp.SKInterface
+	case "namespace":
+		return lsp.SKNamespace
+	// type: typedef and type alias, TODO
+	case "bracket", "comment", "label", "operator", "property", "unknown", "concept", "modifier", "type":
 		return lsp.SKUnknown
 	}
 	panic(fmt.Sprintf("Weird token type: %s at %+v\n", tok.Type, tok.Location))
@@ -132,8 +201,8 @@ func (c *CxxSpec) IsMainFunction(sym lsp.DocumentSymbol) bool {
 
 func (c *CxxSpec) IsEntitySymbol(sym lsp.DocumentSymbol) bool {
 	typ := sym.Kind
-	return typ == lsp.SKFunction || typ == lsp.SKVariable || typ == lsp.SKClass
-
+	return typ == lsp.SKFunction || typ == lsp.SKMethod || typ == lsp.SKVariable || typ == lsp.SKConstant ||
+		typ == lsp.SKClass || typ == lsp.SKStruct || typ == lsp.SKEnum
 }
 
 func (c *CxxSpec) IsPublicSymbol(sym lsp.DocumentSymbol) bool {
@@ -142,14 +211,16 @@ func (c *CxxSpec) IsPublicSymbol(sym lsp.DocumentSymbol) bool {
 		return false
 	}
 	for _, m := range sym.Tokens[id].Modifiers {
-		if m == "globalScope" {
+		// TODO(cpp): check the access specifier of class members
+		if m == "globalScope" || m == "classScope" {
 			return true
 		}
 	}
 	return false
 }
 
-// TODO(cpp): support C++ OOP
+// NOTICE: C++ methods are nested in their class, the receiver is collected from the parent symbol.
+// TODO(cpp): support out-of-class method definitions
 func (c *CxxSpec) HasImplSymbol() bool {
 	return false
 }
@@ -160,7 +231,7 @@ func (c *CxxSpec) ImplSymbol(sym lsp.DocumentSymbol) (int, int, int) {
 
 func (c *CxxSpec) FunctionSymbol(sym lsp.DocumentSymbol) (int, []int, []int, []int) {
 	// No receiver and no type params for C
-	if sym.Kind != lsp.SKFunction {
+	if sym.Kind != lsp.SKFunction && sym.Kind != lsp.SKMethod {
 		return -1, nil, nil, nil
 	}
 	receiver := -1
@@ -180,7 +251,7 @@ func (c *CxxSpec) FunctionSymbol(sym lsp.DocumentSymbol) (int, []int, []int, []i
 	for i, tok := range sym.Tokens {
 		switch phase {
 		case 0:
-			if tok.Type == "function" {
+			if tok.Type == "function" || tok.Type == "method" {
 				offset := lsp.RelativePostionWithLines(*lines, sym.Location.Range.Start, tok.Location.Range.Start)
 				endRelOffset = offset + strings.Index(sym.Text[offset:], ")")
 				phase = 1
@@ -0,0 +1,82 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cxx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestCxxSpec_FileImports(t *testing.T) {
+	src := "#include <vector>\n  # include \"util/log.h\"\nint x; // #include <not_this>\n"
+	got, err := NewCxxSpec().FileImports([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uniast.Import{{Path: "<vector>"}, {Path: `"util/log.h"`}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FileImports() = %v, want %v", got, want)
+	}
+}
+
+func TestParseNamespace(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"none", "int main() { return 0; }", ""},
+		{"using", "using namespace std;\nint x;", ""},
+		{"simple", "#include <a>\nnamespace foo {\nclass A {};\n}", "foo"},
+		{"nested", "namespace foo {\nnamespace bar {\nclass A {};\n}}", "foo::bar"},
+		{"cxx17", "namespace foo::bar {\n}", "foo::bar"},
+		{"comment", "// namespace fake {\n/* namespace fake2 { */\nnamespace real {\n}", "real"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNamespace(tt.content); got != tt.want {
+				t.Errorf("parseNamespace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCxxSpec_NameSpace(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.cpp": "namespace foo { namespace bar {\nvoid f() {}\n} }\n",
+		"b.c":   "void g() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	spec := NewCxxSpec()
+	if _, err := spec.WorkSpace(dir); err != nil {
+		t.Fatal(err)
+	}
+	if spec.ShouldSkip(filepath.Join(dir, "a.cpp")) || !spec.ShouldSkip(filepath.Join(dir, "a.txt")) {
+		t.Errorf("ShouldSkip() should only accept C/C++ files")
+	}
+	if _, pkg, _ := spec.NameSpace(filepath.Join(dir, "a.cpp"), nil); pkg != "foo::bar" {
+		t.Errorf("NameSpace() of a C++ file = %q, want foo::bar", pkg)
+	}
+	if _, pkg, _ := spec.NameSpace(filepath.Join(dir, "b.c"), nil); pkg != "b.c" {
+		t.Errorf("NameSpace() of a C file = %q, want b.c", pkg)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -34,8 +35,25 @@ var _ uniast.Writer = (*Writer)(nil)
 
 type Options struct {
 	CompilerPath string
+	// FileFilter is called with each source file path (relative to the output dir) before writing;
+	// returning false skips the file. nil writes all files.
+	FileFilter func(filePath string) bool
+	// SplitByPackage writes the headers and sources of each namespace together into its own directory under the module root,
+	// instead of splitting them into include/ and src/.
+	SplitByPackage bool
+	// BuildSystem selects the build files generated for each module: BuildSystemCMake (default), BuildSystemMeson or BuildSystemBoth
+	BuildSystem string
 }
 
+const (
+	// BuildSystemCMake generates CMakeLists.txt
+	BuildSystemCMake = "cmake"
+	// BuildSystemMeson generates meson.build
+	BuildSystemMeson = "meson"
+	// BuildSystemBoth generates both CMakeLists.txt and meson.build
+	BuildSystemBoth = "both"
+)
+
 type Writer struct {
 	Options
 	visited map[string]map[string]*fileNode // namespace -> filename -> fileNode
@@ -61,6 +79,9 @@ func NewWriter(opts Options) *Writer {
 	if opts.CompilerPath == "" {
 		opts.CompilerPath = "g++"
 	}
+	if opts.BuildSystem == "" {
+		opts.BuildSystem = BuildSystemCMake
+	}
 	return &Writer{
 		Options: opts,
 		visited: make(map[string]map[string]*fileNode),
@@ -73,6 +94,11 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 	if mod == nil {
 		return fmt.Errorf("module %s not found", modPath)
 	}
+	switch w.BuildSystem {
+	case BuildSystemCMake, BuildSystemMeson, BuildSystemBoth:
+	default:
+		return fmt.Errorf("unsupported C++ build system %q, must be %s, %s or %s", w.BuildSystem, BuildSystemCMake, BuildSystemMeson, BuildSystemBoth)
+	}
 
 	// Collect all packages
 	for _, pkg := range mod.Packages {
@@ -85,6 +111,9 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 	outdir := filepath.Join(outDir, mod.Dir)
 	includeDir := filepath.Join(outdir, "include")
 	srcDir := filepath.Join(outDir, mod.Dir, "src")
+	if w.SplitByPackage {
+		includeDir, srcDir = outdir, outdir
+	}
 
 	// Create directories
 	if err := os.MkdirAll(includeDir, 0755); err != nil {
@@ -106,6 +135,9 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 
 		for typeName, header := range nsHeaders {
 			headerPath := filepath.Join(nsDir, typeName+".h")
+			if !utils.ShouldWriteFile(w.FileFilter, outDir, headerPath) {
+				continue
+			}
 			if err := w.writeHeaderFile(headerPath, namespace, typeName, header); err != nil {
 				return fmt.Errorf("write header %s failed: %v", headerPath, err)
 			}
@@ -114,9 +146,8 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 
 	// Write implementation files
 	for namespace, nsFiles := range w.visited {
-		nsDir := srcDir
 		if namespace != "" {
-			nsDir = filepath.Join(srcDir, strings.ReplaceAll(namespace, "::", string(filepath.Separator)))
+			nsDir := filepath.Join(srcDir, strings.ReplaceAll(namespace, "::", string(filepath.Separator)))
 			if err := os.MkdirAll(nsDir, 0755); err != nil {
 				return fmt.Errorf("mkdir %s failed: %v", nsDir, err)
 			}
@@ -141,11 +172,10 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 				sb.WriteString("\n\n")
 			}
 
-			// Determine file extension
-			if !strings.HasSuffix(filename, ".cpp") && !strings.HasSuffix(filename, ".c") {
-				filename = filename + ".cpp"
+			fpath := filepath.Join(outdir, w.sourcePath(namespace, filename))
+			if !utils.ShouldWriteFile(w.FileFilter, outDir, fpath) {
+				continue
 			}
-			fpath := filepath.Join(nsDir, filename)
 
 			if err := os.WriteFile(fpath, []byte(sb.String()), 0644); err != nil {
 				return fmt.Errorf("write file %s failed: %v", fpath, err)
@@ -153,14 +183,37 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 		}
 	}
 
-	// Generate CMakeLists.txt if needed
-	if err := w.generateCMakeLists(mod, outdir); err != nil {
-		log.Error("generate CMakeLists.txt failed: %v", err)
+	// Generate the build files
+	if w.BuildSystem != BuildSystemMeson {
+		if err := w.generateCMakeLists(mod, outdir); err != nil {
+			log.Error("generate CMakeLists.txt failed: %v", err)
+		}
+	}
+	if w.BuildSystem != BuildSystemCMake {
+		if err := w.generateMesonBuild(mod, outdir); err != nil {
+			log.Error("generate meson.build failed: %v", err)
+		}
 	}
 
 	return nil
 }
 
+// sourcePath returns the path of the implementation file of namespace relative to the module dir
+func (w *Writer) sourcePath(namespace, filename string) string {
+	dir := "src"
+	if w.SplitByPackage {
+		dir = ""
+	}
+	if namespace != "" {
+		dir = filepath.Join(dir, strings.ReplaceAll(namespace, "::", string(filepath.Separator)))
+	}
+	// Determine file extension
+	if !strings.HasSuffix(filename, ".cpp") && !strings.HasSuffix(filename, ".c") {
+		filename = filename + ".cpp"
+	}
+	return filepath.Join(dir, filename)
+}
+
 func (w *Writer) writeHeaderFile(path, namespace, typeName string, header *headerInfo) error {
 	var sb strings.Builder
 
@@ -473,8 +526,14 @@ func (w *Writer) generateCMakeLists(mod *uniast.Module, outdir string) error {
 	sb.WriteString(")\n\n")
 	sb.WriteString("set(CMAKE_CXX_STANDARD 17)\n")
 	sb.WriteString("set(CMAKE_CXX_STANDARD_REQUIRED ON)\n\n")
-	sb.WriteString("include_directories(include)\n\n")
-	sb.WriteString("file(GLOB_RECURSE SOURCES \"src/*.cpp\" \"src/*.c\")\n\n")
+	if w.SplitByPackage {
+		sb.WriteString("include_directories(${CMAKE_SOURCE_DIR})\n\n")
+		sb.WriteString("file(GLOB_RECURSE SOURCES \"*.cpp\" \"*.c\")\n")
+		sb.WriteString("list(FILTER SOURCES EXCLUDE REGEX \"^${CMAKE_BINARY_DIR}/\")\n\n")
+	} else {
+		sb.WriteString("include_directories(include)\n\n")
+		sb.WriteString("file(GLOB_RECURSE SOURCES \"src/*.cpp\" \"src/*.c\")\n\n")
+	}
 	sb.WriteString("add_executable(")
 	sb.WriteString(mod.Name)
 	sb.WriteString(" ${SOURCES})\n")
@@ -493,3 +552,45 @@ func (w *Writer) generateCMakeLists(mod *uniast.Module, outdir string) error {
 
 	return nil
 }
+
+// generateMesonBuild generates the meson.build of the module,
+// listing its sources explicitly instead of globbing them as recommended by meson
+func (w *Writer) generateMesonBuild(mod *uniast.Module, outdir string) error {
+	var sources []string
+	for namespace, nsFiles := range w.visited {
+		for filename := range nsFiles {
+			sources = append(sources, filepath.ToSlash(w.sourcePath(namespace, filename)))
+		}
+	}
+	sort.Strings(sources)
+
+	// meson target names can't contain path separators
+	name := mesonString(path.Base(mod.Name))
+	includeDir := "include"
+	if w.SplitByPackage {
+		includeDir = "."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("project(" + name + ", 'cpp',\n")
+	sb.WriteString("  default_options : ['cpp_std=c++17'])\n\n")
+	sb.WriteString("inc = include_directories(" + mesonString(includeDir) + ")\n\n")
+	sb.WriteString("sources = files(\n")
+	for _, src := range sources {
+		sb.WriteString("  " + mesonString(src) + ",\n")
+	}
+	sb.WriteString(")\n\n")
+	sb.WriteString("executable(" + name + ", sources, include_directories : inc)\n")
+
+	mesonPath := filepath.Join(outdir, "meson.build")
+	if err := os.WriteFile(mesonPath, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("write meson.build failed: %v", err)
+	}
+	return nil
+}
+
+// mesonString quotes s as a meson string literal
+func mesonString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
@@ -17,6 +17,9 @@
 package writer
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -102,3 +105,77 @@ func TestWriter_IdToImport(t *testing.T) {
 		})
 	}
 }
+
+func TestWriter_WriteModule_BuildSystem(t *testing.T) {
+	newRepo := func() *uniast.Repository {
+		repo := uniast.NewRepository("demo")
+		mod := uniast.NewModule("github.com/example/demo", ".", uniast.Cxx)
+		pkg := uniast.NewPackage("util")
+		for _, f := range []struct{ name, file string }{{"add", "math.cpp"}, {"main", "main"}} {
+			id := uniast.NewIdentity(mod.Name, "util", f.name)
+			pkg.Functions[f.name] = &uniast.Function{
+				Identity: id,
+				FileLine: uniast.FileLine{File: f.file, Line: 1},
+				Content:  "int " + f.name + "() { return 0; }",
+			}
+		}
+		mod.Packages["util"] = pkg
+		repo.Modules[mod.Name] = mod
+		repo.BuildGraph()
+		return &repo
+	}
+
+	dir := t.TempDir()
+	if err := NewWriter(Options{BuildSystem: BuildSystemMeson}).WriteModule(newRepo(), "github.com/example/demo", dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "CMakeLists.txt")); !os.IsNotExist(err) {
+		t.Errorf("expect no CMakeLists.txt for meson, got %v", err)
+	}
+	meson, err := os.ReadFile(filepath.Join(dir, "meson.build"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `project('demo', 'cpp',
+  default_options : ['cpp_std=c++17'])
+
+inc = include_directories('include')
+
+sources = files(
+  'src/util/main.cpp',
+  'src/util/math.cpp',
+)
+
+executable('demo', sources, include_directories : inc)
+`
+	if string(meson) != want {
+		t.Errorf("meson.build =\n%s\nwant\n%s", meson, want)
+	}
+	for _, src := range []string{"src/util/main.cpp", "src/util/math.cpp"} {
+		if _, err := os.Stat(filepath.Join(dir, src)); err != nil {
+			t.Errorf("expect source %s listed in meson.build to be written: %v", src, err)
+		}
+	}
+
+	dir = t.TempDir()
+	if err := NewWriter(Options{BuildSystem: BuildSystemBoth, SplitByPackage: true}).WriteModule(newRepo(), "github.com/example/demo", dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "CMakeLists.txt")); err != nil {
+		t.Errorf("expect CMakeLists.txt for both: %v", err)
+	}
+	meson, err = os.ReadFile(filepath.Join(dir, "meson.build"))
+	if err != nil || !strings.Contains(string(meson), "include_directories('.')") || !strings.Contains(string(meson), "'util/math.cpp'") {
+		t.Errorf("unexpected meson.build of split packages: %s, %v", meson, err)
+	}
+
+	if err := NewWriter(Options{BuildSystem: "bazel"}).WriteModule(newRepo(), "github.com/example/demo", t.TempDir()); err == nil {
+		t.Errorf("expect an error for an unsupported build system")
+	}
+}
+
+func Test_mesonString(t *testing.T) {
+	if got := mesonString(`it's a\b`); got != `'it\'s a\\b'` {
+		t.Errorf("mesonString() = %s", got)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"go/token"
 	"go/types"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -31,20 +32,29 @@ func (p *GoParser) parseFile(ctx *fileContext, f *ast.File) error {
 	ast.Inspect(f, func(node ast.Node) bool {
 		if funcDecl, ok := node.(*ast.FuncDecl); ok {
 			// parse funcs
-			_, ct := p.parseFunc(ctx, funcDecl)
+			f, ct := p.parseFunc(ctx, funcDecl)
 			// fileFuncs[f.Name] = f
+			if f != nil {
+				if tags := goGenerateTags(funcDecl.Doc); tags != nil {
+					f.Tags = tags
+				}
+				f.DocString = docString(funcDecl.Doc)
+			}
 			cont = ct
 		} else if decl, ok := node.(*ast.GenDecl); ok {
-			var doc *ast.CommentGroup
-			if ctx.collectComment && decl.Doc != nil {
-				doc = decl.Doc
-			}
+			// the doc of the declaration, which is kept in node contents only if collectComment
+			doc := decl.Doc
 			var ct = true
 			switch decl.Tok {
 			case token.TYPE:
+				tags := goGenerateTags(decl.Doc)
 				for _, spec := range decl.Specs {
 					typDecl := spec.(*ast.TypeSpec)
-					_, ct = p.parseType(ctx, typDecl, doc)
+					var st *Type
+					st, ct = p.parseType(ctx, typDecl, doc)
+					if st != nil && tags != nil {
+						st.Tags = tags
+					}
 				}
 			case token.VAR:
 				var firstVal *float64
@@ -89,6 +99,76 @@ func (p *GoParser) parseFile(ctx *fileContext, f *ast.File) error {
 	return nil
 }
 
+// goGenerateTags collects the //go:generate directives in the doc comment as Tags
+func goGenerateTags(doc *ast.CommentGroup) map[string]string {
+	if doc == nil {
+		return nil
+	}
+	var cmds []string
+	for _, c := range doc.List {
+		if cmd, ok := strings.CutPrefix(c.Text, "//go:generate "); ok {
+			cmds = append(cmds, strings.TrimSpace(cmd))
+		}
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	return map[string]string{TagGoGenerate: strings.Join(cmds, "\n")}
+}
+
+// docString returns the text of the first non-empty doc comment, without comment markers and directives
+func docString(docs ...*ast.CommentGroup) string {
+	for _, doc := range docs {
+		if text := strings.TrimSpace(doc.Text()); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// directivePrefixes are the prefixes of tool-control comments kept by Options.PreserveDirectives
+var directivePrefixes = []string{"//go:generate", "//nolint", "//go:embed", "// Code generated "}
+
+// collectDirectives collects the directive comments of f which are not part of any node content,
+// the ones preceding the package clause are at line 0
+func collectDirectives(fset *token.FileSet, f *ast.File, collectComment bool) []string {
+	var ret []string
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			if !slices.ContainsFunc(directivePrefixes, func(prefix string) bool { return strings.HasPrefix(c.Text, prefix) }) {
+				continue
+			}
+			if slices.ContainsFunc(f.Decls, func(decl ast.Decl) bool { return inDeclContent(decl, c.Pos(), collectComment) }) {
+				continue
+			}
+			line := 0
+			if c.Pos() > f.Package {
+				line = fset.Position(c.Pos()).Line
+			}
+			ret = append(ret, NewDirective(line, c.Text))
+		}
+	}
+	return ret
+}
+
+// inDeclContent tells if pos is in the content of decl, which includes the doc comment if collectComment
+func inDeclContent(decl ast.Decl, pos token.Pos, collectComment bool) bool {
+	start := decl.Pos()
+	if collectComment {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+	}
+	return pos >= start && pos < decl.End()
+}
+
 func (p *GoParser) newVar(mod string, pkg string, name string, isConst bool) *Var {
 	ret := &Var{
 		Identity:   NewIdentity(mod, pkg, name),
@@ -118,6 +198,7 @@ func (p *GoParser) parseVar(ctx *fileContext, vspec *ast.ValueSpec, isConst bool
 		}
 		v = p.newVar(ctx.module.Name, ctx.pkgPath, name.Name, isConst)
 		v.FileLine = ctx.FileLine(vspec)
+		v.DocString = docString(vspec.Doc, doc)
 
 		// collect func value dependencies, in case of var a = func() {...}
 		if val != nil && !isConst {
@@ -466,6 +547,7 @@ func (p *GoParser) parseASTNode(ctx *fileContext, node ast.Node, collect *collec
 func (p *GoParser) parseFunc(ctx *fileContext, funcDecl *ast.FuncDecl) (*Function, bool) {
 	// method receiver
 	var receiver *Receiver
+	var receiverType string
 	var tparams []Dependency
 	isMethod := funcDecl.Recv != nil && len(funcDecl.Recv.List) > 0
 	if isMethod {
@@ -476,6 +558,7 @@ func (p *GoParser) parseFunc(ctx *fileContext, funcDecl *ast.FuncDecl) (*Functio
 			IsPointer: ti.IsPointer,
 			// Name:      name,
 		}
+		receiverType = string(ctx.GetRawContent(rt))
 		// collect receiver's type params
 		for _, d := range ti.Deps {
 			tparams = append(tparams, Dependency{
@@ -536,6 +619,7 @@ set_func:
 	f.MethodCalls = collects.methodCalls
 	f.IsMethod = isMethod
 	f.Receiver = receiver
+	f.ReceiverType = receiverType
 	f.Params = params
 	f.Results = results
 	f.GlobalVars = collects.globalVars
@@ -557,6 +641,10 @@ func (p *GoParser) parseType(ctx *fileContext, typDecl *ast.TypeSpec, doc *ast.C
 		// typedef, ex: type Str StructA
 		st = p.newType(ctx.module.Name, ctx.pkgPath, typDecl.Name.Name)
 		st.TypeKind = "typedef"
+		if typDecl.Assign.IsValid() {
+			// type alias, ex: type Str = StructA
+			st.TypeKind = TypeKindAlias
+		}
 		p.collectTypes(ctx, typDecl.Type, st, typDecl.Assign.IsValid())
 		ct = false
 		// check if it implements any parser.interfaces
@@ -569,9 +657,16 @@ func (p *GoParser) parseType(ctx *fileContext, typDecl *ast.TypeSpec, doc *ast.C
 
 	if typDecl.TypeParams != nil {
 		ctx.collectFields(typDecl.TypeParams.List, &st.SubStruct)
+		for _, field := range typDecl.TypeParams.List {
+			constraint := string(ctx.GetRawContent(field.Type))
+			for _, name := range field.Names {
+				st.TypeParams = append(st.TypeParams, TypeParam{Name: name.Name, Constraint: constraint})
+			}
+		}
 	}
 
 	st.FileLine = ctx.FileLine(typDecl)
+	st.DocString = docString(typDecl.Doc, doc)
 	st.Content = string(ctx.GetRawContent(typDecl))
 	if ctx.collectComment && doc != nil {
 		st.Content = string(ctx.GetRawContent(doc)) + "\n" + string(ctx.GetRawContent(typDecl))
@@ -0,0 +1,192 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"go/format"
+	"go/types"
+	"regexp"
+	"strings"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// collectGenericInstances collects the concrete instantiations of the generic types of the repo used by a package,
+// e.g. Result[User] becomes the type Result_User with T substituted. They are added by addGenericInstances.
+func (p *GoParser) collectGenericInstances(info *types.Info) {
+	if info == nil {
+		return
+	}
+	for _, inst := range info.Instances {
+		named, ok := inst.Type.(*types.Named)
+		if !ok || named.TypeArgs().Len() == 0 {
+			continue
+		}
+		origin := named.Origin().Obj()
+		if origin.Pkg() == nil {
+			continue
+		}
+		pkgPath := origin.Pkg().Path()
+		modName, _ := p.getModuleFromPkg(pkgPath)
+		if p.repo.Modules[modName] == nil {
+			// not a generic type of the repo
+			continue
+		}
+
+		qualifier := func(pkg *types.Package) string {
+			if pkg.Path() == pkgPath {
+				return ""
+			}
+			return pkg.Name()
+		}
+		var args, argNames []string
+		var deps []Dependency
+		generic := false
+		for i := 0; i < named.TypeArgs().Len(); i++ {
+			arg := named.TypeArgs().At(i)
+			if containsTypeParam(arg) {
+				// instantiated with the type params of another generic node, not concrete
+				generic = true
+				break
+			}
+			args = append(args, types.TypeString(arg, qualifier))
+			argNames = append(argNames, genericArgName(types.TypeString(arg, qualifier)))
+			if id, ok := p.localNamedType(arg); ok {
+				deps = InsertDependency(deps, NewDependency(id, FileLine{}))
+			}
+		}
+		if generic {
+			continue
+		}
+
+		name := origin.Name() + "_" + strings.Join(argNames, "_")
+		id := NewIdentity(modName, pkgPath, name)
+		if p.instances[id.Full()] != nil {
+			continue
+		}
+		content := "type " + name + " " + types.TypeString(named.Underlying(), qualifier)
+		if bs, err := format.Source([]byte(content)); err == nil {
+			content = string(bs)
+		}
+		kind := TypeKind("typedef")
+		switch named.Underlying().(type) {
+		case *types.Struct:
+			kind = "struct"
+		case *types.Interface:
+			kind = "interface"
+		}
+		if p.instances == nil {
+			p.instances = map[string]*Type{}
+		}
+		p.instances[id.Full()] = &Type{
+			Exported:  origin.Exported(),
+			TypeKind:  kind,
+			Identity:  id,
+			Content:   content,
+			SubStruct: deps,
+			Tags:      map[string]string{TagGoGeneric: origin.Name() + "[" + strings.Join(args, ", ") + "]"},
+		}
+	}
+}
+
+// addGenericInstances adds the instantiated types collected by collectGenericInstances
+// to the packages of their generic types, at the position of the generic types
+func (p *GoParser) addGenericInstances() {
+	for _, st := range p.instances {
+		pkg := p.repo.GetPackage(st.ModPath, st.PkgPath)
+		if pkg == nil || pkg.Types[st.Name] != nil {
+			continue
+		}
+		name, _, _ := strings.Cut(st.Tags[TagGoGeneric], "[")
+		origin := pkg.Types[name]
+		if origin == nil {
+			continue
+		}
+		st.FileLine = origin.FileLine
+		pkg.Types[st.Name] = st
+	}
+}
+
+// containsTypeParam tells if t refers to a type parameter
+func containsTypeParam(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Pointer:
+		return containsTypeParam(t.Elem())
+	case *types.Slice:
+		return containsTypeParam(t.Elem())
+	case *types.Array:
+		return containsTypeParam(t.Elem())
+	case *types.Chan:
+		return containsTypeParam(t.Elem())
+	case *types.Map:
+		return containsTypeParam(t.Key()) || containsTypeParam(t.Elem())
+	case *types.Named:
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			if containsTypeParam(t.TypeArgs().At(i)) {
+				return true
+			}
+		}
+	case *types.Signature:
+		for _, tuple := range []*types.Tuple{t.Params(), t.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				if containsTypeParam(tuple.At(i).Type()) {
+					return true
+				}
+			}
+		}
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if containsTypeParam(t.Field(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// localNamedType returns the identity of the named type of the repo t refers to, e.g. User for *User
+func (p *GoParser) localNamedType(t types.Type) (Identity, bool) {
+	for {
+		switch tt := t.(type) {
+		case *types.Pointer:
+			t = tt.Elem()
+			continue
+		case *types.Slice:
+			t = tt.Elem()
+			continue
+		case *types.Named:
+			obj := tt.Origin().Obj()
+			if obj.Pkg() == nil {
+				return Identity{}, false
+			}
+			modName, _ := p.getModuleFromPkg(obj.Pkg().Path())
+			if p.repo.Modules[modName] == nil {
+				return Identity{}, false
+			}
+			return NewIdentity(modName, obj.Pkg().Path(), obj.Name()), true
+		}
+		return Identity{}, false
+	}
+}
+
+var nonIdentChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// genericArgName returns the type argument as a part of an identifier, e.g. *pkg.User => Ptr_pkg_User
+func genericArgName(arg string) string {
+	arg = strings.NewReplacer("*", "Ptr_", "[]", "Slice_").Replace(arg)
+	return strings.Trim(nonIdentChars.ReplaceAllString(arg, "_"), "_")
+}
@@ -23,11 +23,30 @@ import (
 type Options struct {
 	ReferCodeDepth int
 	Excludes       []string
+	Includes       []string // if not empty, only paths matching one of them are parsed
 	CollectComment bool
 	NeedTest       bool
+	// TestFileSuffix are the suffixes of the test files, default to DefaultTestFileSuffix.
+	// With NeedTest, only the _test.go files matching one of them are parsed, e.g. _integration_test.go;
+	// without it, the other files matching one of them are skipped too.
+	TestFileSuffix []string
 	LoadByPackages bool
+	// PackageConcurrency is the max number of packages loaded in parallel when LoadByPackages, 0 means 1.
+	// Packages are still parsed one by one.
+	PackageConcurrency int
+	// ModulePath overrides the path of the module at the root of the repo,
+	// it is required to parse Go code without go.mod
+	ModulePath string
+	// ResolveGenerics adds a type for each concrete instantiation of a generic type of the repo,
+	// e.g. Result_User for Result[User], with the type params substituted
+	ResolveGenerics bool
+	// PreserveDirectives collects the tool-control comments out of any node into File.Directives
+	PreserveDirectives bool
 }
 
+// DefaultTestFileSuffix is the default Options.TestFileSuffix
+var DefaultTestFileSuffix = []string{"_test.go"}
+
 // type Option func(options *Options)
 
 // func WithReferCodeDepth(depth int) Option {
@@ -36,7 +55,7 @@ type Options struct {
 // 	}
 // }
 
-func compileExcludes(excludes []string) (ret []*regexp.Regexp) {
+func compilePatterns(excludes []string) (ret []*regexp.Regexp) {
 	for _, ex := range excludes {
 		r, e := regexp.Compile(ex)
 		if e != nil {
@@ -26,6 +26,7 @@ import (
 	"io/fs"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -47,8 +48,13 @@ type GoParser struct {
 	types       map[types.Type]Identity
 	files       map[string][]byte
 	exclues     []*regexp.Regexp
-	cgoPkgs     map[string]bool // CGO packages
-	workDirs    map[string]bool // directories that are in go.work scope
+	includes    []*regexp.Regexp
+	cgoPkgs     map[string]bool             // CGO packages
+	workDirs    map[string]bool             // directories that are in go.work scope
+	preloaded   map[PkgPath]*loadedPackages // packages loaded ahead of parsing, see parsePackages
+	overlay     map[string][]byte           // files replaced when loading packages, e.g. the go.mod of Options.ModulePath
+	renamed     string                      // module renamed to Options.ModulePath after parsing
+	instances   map[string]*Type            // instantiated generic types by id, see Options.ResolveGenerics
 }
 
 type moduleInfo struct {
@@ -83,15 +89,22 @@ func newGoParser(name string, homePageDir string, opts Options) *GoParser {
 		interfaces:  map[*types.Interface]Identity{},
 		types:       map[types.Type]Identity{},
 		files:       map[string][]byte{},
+		preloaded:   map[PkgPath]*loadedPackages{},
 	}
 
 	if opts.Excludes != nil {
-		p.exclues = compileExcludes(opts.Excludes)
+		p.exclues = compilePatterns(opts.Excludes)
+	}
+	if opts.Includes != nil {
+		p.includes = compilePatterns(opts.Includes)
 	}
 
 	if err := p.collectGoMods(p.homePageDir); err != nil {
 		panic(err)
 	}
+	if opts.ModulePath != "" {
+		p.setModulePath(opts.ModulePath)
+	}
 
 	p.opts = opts
 	return p
@@ -152,6 +165,10 @@ func (p *GoParser) collectGoMods(startDir string) error {
 		if err != nil {
 			return err
 		}
+		// NOTICE: read requires after `go mod tidy` in getDeps
+		if requires, err := getRequires(path); err == nil {
+			p.repo.Modules[name].ExternalDependencies = requires
+		}
 		if p.cgoPkgs == nil {
 			p.cgoPkgs = make(map[string]bool)
 		}
@@ -173,6 +190,25 @@ func (p *GoParser) collectGoMods(startDir string) error {
 	return nil
 }
 
+// setModulePath sets the path of the module at the root of the repo.
+// Without go.mod, the module is parsed with an overlay go.mod declaring modPath;
+// otherwise the module declared by go.mod is renamed to modPath after parsing.
+func (p *GoParser) setModulePath(modPath string) {
+	for _, m := range p.modules {
+		if m.dir == "." {
+			if m.name != modPath {
+				p.renamed = m.name
+			}
+			return
+		}
+	}
+	p.repo.Modules[modPath] = newModule(modPath, ".")
+	p.modules = append(p.modules, newModuleInfo(modPath, ".", modPath))
+	p.overlay = map[string][]byte{
+		filepath.Join(p.homePageDir, "go.mod"): []byte("module " + modPath + "\n\ngo 1.21\n"),
+	}
+}
+
 type replace struct {
 	Path    string `json:"Path"`
 	Version string `json:"Version"`
@@ -295,28 +331,36 @@ func (p *GoParser) ParseRepo() (Repository, error) {
 	}
 	p.associateStructWithMethods()
 	p.associateImplements()
-	fmt.Fprintf(os.Stderr, "total call packages.Load %d times\n", loadCount)
+	p.addGenericInstances()
+	fmt.Fprintf(os.Stderr, "total call packages.Load %d times\n", loadCount.Load())
+	if p.renamed != "" {
+		if err := p.repo.RenameModule(p.renamed, p.opts.ModulePath); err != nil {
+			return p.getRepo(), err
+		}
+	}
 	return p.getRepo(), nil
 }
 
 func (p *GoParser) ParseModule(mod *Module, dir string) (err error) {
-	// run go mod tidy before parse
-	cmd := exec.Command("go", "mod", "tidy")
-	cmd.Dir = dir
-	cmd.Env = append(os.Environ(), "GOTOOLCHAIN=local")
-	buf := bytes.NewBuffer(nil)
-	cmd.Stderr = buf
-	cmd.Stdout = buf
-	go func() {
-		sc := bufio.NewScanner(buf)
-		// scan and print
-		for sc.Scan() {
-			fmt.Fprintln(os.Stderr, sc.Text())
-		}
-	}()
-	fmt.Fprintf(os.Stderr, "running go mod tidy in %s ...\n", dir)
-	if err := cmd.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "run go mod tidy failed in %s: %v\n", dir, buf.String())
+	// run go mod tidy before parse, unless go.mod is an overlay of Options.ModulePath
+	if p.overlay == nil {
+		cmd := exec.Command("go", "mod", "tidy")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOTOOLCHAIN=local")
+		buf := bytes.NewBuffer(nil)
+		cmd.Stderr = buf
+		cmd.Stdout = buf
+		go func() {
+			sc := bufio.NewScanner(buf)
+			// scan and print
+			for sc.Scan() {
+				fmt.Fprintln(os.Stderr, sc.Text())
+			}
+		}()
+		fmt.Fprintf(os.Stderr, "running go mod tidy in %s ...\n", dir)
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "run go mod tidy failed in %s: %v\n", dir, buf.String())
+		}
 	}
 
 	filepath.Walk(dir, func(path string, info fs.FileInfo, e error) error {
@@ -332,22 +376,22 @@ func (p *GoParser) ParseModule(mod *Module, dir string) (err error) {
 	})
 
 	if p.opts.LoadByPackages {
-		var errs []error
+		var pkgPaths []PkgPath
 		filepath.Walk(dir, func(path string, info fs.FileInfo, e error) error {
 			if e != nil || !info.IsDir() || shouldIgnoreDir(path) {
 				return nil
 			}
-			for _, exclude := range p.exclues {
-				if exclude.MatchString(path) {
-					return nil
-				}
+			if p.excludedPackage(path) {
+				// the sub packages are excluded too
+				return filepath.SkipDir
 			}
-			if err := p.parsePackage(p.pkgPathFromABS(path)); err != nil {
-				errs = append(errs, err)
+			if p.shouldSkipPath(path) {
+				return nil
 			}
+			pkgPaths = append(pkgPaths, p.pkgPathFromABS(path))
 			return nil
 		})
-		if len(errs) > 0 {
+		if errs := p.parsePackages(pkgPaths); len(errs) > 0 {
 			return fmt.Errorf("parse package failed: %v", errs)
 		}
 		return nil
@@ -356,6 +400,82 @@ func (p *GoParser) ParseModule(mod *Module, dir string) (err error) {
 	}
 }
 
+// shouldSkipPath tells if the path is not matched by any include pattern (when given), or matched by an exclude pattern
+func (p *GoParser) shouldSkipPath(path string) bool {
+	if len(p.includes) > 0 {
+		included := false
+		for _, include := range p.includes {
+			if include.MatchString(path) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return true
+		}
+	}
+	for _, exclude := range p.exclues {
+		if exclude.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipTestFile tells if the file at path is not parsed as a test file, see Options.TestFileSuffix
+func (p *GoParser) skipTestFile(path string) bool {
+	suffixes := p.opts.TestFileSuffix
+	if len(suffixes) == 0 {
+		suffixes = DefaultTestFileSuffix
+	}
+	isTest := false
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(path, suffix) {
+			isTest = true
+			break
+		}
+	}
+	if !p.opts.NeedTest {
+		return isTest
+	}
+	return !isTest && strings.HasSuffix(path, "_test.go")
+}
+
+// excludedPackage tells if the package at dir is matched by an exclude pattern in package style,
+// i.e. by path.Match against its import path or its dir relative to the repo, see matchPackagePattern
+func (p *GoParser) excludedPackage(dir string) bool {
+	var pkgPath PkgPath
+	if mod, _, rel := p.getModuleFromPath(dir); mod != "" {
+		pkgPath = mod
+		if rel != "" && rel != "." {
+			pkgPath = mod + "/" + filepath.ToSlash(rel)
+		}
+	}
+	rel, err := filepath.Rel(p.homePageDir, dir)
+	if err != nil || rel == "." {
+		rel = ""
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range p.opts.Excludes {
+		if (pkgPath != "" && matchPackagePattern(pattern, pkgPath)) || (rel != "" && matchPackagePattern(pattern, rel)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPackagePattern tells if pkg (an import path or a relative dir) is matched by pattern with path.Match,
+// the leading "./" and the trailing "/..." of the pattern are ignored, e.g. ./internal matches internal
+func matchPackagePattern(pattern string, pkg string) bool {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(pattern), "./"), "/...")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" || pattern == "." {
+		return false
+	}
+	ok, err := path.Match(pattern, pkg)
+	return err == nil && ok
+}
+
 // getRepo return currently parsed golang AST
 // Notice: To get completely parsed repo, you'd better call goParser.ParseRepo() before this
 func (p *GoParser) getRepo() Repository {
@@ -544,7 +664,7 @@ func (p *GoParser) searchOnFile(file *ast.File, fset *token.FileSet, fcontent []
 						st = p.newType(mod, pkg, spec.Name.Name)
 						st.Content = string(GetRawContent(fset, fcontent, spec, p.opts.CollectComment))
 						st.FileLine = p.exportFileLine(fset, spec)
-						st.TypeKind = getTypeKind(spec.Type)
+						st.TypeKind = getTypeKind(spec)
 						ids = append(ids, newIdentity(mod, pkg, name))
 					}
 					if inter, ok := spec.Type.(*ast.InterfaceType); st != nil && ok {
@@ -24,6 +24,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	. "github.com/cloudwego/abcoder/lang/uniast"
 	"golang.org/x/tools/go/packages"
@@ -163,15 +164,83 @@ func (p *GoParser) parsePackage(pkgPath PkgPath) (err error) {
 	return p.loadPackages(lib, filepath.Join(p.homePageDir, lib.Dir), pkgPath)
 }
 
-var loadCount = 0
+// parsePackages parses the packages one by one.
+// If PackageConcurrency > 1, the packages are loaded in parallel ahead of parsing,
+// and at most PackageConcurrency loaded packages are kept in memory.
+func (p *GoParser) parsePackages(pkgPaths []PkgPath) (errs []error) {
+	n := p.opts.PackageConcurrency
+	if n <= 1 {
+		for _, pkgPath := range pkgPaths {
+			if err := p.parsePackage(pkgPath); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return
+	}
 
-func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err error) {
-	if mm := p.repo.Modules[mod.Name]; mm != nil && (*mm).Packages[pkgPath] != nil {
-		return nil
+	// resolve the modules before loading, since parsing may change p.repo
+	type loadTarget struct {
+		mod *Module
+		dir string
+	}
+	targets := make([]*loadTarget, len(pkgPaths))
+	for i, pkgPath := range pkgPaths {
+		mod, _ := p.getModuleFromPkg(pkgPath)
+		if lib := p.repo.Modules[mod]; lib != nil && !p.visited[pkgPath] {
+			targets[i] = &loadTarget{mod: lib, dir: filepath.Join(p.homePageDir, lib.Dir)}
+		}
+	}
+
+	results := make([]chan *loadedPackages, len(pkgPaths))
+	for i := range results {
+		results[i] = make(chan *loadedPackages, 1)
+	}
+	sem := make(chan struct{}, n)
+	go func() {
+		for i, t := range targets {
+			if t == nil {
+				results[i] <- nil
+				continue
+			}
+			sem <- struct{}{}
+			go func(i int, t *loadTarget) {
+				results[i] <- p.load(t.mod, t.dir, pkgPaths[i])
+			}(i, t)
+		}
+	}()
+
+	for i, pkgPath := range pkgPaths {
+		loaded := <-results[i]
+		if loaded != nil {
+			p.preloaded[pkgPath] = loaded
+		}
+		if err := p.parsePackage(pkgPath); err != nil {
+			errs = append(errs, err)
+		}
+		if loaded != nil {
+			delete(p.preloaded, pkgPath)
+			<-sem
+		}
 	}
+	return
+}
+
+var loadCount atomic.Int64
+
+// loadedPackages is the result of loading packages
+type loadedPackages struct {
+	pkgs   []*packages.Package
+	fset   *token.FileSet
+	hasCGO bool
+	err    error
+}
+
+// load loads the packages by packages.Load.
+// It doesn't touch the parsed results, so it can be called concurrently.
+func (p *GoParser) load(mod *Module, dir string, pkgPath PkgPath) *loadedPackages {
 	fmt.Fprintf(os.Stderr, "[loadPackages] mod: %s, dir: %s, pkgPath: %s\n", mod.Name, dir, pkgPath)
 	fset := token.NewFileSet()
-	loadCount++
+	loadCount.Add(1)
 
 	baseOpts := packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports
 	if p.opts.ReferCodeDepth != 0 {
@@ -182,9 +251,10 @@ func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err e
 	}
 
 	cfg := &packages.Config{
-		Mode: baseOpts,
-		Fset: fset,
-		Dir:  dir,
+		Mode:    baseOpts,
+		Fset:    fset,
+		Dir:     dir,
+		Overlay: p.overlay,
 	}
 
 	if p.opts.NeedTest {
@@ -193,7 +263,7 @@ func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err e
 
 	pkgs, err := packages.Load(cfg, pkgPath)
 	if err != nil {
-		return fmt.Errorf("load path '%s' failed: %v", dir, err)
+		return &loadedPackages{err: fmt.Errorf("load path '%s' failed: %v", dir, err)}
 	}
 
 	hasCGO := false
@@ -206,9 +276,24 @@ func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err e
 		cfg.Mode = baseOpts
 		pkgs, err = packages.Load(cfg, pkgPath)
 		if err != nil {
-			return fmt.Errorf("load path '%s' with CGO failed: %v", dir, err)
+			return &loadedPackages{err: fmt.Errorf("load path '%s' with CGO failed: %v", dir, err)}
 		}
 	}
+	return &loadedPackages{pkgs: pkgs, fset: fset, hasCGO: hasCGO}
+}
+
+func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err error) {
+	if mm := p.repo.Modules[mod.Name]; mm != nil && (*mm).Packages[pkgPath] != nil {
+		return nil
+	}
+	loaded := p.preloaded[pkgPath]
+	if loaded == nil {
+		loaded = p.load(mod, dir, pkgPath)
+	}
+	if loaded.err != nil {
+		return loaded.err
+	}
+	pkgs, fset, hasCGO := loaded.pkgs, loaded.fset, loaded.hasCGO
 
 	for _, pkg := range pkgs {
 		if mm := p.repo.Modules[mod.Name]; mm != nil && (*mm).Packages[pkg.ID] != nil {
@@ -230,15 +315,8 @@ func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err e
 			} else {
 				filePath = fset.Position(file.Pos()).Filename
 			}
-			var skip bool
-			for _, exclude := range p.exclues {
-				if exclude.MatchString(filePath) {
-					fmt.Fprintf(os.Stderr, "skip file %s\n", filePath)
-					skip = true
-					break
-				}
-			}
-			if skip {
+			if p.shouldSkipPath(filePath) || p.skipTestFile(filePath) {
+				fmt.Fprintf(os.Stderr, "skip file %s\n", filePath)
 				continue
 			}
 			bs := p.getFileBytes(filePath)
@@ -267,11 +345,17 @@ func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err e
 			if f.Package == "" {
 				f.Package = pkg.ID
 				f.Imports = imports.Origins
+				if p.opts.PreserveDirectives {
+					f.Directives = collectDirectives(fset, file, p.opts.CollectComment)
+				}
 			}
 			if err := p.parseFile(ctx, file); err != nil {
 				return err
 			}
 		}
+		if p.opts.ResolveGenerics {
+			p.collectGenericInstances(pkg.TypesInfo)
+		}
 		if obj := mod.Packages[pkg.ID]; obj != nil {
 			// obj.Dependencies = make([]PkgPath, 0, len(pkg.Imports))
 			// for _, imp := range pkg.Imports {
@@ -281,7 +365,8 @@ func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err e
 			// 	obj.Dependencies = append(obj.Dependencies, imp.ID)
 			// }
 			obj.PkgPath = pkg.ID
-			if strings.HasSuffix(obj.PkgPath, ".test]") {
+			// test variants (pkg [pkg.test]) and external test packages (pkg_test)
+			if strings.HasSuffix(obj.PkgPath, ".test]") || strings.HasSuffix(obj.PkgPath, "_test") {
 				obj.IsTest = true
 			}
 			if strings.HasSuffix(obj.PkgPath, ".test") {
@@ -20,6 +20,11 @@ import (
 	"go/parser"
 	"go/token"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/abcoder/lang/testutils"
@@ -225,3 +230,445 @@ func Test_matchMod(t *testing.T) {
 		})
 	}
 }
+
+func Test_goParser_TypeParams(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/go.mod", []byte("module example.com/generic\n\ngo 1.18\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := "package generic\n\ntype Stack[T any] struct {\n\titems []T\n}\n\ntype Pair[K comparable, V ~int | ~string] struct {\n\tKey K\n\tVal V\n}\n"
+	if err := os.WriteFile(dir+"/stack.go", []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := newGoParser("example.com/generic", dir, Options{})
+	r, err := p.ParseRepo()
+	if err != nil {
+		t.Fatalf("failed to parse repo %s", err)
+	}
+	pkg := r.GetPackage("example.com/generic", "example.com/generic")
+	if pkg == nil {
+		t.Fatal("package not found")
+	}
+	stack := pkg.Types["Stack"]
+	if stack == nil || len(stack.TypeParams) != 1 || stack.TypeParams[0] != (TypeParam{Name: "T", Constraint: "any"}) {
+		t.Errorf("unexpected Stack type params: %+v", stack)
+	}
+	pair := pkg.Types["Pair"]
+	want := []TypeParam{{Name: "K", Constraint: "comparable"}, {Name: "V", Constraint: "~int | ~string"}}
+	if pair == nil || len(pair.TypeParams) != 2 || pair.TypeParams[0] != want[0] || pair.TypeParams[1] != want[1] {
+		t.Errorf("unexpected Pair type params: %+v", pair)
+	}
+}
+
+func Test_goParser_PackageConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/go.mod", []byte("module example.com/multi\n\ngo 1.18\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pkgs := []string{"a", "b", "c", "d"}
+	for _, name := range pkgs {
+		if err := os.MkdirAll(dir+"/"+name, 0755); err != nil {
+			t.Fatal(err)
+		}
+		src := "package " + name + "\n\nfunc F() int { return 1 }\n"
+		if err := os.WriteFile(dir+"/"+name+"/"+name+".go", []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, n := range []int{0, 2} {
+		p := newGoParser("example.com/multi", dir, Options{LoadByPackages: true, PackageConcurrency: n})
+		r, err := p.ParseRepo()
+		if err != nil {
+			t.Fatalf("PackageConcurrency=%d: failed to parse repo %s", n, err)
+		}
+		for _, name := range pkgs {
+			pkg := r.GetPackage("example.com/multi", "example.com/multi/"+name)
+			if pkg == nil || pkg.Functions["F"] == nil {
+				t.Errorf("PackageConcurrency=%d: function F of package %s not found", n, name)
+			}
+		}
+		if len(p.preloaded) != 0 {
+			t.Errorf("PackageConcurrency=%d: preloaded packages should be released, got %d", n, len(p.preloaded))
+		}
+	}
+}
+
+func Test_goParser_GoGenerateTags(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/go.mod", []byte("module example.com/gen\n\ngo 1.18\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := "package gen\n\n//go:generate stringer -type=Status\ntype Status int\n\n// Plain is a plain type\ntype Plain int\n"
+	if err := os.WriteFile(dir+"/gen.go", []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := newGoParser("example.com/gen", dir, Options{})
+	r, err := p.ParseRepo()
+	if err != nil {
+		t.Fatalf("failed to parse repo %s", err)
+	}
+	pkg := r.GetPackage("example.com/gen", "example.com/gen")
+	if pkg == nil {
+		t.Fatal("package not found")
+	}
+	if st := pkg.Types["Status"]; st == nil || st.Tags[TagGoGenerate] != "stringer -type=Status" {
+		t.Errorf("unexpected Status tags: %+v", st)
+	}
+	if pl := pkg.Types["Plain"]; pl == nil || pl.Tags != nil {
+		t.Errorf("Plain should have no tags: %+v", pl)
+	}
+}
+
+func Test_goParser_DocString(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/go.mod", []byte("module example.com/doc\n\ngo 1.18\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := "package doc\n\n// Status is a status.\n//go:generate stringer -type=Status\ntype Status int\n\n// Max is the max status.\nconst Max Status = 3\n\n// Get returns the status.\n//\n// It never fails.\nfunc Get() Status { return Max }\n\nfunc Plain() {}\n"
+	if err := os.WriteFile(dir+"/doc.go", []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := newGoParser("example.com/doc", dir, Options{})
+	r, err := p.ParseRepo()
+	if err != nil {
+		t.Fatalf("failed to parse repo %s", err)
+	}
+	pkg := r.GetPackage("example.com/doc", "example.com/doc")
+	if pkg == nil {
+		t.Fatal("package not found")
+	}
+	if st := pkg.Types["Status"]; st == nil || st.DocString != "Status is a status." {
+		t.Errorf("unexpected Status doc: %+v", st)
+	}
+	if v := pkg.Vars["Max"]; v == nil || v.DocString != "Max is the max status." {
+		t.Errorf("unexpected Max doc: %+v", v)
+	}
+	if f := pkg.Functions["Get"]; f == nil || f.DocString != "Get returns the status.\n\nIt never fails." {
+		t.Errorf("unexpected Get doc: %+v", f)
+	}
+	if f := pkg.Functions["Plain"]; f == nil || f.DocString != "" {
+		t.Errorf("Plain should have no doc: %+v", f)
+	}
+}
+
+func Test_goParser_PreserveDirectives(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/go.mod", []byte("module example.com/gen\n\ngo 1.18\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := "// Code generated by mockgen. DO NOT EDIT.\n\npackage gen\n\n//go:generate mockgen -source=gen.go\n\n// Status is a status\n//nolint:revive\ntype Status int\n\nfunc F() {\n\t//nolint:errcheck\n\tF()\n}\n"
+	if err := os.WriteFile(dir+"/gen.go", []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, collectComment := range []bool{false, true} {
+		p := newGoParser("example.com/gen", dir, Options{PreserveDirectives: true, CollectComment: collectComment})
+		r, err := p.ParseRepo()
+		if err != nil {
+			t.Fatalf("failed to parse repo %s", err)
+		}
+		f := r.Modules["example.com/gen"].Files["gen.go"]
+		if f == nil {
+			t.Fatal("file not found")
+		}
+		want := []string{"0:// Code generated by mockgen. DO NOT EDIT.", "5://go:generate mockgen -source=gen.go"}
+		if !collectComment {
+			// the doc comment is not in the node content
+			want = append(want, "8://nolint:revive")
+		}
+		if !reflect.DeepEqual(f.Directives, want) {
+			t.Errorf("CollectComment=%v: Directives = %q, want %q", collectComment, f.Directives, want)
+		}
+	}
+
+	p := newGoParser("example.com/gen", dir, Options{})
+	r, err := p.ParseRepo()
+	if err != nil {
+		t.Fatalf("failed to parse repo %s", err)
+	}
+	if f := r.Modules["example.com/gen"].Files["gen.go"]; f == nil || f.Directives != nil {
+		t.Errorf("Directives should not be collected by default: %+v", f)
+	}
+}
+
+func TestGoParser_LoadByPackagesExcludes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                "module example.com/app\n\ngo 1.21\n",
+		"main.go":               "package main\n\nfunc main() {}\n",
+		"internal/auth/auth.go": "package auth\n\nfunc Login() {}\n",
+		"internal/db/db.go":     "package db\n\nfunc Open() {}\n",
+		"pkg/util/util.go":      "package util\n\nfunc Util() {}\n",
+		"pkg/util/sub/sub.go":   "package sub\n\nfunc Sub() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		excludes []string
+		want     []string
+	}{
+		{[]string{"./internal"}, []string{"example.com/app", "example.com/app/pkg/util", "example.com/app/pkg/util/sub"}},
+		{[]string{"./pkg/...", "internal/db"}, []string{"example.com/app", "example.com/app/internal/auth"}},
+		{[]string{"example.com/app/internal/*"}, []string{"example.com/app", "example.com/app/pkg/util", "example.com/app/pkg/util/sub"}},
+	} {
+		p := newGoParser("app", dir, Options{LoadByPackages: true, Excludes: tt.excludes})
+		repo, err := p.ParseRepo()
+		if err != nil {
+			t.Fatalf("ParseRepo() with excludes %v error = %v", tt.excludes, err)
+		}
+		var got []string
+		for pkg := range repo.Modules["example.com/app"].Packages {
+			got = append(got, pkg)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("packages with excludes %v = %v, want %v", tt.excludes, got, tt.want)
+		}
+	}
+}
+
+func Test_matchPackagePattern(t *testing.T) {
+	tests := []struct {
+		pattern, pkg string
+		want         bool
+	}{
+		{"./internal", "internal", true},
+		{"./internal/", "internal", true},
+		{"./pkg/...", "pkg", true},
+		{"internal/*", "internal/db", true},
+		{"example.com/app/internal", "example.com/app/internal", true},
+		{"./internal", "internal/db", false}, // sub packages are skipped by the walk
+		{"./", "internal", false},
+		{"[", "internal", false},
+	}
+	for _, tt := range tests {
+		if got := matchPackagePattern(tt.pattern, tt.pkg); got != tt.want {
+			t.Errorf("matchPackagePattern(%q, %q) = %v, want %v", tt.pattern, tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestGoParser_TestFileSuffix(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                  "module example.com/app\n\ngo 1.21\n",
+		"app.go":                  "package app\n\nfunc App() {}\n",
+		"app_test.go":             "package app\n\nfunc unitHelper() {}\n",
+		"app_integration_test.go": "package app\n\nfunc integrationHelper() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		opts Options
+		want []string
+	}{
+		{Options{}, []string{"App"}},
+		{Options{NeedTest: true}, []string{"App", "integrationHelper", "unitHelper"}},
+		{Options{NeedTest: true, TestFileSuffix: []string{"_integration_test.go"}}, []string{"App", "integrationHelper"}},
+	} {
+		p := newGoParser("app", dir, tt.opts)
+		repo, err := p.ParseRepo()
+		if err != nil {
+			t.Fatalf("ParseRepo() with %+v error = %v", tt.opts, err)
+		}
+		var got []string
+		for _, pkg := range repo.Modules["example.com/app"].Packages {
+			for name := range pkg.Functions {
+				got = append(got, name)
+			}
+		}
+		sort.Strings(got)
+		got = slices.Compact(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("functions with %+v = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestGoParser_ReceiverType(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"app.go": "package app\n\ntype User struct{}\n\ntype List[T any] []T\n\n" +
+			"func (u *User) Save() error { return nil }\n\nfunc (u User) Name() string { return \"\" }\n\n" +
+			"func (l List[T]) Len() int { return len(l) }\n\nfunc New() *User { return &User{} }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := newGoParser("app", dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatalf("ParseRepo() error = %v", err)
+	}
+	fns := repo.Modules["example.com/app"].Packages["example.com/app"].Functions
+	for name, want := range map[string]string{"User.Save": "*User", "User.Name": "User", "List.Len": "List[T]", "New": ""} {
+		fn := fns[name]
+		if fn == nil {
+			t.Errorf("function %s not found", name)
+			continue
+		}
+		if fn.ReceiverType != want {
+			t.Errorf("%s.ReceiverType = %q, want %q", name, fn.ReceiverType, want)
+		}
+	}
+}
+
+func TestGoParser_NodeKind(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"app.go": "package app\n\ntype User struct{}\n\ntype Store interface{ Get() User }\n\n" +
+			"type ID = string\n\ntype Name string\n\nconst Max = 10\n\nvar Default = User{}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := newGoParser("app", dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatalf("ParseRepo() error = %v", err)
+	}
+	pkg := repo.Modules["example.com/app"].Packages["example.com/app"]
+	for name, want := range map[string]NodeType{"User": TypeStruct, "Store": TypeInterface, "ID": TypeAlias, "Name": TYPE} {
+		if typ := pkg.Types[name]; typ == nil || typ.NodeKind() != want {
+			t.Errorf("type %s kind = %v, want %s", name, typ, want)
+		}
+	}
+	for name, want := range map[string]NodeType{"Max": VarConst, "Default": VAR} {
+		if v := pkg.Vars[name]; v == nil || v.NodeKind() != want {
+			t.Errorf("var %s kind = %v, want %s", name, v, want)
+		}
+	}
+}
+
+func TestGoParser_ModulePath(t *testing.T) {
+	files := map[string]string{
+		"lib.go":       "package mylib\n\nimport \"github.com/example/mylib/util\"\n\nfunc Hello() string { return util.Name() }\n",
+		"util/util.go": "package util\n\nfunc Name() string { return \"mylib\" }\n",
+	}
+	write := func(dir string, files map[string]string) {
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	t.Run("without go.mod", func(t *testing.T) {
+		dir := t.TempDir()
+		write(dir, files)
+		repo, err := newGoParser("mylib", dir, Options{ModulePath: "github.com/example/mylib"}).ParseRepo()
+		if err != nil {
+			t.Fatalf("ParseRepo() error = %v", err)
+		}
+		mod := repo.Modules["github.com/example/mylib"]
+		if mod == nil {
+			t.Fatalf("module not found, got %v", repo.Modules)
+		}
+		fn := mod.Packages["github.com/example/mylib"].Functions["Hello"]
+		if fn == nil {
+			t.Fatal("function Hello not found")
+		}
+		if len(fn.FunctionCalls) != 1 || fn.FunctionCalls[0].PkgPath != "github.com/example/mylib/util" {
+			t.Errorf("Hello.FunctionCalls = %v, want util.Name", fn.FunctionCalls)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); !os.IsNotExist(err) {
+			t.Errorf("go.mod written to the repo")
+		}
+	})
+
+	t.Run("override go.mod", func(t *testing.T) {
+		dir := t.TempDir()
+		write(dir, map[string]string{
+			"go.mod":       "module example.com/old\n\ngo 1.21\n",
+			"lib.go":       "package mylib\n\nimport \"example.com/old/util\"\n\nfunc Hello() string { return util.Name() }\n",
+			"util/util.go": files["util/util.go"],
+		})
+		repo, err := newGoParser("mylib", dir, Options{ModulePath: "github.com/example/mylib"}).ParseRepo()
+		if err != nil {
+			t.Fatalf("ParseRepo() error = %v", err)
+		}
+		if repo.Modules["example.com/old"] != nil {
+			t.Errorf("module example.com/old not renamed")
+		}
+		mod := repo.Modules["github.com/example/mylib"]
+		if mod == nil || mod.Packages["github.com/example/mylib/util"] == nil {
+			t.Fatalf("package github.com/example/mylib/util not found")
+		}
+		fn := mod.Packages["github.com/example/mylib"].Functions["Hello"]
+		if fn == nil || len(fn.FunctionCalls) != 1 || fn.FunctionCalls[0].ModPath != "github.com/example/mylib" {
+			t.Errorf("Hello not renamed: %v", fn)
+		}
+	})
+}
+
+func TestGoParser_ResolveGenerics(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"app.go": "package app\n\ntype Result[T any] struct {\n\tValue T\n\tErr   error\n}\n\n" +
+			"type User struct{ Name string }\n\ntype Order struct{ ID int }\n\n" +
+			"func Wrap[T any](v T) Result[T] { return Result[T]{Value: v} }\n\n" +
+			"func GetUser() Result[User] { return Result[User]{} }\n\n" +
+			"func GetOrders() Result[[]*Order] { return Wrap([]*Order{}) }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo, err := newGoParser("app", dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatalf("ParseRepo() error = %v", err)
+	}
+	if types := repo.Modules["example.com/app"].Packages["example.com/app"].Types; types["Result_User"] != nil {
+		t.Errorf("instantiations should only be added with ResolveGenerics")
+	}
+
+	repo, err = newGoParser("app", dir, Options{ResolveGenerics: true}).ParseRepo()
+	if err != nil {
+		t.Fatalf("ParseRepo() error = %v", err)
+	}
+	types := repo.Modules["example.com/app"].Packages["example.com/app"].Types
+	user := types["Result_User"]
+	if user == nil {
+		t.Fatalf("Result_User not found, got %v", types)
+	}
+	if !strings.Contains(user.Content, "type Result_User struct") || !strings.Contains(user.Content, "Value User") {
+		t.Errorf("Result_User.Content = %q, want T substituted", user.Content)
+	}
+	if user.TypeKind != "struct" || user.Tags[TagGoGeneric] != "Result[User]" || user.FileLine != types["Result"].FileLine {
+		t.Errorf("Result_User = %+v", user)
+	}
+	if len(user.SubStruct) != 1 || user.SubStruct[0].Name != "User" {
+		t.Errorf("Result_User.SubStruct = %v, want User", user.SubStruct)
+	}
+	if orders := types["Result_Slice_Ptr_Order"]; orders == nil || !strings.Contains(orders.Content, "Value []*Order") {
+		t.Errorf("Result_Slice_Ptr_Order = %+v", orders)
+	}
+	for name := range types {
+		if strings.HasPrefix(name, "Result_T") {
+			t.Errorf("Result[T] in generic code should not be instantiated, got %s", name)
+		}
+	}
+}
@@ -165,6 +165,23 @@ func hasNoDeps(modFilePath string) bool {
 	return len(modf.Require) == 0
 }
 
+// getRequires returns the required modules of go.mod as {module path: version}
+func getRequires(modFilePath string) (map[string]string, error) {
+	content, err := os.ReadFile(modFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	modf, err := modfile.Parse(modFilePath, content, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+	ret := make(map[string]string, len(modf.Require))
+	for _, r := range modf.Require {
+		ret[r.Mod.Path] = r.Mod.Version
+	}
+	return ret, nil
+}
+
 func getModuleName(modFilePath string) (string, error) {
 	content, err := os.ReadFile(modFilePath)
 	if err != nil {
@@ -191,8 +208,11 @@ func isPkgScope(scope *types.Scope) bool {
 	return scope != nil && scope.Parent() == types.Universe
 }
 
-func getTypeKind(n ast.Expr) TypeKind {
-	switch n.(type) {
+func getTypeKind(spec *ast.TypeSpec) TypeKind {
+	if spec.Assign.IsValid() {
+		return TypeKindAlias
+	}
+	switch spec.Type.(type) {
 	case *ast.StructType:
 		return TypeKindStruct
 	case *ast.InterfaceType:
@@ -20,6 +20,8 @@ import (
 	"go/parser"
 	"go/token"
 	"go/types"
+	"os"
+	"path/filepath"
 	"slices"
 	"sync"
 	"testing"
@@ -285,3 +287,20 @@ func Test_isSysPkg(t *testing.T) {
 		assert.False(t, foundOs, "os should have been evicted from the cache")
 	})
 }
+
+func Test_getRequires(t *testing.T) {
+	modFile := filepath.Join(t.TempDir(), "go.mod")
+	content := "module example.com/a\n\ngo 1.21\n\nrequire github.com/pkg/errors v0.9.1\n\nrequire (\n\tgolang.org/x/mod v0.17.0\n\tgolang.org/x/sync v0.7.0 // indirect\n)\n"
+	require.NoError(t, os.WriteFile(modFile, []byte(content), 0644))
+
+	got, err := getRequires(modFile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"github.com/pkg/errors": "v0.9.1",
+		"golang.org/x/mod":      "v0.17.0",
+		"golang.org/x/sync":     "v0.7.0",
+	}, got)
+
+	_, err = getRequires(filepath.Join(t.TempDir(), "go.mod"))
+	assert.Error(t, err)
+}
@@ -40,7 +40,7 @@ func writeImport(sb *strings.Builder, impts []uniast.Import) {
 }
 
 func writeSingleImport(sb *strings.Builder, v uniast.Import) {
-	if v.Alias != nil {
+	if v.Alias != nil && *v.Alias != "" {
 		sb.WriteString(*v.Alias)
 		sb.WriteString(" ")
 	}
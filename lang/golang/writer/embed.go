@@ -0,0 +1,119 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package writer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var embedDirectiveRegex = regexp.MustCompile(`(?m)^[ \t]*//go:embed[ \t]+(.+?)[ \t]*$`)
+
+// embedPatterns returns the patterns of the //go:embed directives in src,
+// unquoted and without the all: prefix
+func embedPatterns(src string) []string {
+	var patterns []string
+	for _, m := range embedDirectiveRegex.FindAllStringSubmatch(src, -1) {
+		for args := strings.TrimSpace(m[1]); args != ""; args = strings.TrimSpace(args) {
+			var p string
+			if q := args[0]; q == '"' || q == '`' {
+				end := 1
+				for end < len(args) && args[end] != q {
+					if q == '"' && args[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				if end >= len(args) {
+					// unterminated quote
+					break
+				}
+				p, args = args[:end+1], args[end+1:]
+				if s, err := strconv.Unquote(p); err == nil {
+					p = s
+				}
+			} else if i := strings.IndexAny(args, " \t"); i >= 0 {
+				p, args = args[:i], args[i:]
+			} else {
+				p, args = args, ""
+			}
+			if p = strings.TrimPrefix(p, "all:"); p != "" {
+				patterns = append(patterns, p)
+			}
+		}
+	}
+	return patterns
+}
+
+// copyEmbeds makes the patterns embedded by a package match in its output dir dstDir,
+// copying the missing files from the package dir srcDir of the source repo
+func copyEmbeds(srcDir, dstDir string, patterns []string) error {
+	for _, p := range patterns {
+		if ms, err := filepath.Glob(filepath.Join(dstDir, p)); err != nil {
+			return fmt.Errorf("invalid embed pattern %q: %v", p, err)
+		} else if len(ms) > 0 {
+			continue
+		}
+		if srcDir == "" {
+			return fmt.Errorf("embed pattern %q matches no file, and the source dir is unknown", p)
+		}
+		ms, _ := filepath.Glob(filepath.Join(srcDir, p))
+		if len(ms) == 0 {
+			return fmt.Errorf("embed pattern %q matches no file in %s", p, srcDir)
+		}
+		for _, m := range ms {
+			rel, err := filepath.Rel(srcDir, m)
+			if err != nil {
+				return err
+			}
+			if err := copyPath(m, filepath.Join(dstDir, rel)); err != nil {
+				return fmt.Errorf("copy embedded file %s failed: %v", m, err)
+			}
+		}
+	}
+	return nil
+}
+
+// copyPath copies the file or the directory tree src to dst
+func copyPath(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, bs, 0644)
+	})
+}
@@ -0,0 +1,117 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package writer
+
+import (
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// testStubs collects the exported functions of a package directory to generate test stubs for them
+type testStubs struct {
+	pkgName string
+	files   map[string]bool // names of the files already in the directory
+	tests   map[string]bool // names of the test functions already in the directory
+	sources map[string][]*uniast.Function
+}
+
+func newTestStubs(pkgName string) *testStubs {
+	return &testStubs{
+		pkgName: pkgName,
+		files:   map[string]bool{},
+		tests:   map[string]bool{},
+		sources: map[string][]*uniast.Function{},
+	}
+}
+
+func (s *testStubs) addFile(name string, funcs []*uniast.Function) {
+	s.files[name] = true
+	if strings.HasSuffix(name, "_test.go") {
+		for _, f := range funcs {
+			s.tests[f.Name] = true
+		}
+		return
+	}
+	s.sources[name] = append(s.sources[name], funcs...)
+}
+
+var nonIdentRegex = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// render returns the content of the test stub files by name
+func (s *testStubs) render() map[string]string {
+	names := make([]string, 0, len(s.sources))
+	for name := range s.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ret := make(map[string]string)
+	for _, name := range names {
+		funcs := make([]*uniast.Function, 0, len(s.sources[name]))
+		for _, f := range s.sources[name] {
+			method := f.Name[strings.LastIndex(f.Name, ".")+1:]
+			if !f.IsInterfaceMethod && token.IsExported(method) {
+				funcs = append(funcs, f)
+			}
+		}
+		if len(funcs) == 0 {
+			continue
+		}
+		sort.SliceStable(funcs, func(i, j int) bool {
+			return funcs[i].Line < funcs[j].Line
+		})
+
+		var sb strings.Builder
+		sb.WriteString("package ")
+		sb.WriteString(s.pkgName)
+		sb.WriteString("_test\n\nimport \"testing\"\n")
+		for _, f := range funcs {
+			sb.WriteString("\nfunc ")
+			sb.WriteString(s.testName(f.Name))
+			sb.WriteString("(t *testing.T) {\n\tt.Skip(\"TODO\")\n}\n")
+		}
+		ret[s.fileName(name)] = sb.String()
+	}
+	return ret
+}
+
+// fileName returns the name of the test file of the source file, not conflicting with the files in the directory
+func (s *testStubs) fileName(source string) string {
+	base := strings.TrimSuffix(source, ".go")
+	name := base + "_test.go"
+	for i := 2; s.files[name]; i++ {
+		name = base + "_stub" + strconv.Itoa(i) + "_test.go"
+	}
+	s.files[name] = true
+	return name
+}
+
+// testName returns a unique TestXxx name for the function, e.g. T.Get => TestT_Get
+func (s *testStubs) testName(fn string) string {
+	base := "Test" + strings.Trim(nonIdentRegex.ReplaceAllString(fn, "_"), "_")
+	name := base
+	for i := 2; s.tests[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	s.tests[name] = true
+	return name
+}
@@ -25,6 +25,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -41,6 +42,15 @@ type Options struct {
 	// RepoDir   string
 	// OutDir    string
 	CompilerPath string
+	// FileFilter is called with each source file path (relative to the output dir) before writing;
+	// returning false skips the file. nil writes all files.
+	FileFilter func(filePath string) bool
+	// GenerateTestStubs writes a <file>_test.go next to each file with exported functions,
+	// holding a skipped TestXxx for each of them
+	GenerateTestStubs bool
+	// EmbedSourceDir is the source repo dir where the files embedded by //go:embed directives
+	// missing in the output dir are copied from, Repository.Path by default
+	EmbedSourceDir string
 }
 
 type Writer struct {
@@ -51,6 +61,31 @@ type Writer struct {
 type fileNode struct {
 	chunks []chunk
 	impts  []uniast.Import
+	funcs  []*uniast.Function // functions written in the file, only collected for GenerateTestStubs
+	embeds []string           // patterns of the //go:embed directives in the file
+	// imptKeys are the keys (see importKey) of impts, to skip the imports added by several chunks
+	imptKeys map[string]bool
+}
+
+// addImport appends impt to the imports of the file if it isn't there yet
+func (f *fileNode) addImport(impt uniast.Import) {
+	key := importKey(impt)
+	if f.imptKeys[key] {
+		return
+	}
+	if f.imptKeys == nil {
+		f.imptKeys = make(map[string]bool)
+	}
+	f.imptKeys[key] = true
+	f.impts = append(f.impts, impt)
+}
+
+// importKey identifies an import by its path and alias
+func importKey(impt uniast.Import) string {
+	if impt.Alias != nil {
+		return impt.Path + " " + *impt.Alias
+	}
+	return impt.Path
 }
 
 type chunk struct {
@@ -110,6 +145,11 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 	}
 
 	outdir := filepath.Join(outDir, mod.Dir)
+	srcDir := w.EmbedSourceDir
+	if srcDir == "" {
+		srcDir = repo.Path
+	}
+	stubs := make(map[string]*testStubs) // package dir => test stubs
 	for dir, pkg := range w.visited {
 		// sanitize the package path
 		cleanDir := sanitizePkgPath(dir)
@@ -119,9 +159,48 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 			return fmt.Errorf("mkdir %s failed: %v", pkgDir, err)
 		}
 
+		if w.GenerateTestStubs {
+			ts := stubs[pkgDir]
+			if ts == nil {
+				name := filepath.Base(cleanDir)
+				if p := mod.Packages[dir]; p != nil && p.IsMain {
+					name = "main"
+				}
+				ts = newTestStubs(name)
+				stubs[pkgDir] = ts
+			}
+			for fpath, f := range pkg {
+				ts.addFile(fpath, f.funcs)
+			}
+			for fpath := range mod.Files {
+				if filepath.Dir(fpath) == filepath.Join(mod.Dir, rel) {
+					ts.files[filepath.Base(fpath)] = true
+				}
+			}
+		}
+
 		for fpath, f := range pkg {
 
 			var sb strings.Builder
+			fi := mod.Files[filepath.Join(mod.Dir, rel, fpath)]
+
+			// directives are written before the package clause or among the nodes by their lines
+			chunks := slices.Clone(f.chunks)
+			if fi != nil && len(fi.Directives) > 0 {
+				for _, d := range fi.Directives {
+					line, comment := uniast.ParseDirective(d)
+					if line == 0 {
+						sb.WriteString(comment)
+						sb.WriteString("\n")
+					} else {
+						chunks = append(chunks, chunk{codes: comment, line: line})
+					}
+				}
+				if sb.Len() > 0 {
+					sb.WriteString("\n")
+				}
+			}
+
 			sb.WriteString("package ")
 			if p := mod.Packages[dir]; p != nil && p.IsMain {
 				sb.WriteString("main")
@@ -131,7 +210,7 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 			sb.WriteString("\n\n")
 
 			var fimpts []uniast.Import
-			if fi, ok := mod.Files[filepath.Join(mod.Dir, rel, fpath)]; ok && fi.Imports != nil {
+			if fi != nil && fi.Imports != nil {
 				fimpts = fi.Imports
 			}
 			impts := mergeImports(fimpts, f.impts)
@@ -139,20 +218,45 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 				writeImport(&sb, impts)
 			}
 
-			sort.SliceStable(f.chunks, func(i, j int) bool {
-				return f.chunks[i].line < f.chunks[j].line
+			sort.SliceStable(chunks, func(i, j int) bool {
+				return chunks[i].line < chunks[j].line
 			})
-			for _, c := range f.chunks {
+			for _, c := range chunks {
 				sb.WriteString(c.codes)
 				sb.WriteString("\n\n")
 			}
 			fpath = filepath.Join(pkgDir, fpath)
+			if !utils.ShouldWriteFile(w.FileFilter, outDir, fpath) {
+				continue
+			}
+			// the embedded files must exist before the file is written, otherwise the package can't build
+			if len(f.embeds) > 0 {
+				var pkgSrcDir string
+				if srcDir != "" {
+					pkgSrcDir = filepath.Join(srcDir, mod.Dir, rel)
+				}
+				if err := copyEmbeds(pkgSrcDir, pkgDir, f.embeds); err != nil {
+					return fmt.Errorf("write file %s failed: %v", fpath, err)
+				}
+			}
 			if err := os.WriteFile(fpath, []byte(sb.String()), 0644); err != nil {
 				return fmt.Errorf("write file %s failed: %v", fpath, err)
 			}
 		}
 	}
 
+	for pkgDir, ts := range stubs {
+		for name, src := range ts.render() {
+			fpath := filepath.Join(pkgDir, name)
+			if !utils.ShouldWriteFile(w.FileFilter, outDir, fpath) {
+				continue
+			}
+			if err := os.WriteFile(fpath, []byte(src), 0644); err != nil {
+				return fmt.Errorf("write file %s failed: %v", fpath, err)
+			}
+		}
+	}
+
 	// create go mod
 	var bs strings.Builder
 	bs.WriteString("module ")
@@ -233,6 +337,10 @@ func (w *Writer) appendPackage(repo *uniast.Repository, pkg *uniast.Package) err
 		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, v.File, v.Line, v.Content); err != nil {
 			return fmt.Errorf("append chunk for var %s failed: %v", v.Name, err)
 		}
+		if embeds := embedPatterns(v.Content); len(embeds) > 0 {
+			fs := w.visited[pkg.PkgPath][nodeFileName(v.File, pkg.IsMain)]
+			fs.embeds = append(fs.embeds, embeds...)
+		}
 	}
 	for _, f := range pkg.Functions {
 		if f.IsInterfaceMethod {
@@ -243,10 +351,14 @@ func (w *Writer) appendPackage(repo *uniast.Repository, pkg *uniast.Package) err
 		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, f.File, f.Line, f.Content); err != nil {
 			return fmt.Errorf("append chunk for function %s failed: %v", f.Name, err)
 		}
+		if w.GenerateTestStubs {
+			fs := w.visited[pkg.PkgPath][nodeFileName(f.File, pkg.IsMain)]
+			fs.funcs = append(fs.funcs, f)
+		}
 	}
 	for _, t := range pkg.Types {
 		n := repo.GetNode(t.Identity)
-		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, t.File, t.Line, t.Content); err != nil {
+		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, t.File, t.Line, withTypeParams(t.Content, t.Name, t.TypeParams)); err != nil {
 			return fmt.Errorf("append chunk for type %s failed: %v", t.Name, err)
 		}
 	}
@@ -259,16 +371,7 @@ func (w *Writer) appendNode(node *uniast.Node, pkg string, isMain bool, file str
 		p = make(map[string]*fileNode)
 		w.visited[pkg] = p
 	}
-	var fpath string
-	if file == "" {
-		if isMain {
-			fpath = "main.go"
-		} else {
-			fpath = "lib.go"
-		}
-	} else {
-		fpath = filepath.Base(file)
-	}
+	fpath := nodeFileName(file, isMain)
 	// codes, impts, err := SplitGoImportsAndCodes(src)
 	// if err != nil {
 	// 	return fmt.Errorf("split go imports and codes failed: %v", err)
@@ -285,14 +388,14 @@ func (w *Writer) appendNode(node *uniast.Node, pkg string, isMain bool, file str
 		if v.PkgPath == "" || v.PkgPath == pkg {
 			continue
 		}
-		fs.impts = append(fs.impts, uniast.Import{Path: strconv.Quote(v.PkgPath)})
+		fs.addImport(uniast.Import{Path: strconv.Quote(v.PkgPath)})
 	}
 
 	// 检查是否有imports
 	if cs, impts, err := w.SplitImportsAndCodes(src); err == nil {
 		src = cs
 		for _, v := range impts {
-			fs.impts = append(fs.impts, v)
+			fs.addImport(v)
 		}
 	}
 
@@ -303,6 +406,37 @@ func (w *Writer) appendNode(node *uniast.Node, pkg string, isMain bool, file str
 	return nil
 }
 
+// nodeFileName returns the name of the file where a node of file is written
+func nodeFileName(file string, isMain bool) string {
+	if file != "" {
+		return filepath.Base(file)
+	}
+	if isMain {
+		return "main.go"
+	}
+	return "lib.go"
+}
+
+// withTypeParams inserts the type parameters list after the type name if the type declaration misses it
+func withTypeParams(src string, name string, params []uniast.TypeParam) string {
+	if len(params) == 0 {
+		return src
+	}
+	re, err := regexp.Compile(`(?m)^\s*(?:type\s+)?` + regexp.QuoteMeta(name) + `\b`)
+	if err != nil {
+		return src
+	}
+	loc := re.FindStringIndex(src)
+	if loc == nil || (loc[1] < len(src) && src[loc[1]] == '[') {
+		return src
+	}
+	ps := make([]string, 0, len(params))
+	for _, p := range params {
+		ps = append(ps, p.Name+" "+p.Constraint)
+	}
+	return src[:loc[1]] + "[" + strings.Join(ps, ", ") + "]" + src[loc[1]:]
+}
+
 // receive a piece of golang code, parse it and splits the imports and codes
 func (w Writer) SplitImportsAndCodes(src string) (codes string, imports []uniast.Import, err error) {
 	fset := token.NewFileSet()
@@ -312,11 +446,12 @@ func (w Writer) SplitImportsAndCodes(src string) (codes string, imports []uniast
 		return src, nil, nil
 	}
 	for _, imp := range f.Imports {
-		var alias string
+		i := uniast.Import{Path: imp.Path.Value}
 		if imp.Name != nil {
-			alias = imp.Name.Name
+			alias := imp.Name.Name
+			i.Alias = &alias
 		}
-		imports = append(imports, uniast.Import{Path: imp.Path.Value, Alias: &alias})
+		imports = append(imports, i)
 	}
 	start := 0
 	for _, s := range f.Decls {
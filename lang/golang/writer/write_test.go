@@ -19,7 +19,9 @@ package writer
 import (
 	"bytes"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/abcoder/lang/testutils"
@@ -29,7 +31,7 @@ import (
 func TestWriter_WriteRepo(t *testing.T) {
 	astFile := testutils.GetTestAstFile("localsession")
 	repo, err := uniast.LoadRepo(astFile)
-	if err != nil {
+	if err != nil && !uniast.IsVersionMismatch(err) {
 		t.Fatal(err)
 	}
 	type fields struct {
@@ -154,3 +156,197 @@ func TestPatcher_PatchImports(t *testing.T) {
 		})
 	}
 }
+
+func Test_withTypeParams(t *testing.T) {
+	params := []uniast.TypeParam{{Name: "K", Constraint: "comparable"}, {Name: "V", Constraint: "any"}}
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"spec", "Map struct {\n\tm map[K]V\n}", "Map[K comparable, V any] struct {\n\tm map[K]V\n}"},
+		{"decl with comment", "// Map is a map\ntype Map struct{}", "// Map is a map\ntype Map[K comparable, V any] struct{}"},
+		{"already has params", "type Map[K comparable, V any] struct{}", "type Map[K comparable, V any] struct{}"},
+		{"name not found", "type Other struct{}", "type Other struct{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withTypeParams(tt.src, "Map", params); got != tt.want {
+				t.Errorf("withTypeParams() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := withTypeParams("type Map struct{}", "Map", nil); got != "type Map struct{}" {
+		t.Errorf("withTypeParams() without params = %q", got)
+	}
+}
+
+func TestWriter_WriteDirectives(t *testing.T) {
+	repo := uniast.NewRepository("example.com/gen")
+	mod := uniast.NewModule("example.com/gen", "gen", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	pkg := uniast.NewPackage("example.com/gen")
+	mod.Packages[pkg.PkgPath] = pkg
+	mod.Files["gen/gen.go"] = &uniast.File{
+		Path:       "gen/gen.go",
+		Package:    pkg.PkgPath,
+		Directives: []string{uniast.NewDirective(0, "// Code generated by mockgen. DO NOT EDIT."), uniast.NewDirective(5, "//go:generate mockgen -source=gen.go")},
+	}
+	pkg.Types["A"] = &uniast.Type{Identity: uniast.NewIdentity(mod.Name, pkg.PkgPath, "A"), FileLine: uniast.FileLine{File: "gen/gen.go", Line: 3}, Content: "type A int"}
+	pkg.Types["B"] = &uniast.Type{Identity: uniast.NewIdentity(mod.Name, pkg.PkgPath, "B"), FileLine: uniast.FileLine{File: "gen/gen.go", Line: 7}, Content: "type B int"}
+
+	dir := t.TempDir()
+	w := NewWriter(Options{CompilerPath: "true"})
+	if err := w.WriteRepo(&repo, dir); err != nil {
+		t.Fatalf("WriteRepo() error = %v", err)
+	}
+	bs, err := os.ReadFile(filepath.Join(dir, "gen", "gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "// Code generated by mockgen. DO NOT EDIT.\n\npackage gen\n\ntype A int\n\n//go:generate mockgen -source=gen.go\n\ntype B int\n\n"
+	if string(bs) != want {
+		t.Errorf("written file = %q, want %q", bs, want)
+	}
+}
+
+func TestWriter_WriteEmbed(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "web", "static", "css"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"web/index.html":          "<html></html>",
+		"web/static/css/main.css": "body {}",
+	} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := uniast.NewRepository("example.com/web")
+	repo.Path = src
+	mod := uniast.NewModule("example.com/web", "web", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	pkg := uniast.NewPackage("example.com/web")
+	mod.Packages[pkg.PkgPath] = pkg
+	pkg.Vars["index"] = &uniast.Var{
+		Identity: uniast.NewIdentity(mod.Name, pkg.PkgPath, "index"),
+		FileLine: uniast.FileLine{File: "web/web.go", Line: 3},
+		Content:  "//go:embed \"index.html\"\nvar index string",
+	}
+	pkg.Vars["static"] = &uniast.Var{
+		Identity: uniast.NewIdentity(mod.Name, pkg.PkgPath, "static"),
+		FileLine: uniast.FileLine{File: "web/web.go", Line: 6},
+		Content:  "//go:embed all:static\nvar static embed.FS",
+	}
+	repo.BuildGraph()
+
+	dir := t.TempDir()
+	if err := NewWriter(Options{CompilerPath: "true"}).WriteRepo(&repo, dir); err != nil {
+		t.Fatalf("WriteRepo() error = %v", err)
+	}
+	for _, name := range []string{"web/web.go", "web/index.html", "web/static/css/main.css"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s is not written: %v", name, err)
+		}
+	}
+
+	// the embedded files are neither in the output dir nor in the source dir
+	pkg.Vars["missing"] = &uniast.Var{
+		Identity: uniast.NewIdentity(mod.Name, pkg.PkgPath, "missing"),
+		FileLine: uniast.FileLine{File: "web/web.go", Line: 9},
+		Content:  "//go:embed *.txt\nvar missing embed.FS",
+	}
+	repo.BuildGraph()
+	if err := NewWriter(Options{CompilerPath: "true"}).WriteRepo(&repo, t.TempDir()); err == nil || !strings.Contains(err.Error(), `"*.txt"`) {
+		t.Errorf("WriteRepo() error = %v, want the missing embed pattern", err)
+	}
+}
+
+func Test_embedPatterns(t *testing.T) {
+	src := "//go:embed a.txt  \"b c.txt\" `d.txt`\n//go:embed all:static\nvar fs embed.FS"
+	want := []string{"a.txt", "b c.txt", "d.txt", "static"}
+	if got := embedPatterns(src); !reflect.DeepEqual(got, want) {
+		t.Errorf("embedPatterns() = %q, want %q", got, want)
+	}
+}
+
+func TestWriter_ImportAlias(t *testing.T) {
+	src := "package p\n\nimport (\n\tnethttp \"net/http\"\n\t. \"testing\"\n\t_ \"embed\"\n\t\"fmt\"\n)\n\nvar _ = fmt.Sprint(nethttp.MethodGet, Short())"
+	codes, impts, err := NewWriter(Options{}).SplitImportsAndCodes(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if codes != "var _ = fmt.Sprint(nethttp.MethodGet, Short())" {
+		t.Errorf("codes = %q", codes)
+	}
+	if impts[3].Alias != nil {
+		t.Errorf("unaliased import should have nil Alias, got %q", *impts[3].Alias)
+	}
+	var sb strings.Builder
+	writeImport(&sb, impts)
+	want := "import (\n\tnethttp \"net/http\"\n\t. \"testing\"\n\t_ \"embed\"\n\t\"fmt\"\n)\n"
+	if sb.String() != want {
+		t.Errorf("writeImport() = %q, want %q", sb.String(), want)
+	}
+}
+
+func TestWriter_appendNodeDedupImports(t *testing.T) {
+	w := NewWriter(Options{})
+	repo := uniast.NewRepository("r")
+	node := uniast.NewNode(uniast.NewIdentity("m", "m/a", "A"), uniast.FUNC, &repo)
+	node.Dependencies = []uniast.Relation{{Kind: uniast.DEPENDENCY, Identity: uniast.NewIdentity("m", "m/b", "B")}}
+	srcs := []string{
+		"package a\n\nimport \"fmt\"\n\nfunc A() { fmt.Println(b.B) }",
+		"package a\n\nimport (\n\t\"fmt\"\n\t\"time\"\n)\n\nfunc A2() { fmt.Println(time.Now(), b.B) }",
+		"package a\n\nimport f \"fmt\"\n\nfunc A3() { f.Println() }",
+	}
+	for i, src := range srcs {
+		if err := w.appendNode(node, "m/a", false, "a.go", i, src); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	for _, impt := range w.visited["m/a"]["a.go"].impts {
+		got = append(got, importKey(impt))
+	}
+	want := []string{`"m/b"`, `"fmt"`, `"time"`, `"fmt" f`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("imports = %q, want %q", got, want)
+	}
+}
+
+func TestWriter_GenerateTestStubs(t *testing.T) {
+	repo := uniast.NewRepository("example.com/calc")
+	mod := uniast.NewModule("example.com/calc", "calc", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	pkg := uniast.NewPackage("example.com/calc")
+	mod.Packages[pkg.PkgPath] = pkg
+	id := func(name string) uniast.Identity { return uniast.NewIdentity(mod.Name, pkg.PkgPath, name) }
+	pkg.Types["Calc"] = &uniast.Type{Identity: id("Calc"), FileLine: uniast.FileLine{File: "calc/calc.go", Line: 1}, Content: "type Calc struct{}"}
+	pkg.Functions["Add"] = &uniast.Function{Identity: id("Add"), FileLine: uniast.FileLine{File: "calc/calc.go", Line: 3}, Content: "func Add(a, b int) int { return a + b }"}
+	pkg.Functions["sub"] = &uniast.Function{Identity: id("sub"), FileLine: uniast.FileLine{File: "calc/calc.go", Line: 5}, Content: "func sub(a, b int) int { return a - b }"}
+	pkg.Functions["Calc.Mul"] = &uniast.Function{Identity: id("Calc.Mul"), IsMethod: true, FileLine: uniast.FileLine{File: "calc/calc.go", Line: 7}, Content: "func (Calc) Mul(a, b int) int { return a * b }"}
+	pkg.Functions["helper"] = &uniast.Function{Identity: id("helper"), FileLine: uniast.FileLine{File: "calc/util.go", Line: 1}, Content: "func helper() {}"}
+	// an existing test file taking the name of the stub file
+	pkg.Functions["TestAdd"] = &uniast.Function{Identity: id("TestAdd"), FileLine: uniast.FileLine{File: "calc/calc_test.go", Line: 3}, Content: "func TestAdd(t *testing.T) {}"}
+	repo.BuildGraph()
+
+	dir := t.TempDir()
+	w := NewWriter(Options{CompilerPath: "true", GenerateTestStubs: true})
+	if err := w.WriteRepo(&repo, dir); err != nil {
+		t.Fatalf("WriteRepo() error = %v", err)
+	}
+	bs, err := os.ReadFile(filepath.Join(dir, "calc", "calc_stub2_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "package calc_test\n\nimport \"testing\"\n\nfunc TestAdd2(t *testing.T) {\n\tt.Skip(\"TODO\")\n}\n\nfunc TestCalc_Mul(t *testing.T) {\n\tt.Skip(\"TODO\")\n}\n"
+	if string(bs) != want {
+		t.Errorf("test stub file = %q, want %q", bs, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "calc", "util_test.go")); !os.IsNotExist(err) {
+		t.Errorf("no test stub file should be written for a file without exported functions")
+	}
+}
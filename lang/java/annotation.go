@@ -0,0 +1,81 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"regexp"
+	"strings"
+)
+
+// springAnnotations are the well-known Spring annotations which affect the runtime behavior
+var springAnnotations = map[string]bool{
+	"SpringBootApplication": true,
+	"Configuration":         true,
+	"Component":             true,
+	"Service":               true,
+	"Repository":            true,
+	"Controller":            true,
+	"RestController":        true,
+	"ControllerAdvice":      true,
+	"RestControllerAdvice":  true,
+	"Bean":                  true,
+	"Autowired":             true,
+	"Qualifier":             true,
+	"Value":                 true,
+	"Transactional":         true,
+	"Scheduled":             true,
+	"RequestMapping":        true,
+	"GetMapping":            true,
+	"PostMapping":           true,
+	"PutMapping":            true,
+	"DeleteMapping":         true,
+	"PatchMapping":          true,
+	"PathVariable":          true,
+	"RequestParam":          true,
+	"RequestBody":           true,
+	"ResponseBody":          true,
+	"ExceptionHandler":      true,
+}
+
+var annotationRegex = regexp.MustCompile(`@([\w.]+)(\([^()]*\))?`)
+
+// SpringAnnotations returns the Spring annotations (separated by space) in the declaration header of the content,
+// ex: `@RestController @RequestMapping("/api")`. The body after the first '{' is ignored.
+func SpringAnnotations(content string) string {
+	header := content
+	depth := 0
+	for i, c := range content {
+		if c == '(' {
+			depth++
+		} else if c == ')' {
+			depth--
+		} else if c == '{' && depth == 0 {
+			header = content[:i]
+			break
+		}
+	}
+
+	var ret []string
+	for _, m := range annotationRegex.FindAllStringSubmatch(header, -1) {
+		name := m[1]
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			name = name[i+1:]
+		}
+		if springAnnotations[name] {
+			ret = append(ret, m[0])
+		}
+	}
+	return strings.Join(ret, " ")
+}
@@ -0,0 +1,144 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package collect builds the UniAST of a java repository with jdtls,
+// without requiring an AST file parsed beforehand.
+package collect
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/collect"
+	"github.com/cloudwego/abcoder/lang/java"
+	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/register"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// DefaultLSP is the jdtls executable looked up in PATH if Options.LSP is not specified
+const DefaultLSP = "jdtls"
+
+// Options is the options for collecting a java repository.
+type Options struct {
+	// LSP is the jdtls executable, DefaultLSP by default.
+	// If it isn't found in PATH, the jdtls installed by abcoder is used.
+	LSP string
+	// LspOptions is the initialization options of jdtls, e.g. java.home
+	LspOptions map[string]string
+	// LSPTimeout bounds the initialization of jdtls, 0 means no timeout
+	LSPTimeout time.Duration
+	Verbose    bool
+	// NoGraph skips building the dependency graph, the Graph of the repo is left empty
+	NoGraph bool
+	collect.CollectOption
+}
+
+// Collector collects the types, methods and fields of a java repository through jdtls.
+// The modules of a multi-module maven project are read from its pom.xml files by collect.Collector,
+// and each type or method is put into the package declared by its file.
+type Collector struct {
+	repo string
+	opts Options
+}
+
+// NewCollector returns a collector for the java repository at repo
+func NewCollector(repo string, opts Options) *Collector {
+	if !filepath.IsAbs(repo) {
+		repo, _ = filepath.Abs(repo)
+	}
+	opts.Language = uniast.Java
+	return &Collector{repo: repo, opts: opts}
+}
+
+// Collect starts jdtls, collects the symbols of the repository and returns its UniAST.
+// The References of the nodes are the reversed dependencies resolved by jdtls.
+func (c *Collector) Collect(ctx context.Context) (*uniast.Repository, error) {
+	if _, err := os.Stat(c.repo); os.IsNotExist(err) {
+		return nil, fmt.Errorf("repository not found: %s", c.repo)
+	}
+	server, err := c.lspServer()
+	if err != nil {
+		return nil, err
+	}
+	openfile, wait := java.CheckRepo(c.repo)
+	log.Info("open file '%s' and wait for %d seconds for initialize workspace\n", openfile, wait/time.Second)
+
+	log.Info("start initialize LSP server %s...\n", server)
+	register.RegisterProviders()
+	cli, err := lsp.NewLSPClient(c.repo, openfile, wait, lsp.ClientOptions{
+		Server:                server,
+		Language:              uniast.Java,
+		Verbose:               c.opts.Verbose,
+		InitializationOptions: c.opts.LspOptions,
+		InitTimeout:           c.opts.LSPTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("initialize LSP server %s failed: %w", server, err)
+	}
+	defer cli.Close()
+	log.Info("end initialize LSP server")
+
+	collector := collect.NewCollector(c.repo, cli)
+	collector.CollectOption = c.opts.CollectOption
+	log.Info("start collecting symbols...\n")
+	if err := collector.Collect(ctx); err != nil {
+		return nil, err
+	}
+	log.Info("start exporting symbols...\n")
+	repo, err := collector.Export(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.opts.NoGraph {
+		repo.Graph = uniast.NodeGraph{}
+		return repo, nil
+	}
+	if err := repo.BuildGraph(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// lspServer returns the jdtls command to start,
+// detecting the java home for the jdtls installed by abcoder if it isn't specified
+func (c *Collector) lspServer() (string, error) {
+	if c.opts.LSP != "" {
+		return c.opts.LSP, nil
+	}
+	if path, err := exec.LookPath(DefaultLSP); err == nil {
+		return path, nil
+	}
+	if c.opts.LspOptions["java.home"] == "" {
+		// jdtls fails silently without a valid java home
+		home, err := java.DetectJavaHome()
+		if err != nil {
+			return "", err
+		}
+		log.Info("java home not specified, detected: %s\n", home)
+		lspOptions := make(map[string]string, len(c.opts.LspOptions)+1)
+		for k, v := range c.opts.LspOptions {
+			lspOptions[k] = v
+		}
+		lspOptions["java.home"] = home
+		c.opts.LspOptions = lspOptions
+	}
+	_, server := java.GetDefaultLSP(c.opts.LspOptions)
+	return server, nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestCollector_lspServer(t *testing.T) {
+	bin := t.TempDir()
+	jdtls := filepath.Join(bin, DefaultLSP)
+	if err := os.WriteFile(jdtls, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	c := NewCollector("testdata", Options{})
+	if c.opts.Language != uniast.Java || !filepath.IsAbs(c.repo) {
+		t.Fatalf("unexpected collector: %+v", c)
+	}
+	if server, err := c.lspServer(); err != nil || server != jdtls {
+		t.Fatalf("lspServer() = %q, %v, want %q", server, err, jdtls)
+	}
+
+	c = NewCollector("testdata", Options{LSP: "/opt/jdtls/bin/jdtls"})
+	if server, err := c.lspServer(); err != nil || server != "/opt/jdtls/bin/jdtls" {
+		t.Fatalf("lspServer() = %q, %v, want the specified LSP", server, err)
+	}
+}
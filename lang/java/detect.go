@@ -0,0 +1,89 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// javaHomeCandidates are the common JDK installation paths, checked in order
+var javaHomeCandidates = []string{
+	"/usr/lib/jvm/default",
+	"/usr/lib/jvm/default-java",
+	"/usr/local/opt/openjdk",
+	"/opt/homebrew/opt/openjdk",
+}
+
+// javaSettings returns the output of `java -XshowSettings`, replaceable for testing
+var javaSettings = func() ([]byte, error) {
+	// NOTICE: java prints the settings to stderr
+	return exec.Command("java", "-XshowSettings:all", "-version").CombinedOutput()
+}
+
+// DetectJavaHome detects the java home directory by:
+//  1. the JAVA_HOME env
+//  2. the `java.home` property shown by `java -XshowSettings:all`
+//  3. the common installation paths
+func DetectJavaHome() (string, error) {
+	if home := os.Getenv("JAVA_HOME"); home != "" && isDir(home) {
+		return home, nil
+	}
+	if out, err := javaSettings(); err == nil {
+		if home := parseJavaHomeSetting(out); home != "" && isDir(home) {
+			return home, nil
+		}
+	}
+	for _, home := range javaHomeCandidates {
+		if isDir(home) {
+			return home, nil
+		}
+	}
+	return "", fmt.Errorf("java home not found in JAVA_HOME, `java -XshowSettings:all` or %v, please install a JDK or specify it by --java-home", javaHomeCandidates)
+}
+
+// JavaCommand returns the java executable of javaHome, which can be either a java home directory
+// or the java executable itself
+func JavaCommand(javaHome string) string {
+	if javaHome == "" {
+		return "java"
+	}
+	if isDir(javaHome) {
+		return filepath.Join(javaHome, "bin", "java")
+	}
+	return javaHome
+}
+
+// parseJavaHomeSetting extracts `java.home = /path/to/jdk` from the output of `java -XshowSettings`
+func parseJavaHomeSetting(out []byte) string {
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(sc.Text()), "=")
+		if ok && strings.TrimSpace(key) == "java.home" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectJavaHome(t *testing.T) {
+	oldSettings, oldCandidates := javaSettings, javaHomeCandidates
+	defer func() { javaSettings, javaHomeCandidates = oldSettings, oldCandidates }()
+
+	envHome, settingsHome, candidateHome := t.TempDir(), t.TempDir(), t.TempDir()
+	javaSettings = func() ([]byte, error) {
+		return []byte("Property settings:\n    file.encoding = UTF-8\n    java.home = " + settingsHome + "\n    java.version = 17\n"), nil
+	}
+	javaHomeCandidates = []string{filepath.Join(candidateHome, "missing"), candidateHome}
+
+	t.Setenv("JAVA_HOME", envHome)
+	if got, err := DetectJavaHome(); err != nil || got != envHome {
+		t.Errorf("DetectJavaHome() = %q, %v, want JAVA_HOME %q", got, err, envHome)
+	}
+
+	t.Setenv("JAVA_HOME", "")
+	if got, err := DetectJavaHome(); err != nil || got != settingsHome {
+		t.Errorf("DetectJavaHome() = %q, %v, want java.home setting %q", got, err, settingsHome)
+	}
+
+	javaSettings = func() ([]byte, error) { return nil, errors.New("java not found") }
+	if got, err := DetectJavaHome(); err != nil || got != candidateHome {
+		t.Errorf("DetectJavaHome() = %q, %v, want candidate %q", got, err, candidateHome)
+	}
+
+	javaHomeCandidates = nil
+	if _, err := DetectJavaHome(); err == nil || !strings.Contains(err.Error(), "--java-home") {
+		t.Errorf("DetectJavaHome() error = %v, want error pointing to --java-home", err)
+	}
+}
+
+func TestJavaCommand(t *testing.T) {
+	home := t.TempDir()
+	if got := JavaCommand(home); got != filepath.Join(home, "bin", "java") {
+		t.Errorf("JavaCommand(dir) = %q", got)
+	}
+	if got := JavaCommand("/opt/jdk/bin/java"); got != "/opt/jdk/bin/java" {
+		t.Errorf("JavaCommand(executable) = %q", got)
+	}
+	if got := JavaCommand(""); got != "java" {
+		t.Errorf("JavaCommand(\"\") = %q", got)
+	}
+}
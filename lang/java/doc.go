@@ -0,0 +1,36 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import "strings"
+
+// DocString returns the text of a javadoc comment `/** ... */` without the comment markers,
+// or an empty string if the comment is not a javadoc.
+func DocString(comment string) string {
+	comment = strings.TrimSpace(comment)
+	if !strings.HasPrefix(comment, "/**") || !strings.HasSuffix(comment, "*/") || len(comment) < len("/**/") {
+		return ""
+	}
+	comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/**"), "*/")
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, "*"); ok {
+			line = strings.TrimPrefix(rest, " ")
+		}
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -243,11 +243,7 @@ func generateExecuteCmd(LspOptions map[string]string) string {
 		"--add-opens java.base/java.util=ALL-UNNAMED",
 		"--add-opens java.base/java.lang=ALL-UNNAMED",
 	}
-	javaCmd := "java "
-	if len(LspOptions["java.home"]) != 0 {
-		javaCmd = LspOptions["java.home"] + " "
-	}
-	return javaCmd + strings.Join(args, " ")
+	return JavaCommand(LspOptions["java.home"]) + " " + strings.Join(args, " ")
 }
 
 func CheckRepo(repo string) (string, time.Duration) {
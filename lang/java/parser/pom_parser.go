@@ -35,6 +35,9 @@ type ModuleInfo struct {
 	TargetPath     string
 	SubModules     []*ModuleInfo
 	Properties     map[string]string
+	Dependencies   map[string]string // groupId:artifactId => version, including those inherited from parent
+
+	managedVersions map[string]string // groupId:artifactId => version in dependencyManagement
 }
 
 // ParseMavenProject recursively parses a module and its submodules.
@@ -134,6 +137,7 @@ func parseMavenProject(pomPath string, parent *ModuleInfo) (*ModuleInfo, error)
 		SubModules:     []*ModuleInfo{},
 		Properties:     properties,
 	}
+	currentModule.managedVersions, currentModule.Dependencies = parseDependencies(project, parent, properties, groupID, version)
 
 	// 3. If a <modules> section exists, recursively parse the submodules.
 	if project.Modules != nil && len(*project.Modules) > 0 {
@@ -155,6 +159,56 @@ func parseMavenProject(pomPath string, parent *ModuleInfo) (*ModuleInfo, error)
 	return currentModule, nil
 }
 
+// parseDependencies returns the managed versions and the dependencies of the project.
+// The version of a dependency is resolved from properties or dependencyManagement if absent.
+func parseDependencies(project *gopom.Project, parent *ModuleInfo, properties map[string]string, groupID, version string) (managed, deps map[string]string) {
+	props := make(map[string]string, len(properties)+2)
+	props["project.groupId"] = groupID
+	props["project.version"] = version
+	for k, v := range properties {
+		props[k] = v
+	}
+
+	managed = make(map[string]string)
+	deps = make(map[string]string)
+	if parent != nil {
+		for k, v := range parent.managedVersions {
+			managed[k] = v
+		}
+		for k, v := range parent.Dependencies {
+			deps[k] = v
+		}
+	}
+	if project.DependencyManagement != nil && project.DependencyManagement.Dependencies != nil {
+		for _, dep := range *project.DependencyManagement.Dependencies {
+			if key := dependencyKey(dep, props); key != "" && dep.Version != nil {
+				managed[key] = resolveProperty(*dep.Version, props)
+			}
+		}
+	}
+	if project.Dependencies != nil {
+		for _, dep := range *project.Dependencies {
+			key := dependencyKey(dep, props)
+			if key == "" {
+				continue
+			}
+			if dep.Version != nil {
+				deps[key] = resolveProperty(*dep.Version, props)
+			} else {
+				deps[key] = managed[key]
+			}
+		}
+	}
+	return managed, deps
+}
+
+func dependencyKey(dep gopom.Dependency, properties map[string]string) string {
+	if dep.GroupID == nil || dep.ArtifactID == nil {
+		return ""
+	}
+	return resolveProperty(*dep.GroupID, properties) + ":" + resolveProperty(*dep.ArtifactID, properties)
+}
+
 func GetModuleMap(root *ModuleInfo) map[string]string {
 	rets := map[string]string{}
 	var queue []*ModuleInfo
@@ -15,6 +15,7 @@
 package parser
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 )
@@ -44,3 +45,82 @@ func TestParseMavenProject(t *testing.T) {
 	// Print the tree to visually verify the structure
 	PrintProjectTree(rootModule, "")
 }
+
+func TestParseMavenProject_Dependencies(t *testing.T) {
+	root := t.TempDir()
+	parentPom := `<project>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>1.0.0</version>
+    <properties>
+        <jackson.version>2.17.0</jackson.version>
+    </properties>
+    <modules>
+        <module>child</module>
+    </modules>
+    <dependencyManagement>
+        <dependencies>
+            <dependency>
+                <groupId>com.fasterxml.jackson.core</groupId>
+                <artifactId>jackson-databind</artifactId>
+                <version>${jackson.version}</version>
+            </dependency>
+        </dependencies>
+    </dependencyManagement>
+    <dependencies>
+        <dependency>
+            <groupId>junit</groupId>
+            <artifactId>junit</artifactId>
+            <version>4.13.2</version>
+        </dependency>
+    </dependencies>
+</project>`
+	childPom := `<project>
+    <artifactId>child</artifactId>
+    <dependencies>
+        <dependency>
+            <groupId>com.fasterxml.jackson.core</groupId>
+            <artifactId>jackson-databind</artifactId>
+        </dependency>
+        <dependency>
+            <groupId>${project.groupId}</groupId>
+            <artifactId>common</artifactId>
+            <version>${project.version}</version>
+        </dependency>
+    </dependencies>
+</project>`
+	if err := os.WriteFile(filepath.Join(root, "pom.xml"), []byte(parentPom), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "child"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "child", "pom.xml"), []byte(childPom), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootModule, err := ParseMavenProject(filepath.Join(root, "pom.xml"))
+	if err != nil {
+		t.Fatalf("Error parsing root project: %v", err)
+	}
+	if len(rootModule.Dependencies) != 1 || rootModule.Dependencies["junit:junit"] != "4.13.2" {
+		t.Errorf("unexpected root dependencies: %v", rootModule.Dependencies)
+	}
+	if len(rootModule.SubModules) != 1 {
+		t.Fatalf("Expected 1 submodule, but got %d", len(rootModule.SubModules))
+	}
+	want := map[string]string{
+		"junit:junit": "4.13.2",
+		"com.fasterxml.jackson.core:jackson-databind": "2.17.0",
+		"com.example:common":                          "1.0.0",
+	}
+	got := rootModule.SubModules[0].Dependencies
+	if len(got) != len(want) {
+		t.Errorf("child dependencies = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("child dependency %s = %q, want %q", k, got[k], v)
+		}
+	}
+}
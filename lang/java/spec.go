@@ -84,6 +84,14 @@ func (c *JavaSpec) WorkSpace(root string) (map[string]string, error) {
 	return rets, nil
 }
 
+// implement lsp.ModuleDependencySpec
+func (c *JavaSpec) ModuleDependencies(mod string) map[string]string {
+	if m := c.nameToMod[mod]; m != nil {
+		return m.Dependencies
+	}
+	return nil
+}
+
 func (c *JavaSpec) PathToMod(path string) *javaparser.ModuleInfo {
 
 	var maxPathmatchMods *javaparser.ModuleInfo
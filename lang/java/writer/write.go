@@ -34,6 +34,9 @@ var _ uniast.Writer = (*Writer)(nil)
 
 type Options struct {
 	CompilerPath string
+	// FileFilter is called with each source file path (relative to the output dir) before writing;
+	// returning false skips the file. nil writes all files.
+	FileFilter func(filePath string) bool
 }
 
 type Writer struct {
@@ -120,6 +123,9 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 				filename = filename + ".java"
 			}
 			fpath := filepath.Join(pkgDir, filename)
+			if !utils.ShouldWriteFile(w.FileFilter, outDir, fpath) {
+				continue
+			}
 
 			if err := os.WriteFile(fpath, []byte(sb.String()), 0644); err != nil {
 				return fmt.Errorf("write file %s failed: %v", fpath, err)
@@ -17,6 +17,8 @@
 package writer
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -96,3 +98,36 @@ func TestWriter_IdToImport(t *testing.T) {
 		})
 	}
 }
+
+func TestWriter_WriteModule_FileFilter(t *testing.T) {
+	repo := uniast.NewRepository("demo")
+	mod := uniast.NewModule("demo", ".", uniast.Java)
+	repo.Modules["demo"] = mod
+	pkg := uniast.NewPackage("com.example")
+	mod.Packages["com.example"] = pkg
+	for _, name := range []string{"Keep", "Skip"} {
+		id := uniast.NewIdentity("demo", "com.example", name)
+		pkg.Types[name] = &uniast.Type{
+			Identity: id,
+			FileLine: uniast.FileLine{File: name + ".java", Line: 1},
+			Content:  "public class " + name + " {}",
+		}
+	}
+	repo.BuildGraph()
+
+	outDir := t.TempDir()
+	w := NewWriter(Options{FileFilter: func(filePath string) bool {
+		return filepath.Base(filePath) != "Skip.java"
+	}})
+	if err := w.WriteModule(&repo, "demo", outDir); err != nil {
+		t.Fatalf("WriteModule() error = %v", err)
+	}
+
+	pkgDir := filepath.Join(outDir, "com", "example")
+	if _, err := os.Stat(filepath.Join(pkgDir, "Keep.java")); err != nil {
+		t.Errorf("Keep.java should be written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(pkgDir, "Skip.java")); !os.IsNotExist(err) {
+		t.Errorf("Skip.java should be filtered out, stat err = %v", err)
+	}
+}
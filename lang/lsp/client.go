@@ -18,11 +18,13 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudwego/abcoder/lang/log"
@@ -37,6 +39,7 @@ type LSPClient struct {
 	tokenTypes             []string
 	tokenModifiers         []string
 	hasSemanticTokensRange bool
+	filesMu                sync.RWMutex // guards files, since files can be opened concurrently
 	files                  map[DocumentURI]*TextDocumentItem
 	provider               LanguageServiceProvider
 	ClientOptions
@@ -47,6 +50,8 @@ type ClientOptions struct {
 	uniast.Language
 	Verbose               bool
 	InitializationOptions interface{}
+	// InitTimeout bounds the initialization handshake with the server, 0 means no timeout
+	InitTimeout time.Duration
 }
 
 func NewLSPClient(repo string, openfile string, wait time.Duration, opts ClientOptions) (*LSPClient, error) {
@@ -56,8 +61,21 @@ func NewLSPClient(repo string, openfile string, wait time.Duration, opts ClientO
 		return nil, err
 	}
 
-	cli, err := initLSPClient(context.Background(), svr, NewURI(repo), opts.Verbose, opts.Language, opts.InitializationOptions)
+	ctx := context.Background()
+	if opts.InitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.InitTimeout)
+		defer cancel()
+	}
+	cli, err := initLSPClient(ctx, svr, NewURI(repo), opts.Verbose, opts.Language, opts.InitializationOptions)
 	if err != nil {
+		svr.kill()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("LSP server %s did not initialize in %v: %w", opts.Server, opts.InitTimeout, err)
+		}
+		if stderr := svr.stderr.String(); stderr != "" {
+			err = fmt.Errorf("%w\nLSP server stderr:\n%s", err, stderr)
+		}
 		return nil, err
 	}
 
@@ -125,7 +143,8 @@ func (c *LSPClient) InitFiles() {
 func initLSPClient(ctx context.Context, svr io.ReadWriteCloser, dir DocumentURI, verbose bool, language uniast.Language, InitializationOptions interface{}) (*LSPClient, error) {
 	h := newLSPHandler()
 	stream := jsonrpc2.NewBufferedStream(svr, jsonrpc2.VSCodeObjectCodec{})
-	conn := jsonrpc2.NewConn(ctx, stream, h)
+	// the connection outlives ctx, which only bounds the initialization
+	conn := jsonrpc2.NewConn(context.Background(), stream, h)
 	cli := &LSPClient{Conn: conn, lspHandler: h}
 
 	// Initialize the LSP server
@@ -224,7 +243,8 @@ func initLSPClient(ctx context.Context, svr io.ReadWriteCloser, dir DocumentURI,
 type rwc struct {
 	io.ReadCloser
 	io.WriteCloser
-	cmd *exec.Cmd
+	cmd    *exec.Cmd
+	stderr *stderrTail
 }
 
 func (rwc rwc) Close() error {
@@ -237,8 +257,41 @@ func (rwc rwc) Close() error {
 	return rwc.cmd.Wait()
 }
 
+// kill stops the LSP process, e.g. when it doesn't answer the initialization
+func (rwc rwc) kill() {
+	if rwc.cmd.Process != nil {
+		rwc.cmd.Process.Kill()
+	}
+	rwc.Close()
+	rwc.cmd.Wait()
+}
+
+// maxStderrTailLines is the max number of the last stderr lines of the LSP server kept for errors
+const maxStderrTailLines = 50
+
+// stderrTail keeps the last stderr lines of the LSP server
+type stderrTail struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (s *stderrTail) add(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.lines) == maxStderrTailLines {
+		s.lines = s.lines[1:]
+	}
+	s.lines = append(s.lines, line)
+}
+
+func (s *stderrTail) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.lines, "\n")
+}
+
 // start a LSP process and return its io
-func startLSPSever(path string, opts ClientOptions) (io.ReadWriteCloser, error) {
+func startLSPSever(path string, opts ClientOptions) (rwc, error) {
 
 	var cmd *exec.Cmd
 	if uniast.Java == opts.Language {
@@ -251,28 +304,30 @@ func startLSPSever(path string, opts ClientOptions) (io.ReadWriteCloser, error)
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get stdin pipe: %v", err)
+		return rwc{}, fmt.Errorf("Failed to get stdin pipe: %v", err)
 	}
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get stdout pipe: %v", err)
+		return rwc{}, fmt.Errorf("Failed to get stdout pipe: %v", err)
 	}
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get stderr pipe: %v", err)
+		return rwc{}, fmt.Errorf("Failed to get stderr pipe: %v", err)
 	}
 	// Read stderr in a separate goroutine
+	tail := &stderrTail{}
 	go func() {
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
+			tail.add(scanner.Text())
 			log.Error("LSP server stderr: %s\n", scanner.Text())
 			// os.Exit(2)
 		}
 	}()
 
 	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("Failed to start LSP server: %v", err)
+		return rwc{}, fmt.Errorf("Failed to start LSP server: %v", err)
 	}
 
-	return rwc{stdout, stdin, cmd}, nil
+	return rwc{stdout, stdin, cmd, tail}, nil
 }
@@ -18,9 +18,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
 )
@@ -253,3 +256,26 @@ Output`
 		}
 	})
 }
+
+func TestNewLSPClient_InitTimeout(t *testing.T) {
+	// a server which never answers the initialization
+	server := filepath.Join(t.TempDir(), "hang-lsp")
+	if err := os.WriteFile(server, []byte("#!/bin/sh\necho 'fatal: workspace is locked' >&2\nexec sleep 30\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err := NewLSPClient(t.TempDir(), "", 0, ClientOptions{
+		Server:      server,
+		Language:    uniast.Rust,
+		InitTimeout: 500 * time.Millisecond,
+	})
+	if err == nil {
+		t.Fatal("expect an error when the server doesn't initialize")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("NewLSPClient returns after %v, want about the InitTimeout", elapsed)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "did not initialize in 500ms") || !strings.Contains(msg, "fatal: workspace is locked") {
+		t.Errorf("expect the error to tell the timeout and the server stderr, got: %v", err)
+	}
+}
@@ -38,8 +38,21 @@ type DidOpenTextDocumentParams struct {
 	TextDocument TextDocumentItem `json:"textDocument"`
 }
 
+func (cli *LSPClient) loadFile(uri DocumentURI) (*TextDocumentItem, bool) {
+	cli.filesMu.RLock()
+	defer cli.filesMu.RUnlock()
+	f, ok := cli.files[uri]
+	return f, ok
+}
+
+func (cli *LSPClient) storeFile(uri DocumentURI, f *TextDocumentItem) {
+	cli.filesMu.Lock()
+	defer cli.filesMu.Unlock()
+	cli.files[uri] = f
+}
+
 func (cli *LSPClient) DidOpen(ctx context.Context, file DocumentURI) (*TextDocumentItem, error) {
-	if f, ok := cli.files[file]; ok {
+	if f, ok := cli.loadFile(file); ok {
 		return f, nil
 	}
 	text, err := os.ReadFile(file.File())
@@ -53,7 +66,7 @@ func (cli *LSPClient) DidOpen(ctx context.Context, file DocumentURI) (*TextDocum
 		Text:       string(text),
 		LineCounts: utils.CountLines(string(text)),
 	}
-	cli.files[file] = f
+	cli.storeFile(file, f)
 	req := DidOpenTextDocumentParams{
 		TextDocument: *f,
 	}
@@ -286,7 +299,7 @@ func (cli *LSPClient) TypeDefinition(ctx context.Context, uri DocumentURI, pos P
 
 // read file and get the text of block of range
 func (cli *LSPClient) Locate(id Location) (string, error) {
-	f, ok := cli.files[id.URI]
+	f, ok := cli.loadFile(id.URI)
 	if !ok {
 		// open file os
 		fd, err := os.ReadFile(id.URI.File())
@@ -301,7 +314,7 @@ func (cli *LSPClient) Locate(id Location) (string, error) {
 			Text:       text,
 			LineCounts: utils.CountLines(text),
 		}
-		cli.files[id.URI] = f
+		cli.storeFile(id.URI, f)
 	}
 
 	text := f.Text
@@ -313,7 +326,7 @@ func (cli *LSPClient) Locate(id Location) (string, error) {
 
 // get line text of pos
 func (cli *LSPClient) Line(uri DocumentURI, pos int) string {
-	f, ok := cli.files[uri]
+	f, ok := cli.loadFile(uri)
 	if !ok {
 		// open file os
 		fd, err := os.ReadFile(uri.File())
@@ -328,7 +341,7 @@ func (cli *LSPClient) Line(uri DocumentURI, pos int) string {
 			Text:       text,
 			LineCounts: utils.CountLines(text),
 		}
-		cli.files[uri] = f
+		cli.storeFile(uri, f)
 	}
 	if pos < 0 || pos >= len(f.LineCounts) {
 		return ""
@@ -342,7 +355,7 @@ func (cli *LSPClient) Line(uri DocumentURI, pos int) string {
 }
 
 func (cli *LSPClient) LineCounts(uri DocumentURI) []int {
-	f, ok := cli.files[uri]
+	f, ok := cli.loadFile(uri)
 	if !ok {
 		// open file os
 		fd, err := os.ReadFile(uri.File())
@@ -357,20 +370,21 @@ func (cli *LSPClient) LineCounts(uri DocumentURI) []int {
 			Text:       text,
 			LineCounts: utils.CountLines(text),
 		}
-		cli.files[uri] = f
+		cli.storeFile(uri, f)
 	}
 	return f.LineCounts
 }
 
 func (cli *LSPClient) GetFile(uri DocumentURI) *TextDocumentItem {
-	return cli.files[uri]
+	f, _ := cli.loadFile(uri)
+	return f
 }
 
 func (cli *LSPClient) GetParent(sym *DocumentSymbol) (ret *DocumentSymbol) {
 	if sym == nil {
 		return nil
 	}
-	if f, ok := cli.files[sym.Location.URI]; ok {
+	if f, ok := cli.loadFile(sym.Location.URI); ok {
 		for _, s := range f.Symbols {
 			if s != sym && s.Location.Range.Include(sym.Location.Range) {
 				if ret == nil || ret.Location.Range.Include(s.Location.Range) {
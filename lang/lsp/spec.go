@@ -70,3 +70,9 @@ type LanguageSpec interface {
 	// some language may allow local symbols inside another symbol
 	ProtectedSymbolKinds() []SymbolKind
 }
+
+// ModuleDependencySpec is optionally implemented by a LanguageSpec whose module manifest declares third-party libraries
+type ModuleDependencySpec interface {
+	// return the third-party libraries [name=>version] of a module returned by WorkSpace()
+	ModuleDependencies(mod string) map[string]string
+}
@@ -28,7 +28,7 @@ import (
 	"github.com/cloudwego/abcoder/lang/collect"
 	"github.com/cloudwego/abcoder/lang/cxx"
 	"github.com/cloudwego/abcoder/lang/golang/parser"
-	"github.com/cloudwego/abcoder/lang/java"
+	javacollect "github.com/cloudwego/abcoder/lang/java/collect"
 	"github.com/cloudwego/abcoder/lang/log"
 	"github.com/cloudwego/abcoder/lang/lsp"
 	"github.com/cloudwego/abcoder/lang/python"
@@ -38,6 +38,9 @@ import (
 	"github.com/cloudwego/abcoder/version"
 )
 
+// DefaultLSPTimeout is the default max time to wait for the LSP server to initialize
+const DefaultLSPTimeout = 60 * time.Second
+
 // ParseOptions is the options for parsing the repo.
 type ParseOptions struct {
 	// LSP sever executable path
@@ -49,10 +52,19 @@ type ParseOptions struct {
 	RepoID string
 
 	LspOptions map[string]string
+	// LSPTimeout bounds the initialization of the LSP server, 0 means no timeout
+	LSPTimeout time.Duration
 
 	// TS options
 	// tsconfig string
 	TSParseOptions
+
+	// Stats, if not nil, is filled with the statistics of the parse run
+	Stats *ParseStats
+
+	// NoGraph skips building the dependency graph, the Graph of the repo is left empty.
+	// It's faster for large repos when only the package and file structure is needed.
+	NoGraph bool
 }
 
 type TSParseOptions struct {
@@ -63,10 +75,57 @@ type TSParseOptions struct {
 }
 
 func Parse(ctx context.Context, uri string, args ParseOptions) ([]byte, error) {
+	start := time.Now()
 	if !filepath.IsAbs(uri) {
 		uri, _ = filepath.Abs(uri)
 	}
-	l, lspPath, err := checkLSP(args.Language, args.LSP, args)
+	var repo *uniast.Repository
+	var err error
+	if args.Language == uniast.Java {
+		// jdtls is started by the java collector
+		repo, err = javacollect.NewCollector(uri, javacollect.Options{
+			LSP:           args.LSP,
+			LspOptions:    args.LspOptions,
+			LSPTimeout:    args.LSPTimeout,
+			Verbose:       args.Verbose,
+			NoGraph:       args.NoGraph,
+			CollectOption: args.CollectOption,
+		}).Collect(ctx)
+	} else {
+		repo, err = parseWithLSP(ctx, uri, args)
+	}
+	if err != nil {
+		log.Error("Failed to collect symbols: %v\n", err)
+		return nil, err
+	}
+	log.Info("all symbols collected, start writing to stdout...\n")
+
+	if args.RepoID != "" {
+		repo.Name = args.RepoID
+	}
+
+	repo.ASTVersion = uniast.Version
+	repo.ToolVersion = version.Version
+	if args.Stats != nil {
+		collectParseStats(args.Stats, uri, args.Language, repo)
+		args.Stats.Duration = time.Since(start)
+	}
+	if repo.Checksum, err = repo.ComputeChecksum(); err != nil {
+		log.Error("Failed to compute repository checksum: %v\n", err)
+		return nil, err
+	}
+
+	out, err := json.Marshal(repo)
+	if err != nil {
+		log.Error("Failed to marshal repository: %v\n", err)
+		return nil, err
+	}
+	return out, nil
+}
+
+// parseWithLSP collects the symbols of the repo with the LSP of its language, or with the parser for Go
+func parseWithLSP(ctx context.Context, uri string, args ParseOptions) (*uniast.Repository, error) {
+	l, lspPath, err := checkLSP(args.Language, args.LSP)
 	if err != nil {
 		return nil, err
 	}
@@ -86,6 +145,7 @@ func Parse(ctx context.Context, uri string, args ParseOptions) ([]byte, error) {
 			Language:              l,
 			Verbose:               args.Verbose,
 			InitializationOptions: args.LspOptions,
+			InitTimeout:           args.LSPTimeout,
 		})
 		if err != nil {
 			log.Error("failed to initialize LSP server: %v\n", err)
@@ -93,27 +153,7 @@ func Parse(ctx context.Context, uri string, args ParseOptions) ([]byte, error) {
 		}
 		log.Info("end initialize LSP server")
 	}
-
-	repo, err := collectSymbol(ctx, client, uri, args.CollectOption)
-	if err != nil {
-		log.Error("Failed to collect symbols: %v\n", err)
-		return nil, err
-	}
-	log.Info("all symbols collected, start writing to stdout...\n")
-
-	if args.RepoID != "" {
-		repo.Name = args.RepoID
-	}
-
-	repo.ASTVersion = uniast.Version
-	repo.ToolVersion = version.Version
-
-	out, err := json.Marshal(repo)
-	if err != nil {
-		log.Error("Failed to marshal repository: %v\n", err)
-		return nil, err
-	}
-	return out, nil
+	return collectSymbol(ctx, client, uri, args.CollectOption, args.NoGraph)
 }
 
 func checkRepoPath(repoPath string, language uniast.Language) (openfile string, wait time.Duration, err error) {
@@ -128,8 +168,6 @@ func checkRepoPath(repoPath string, language uniast.Language) (openfile string,
 		openfile, wait = cxx.CheckRepo(repoPath)
 	case uniast.Python:
 		openfile, wait = python.CheckRepo(repoPath)
-	case uniast.Java:
-		openfile, wait = java.CheckRepo(repoPath)
 	default:
 		openfile = ""
 		wait = 0
@@ -139,7 +177,7 @@ func checkRepoPath(repoPath string, language uniast.Language) (openfile string,
 	return
 }
 
-func checkLSP(language uniast.Language, lspPath string, args ParseOptions) (l uniast.Language, s string, err error) {
+func checkLSP(language uniast.Language, lspPath string) (l uniast.Language, s string, err error) {
 	if lspPath != "" {
 		// designated LSP
 		l = language
@@ -153,8 +191,6 @@ func checkLSP(language uniast.Language, lspPath string, args ParseOptions) (l un
 			l, s = cxx.GetDefaultLSP()
 		case uniast.Python:
 			l, s = python.GetDefaultLSP()
-		case uniast.Java:
-			l, s = java.GetDefaultLSP(args.LspOptions)
 		case uniast.Golang:
 			if _, err := exec.LookPath("go"); err != nil {
 				if _, err := os.Stat(lspPath); os.IsNotExist(err) {
@@ -170,7 +206,7 @@ func checkLSP(language uniast.Language, lspPath string, args ParseOptions) (l un
 	return l, s, nil
 }
 
-func collectSymbol(ctx context.Context, cli *lsp.LSPClient, repoPath string, opts collect.CollectOption) (repo *uniast.Repository, err error) {
+func collectSymbol(ctx context.Context, cli *lsp.LSPClient, repoPath string, opts collect.CollectOption, noGraph bool) (repo *uniast.Repository, err error) {
 	if opts.Language == uniast.Golang {
 		repo, err = callGoParser(ctx, repoPath, opts)
 		if err != nil {
@@ -192,6 +228,10 @@ func collectSymbol(ctx context.Context, cli *lsp.LSPClient, repoPath string, opt
 		}
 	}
 
+	if noGraph {
+		repo.Graph = uniast.NodeGraph{}
+		return repo, nil
+	}
 	if err := repo.BuildGraph(); err != nil {
 		return nil, err
 	}
@@ -199,6 +239,10 @@ func collectSymbol(ctx context.Context, cli *lsp.LSPClient, repoPath string, opt
 }
 
 func callGoParser(ctx context.Context, repoPath string, opts collect.CollectOption) (*uniast.Repository, error) {
+	if opts.FileConcurrency > 1 {
+		// the files of a package are parsed into the same package, one by one
+		return nil, fmt.Errorf("file concurrency %d is not supported for Go, use the package concurrency with load-by-packages instead", opts.FileConcurrency)
+	}
 	goopts := parser.Options{}
 	if opts.LoadExternalSymbol {
 		goopts.ReferCodeDepth = 1
@@ -212,7 +256,13 @@ func callGoParser(ctx context.Context, repoPath string, opts collect.CollectOpti
 	if opts.LoadByPackages {
 		goopts.LoadByPackages = true
 	}
+	goopts.TestFileSuffix = opts.TestFileSuffix
+	goopts.ModulePath = opts.ModulePath
+	goopts.ResolveGenerics = opts.ResolveGenerics
 	goopts.Excludes = opts.Excludes
+	goopts.Includes = opts.Includes
+	goopts.PackageConcurrency = opts.PackageConcurrency
+	goopts.PreserveDirectives = opts.PreserveDirectives
 	p := parser.NewParser(repoPath, repoPath, goopts)
 	repo, err := p.ParseRepo()
 	if err != nil {
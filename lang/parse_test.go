@@ -155,3 +155,64 @@ func TestParser_NodeFieldsConsistency(t *testing.T) {
 		checkRepoConsistency(t, lang, &repo, testCase)
 	}
 }
+
+func TestCollectParseStats(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"a.go", "a_test.go", "vendor/v/v.go", "README.md"} {
+		p := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("package a\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := uniast.NewRepository("r")
+	mod := uniast.NewModule("m", ".", uniast.Golang)
+	mod.Files["a.go"] = uniast.NewFile("a.go")
+	mod.Files["README.md"] = uniast.NewFile("README.md")
+	pkg := uniast.NewPackage("m/a")
+	pkg.Functions["F"] = &uniast.Function{}
+	pkg.Types["T"] = &uniast.Type{}
+	mod.Packages["m/a"] = pkg
+	repo.Modules["m"] = mod
+	ext := uniast.NewModule("ext", "", uniast.Golang)
+	extPkg := uniast.NewPackage("ext/x")
+	extPkg.Vars["V"] = &uniast.Var{}
+	ext.Packages["ext/x"] = extPkg
+	repo.Modules["ext"] = ext
+
+	var stats ParseStats
+	collectParseStats(&stats, dir, uniast.Golang, &repo)
+	if stats.FilesScanned != 3 || stats.FilesParsed != 1 || stats.FilesSkipped != 2 {
+		t.Errorf("files = %d/%d/%d, want 3/1/2", stats.FilesScanned, stats.FilesParsed, stats.FilesSkipped)
+	}
+	if stats.Types != 1 || stats.Functions != 1 || stats.Vars != 0 || stats.ExternalSymbols != 1 {
+		t.Errorf("nodes = %d/%d/%d/%d, want 1/1/0/1", stats.Types, stats.Functions, stats.Vars, stats.ExternalSymbols)
+	}
+	if !strings.Contains(stats.String(), "files skipped:    2") {
+		t.Errorf("unexpected String(): %s", stats.String())
+	}
+}
+
+func TestCallGoParser_FileConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/a\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\nfunc F() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := callGoParser(context.Background(), dir, collect.CollectOption{FileConcurrency: 4}); err == nil || !strings.Contains(err.Error(), "not supported for Go") {
+		t.Errorf("callGoParser() with FileConcurrency = 4, error = %v, want not supported", err)
+	}
+	repo, err := callGoParser(context.Background(), dir, collect.CollectOption{FileConcurrency: 1})
+	if err != nil {
+		t.Fatalf("callGoParser() with FileConcurrency = 1, error = %v", err)
+	}
+	if mod := repo.Modules["example.com/a"]; mod == nil || mod.Packages["example.com/a"] == nil || mod.Packages["example.com/a"].Functions["F"] == nil {
+		t.Errorf("function F should be parsed, modules = %v", repo.Modules)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+import "strings"
+
+// DocString returns the text of the docstring of a def or class, which is the first
+// triple-quoted string of its body, or an empty string if the body doesn't start with one.
+func DocString(content string) string {
+	lines := strings.Split(content, "\n")
+	// skip decorators and the header, which ends with ':'
+	start := 0
+	for start < len(lines) && !strings.HasSuffix(strings.TrimSpace(stripLineComment(lines[start])), ":") {
+		start++
+	}
+	if start >= len(lines) {
+		return ""
+	}
+	body := strings.TrimSpace(strings.Join(lines[start+1:], "\n"))
+	// string prefixes, ex: r"""raw"""
+	body = strings.TrimLeft(body, "rRuU")
+	if len(body) < 6 || (!strings.HasPrefix(body, `"""`) && !strings.HasPrefix(body, "'''")) {
+		return ""
+	}
+	quote := body[:3]
+	end := strings.Index(body[3:], quote)
+	if end < 0 {
+		return ""
+	}
+	return cleanDocString(body[3 : 3+end])
+}
+
+// stripLineComment removes the trailing `# comment` of a line
+func stripLineComment(line string) string {
+	if i := strings.Index(line, "#"); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// cleanDocString removes the common indentation of the docstring lines after the first one, like inspect.cleandoc
+func cleanDocString(doc string) string {
+	lines := strings.Split(doc, "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := len(line) - len(strings.TrimLeft(line, " \t")); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	lines[0] = strings.TrimSpace(lines[0])
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			lines[i] = ""
+		} else if indent > 0 {
+			lines[i] = strings.TrimRight(lines[i][indent:], " \t")
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
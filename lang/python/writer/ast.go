@@ -34,7 +34,7 @@ func writeImport(sb *strings.Builder, impts []uniast.Import) {
 	localImports := []uniast.Import{}
 
 	for _, imp := range impts {
-		path := strings.TrimSpace(imp.Path)
+		path := importModule(imp.Path)
 		// Check if it's a standard library import
 		if isStdLibImport(path) {
 			stdlibImports = append(stdlibImports, imp)
@@ -86,10 +86,43 @@ func writeSingleImport(sb *strings.Builder, v uniast.Import) {
 		// Otherwise, treat as module name
 		sb.WriteString("import ")
 		sb.WriteString(path)
+		if v.Alias != nil && *v.Alias != "" {
+			sb.WriteString(" as ")
+			sb.WriteString(*v.Alias)
+		}
 		sb.WriteString("\n")
 	}
 }
 
+// dependencyImports returns the `from <module> import <name>` imports of the dependencies out of pkg
+func dependencyImports(deps []uniast.Relation, pkg string) []uniast.Import {
+	var ret []uniast.Import
+	for _, v := range deps {
+		if v.PkgPath == "" || v.PkgPath == pkg {
+			continue
+		}
+		// methods are reached from their class
+		name, _, _ := strings.Cut(v.Name, ".")
+		module := strings.ReplaceAll(v.PkgPath, "/", ".")
+		ret = append(ret, uniast.Import{Path: "from " + module + " import " + name})
+	}
+	return ret
+}
+
+// importModule returns the imported module of an import path, which may be a full import statement
+func importModule(path string) string {
+	path = strings.TrimSpace(path)
+	if rest, ok := strings.CutPrefix(path, "from "); ok {
+		path = strings.TrimSpace(rest)
+	} else if rest, ok := strings.CutPrefix(path, "import "); ok {
+		path = strings.TrimSpace(rest)
+	}
+	if i := strings.IndexAny(path, " ,"); i > 0 {
+		path = path[:i]
+	}
+	return path
+}
+
 func sortImports(impts []uniast.Import) {
 	sort.Slice(impts, func(i, j int) bool {
 		return impts[i].Path < impts[j].Path
@@ -125,6 +158,7 @@ func isStdLibImport(path string) bool {
 	}
 
 	// Extract module name (first part before dot)
+	path = importModule(path)
 	moduleName := path
 	if idx := strings.Index(path, "."); idx > 0 {
 		moduleName = path[:idx]
@@ -0,0 +1,158 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package writer
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+const indent = "    "
+
+// shortName returns the last part of a dotted name, e.g. Foo.bar => bar
+func shortName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// snakeCase converts a type name to a field name, e.g. UserInfo => user_info
+func snakeCase(name string) string {
+	var sb strings.Builder
+	rs := []rune(name)
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// renderType renders a type without content as a class,
+// whose __init__ takes one annotated argument for each field type
+func renderType(t *uniast.Type) string {
+	var sb strings.Builder
+	sb.WriteString("class ")
+	sb.WriteString(shortName(t.Name))
+	if len(t.InlineStruct) > 0 {
+		bases := make([]string, 0, len(t.InlineStruct))
+		for _, b := range t.InlineStruct {
+			bases = append(bases, shortName(b.Name))
+		}
+		sb.WriteString("(" + strings.Join(bases, ", ") + ")")
+	}
+	sb.WriteString(":\n")
+
+	if t.TypeKind == uniast.TypeKindInterface || len(t.SubStruct) == 0 {
+		sb.WriteString(indent + "pass")
+		return sb.String()
+	}
+	seen := make(map[string]int, len(t.SubStruct))
+	params := []string{"self"}
+	var body []string
+	for _, f := range t.SubStruct {
+		typ := shortName(f.Name)
+		name := snakeCase(typ)
+		if seen[name]++; seen[name] > 1 {
+			name += strconv.Itoa(seen[name])
+		}
+		params = append(params, name+": "+typ)
+		body = append(body, indent+indent+"self."+name+" = "+name)
+	}
+	sb.WriteString(indent + "def __init__(" + strings.Join(params, ", ") + ") -> None:\n")
+	sb.WriteString(strings.Join(body, "\n"))
+	return sb.String()
+}
+
+// renderFunction renders a function without content as a def raising NotImplementedError,
+// using its signature if any, otherwise its parameter and result types
+func renderFunction(f *uniast.Function) string {
+	sig := strings.TrimSpace(f.Signature)
+	if strings.HasPrefix(sig, "def ") || strings.HasPrefix(sig, "async def ") {
+		return strings.TrimSuffix(sig, ":") + ":\n" + indent + "raise NotImplementedError"
+	}
+
+	var params []string
+	if f.IsMethod {
+		params = append(params, "self")
+	}
+	for i, p := range f.Params {
+		params = append(params, "arg"+strconv.Itoa(i)+": "+shortName(p.Name))
+	}
+	ret := "None"
+	if len(f.Results) == 1 {
+		ret = shortName(f.Results[0].Name)
+	} else if len(f.Results) > 1 {
+		rs := make([]string, 0, len(f.Results))
+		for _, r := range f.Results {
+			rs = append(rs, shortName(r.Name))
+		}
+		ret = "tuple[" + strings.Join(rs, ", ") + "]"
+	}
+	return "def " + shortName(f.Name) + "(" + strings.Join(params, ", ") + ") -> " + ret + ":\n" + indent + "raise NotImplementedError"
+}
+
+// renderVar renders a var without content as a module-level assignment, annotated with its type if known
+func renderVar(v *uniast.Var) string {
+	if v.Type != nil {
+		return shortName(v.Name) + ": " + shortName(v.Type.Name) + " = None"
+	}
+	return shortName(v.Name) + " = None"
+}
+
+// indentMethod indents the content of a method to put it into a class body.
+// The content of a parsed method lacks the indentation of its first line only,
+// which is recovered from the indentation of the following lines.
+// A leading decorator or comment line is at the same indentation as the def line.
+func indentMethod(src string) string {
+	lines := strings.Split(strings.TrimRight(src, "\n"), "\n")
+	first := strings.TrimLeft(lines[0], " \t")
+	// the indentation of the def line in the source
+	def := -1
+	for _, l := range lines[1:] {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if n := len(l) - len(strings.TrimLeft(l, " ")); def < 0 || n < def {
+			def = n
+		}
+	}
+	if def >= 0 && !strings.HasPrefix(first, "@") && !strings.HasPrefix(first, "#") {
+		// lines following a def are its body
+		def -= len(indent)
+	}
+	if def < 0 {
+		def = 0
+	}
+
+	var sb strings.Builder
+	sb.WriteString(indent + first)
+	for _, l := range lines[1:] {
+		sb.WriteString("\n")
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " "))
+		sb.WriteString(indent + strings.Repeat(" ", max(n-def, 0)) + l[n:])
+	}
+	return sb.String()
+}
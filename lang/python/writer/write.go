@@ -34,6 +34,11 @@ var _ uniast.Writer = (*Writer)(nil)
 
 type Options struct {
 	CompilerPath string
+	// FileFilter is called with each source file path (relative to the output dir) before writing;
+	// returning false skips the file. nil writes all files.
+	FileFilter func(filePath string) bool
+	// Format formats the written files with black, if it is on PATH
+	Format bool
 }
 
 type Writer struct {
@@ -42,6 +47,7 @@ type Writer struct {
 }
 
 type fileNode struct {
+	path   string // path of the source file relative to the repo, empty if unknown
 	chunks []chunk
 	impts  []uniast.Import
 }
@@ -101,12 +107,13 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 
 			// Merge imports
 			var fimpts []uniast.Import
-			if fi, ok := mod.Files[filepath.Join(mod.Dir, filename)]; ok && fi.Imports != nil {
+			if fi := mod.Files[f.path]; fi != nil && fi.Imports != nil {
 				fimpts = fi.Imports
 			}
 			impts := mergeImports(fimpts, f.impts)
 			if len(impts) > 0 {
 				writeImport(&sb, impts)
+				sb.WriteString("\n\n")
 			}
 
 			// Sort chunks by line number
@@ -125,6 +132,9 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 				filename = filename + ".py"
 			}
 			fpath := filepath.Join(pkgDir, filename)
+			if !utils.ShouldWriteFile(w.FileFilter, outDir, fpath) {
+				continue
+			}
 
 			if err := os.WriteFile(fpath, []byte(sb.String()), 0644); err != nil {
 				return fmt.Errorf("write file %s failed: %v", fpath, err)
@@ -137,35 +147,111 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 		log.Error("generate pyproject.toml failed: %v", err)
 	}
 
+	// format with black if available, like gofmt for Go
+	if w.Format {
+		if black, err := exec.LookPath("black"); err == nil {
+			cmd := exec.Command(black, "--quiet", ".")
+			cmd.Dir = outdir
+			if err := cmd.Run(); err != nil {
+				log.Info("black formatting skipped: %v", err)
+			}
+		}
+	}
+
 	return nil
 }
 
 func (w *Writer) appendPackage(repo *uniast.Repository, pkg *uniast.Package) error {
 	for _, v := range pkg.Vars {
 		n := repo.GetNode(v.Identity)
-		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, v.File, v.Line, v.Content); err != nil {
+		src := v.Content
+		if strings.TrimSpace(src) == "" {
+			src = renderVar(v)
+		}
+		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, v.File, v.Line, src); err != nil {
 			return fmt.Errorf("append chunk for var %s failed: %v", v.Name, err)
 		}
 	}
+
+	// methods are written in the class body of their receiver
+	methods := make(map[string][]*uniast.Function)
 	for _, f := range pkg.Functions {
 		if f.IsInterfaceMethod {
 			continue
 		}
+		if cls := receiverName(f); cls != "" && pkg.Types[cls] != nil {
+			methods[cls] = append(methods[cls], f)
+			continue
+		}
 		n := repo.GetNode(f.Identity)
-		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, f.File, f.Line, f.Content); err != nil {
+		src := f.Content
+		if strings.TrimSpace(src) == "" {
+			src = renderFunction(f)
+		}
+		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, f.File, f.Line, src); err != nil {
 			return fmt.Errorf("append chunk for function %s failed: %v", f.Name, err)
 		}
 	}
-	for _, t := range pkg.Types {
+	for name, t := range pkg.Types {
 		n := repo.GetNode(t.Identity)
-		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, t.File, t.Line, t.Content); err != nil {
+		src := t.Content
+		if strings.TrimSpace(src) == "" {
+			src = renderType(t)
+		}
+		src, extra := w.addMethods(repo, src, methods[name])
+		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, t.File, t.Line, src, extra...); err != nil {
 			return fmt.Errorf("append chunk for type %s failed: %v", t.Name, err)
 		}
 	}
 	return nil
 }
 
-func (w *Writer) appendNode(node *uniast.Node, pkg string, isMain bool, file string, line int, src string) error {
+// addMethods appends the methods missing in the class content to its body,
+// and returns the imports of the methods, including the ones of their dependencies
+func (w *Writer) addMethods(repo *uniast.Repository, src string, methods []*uniast.Function) (string, []uniast.Import) {
+	sort.SliceStable(methods, func(i, j int) bool {
+		return methods[i].Line < methods[j].Line
+	})
+	var impts []uniast.Import
+	for _, m := range methods {
+		// parsed classes already hold their methods
+		if strings.Contains(src, "def "+shortName(m.Name)+"(") {
+			continue
+		}
+		msrc := m.Content
+		if strings.TrimSpace(msrc) == "" {
+			msrc = renderFunction(m)
+		}
+		if cs, mimpts, err := w.SplitImportsAndCodes(msrc); err == nil {
+			msrc = cs
+			impts = append(impts, mimpts...)
+		}
+		if n := repo.GetNode(m.Identity); n != nil {
+			impts = append(impts, dependencyImports(n.Dependencies, m.PkgPath)...)
+		}
+		body := strings.TrimRight(src, "\n")
+		if strings.HasSuffix(body, "\n"+indent+"pass") {
+			body = strings.TrimSuffix(body, indent+"pass")
+		} else {
+			body += "\n\n"
+		}
+		src = body + indentMethod(msrc)
+	}
+	return src, impts
+}
+
+// receiverName returns the name of the class a method belongs to, empty if f is not a method
+func receiverName(f *uniast.Function) string {
+	if f.Receiver != nil {
+		return f.Receiver.Type.Name
+	}
+	if i := strings.LastIndex(f.Name, "."); f.IsMethod && i > 0 {
+		return f.Name[:i]
+	}
+	return ""
+}
+
+func (w *Writer) appendNode(node *uniast.Node, pkg string, isMain bool, file string, line int, src string, impts ...uniast.Import) error {
 	module := pkg
 	if module == "" {
 		module = "main"
@@ -199,16 +285,15 @@ func (w *Writer) appendNode(node *uniast.Node, pkg string, isMain bool, file str
 		}
 		m[filename] = fs
 	}
+	if fs.path == "" {
+		fs.path = file
+	}
 
 	// Collect dependencies as imports
-	for _, v := range node.Dependencies {
-		if v.PkgPath == "" || v.PkgPath == pkg {
-			continue
-		}
-		// Convert to Python import format
-		importPath := strings.ReplaceAll(v.PkgPath, "/", ".")
-		fs.impts = append(fs.impts, uniast.Import{Path: importPath})
+	if node != nil {
+		fs.impts = append(fs.impts, dependencyImports(node.Dependencies, pkg)...)
 	}
+	fs.impts = append(fs.impts, impts...)
 
 	// Extract imports from source code
 	if cs, impts, err := w.SplitImportsAndCodes(src); err == nil {
@@ -347,44 +432,50 @@ func (w *Writer) CreateFile(fi *uniast.File, mod *uniast.Module) ([]byte, error)
 	return []byte(sb.String()), nil
 }
 
+// generatePyProjectToml writes the pyproject.toml of the module, in the same layout as the translate ConfigGenerator
 func (w *Writer) generatePyProjectToml(mod *uniast.Module, outdir string) error {
+	name := strings.ToLower(strings.NewReplacer("-", "_", "/", "_").Replace(mod.Name))
+	if name == "" {
+		name = "translated"
+	}
+
+	deps := make(map[string]bool, len(mod.Dependencies)+len(mod.ExternalDependencies))
+	for name, dep := range mod.Dependencies {
+		depParts := strings.Split(dep, "@")
+		if len(depParts) >= 2 && depParts[1] != "" {
+			name = name + "==" + depParts[1]
+		}
+		deps[name] = true
+	}
+	for name, version := range mod.ExternalDependencies {
+		if version != "" && !strings.ContainsAny(version, "<>=!~") {
+			version = "==" + version
+		}
+		deps[name+version] = true
+	}
+	sorted := make([]string, 0, len(deps))
+	for dep := range deps {
+		sorted = append(sorted, dep)
+	}
+	sort.Strings(sorted)
+
 	var sb strings.Builder
 	sb.WriteString("[build-system]\n")
 	sb.WriteString("requires = [\"setuptools>=61.0\"]\n")
 	sb.WriteString("build-backend = \"setuptools.build_meta\"\n\n")
 	sb.WriteString("[project]\n")
-	sb.WriteString("name = \"")
-	sb.WriteString(mod.Name)
-	sb.WriteString("\"\n")
-	sb.WriteString("version = \"1.0.0\"\n")
-	sb.WriteString("description = \"Generated project\"\n\n")
-
-	if len(mod.Dependencies) > 0 {
-		sb.WriteString("dependencies = [\n")
-		for name, dep := range mod.Dependencies {
-			depParts := strings.Split(dep, "@")
-			depName := name
-			if len(depParts) >= 2 && depParts[1] != "" {
-				depName = name + "==" + depParts[1]
-			}
-			sb.WriteString("    \"")
-			sb.WriteString(depName)
-			sb.WriteString("\",\n")
-		}
-		sb.WriteString("]\n\n")
+	sb.WriteString("name = \"" + name + "\"\n")
+	sb.WriteString("version = \"0.1.0\"\n")
+	sb.WriteString("requires-python = \">=3.8\"\n")
+	sb.WriteString("dependencies = [\n")
+	for _, dep := range sorted {
+		sb.WriteString("    \"" + dep + "\",\n")
 	}
+	sb.WriteString("]\n")
 
 	tomlPath := filepath.Join(outdir, "pyproject.toml")
 	if err := os.WriteFile(tomlPath, []byte(sb.String()), 0644); err != nil {
 		return fmt.Errorf("write pyproject.toml failed: %v", err)
 	}
-
-	// Try to format with black if available
-	cmd := exec.Command("black", ".")
-	cmd.Dir = outdir
-	if err := cmd.Run(); err != nil {
-		log.Error("black formatting failed: %v", err)
-	}
-
 	return nil
 }
@@ -17,6 +17,10 @@
 package writer
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -96,3 +100,114 @@ func TestWriter_IdToImport(t *testing.T) {
 		})
 	}
 }
+
+func TestWriter_WriteModule_Render(t *testing.T) {
+	repo := uniast.NewRepository("shop")
+	mod := uniast.NewModule("shop", ".", uniast.Python)
+	repo.Modules["shop"] = mod
+	models, svc := uniast.NewPackage("shop.models"), uniast.NewPackage("shop.service")
+	mod.Packages[models.PkgPath], mod.Packages[svc.PkgPath] = models, svc
+	id := func(pkg *uniast.Package, name string) uniast.Identity {
+		return uniast.NewIdentity("shop", pkg.PkgPath, name)
+	}
+	fl := func(file string, line int) uniast.FileLine { return uniast.FileLine{File: file, Line: line} }
+
+	models.Types["User"] = &uniast.Type{Identity: id(models, "User"), FileLine: fl("shop/models.py", 1), Content: "class User:\n    def __init__(self, name: str):\n        self.name = name\n\n    def hello(self) -> str:\n        return self.name"}
+	// a method already in its parsed class
+	models.Functions["User.hello"] = &uniast.Function{Identity: id(models, "User.hello"), IsMethod: true, FileLine: fl("shop/models.py", 5), Content: "def hello(self) -> str:\n        return self.name"}
+
+	// nodes without content are rendered
+	svc.Types["Cart"] = &uniast.Type{Identity: id(svc, "Cart"), FileLine: fl("shop/service.py", 1), SubStruct: []uniast.Dependency{{Identity: id(models, "User")}}}
+	svc.Functions["Cart.total"] = &uniast.Function{Identity: id(svc, "Cart.total"), IsMethod: true, FileLine: fl("shop/service.py", 5), Content: "def total(self) -> int:\n        if self.user:\n            return 1\n        return 0"}
+	svc.Functions["Cart.clear"] = &uniast.Function{Identity: id(svc, "Cart.clear"), IsMethod: true, FileLine: fl("shop/service.py", 9), Results: []uniast.Dependency{{Identity: uniast.NewIdentity("", "", "bool")}}}
+	svc.Functions["checkout"] = &uniast.Function{Identity: id(svc, "checkout"), FileLine: fl("shop/service.py", 12), Signature: "def checkout(cart: Cart) -> bool"}
+	svc.Vars["LIMIT"] = &uniast.Var{Identity: id(svc, "LIMIT"), FileLine: fl("shop/service.py", 15), Type: &uniast.Identity{Name: "int"}}
+	repo.BuildGraph()
+
+	dir := t.TempDir()
+	// not formatted by black, the output is compared as is
+	if err := NewWriter(Options{Format: false}).WriteModule(&repo, "shop", dir); err != nil {
+		t.Fatalf("WriteModule() error = %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "shop", "service", "service.py"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "from shop.models import User\n\n\n" +
+		"class Cart:\n    def __init__(self, user: User) -> None:\n        self.user = user\n\n" +
+		"    def total(self) -> int:\n        if self.user:\n            return 1\n        return 0\n\n" +
+		"    def clear(self) -> bool:\n        raise NotImplementedError\n\n" +
+		"def checkout(cart: Cart) -> bool:\n    raise NotImplementedError\n\n" +
+		"LIMIT: int = None\n\n"
+	if string(got) != want {
+		t.Errorf("service.py =\n%s\nwant\n%s", got, want)
+	}
+	got, err = os.ReadFile(filepath.Join(dir, "shop", "models", "models.py"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(got), "def hello(") != 1 {
+		t.Errorf("the method in the parsed class should be written once:\n%s", got)
+	}
+
+	toml, err := os.ReadFile(filepath.Join(dir, "pyproject.toml"))
+	if err != nil || !strings.Contains(string(toml), "name = \"shop\"") {
+		t.Errorf("pyproject.toml = %s, %v", toml, err)
+	}
+
+	if python, err := exec.LookPath("python3"); err == nil {
+		if out, err := exec.Command(python, "-m", "py_compile", filepath.Join(dir, "shop", "service", "service.py")).CombinedOutput(); err != nil {
+			t.Errorf("service.py is not valid python: %v\n%s", err, out)
+		}
+	}
+}
+
+func TestWriter_WriteModule_Black(t *testing.T) {
+	if _, err := exec.LookPath("black"); err != nil {
+		t.Skip("black is not installed")
+	}
+	repo := uniast.NewRepository("app")
+	mod := uniast.NewModule("app", ".", uniast.Python)
+	repo.Modules["app"] = mod
+	pkg := uniast.NewPackage("app")
+	mod.Packages[pkg.PkgPath] = pkg
+	pkg.Functions["add"] = &uniast.Function{
+		Identity: uniast.NewIdentity("app", pkg.PkgPath, "add"),
+		FileLine: uniast.FileLine{File: "app/app.py", Line: 1},
+		Content:  "def add(a,b):\n    return a+b",
+	}
+	repo.BuildGraph()
+
+	dir := t.TempDir()
+	if err := NewWriter(Options{Format: true}).WriteModule(&repo, "app", dir); err != nil {
+		t.Fatalf("WriteModule() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "app", "app.py"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "def add(a, b):\n    return a + b\n"; string(got) != want {
+		t.Errorf("app.py =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func Test_indentMethod(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"def", "def f(self):\n        return 1", "    def f(self):\n        return 1"},
+		{"decorator", "@property\n    def f(self):\n        return 1", "    @property\n    def f(self):\n        return 1"},
+		{"comment", "# Original java: int f() {\n    def f(self):\n        return 1", "    # Original java: int f() {\n    def f(self):\n        return 1"},
+		{"unindented", "# Original java: int f() {\n\ndef f(self):\n    return 1", "    # Original java: int f() {\n\n    def f(self):\n        return 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := indentMethod(tt.src); got != tt.want {
+				t.Errorf("indentMethod() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ package rust
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/abcoder/lang/testutils"
@@ -83,3 +84,32 @@ func TestRustSpec_NameSpaceInternal(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCargoDependencies(t *testing.T) {
+	cargo := `[package]
+name = "demo"
+version = "0.1.0"
+
+[dependencies]
+serde = "1.0" # serialization
+tokio = { version = "1", features = ["full"] }
+local-lib = { path = "../local-lib" }
+
+[dev-dependencies]
+criterion = "0.5"
+
+[dependencies.reqwest]
+version = "0.12"
+features = ["json"]
+`
+	got := parseCargoDependencies(strings.Split(cargo, "\n"))
+	want := map[string]string{
+		"serde":     "1.0",
+		"tokio":     "1",
+		"local-lib": "*",
+		"reqwest":   "0.12",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCargoDependencies() = %v, want %v", got, want)
+	}
+}
@@ -39,8 +39,9 @@ func (c *RustSpec) ProtectedSymbolKinds() []lsp.SymbolKind {
 }
 
 type Module struct {
-	Name string
-	Path string
+	Name         string
+	Path         string
+	Dependencies map[string]string // crate name => version in [dependencies]
 }
 
 func NewRustSpec() *RustSpec {
@@ -454,8 +455,9 @@ func (c *RustSpec) collect(i *int, lines []string, path string, rets map[string]
 			// }
 			// TODO: duplicate append
 			c.crates = append(c.crates, Module{
-				Name: m[1],
-				Path: dir,
+				Name:         m[1],
+				Path:         dir,
+				Dependencies: parseCargoDependencies(lines),
 			})
 			rets[m[1]] = dir
 			*i = j
@@ -469,6 +471,66 @@ func (c *RustSpec) collect(i *int, lines []string, path string, rets map[string]
 	return nil
 }
 
+// implement lsp.ModuleDependencySpec
+func (c *RustSpec) ModuleDependencies(mod string) map[string]string {
+	for _, crate := range c.crates {
+		if crate.Name == mod {
+			return crate.Dependencies
+		}
+	}
+	return nil
+}
+
+var (
+	cargoKeyRegex     = regexp.MustCompile(`^([\w-]+)\s*=\s*(.+)$`)
+	cargoStringRegex  = regexp.MustCompile(`^"([^"]*)"`)
+	cargoVersionRegex = regexp.MustCompile(`version\s*=\s*"([^"]*)"`)
+)
+
+// parseCargoDependencies parses the [dependencies] table of Cargo.toml lines, supporting
+// `name = "1.0"`, `name = { version = "1.0", ... }` and `[dependencies.name]` forms.
+// Dependencies without a version (path or git) are recorded as "*".
+func parseCargoDependencies(lines []string) map[string]string {
+	deps := map[string]string{}
+	section := ""
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			if name, ok := strings.CutPrefix(section, "dependencies."); ok {
+				deps[name] = "*"
+			}
+			continue
+		}
+		if name, ok := strings.CutPrefix(section, "dependencies."); ok {
+			if m := cargoKeyRegex.FindStringSubmatch(line); m != nil && m[1] == "version" {
+				if v := cargoStringRegex.FindStringSubmatch(m[2]); v != nil {
+					deps[name] = v[1]
+				}
+			}
+			continue
+		}
+		if section != "dependencies" {
+			continue
+		}
+		m := cargoKeyRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if v := cargoStringRegex.FindStringSubmatch(m[2]); v != nil {
+			deps[m[1]] = v[1]
+		} else if v := cargoVersionRegex.FindStringSubmatch(m[2]); v != nil {
+			deps[m[1]] = v[1]
+		} else {
+			deps[m[1]] = "*"
+		}
+	}
+	return deps
+}
+
 func (c *RustSpec) GetUnloadedSymbol(from lsp.Token, loc lsp.Location) (string, error) {
 	// TODO: may need handle more cases
 	return ExtractLazyStaticeSymbol(loc)
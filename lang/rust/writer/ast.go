@@ -17,7 +17,9 @@
 package writer
 
 import (
+	"regexp"
 	"strings"
+	"unicode"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
 	rustast "github.com/cloudwego/abcoder/lang/rust"
@@ -94,3 +96,81 @@ func mergeImports(priors []uniast.Import, subs []uniast.Import) (ret []uniast.Im
 
 	return ret
 }
+
+// rustModPath returns the path of the Rust module of the package pkgPath in the crate modName,
+// e.g. my_crate::model::user or my_crate/model/user -> model::user, and an empty path for the crate root.
+// The crate name, crate and src prefixes are dropped, and each part is made a valid module name.
+func rustModPath(modName string, pkgPath string) string {
+	parts := strings.FieldsFunc(pkgPath, func(r rune) bool { return r == ':' || r == '/' })
+	for len(parts) > 0 && (parts[0] == modName || parts[0] == crateName(modName) || parts[0] == "crate") {
+		parts = parts[1:]
+	}
+	if len(parts) > 0 && parts[0] == "src" {
+		parts = parts[1:]
+	}
+	for i, part := range parts {
+		parts[i] = rustModName(part)
+	}
+	return strings.Join(parts, "::")
+}
+
+// splitModPath splits a Rust module path into its module names
+func splitModPath(modPath string) []string {
+	if modPath == "" {
+		return nil
+	}
+	return strings.Split(modPath, "::")
+}
+
+// rustModName converts name to a snake_case Rust module name, e.g. user-service -> user_service
+func rustModName(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	mod := sb.String()
+	if mod == "" || unicode.IsDigit(rune(mod[0])) {
+		mod = "_" + mod
+	}
+	return mod
+}
+
+// crateName returns the name of the crate of the module modName, without version
+func crateName(modName string) string {
+	if i := strings.LastIndex(modName, "@"); i > 0 {
+		modName = modName[:i]
+	}
+	return strings.ReplaceAll(modName, "-", "_")
+}
+
+// isRustIdent tells if name is a plain identifier which can be imported, unlike a method Type.method
+func isRustIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// structDefRegex matches the line defining a struct, after its doc comments and attributes
+var structDefRegex = regexp.MustCompile(`(?m)^[ \t]*(pub(\([^)]*\))?[ \t]+)?struct[ \t]`)
+
+// deriveStruct adds #[derive(Debug, Clone)] to the struct defined by content, unless it already derives some traits
+func deriveStruct(content string) string {
+	if strings.Contains(content, "#[derive(") {
+		return content
+	}
+	loc := structDefRegex.FindStringIndex(content)
+	if loc == nil {
+		return content
+	}
+	return content[:loc[0]] + "#[derive(Debug, Clone)]\n" + content[loc[0]:]
+}
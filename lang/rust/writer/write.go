@@ -21,6 +21,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -34,11 +35,15 @@ var _ uniast.Writer = (*Writer)(nil)
 
 type Options struct {
 	CompilerPath string
+	// FileFilter is called with each source file path (relative to the output dir) before writing;
+	// returning false skips the file. nil writes all files.
+	FileFilter func(filePath string) bool
 }
 
 type Writer struct {
 	Options
-	visited map[string]map[string]*fileNode // crate -> mod_path -> fileNode
+	visited map[string]*fileNode // Rust module path (e.g. model::user, empty for the crate root) -> fileNode
+	mains   map[string]bool      // Rust module paths of the main packages
 }
 
 type fileNode struct {
@@ -57,26 +62,33 @@ func NewWriter(opts Options) *Writer {
 	}
 	return &Writer{
 		Options: opts,
-		visited: make(map[string]map[string]*fileNode),
+		visited: make(map[string]*fileNode),
+		mains:   make(map[string]bool),
 	}
 }
 
+// WriteModule writes the packages of the module as a cargo crate under outDir/mod.Dir:
+// the root package into src/lib.rs (src/main.rs if it is a main package) and each other package
+// into src/<module path>/mod.rs, every module being declared by `pub mod` in its parent.
+// An existing Cargo.toml (e.g. generated by translate) is kept, only the missing dependencies are added to it.
 func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir string) error {
 	mod := repo.Modules[modPath]
 	if mod == nil {
 		return fmt.Errorf("module %s not found", modPath)
 	}
+	w.visited = make(map[string]*fileNode)
+	w.mains = make(map[string]bool)
 
 	// Collect all packages
 	for _, pkg := range mod.Packages {
-		if err := w.appendPackage(repo, pkg); err != nil {
+		if err := w.appendPackage(repo, mod, pkg); err != nil {
 			return fmt.Errorf("write package %s failed: %v", pkg.PkgPath, err)
 		}
 	}
 
 	// Write files
 	outdir := filepath.Join(outDir, mod.Dir)
-	crateName := mod.Name
+	crateName := crateName(mod.Name)
 
 	// Create src/ directory structure
 	srcDir := filepath.Join(outdir, "src")
@@ -84,64 +96,85 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 		return fmt.Errorf("mkdir %s failed: %v", srcDir, err)
 	}
 
-	// Group files by module path
-	modFiles := make(map[string][]*fileInfo)
-	for modPath, mod := range w.visited {
-		for filename, f := range mod {
-			modFiles[modPath] = append(modFiles[modPath], &fileInfo{
-				name:   filename,
-				fileNode: f,
-			})
+	// Every parent of a module must exist to declare it
+	children := make(map[string][]string) // module path -> names of its sub modules
+	modPaths := []string{""}
+	seen := map[string]bool{"": true}
+	for modPath := range w.visited {
+		parts := splitModPath(modPath)
+		for i := range parts {
+			path := strings.Join(parts[:i+1], "::")
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			modPaths = append(modPaths, path)
+			parent := strings.Join(parts[:i], "::")
+			children[parent] = append(children[parent], parts[i])
 		}
 	}
+	sort.Strings(modPaths)
 
 	// Write module files
-	for modPath, files := range modFiles {
+	var written []string
+	for _, modPath := range modPaths {
 		var filePath string
-		if modPath == "" || modPath == crateName {
-			// Root module -> lib.rs
+		if modPath == "" {
+			// Root module -> lib.rs, or main.rs for a binary crate
 			filePath = filepath.Join(srcDir, "lib.rs")
+			if w.mains[""] {
+				filePath = filepath.Join(srcDir, "main.rs")
+			}
 		} else {
-			// Submodule -> mod/mod.rs or mod.rs
-			modDir := filepath.Join(srcDir, strings.ReplaceAll(modPath, "::", string(filepath.Separator)))
+			// Submodule -> mod/mod.rs
+			modDir := filepath.Join(srcDir, filepath.Join(splitModPath(modPath)...))
 			if err := os.MkdirAll(modDir, 0755); err != nil {
 				return fmt.Errorf("mkdir %s failed: %v", modDir, err)
 			}
 			filePath = filepath.Join(modDir, "mod.rs")
 		}
 
-		// Merge all files in this module
-		var allChunks []chunk
-		var allImports []uniast.Import
-		for _, file := range files {
-			allChunks = append(allChunks, file.chunks...)
-			allImports = append(allImports, file.impts...)
+		if !utils.ShouldWriteFile(w.FileFilter, outDir, filePath) {
+			continue
 		}
 
-		// Sort chunks by line
-		sort.SliceStable(allChunks, func(i, j int) bool {
-			return allChunks[i].line < allChunks[j].line
-		})
-
-		// Merge imports using existing Rust import logic
-		mergedImports := mergeImports(nil, allImports)
-
 		var sb strings.Builder
 
-		// Write use statements
-		if len(mergedImports) > 0 {
-			writeImport(&sb, mergedImports)
+		// Declare sub modules
+		subs := children[modPath]
+		sort.Strings(subs)
+		for _, sub := range subs {
+			sb.WriteString("pub mod ")
+			sb.WriteString(sub)
+			sb.WriteString(";\n")
 		}
+		if len(subs) > 0 {
+			sb.WriteString("\n")
+		}
+
+		if f := w.visited[modPath]; f != nil {
+			// Sort chunks by line
+			sort.SliceStable(f.chunks, func(i, j int) bool {
+				return f.chunks[i].line < f.chunks[j].line
+			})
 
-		// Write code chunks
-		for _, c := range allChunks {
-			sb.WriteString(c.codes)
-			sb.WriteString("\n\n")
+			// Write use statements, merged using existing Rust import logic
+			if imports := mergeImports(nil, f.impts); len(imports) > 0 {
+				writeImport(&sb, imports)
+				sb.WriteString("\n")
+			}
+
+			// Write code chunks
+			for _, c := range f.chunks {
+				sb.WriteString(c.codes)
+				sb.WriteString("\n\n")
+			}
 		}
 
 		if err := os.WriteFile(filePath, []byte(sb.String()), 0644); err != nil {
 			return fmt.Errorf("write file %s failed: %v", filePath, err)
 		}
+		written = append(written, filePath)
 	}
 
 	// Generate Cargo.toml
@@ -150,24 +183,29 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 	}
 
 	// Try to format with rustfmt
-	cmd := exec.Command("rustfmt", "src/**/*.rs")
-	cmd.Dir = outdir
-	if err := cmd.Run(); err != nil {
-		log.Error("rustfmt failed: %v", err)
+	if len(written) > 0 {
+		if _, err := exec.LookPath("rustfmt"); err != nil {
+			log.Info("rustfmt not found, skip formatting")
+		} else {
+			cmd := exec.Command("rustfmt", append([]string{"--edition", "2021"}, written...)...)
+			cmd.Dir = outdir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				log.Error("rustfmt failed: %v\n%s", err, out)
+			}
+		}
 	}
 
 	return nil
 }
 
-type fileInfo struct {
-	name     string
-	*fileNode
-}
-
-func (w *Writer) appendPackage(repo *uniast.Repository, pkg *uniast.Package) error {
+func (w *Writer) appendPackage(repo *uniast.Repository, mod *uniast.Module, pkg *uniast.Package) error {
+	modPath := rustModPath(mod.Name, pkg.PkgPath)
+	if pkg.IsMain {
+		w.mains[modPath] = true
+	}
 	for _, v := range pkg.Vars {
 		n := repo.GetNode(v.Identity)
-		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, v.File, v.Line, v.Content); err != nil {
+		if err := w.appendNode(n, mod, modPath, v.Line, v.Content); err != nil {
 			return fmt.Errorf("append chunk for var %s failed: %v", v.Name, err)
 		}
 	}
@@ -176,63 +214,56 @@ func (w *Writer) appendPackage(repo *uniast.Repository, pkg *uniast.Package) err
 			continue
 		}
 		n := repo.GetNode(f.Identity)
-		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, f.File, f.Line, f.Content); err != nil {
+		if err := w.appendNode(n, mod, modPath, f.Line, f.Content); err != nil {
 			return fmt.Errorf("append chunk for function %s failed: %v", f.Name, err)
 		}
 	}
 	for _, t := range pkg.Types {
+		content := t.Content
+		if t.TypeKind == uniast.TypeKindStruct {
+			content = deriveStruct(content)
+		}
 		n := repo.GetNode(t.Identity)
-		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, t.File, t.Line, t.Content); err != nil {
+		if err := w.appendNode(n, mod, modPath, t.Line, content); err != nil {
 			return fmt.Errorf("append chunk for type %s failed: %v", t.Name, err)
 		}
 	}
 	return nil
 }
 
-func (w *Writer) appendNode(node *uniast.Node, pkg string, isMain bool, file string, line int, src string) error {
-	// Convert package path to module path
-	modPath := strings.ReplaceAll(pkg, "/", "::")
-	if modPath == "" {
-		modPath = "main"
-	}
-
-	m := w.visited[modPath]
-	if m == nil {
-		m = make(map[string]*fileNode)
-		w.visited[modPath] = m
-	}
-
-	var filename string
-	if file == "" {
-		if isMain {
-			filename = "main.rs"
-		} else {
-			filename = "lib.rs"
-		}
-	} else {
-		filename = filepath.Base(file)
-		if !strings.HasSuffix(filename, ".rs") {
-			filename = filename + ".rs"
-		}
-	}
-
-	fs := m[filename]
+func (w *Writer) appendNode(node *uniast.Node, mod *uniast.Module, modPath string, line int, src string) error {
+	fs := w.visited[modPath]
 	if fs == nil {
 		fs = &fileNode{
 			chunks: make([]chunk, 0),
 			impts:  make([]uniast.Import, 0),
 		}
-		m[filename] = fs
+		w.visited[modPath] = fs
 	}
 
 	// Collect dependencies as imports
-	for _, v := range node.Dependencies {
-		if v.PkgPath == "" || v.PkgPath == pkg {
-			continue
+	if node != nil {
+		for _, v := range node.Dependencies {
+			if v.PkgPath == "" {
+				continue
+			}
+			if v.ModPath != mod.Name {
+				// Convert to Rust use format
+				importPath := strings.ReplaceAll(v.PkgPath, "/", "::")
+				fs.impts = append(fs.impts, uniast.Import{Path: "use " + importPath + ";"})
+				continue
+			}
+			// items of the crate are imported by their path from the crate root
+			depModPath := rustModPath(mod.Name, v.PkgPath)
+			if depModPath == modPath || !isRustIdent(v.Name) {
+				continue
+			}
+			importPath := "crate::" + v.Name
+			if depModPath != "" {
+				importPath = "crate::" + depModPath + "::" + v.Name
+			}
+			fs.impts = append(fs.impts, uniast.Import{Path: "use " + importPath + ";"})
 		}
-		// Convert to Rust use format
-		importPath := strings.ReplaceAll(v.PkgPath, "/", "::")
-		fs.impts = append(fs.impts, uniast.Import{Path: "use " + importPath + ";"})
 	}
 
 	// Extract use statements from source code using existing Rust parser
@@ -315,33 +346,64 @@ func (w *Writer) CreateFile(fi *uniast.File, mod *uniast.Module) ([]byte, error)
 	return []byte(sb.String()), nil
 }
 
+// generateCargoToml writes the Cargo.toml of the crate, or adds the dependencies of mod missing from an existing one
 func (w *Writer) generateCargoToml(mod *uniast.Module, outdir string, crateName string) error {
+	tomlPath := filepath.Join(outdir, "Cargo.toml")
+	var existing string
+	if data, err := os.ReadFile(tomlPath); err == nil {
+		existing = string(data)
+	}
+
+	names := make([]string, 0, len(mod.Dependencies))
+	for name := range mod.Dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var deps strings.Builder
+	for _, name := range names {
+		if existing != "" && regexp.MustCompile(`(?m)^\s*`+regexp.QuoteMeta(name)+`\s*=`).MatchString(existing) {
+			continue
+		}
+		depParts := strings.Split(mod.Dependencies[name], "@")
+		depVersion := "1.0"
+		if len(depParts) >= 2 && depParts[1] != "" {
+			depVersion = depParts[1]
+		}
+		deps.WriteString(name)
+		deps.WriteString(" = \"")
+		deps.WriteString(depVersion)
+		deps.WriteString("\"\n")
+	}
+
 	var sb strings.Builder
-	sb.WriteString("[package]\n")
-	sb.WriteString("name = \"")
-	sb.WriteString(crateName)
-	sb.WriteString("\"\n")
-	sb.WriteString("version = \"1.0.0\"\n")
-	sb.WriteString("edition = \"2021\"\n\n")
-
-	if len(mod.Dependencies) > 0 {
-		sb.WriteString("[dependencies]\n")
-		for name, dep := range mod.Dependencies {
-			depParts := strings.Split(dep, "@")
-			depName := name
-			depVersion := "1.0"
-			if len(depParts) >= 2 && depParts[1] != "" {
-				depVersion = depParts[1]
-			}
-			sb.WriteString(depName)
-			sb.WriteString(" = \"")
-			sb.WriteString(depVersion)
-			sb.WriteString("\"\n")
+	switch {
+	case existing == "":
+		sb.WriteString("[package]\n")
+		sb.WriteString("name = \"")
+		sb.WriteString(crateName)
+		sb.WriteString("\"\n")
+		sb.WriteString("version = \"1.0.0\"\n")
+		sb.WriteString("edition = \"2021\"\n\n")
+		if deps.Len() > 0 {
+			sb.WriteString("[dependencies]\n")
+			sb.WriteString(deps.String())
+			sb.WriteString("\n")
+		}
+	case deps.Len() == 0:
+		return nil
+	default:
+		if i := strings.Index(existing, "[dependencies]\n"); i >= 0 {
+			i += len("[dependencies]\n")
+			sb.WriteString(existing[:i])
+			sb.WriteString(deps.String())
+			sb.WriteString(existing[i:])
+		} else {
+			sb.WriteString(strings.TrimRight(existing, "\n"))
+			sb.WriteString("\n\n[dependencies]\n")
+			sb.WriteString(deps.String())
 		}
-		sb.WriteString("\n")
 	}
 
-	tomlPath := filepath.Join(outdir, "Cargo.toml")
 	if err := os.WriteFile(tomlPath, []byte(sb.String()), 0644); err != nil {
 		return fmt.Errorf("write Cargo.toml failed: %v", err)
 	}
@@ -17,6 +17,9 @@
 package writer
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -94,3 +97,99 @@ func TestWriter_IdToImport(t *testing.T) {
 		})
 	}
 }
+
+func TestWriter_WriteModule(t *testing.T) {
+	userID := uniast.NewIdentity("my-crate", "my-crate::model::user", "User")
+	newID := uniast.NewIdentity("my-crate", "my-crate::service", "new_user")
+	mainID := uniast.NewIdentity("my-crate", "my-crate", "main")
+	repo := &uniast.Repository{
+		Name: "my-crate",
+		Modules: map[string]*uniast.Module{
+			"my-crate": {
+				Name:         "my-crate",
+				Dir:          ".",
+				Language:     uniast.Rust,
+				Dependencies: map[string]string{"serde": "serde@1.0", "tokio": "tokio@1"},
+				Packages: map[uniast.PkgPath]*uniast.Package{
+					"my-crate": {
+						PkgPath:   "my-crate",
+						IsMain:    true,
+						Functions: map[string]*uniast.Function{"main": {Identity: mainID, Content: "fn main() {}"}},
+					},
+					"my-crate::model::user": {
+						PkgPath: "my-crate::model::user",
+						Types: map[string]*uniast.Type{"User": {
+							Identity: userID,
+							TypeKind: uniast.TypeKindStruct,
+							Content:  "/// A user\npub struct User {\n    pub name: String,\n}",
+						}},
+					},
+					"my-crate::service": {
+						PkgPath: "my-crate::service",
+						Functions: map[string]*uniast.Function{"new_user": {
+							Identity: newID,
+							Content:  "pub fn new_user(name: &str) -> User {\n    User { name: name.to_string() }\n}",
+						}},
+					},
+				},
+			},
+		},
+		Graph: map[string]*uniast.Node{
+			newID.Full(): {Identity: newID, Type: uniast.FUNC, Dependencies: []uniast.Relation{{Identity: userID}}},
+		},
+	}
+
+	dir := t.TempDir()
+	// keep the Cargo.toml generated by translate, with its dependencies
+	if err := os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte("[package]\nname = \"my_crate\"\n\n[dependencies]\ntokio = { version = \"1\", features = [\"full\"] }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewWriter(Options{}).WriteModule(repo, "my-crate", dir); err != nil {
+		t.Fatalf("WriteModule() error = %v", err)
+	}
+
+	for file, wants := range map[string][]string{
+		"src/main.rs":           {"pub mod model;", "pub mod service;", "fn main()"},
+		"src/model/mod.rs":      {"pub mod user;"},
+		"src/model/user/mod.rs": {"/// A user\n#[derive(Debug, Clone)]\npub struct User"},
+		"src/service/mod.rs":    {"use crate::model::user::User;", "pub fn new_user"},
+		"Cargo.toml":            {"serde = \"1.0\"", "features = [\"full\"]"},
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Errorf("read %s: %v", file, err)
+			continue
+		}
+		for _, want := range wants {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("%s = %q, want it to contain %q", file, data, want)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src", "lib.rs")); err == nil {
+		t.Error("src/lib.rs should not be written for a binary crate")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "Cargo.toml")); strings.Count(string(data), "tokio") != 1 {
+		t.Errorf("Cargo.toml = %q, want tokio declared once", data)
+	}
+}
+
+func TestRustModPath(t *testing.T) {
+	tests := []struct {
+		modName string
+		pkgPath string
+		want    string
+	}{
+		{"my-crate", "my-crate", ""},
+		{"my-crate", "my_crate::model", "model"},
+		{"my-crate", "my-crate::src::model::user", "model::user"},
+		{"my-crate", "crate::model", "model"},
+		{"app", "model/user-service", "model::user_service"},
+		{"app", "v2::API", "v2::api"},
+	}
+	for _, tt := range tests {
+		if got := rustModPath(tt.modName, tt.pkgPath); got != tt.want {
+			t.Errorf("rustModPath(%q, %q) = %q, want %q", tt.modName, tt.pkgPath, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lang
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// ParseStats is the statistics of a parse run
+type ParseStats struct {
+	FilesScanned    int           // source files found under the repo
+	FilesParsed     int           // source files collected into the AST
+	FilesSkipped    int           // source files skipped (tests, vendor, excluded...)
+	Types           int           // types collected
+	Functions       int           // functions collected
+	Vars            int           // vars collected
+	ExternalSymbols int           // nodes loaded from external modules
+	Duration        time.Duration // parse wall-clock time
+}
+
+func (s ParseStats) String() string {
+	sb := strings.Builder{}
+	sb.WriteString("parse stats:\n")
+	fmt.Fprintf(&sb, "  files scanned:    %d\n", s.FilesScanned)
+	fmt.Fprintf(&sb, "  files parsed:     %d\n", s.FilesParsed)
+	fmt.Fprintf(&sb, "  files skipped:    %d\n", s.FilesSkipped)
+	fmt.Fprintf(&sb, "  types:            %d\n", s.Types)
+	fmt.Fprintf(&sb, "  functions:        %d\n", s.Functions)
+	fmt.Fprintf(&sb, "  vars:             %d\n", s.Vars)
+	fmt.Fprintf(&sb, "  external symbols: %d\n", s.ExternalSymbols)
+	fmt.Fprintf(&sb, "  duration:         %s\n", s.Duration)
+	return sb.String()
+}
+
+var sourceFileExts = map[uniast.Language][]string{
+	uniast.Golang: {".go"},
+	uniast.Rust:   {".rs"},
+	uniast.Cxx:    {".c", ".h", ".cc", ".cpp", ".hpp"},
+	uniast.Python: {".py"},
+	uniast.Java:   {".java"},
+}
+
+func isSourceFile(language uniast.Language, path string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range sourceFileExts[language] {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// collectParseStats fills stats according to the repo directory and the parsed repo
+func collectParseStats(stats *ParseStats, repoPath string, language uniast.Language, repo *uniast.Repository) {
+	filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != repoPath && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isSourceFile(language, path) {
+			stats.FilesScanned++
+		}
+		return nil
+	})
+
+	for _, mod := range repo.Modules {
+		if mod.IsExternal() {
+			for _, pkg := range mod.Packages {
+				stats.ExternalSymbols += pkg.NodeCount()
+			}
+			continue
+		}
+		for path := range mod.Files {
+			if isSourceFile(language, path) {
+				stats.FilesParsed++
+			}
+		}
+	}
+	for range repo.AllTypes {
+		stats.Types++
+	}
+	for range repo.AllFunctions {
+		stats.Functions++
+	}
+	for range repo.AllVars {
+		stats.Vars++
+	}
+	if stats.FilesScanned > stats.FilesParsed {
+		stats.FilesSkipped = stats.FilesScanned - stats.FilesParsed
+	}
+}
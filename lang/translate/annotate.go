@@ -0,0 +1,102 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// maxAnnotatedSourceLines is the max number of original source lines annotated above a translated node
+const maxAnnotatedSourceLines = 20
+
+// sourceAnnotationRegex matches the lines added by AnnotateSource
+var sourceAnnotationRegex = regexp.MustCompile(`^[ \t]*(//|#) Original [^\s:]+:( |$)`)
+
+// lineCommentPrefix returns the line comment marker of lang
+func lineCommentPrefix(lang uniast.Language) string {
+	if lang == uniast.Python {
+		return "#"
+	}
+	return "//"
+}
+
+// AnnotateSource puts the original source above the translated content,
+// as `// Original <srcLang>: <line>` comments in the comment style of dstLang.
+// Only the first maxAnnotatedSourceLines lines of the source are kept.
+func AnnotateSource(target, source string, srcLang, dstLang uniast.Language) string {
+	source = strings.Trim(source, "\n")
+	if strings.TrimSpace(source) == "" || strings.TrimSpace(target) == "" {
+		return target
+	}
+	prefix := lineCommentPrefix(dstLang) + " Original " + string(srcLang) + ":"
+	lines := strings.Split(source, "\n")
+	var sb strings.Builder
+	for i, l := range lines {
+		if i == maxAnnotatedSourceLines {
+			fmt.Fprintf(&sb, "%s ... (%d more lines)\n", prefix, len(lines)-i)
+			break
+		}
+		if l = strings.TrimRight(l, " \t\r"); l == "" {
+			sb.WriteString(prefix + "\n")
+		} else {
+			sb.WriteString(prefix + " " + l + "\n")
+		}
+	}
+	// keep the annotation apart from the doc comment of the translated content
+	sb.WriteString("\n")
+	sb.WriteString(target)
+	return sb.String()
+}
+
+// StripSourceAnnotations removes the comments added by AnnotateSource
+func StripSourceAnnotations(content string) string {
+	lines := strings.Split(content, "\n")
+	out := make([]string, 0, len(lines))
+	annotated := false
+	for _, l := range lines {
+		if sourceAnnotationRegex.MatchString(l) {
+			annotated = true
+			continue
+		}
+		if annotated && strings.TrimSpace(l) == "" {
+			// the separator line after the annotation
+			annotated = false
+			continue
+		}
+		annotated = false
+		out = append(out, l)
+	}
+	return strings.Join(out, "\n")
+}
+
+// StripRepoSourceAnnotations removes the comments added by AnnotateSource from the content of all the nodes of repo,
+// e.g. to write a translation made with --annotate-source without them
+func StripRepoSourceAnnotations(repo *uniast.Repository) {
+	for _, fn := range repo.AllFunctions {
+		fn.Content = StripSourceAnnotations(fn.Content)
+	}
+	for _, typ := range repo.AllTypes {
+		typ.Content = StripSourceAnnotations(typ.Content)
+	}
+	for _, v := range repo.AllVars {
+		v.Content = StripSourceAnnotations(v.Content)
+	}
+}
@@ -0,0 +1,360 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// DefaultBuildRetry is the default number of re-translations when the written code fails to build
+const DefaultBuildRetry = 3
+
+// BuildError is a compile error reported by the build tool of the target language
+type BuildError struct {
+	File    string // path relative to the build directory
+	Line    int
+	Message string
+}
+
+func (e BuildError) String() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+}
+
+// BuildFunc builds the project in dir and returns the output of the build tool.
+// A non-nil error means the build failed.
+type BuildFunc func(ctx context.Context, dir string) (string, error)
+
+// ValidateBuildOptions holds the configuration for ValidateBuild
+type ValidateBuildOptions struct {
+	// OutputDir is the directory the target code is written to and built in (required)
+	OutputDir string
+	// Write writes the target repository to OutputDir, including any post-processing (required)
+	Write func(repo *uniast.Repository) error
+	// Build builds OutputDir, default to RunBuild with the target language
+	Build BuildFunc
+	// MaxRetry is the max number of re-translations on build failure (default: DefaultBuildRetry)
+	MaxRetry int
+	// RetryCallback is optional; called before each re-translation with the build errors and the nodes to re-translate
+	RetryCallback func(attempt int, errs []BuildError, failed []FailedNodeInfo)
+}
+
+var (
+	// file.go:12:5: message
+	goBuildErrorRegex = regexp.MustCompile(`^(\S+\.go):(\d+)(?::\d+)?: (.+)$`)
+	// error[E0412]: message, followed by `  --> src/file.rs:12:5`
+	rustBuildErrorRegex    = regexp.MustCompile(`^error(?:\[\w+\])?: .+$`)
+	rustBuildLocationRegex = regexp.MustCompile(`^\s*--> (\S+\.rs):(\d+)(?::\d+)?$`)
+	// javac: File.java:12: error: message
+	javacBuildErrorRegex = regexp.MustCompile(`^(\S+\.java):(\d+): error: (.+)$`)
+	// maven: [ERROR] /path/File.java:[12,5] message
+	mavenBuildErrorRegex = regexp.MustCompile(`^\[ERROR\] (\S+\.java):\[(\d+)(?:,\d+)?\] (.+)$`)
+
+	// symbols mentioned by build errors, used when the error is not located inside any node
+	backquotedSymbolRegex = regexp.MustCompile("`([^`]+)`")
+	goImportSymbolRegex   = regexp.MustCompile(`could not import (\S+)`)
+	goUndefinedRegex      = regexp.MustCompile(`undefined: (\S+)`)
+)
+
+// BuildCommand returns the build command of the language, or nil if not supported
+func BuildCommand(lang uniast.Language) []string {
+	switch lang {
+	case uniast.Golang:
+		return []string{"go", "build", "./..."}
+	case uniast.Rust:
+		return []string{"cargo", "check", "--message-format", "short"}
+	case uniast.Java:
+		return []string{"mvn", "-q", "compile"}
+	default:
+		return nil
+	}
+}
+
+// RunBuild runs the build tool of the language in dir and returns its combined output
+func RunBuild(ctx context.Context, lang uniast.Language, dir string) (string, error) {
+	args := BuildCommand(lang)
+	if len(args) == 0 {
+		return "", fmt.Errorf("build validation is not supported for %s", lang)
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("%s failed: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
+
+// ParseBuildErrors parses the output of the build tool of the language into located build errors.
+// File paths are made relative to dir.
+func ParseBuildErrors(lang uniast.Language, dir, output string) []BuildError {
+	var errs []BuildError
+	add := func(file, line, msg string) {
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return
+		}
+		if filepath.IsAbs(file) {
+			if rel, err := filepath.Rel(dir, file); err == nil {
+				file = rel
+			}
+		}
+		errs = append(errs, BuildError{File: filepath.ToSlash(filepath.Clean(file)), Line: n, Message: strings.TrimSpace(msg)})
+	}
+
+	lines := strings.Split(output, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		switch lang {
+		case uniast.Golang:
+			if m := goBuildErrorRegex.FindStringSubmatch(line); m != nil {
+				add(m[1], m[2], m[3])
+			}
+		case uniast.Rust:
+			// short format: src/file.rs:12:5: error[E0412]: message
+			if idx := strings.Index(line, ".rs:"); idx > 0 && strings.Contains(line, ": error") {
+				parts := strings.SplitN(line, ":", 4)
+				if len(parts) == 4 {
+					add(parts[0], parts[1], parts[3])
+				}
+				continue
+			}
+			// human format: the location follows the message
+			if !rustBuildErrorRegex.MatchString(line) {
+				continue
+			}
+			for j := i + 1; j < len(lines) && j <= i+2; j++ {
+				if loc := rustBuildLocationRegex.FindStringSubmatch(strings.TrimRight(lines[j], "\r")); loc != nil {
+					add(loc[1], loc[2], line)
+					i = j
+					break
+				}
+			}
+		case uniast.Java:
+			if m := mavenBuildErrorRegex.FindStringSubmatch(line); m != nil {
+				add(m[1], m[2], m[3])
+			} else if m := javacBuildErrorRegex.FindStringSubmatch(line); m != nil {
+				add(m[1], m[2], m[3])
+			}
+		}
+	}
+	return errs
+}
+
+// builtNode is a node of the written code with its line range in a file
+type builtNode struct {
+	id      uniast.Identity
+	content string
+	start   int // 1-based line of the first code line, 0 if not found
+	end     int
+}
+
+// MapBuildErrors maps build errors to the nodes of repo written into dir.
+// A build error is mapped to the node whose code encloses the error line; errors outside any node
+// (e.g. unused or unresolved imports) are mapped to the nodes of the file mentioning the erroneous symbol.
+// Returns node Identity.Full() => error messages.
+func MapBuildErrors(repo *uniast.Repository, dir string, errs []BuildError) map[string]string {
+	var nodes []builtNode
+	for id, fn := range repo.AllFunctions {
+		nodes = append(nodes, builtNode{id: id, content: fn.Content})
+	}
+	for id, typ := range repo.AllTypes {
+		nodes = append(nodes, builtNode{id: id, content: typ.Content})
+	}
+	for id, v := range repo.AllVars {
+		nodes = append(nodes, builtNode{id: id, content: v.Content})
+	}
+
+	located := make(map[string][]builtNode)
+	messages := make(map[string][]string)
+	addMessage := func(id uniast.Identity, msg string) {
+		key := id.Full()
+		for _, m := range messages[key] {
+			if m == msg {
+				return
+			}
+		}
+		messages[key] = append(messages[key], msg)
+	}
+
+	for _, e := range errs {
+		fileNodes, ok := located[e.File]
+		if !ok {
+			// files not written by us (e.g. generated by the build tool) have no nodes
+			if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(e.File))); err == nil {
+				fileNodes = locateNodes(strings.Split(string(data), "\n"), nodes)
+			}
+			located[e.File] = fileNodes
+		}
+
+		var enclosing *builtNode
+		for i := range fileNodes {
+			n := &fileNodes[i]
+			if n.start <= e.Line && e.Line <= n.end && (enclosing == nil || n.start > enclosing.start) {
+				enclosing = n
+			}
+		}
+		if enclosing != nil {
+			addMessage(enclosing.id, e.String())
+			continue
+		}
+		if sym := buildErrorSymbol(e.Message); sym != "" {
+			for _, n := range fileNodes {
+				if strings.Contains(n.content, sym) {
+					addMessage(n.id, e.String())
+				}
+			}
+		}
+	}
+
+	ret := make(map[string]string, len(messages))
+	for id, msgs := range messages {
+		ret[id] = strings.Join(msgs, "; ")
+	}
+	return ret
+}
+
+// locateNodes finds the line ranges of nodes in the lines of a file, returns the found ones.
+// Nodes are located by their first code line, ignoring whitespace changes made by formatters.
+func locateNodes(lines []string, nodes []builtNode) []builtNode {
+	index := make(map[string][]int, len(lines))
+	for i, line := range lines {
+		key := normalizeCodeLine(line)
+		if key != "" {
+			index[key] = append(index[key], i+1)
+		}
+	}
+	var ret []builtNode
+	for _, n := range nodes {
+		contentLines := strings.Split(n.content, "\n")
+		for i, line := range contentLines {
+			key := normalizeCodeLine(line)
+			if key == "" || isCommentLine(key) {
+				continue
+			}
+			if starts := index[key]; len(starts) == 1 {
+				n.start = starts[0]
+				n.end = n.start + len(contentLines) - i - 1
+				ret = append(ret, n)
+			}
+			break
+		}
+	}
+	return ret
+}
+
+func normalizeCodeLine(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+func isCommentLine(line string) bool {
+	return strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*") || strings.HasPrefix(line, "*") || strings.HasPrefix(line, "#")
+}
+
+// buildErrorSymbol extracts the erroneous symbol mentioned by a build error message, if any
+func buildErrorSymbol(msg string) string {
+	if m := goImportSymbolRegex.FindStringSubmatch(msg); m != nil {
+		return path.Base(strings.Trim(m[1], `"`)) + "."
+	}
+	if m := goUndefinedRegex.FindStringSubmatch(msg); m != nil {
+		return m[1]
+	}
+	if m := backquotedSymbolRegex.FindStringSubmatch(msg); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// ValidateBuild writes dstRepo and builds it, then re-translates the nodes causing build errors
+// with the errors added to their prompts, until the build passes or vopts.MaxRetry is reached.
+// It returns the last written repository and the build errors left (nil if the build passes).
+func ValidateBuild(ctx context.Context, srcRepo, dstRepo *uniast.Repository, opts TranslateOptions, vopts ValidateBuildOptions) (*uniast.Repository, []BuildError, error) {
+	if vopts.OutputDir == "" || vopts.Write == nil {
+		return nil, nil, fmt.Errorf("both OutputDir and Write are required")
+	}
+	if vopts.Build == nil {
+		if len(BuildCommand(opts.TargetLanguage)) == 0 {
+			return nil, nil, fmt.Errorf("build validation is not supported for %s", opts.TargetLanguage)
+		}
+		vopts.Build = func(ctx context.Context, dir string) (string, error) {
+			return RunBuild(ctx, opts.TargetLanguage, dir)
+		}
+	}
+	if vopts.MaxRetry < 0 {
+		vopts.MaxRetry = 0
+	} else if vopts.MaxRetry == 0 {
+		vopts.MaxRetry = DefaultBuildRetry
+	}
+
+	transformer := NewTransformer(opts)
+	repo := dstRepo
+	for attempt := 0; ; attempt++ {
+		if err := vopts.Write(repo); err != nil {
+			return nil, nil, fmt.Errorf("write target code failed: %w", err)
+		}
+		output, buildErr := vopts.Build(ctx, vopts.OutputDir)
+		if buildErr == nil {
+			return repo, nil, nil
+		}
+		errs := ParseBuildErrors(opts.TargetLanguage, vopts.OutputDir, output)
+		if len(errs) == 0 {
+			return repo, nil, fmt.Errorf("build failed without located errors: %w", buildErr)
+		}
+		if attempt >= vopts.MaxRetry {
+			return repo, errs, nil
+		}
+
+		// map the errors back to the source nodes to re-translate
+		sourceIDs := transformer.sourceIdentities(srcRepo, repo)
+		buildErrors := make(map[string]string)
+		for targetID, msg := range MapBuildErrors(repo, vopts.OutputDir, errs) {
+			if srcID, ok := sourceIDs[targetID]; ok {
+				buildErrors[srcID] = msg
+			}
+		}
+		if len(buildErrors) == 0 {
+			return repo, errs, nil
+		}
+		failed := make([]FailedNodeInfo, 0, len(buildErrors))
+		for id, msg := range buildErrors {
+			failed = append(failed, FailedNodeInfo{NodeID: id, Err: msg})
+		}
+		sort.Slice(failed, func(i, j int) bool { return failed[i].NodeID < failed[j].NodeID })
+		if vopts.RetryCallback != nil {
+			vopts.RetryCallback(attempt+1, errs, failed)
+		}
+
+		retryOpts := opts
+		retryOpts.AlreadyTranslatedIDs = nil
+		retryOpts.BuildErrors = buildErrors
+		next, err := ReTranslateFailedNodes(ctx, srcRepo, repo, failed, retryOpts)
+		if err != nil {
+			return repo, errs, fmt.Errorf("re-translate nodes with build errors failed: %w", err)
+		}
+		repo = next
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -27,10 +28,16 @@ import (
 
 // ConfigGenerator generates project configuration files
 type ConfigGenerator struct {
-	targetLang     uniast.Language
-	moduleName     string
-	generatedFiles map[string]string
-	dependencies   []string
+	targetLang         uniast.Language
+	moduleName         string
+	generatedFiles     map[string]string
+	dependencies       []string
+	generateDockerfile bool
+	// externalDeps holds the third-party libraries (path => version) declared by the internal modules
+	// of the repository in the target language, or mapped from the ones of the source repository
+	externalDeps map[string]string
+	srcLang      uniast.Language
+	srcRepo      *uniast.Repository
 }
 
 // NewConfigGenerator creates a new ConfigGenerator
@@ -48,12 +55,25 @@ func (g *ConfigGenerator) AddDependency(dep string) {
 	g.dependencies = append(g.dependencies, dep)
 }
 
+// SetSource sets the source language and repository, whose manifest dependencies
+// are mapped to the target language by mapExternalDependency
+func (g *ConfigGenerator) SetSource(lang uniast.Language, repo *uniast.Repository) {
+	g.srcLang = lang
+	g.srcRepo = repo
+}
+
+// SetGenerateDockerfile sets whether to generate a Dockerfile (only works for Go now)
+func (g *ConfigGenerator) SetGenerateDockerfile(enable bool) {
+	g.generateDockerfile = enable
+}
+
 // Generate creates project configuration files
 func (g *ConfigGenerator) Generate(repo *uniast.Repository, outputDir string) (*uniast.Repository, error) {
 	// Determine module name if not set
 	if g.moduleName == "" {
 		g.moduleName = g.inferModuleName(repo)
 	}
+	g.collectExternalDependencies(repo)
 
 	// Generate config based on target language
 	switch g.targetLang {
@@ -89,6 +109,99 @@ func (g *ConfigGenerator) GetFiles() map[string]string {
 	return g.generatedFiles
 }
 
+// collectExternalDependencies gathers ExternalDependencies of the internal modules of the source repository,
+// mapped to the target language, and of the modules of repo written in the target language,
+// so that generated manifests pin the same versions
+func (g *ConfigGenerator) collectExternalDependencies(repo *uniast.Repository) {
+	g.externalDeps = make(map[string]string)
+	add := func(srcLang uniast.Language, mod *uniast.Module) {
+		for name, version := range mod.ExternalDependencies {
+			lib, ok := mapExternalDependency(srcLang, g.targetLang, name, version)
+			// internal packages can't be required, see fixGoInternalImports
+			if !ok || (g.targetLang == uniast.Golang && strings.Contains("/"+lib.Name+"/", "/internal/")) {
+				continue
+			}
+			g.externalDeps[lib.Name] = lib.Version
+		}
+	}
+	if g.srcRepo != nil {
+		for _, mod := range g.srcRepo.Modules {
+			if mod.IsExternal() {
+				continue
+			}
+			srcLang := mod.Language
+			if srcLang == uniast.Unknown {
+				srcLang = g.srcLang
+			}
+			add(srcLang, mod)
+		}
+	}
+	for _, mod := range repo.Modules {
+		if mod.IsExternal() || mod.Language != g.targetLang {
+			continue
+		}
+		add(g.targetLang, mod)
+	}
+}
+
+// externalRequires returns the sorted lines of the collected external dependencies
+// which are not added by AddDependency. nameOf returns the library name of an added dependency
+// and format renders the line of a library.
+func (g *ConfigGenerator) externalRequires(nameOf func(dep string) string, format func(name, version string) string) []string {
+	added := make(map[string]bool, len(g.dependencies))
+	for _, dep := range g.dependencies {
+		added[nameOf(dep)] = true
+	}
+	requires := make([]string, 0, len(g.externalDeps))
+	for name, version := range g.externalDeps {
+		if !added[name] {
+			requires = append(requires, format(name, version))
+		}
+	}
+	sort.Strings(requires)
+	return requires
+}
+
+// goRequires returns the sorted require lines of go.mod,
+// merging the added dependencies with the collected external ones
+func (g *ConfigGenerator) goRequires() []string {
+	requires := make([]string, 0, len(g.dependencies)+len(g.externalDeps))
+	for _, dep := range g.dependencies {
+		if strings.TrimSpace(dep) != "" {
+			requires = append(requires, dep)
+		}
+	}
+	requires = append(requires, g.externalRequires(firstField, func(path, version string) string {
+		return path + " " + version
+	})...)
+	sort.Strings(requires)
+	return requires
+}
+
+// firstField returns the first whitespace-separated field of s, e.g. the module path of a go.mod require line
+func firstField(s string) string {
+	if fields := strings.Fields(s); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// pythonRequirement renders a PyPI dependency, pinning a plain version with "==" like the Python writer
+func pythonRequirement(name, version string) string {
+	if version != "" && !strings.ContainsAny(version, "<>=!~") {
+		version = "==" + version
+	}
+	return name + version
+}
+
+// pythonRequirementName returns the project name of a requirement like "fastapi>=0.100.0"
+func pythonRequirementName(dep string) string {
+	if i := strings.IndexAny(dep, "<>=!~[ ;"); i >= 0 {
+		return dep[:i]
+	}
+	return dep
+}
+
 // inferModuleName infers module name from repository
 func (g *ConfigGenerator) inferModuleName(repo *uniast.Repository) string {
 	if repo.Name != "" {
@@ -172,17 +285,63 @@ func (g *ConfigGenerator) generateGoConfig(outputDir string) {
 	goMod := fmt.Sprintf(`module %s
 
 go 1.21
-
-require (
 `, moduleName)
 
 	// Add dependencies
-	for _, dep := range g.dependencies {
-		goMod += fmt.Sprintf("\t%s\n", dep)
+	if requires := g.goRequires(); len(requires) > 0 {
+		goMod += "\nrequire (\n"
+		for _, dep := range requires {
+			goMod += fmt.Sprintf("\t%s\n", dep)
+		}
+		goMod += ")\n"
 	}
-	goMod += ")\n"
 
 	g.generatedFiles["go.mod"] = goMod
+	// empty go.sum, filled by go mod tidy
+	g.generatedFiles["go.sum"] = ""
+
+	g.generatedFiles[".gitignore"] = `# Binaries
+*.exe
+*.exe~
+*.dll
+*.so
+*.dylib
+*.test
+*.out
+
+# Build output
+bin/
+dist/
+
+# Dependencies
+vendor/
+`
+
+	g.generatedFiles["Makefile"] = `.PHONY: build test lint
+
+build:
+	go build ./...
+
+test:
+	go test ./...
+
+lint:
+	go vet ./...
+`
+
+	if g.generateDockerfile {
+		g.generatedFiles["Dockerfile"] = `FROM golang:1.21 AS builder
+WORKDIR /src
+COPY go.mod go.sum ./
+RUN go mod download
+COPY . .
+RUN go mod tidy && CGO_ENABLED=0 go build -o /out/app .
+
+FROM gcr.io/distroless/static-debian12
+COPY --from=builder /out/app /app
+ENTRYPOINT ["/app"]
+`
+	}
 
 	// Create standard directory structure markers
 	g.generatedFiles["cmd/.gitkeep"] = ""
@@ -212,20 +371,17 @@ edition = "2021"
 	for _, dep := range g.dependencies {
 		cargoToml += fmt.Sprintf("%s\n", dep)
 	}
+	for _, dep := range g.externalRequires(func(dep string) string {
+		name, _, _ := strings.Cut(dep, "=")
+		return strings.TrimSpace(name)
+	}, func(name, version string) string {
+		return fmt.Sprintf("%s = %q", name, version)
+	}) {
+		cargoToml += dep + "\n"
+	}
 
 	g.generatedFiles["Cargo.toml"] = cargoToml
-
-	// Create src directory structure
-	g.generatedFiles["src/lib.rs"] = `// Library root
-pub mod model;
-pub mod service;
-pub mod repository;
-`
-
-	// Create module files
-	g.generatedFiles["src/model/mod.rs"] = "// Model module\n"
-	g.generatedFiles["src/service/mod.rs"] = "// Service module\n"
-	g.generatedFiles["src/repository/mod.rs"] = "// Repository module\n"
+	// src/lib.rs and the module files declaring the translated packages are written by the Rust writer
 }
 
 // generatePythonConfig generates Python project configuration
@@ -254,7 +410,8 @@ dependencies = [
 `, projectName)
 
 	// Add dependencies
-	for _, dep := range g.dependencies {
+	pyDeps := append(append([]string{}, g.dependencies...), g.externalRequires(pythonRequirementName, pythonRequirement)...)
+	for _, dep := range pyDeps {
 		pyprojectToml += fmt.Sprintf("    \"%s\",\n", dep)
 	}
 	pyprojectToml += `]
@@ -270,7 +427,7 @@ dev = [
 
 	// requirements.txt
 	requirements := "# Project dependencies\n"
-	for _, dep := range g.dependencies {
+	for _, dep := range pyDeps {
 		requirements += dep + "\n"
 	}
 	g.generatedFiles["requirements.txt"] = requirements
@@ -350,7 +507,7 @@ make -j$(nproc)
 	g.generatedFiles["build.sh"] = buildScript
 }
 
-// generateJavaConfig generates Java project configuration (Maven)
+// generateJavaConfig generates Java project configuration (Maven pom.xml and Gradle build.gradle)
 func (g *ConfigGenerator) generateJavaConfig(outputDir string) {
 	groupId := "com.example"
 	artifactId := g.moduleName
@@ -385,6 +542,22 @@ func (g *ConfigGenerator) generateJavaConfig(outputDir string) {
 	for _, dep := range g.dependencies {
 		pomXml += fmt.Sprintf("        <!-- %s -->\n", dep)
 	}
+	javaDeps := g.externalRequires(func(dep string) string { return dep }, func(name, version string) string {
+		return name + ":" + version
+	})
+	for _, dep := range javaDeps {
+		parts := strings.SplitN(dep, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		pomXml += "        <dependency>\n"
+		pomXml += fmt.Sprintf("            <groupId>%s</groupId>\n", parts[0])
+		pomXml += fmt.Sprintf("            <artifactId>%s</artifactId>\n", parts[1])
+		if parts[2] != "" {
+			pomXml += fmt.Sprintf("            <version>%s</version>\n", parts[2])
+		}
+		pomXml += "        </dependency>\n"
+	}
 
 	pomXml += `    </dependencies>
 
@@ -401,6 +574,38 @@ func (g *ConfigGenerator) generateJavaConfig(outputDir string) {
 `
 	g.generatedFiles["pom.xml"] = pomXml
 
+	// build.gradle: Gradle alternative to pom.xml
+	buildGradle := fmt.Sprintf(`plugins {
+    id 'java'
+}
+
+group = '%s'
+version = '0.1.0-SNAPSHOT'
+
+java {
+    sourceCompatibility = JavaVersion.VERSION_17
+    targetCompatibility = JavaVersion.VERSION_17
+}
+
+repositories {
+    mavenCentral()
+}
+
+dependencies {
+`, groupId)
+	for _, dep := range append(append([]string{}, g.dependencies...), javaDeps...) {
+		buildGradle += fmt.Sprintf("    implementation '%s'\n", dep)
+	}
+	buildGradle += `    testImplementation 'org.junit.jupiter:junit-jupiter:5.10.0'
+}
+
+test {
+    useJUnitPlatform()
+}
+`
+	g.generatedFiles["build.gradle"] = buildGradle
+	g.generatedFiles["settings.gradle"] = fmt.Sprintf("rootProject.name = '%s'\n", artifactId)
+
 	// Create directory structure
 	g.generatedFiles["src/main/java/.gitkeep"] = ""
 	g.generatedFiles["src/main/resources/.gitkeep"] = ""
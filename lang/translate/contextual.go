@@ -0,0 +1,89 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"sort"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// ContextualTranslator provides the package context of the nodes to translate:
+// the already translated nodes of the same target package, see TranslateOptions.ContextWindowPkgNodes
+type ContextualTranslator struct {
+	maxNodes int
+}
+
+// NewContextualTranslator creates a ContextualTranslator showing up to maxNodes translated nodes,
+// it returns nil if maxNodes <= 0, which provides no context
+func NewContextualTranslator(maxNodes int) *ContextualTranslator {
+	if maxNodes <= 0 {
+		return nil
+	}
+	return &ContextualTranslator{maxNodes: maxNodes}
+}
+
+// PackageContext returns up to maxNodes translated nodes of the target package, types first.
+// The nodes belong to a snapshot repository of the package, so that they can be read
+// while the package is filled by concurrent translations.
+func (c *ContextualTranslator) PackageContext(mod *uniast.Module, pkg *uniast.Package) []*uniast.Node {
+	if c == nil || mod == nil || pkg == nil {
+		return nil
+	}
+	repo := uniast.NewRepository(mod.Name)
+	snapshot := uniast.NewModule(mod.Name, ".", mod.Language)
+	repo.SetModule(mod.Name, snapshot)
+	snapshotPkg := uniast.NewPackage(pkg.PkgPath)
+	snapshot.Packages[pkg.PkgPath] = snapshotPkg
+
+	var nodes []*uniast.Node
+	for _, name := range sortedKeys(pkg.Types) {
+		if len(nodes) >= c.maxNodes {
+			return nodes
+		}
+		t := pkg.Types[name]
+		snapshotPkg.Types[name] = t
+		nodes = append(nodes, uniast.NewNode(t.Identity, uniast.TYPE, &repo))
+	}
+	for _, name := range sortedKeys(pkg.Functions) {
+		if len(nodes) >= c.maxNodes {
+			return nodes
+		}
+		f := pkg.Functions[name]
+		snapshotPkg.Functions[name] = f
+		nodes = append(nodes, uniast.NewNode(f.Identity, uniast.FUNC, &repo))
+	}
+	for _, name := range sortedKeys(pkg.Vars) {
+		if len(nodes) >= c.maxNodes {
+			return nodes
+		}
+		v := pkg.Vars[name]
+		snapshotPkg.Vars[name] = v
+		nodes = append(nodes, uniast.NewNode(v.Identity, uniast.VAR, &repo))
+	}
+	return nodes
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,73 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import "testing"
+
+func TestConvertJavaPackageToGoModule(t *testing.T) {
+	// ConvertJavaPackageToGoModule returns the last segment for flat package structure
+	tests := []struct {
+		javaPackage string
+		expected    string
+	}{
+		{"com.example.project", "project"},
+		{"org.apache.commons", "commons"},
+		{"java.lang", "lang"},
+		{"com.example.model", "model"},
+		{"service", "service"},
+		// single segment
+		{"util", "util"},
+		{"impl", "impl"},
+		// impl is prefixed by its parent
+		{"com.example.service.impl", "serviceimpl"},
+		{"com.example.UserService.Impl", "userserviceimpl"},
+		// digits and major versions
+		{"com.example.v2.api", "api"},
+		{"com.example.api.v2", "api"},
+		{"com.example.v2", "example"},
+		{"v2", "v2"},
+		{"com.example.oauth2", "oauth2"},
+		{"com.example.3d", "_3d"},
+		// Go style paths
+		{"service/user", "user"},
+		{"github.com/example/project/model", "model"},
+		{"service/user/", "user"},
+		// invalid chars and case
+		{"com.example.MyService", "myservice"},
+		{"com.example.user_service", "userservice"},
+		{"com.example.user-service", "userservice"},
+		{"com.example.$proxy", "proxy"},
+		// Go keywords
+		{"com.example.type", "typepkg"},
+		{"com.example.func", "funcpkg"},
+		// empty segments
+		{"com.example.", "example"},
+		{".model", "model"},
+		{"com..model", "model"},
+		{"com.example._", "example"},
+		// no name
+		{"", ""},
+		{"...", ""},
+	}
+
+	for _, tt := range tests {
+		result := ConvertJavaPackageToGoModule(tt.javaPackage)
+		if result != tt.expected {
+			t.Errorf("ConvertJavaPackageToGoModule(%q) = %q, want %q", tt.javaPackage, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// libraryDependency is a third-party library in the form of Module.ExternalDependencies
+type libraryDependency struct {
+	Name    string // go module path, maven groupId:artifactId, crate name or PyPI project
+	Version string
+}
+
+// equivalentLibraries are the libraries playing the same role in each language,
+// used to map the manifest dependencies of the source repo to the target language.
+// A language without an entry uses its standard library or has no common equivalent.
+var equivalentLibraries = []map[uniast.Language]libraryDependency{
+	// redis client
+	{
+		uniast.Golang: {"github.com/redis/go-redis/v9", "v9.5.1"},
+		uniast.Java:   {"redis.clients:jedis", "5.1.0"},
+		uniast.Rust:   {"redis", "0.25"},
+		uniast.Python: {"redis", ">=5.0"},
+	},
+	// MySQL driver
+	{
+		uniast.Golang: {"github.com/go-sql-driver/mysql", "v1.8.1"},
+		uniast.Java:   {"com.mysql:mysql-connector-j", "8.3.0"},
+		uniast.Rust:   {"mysql", "25.0"},
+		uniast.Python: {"PyMySQL", ">=1.1"},
+	},
+	// PostgreSQL driver
+	{
+		uniast.Golang: {"github.com/lib/pq", "v1.10.9"},
+		uniast.Java:   {"org.postgresql:postgresql", "42.7.3"},
+		uniast.Rust:   {"postgres", "0.19"},
+		uniast.Python: {"psycopg2", ">=2.9"},
+	},
+	// JWT
+	{
+		uniast.Golang: {"github.com/golang-jwt/jwt/v5", "v5.2.1"},
+		uniast.Java:   {"io.jsonwebtoken:jjwt-api", "0.12.5"},
+		uniast.Rust:   {"jsonwebtoken", "9.3"},
+		uniast.Python: {"PyJWT", ">=2.8"},
+	},
+	// YAML
+	{
+		uniast.Golang: {"gopkg.in/yaml.v3", "v3.0.1"},
+		uniast.Java:   {"org.yaml:snakeyaml", "2.2"},
+		uniast.Rust:   {"serde_yaml", "0.9"},
+		uniast.Python: {"PyYAML", ">=6.0"},
+	},
+	// UUID
+	{
+		uniast.Golang: {"github.com/google/uuid", "v1.6.0"},
+		uniast.Rust:   {"uuid", "1.8"},
+	},
+}
+
+// mapExternalDependency returns the library of dstLang equivalent to the dependency name of srcLang.
+// The dependency is kept as is if both languages are the same.
+func mapExternalDependency(srcLang, dstLang uniast.Language, name, version string) (libraryDependency, bool) {
+	if srcLang == dstLang {
+		return libraryDependency{name, version}, true
+	}
+	for _, libs := range equivalentLibraries {
+		if src, ok := libs[srcLang]; ok && src.Name == name {
+			dst, ok := libs[dstLang]
+			return dst, ok
+		}
+	}
+	return libraryDependency{}, false
+}
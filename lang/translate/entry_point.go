@@ -17,6 +17,9 @@
 package translate
 
 import (
+	"context"
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -30,6 +33,8 @@ const (
 	EntryPointSpringBoot
 	EntryPointRestController
 	EntryPointScheduledTask
+	// EntryPointPythonMain is the body of a Python `if __name__ == "__main__":` block
+	EntryPointPythonMain
 )
 
 // EntryPointInfo contains information about a detected entry point
@@ -45,7 +50,10 @@ type EntryPointInfo struct {
 
 // EntryPointHandler handles entry point detection and generation
 type EntryPointHandler struct {
-	targetLang uniast.Language
+	targetLang    uniast.Language
+	srcLang       uniast.Language
+	srcRepo       *uniast.Repository
+	llmTranslator LLMTranslateFunc
 }
 
 // NewEntryPointHandler creates a new EntryPointHandler
@@ -55,29 +63,45 @@ func NewEntryPointHandler(targetLang uniast.Language) *EntryPointHandler {
 	}
 }
 
+// SetSource sets the source language and repository, entry points which only exist
+// in the source code (e.g. Python `if __name__ == "__main__":` blocks) are detected from srcRepo
+func (h *EntryPointHandler) SetSource(srcLang uniast.Language, srcRepo *uniast.Repository) {
+	h.srcLang = srcLang
+	h.srcRepo = srcRepo
+}
+
+// SetLLMTranslator sets the LLM callback used to translate the source entry point bodies
+func (h *EntryPointHandler) SetLLMTranslator(fn LLMTranslateFunc) {
+	h.llmTranslator = fn
+}
+
 // DetectEntryPoints finds all entry points in the repository
 func (h *EntryPointHandler) DetectEntryPoints(repo *uniast.Repository) []EntryPointInfo {
 	var entryPoints []EntryPointInfo
 
-	for _, mod := range repo.Modules {
-		if mod.IsExternal() {
-			continue
+	// Check functions for main methods
+	for _, fn := range repo.AllFunctions {
+		if ep := h.detectFunctionEntryPoint(fn); ep != nil {
+			entryPoints = append(entryPoints, *ep)
+		}
+		if ep := detectPythonMain(fn.Identity, fn.Content); ep != nil {
+			entryPoints = append(entryPoints, *ep)
 		}
+	}
 
-		for _, pkg := range mod.Packages {
-			// Check functions for main methods
-			for _, fn := range pkg.Functions {
-				if ep := h.detectFunctionEntryPoint(fn); ep != nil {
-					entryPoints = append(entryPoints, *ep)
-				}
+	// Check the source functions for Python main blocks, which are not kept by the translation
+	if h.srcRepo != nil && h.srcRepo != repo && h.srcLang == uniast.Python {
+		for _, fn := range h.srcRepo.AllFunctions {
+			if ep := detectPythonMain(fn.Identity, fn.Content); ep != nil {
+				entryPoints = append(entryPoints, *ep)
 			}
+		}
+	}
 
-			// Check types for annotated classes (Spring Boot, etc.)
-			for _, typ := range pkg.Types {
-				if eps := h.detectTypeEntryPoints(typ); len(eps) > 0 {
-					entryPoints = append(entryPoints, eps...)
-				}
-			}
+	// Check types for annotated classes (Spring Boot, etc.)
+	for _, typ := range repo.AllTypes {
+		if eps := h.detectTypeEntryPoints(typ); len(eps) > 0 {
+			entryPoints = append(entryPoints, eps...)
 		}
 	}
 
@@ -111,6 +135,63 @@ func (h *EntryPointHandler) detectFunctionEntryPoint(fn *uniast.Function) *Entry
 	return nil
 }
 
+// pythonMainRegex matches the `if __name__ == "__main__":` line, with the indent and the inline body
+var pythonMainRegex = regexp.MustCompile(`(?m)^([ \t]*)if\s+__name__\s*==\s*["']__main__["']\s*:[ \t]*(.*)$`)
+
+// detectPythonMain extracts the body of the `if __name__ == "__main__":` block in content
+func detectPythonMain(id uniast.Identity, content string) *EntryPointInfo {
+	loc := pythonMainRegex.FindStringSubmatchIndex(content)
+	if loc == nil {
+		return nil
+	}
+	indent := content[loc[2]:loc[3]]
+	body := strings.TrimSpace(content[loc[4]:loc[5]])
+	if body == "" {
+		// the body is the following lines indented deeper than the if statement
+		var lines []string
+		for _, line := range strings.Split(strings.TrimPrefix(content[loc[1]:], "\n"), "\n") {
+			if strings.TrimSpace(line) != "" && len(line)-len(strings.TrimLeft(line, " \t")) <= len(indent) {
+				break
+			}
+			lines = append(lines, line)
+		}
+		body = strings.TrimSpace(dedent(lines))
+	}
+	if body == "" {
+		return nil
+	}
+	return &EntryPointInfo{
+		Type:       EntryPointPythonMain,
+		Name:       id.Name,
+		Package:    id.PkgPath,
+		MethodName: "main",
+		Content:    body,
+		Identity:   id,
+	}
+}
+
+// dedent removes the common leading whitespaces of the non-empty lines
+func dedent(lines []string) string {
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if common < 0 || n < common {
+			common = n
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= common && common > 0 {
+			lines[i] = line[common:]
+		} else {
+			lines[i] = strings.TrimSpace(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // detectTypeEntryPoints checks if a type contains entry point annotations
 func (h *EntryPointHandler) detectTypeEntryPoints(typ *uniast.Type) []EntryPointInfo {
 	var entryPoints []EntryPointInfo
@@ -175,7 +256,12 @@ func (h *EntryPointHandler) GenerateDefaultEntry(repo *uniast.Repository) (*unia
 		return repo, nil
 	}
 
-	// Create or get main package
+	h.addMainFunction(targetMod, modName, entryContent)
+	return repo, nil
+}
+
+// mainPackage returns the main package of the module, which is created if missing
+func (h *EntryPointHandler) mainPackage(targetMod *uniast.Module) *uniast.Package {
 	mainPkgPath := h.getMainPackagePath()
 	mainPkg, exists := targetMod.Packages[uniast.PkgPath(mainPkgPath)]
 	if !exists {
@@ -188,6 +274,14 @@ func (h *EntryPointHandler) GenerateDefaultEntry(repo *uniast.Repository) (*unia
 		}
 		targetMod.Packages[uniast.PkgPath(mainPkgPath)] = mainPkg
 	}
+	mainPkg.IsMain = true
+	return mainPkg
+}
+
+// addMainFunction adds the main function with content into the main package of the module
+func (h *EntryPointHandler) addMainFunction(targetMod *uniast.Module, modName string, content string) {
+	mainPkgPath := h.getMainPackagePath()
+	mainPkg := h.mainPackage(targetMod)
 
 	// Add main function
 	mainFunc := &uniast.Function{
@@ -201,21 +295,128 @@ func (h *EntryPointHandler) GenerateDefaultEntry(repo *uniast.Repository) (*unia
 			File: h.getMainFileName(),
 			Line: 1,
 		},
-		Content: entryContent,
+		Content: content,
 	}
 	mainPkg.Functions["main"] = mainFunc
-	mainPkg.IsMain = true
-
-	return repo, nil
 }
 
 // ConvertEntryPoints converts existing entry points to target language style
 func (h *EntryPointHandler) ConvertEntryPoints(repo *uniast.Repository, entryPoints []EntryPointInfo) (*uniast.Repository, error) {
-	// Entry points have already been translated by LLM
-	// This method can be used for additional adjustments if needed
+	// Entry points have already been translated by LLM, except Python main blocks
+	// which are not nodes: they are converted to the Go main function
+	if h.targetLang != uniast.Golang {
+		return repo, nil
+	}
+	// Rust `fn main()` is translated as a node with the Rust specific notes (std::env::args, println!, Result returns...)
+	// into the package of the crate, it is moved to the main package. If it failed, it is converted here from the source.
+	if h.srcLang == uniast.Rust {
+		if h.moveMainFunction(repo) {
+			return repo, nil
+		}
+		ep := h.findRustMain()
+		if ep == nil {
+			return repo, nil
+		}
+		entryPoints = []EntryPointInfo{*ep}
+	}
+	for _, ep := range entryPoints {
+		if ep.Type != EntryPointPythonMain && h.srcLang != uniast.Rust {
+			continue
+		}
+		var targetMod *uniast.Module
+		var modName string
+		for name, mod := range repo.Modules {
+			if !mod.IsExternal() {
+				targetMod, modName = mod, name
+				break
+			}
+		}
+		if targetMod == nil {
+			return repo, nil
+		}
+		if pkg := targetMod.Packages[uniast.PkgPath(h.getMainPackagePath())]; pkg != nil && pkg.Functions["main"] != nil {
+			return repo, nil
+		}
+		body := h.translateEntryBody(ep)
+		h.addMainFunction(targetMod, modName, "func main() {\n"+prefixLines(strings.Split(body, "\n"), "\t", "")+"\n}")
+		// only one main function is allowed
+		break
+	}
 	return repo, nil
 }
 
+// moveMainFunction moves the main function, which is not a method, of the packages of repo into the main package.
+// It returns false if repo has no main function.
+func (h *EntryPointHandler) moveMainFunction(repo *uniast.Repository) bool {
+	mainPkgPath := uniast.PkgPath(h.getMainPackagePath())
+	for _, mod := range repo.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		for pkgPath, pkg := range mod.Packages {
+			for name, fn := range pkg.Functions {
+				if fn.Name != "main" || fn.IsMethod {
+					continue
+				}
+				if pkgPath != mainPkgPath {
+					delete(pkg.Functions, name)
+					fn.PkgPath = string(mainPkgPath)
+					fn.File = h.getMainFileName()
+					h.mainPackage(mod).Functions["main"] = fn
+				}
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findRustMain returns the `fn main()` of the source Rust repository, the first one by identity if there are several binaries
+func (h *EntryPointHandler) findRustMain() *EntryPointInfo {
+	if h.srcRepo == nil {
+		return nil
+	}
+	var main *EntryPointInfo
+	for _, fn := range h.srcRepo.AllFunctions {
+		if fn.IsMethod {
+			continue
+		}
+		if ep := h.detectFunctionEntryPoint(fn); ep != nil && (main == nil || ep.Identity.Full() < main.Identity.Full()) {
+			main = ep
+		}
+	}
+	return main
+}
+
+// translateEntryBody translates the body of a source entry point into the statements of the Go main function.
+// The body is kept as comments if no LLMTranslator is set or the LLM call fails.
+func (h *EntryPointHandler) translateEntryBody(ep EntryPointInfo) string {
+	commented := "// TODO: translate the entry point of " + ep.Identity.Full() + "\n" + prefixLines(strings.Split(ep.Content, "\n"), "// ", "//")
+	if h.llmTranslator == nil {
+		return commented
+	}
+	srcLang := uniast.Python
+	if ep.Type != EntryPointPythonMain && h.srcLang != "" {
+		srcLang = h.srcLang
+	}
+	req := &LLMTranslateRequest{
+		SourceLanguage: srcLang,
+		TargetLanguage: h.targetLang,
+		NodeType:       uniast.FUNC,
+		SourceContent:  ep.Content,
+		Identity:       ep.Identity,
+	}
+	req.Prompt = NewPromptBuilder(req.SourceLanguage, req.TargetLanguage, nil).BuildEntryPointPrompt(req)
+	resp, err := h.llmTranslator(context.Background(), req)
+	if err == nil && resp.Error != "" {
+		err = fmt.Errorf("LLM error: %s", resp.Error)
+	}
+	if err != nil {
+		return fmt.Sprintf("// translate entry point failed: %v\n%s", err, commented)
+	}
+	return strings.TrimSpace(trimCodeFence(resp.TargetContent))
+}
+
 // generateEntryContent generates the entry point content for target language
 func (h *EntryPointHandler) generateEntryContent() string {
 	switch h.targetLang {
@@ -291,7 +492,7 @@ func (h *EntryPointHandler) getMainFileName() string {
 // HasMainEntry checks if there's already a main entry point
 func (h *EntryPointHandler) HasMainEntry(entryPoints []EntryPointInfo) bool {
 	for _, ep := range entryPoints {
-		if ep.Type == EntryPointMain || ep.Type == EntryPointSpringBoot {
+		if ep.Type == EntryPointMain || ep.Type == EntryPointSpringBoot || ep.Type == EntryPointPythonMain {
 			return true
 		}
 	}
@@ -18,6 +18,7 @@ package translate
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -29,6 +30,8 @@ type FrameworkIntegrator struct {
 	framework      string
 	generatedFiles map[string]string
 	routes         []RouteInfo
+	srcLang        uniast.Language
+	srcRepo        *uniast.Repository
 }
 
 // RouteInfo contains information about a detected route
@@ -49,6 +52,13 @@ func NewFrameworkIntegrator(targetLang uniast.Language, framework string) *Frame
 	}
 }
 
+// SetSource sets the source language and repository, routes which can only be recognized
+// from the source code (e.g. Express/Fastify for TypeScript) are detected from srcRepo
+func (f *FrameworkIntegrator) SetSource(srcLang uniast.Language, srcRepo *uniast.Repository) {
+	f.srcLang = srcLang
+	f.srcRepo = srcRepo
+}
+
 // Integrate performs framework integration
 func (f *FrameworkIntegrator) Integrate(repo *uniast.Repository) (*uniast.Repository, error) {
 	// Detect REST endpoints
@@ -88,19 +98,14 @@ func (f *FrameworkIntegrator) GetDependencies() []string {
 
 // detectRoutes finds all REST endpoints in the repository
 func (f *FrameworkIntegrator) detectRoutes(repo *uniast.Repository) {
-	for _, mod := range repo.Modules {
-		if mod.IsExternal() {
-			continue
-		}
-
-		for _, pkg := range mod.Packages {
-			for _, typ := range pkg.Types {
-				f.detectRoutesFromType(typ)
-			}
-			for _, fn := range pkg.Functions {
-				f.detectRoutesFromFunction(fn)
-			}
-		}
+	if f.srcLang == uniast.TypeScript && f.srcRepo != nil {
+		f.detectTSRoutes(f.srcRepo)
+	}
+	for _, typ := range repo.AllTypes {
+		f.detectRoutesFromType(typ)
+	}
+	for _, fn := range repo.AllFunctions {
+		f.detectRoutesFromFunction(fn)
 	}
 }
 
@@ -182,6 +187,137 @@ func (f *FrameworkIntegrator) detectRoutesFromFunction(fn *uniast.Function) {
 	// This can be extended for other patterns
 }
 
+// tsRouteRegex matches Express `router.get('/path', ...)` and Fastify `fastify.post('/path', ...)` calls,
+// the receiver must look like an app/router/server object to avoid matching `map.get('key')`
+var tsRouteRegex = regexp.MustCompile("(?i)\\b([\\w$]*(?:app|router|routes|server|fastify|api))\\s*\\.\\s*(get|post|put|delete|patch|head|options)\\s*\\(\\s*(?:'([^']*)'|\"([^\"]*)\"|`([^`]*)`)\\s*,")
+
+// tsNamedFuncRegex matches an inline named function handler like `async function getUser(req, res) {...}`
+var tsNamedFuncRegex = regexp.MustCompile(`^(?:async\s+)?function\s*\*?\s*([\w$]+)`)
+
+// tsHandlerRefRegex matches a handler reference like `getUser` or `userController.getUser`
+var tsHandlerRefRegex = regexp.MustCompile(`^[\w$]+(?:\s*\.\s*[\w$]+)*$`)
+
+// detectTSRoutes detects Express and Fastify routes from TypeScript source nodes
+func (f *FrameworkIntegrator) detectTSRoutes(repo *uniast.Repository) {
+	seen := make(map[string]bool)
+	detect := func(name, content string) {
+		for _, r := range extractTSRoutes(content) {
+			key := r.Method + " " + r.Path
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			r.SourceType = name
+			f.routes = append(f.routes, r)
+		}
+	}
+	for _, fn := range repo.AllFunctions {
+		detect(fn.Name, fn.Content)
+	}
+	for _, v := range repo.AllVars {
+		detect(v.Name, v.Content)
+	}
+	for _, typ := range repo.AllTypes {
+		detect(typ.Name, typ.Content)
+	}
+}
+
+// extractTSRoutes extracts the routes registered by Express/Fastify shorthand methods in content
+func extractTSRoutes(content string) []RouteInfo {
+	var routes []RouteInfo
+	for _, m := range tsRouteRegex.FindAllStringSubmatchIndex(content, -1) {
+		method := strings.ToUpper(content[m[4]:m[5]])
+		var path string
+		for g := 3; g <= 5; g++ {
+			if m[2*g] >= 0 {
+				path = content[m[2*g]:m[2*g+1]]
+				break
+			}
+		}
+		handler := tsHandlerName(lastCallArg(content[m[1]:]))
+		if handler == "" {
+			handler = routeHandlerName(method, path)
+		}
+		routes = append(routes, RouteInfo{
+			Method:      method,
+			Path:        path,
+			HandlerName: handler,
+		})
+	}
+	return routes
+}
+
+// lastCallArg returns the last top-level argument of the call whose argument list starts at the head of rest
+// and ends at the matching close parenthesis
+func lastCallArg(rest string) string {
+	depth := 0
+	start := 0
+	var quote byte
+	for i := 0; i < len(rest); i++ {
+		c := rest[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(', '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case ')':
+			if depth == 0 {
+				return strings.TrimSpace(rest[start:i])
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				// skip a trailing comma before the close parenthesis
+				if next := strings.TrimSpace(rest[i+1:]); !strings.HasPrefix(next, ")") {
+					start = i + 1
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// tsHandlerName returns the handler name of a route argument, or empty if it is an anonymous function
+func tsHandlerName(arg string) string {
+	if m := tsNamedFuncRegex.FindStringSubmatch(arg); m != nil {
+		return m[1]
+	}
+	if tsHandlerRefRegex.MatchString(arg) {
+		// `userController.getUser` -> `getUser`
+		if idx := strings.LastIndex(arg, "."); idx != -1 {
+			return strings.TrimSpace(arg[idx+1:])
+		}
+		return arg
+	}
+	return ""
+}
+
+// routeHandlerName derives a handler name from the method and path, e.g. GET /users/:id -> getUsersId
+func routeHandlerName(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	words := strings.FieldsFunc(path, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	if len(words) == 0 {
+		words = []string{"root"}
+	}
+	for _, w := range words {
+		sb.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return sb.String()
+}
+
 // integrateGo generates Go web framework integration
 func (f *FrameworkIntegrator) integrateGo(repo *uniast.Repository) (*uniast.Repository, error) {
 	switch f.framework {
@@ -189,6 +325,8 @@ func (f *FrameworkIntegrator) integrateGo(repo *uniast.Repository) (*uniast.Repo
 		return f.integrateGin(repo)
 	case "echo":
 		return f.integrateEcho(repo)
+	case "hertz":
+		return f.integrateHertz(repo)
 	default:
 		return f.integrateGin(repo) // Default to Gin
 	}
@@ -240,9 +378,8 @@ func RegisterRoutes(r *gin.Engine) {
 `)
 
 	for _, route := range f.routes {
-		method := strings.ToLower(route.Method)
 		sb.WriteString(fmt.Sprintf("\t\tapi.%s(\"%s\", %sHandler)\n",
-			strings.Title(method), route.Path, frameworkToCamelCase(route.HandlerName)))
+			strings.ToUpper(route.Method), route.Path, frameworkToCamelCase(route.HandlerName)))
 	}
 
 	sb.WriteString(`	}
@@ -250,8 +387,13 @@ func RegisterRoutes(r *gin.Engine) {
 
 `)
 
-	// Generate handler stubs
+	// Generate handler stubs, routes may share the same handler
+	seen := make(map[string]bool)
 	for _, route := range f.routes {
+		if seen[route.HandlerName] {
+			continue
+		}
+		seen[route.HandlerName] = true
 		sb.WriteString(fmt.Sprintf(`// %sHandler handles %s %s
 func %sHandler(c *gin.Context) {
 	// TODO: Implement handler logic
@@ -318,8 +460,13 @@ func RegisterRoutes(e *echo.Echo) {
 
 	sb.WriteString("}\n\n")
 
-	// Generate handler stubs
+	// Generate handler stubs, routes may share the same handler
+	seen := make(map[string]bool)
 	for _, route := range f.routes {
+		if seen[route.HandlerName] {
+			continue
+		}
+		seen[route.HandlerName] = true
 		sb.WriteString(fmt.Sprintf(`// %sHandler handles %s %s
 func %sHandler(c echo.Context) error {
 	// TODO: Implement handler logic
@@ -332,6 +479,78 @@ func %sHandler(c echo.Context) error {
 	return sb.String()
 }
 
+// integrateHertz generates CloudWeGo Hertz framework code
+func (f *FrameworkIntegrator) integrateHertz(repo *uniast.Repository) (*uniast.Repository, error) {
+	mainContent := `package main
+
+import (
+	"github.com/cloudwego/hertz/pkg/app/server"
+)
+
+func main() {
+	h := server.Default(server.WithHostPorts(":8080"))
+	
+	// Register routes
+	registerRoutes(h)
+	
+	// Start server
+	h.Spin()
+}
+`
+
+	routesContent := f.generateHertzRoutes()
+
+	f.generatedFiles["cmd/main.go"] = mainContent
+	f.generatedFiles["internal/router/routes.go"] = routesContent
+
+	return repo, nil
+}
+
+// generateHertzRoutes generates Hertz route registration code
+func (f *FrameworkIntegrator) generateHertzRoutes() string {
+	var sb strings.Builder
+	sb.WriteString(`package router
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// RegisterRoutes registers all API routes
+func RegisterRoutes(h *server.Hertz) {
+	api := h.Group("/api")
+`)
+
+	for _, route := range f.routes {
+		sb.WriteString(fmt.Sprintf("\tapi.%s(\"%s\", %sHandler)\n",
+			strings.ToUpper(route.Method), route.Path, frameworkToCamelCase(route.HandlerName)))
+	}
+
+	sb.WriteString("}\n\n")
+
+	// Generate handler stubs, routes may share the same handler
+	seen := make(map[string]bool)
+	for _, route := range f.routes {
+		if seen[route.HandlerName] {
+			continue
+		}
+		seen[route.HandlerName] = true
+		sb.WriteString(fmt.Sprintf(`// %sHandler handles %s %s
+func %sHandler(ctx context.Context, c *app.RequestContext) {
+	// TODO: Implement handler logic
+	c.JSON(http.StatusOK, utils.H{"message": "success"})
+}
+
+`, frameworkToCamelCase(route.HandlerName), route.Method, route.Path, frameworkToCamelCase(route.HandlerName)))
+	}
+
+	return sb.String()
+}
+
 // integrateRust generates Rust web framework integration
 func (f *FrameworkIntegrator) integrateRust(repo *uniast.Repository) (*uniast.Repository, error) {
 	switch f.framework {
@@ -503,6 +722,8 @@ func (f *FrameworkIntegrator) getGoDependencies() []string {
 		return []string{"github.com/gin-gonic/gin v1.9.1"}
 	case "echo":
 		return []string{"github.com/labstack/echo/v4 v4.11.4"}
+	case "hertz":
+		return []string{"github.com/cloudwego/hertz v0.9.0"}
 	default:
 		return []string{"github.com/gin-gonic/gin v1.9.1"}
 	}
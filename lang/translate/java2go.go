@@ -170,6 +170,7 @@ func TranslateJava2Go(ctx context.Context, javaRepo *uniast.Repository, opts Jav
 					Exported:          javaFn.Exported,
 					IsMethod:          javaFn.IsMethod,
 					IsInterfaceMethod: javaFn.IsInterfaceMethod,
+					IsConstructor:     javaFn.IsConstructor,
 					Identity: uniast.Identity{
 						ModPath: goModName, // All use the same module
 						PkgPath: goPkgPath,
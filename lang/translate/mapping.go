@@ -17,6 +17,7 @@
 package translate
 
 import (
+	"go/token"
 	"strings"
 )
 
@@ -159,16 +160,62 @@ func ConvertPackagePath(javaPath string) string {
 // ConvertJavaPackageToGoModule converts Java package name to simplified Go package name
 // Example: com.example.common.model -> model
 // Example: com.example.core.service -> service
-// Takes only the last segment as the package name for flat structure
+// Takes only the last segment as the package name for flat structure, which is made a valid Go package name:
+// lower case letters and digits only, e.g. com.example.MyService -> myservice.
+// Some segments can't be a package name alone:
+//   - impl is prefixed by its parent, e.g. com.example.service.impl -> serviceimpl
+//   - major versions are skipped, e.g. com.example.api.v2 -> api
+//   - Go keywords are suffixed by pkg, e.g. com.example.type -> typepkg
+//
+// Go style paths are accepted too, e.g. service/user -> user.
+// It returns an empty string if javaPackage contains no name.
 func ConvertJavaPackageToGoModule(javaPackage string) string {
-	parts := strings.Split(javaPackage, ".")
-	if len(parts) == 0 {
-		return javaPackage
+	parts := strings.FieldsFunc(javaPackage, func(r rune) bool {
+		return r == '.' || r == '/' || r == '\\'
+	})
+	var segs []string
+	for _, part := range parts {
+		if seg := goPackageSegment(part); seg != "" {
+			segs = append(segs, seg)
+		}
+	}
+	// drop the major versions at the end, but keep a version-only package
+	for len(segs) > 1 && isMajorVersion(segs[len(segs)-1]) {
+		segs = segs[:len(segs)-1]
+	}
+	if len(segs) == 0 {
+		return ""
 	}
 
 	// Take only the last segment as the package name
 	// This creates a flat structure: model, service, repository, etc.
-	return parts[len(parts)-1]
+	name := segs[len(segs)-1]
+	if name == "impl" && len(segs) > 1 && !isMajorVersion(segs[len(segs)-2]) {
+		name = segs[len(segs)-2] + name
+	}
+	if token.IsKeyword(name) {
+		name += "pkg"
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// goPackageSegment lowers the segment of a package path and drops the chars not allowed in a Go package name
+func goPackageSegment(part string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(part) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// isMajorVersion tells if the package segment is a major version, e.g. v2
+func isMajorVersion(seg string) bool {
+	return len(seg) > 1 && seg[0] == 'v' && strings.Trim(seg[1:], "0123456789") == ""
 }
 
 // GetGoModuleNameFromGroupId converts Java groupId to Go module name
@@ -40,27 +40,6 @@ func TestGetGoType(t *testing.T) {
 	}
 }
 
-func TestConvertJavaPackageToGoModule(t *testing.T) {
-	// ConvertJavaPackageToGoModule returns the last segment for flat package structure
-	tests := []struct {
-		javaPackage string
-		expected    string
-	}{
-		{"com.example.project", "project"},
-		{"org.apache.commons", "commons"},
-		{"java.lang", "lang"},
-		{"com.example.model", "model"},
-		{"service", "service"},
-	}
-
-	for _, tt := range tests {
-		result := ConvertJavaPackageToGoModule(tt.javaPackage)
-		if result != tt.expected {
-			t.Errorf("ConvertJavaPackageToGoModule(%q) = %q, want %q", tt.javaPackage, result, tt.expected)
-		}
-	}
-}
-
 func TestGetGoModuleNameFromGroupId(t *testing.T) {
 	// GetGoModuleNameFromGroupId converts groupId to Go module name
 	tests := []struct {
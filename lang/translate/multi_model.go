@@ -0,0 +1,78 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// MultiModelTranslator routes the translation of each node to the LLM of its kind,
+// e.g. a powerful model for types and a cheaper one for vars.
+// Each model is an LLMTranslateFunc built by the caller from its model config,
+// since this package can't depend on the LLM clients.
+// A nil model falls back to TranslateOptions.LLMTranslator.
+type MultiModelTranslator struct {
+	TypeModel     LLMTranslateFunc
+	FunctionModel LLMTranslateFunc
+	VarModel      LLMTranslateFunc
+}
+
+// Translate implements LLMTranslateFunc by calling the model of req.NodeType
+func (m *MultiModelTranslator) Translate(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+	model := m.model(req.NodeType)
+	if model == nil {
+		return nil, fmt.Errorf("no LLM model to translate %s nodes", req.NodeType)
+	}
+	return model(ctx, req)
+}
+
+func (m *MultiModelTranslator) model(typ uniast.NodeType) LLMTranslateFunc {
+	switch typ {
+	case uniast.TYPE:
+		return m.TypeModel
+	case uniast.FUNC:
+		return m.FunctionModel
+	case uniast.VAR:
+		return m.VarModel
+	default:
+		return nil
+	}
+}
+
+// withDefault returns a copy of m whose missing models are def
+func (m *MultiModelTranslator) withDefault(def LLMTranslateFunc) *MultiModelTranslator {
+	ret := *m
+	for _, model := range []*LLMTranslateFunc{&ret.TypeModel, &ret.FunctionModel, &ret.VarModel} {
+		if *model == nil {
+			*model = def
+		}
+	}
+	return &ret
+}
+
+// withLLMRouted returns the options whose LLMTranslator is routed by MultiModel if set.
+// MultiModel is cleared then, so that the NodeTranslator of a BaseTransformer doesn't route it twice
+func (o TranslateOptions) withLLMRouted() TranslateOptions {
+	if o.MultiModel != nil {
+		o.LLMTranslator = o.MultiModel.withDefault(o.LLMTranslator).Translate
+		o.MultiModel = nil
+	}
+	return o
+}
@@ -18,9 +18,13 @@ package translate
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"unicode"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -58,45 +62,69 @@ type NodeTranslator struct {
 	opts          TranslateOptions
 	promptBuilder *PromptBuilder
 	typeHints     *TypeHints
+	qualityScorer *QualityScorer
+
+	// contentCache caches the accepted translations of this run,
+	// content key (see contentKey) => *LLMTranslateResponse
+	contentCache sync.Map
+	cacheHits    atomic.Int64
 }
 
 // NewNodeTranslator creates a new NodeTranslator
 func NewNodeTranslator(opts TranslateOptions, typeHints *TypeHints) *NodeTranslator {
+	opts = opts.withLLMRouted()
 	return &NodeTranslator{
 		opts:          opts,
-		promptBuilder: NewPromptBuilder(opts.SourceLanguage, opts.TargetLanguage, typeHints),
+		promptBuilder: NewPromptBuilder(opts.SourceLanguage, opts.TargetLanguage, typeHints).WithMaxPromptLength(opts.MaxPromptLength),
 		typeHints:     typeHints,
+		qualityScorer: NewQualityScorer(),
 	}
 }
 
 // TranslateType translates a Type node
 func (t *NodeTranslator) TranslateType(ctx context.Context, src *uniast.Type, tctx *TranslateContext) (*uniast.Type, error) {
 	// 1. Build LLM request
+	req := t.typeRequest(src, tctx)
+	req.Prompt = t.promptBuilder.BuildTypePrompt(req)
+
+	// 2. Call LLM
+	resp, err := t.callLLM(ctx, req, src.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Build target Type
+	return t.buildTargetType(src, tctx, resp.TargetContent), nil
+}
+
+// typeRequest builds the LLM request of a Type node, without the prompt
+func (t *NodeTranslator) typeRequest(src *uniast.Type, tctx *TranslateContext) *LLMTranslateRequest {
 	sourceContent, truncated := truncateSourceForPrompt(src.Content, t.opts.MaxSourceChars)
-	req := &LLMTranslateRequest{
+	return &LLMTranslateRequest{
 		SourceLanguage:  t.opts.SourceLanguage,
 		TargetLanguage:  t.opts.TargetLanguage,
 		NodeType:        uniast.TYPE,
+		NodeKind:        src.NodeKind(),
 		SourceContent:   sourceContent,
 		SourceTruncated: truncated,
 		Identity:        src.Identity,
 		TypeHints:       t.typeHints,
 		Dependencies:    t.collectDependencyHints(src.Identity, tctx),
+		ContextNodes:    t.collectContextNodes(src.Identity, tctx),
+		PackageContext:  tctx.PackageContext,
+		DocString:       src.DocString,
+		TargetComment:   t.translateDocComment(src.Content, t.convertTypeName(src.Name, src.Exported)),
+		TypeParams:      src.TypeParams,
+		Tags:            src.Tags,
+		BuildError:      t.opts.BuildErrors[src.Identity.Full()],
 	}
-	req.Prompt = t.promptBuilder.BuildTypePrompt(req)
-
-	// 2. Call LLM
-	resp, err := t.opts.LLMTranslator(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("LLM call failed: %w", err)
-	}
-	if resp.Error != "" {
-		return nil, fmt.Errorf("LLM error: %s", resp.Error)
-	}
+}
 
-	// 3. Build target Type
+// buildTargetType builds the target Type of src with the given content
+func (t *NodeTranslator) buildTargetType(src *uniast.Type, tctx *TranslateContext, content string) *uniast.Type {
 	targetName := t.convertTypeName(src.Name, src.Exported)
-	targetType := &uniast.Type{
+	tctx.AddTranslatedContent(src.Identity, content)
+	return &uniast.Type{
 		Exported: src.Exported,
 		TypeKind: src.TypeKind,
 		Identity: uniast.Identity{
@@ -108,17 +136,30 @@ func (t *NodeTranslator) TranslateType(ctx context.Context, src *uniast.Type, tc
 			File: t.convertFilePath(src.File),
 			Line: src.Line,
 		},
-		Content: resp.TargetContent,
+		Content: content,
 	}
-
-	return targetType, nil
 }
 
 // TranslateFunction translates a Function node
 func (t *NodeTranslator) TranslateFunction(ctx context.Context, src *uniast.Function, tctx *TranslateContext) (*uniast.Function, error) {
 	// 1. Build LLM request
+	req := t.functionRequest(src, tctx)
+	req.Prompt = t.promptBuilder.BuildFunctionPrompt(req)
+
+	// 2. Call LLM
+	resp, err := t.callLLM(ctx, req, src.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Build target Function
+	return t.buildTargetFunction(src, tctx, resp.TargetContent, resp.TargetSignature), nil
+}
+
+// functionRequest builds the LLM request of a Function node, without the prompt
+func (t *NodeTranslator) functionRequest(src *uniast.Function, tctx *TranslateContext) *LLMTranslateRequest {
 	sourceContent, truncated := truncateSourceForPrompt(src.Content, t.opts.MaxSourceChars)
-	req := &LLMTranslateRequest{
+	return &LLMTranslateRequest{
 		SourceLanguage:  t.opts.SourceLanguage,
 		TargetLanguage:  t.opts.TargetLanguage,
 		NodeType:        uniast.FUNC,
@@ -127,24 +168,26 @@ func (t *NodeTranslator) TranslateFunction(ctx context.Context, src *uniast.Func
 		Identity:        src.Identity,
 		TypeHints:       t.typeHints,
 		Dependencies:    t.collectDependencyHints(src.Identity, tctx),
+		ContextNodes:    t.collectContextNodes(src.Identity, tctx),
+		PackageContext:  tctx.PackageContext,
+		DocString:       src.DocString,
+		TargetComment:   t.translateDocComment(src.Content, t.convertFunctionName(src.Name, src.Exported)),
+		Tags:            src.Tags,
+		BuildError:      t.opts.BuildErrors[src.Identity.Full()],
+		IsConstructor:   src.IsConstructor,
+		ReceiverType:    src.ReceiverType,
 	}
-	req.Prompt = t.promptBuilder.BuildFunctionPrompt(req)
-
-	// 2. Call LLM
-	resp, err := t.opts.LLMTranslator(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("LLM call failed: %w", err)
-	}
-	if resp.Error != "" {
-		return nil, fmt.Errorf("LLM error: %s", resp.Error)
-	}
+}
 
-	// 3. Build target Function
+// buildTargetFunction builds the target Function of src with the given content and signature
+func (t *NodeTranslator) buildTargetFunction(src *uniast.Function, tctx *TranslateContext, content, signature string) *uniast.Function {
 	targetName := t.convertFunctionName(src.Name, src.Exported)
-	targetFunc := &uniast.Function{
+	tctx.AddTranslatedContent(src.Identity, content)
+	return &uniast.Function{
 		Exported:          src.Exported,
 		IsMethod:          src.IsMethod,
 		IsInterfaceMethod: src.IsInterfaceMethod,
+		IsConstructor:     src.IsConstructor,
 		Identity: uniast.Identity{
 			ModPath: tctx.Module.Name,
 			PkgPath: string(tctx.Package.PkgPath),
@@ -154,41 +197,53 @@ func (t *NodeTranslator) TranslateFunction(ctx context.Context, src *uniast.Func
 			File: t.convertFilePath(src.File),
 			Line: src.Line,
 		},
-		Content:   resp.TargetContent,
-		Signature: resp.TargetSignature,
+		Content:   content,
+		Signature: signature,
 	}
-
-	return targetFunc, nil
 }
 
 // TranslateVar translates a Var node
 func (t *NodeTranslator) TranslateVar(ctx context.Context, src *uniast.Var, tctx *TranslateContext) (*uniast.Var, error) {
 	// 1. Build LLM request
+	req := t.varRequest(src, tctx)
+	req.Prompt = t.promptBuilder.BuildVarPrompt(req)
+
+	// 2. Call LLM
+	resp, err := t.callLLM(ctx, req, src.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Build target Var
+	return t.buildTargetVar(src, tctx, resp.TargetContent), nil
+}
+
+// varRequest builds the LLM request of a Var node, without the prompt
+func (t *NodeTranslator) varRequest(src *uniast.Var, tctx *TranslateContext) *LLMTranslateRequest {
 	sourceContent, truncated := truncateSourceForPrompt(src.Content, t.opts.MaxSourceChars)
-	req := &LLMTranslateRequest{
+	return &LLMTranslateRequest{
 		SourceLanguage:  t.opts.SourceLanguage,
 		TargetLanguage:  t.opts.TargetLanguage,
 		NodeType:        uniast.VAR,
+		NodeKind:        src.NodeKind(),
 		SourceContent:   sourceContent,
 		SourceTruncated: truncated,
 		Identity:        src.Identity,
 		TypeHints:       t.typeHints,
 		Dependencies:    t.collectDependencyHints(src.Identity, tctx),
+		ContextNodes:    t.collectContextNodes(src.Identity, tctx),
+		PackageContext:  tctx.PackageContext,
+		TargetComment:   t.translateDocComment(src.Content, t.convertVarName(src.Name, src.IsExported)),
+		Tags:            src.Tags,
+		BuildError:      t.opts.BuildErrors[src.Identity.Full()],
 	}
-	req.Prompt = t.promptBuilder.BuildVarPrompt(req)
-
-	// 2. Call LLM
-	resp, err := t.opts.LLMTranslator(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("LLM call failed: %w", err)
-	}
-	if resp.Error != "" {
-		return nil, fmt.Errorf("LLM error: %s", resp.Error)
-	}
+}
 
-	// 3. Build target Var
+// buildTargetVar builds the target Var of src with the given content
+func (t *NodeTranslator) buildTargetVar(src *uniast.Var, tctx *TranslateContext, content string) *uniast.Var {
 	targetName := t.convertVarName(src.Name, src.IsExported)
-	targetVar := &uniast.Var{
+	tctx.AddTranslatedContent(src.Identity, content)
+	return &uniast.Var{
 		IsExported: src.IsExported,
 		IsConst:    src.IsConst,
 		IsPointer:  src.IsPointer,
@@ -201,10 +256,171 @@ func (t *NodeTranslator) TranslateVar(ctx context.Context, src *uniast.Var, tctx
 			File: t.convertFilePath(src.File),
 			Line: src.Line,
 		},
-		Content: resp.TargetContent,
+		Content: content,
+	}
+}
+
+// TranslateBatch translates the nodes of reqs in a single LLM call with a batch prompt.
+// The returned responses are aligned with reqs, a nil response means the node is missing in the LLM output.
+func (t *NodeTranslator) TranslateBatch(ctx context.Context, reqs []*LLMTranslateRequest) ([]*LLMTranslateResponse, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	sources := make([]string, len(reqs))
+	for i, r := range reqs {
+		sources[i] = r.SourceContent
+	}
+	req := &LLMTranslateRequest{
+		SourceLanguage: t.opts.SourceLanguage,
+		TargetLanguage: t.opts.TargetLanguage,
+		NodeType:       reqs[0].NodeType,
+		SourceContent:  strings.Join(sources, "\n\n"),
+		Identity:       uniast.Identity{ModPath: reqs[0].Identity.ModPath, PkgPath: reqs[0].Identity.PkgPath},
+		TypeHints:      t.typeHints,
+		Batch:          reqs,
+	}
+	req.Prompt = t.promptBuilder.BuildBatchPrompt(reqs)
+
+	resp, err := t.opts.LLMTranslator(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("LLM call failed: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("LLM error: %s", resp.Error)
+	}
+
+	parsed := ParseBatchResponse(resp.TargetContent)
+	ret := make([]*LLMTranslateResponse, len(reqs))
+	copy(ret, parsed)
+	return ret, nil
+}
+
+// TranslatePackage translates all the nodes of pkg in a single LLM call with a package prompt.
+// The returned map is source node name => response, a node missing in the LLM output has no response.
+func (t *NodeTranslator) TranslatePackage(ctx context.Context, pkg *uniast.Package, tctx *TranslateContext) (map[string]*LLMTranslateResponse, error) {
+	if pkg.NodeCount() == 0 {
+		return nil, nil
+	}
+	var reqs []*LLMTranslateRequest
+	for _, name := range sortedKeys(pkg.Types) {
+		reqs = append(reqs, t.typeRequest(pkg.Types[name], tctx))
 	}
+	for _, name := range sortedKeys(pkg.Functions) {
+		reqs = append(reqs, t.functionRequest(pkg.Functions[name], tctx))
+	}
+	for _, name := range sortedKeys(pkg.Vars) {
+		reqs = append(reqs, t.varRequest(pkg.Vars[name], tctx))
+	}
+	sources := make([]string, len(reqs))
+	for i, r := range reqs {
+		sources[i] = r.SourceContent
+	}
+	req := &LLMTranslateRequest{
+		SourceLanguage: t.opts.SourceLanguage,
+		TargetLanguage: t.opts.TargetLanguage,
+		NodeType:       reqs[0].NodeType,
+		SourceContent:  strings.Join(sources, "\n\n"),
+		Identity:       uniast.Identity{ModPath: reqs[0].Identity.ModPath, PkgPath: reqs[0].Identity.PkgPath},
+		TypeHints:      t.typeHints,
+		Batch:          reqs,
+	}
+	req.Prompt = t.promptBuilder.BuildPackagePrompt(pkg, t.opts.SourceLanguage, t.opts.TargetLanguage)
 
-	return targetVar, nil
+	resp, err := t.opts.LLMTranslator(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("LLM call failed: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("LLM error: %s", resp.Error)
+	}
+
+	ret := make(map[string]*LLMTranslateResponse)
+	for name, content := range ParsePackageResponse(resp.TargetContent) {
+		ret[name] = &LLMTranslateResponse{TargetContent: content}
+	}
+	return ret, nil
+}
+
+// callLLM translates req by the LLM, unless the same srcContent has been translated before.
+// The response is cached only if it passes the quality check.
+func (t *NodeTranslator) callLLM(ctx context.Context, req *LLMTranslateRequest, srcContent string) (*LLMTranslateResponse, error) {
+	key := t.contentKey(req, srcContent)
+	// the cached translation is what caused the build error, ask the LLM again
+	if req.BuildError == "" {
+		if v, ok := t.contentCache.Load(key); ok {
+			t.cacheHits.Add(1)
+			return v.(*LLMTranslateResponse), nil
+		}
+	}
+
+	resp, err := t.opts.LLMTranslator(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("LLM call failed: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("LLM error: %s", resp.Error)
+	}
+	if err := t.checkQuality(srcContent, resp.TargetContent); err != nil {
+		return nil, err
+	}
+	t.contentCache.Store(key, resp)
+	return resp, nil
+}
+
+// contentKey is the hex sha256 of the source content, the language pair, the node type and the owner type of a method.
+// Identical bodies of methods on different types, e.g. Java getters, are translated with different receivers.
+func (t *NodeTranslator) contentKey(req *LLMTranslateRequest, srcContent string) string {
+	owner := req.ReceiverType
+	if owner == "" && req.NodeType == uniast.FUNC {
+		// methods named by the owner type, e.g. User.getName
+		if i := strings.LastIndexAny(req.Identity.Name, ".:"); i >= 0 {
+			owner = req.Identity.Name[:i]
+		}
+	}
+	h := sha256.Sum256([]byte(srcContent + "\x00" + string(t.opts.SourceLanguage) + "\x00" + string(t.opts.TargetLanguage) +
+		"\x00" + req.NodeType.String() + "\x00" + owner))
+	return hex.EncodeToString(h[:])
+}
+
+// CacheHits returns the number of translations served by the content cache
+func (t *NodeTranslator) CacheHits() int {
+	return int(t.cacheHits.Load())
+}
+
+// checkQuality scores the translation of srcContent, returns an error if it is below opts.MinQualityScore
+func (t *NodeTranslator) checkQuality(srcContent, dstContent string) error {
+	if t.opts.MinQualityScore <= 0 {
+		return nil
+	}
+	report := t.qualityScorer.Score(srcContent, dstContent, t.opts.SourceLanguage, t.opts.TargetLanguage)
+	if report.Total < t.opts.MinQualityScore {
+		return fmt.Errorf("translation quality score %.1f is below %.1f: %s", report.Total, t.opts.MinQualityScore, strings.Join(report.Issues, "; "))
+	}
+	return nil
+}
+
+// largeNodeStub returns the stub content replacing a node whose source has contentLen chars
+func (t *NodeTranslator) largeNodeStub(contentLen int) string {
+	comment := "//"
+	if t.opts.TargetLanguage == uniast.Python {
+		comment = "#"
+	}
+	return fmt.Sprintf("%s Skipped: source too large (%d chars), translate manually", comment, contentLen)
+}
+
+// filteredNodeStub returns the stub content replacing a node filtered out by NodeFilter,
+// which keeps the source commented out for manual translation
+func (t *NodeTranslator) filteredNodeStub(content string) string {
+	comment := "//"
+	if t.opts.TargetLanguage == uniast.Python {
+		comment = "#"
+	}
+	var sb strings.Builder
+	sb.WriteString(comment + " Skipped: filtered out by node filter, original source:")
+	for _, line := range strings.Split(content, "\n") {
+		sb.WriteString("\n" + comment + " " + line)
+	}
+	return sb.String()
 }
 
 // collectDependencyHints collects hints about already translated dependencies
@@ -240,6 +456,30 @@ func (t *NodeTranslator) collectDependencyHints(srcID uniast.Identity, tctx *Tra
 	return hints
 }
 
+// collectContextNodes collects the translated code of the already translated dependencies
+func (t *NodeTranslator) collectContextNodes(srcID uniast.Identity, tctx *TranslateContext) []ContextNodeHint {
+	node := tctx.SourceRepo.GetNode(srcID)
+	if node == nil {
+		return nil
+	}
+
+	var hints []ContextNodeHint
+	for _, dep := range node.Dependencies {
+		targetID, ok := tctx.GetTranslatedNode(dep.Identity)
+		if !ok {
+			continue
+		}
+		if content, ok := tctx.GetTranslatedContent(dep.Identity); ok && strings.TrimSpace(content) != "" {
+			hints = append(hints, ContextNodeHint{Identity: targetID, TranslatedContent: content})
+		}
+	}
+
+	if t.opts.MaxDependenciesInPrompt > 0 && len(hints) > t.opts.MaxDependenciesInPrompt {
+		hints = hints[:t.opts.MaxDependenciesInPrompt]
+	}
+	return hints
+}
+
 // convertTypeName converts a type name to target language convention
 func (t *NodeTranslator) convertTypeName(name string, exported bool) string {
 	switch t.opts.TargetLanguage {
@@ -279,7 +519,10 @@ func (t *NodeTranslator) convertFunctionName(name string, exported bool) string
 		// Python: snake_case for functions
 		return toSnakeCase(name)
 	case uniast.Java:
-		// Java: camelCase for methods
+		// Java: camelCase for methods; Go methods are named {Type}.{Method}
+		if idx := strings.LastIndex(name, "."); idx >= 0 {
+			return toPascalCase(name[:idx]) + "." + toCamelCase(name[idx+1:])
+		}
 		return toCamelCase(name)
 	default:
 		return name
@@ -353,6 +596,343 @@ func (t *NodeTranslator) getFileExtension() string {
 	}
 }
 
+// Comment conversion helpers
+
+// docComment is the language-neutral form of a doc comment
+type docComment struct {
+	summary []string
+	params  [][2]string // name, description
+	returns string
+	throws  [][2]string // exception/error type, description
+}
+
+// TranslateComment converts a doc comment from srcLang style to dstLang style.
+// Parameter descriptions, @param/@return tags and @throws annotations are kept in the target idiom
+// (e.g. Javadoc tags become godoc prose, @throws becomes an error return note).
+func TranslateComment(srcComment string, srcLang, dstLang uniast.Language) string {
+	if strings.TrimSpace(srcComment) == "" {
+		return ""
+	}
+	if srcLang == dstLang {
+		return srcComment
+	}
+	doc := parseDocComment(srcComment, srcLang)
+	if len(doc.summary) == 0 && len(doc.params) == 0 && doc.returns == "" && len(doc.throws) == 0 {
+		return ""
+	}
+	return renderDocComment(doc, dstLang)
+}
+
+// extractDocComment returns the doc comment of a node from its source content:
+// the leading comment lines, or the first docstring of the body for Python.
+func extractDocComment(content string, lang uniast.Language) string {
+	lines := strings.Split(content, "\n")
+	var doc []string
+	if lang == uniast.Python {
+		// skip the signature, which ends with ':'
+		start := 0
+		for start < len(lines) && !strings.HasSuffix(strings.TrimSpace(lines[start]), ":") {
+			start++
+		}
+		quote := ""
+		for _, line := range lines[min(start+1, len(lines)):] {
+			trimmed := strings.TrimSpace(line)
+			if quote == "" {
+				if trimmed == "" {
+					continue
+				}
+				if !strings.HasPrefix(trimmed, `"""`) && !strings.HasPrefix(trimmed, "'''") {
+					return ""
+				}
+				quote = trimmed[:3]
+				doc = append(doc, trimmed)
+				if len(trimmed) >= 6 && strings.HasSuffix(trimmed, quote) {
+					break
+				}
+				continue
+			}
+			doc = append(doc, trimmed)
+			if strings.HasSuffix(trimmed, quote) {
+				break
+			}
+		}
+		return strings.Join(doc, "\n")
+	}
+
+	inBlock := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inBlock:
+			doc = append(doc, trimmed)
+			inBlock = !strings.Contains(trimmed, "*/")
+		case strings.HasPrefix(trimmed, "/*"):
+			doc = append(doc, trimmed)
+			inBlock = !strings.Contains(trimmed, "*/")
+		case strings.HasPrefix(trimmed, "//"):
+			doc = append(doc, trimmed)
+		case trimmed == "" && len(doc) == 0:
+			continue
+		default:
+			return strings.Join(doc, "\n")
+		}
+	}
+	return strings.Join(doc, "\n")
+}
+
+// parseDocComment parses a doc comment into docComment
+func parseDocComment(comment string, lang uniast.Language) docComment {
+	var doc docComment
+	// section of Python Google-style or Rust docs which the following lines belong to
+	section := ""
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		switch lang {
+		case uniast.Python:
+			line = strings.TrimSpace(strings.Trim(line, `"'`))
+		default:
+			for _, prefix := range []string{"/**", "/*!", "/*", "*/", "///", "//!", "//", "*"} {
+				if strings.HasPrefix(line, prefix) {
+					line = strings.TrimPrefix(line, prefix)
+					break
+				}
+			}
+			line = strings.TrimSpace(strings.TrimSuffix(line, "*/"))
+		}
+		if line == "" {
+			if section == "" && len(doc.summary) > 0 && doc.summary[len(doc.summary)-1] != "" {
+				doc.summary = append(doc.summary, "")
+			}
+			continue
+		}
+
+		// Javadoc / Doxygen / JSDoc / Sphinx tags
+		if tag, rest, ok := cutDocTag(line); ok {
+			section = "tag"
+			switch tag {
+			case "param", "arg":
+				name, desc := cutFirstWord(rest)
+				doc.params = append(doc.params, [2]string{strings.Trim(name, "{}"), desc})
+			case "return", "returns":
+				doc.returns = rest
+			case "throws", "exception", "raises", "raise":
+				typ, desc := cutFirstWord(rest)
+				doc.throws = append(doc.throws, [2]string{typ, desc})
+			default:
+				doc.summary = append(doc.summary, line)
+			}
+			continue
+		}
+
+		// Python Google-style and Rust sections
+		switch strings.ToLower(strings.TrimSpace(strings.TrimLeft(line, "#"))) {
+		case "args:", "arguments:", "parameters:", "arguments", "parameters":
+			section = "params"
+			continue
+		case "returns:", "return:", "returns", "return":
+			section = "returns"
+			continue
+		case "raises:", "throws:", "errors", "errors:", "panics":
+			section = "throws"
+			continue
+		}
+		switch section {
+		case "params":
+			item := strings.TrimSpace(strings.TrimLeft(line, "*-"))
+			if name, desc, ok := strings.Cut(item, ":"); ok && !strings.Contains(strings.TrimSpace(name), " ") {
+				doc.params = append(doc.params, [2]string{strings.Trim(strings.TrimSpace(name), "`"), strings.TrimSpace(desc)})
+			} else if name, desc, ok := strings.Cut(item, " - "); ok {
+				doc.params = append(doc.params, [2]string{strings.Trim(strings.TrimSpace(name), "`"), strings.TrimSpace(desc)})
+			} else if n := len(doc.params); n > 0 {
+				doc.params[n-1][1] = strings.TrimSpace(doc.params[n-1][1] + " " + item)
+			}
+		case "returns":
+			doc.returns = strings.TrimSpace(doc.returns + " " + line)
+		case "throws":
+			if typ, desc, ok := strings.Cut(line, ":"); ok && !strings.Contains(strings.TrimSpace(typ), " ") {
+				doc.throws = append(doc.throws, [2]string{strings.TrimSpace(typ), strings.TrimSpace(desc)})
+			} else if n := len(doc.throws); n > 0 {
+				doc.throws[n-1][1] = strings.TrimSpace(doc.throws[n-1][1] + " " + line)
+			} else {
+				doc.throws = append(doc.throws, [2]string{"", line})
+			}
+		case "tag":
+			// continuation of the last tag
+			switch {
+			case doc.returns != "" && len(doc.throws) == 0:
+				doc.returns += " " + line
+			case len(doc.throws) > 0:
+				doc.throws[len(doc.throws)-1][1] = strings.TrimSpace(doc.throws[len(doc.throws)-1][1] + " " + line)
+			case len(doc.params) > 0:
+				doc.params[len(doc.params)-1][1] = strings.TrimSpace(doc.params[len(doc.params)-1][1] + " " + line)
+			}
+		default:
+			doc.summary = append(doc.summary, line)
+		}
+	}
+	for len(doc.summary) > 0 && doc.summary[len(doc.summary)-1] == "" {
+		doc.summary = doc.summary[:len(doc.summary)-1]
+	}
+	return doc
+}
+
+// cutDocTag cuts a "@tag rest", "\tag rest" or ":tag name: rest" line
+func cutDocTag(line string) (tag string, rest string, ok bool) {
+	switch {
+	case strings.HasPrefix(line, "@"), strings.HasPrefix(line, "\\"):
+		tag, rest = cutFirstWord(line[1:])
+		return strings.ToLower(tag), rest, tag != ""
+	case strings.HasPrefix(line, ":"):
+		// Sphinx: ":param name: desc", ":returns: desc", ":raises Error: desc"
+		head, desc, found := strings.Cut(line[1:], ":")
+		if !found {
+			return "", "", false
+		}
+		tag, name := cutFirstWord(head)
+		return strings.ToLower(tag), strings.TrimSpace(name + " " + strings.TrimSpace(desc)), tag != ""
+	}
+	return "", "", false
+}
+
+// cutFirstWord splits s into its first word and the rest
+func cutFirstWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexFunc(s, unicode.IsSpace); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i+1:])
+	}
+	return s, ""
+}
+
+// renderDocComment renders a docComment in the doc comment style of lang
+func renderDocComment(doc docComment, lang uniast.Language) string {
+	var body []string
+	switch lang {
+	case uniast.Golang:
+		body = append(body, doc.summary...)
+		if len(doc.params) > 0 {
+			body = append(body, "", "Parameters:")
+			for _, p := range doc.params {
+				body = append(body, fmt.Sprintf("  - %s: %s", p[0], p[1]))
+			}
+		}
+		if doc.returns != "" {
+			body = append(body, "", "Returns "+doc.returns)
+		}
+		for i, e := range doc.throws {
+			if i == 0 {
+				body = append(body, "")
+			}
+			body = append(body, strings.TrimSpace(fmt.Sprintf("It returns an error (%s) %s", e[0], e[1])))
+		}
+		return prefixLines(trimEmptyEdges(body), "// ", "//")
+	case uniast.Python:
+		body = append(body, doc.summary...)
+		if len(doc.params) > 0 {
+			body = append(body, "", "Args:")
+			for _, p := range doc.params {
+				body = append(body, fmt.Sprintf("    %s: %s", p[0], p[1]))
+			}
+		}
+		if doc.returns != "" {
+			body = append(body, "", "Returns:", "    "+doc.returns)
+		}
+		if len(doc.throws) > 0 {
+			body = append(body, "", "Raises:")
+			for _, e := range doc.throws {
+				body = append(body, fmt.Sprintf("    %s: %s", e[0], e[1]))
+			}
+		}
+		body = trimEmptyEdges(body)
+		if len(body) == 1 {
+			return `"""` + body[0] + `"""`
+		}
+		return `"""` + strings.Join(body, "\n") + "\n" + `"""`
+	case uniast.Rust:
+		body = append(body, doc.summary...)
+		if len(doc.params) > 0 {
+			body = append(body, "", "# Arguments", "")
+			for _, p := range doc.params {
+				body = append(body, fmt.Sprintf("* `%s` - %s", p[0], p[1]))
+			}
+		}
+		if doc.returns != "" {
+			body = append(body, "", "# Returns", "", doc.returns)
+		}
+		if len(doc.throws) > 0 {
+			body = append(body, "", "# Errors", "")
+			for _, e := range doc.throws {
+				body = append(body, strings.TrimSpace(fmt.Sprintf("Returns `%s` %s", e[0], e[1])))
+			}
+		}
+		return prefixLines(trimEmptyEdges(body), "/// ", "///")
+	default:
+		// Javadoc style, which is also understood by Doxygen (C++) and JSDoc (TypeScript)
+		body = append(body, doc.summary...)
+		if len(doc.params)+len(doc.throws) > 0 || doc.returns != "" {
+			body = append(body, "")
+		}
+		for _, p := range doc.params {
+			body = append(body, strings.TrimSpace("@param "+p[0]+" "+p[1]))
+		}
+		if doc.returns != "" {
+			body = append(body, "@return "+doc.returns)
+		}
+		for _, e := range doc.throws {
+			typ := e[0]
+			if typ == "" || lang == uniast.Java && typ == "error" {
+				typ = "Exception"
+			}
+			body = append(body, strings.TrimSpace("@throws "+typ+" "+e[1]))
+		}
+		return "/**\n" + prefixLines(trimEmptyEdges(body), " * ", " *") + "\n */"
+	}
+}
+
+// trimEmptyEdges removes leading and trailing empty lines
+func trimEmptyEdges(lines []string) []string {
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// prefixLines prefixes each line, using emptyPrefix for empty lines
+func prefixLines(lines []string, prefix, emptyPrefix string) string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		if l == "" {
+			out[i] = emptyPrefix
+		} else {
+			out[i] = prefix + l
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// translateDocComment extracts the doc comment of a node and converts it to the target language.
+// For Go targets, the comment is made to start with the node name per godoc convention.
+func (t *NodeTranslator) translateDocComment(content string, targetName string) string {
+	comment := TranslateComment(extractDocComment(content, t.opts.SourceLanguage), t.opts.SourceLanguage, t.opts.TargetLanguage)
+	if comment == "" || t.opts.TargetLanguage != uniast.Golang || targetName == "" {
+		return comment
+	}
+	name := targetName
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		name = name[idx+1:]
+	}
+	first := strings.TrimPrefix(comment, "// ")
+	if strings.HasPrefix(first, name+" ") {
+		return comment
+	}
+	if r := []rune(first); len(r) > 0 && unicode.IsUpper(r[0]) && (len(r) == 1 || !unicode.IsUpper(r[1])) {
+		first = string(unicode.ToLower(r[0])) + string(r[1:])
+	}
+	return "// " + name + " " + first
+}
+
 // Naming convention conversion helpers
 
 // toPascalCase converts a string to PascalCase
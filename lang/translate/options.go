@@ -19,6 +19,7 @@ package translate
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
 )
@@ -29,12 +30,15 @@ type TranslateOptions struct {
 	SourceLanguage uniast.Language
 	// TargetLanguage specifies the target language (required)
 	TargetLanguage uniast.Language
-	// TargetModuleName specifies the module name for the target code
+	// TargetModuleName specifies the module name for the target code, e.g. the module path in go.mod.
+	// It must be a valid module path and is used as is; derived from the source repository if empty.
 	TargetModuleName string
 	// OutputDir specifies the output directory for generated code
 	OutputDir string
 	// LLMTranslator is the callback function for LLM translation (required)
 	LLMTranslator LLMTranslateFunc
+	// MultiModel, if not nil, overrides LLMTranslator to translate types, functions and vars with different models
+	MultiModel *MultiModelTranslator
 	// Parallel enables parallel translation (default: false)
 	Parallel bool
 	// Concurrency specifies the number of concurrent translations per package
@@ -47,16 +51,34 @@ type TranslateOptions struct {
 	MaxDependenciesInPrompt int
 	// MaxSourceChars truncates source code in the prompt when exceeded (0 = no limit). Reduces context overflow and latency.
 	MaxSourceChars int
+	// MaxPromptLength limits the whole prompt to about this many tokens, estimated as 4 chars per token (0 = no limit).
+	// The dependency and context sections are cut first, then the type mapping, and the source only as a last resort.
+	MaxPromptLength int
+	// ContextWindowPkgNodes, if > 0, shows the signatures of up to N already translated nodes of the same package
+	// in each prompt as package context, so that the LLM can use its siblings idiomatically (default: 0 = no context)
+	ContextWindowPkgNodes int
+	// PackageSplitThreshold splits a source package with more types than it into several target packages,
+	// grouped by the common prefix of the type names, e.g. service.UserService => service/user (0 = never split, Go target only)
+	PackageSplitThreshold int
+	// CustomTypeHints are added to the built-in type mappings of the language pair (overriding the same source type),
+	// e.g. {Source: "ImmutableList<T>", Target: "[]T"}; they appear in the type mapping table of every prompt
+	CustomTypeHints []TypeHintOverride
+	// TypeHintsFile is a YAML or JSON file of type mappings by language pair, loaded by TypeHints.LoadFromFile
+	// before CustomTypeHints, e.g. "java->go: {com.example.Money: decimal.Decimal}"
+	TypeHintsFile string
 
 	// Post-processing options
-	// WebFramework specifies the web framework to integrate: "gin", "echo", "actix", "fastapi", "none"
+	// WebFramework specifies the web framework to integrate: "gin", "echo", "hertz", "actix", "fastapi", "none"
 	WebFramework string
 	// GenerateEntryPoint enables generation of entry point if missing (default: true)
 	GenerateEntryPoint bool
 	// GenerateConfig enables generation of project config files (default: true)
 	GenerateConfig bool
+	// GenerateDockerfile enables generation of a Dockerfile along with the config files (only works for Go now)
+	GenerateDockerfile bool
 
-	// MaxRetryPerNode is the number of retries per node on translate failure (default: 1). One node = one retry unit.
+	// MaxRetryPerNode is the max number of attempts to translate a node, a failed attempt is retried until it is reached
+	// (default: 0, which makes a single attempt like 1). One node = one retry unit.
 	MaxRetryPerNode int
 	// Result, if non-nil, is filled with FailedNodes and TranslatedIDs after Transform (for observability and resume).
 	Result *TranslateResult
@@ -64,10 +86,30 @@ type TranslateOptions struct {
 	AlreadyTranslatedIDs map[string]struct{}
 	// ProgressCallback is optional; called after each node is processed (done, total, kind, nodeID) for real-time progress.
 	ProgressCallback ProgressCallbackFunc
+	// OnNodeTranslated is optional; called with each node translated by the LLM (not the skipped stubs) and its content,
+	// before it is stored in the target package. It is called under the lock of the package in parallel mode,
+	// but packages translated at once (PackageConcurrency > 1) may call it concurrently.
+	OnNodeTranslated func(srcID, dstID uniast.Identity, content string)
+	// SkipLargeNodes skips nodes whose source exceeds this many chars (0 = no skip); they are replaced with a stub comment.
+	SkipLargeNodes int
+	// NodeFilter, if non-nil, selects the nodes to translate (e.g. to skip generated code);
+	// nodes it returns false for are copied as a commented-out stub of the source.
+	NodeFilter func(uniast.Identity) bool
+	// BuildErrors maps source Identity.Full() to the build errors caused by its previous translation (optional);
+	// the errors are added to the prompt of the node so that the LLM can fix them.
+	BuildErrors map[string]string
+	// BatchSize > 1 translates up to BatchSize small functions or vars of the same package in a single LLM call,
+	// the nodes missing in the batch output are translated one by one (default: 0 = one node per call).
+	// WholePackageBatchSize translates all the nodes of each package in a single LLM call instead.
+	BatchSize int
+	// MinQualityScore, if > 0, flags translations whose QualityScorer total (0-100) is below it as failed,
+	// so that they are retried like other failures (up to MaxRetryPerNode)
+	MinQualityScore float64
 }
 
 // ProgressCallbackFunc is called after each node is processed. done = processed count, total = CountTranslatableNodes, kind = "type"|"func"|"var", nodeID = Identity.Full().
-type ProgressCallbackFunc func(done, total int, currentKind, currentNodeID string)
+// eta is the estimated time left, 0 until a node translated by the LLM has been timed (see ProgressState.ETA).
+type ProgressCallbackFunc func(done, total int, currentKind, currentNodeID string, eta time.Duration)
 
 // FailedNodeInfo records a node that failed translation after max retries.
 type FailedNodeInfo struct {
@@ -76,14 +118,48 @@ type FailedNodeInfo struct {
 	Err      string
 }
 
+// FailedBatchInfo records a batch LLM call that failed, its nodes are translated one by one instead.
+type FailedBatchInfo struct {
+	NodeIDs []string // source Identity.Full() of the nodes of the batch
+	Err     string
+}
+
+// SkippedNodeInfo records a node that was not sent to the LLM.
+type SkippedNodeInfo struct {
+	NodeID     string // source Identity.Full()
+	Reason     string
+	ContentLen int
+}
+
 // TranslateResult is filled by Transform when opts.Result is non-nil (node-granular outcome and cache).
 // TotalNodes and ProcessedNodes are set at end for stats and checkpoint/resume.
 type TranslateResult struct {
 	FailedNodes     []FailedNodeInfo
+	SkippedNodes    []SkippedNodeInfo // nodes replaced with a stub (e.g. source too large)
+	FailedBatches   []FailedBatchInfo // batch LLM calls which failed, see TranslateOptions.BatchSize
 	TranslatedIDs   map[string]struct{} // source Identity.Full() of successfully translated nodes
 	TotalNodes      int                 // CountTranslatableNodes at start
 	ProcessedNodes  int                 // done count at end (success + failed)
 	CheckpointPath  string              // reserved: path to checkpoint file for resume
+	CacheHits       int                 // translations reusing the result of identical source content instead of calling the LLM
+	// TranslationStats breaks down the outcome of the nodes by kind, set at end
+	TranslationStats TranslationStats
+}
+
+// TranslationStats is the outcome of the translated nodes of each kind
+type TranslationStats struct {
+	Types     TranslationKindStats `json:"types"`
+	Functions TranslationKindStats `json:"functions"`
+	Vars      TranslationKindStats `json:"vars"`
+}
+
+// TranslationKindStats counts the nodes of a kind by outcome.
+// Nodes neither translated, failed nor skipped (e.g. not reached) are only counted in Total.
+type TranslationKindStats struct {
+	Total      int `json:"total"`
+	Translated int `json:"translated"`
+	Failed     int `json:"failed"`
+	Skipped    int `json:"skipped"`
 }
 
 // LLMTranslateFunc is the callback function type for LLM translation
@@ -98,6 +174,8 @@ type LLMTranslateRequest struct {
 	TargetLanguage uniast.Language
 	// NodeType is the type of AST node: FUNC, TYPE, VAR
 	NodeType uniast.NodeType
+	// NodeKind is the specific kind of a TYPE or VAR node, e.g. uniast.TypeEnum or uniast.VarConst (optional)
+	NodeKind uniast.NodeType
 	// SourceContent is the source code content (from node's Content field)
 	SourceContent string
 	// Identity contains the node identification information
@@ -106,8 +184,28 @@ type LLMTranslateRequest struct {
 	TypeHints *TypeHints
 	// Dependencies contains information about already translated dependencies
 	Dependencies []DependencyHint
+	// ContextNodes are the translated code of the already translated dependencies, e.g. sibling methods (optional)
+	ContextNodes []ContextNodeHint
+	// PackageContext are the already translated nodes of the same target package (optional)
+	PackageContext []*uniast.Node
 	// SourceTruncated is set when SourceContent was truncated for context limit; PromptBuilder may add a note.
 	SourceTruncated bool
+	// DocString is the leading doc comment of the source node without comment markers (optional)
+	DocString string
+	// TargetComment is the doc comment of the node converted to the target language idiom (optional)
+	TargetComment string
+	// TypeParams are the generic type parameters of a type node (optional)
+	TypeParams []uniast.TypeParam
+	// Tags are the language-specific metadata annotations of the source node (optional)
+	Tags map[string]string
+	// BuildError is the build error caused by the previous translation of the node (optional)
+	BuildError string
+	// IsConstructor is set when the function is a constructor of its type, see uniast.Function.IsConstructor
+	IsConstructor bool
+	// ReceiverType is the receiver type of a method, e.g. *User, see uniast.Function.ReceiverType (optional)
+	ReceiverType string
+	// Batch are the requests of the nodes translated together by a batch prompt (optional)
+	Batch []*LLMTranslateRequest
 	// Prompt is the complete prompt built by PromptBuilder
 	Prompt string
 }
@@ -134,6 +232,14 @@ type DependencyHint struct {
 	TargetSignature string
 }
 
+// ContextNodeHint provides the translated code of an already translated dependency
+type ContextNodeHint struct {
+	// Identity is the identity in target language
+	Identity uniast.Identity
+	// TranslatedContent is the code in target language
+	TranslatedContent string
+}
+
 // TranslateContext holds the context during translation
 type TranslateContext struct {
 	// SourceRepo is the source repository being translated
@@ -144,41 +250,100 @@ type TranslateContext struct {
 	Module *uniast.Module
 	// Package is the current target package
 	Package *uniast.Package
+	// PackageContext are the already translated nodes of Package shown to the LLM,
+	// set before translating each kind of nodes when TranslateOptions.ContextWindowPkgNodes > 0
+	PackageContext []*uniast.Node
 	// TranslatedNodes maps source identity to target identity for already translated nodes
 	// Access via AddTranslatedNode/GetTranslatedNode when used from parallel translation.
 	TranslatedNodes map[string]uniast.Identity
-	// mu protects TranslatedNodes for concurrent read/write
+	// TranslatedContents maps source identity to the target content of already translated nodes,
+	// shown to the LLM as the ContextNodes of the nodes depending on them.
+	// Access via AddTranslatedContent/GetTranslatedContent when used from parallel translation.
+	TranslatedContents map[string]string
+	// mu protects TranslatedNodes and TranslatedContents for concurrent read/write
 	mu sync.RWMutex
+	// shared is the context whose maps this one shares, see packageContext; its mu is used instead
+	shared *TranslateContext
 	// Result, if non-nil, receives FailedNodes and TranslatedIDs (one node = one retry unit).
 	Result *TranslateResult
 	// Progress is optional; when set, ReportNodeDone is called after each node for real-time progress.
 	Progress *ProgressState
 }
 
+// etaWindow is the number of the latest node translation durations averaged by ProgressState.ETA
+const etaWindow = 50
+
 // ProgressState holds total/done and callback for thread-safe progress reporting.
 type ProgressState struct {
 	Total    int
 	done     int
 	mu       sync.Mutex
 	Callback ProgressCallbackFunc
+
+	// durations is a circular buffer of the latest node translation durations,
+	// next is the slot of the next one and timed the number of durations recorded
+	durations [etaWindow]time.Duration
+	next      int
+	timed     int
 }
 
 // ReportNodeDone increments done and invokes Callback (if set). Safe for concurrent use.
+// Use it for nodes not translated by the LLM (e.g. stubs), which are not timed.
 func (p *ProgressState) ReportNodeDone(currentKind, currentNodeID string) {
+	p.report(currentKind, currentNodeID, 0, false)
+}
+
+// ReportNodeTranslated is ReportNodeDone for a node translated (or failed) in elapsed,
+// which is recorded for the ETA. Safe for concurrent use.
+func (p *ProgressState) ReportNodeTranslated(currentKind, currentNodeID string, elapsed time.Duration) {
+	p.report(currentKind, currentNodeID, elapsed, true)
+}
+
+func (p *ProgressState) report(currentKind, currentNodeID string, elapsed time.Duration, timed bool) {
 	if p == nil {
 		return
 	}
 	p.mu.Lock()
 	p.done++
+	if timed {
+		p.durations[p.next] = elapsed
+		p.next = (p.next + 1) % etaWindow
+		p.timed++
+	}
 	done := p.done
 	total := p.Total
+	eta := p.eta()
 	cb := p.Callback
 	p.mu.Unlock()
 	if cb != nil && total > 0 {
-		cb(done, total, currentKind, currentNodeID)
+		cb(done, total, currentKind, currentNodeID, eta)
 	}
 }
 
+// ETA returns the estimated time left: the nodes left times the average duration
+// of the latest etaWindow translated nodes, 0 if none was timed yet. Safe for concurrent use.
+func (p *ProgressState) ETA() time.Duration {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.eta()
+}
+
+func (p *ProgressState) eta() time.Duration {
+	n := min(p.timed, etaWindow)
+	left := p.Total - p.done
+	if n == 0 || left <= 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, d := range p.durations[:n] {
+		sum += d
+	}
+	return time.Duration(left) * (sum / time.Duration(n))
+}
+
 // Done returns the current processed count. Safe for concurrent use.
 func (p *ProgressState) Done() int {
 	if p == nil {
@@ -192,25 +357,76 @@ func (p *ProgressState) Done() int {
 // NewTranslateContext creates a new TranslateContext
 func NewTranslateContext(srcRepo, targetRepo *uniast.Repository, mod *uniast.Module, pkg *uniast.Package) *TranslateContext {
 	return &TranslateContext{
-		SourceRepo:      srcRepo,
-		TargetRepo:      targetRepo,
-		Module:          mod,
-		Package:         pkg,
-		TranslatedNodes: make(map[string]uniast.Identity),
+		SourceRepo:         srcRepo,
+		TargetRepo:         targetRepo,
+		Module:             mod,
+		Package:            pkg,
+		TranslatedNodes:    make(map[string]uniast.Identity),
+		TranslatedContents: make(map[string]string),
 	}
 }
 
+// packageContext returns a context of the target pkg sharing the translated nodes and contents of c,
+// so the contexts of the packages translated at once are synchronized by the same mutex
+func (c *TranslateContext) packageContext(mod *uniast.Module, pkg *uniast.Package) *TranslateContext {
+	root := c
+	if c.shared != nil {
+		root = c.shared
+	}
+	return &TranslateContext{
+		SourceRepo:         c.SourceRepo,
+		TargetRepo:         c.TargetRepo,
+		Module:             mod,
+		Package:            pkg,
+		TranslatedNodes:    c.TranslatedNodes,
+		TranslatedContents: c.TranslatedContents,
+		Result:             c.Result,
+		Progress:           c.Progress,
+		shared:             root,
+	}
+}
+
+// mutex returns the mutex protecting the maps of c
+func (c *TranslateContext) mutex() *sync.RWMutex {
+	if c.shared != nil {
+		return &c.shared.mu
+	}
+	return &c.mu
+}
+
 // AddTranslatedNode records a translated node mapping (safe for concurrent use)
 func (c *TranslateContext) AddTranslatedNode(sourceID, targetID uniast.Identity) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	mu := c.mutex()
+	mu.Lock()
+	defer mu.Unlock()
 	c.TranslatedNodes[sourceID.Full()] = targetID
 }
 
 // GetTranslatedNode returns the target identity for a source identity (safe for concurrent use)
 func (c *TranslateContext) GetTranslatedNode(sourceID uniast.Identity) (uniast.Identity, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	mu := c.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
 	targetID, ok := c.TranslatedNodes[sourceID.Full()]
 	return targetID, ok
 }
+
+// AddTranslatedContent records the target content of a translated node (safe for concurrent use)
+func (c *TranslateContext) AddTranslatedContent(sourceID uniast.Identity, content string) {
+	mu := c.mutex()
+	mu.Lock()
+	defer mu.Unlock()
+	if c.TranslatedContents == nil {
+		c.TranslatedContents = make(map[string]string)
+	}
+	c.TranslatedContents[sourceID.Full()] = content
+}
+
+// GetTranslatedContent returns the target content of a translated source node (safe for concurrent use)
+func (c *TranslateContext) GetTranslatedContent(sourceID uniast.Identity) (string, bool) {
+	mu := c.mutex()
+	mu.RLock()
+	defer mu.RUnlock()
+	content, ok := c.TranslatedContents[sourceID.Full()]
+	return content, ok
+}
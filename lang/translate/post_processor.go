@@ -18,7 +18,12 @@ package translate
 
 import (
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -27,10 +32,15 @@ import (
 // PostProcessOptions contains options for post-translation processing
 type PostProcessOptions struct {
 	GenerateEntryPoint bool   // Whether to generate entry point if missing
-	WebFramework       string // Web framework: "gin", "echo", "actix", "fastapi", "none"
+	WebFramework       string // Web framework: "gin", "echo", "hertz", "actix", "fastapi", "none"
 	GenerateConfig     bool   // Whether to generate project config files
 	ModuleName         string // Module name for config generation
 	OutputDir          string // Output directory path
+	GenerateDockerfile bool   // Whether to generate a Dockerfile (only works for Go now)
+
+	SourceLanguage uniast.Language    // Source language of the translation
+	SourceRepo     *uniast.Repository // Source repository, used to detect routes and entry points from source code (e.g. Express/Fastify)
+	LLMTranslator  LLMTranslateFunc   // LLM callback, used to translate entry points which are not nodes (e.g. Python main blocks)
 }
 
 // PostProcessor handles post-translation processing
@@ -44,12 +54,20 @@ type PostProcessor struct {
 
 // NewPostProcessor creates a new PostProcessor
 func NewPostProcessor(targetLang uniast.Language, opts PostProcessOptions) *PostProcessor {
+	configGenerator := NewConfigGenerator(targetLang, opts.ModuleName)
+	configGenerator.SetGenerateDockerfile(opts.GenerateDockerfile)
+	configGenerator.SetSource(opts.SourceLanguage, opts.SourceRepo)
+	frameworkIntegrator := NewFrameworkIntegrator(targetLang, opts.WebFramework)
+	frameworkIntegrator.SetSource(opts.SourceLanguage, opts.SourceRepo)
+	entryPointHandler := NewEntryPointHandler(targetLang)
+	entryPointHandler.SetSource(opts.SourceLanguage, opts.SourceRepo)
+	entryPointHandler.SetLLMTranslator(opts.LLMTranslator)
 	return &PostProcessor{
 		targetLang:          targetLang,
 		opts:                opts,
-		entryPointHandler:   NewEntryPointHandler(targetLang),
-		configGenerator:     NewConfigGenerator(targetLang, opts.ModuleName),
-		frameworkIntegrator: NewFrameworkIntegrator(targetLang, opts.WebFramework),
+		entryPointHandler:   entryPointHandler,
+		configGenerator:     configGenerator,
+		frameworkIntegrator: frameworkIntegrator,
 	}
 }
 
@@ -80,6 +98,11 @@ func (p *PostProcessor) Process(repo *uniast.Repository) (*uniast.Repository, er
 
 	// Step 3: Generate project configuration files
 	if p.opts.GenerateConfig {
+		if p.opts.WebFramework != "" && p.opts.WebFramework != "none" {
+			for _, dep := range p.frameworkIntegrator.GetDependencies() {
+				p.configGenerator.AddDependency(dep)
+			}
+		}
 		repo, err = p.generateConfig(repo)
 		if err != nil {
 			return nil, fmt.Errorf("config generation failed: %w", err)
@@ -209,30 +232,309 @@ func (p *PostProcessor) fixGoImports(repo *uniast.Repository) *uniast.Repository
 	importRegex := regexp.MustCompile(`"(com\.example[^"]*|your-module[^"]*|[a-z]+\.[a-z]+\.[a-z]+[^"]*)"`)
 
 	// Fix imports in all functions, types, vars
-	for _, mod := range repo.Modules {
-		if mod.IsExternal() {
-			continue
+	for _, fn := range repo.AllFunctions {
+		fn.Content = p.fixImportsInContent(fn.Content, existingPkgs, importRegex, moduleName, goPkgNames)
+	}
+	for _, typ := range repo.AllTypes {
+		typ.Content = p.fixImportsInContent(typ.Content, existingPkgs, importRegex, moduleName, goPkgNames)
+	}
+	for _, v := range repo.AllVars {
+		v.Content = p.fixImportsInContent(v.Content, existingPkgs, importRegex, moduleName, goPkgNames)
+	}
+	// Fix imports in files
+	for _, mod := range repo.InternalModules() {
+		for _, file := range mod.Files {
+			for i, imp := range file.Imports {
+				file.Imports[i].Path = p.fixImportPath(imp.Path, existingPkgs, moduleName, goPkgNames)
+			}
+		}
+	}
+
+	p.fixGoInternalImports(repo)
+	return repo
+}
+
+// fixGoInternalImports removes the imports of internal packages which Go forbids to the importing package,
+// i.e. a/b/internal/c imported from outside a/b, from the files, the node contents and the node dependencies.
+// The references to a removed package in the nodes are replaced with interface{},
+// and the nodes are marked with a TODO comment to replace it with a public equivalent.
+func (p *PostProcessor) fixGoInternalImports(repo *uniast.Repository) {
+	for _, mod := range repo.InternalModules() {
+		// removed imports of each file, by file path
+		removedByFile := make(map[string][]uniast.Import)
+		for _, file := range mod.Files {
+			importer := goImportPath(mod.Name, file.Package)
+			imports := file.Imports[:0]
+			for _, imp := range file.Imports {
+				if canImportGoPackage(importer, strings.Trim(imp.Path, `"`)) {
+					imports = append(imports, imp)
+				} else {
+					removedByFile[file.Path] = append(removedByFile[file.Path], imp)
+				}
+			}
+			file.Imports = imports
 		}
-		for _, pkg := range mod.Packages {
+
+		for pkgPath, pkg := range mod.Packages {
+			importer := goImportPath(mod.Name, pkgPath)
+			fix := func(id uniast.Identity, file string, content *string) {
+				var removed []uniast.Import
+				*content, removed = removeGoInternalImports(*content, importer)
+				removed = append(removed, removedByFile[file]...)
+				if len(removed) > 0 {
+					*content = replaceGoPackageRefs(*content, removed)
+				}
+				if node := repo.GetNode(id); node != nil {
+					deps := node.Dependencies[:0]
+					for _, dep := range node.Dependencies {
+						depPath := dep.PkgPath
+						if m := repo.Modules[dep.ModPath]; m != nil && !m.IsExternal() {
+							depPath = goImportPath(m.Name, dep.PkgPath)
+						}
+						if canImportGoPackage(importer, depPath) {
+							deps = append(deps, dep)
+						}
+					}
+					node.Dependencies = deps
+				}
+			}
 			for _, fn := range pkg.Functions {
-				fn.Content = p.fixImportsInContent(fn.Content, existingPkgs, importRegex, moduleName, goPkgNames)
+				fix(fn.Identity, fn.File, &fn.Content)
 			}
 			for _, typ := range pkg.Types {
-				typ.Content = p.fixImportsInContent(typ.Content, existingPkgs, importRegex, moduleName, goPkgNames)
+				fix(typ.Identity, typ.File, &typ.Content)
 			}
 			for _, v := range pkg.Vars {
-				v.Content = p.fixImportsInContent(v.Content, existingPkgs, importRegex, moduleName, goPkgNames)
+				fix(v.Identity, v.File, &v.Content)
 			}
 		}
-		// Fix imports in files
-		for _, file := range mod.Files {
-			for i, imp := range file.Imports {
-				file.Imports[i].Path = p.fixImportPath(imp.Path, existingPkgs, moduleName, goPkgNames)
+	}
+}
+
+// goImportPath returns the import path of the package pkgPath of the Go module modName,
+// pkgPath being either a full import path or relative to the module
+func goImportPath(modName string, pkgPath uniast.PkgPath) string {
+	if pkgPath == "" || pkgPath == modName || strings.HasPrefix(pkgPath, modName+"/") {
+		return pkgPath
+	}
+	return modName + "/" + strings.TrimPrefix(pkgPath, "./")
+}
+
+// canImportGoPackage tells if the package importer may import the package path under Go's internal rule:
+// a/b/internal/c can only be imported by a/b and its sub packages
+func canImportGoPackage(importer, path string) bool {
+	segs := strings.Split(path, "/")
+	for i := len(segs) - 1; i >= 0; i-- {
+		if segs[i] == "internal" {
+			root := strings.Join(segs[:i], "/")
+			return root == "" || importer == root || strings.HasPrefix(importer, root+"/")
+		}
+	}
+	return true
+}
+
+// goPackageClauseRegex matches the package clause of a Go file
+var goPackageClauseRegex = regexp.MustCompile(`(?m)^package[ \t]+\w+`)
+
+// removeGoInternalImports removes the imports of content that importer can't import, and returns them.
+// Only the import declarations at the top of content are looked at, parsed by go/parser with ImportsOnly.
+func removeGoInternalImports(content string, importer string) (string, []uniast.Import) {
+	src, offset := content, 0
+	if !goPackageClauseRegex.MatchString(content) {
+		// a node content has no package clause
+		src = "package p\n" + content
+		offset = len("package p\n")
+	}
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if f == nil {
+		return content, nil
+	}
+
+	var removed []uniast.Import
+	var cuts [][2]int
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			is := spec.(*ast.ImportSpec)
+			path, err := strconv.Unquote(is.Path.Value)
+			if err != nil || canImportGoPackage(importer, path) {
+				continue
 			}
+			imp := uniast.Import{Path: strconv.Quote(path)}
+			if is.Name != nil {
+				alias := is.Name.Name
+				imp.Alias = &alias
+			}
+			removed = append(removed, imp)
+			// the whole line of the spec, or of the declaration if it is not a block
+			var node ast.Node = is
+			if !gd.Lparen.IsValid() {
+				node = gd
+			}
+			cut := lineRange(content, fset.Position(node.Pos()).Offset-offset, fset.Position(node.End()).Offset-offset)
+			cuts = append(cuts, cut)
 		}
 	}
+	if len(removed) == 0 {
+		return content, nil
+	}
+	for i := len(cuts) - 1; i >= 0; i-- {
+		content = content[:cuts[i][0]] + content[cuts[i][1]:]
+	}
+	// an import block left empty
+	content = regexp.MustCompile(`import[ \t]*\([ \t\n]*\)[ \t]*\n?`).ReplaceAllString(content, "")
+	return content, removed
+}
+
+// lineRange extends the range [start, end) of content to its whole lines including the ending newline,
+// unless other code shares the lines, e.g. several import specs separated by ;
+func lineRange(content string, start, end int) [2]int {
+	lineStart := strings.LastIndexByte(content[:start], '\n') + 1
+	lineEnd := len(content)
+	if i := strings.IndexByte(content[end:], '\n'); i >= 0 {
+		lineEnd = end + i + 1
+	}
+	if strings.TrimSpace(content[lineStart:start]) != "" || strings.TrimSpace(content[end:lineEnd]) != "" {
+		return [2]int{start, end}
+	}
+	return [2]int{lineStart, lineEnd}
+}
 
-	return repo
+// replaceGoPackageRefs replaces the references to the removed imports in the type positions of content,
+// e.g. the parameter type internal.Config, with interface{}, and adds a TODO comment for each of the packages.
+// The values and calls, e.g. internal.Default(), are left as is to be fixed by hand.
+func replaceGoPackageRefs(content string, removed []uniast.Import) string {
+	src, offset := content, 0
+	if !goPackageClauseRegex.MatchString(content) {
+		src = "package p\n" + content
+		offset = len("package p\n")
+	}
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, "", src, 0)
+
+	var todos strings.Builder
+	for _, imp := range removed {
+		path := strings.Trim(imp.Path, `"`)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Alias != nil && *imp.Alias != "" {
+			name = *imp.Alias
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		if f == nil {
+			// not valid Go, only marked
+			if regexp.MustCompile(`(^|[^\w.])` + regexp.QuoteMeta(name) + `\.[A-Z]\w*`).MatchString(content) {
+				fmt.Fprintf(&todos, "// TODO: internal package %s, replace with a public equivalent\n", path)
+			}
+			continue
+		}
+		refs, types := goPackageRefs(f, name)
+		if refs == 0 {
+			continue
+		}
+		for i := len(types) - 1; i >= 0; i-- {
+			start, end := fset.Position(types[i].Pos()).Offset-offset, fset.Position(types[i].End()).Offset-offset
+			content = content[:start] + "interface{}" + content[end:]
+		}
+		fmt.Fprintf(&todos, "// TODO: internal package %s, replace with a public equivalent\n", path)
+	}
+	return todos.String() + content
+}
+
+// goPackageRefs returns the number of references to the package imported as name in f,
+// and the type expressions among them in order, a pointer type *name.T as a whole
+func goPackageRefs(f *ast.File, name string) (int, []ast.Expr) {
+	isRef := func(e ast.Expr) bool {
+		sel, ok := e.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		x, ok := sel.X.(*ast.Ident)
+		return ok && x.Name == name
+	}
+
+	types := make(map[ast.Expr]bool)
+	var markType func(e ast.Expr)
+	markType = func(e ast.Expr) {
+		switch t := e.(type) {
+		case *ast.SelectorExpr:
+			if isRef(t) {
+				types[t] = true
+			}
+		case *ast.StarExpr:
+			if isRef(t.X) {
+				types[t] = true
+			} else {
+				markType(t.X)
+			}
+		case *ast.ParenExpr:
+			markType(t.X)
+		case *ast.ArrayType:
+			markType(t.Elt)
+		case *ast.MapType:
+			markType(t.Key)
+			markType(t.Value)
+		case *ast.ChanType:
+			markType(t.Value)
+		case *ast.Ellipsis:
+			markType(t.Elt)
+		case *ast.IndexExpr:
+			markType(t.X)
+			markType(t.Index)
+		case *ast.IndexListExpr:
+			markType(t.X)
+			for _, index := range t.Indices {
+				markType(index)
+			}
+		}
+	}
+
+	refs := 0
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if isRef(n) {
+				refs++
+			}
+		case *ast.Field:
+			markType(n.Type)
+		case *ast.ValueSpec:
+			markType(n.Type)
+		case *ast.TypeSpec:
+			markType(n.Type)
+		case *ast.CompositeLit:
+			markType(n.Type)
+		case *ast.TypeAssertExpr:
+			markType(n.Type)
+		case *ast.ArrayType, *ast.MapType, *ast.ChanType:
+			markType(n.(ast.Expr))
+		case *ast.CallExpr:
+			// the type argument of new(T) and make(T, ...)
+			if fn, ok := n.Fun.(*ast.Ident); ok && (fn.Name == "new" || fn.Name == "make") && len(n.Args) > 0 {
+				markType(n.Args[0])
+			}
+		}
+		return true
+	})
+
+	sorted := make([]ast.Expr, 0, len(types))
+	for e := range types {
+		sorted = append(sorted, e)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Pos() < sorted[j].Pos() })
+	// drop the expressions nested in another one
+	ret := sorted[:0]
+	for _, e := range sorted {
+		if len(ret) == 0 || e.Pos() >= ret[len(ret)-1].End() {
+			ret = append(ret, e)
+		}
+	}
+	return refs, ret
 }
 
 // fixImportsInContent fixes import statements in code content
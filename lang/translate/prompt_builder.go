@@ -18,6 +18,9 @@ package translate
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -28,6 +31,9 @@ type PromptBuilder struct {
 	source    uniast.Language
 	target    uniast.Language
 	typeHints *TypeHints
+
+	// maxPromptTokens limits the estimated tokens of each prompt (0 = no limit)
+	maxPromptTokens int
 }
 
 // NewPromptBuilder creates a new PromptBuilder
@@ -39,137 +45,512 @@ func NewPromptBuilder(source, target uniast.Language, typeHints *TypeHints) *Pro
 	}
 }
 
+// WithMaxPromptLength limits the estimated tokens of each prompt built by b (0 = no limit), see TranslateOptions.MaxPromptLength
+func (b *PromptBuilder) WithMaxPromptLength(tokens int) *PromptBuilder {
+	b.maxPromptTokens = tokens
+	return b
+}
+
 // BuildTypePrompt builds a prompt for translating a type
 func (b *PromptBuilder) BuildTypePrompt(req *LLMTranslateRequest) string {
+	return b.buildNodePrompt(req, "type/class", b.getTypeRequirements()+b.getKindRequirements(req.NodeKind))
+}
+
+// BuildFunctionPrompt builds a prompt for translating a function
+func (b *PromptBuilder) BuildFunctionPrompt(req *LLMTranslateRequest) string {
+	requirements := b.getFunctionRequirements()
+	if req.IsConstructor {
+		requirements += b.getConstructorRequirements(req.Identity)
+	}
+	if req.ReceiverType != "" {
+		requirements += fmt.Sprintf("\n- This is a method on `%s`", req.ReceiverType)
+	}
+	requirements += b.getMainRequirements(req)
+	return b.buildNodePrompt(req, "function/method", requirements)
+}
+
+// BuildVarPrompt builds a prompt for translating a variable
+func (b *PromptBuilder) BuildVarPrompt(req *LLMTranslateRequest) string {
+	return b.buildNodePrompt(req, "variable/constant", b.getVarRequirements()+b.getKindRequirements(req.NodeKind))
+}
+
+// buildNodePrompt builds the prompt translating the node of req, a kind node, with the requirements.
+// When the prompt length is limited, the sections are given the budget by priority:
+// the instructions first, then the source, the type mapping, and at last the dependencies and context.
+func (b *PromptBuilder) buildNodePrompt(req *LLMTranslateRequest, kind, requirements string) string {
+	budget := b.newBudget()
+
+	var head, doc, notes, tail strings.Builder
+	head.WriteString(fmt.Sprintf("Translate the following %s %s to %s.\n\n", b.source, kind, b.target))
+	// Add the documentation of the source, apart from the code
+	b.writeDocString(&doc, req.DocString)
+	b.writeComment(&notes, req.TargetComment)
+	b.writeTags(&notes, req.Tags)
+	b.writeTypeParams(&notes, req.TypeParams)
+	b.writeBuildError(&notes, req.BuildError)
+	// Add requirements
+	tail.WriteString("## Requirements\n")
+	tail.WriteString(requirements)
+	tail.WriteString("\n\n")
+	// Add output format
+	tail.WriteString("## Output\n")
+	tail.WriteString("Return ONLY the translated code, no explanations or markdown formatting.\n")
+	budget.Consume(head.String() + doc.String() + notes.String() + tail.String())
+
+	var source, hints, deps, contextNodes strings.Builder
+	b.writeSource(&source, "## Source Code\n", req.SourceContent, req.SourceTruncated, budget)
+	b.writeTypeHints(&hints, budget)
+	if len(req.Dependencies) > 0 {
+		b.writeDependencies(&deps, req.Dependencies, budget)
+	}
+	b.writeContextNodes(&contextNodes, req.ContextNodes, budget)
+	if pkgContext := b.BuildContextSection(req.PackageContext); budget.Take(pkgContext) {
+		contextNodes.WriteString(pkgContext)
+	}
+
 	var sb strings.Builder
+	sb.WriteString(head.String())
+	sb.WriteString(hints.String())
+	sb.WriteString(deps.String())
+	sb.WriteString(contextNodes.String())
+	sb.WriteString(doc.String())
+	sb.WriteString(source.String())
+	sb.WriteString(notes.String())
+	sb.WriteString(tail.String())
+	return sb.String()
+}
 
-	sb.WriteString(fmt.Sprintf("Translate the following %s type/class to %s.\n\n", b.source, b.target))
+// newBudget returns the token budget of a prompt, nil if the prompt length is not limited
+func (b *PromptBuilder) newBudget() *TokenBudget {
+	return NewTokenBudget(b.maxPromptTokens)
+}
 
-	// Add type mapping reference
-	sb.WriteString("## Type Mapping Reference\n")
-	sb.WriteString(b.typeHints.FormatForPrompt())
+// writeSource writes the source code section with the given header.
+// The source is truncated to fit the budget, which it takes the rest of if needed.
+func (b *PromptBuilder) writeSource(sb *strings.Builder, header, content string, truncated bool, budget *TokenBudget) {
+	var fence strings.Builder
+	fence.WriteString(header)
+	fence.WriteString(truncatedSourceNote)
+	fence.WriteString("```")
+	fence.WriteString(string(b.source))
+	fence.WriteString("\n\n```\n\n")
+	budget.Consume(fence.String())
+	if !budget.Take(content) {
+		content, _ = truncateSourceForPrompt(content, max(budget.Remaining()*charsPerToken, 1))
+		budget.Consume(content)
+		truncated = true
+	}
+
+	sb.WriteString(header)
+	if truncated {
+		sb.WriteString(truncatedSourceNote)
+	}
+	sb.WriteString("```")
+	sb.WriteString(string(b.source))
 	sb.WriteString("\n")
+	sb.WriteString(content)
+	sb.WriteString("\n```\n\n")
+}
 
-	// Add already translated dependencies
-	if len(req.Dependencies) > 0 {
-		sb.WriteString("## Already Translated Dependencies\n")
-		b.writeDependencies(&sb, req.Dependencies)
-		sb.WriteString("\n")
+// truncatedSourceNote is written before a source truncated for context limit
+const truncatedSourceNote = "Note: Source was truncated for context limit; translate the visible part only.\n\n"
+
+// writeTypeHints writes the type mapping section, with the mappings fitting the budget
+func (b *PromptBuilder) writeTypeHints(sb *strings.Builder, budget *TokenBudget) {
+	lines := strings.SplitAfter(b.typeHints.FormatForPrompt(), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	// the header of the table is kept with the header of the section
+	n := min(2, len(lines))
+	header := "## Type Mapping Reference\n" + strings.Join(lines[:n], "")
+	if n == len(lines) {
+		if budget.Take(header + "\n") {
+			sb.WriteString(header + "\n")
+		}
+		return
 	}
+	writeBudgetedSection(sb, header, lines[n:], budget)
+}
+
+// BuildEntryPointPrompt builds a prompt for translating the body of a source entry point
+// (e.g. a Python `if __name__ == "__main__":` block) into the statements of the target main function
+func (b *PromptBuilder) BuildEntryPointPrompt(req *LLMTranslateRequest) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Translate the following %s entry point block to the body of the %s main function.\n\n", b.source, b.target))
 
-	// Add source code
 	sb.WriteString("## Source Code\n")
-	if req.SourceTruncated {
-		sb.WriteString("Note: Source was truncated for context limit; translate the visible part only.\n\n")
-	}
 	sb.WriteString("```")
 	sb.WriteString(string(b.source))
 	sb.WriteString("\n")
 	sb.WriteString(req.SourceContent)
 	sb.WriteString("\n```\n\n")
 
-	// Add requirements
-	sb.WriteString("## Requirements\n")
-	sb.WriteString(b.getTypeRequirements())
-	sb.WriteString("\n\n")
+	if requirements := b.getEntryPointRequirements(); requirements != "" {
+		sb.WriteString("## Requirements\n")
+		sb.WriteString(requirements)
+		sb.WriteString("\n\n")
+	}
 
-	// Add output format
 	sb.WriteString("## Output\n")
-	sb.WriteString("Return ONLY the translated code, no explanations or markdown formatting.\n")
+	sb.WriteString("Return ONLY the statements of the function body, without the function declaration, explanations or markdown formatting.\n")
 
 	return sb.String()
 }
 
-// BuildFunctionPrompt builds a prompt for translating a function
-func (b *PromptBuilder) BuildFunctionPrompt(req *LLMTranslateRequest) string {
-	var sb strings.Builder
-
-	sb.WriteString(fmt.Sprintf("Translate the following %s function/method to %s.\n\n", b.source, b.target))
+// batchNodeHeaderRegex matches the header line of each node in a batch response, like `### Node 1`
+var batchNodeHeaderRegex = regexp.MustCompile(`(?m)^[ \t]*(?:#{1,6}|//)[ \t]*Node[ \t]+(\d+)\b.*$`)
 
-	// Add type mapping reference
-	sb.WriteString("## Type Mapping Reference\n")
-	sb.WriteString(b.typeHints.FormatForPrompt())
-	sb.WriteString("\n")
+// BuildBatchPrompt builds a prompt for translating several nodes of the same kind in a single LLM call.
+// Each node is given in a numbered section, and the output is split back by ParseBatchResponse.
+func (b *PromptBuilder) BuildBatchPrompt(reqs []*LLMTranslateRequest) string {
+	if len(reqs) == 0 {
+		return ""
+	}
+	var sb strings.Builder
 
-	// Add already translated dependencies
-	if len(req.Dependencies) > 0 {
-		sb.WriteString("## Already Translated Dependencies\n")
-		b.writeDependencies(&sb, req.Dependencies)
-		sb.WriteString("\n")
+	kind, requirements := "functions/methods", b.getFunctionRequirements()
+	switch reqs[0].NodeType {
+	case uniast.VAR:
+		kind, requirements = "variables/constants", b.getVarRequirements()
+	case uniast.TYPE:
+		kind, requirements = "types/classes", b.getTypeRequirements()
+	}
+	sb.WriteString(fmt.Sprintf("Translate the following %d %s %s to %s.\n\n", len(reqs), b.source, kind, b.target))
+
+	// Add already translated dependencies of all nodes
+	var deps []DependencyHint
+	seen := make(map[string]bool)
+	for _, req := range reqs {
+		for _, dep := range req.Dependencies {
+			if key := dep.SourceIdentity.Full(); !seen[key] {
+				seen[key] = true
+				deps = append(deps, dep)
+			}
+		}
+	}
+	var contextNodes []ContextNodeHint
+	for _, req := range reqs {
+		for _, n := range req.ContextNodes {
+			if key := n.Identity.Full(); !seen[key] {
+				seen[key] = true
+				contextNodes = append(contextNodes, n)
+			}
+		}
 	}
 
-	// Add source code
-	sb.WriteString("## Source Code\n")
-	if req.SourceTruncated {
-		sb.WriteString("Note: Source was truncated for context limit; translate the visible part only.\n\n")
+	// Add source code of each node, the sources are always kept whole
+	var nodes, tail strings.Builder
+	for i, req := range reqs {
+		b.writeSource(&nodes, fmt.Sprintf("## Node %d: `%s`\n", i+1, req.Identity.Name), req.SourceContent, req.SourceTruncated, nil)
+		b.writeComment(&nodes, req.TargetComment)
+		b.writeTags(&nodes, req.Tags)
+		b.writeBuildError(&nodes, req.BuildError)
+		if kind := b.getKindRequirements(req.NodeKind); kind != "" {
+			nodes.WriteString(strings.TrimPrefix(kind, "\n") + "\n\n")
+		}
+		if main := b.getMainRequirements(req); main != "" {
+			nodes.WriteString(strings.TrimPrefix(main, "\n") + "\n\n")
+		}
 	}
-	sb.WriteString("```")
-	sb.WriteString(string(b.source))
-	sb.WriteString("\n")
-	sb.WriteString(req.SourceContent)
-	sb.WriteString("\n```\n\n")
 
 	// Add requirements
-	sb.WriteString("## Requirements\n")
-	sb.WriteString(b.getFunctionRequirements())
-	sb.WriteString("\n\n")
+	tail.WriteString("## Requirements\n")
+	tail.WriteString(requirements)
+	tail.WriteString("\n- Translate each node separately, do NOT merge nodes or add code not belonging to any node")
+	tail.WriteString("\n\n")
 
 	// Add output format
-	sb.WriteString("## Output\n")
-	sb.WriteString("Return ONLY the translated code, no explanations or markdown formatting.\n")
+	tail.WriteString("## Output\n")
+	tail.WriteString(fmt.Sprintf("Return ONLY the translated code of the %d nodes in order, no explanations. ", len(reqs)))
+	tail.WriteString("Put a line `### Node <number>` before the code of each node, e.g.:\n")
+	tail.WriteString("### Node 1\n<translated code of node 1>\n### Node 2\n<translated code of node 2>\n")
+
+	budget := b.newBudget()
+	budget.Consume(sb.String() + nodes.String() + tail.String())
+	var hints, depSection strings.Builder
+	b.writeTypeHints(&hints, budget)
+	if len(deps) > 0 {
+		b.writeDependencies(&depSection, deps, budget)
+	}
+	b.writeContextNodes(&depSection, contextNodes, budget)
 
+	sb.WriteString(hints.String())
+	sb.WriteString(depSection.String())
+	sb.WriteString(nodes.String())
+	sb.WriteString(tail.String())
 	return sb.String()
 }
 
-// BuildVarPrompt builds a prompt for translating a variable
-func (b *PromptBuilder) BuildVarPrompt(req *LLMTranslateRequest) string {
-	var sb strings.Builder
+// ParseBatchResponse splits the LLM response of a batch prompt into the responses of each node.
+// The i-th response belongs to the node numbered i+1, and it is nil if that node is missing in the response.
+func ParseBatchResponse(response string) []*LLMTranslateResponse {
+	var ret []*LLMTranslateResponse
+	matches := batchNodeHeaderRegex.FindAllStringSubmatchIndex(response, -1)
+	for i, m := range matches {
+		n, err := strconv.Atoi(response[m[2]:m[3]])
+		if err != nil || n < 1 {
+			continue
+		}
+		end := len(response)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		content := trimCodeFence(response[m[1]:end])
+		if content == "" {
+			continue
+		}
+		for len(ret) < n {
+			ret = append(ret, nil)
+		}
+		if ret[n-1] == nil {
+			ret[n-1] = &LLMTranslateResponse{TargetContent: content}
+		}
+	}
+	return ret
+}
+
+// packageNodeHeaderRegex matches the header line of each node in a package response, like `### Node User.getName`.
+// Only the markdown heading is accepted, so that code comments like `// Node ...` or `# Node ...` don't split a node
+var packageNodeHeaderRegex = regexp.MustCompile("(?m)^[ \t]*###[ \t]+Node[ \t]+`?([^`\\s:]+)`?.*$")
 
-	sb.WriteString(fmt.Sprintf("Translate the following %s variable/constant to %s.\n\n", b.source, b.target))
+// BuildPackagePrompt builds a prompt for translating all the types, functions and vars of pkg in a single LLM call,
+// so that the LLM sees the whole package contract. The output is split back by ParsePackageResponse.
+func (b *PromptBuilder) BuildPackagePrompt(pkg *uniast.Package, srcLang, dstLang uniast.Language) string {
+	if pkg == nil || pkg.NodeCount() == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Translate the whole %s package `%s` below to %s, as a single cohesive unit.\n\n", srcLang, pkg.PkgPath, dstLang))
 
 	// Add type mapping reference
 	sb.WriteString("## Type Mapping Reference\n")
 	sb.WriteString(b.typeHints.FormatForPrompt())
 	sb.WriteString("\n")
 
-	// Add already translated dependencies
-	if len(req.Dependencies) > 0 {
-		sb.WriteString("## Already Translated Dependencies\n")
-		b.writeDependencies(&sb, req.Dependencies)
+	// Add source code of each node, types first since functions and vars use them
+	writeNode := func(kind, name, content string) {
+		sb.WriteString(fmt.Sprintf("## %s `%s`\n", kind, name))
+		sb.WriteString("```")
+		sb.WriteString(string(srcLang))
 		sb.WriteString("\n")
+		sb.WriteString(content)
+		sb.WriteString("\n```\n\n")
 	}
-
-	// Add source code
-	sb.WriteString("## Source Code\n")
-	if req.SourceTruncated {
-		sb.WriteString("Note: Source was truncated for context limit; translate the visible part only.\n\n")
+	for _, name := range sortedKeys(pkg.Types) {
+		writeNode("Type", name, pkg.Types[name].Content)
+	}
+	for _, name := range sortedKeys(pkg.Functions) {
+		writeNode("Function", name, pkg.Functions[name].Content)
+	}
+	for _, name := range sortedKeys(pkg.Vars) {
+		writeNode("Var", name, pkg.Vars[name].Content)
 	}
-	sb.WriteString("```")
-	sb.WriteString(string(b.source))
-	sb.WriteString("\n")
-	sb.WriteString(req.SourceContent)
-	sb.WriteString("\n```\n\n")
 
 	// Add requirements
 	sb.WriteString("## Requirements\n")
-	sb.WriteString(b.getVarRequirements())
+	if len(pkg.Types) > 0 {
+		sb.WriteString(b.getTypeRequirements())
+		sb.WriteString("\n")
+	}
+	if len(pkg.Functions) > 0 {
+		sb.WriteString(b.getFunctionRequirements())
+		sb.WriteString("\n")
+	}
+	if len(pkg.Vars) > 0 {
+		sb.WriteString(b.getVarRequirements())
+		sb.WriteString("\n")
+	}
+	sb.WriteString("- Keep the nodes consistent with each other, as they belong to the same package")
+	sb.WriteString("\n- Translate each node separately, do NOT merge nodes or add code not belonging to any node")
 	sb.WriteString("\n\n")
 
 	// Add output format
 	sb.WriteString("## Output\n")
-	sb.WriteString("Return ONLY the translated code, no explanations or markdown formatting.\n")
+	sb.WriteString(fmt.Sprintf("Return ONLY the translated code of the %d nodes, no explanations. ", pkg.NodeCount()))
+	sb.WriteString("Put a line `### Node <name>` with the source name of the node before its code, e.g.:\n")
+	sb.WriteString("### Node User\n<translated code of User>\n### Node User.getName\n<translated code of User.getName>\n")
 
 	return sb.String()
 }
 
-// writeDependencies writes dependency hints to the builder
-func (b *PromptBuilder) writeDependencies(sb *strings.Builder, deps []DependencyHint) {
-	for _, dep := range deps {
-		sb.WriteString(fmt.Sprintf("- `%s` -> `%s`\n", dep.SourceIdentity.Name, dep.TargetIdentity.Name))
+// ParsePackageResponse splits the LLM response of a package prompt into the translated code of each node,
+// returns source node name => translated content. The first code of a node wins if it is repeated.
+func ParsePackageResponse(response string) map[string]string {
+	ret := make(map[string]string)
+	matches := packageNodeHeaderRegex.FindAllStringSubmatchIndex(response, -1)
+	for i, m := range matches {
+		name := response[m[2]:m[3]]
+		end := len(response)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		content := trimCodeFence(response[m[1]:end])
+		if content == "" {
+			continue
+		}
+		if _, ok := ret[name]; !ok {
+			ret[name] = content
+		}
+	}
+	return ret
+}
+
+// trimCodeFence trims spaces and the markdown code fence around code
+func trimCodeFence(code string) string {
+	code = strings.TrimSpace(code)
+	if strings.HasPrefix(code, "```") {
+		if idx := strings.Index(code, "\n"); idx >= 0 {
+			code = code[idx+1:]
+		} else {
+			code = ""
+		}
+	}
+	code = strings.TrimSuffix(strings.TrimSpace(code), "```")
+	return strings.TrimSpace(code)
+}
+
+// writeDocString writes the doc comment of the source node to the builder
+func (b *PromptBuilder) writeDocString(sb *strings.Builder, doc string) {
+	if doc == "" {
+		return
+	}
+	sb.WriteString("## Documentation\n")
+	sb.WriteString("The documentation of the source code, describing its intended behavior:\n")
+	sb.WriteString("```\n")
+	sb.WriteString(doc)
+	sb.WriteString("\n```\n\n")
+}
+
+// writeComment writes the converted doc comment to the builder
+func (b *PromptBuilder) writeComment(sb *strings.Builder, comment string) {
+	if comment == "" {
+		return
+	}
+	sb.WriteString("## Doc Comment\n")
+	sb.WriteString("Keep the following doc comment (already converted to the target language style) on the translated code:\n")
+	sb.WriteString("```\n")
+	sb.WriteString(comment)
+	sb.WriteString("\n```\n\n")
+}
+
+// writeTags writes the language-specific metadata annotations of the source node to the builder
+func (b *PromptBuilder) writeTags(sb *strings.Builder, tags map[string]string) {
+	if len(tags) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sb.WriteString("## Source Annotations to Consider\n")
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("- `%s`: `%s`\n", k, tags[k]))
+	}
+	sb.WriteString(fmt.Sprintf("These annotations affect the runtime behavior of the source; preserve their semantics with the idiomatic %s equivalent (framework, code generation or explicit code).\n\n", b.target))
+}
+
+// writeBuildError writes the build error caused by the previous translation to the builder
+func (b *PromptBuilder) writeBuildError(sb *strings.Builder, buildError string) {
+	if buildError == "" {
+		return
+	}
+	sb.WriteString("## Previous Build Error\n")
+	sb.WriteString(fmt.Sprintf("Previous translation caused build error: %s. Fix it.\n\n", buildError))
+}
+
+// writeTypeParams writes the generic type parameters to the builder
+func (b *PromptBuilder) writeTypeParams(sb *strings.Builder, params []uniast.TypeParam) {
+	if len(params) == 0 {
+		return
+	}
+	sb.WriteString("## Type Parameters\n")
+	for _, p := range params {
+		sb.WriteString(fmt.Sprintf("- `%s`: `%s`\n", p.Name, p.Constraint))
+	}
+	sb.WriteString(fmt.Sprintf("Keep these type parameters using %s generics and map each constraint to its closest equivalent.\n\n", b.target))
+}
+
+// BuildContextSection builds the package context section listing the signatures of the translated sibling nodes,
+// or returns an empty string if there is none
+func (b *PromptBuilder) BuildContextSection(translatedSiblings []*uniast.Node) string {
+	var sb strings.Builder
+	for _, n := range translatedSiblings {
+		sig := contextSignature(n)
+		if sig == "" {
+			continue
+		}
+		if sb.Len() == 0 {
+			sb.WriteString("## Package Context\n")
+			sb.WriteString("Already translated nodes of the same package, use them as they are and do NOT redeclare them:\n")
+		}
+		sb.WriteString(fmt.Sprintf("- `%s` (%s):\n", n.Identity.Name, n.Type))
+		sb.WriteString("```")
+		sb.WriteString(string(b.target))
+		sb.WriteString("\n")
+		sb.WriteString(sig)
+		sb.WriteString("\n```\n")
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// maxContextSignatureLines caps the lines of each node in the package context, e.g. for a long class
+const maxContextSignatureLines = 10
+
+// contextSignature returns the signature of a node for the package context:
+// the signature of a function (or its first line if unknown), the declaration of a type or var
+func contextSignature(n *uniast.Node) string {
+	sig := strings.TrimSpace(n.Signature())
+	if sig == "" && n.Type == uniast.FUNC {
+		sig, _, _ = strings.Cut(strings.TrimSpace(n.Content()), "\n")
+		sig = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(sig), "{"))
+	}
+	if lines := strings.Split(sig, "\n"); len(lines) > maxContextSignatureLines {
+		sig = strings.Join(lines[:maxContextSignatureLines], "\n") + "\n..."
+	}
+	return sig
+}
+
+// writeDependencies writes the section of dependency hints to the builder, stopping when the budget is exhausted
+func (b *PromptBuilder) writeDependencies(sb *strings.Builder, deps []DependencyHint, budget *TokenBudget) {
+	entries := make([]string, len(deps))
+	for i, dep := range deps {
+		entries[i] = fmt.Sprintf("- `%s` -> `%s`\n", dep.SourceIdentity.Name, dep.TargetIdentity.Name)
 		if dep.TargetSignature != "" {
-			sb.WriteString(fmt.Sprintf("  Signature: `%s`\n", dep.TargetSignature))
+			entries[i] += fmt.Sprintf("  Signature: `%s`\n", dep.TargetSignature)
+		}
+	}
+	writeBudgetedSection(sb, "## Already Translated Dependencies\n", entries, budget)
+}
+
+// writeBudgetedSection writes the header and the entries fitting the budget, nothing if no entry fits
+func writeBudgetedSection(sb *strings.Builder, header string, entries []string, budget *TokenBudget) {
+	if len(entries) == 0 || !budget.Take(header+entries[0]+"\n") {
+		return
+	}
+	sb.WriteString(header)
+	sb.WriteString(entries[0])
+	for _, entry := range entries[1:] {
+		if !budget.Take(entry) {
+			break
 		}
+		sb.WriteString(entry)
+	}
+	sb.WriteString("\n")
+}
+
+// writeContextNodes writes the translated code of the related nodes to the builder, stopping when the budget is exhausted
+func (b *PromptBuilder) writeContextNodes(sb *strings.Builder, nodes []ContextNodeHint, budget *TokenBudget) {
+	entries := make([]string, len(nodes))
+	for i, n := range nodes {
+		entries[i] = fmt.Sprintf("- `%s`:\n```%s\n%s\n```\n", n.Identity.Name, b.target, strings.TrimSpace(n.TranslatedContent))
 	}
+	writeBudgetedSection(sb, "## Related Translated Code\nThe dependencies below are already translated, call them as they are and do NOT redeclare them:\n", entries, budget)
 }
 
 // getTypeRequirements returns language-specific requirements for type translation
@@ -185,6 +566,10 @@ func (b *PromptBuilder) getTypeRequirements() string {
 		common = `- Source is TypeScript: convert interface to Go struct or interface; convert class to struct with methods; use exported (PascalCase) for public, unexported for private
 ` + common
 	}
+	if b.source == uniast.Golang && b.target == uniast.Java {
+		common = `- Source is Go: convert struct to class (exported fields -> private fields with getters/setters); convert Go interface to Java interface; convert embedded structs to fields or inheritance
+` + common
+	}
 
 	switch b.target {
 	case uniast.Golang:
@@ -218,6 +603,97 @@ func (b *PromptBuilder) getTypeRequirements() string {
 	}
 }
 
+// getKindRequirements returns the requirements for translating a node of the specific kind, e.g. uniast.TypeEnum,
+// into the target language. It is empty for the general kinds FUNC, TYPE and VAR.
+func (b *PromptBuilder) getKindRequirements(kind uniast.NodeType) string {
+	var rule string
+	switch kind {
+	case uniast.TypeStruct:
+		switch b.target {
+		case uniast.Golang, uniast.Rust:
+			rule = "This is a struct/class: translate it to a struct, its methods are translated separately"
+		default:
+			rule = "This is a struct/class: translate it to a class, its methods are translated separately"
+		}
+	case uniast.TypeInterface:
+		switch b.target {
+		case uniast.Golang:
+			rule = "This is an interface: translate it to a Go interface with the method signatures only"
+		case uniast.Rust:
+			rule = "This is an interface: translate it to a Rust trait"
+		case uniast.Python:
+			rule = "This is an interface: translate it to a typing.Protocol or an abc.ABC class with abstract methods"
+		default:
+			rule = "This is an interface: translate it to an interface"
+		}
+	case uniast.TypeEnum:
+		switch b.target {
+		case uniast.Golang:
+			rule = "This is an enum: translate it to a named type (e.g. `type Color int`) and a const block of its variants using iota; " +
+				"variants holding data (e.g. Rust `Shape::Circle(f64)`) become an interface implemented by one struct per variant"
+		case uniast.Rust:
+			rule = "This is an enum: translate it to a Rust enum, with data in the variants if needed"
+		case uniast.Python:
+			rule = "This is an enum: translate it to a class extending enum.Enum"
+		case uniast.Java:
+			rule = "This is an enum: translate it to a Java enum, with fields and a constructor if the variants hold values"
+		default:
+			rule = "This is an enum: translate it to the idiomatic enum of the target language"
+		}
+	case uniast.TypeAlias:
+		switch b.target {
+		case uniast.Golang:
+			rule = "This is a type alias: translate it to a Go alias `type A = B`, NOT to a new defined type `type A B`"
+		case uniast.Rust:
+			rule = "This is a type alias: translate it to a Rust alias `type A = B;`"
+		case uniast.Python:
+			rule = "This is a type alias: translate it to a typing.TypeAlias `A: TypeAlias = B`"
+		case uniast.Java:
+			rule = "This is a type alias: Java has no type aliases, use the aliased type directly or the closest class/interface"
+		default:
+			rule = "This is a type alias: keep it an alias of the same type"
+		}
+	case uniast.VarConst:
+		switch b.target {
+		case uniast.Golang:
+			rule = "This is a constant: translate it to a Go const if the value is known at compile time, otherwise to a var"
+		case uniast.Rust:
+			rule = "This is a constant: translate it to a Rust const, or a static if it is not known at compile time"
+		case uniast.Python:
+			rule = "This is a constant: translate it to a module level SCREAMING_SNAKE_CASE name annotated with Final"
+		case uniast.Java:
+			rule = "This is a constant: translate it to a static final field"
+		default:
+			rule = "This is a constant: keep it immutable"
+		}
+	default:
+		return ""
+	}
+	return "\n- IMPORTANT: " + rule
+}
+
+// getConstructorRequirements returns the requirements for translating the constructor id into the target language
+func (b *PromptBuilder) getConstructorRequirements(id uniast.Identity) string {
+	typeName := id.Name
+	if i := strings.IndexAny(typeName, ".:"); i >= 0 {
+		typeName = typeName[:i]
+	}
+	var rule string
+	switch b.target {
+	case uniast.Golang:
+		rule = fmt.Sprintf("translate as a Go `New%s(...) *%s` function returning the initialized struct, not as a method", typeName, typeName)
+	case uniast.Rust:
+		rule = "translate as an associated `pub fn new(...) -> Self` function in the `impl` block, returning the struct instead of mutating `self`"
+	case uniast.Python:
+		rule = "translate as the `def __init__(self, ...)` method of the class, setting the attributes on `self` and returning nothing"
+	case uniast.Java:
+		rule = fmt.Sprintf("translate as a constructor `%s(...)` of the class, without a return type", typeName)
+	default:
+		rule = "translate as the idiomatic constructor of the type"
+	}
+	return fmt.Sprintf("\n- IMPORTANT: This is a constructor of %s: %s", typeName, rule)
+}
+
 // getFunctionRequirements returns language-specific requirements for function translation
 func (b *PromptBuilder) getFunctionRequirements() string {
 	common := `- Preserve the semantics and functionality of the original function
@@ -229,6 +705,13 @@ func (b *PromptBuilder) getFunctionRequirements() string {
 `
 	if b.source == uniast.TypeScript {
 		common = `- Source is TypeScript: convert Promise<T> to (T, error) or return T; use Go error as last return; map async/await to synchronous Go or goroutines where appropriate
+` + common
+	}
+	if b.source == uniast.Golang && b.target == uniast.Java {
+		common = `- Source is Go: convert (T, error) returns to returning T and throwing an exception (checked for recoverable errors, unchecked otherwise)
+- Source is Go: convert goroutines to CompletableFuture or an ExecutorService; convert channels to BlockingQueue; convert sync.Mutex to ReentrantLock
+- Source is Go: convert defer to try/finally; convert multiple return values to a small result class or record
+- Source is Go: a method "Type.Method" with a receiver becomes an instance method of class Type
 ` + common
 	}
 
@@ -264,6 +747,32 @@ func (b *PromptBuilder) getFunctionRequirements() string {
 	}
 }
 
+// getEntryPointRequirements returns the notes for translating the source entry point into the target main function
+func (b *PromptBuilder) getEntryPointRequirements() string {
+	if b.source == uniast.Rust && b.target == uniast.Golang {
+		return `- Source is Rust: the source is the whole ` + "`fn main()`" + `, translate its body into the body of ` + "`func main()`" + `
+- Convert std::env::args() to os.Args (os.Args[0] is also the program name), std::env::var(key) to os.Getenv or os.LookupEnv
+- Convert println!/print!/eprintln! to fmt.Println/fmt.Printf/fmt.Fprintln(os.Stderr, ...), the {} and {:?} placeholders to %v
+- Convert std::process::exit(code) to os.Exit(code)
+- If main returns Result<(), Box<dyn Error>>: Go main returns nothing, check the error where ? is used and on error print it to os.Stderr and call os.Exit(1); Ok(()) at the end is dropped`
+	}
+	return ""
+}
+
+// getMainRequirements returns the entry point notes if the node of req is the free `fn main()` of a Rust crate,
+// which is moved into the Go package main by EntryPointHandler.ConvertEntryPoints
+func (b *PromptBuilder) getMainRequirements(req *LLMTranslateRequest) string {
+	if req.NodeType != uniast.FUNC || req.ReceiverType != "" || req.Identity.Name != "main" {
+		return ""
+	}
+	requirements := b.getEntryPointRequirements()
+	if requirements == "" {
+		return ""
+	}
+	return "\n" + requirements + `
+- The translated ` + "`func main()`" + ` is placed in package main: qualify the functions and types of the crate with their Go package name and import that package`
+}
+
 // getVarRequirements returns language-specific requirements for variable translation
 func (b *PromptBuilder) getVarRequirements() string {
 	common := `- Preserve the value and meaning of the variable
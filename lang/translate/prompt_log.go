@@ -0,0 +1,126 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// PromptLogIndexFile is the file listing the nodes logged by PromptLogger
+const PromptLogIndexFile = "index.json"
+
+// PromptLogEntry is a node in the index of PromptLogger
+type PromptLogEntry struct {
+	NodeID   string `json:"node_id"`
+	Hash     string `json:"hash"`
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+}
+
+// PromptLogger writes the prompt and the response of each LLM call to a directory for debugging:
+// <hash>-prompt.txt before the call and <hash>-response.txt after it, hash being PromptLogHash of the node.
+// A retried node overwrites the files of its previous call.
+type PromptLogger struct {
+	dir string
+
+	mu    sync.Mutex
+	index map[string]PromptLogEntry // node ID => entry
+}
+
+// NewPromptLogger returns a PromptLogger writing into dir, which is created if not exists
+func NewPromptLogger(dir string) (*PromptLogger, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create prompt log dir %s: %w", dir, err)
+	}
+	return &PromptLogger{dir: dir, index: map[string]PromptLogEntry{}}, nil
+}
+
+// PromptLogHash returns the first 8 hex chars of the sha256 of id.Full()
+func PromptLogHash(id uniast.Identity) string {
+	h := sha256.Sum256([]byte(id.Full()))
+	return hex.EncodeToString(h[:])[:8]
+}
+
+// Wrap returns an LLMTranslateFunc logging the prompts and responses of fn
+func (l *PromptLogger) Wrap(fn LLMTranslateFunc) LLMTranslateFunc {
+	return func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+		entry, err := l.logPrompt(req)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := fn(ctx, req)
+		var content string
+		switch {
+		case err != nil:
+			content = "error: " + err.Error()
+		case resp == nil:
+		case resp.Error != "":
+			content = "error: " + resp.Error
+		default:
+			content = resp.TargetContent
+		}
+		if werr := os.WriteFile(filepath.Join(l.dir, entry.Response), []byte(content), 0o644); werr != nil {
+			return nil, fmt.Errorf("write LLM response of %s: %w", entry.NodeID, werr)
+		}
+		return resp, err
+	}
+}
+
+// logPrompt writes the prompt of req and adds it to the index
+func (l *PromptLogger) logPrompt(req *LLMTranslateRequest) (PromptLogEntry, error) {
+	hash := PromptLogHash(req.Identity)
+	entry := PromptLogEntry{
+		NodeID:   req.Identity.Full(),
+		Hash:     hash,
+		Prompt:   hash + "-prompt.txt",
+		Response: hash + "-response.txt",
+	}
+	if err := os.WriteFile(filepath.Join(l.dir, entry.Prompt), []byte(req.Prompt), 0o644); err != nil {
+		return entry, fmt.Errorf("write LLM prompt of %s: %w", entry.NodeID, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.index[entry.NodeID]; ok {
+		return entry, nil
+	}
+	l.index[entry.NodeID] = entry
+	// rewrite the whole index so that it is complete even if the run is interrupted
+	entries := make([]PromptLogEntry, 0, len(l.index))
+	for _, e := range l.index {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].NodeID < entries[j].NodeID })
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return entry, err
+	}
+	if err := os.WriteFile(filepath.Join(l.dir, PromptLogIndexFile), data, 0o644); err != nil {
+		return entry, fmt.Errorf("write prompt log index: %w", err)
+	}
+	return entry, nil
+}
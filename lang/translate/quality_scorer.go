@@ -0,0 +1,242 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// QualityWeights are the weights of each heuristic score in QualityReport.Total
+type QualityWeights struct {
+	Structure   float64
+	Identifiers float64
+	LineRatio   float64
+	PackageDecl float64
+	NoMarkdown  float64
+	Syntax      float64
+}
+
+// DefaultQualityWeights favors syntax validity and identifier preservation
+var DefaultQualityWeights = QualityWeights{
+	Structure:   20,
+	Identifiers: 25,
+	LineRatio:   10,
+	PackageDecl: 10,
+	NoMarkdown:  10,
+	Syntax:      25,
+}
+
+// QualityReport is the heuristic quality of a translation. All scores are in 0-100.
+type QualityReport struct {
+	// Structure scores how well the bracket balance of the output matches the source
+	Structure float64
+	// Identifiers is the percentage of source identifiers found (name-convention insensitive) in the output
+	Identifiers float64
+	// LineRatio scores whether the output line count is plausible compared with the source
+	LineRatio float64
+	// PackageDecl scores the presence of the package declaration when the source has one
+	PackageDecl float64
+	// NoMarkdown scores the absence of raw markdown code fences in the output
+	NoMarkdown float64
+	// Syntax scores whether the output parses (only checked for Go now)
+	Syntax float64
+	// Total is the weighted sum of all scores
+	Total float64
+	// Issues describes the heuristics which are not fully satisfied
+	Issues []string
+}
+
+// QualityScorer rates the output of a translation with heuristics, without calling any LLM
+type QualityScorer struct {
+	Weights QualityWeights
+}
+
+// NewQualityScorer creates a QualityScorer with DefaultQualityWeights
+func NewQualityScorer() *QualityScorer {
+	return &QualityScorer{Weights: DefaultQualityWeights}
+}
+
+const (
+	// plausible range of output lines / source lines
+	minLineRatio = 0.3
+	maxLineRatio = 3.0
+	// score lost per unbalanced bracket
+	bracketPenalty = 25
+)
+
+var (
+	identifierRegex  = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	javaPackageRegex = regexp.MustCompile(`(?m)^\s*package\s+[\w.]+\s*;`)
+	goPackageRegex   = regexp.MustCompile(`(?m)^\s*package\s+\w+\s*$`)
+
+	// keywords and builtin names of the supported languages, which are not expected to be preserved
+	qualityKeywords = map[string]bool{}
+)
+
+func init() {
+	for _, kw := range strings.Fields(`abstract and as assert async await bool boolean break byte case catch char chan class
+		const continue def default defer del delete do double elif else enum except export extends false final finally
+		float fn for from func function global go goto if impl implements import in instanceof int interface is
+		lambda let long loop map match mod mut new nil none not null or package pass private protected pub public
+		raise range return self short static string struct super switch synchronized this throw throws trait true try
+		type typeof use var void volatile where while with yield override string println printf sprintf`) {
+		qualityKeywords[kw] = true
+	}
+}
+
+// Score rates the translation dstContent of srcContent from srcLang to dstLang
+func (s *QualityScorer) Score(srcContent, dstContent string, srcLang, dstLang uniast.Language) QualityReport {
+	var r QualityReport
+	if strings.TrimSpace(dstContent) == "" {
+		r.Issues = append(r.Issues, "empty translation")
+		return r
+	}
+
+	// structural similarity
+	delta := 0
+	for _, pair := range []string{"()", "{}", "[]"} {
+		d := bracketBalance(dstContent, pair) - bracketBalance(srcContent, pair)
+		if d != 0 {
+			r.Issues = append(r.Issues, fmt.Sprintf("unbalanced %q: %+d compared with source", pair, d))
+		}
+		delta += abs(d)
+	}
+	r.Structure = math.Max(0, 100-float64(bracketPenalty*delta))
+
+	// identifier preservation
+	srcIDs := normalizedIdentifiers(srcContent)
+	if len(srcIDs) == 0 {
+		r.Identifiers = 100
+	} else {
+		dstIDs := normalizedIdentifiers(dstContent)
+		found := 0
+		for id := range srcIDs {
+			if dstIDs[id] {
+				found++
+			}
+		}
+		r.Identifiers = 100 * float64(found) / float64(len(srcIDs))
+		if found < len(srcIDs) {
+			r.Issues = append(r.Issues, fmt.Sprintf("%d of %d source identifiers missing", len(srcIDs)-found, len(srcIDs)))
+		}
+	}
+
+	// line count ratio
+	ratio := float64(countLines(dstContent)) / float64(max(countLines(srcContent), 1))
+	switch {
+	case ratio < minLineRatio:
+		r.LineRatio = 100 * ratio / minLineRatio
+		r.Issues = append(r.Issues, fmt.Sprintf("too few lines: %.2fx of source", ratio))
+	case ratio > maxLineRatio:
+		r.LineRatio = 100 * maxLineRatio / ratio
+		r.Issues = append(r.Issues, fmt.Sprintf("too many lines: %.2fx of source", ratio))
+	default:
+		r.LineRatio = 100
+	}
+
+	// package declaration
+	r.PackageDecl = 100
+	if hasPackageDecl(srcContent, srcLang) && !hasPackageDecl(dstContent, dstLang) {
+		r.PackageDecl = 0
+		r.Issues = append(r.Issues, "missing package declaration")
+	}
+
+	// raw markdown
+	r.NoMarkdown = 100
+	if strings.Contains(dstContent, "```") {
+		r.NoMarkdown = 0
+		r.Issues = append(r.Issues, "raw markdown code fence")
+	}
+
+	// syntax validity
+	r.Syntax = 100
+	if err := checkSyntax(dstContent, dstLang); err != nil {
+		r.Syntax = 0
+		r.Issues = append(r.Issues, "syntax error: "+err.Error())
+	}
+
+	w := s.Weights
+	sum := w.Structure + w.Identifiers + w.LineRatio + w.PackageDecl + w.NoMarkdown + w.Syntax
+	if sum > 0 {
+		r.Total = (w.Structure*r.Structure + w.Identifiers*r.Identifiers + w.LineRatio*r.LineRatio +
+			w.PackageDecl*r.PackageDecl + w.NoMarkdown*r.NoMarkdown + w.Syntax*r.Syntax) / sum
+	}
+	return r
+}
+
+// bracketBalance returns the count of open brackets minus close brackets of the pair
+func bracketBalance(content, pair string) int {
+	return strings.Count(content, pair[:1]) - strings.Count(content, pair[1:])
+}
+
+// normalizedIdentifiers collects the identifiers of content except keywords,
+// lower-cased and without underscores so that naming conventions do not matter
+func normalizedIdentifiers(content string) map[string]bool {
+	ids := make(map[string]bool)
+	for _, id := range identifierRegex.FindAllString(content, -1) {
+		id = strings.ToLower(strings.ReplaceAll(id, "_", ""))
+		if len(id) < 3 || qualityKeywords[id] {
+			continue
+		}
+		ids[id] = true
+	}
+	return ids
+}
+
+func countLines(content string) int {
+	return strings.Count(strings.TrimSpace(content), "\n") + 1
+}
+
+func hasPackageDecl(content string, lang uniast.Language) bool {
+	switch lang {
+	case uniast.Golang:
+		return goPackageRegex.MatchString(content)
+	case uniast.Java:
+		return javaPackageRegex.MatchString(content)
+	default:
+		return false
+	}
+}
+
+// checkSyntax parses content of the language, returns nil if the language is not supported
+func checkSyntax(content string, lang uniast.Language) error {
+	switch lang {
+	case uniast.Golang:
+		// node contents usually have no package clause
+		if !goPackageRegex.MatchString(content) {
+			content = "package p\n\n" + content
+		}
+		_, err := parser.ParseFile(token.NewFileSet(), "", content, parser.SkipObjectResolution)
+		return err
+	default:
+		return nil
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
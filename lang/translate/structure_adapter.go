@@ -19,6 +19,7 @@ package translate
 import (
 	"path/filepath"
 	"strings"
+	"unicode"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
 )
@@ -74,6 +75,69 @@ func (a *StructureAdapter) AdaptPackage(src *uniast.Package) *uniast.Package {
 	}
 }
 
+// SplitHeuristic decides how a large source package is split into several target packages
+type SplitHeuristic struct {
+	// Threshold is the max number of types of a package before it is split (0 = never split)
+	Threshold int
+}
+
+// group returns the sub-package of a type, which is the lower-cased first word of its name,
+// e.g. UserService, UserRepository => user
+func (h SplitHeuristic) group(typeName string) string {
+	words := splitWords(typeName)
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.ToLower(words[0])
+}
+
+// SplitPackage groups the nodes of src into sub-packages by h, keyed by the sub-package name.
+// Functions and vars follow the type owning them (e.g. UserService.getUser),
+// the others are kept in the "" sub-package. Returns nil if src does not need to be split.
+// Only works for Go target now.
+func (a *StructureAdapter) SplitPackage(src *uniast.Package, h SplitHeuristic) map[string]*uniast.Package {
+	if a.target != uniast.Golang || h.Threshold <= 0 || len(src.Types) <= h.Threshold {
+		return nil
+	}
+
+	parts := make(map[string]*uniast.Package)
+	part := func(sub string) *uniast.Package {
+		p, ok := parts[sub]
+		if !ok {
+			p = uniast.NewPackage(src.PkgPath)
+			p.IsMain = src.IsMain
+			p.IsTest = src.IsTest
+			parts[sub] = p
+		}
+		return p
+	}
+	groups := make(map[string]string, len(src.Types))
+	for name, typ := range src.Types {
+		sub := h.group(typ.Name)
+		groups[typ.Name] = sub
+		part(sub).Types[name] = typ
+	}
+	// the owner of a member is the part before the first dot
+	ownerGroup := func(name string) string {
+		owner, _, ok := strings.Cut(name, ".")
+		if !ok {
+			return ""
+		}
+		return groups[owner]
+	}
+	for name, fn := range src.Functions {
+		part(ownerGroup(fn.Name)).Functions[name] = fn
+	}
+	for name, v := range src.Vars {
+		part(ownerGroup(v.Name)).Vars[name] = v
+	}
+
+	if len(parts) < 2 {
+		return nil
+	}
+	return parts
+}
+
 // AdaptFile creates a target File structure from source
 func (a *StructureAdapter) AdaptFile(src *uniast.File) *uniast.File {
 	return &uniast.File{
@@ -85,17 +149,14 @@ func (a *StructureAdapter) AdaptFile(src *uniast.File) *uniast.File {
 
 // convertModuleName converts a module name to target language convention
 func (a *StructureAdapter) convertModuleName(name string) string {
+	if a.target == uniast.Rust {
+		// crates have a plain snake_case name whatever the source, see rustCrateName
+		return rustCrateName(name)
+	}
 	switch {
 	case a.source == uniast.Java && a.target == uniast.Golang:
 		// com.example.project -> github.com/example/project
 		return GetGoModuleNameFromGroupId(name)
-	case a.source == uniast.Java && a.target == uniast.Rust:
-		// com.example.project -> example_project
-		parts := strings.Split(name, ".")
-		if len(parts) > 1 {
-			return strings.Join(parts[1:], "_")
-		}
-		return toSnakeCase(name)
 	case a.source == uniast.Java && a.target == uniast.Python:
 		// com.example.project -> example.project
 		parts := strings.Split(name, ".")
@@ -105,16 +166,7 @@ func (a *StructureAdapter) convertModuleName(name string) string {
 		return name
 	case a.source == uniast.Golang && a.target == uniast.Java:
 		// github.com/example/project -> com.example.project
-		name = strings.TrimPrefix(name, "github.com/")
-		parts := strings.Split(name, "/")
-		return "com." + strings.Join(parts, ".")
-	case a.source == uniast.Golang && a.target == uniast.Rust:
-		// github.com/example/project -> example_project
-		parts := strings.Split(name, "/")
-		if len(parts) > 0 {
-			return toSnakeCase(parts[len(parts)-1])
-		}
-		return toSnakeCase(name)
+		return convertGoPathToJavaPackage(name)
 	case a.source == uniast.Golang && a.target == uniast.Python:
 		// github.com/example/project -> example.project
 		name = strings.TrimPrefix(name, "github.com/")
@@ -125,9 +177,6 @@ func (a *StructureAdapter) convertModuleName(name string) string {
 	case a.source == uniast.Python && a.target == uniast.Java:
 		// example.project -> com.example.project
 		return "com." + name
-	case a.source == uniast.Python && a.target == uniast.Rust:
-		// example.project -> example_project
-		return toSnakeCase(strings.ReplaceAll(name, ".", "_"))
 	case a.source == uniast.Rust && a.target == uniast.Golang:
 		// example_project -> github.com/example/project
 		return "github.com/" + strings.ReplaceAll(name, "_", "/")
@@ -158,6 +207,75 @@ func (a *StructureAdapter) convertModuleName(name string) string {
 	}
 }
 
+// reverseDomainTLDs are the first parts of the reverse domain names prefixing Java packages, e.g. com.example
+var reverseDomainTLDs = map[string]bool{"com": true, "org": true, "net": true, "io": true, "cn": true, "edu": true, "gov": true}
+
+// rustCrateName converts a module name to a Rust crate name: the meaningful last part of the name in snake_case,
+// without any domain, e.g. com.example:user-service:1.0 -> user_service, com.example.UserService -> user_service,
+// github.com/example/user-service -> user_service, example.project -> example_project
+func rustCrateName(name string) string {
+	// Maven coordinates groupId:artifactId[:version] -> artifactId
+	if parts := strings.Split(name, ":"); len(parts) > 1 && parts[1] != "" {
+		name = parts[1]
+	}
+	// domain-style module paths and file paths -> last element
+	name = strings.Trim(strings.ReplaceAll(name, "\\", "/"), "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	// reverse domain prefix, e.g. com.example.project -> project
+	parts := strings.Split(name, ".")
+	if len(parts) > 1 && reverseDomainTLDs[parts[0]] {
+		parts = parts[min(2, len(parts)-1):]
+	}
+
+	var sb strings.Builder
+	for _, part := range parts {
+		for _, r := range toRustSnakeCase(part) {
+			switch {
+			case r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+				sb.WriteRune(r)
+			default:
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune('_')
+	}
+	crate := strings.Trim(sb.String(), "_")
+	for strings.Contains(crate, "__") {
+		crate = strings.ReplaceAll(crate, "__", "_")
+	}
+	if crate == "" {
+		return "app"
+	}
+	if unicode.IsDigit(rune(crate[0])) {
+		crate = "_" + crate
+	}
+	return crate
+}
+
+// toRustSnakeCase converts a CamelCase name to snake_case, keeping acronyms together,
+// e.g. HTTPServer -> http_server, user-service -> user_service
+func toRustSnakeCase(s string) string {
+	runes := []rune(s)
+	var sb strings.Builder
+	for i, r := range runes {
+		if r == '-' || r == ' ' {
+			sb.WriteRune('_')
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
 // convertPackagePath converts a package path to target language convention
 func (a *StructureAdapter) convertPackagePath(path string) string {
 	switch {
@@ -180,8 +298,7 @@ func (a *StructureAdapter) convertPackagePath(path string) string {
 		return path
 	case a.source == uniast.Golang && a.target == uniast.Java:
 		// github.com/example/project/model -> com.example.project.model
-		path = strings.TrimPrefix(path, "github.com/")
-		return "com." + strings.ReplaceAll(path, "/", ".")
+		return convertGoPathToJavaPackage(path)
 	case a.source == uniast.Golang && a.target == uniast.Rust:
 		// github.com/example/project/model -> model
 		return strings.ReplaceAll(path, "/", "::")
@@ -299,3 +416,26 @@ func (a *StructureAdapter) getFileExtension() string {
 		return ""
 	}
 }
+
+// convertGoPathToJavaPackage converts a Go import path to a valid Java package name.
+// e.g. github.com/example/my-project/v2/model -> com.example.my_project.model
+func convertGoPathToJavaPackage(path string) string {
+	path = strings.TrimPrefix(path, "github.com/")
+	var segs []string
+	for i, part := range strings.Split(path, "/") {
+		if part == "" {
+			continue
+		}
+		// drop the Go major version suffix (e.g. /v2), it has no meaning in Java
+		if i > 0 && len(part) > 1 && part[0] == 'v' && strings.Trim(part[1:], "0123456789") == "" {
+			continue
+		}
+		part = strings.ToLower(part)
+		part = strings.NewReplacer("-", "_", ".", "_").Replace(part)
+		if part[0] >= '0' && part[0] <= '9' {
+			part = "_" + part
+		}
+		segs = append(segs, part)
+	}
+	return "com." + strings.Join(segs, ".")
+}
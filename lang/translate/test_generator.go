@@ -0,0 +1,395 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// DefaultTestMinComplexity is the complexity a Go function must exceed to get a generated test
+const DefaultTestMinComplexity = 3
+
+// translateTestFileSuffix is the suffix of the test files written by GenerateTests, after the package name
+const translateTestFileSuffix = "_translate_test.go"
+
+// TestFunc runs the tests of the project in dir and returns the output of `go test -v`.
+// A non-nil error means some tests failed or did not build.
+type TestFunc func(ctx context.Context, dir string) (string, error)
+
+// GenerateTestsOptions holds the configuration for GenerateTests
+type GenerateTestsOptions struct {
+	// OutputDir is the directory the target Go code has been written to (required)
+	OutputDir string
+	// LLMTranslator is called with the test prompt of each function, the test code is in TargetContent (required)
+	LLMTranslator LLMTranslateFunc
+	// MinComplexity is the cyclomatic complexity a function must exceed to be tested (default: DefaultTestMinComplexity)
+	MinComplexity int
+	// Test runs the tests of OutputDir, default to RunGoTests
+	Test TestFunc
+}
+
+// TestReport is the outcome of GenerateTests, included in the pipeline report
+type TestReport struct {
+	// Generated is the number of functions whose generated test was written
+	Generated int `json:"generated"`
+	// Files are the written test files, relative to the output dir
+	Files []string `json:"files,omitempty"`
+	// Passed and Failed count the top-level tests reported by `go test -v`
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+	// Errors are the functions whose test could not be generated and the packages failing to build
+	Errors []string `json:"errors,omitempty"`
+}
+
+// GoComplexity returns the cyclomatic complexity of the Go function content:
+// 1 plus the number of branches (if, for, range, case, select case, && and ||).
+// It returns 0 if content does not parse.
+func GoComplexity(content string) int {
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+content, 0)
+	if err != nil || len(f.Decls) == 0 {
+		return 0
+	}
+	complexity := 1
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if n.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+	return complexity
+}
+
+// buildTestPrompt returns the prompt asking for a table-driven test of the Go function content
+func buildTestPrompt(pkgName, content string) string {
+	return fmt.Sprintf("Generate a table-driven test for this Go function:\n\n```go\n%s\n```\n\n"+
+		"The test is in package %s, next to the function. Return ONLY a Go test file with its package clause and imports, no explanations.",
+		content, pkgName)
+}
+
+// GenerateTests asks the LLM a table-driven test for each Go function of repo more complex than MinComplexity,
+// writes them to <pkg>_translate_test.go in the package directories under OutputDir and runs the tests.
+// A test which can't be generated is recorded in TestReport.Errors; failing tests don't make it return an error.
+func GenerateTests(ctx context.Context, repo *uniast.Repository, opts GenerateTestsOptions) (*TestReport, error) {
+	if opts.OutputDir == "" || opts.LLMTranslator == nil {
+		return nil, fmt.Errorf("OutputDir and LLMTranslator are required to generate tests")
+	}
+	if opts.MinComplexity <= 0 {
+		opts.MinComplexity = DefaultTestMinComplexity
+	}
+	if opts.Test == nil {
+		opts.Test = RunGoTests
+	}
+
+	report := &TestReport{}
+	files := make(map[string]*testFile) // test file path => content
+	var fns []*uniast.Function
+	for _, fn := range repo.AllFunctions {
+		if fn.Content == "" || strings.HasSuffix(fn.File, "_test.go") || GoComplexity(fn.Content) <= opts.MinComplexity {
+			continue
+		}
+		fns = append(fns, fn)
+	}
+	sort.Slice(fns, func(i, j int) bool { return fns[i].Identity.Full() < fns[j].Identity.Full() })
+	for _, fn := range fns {
+		mod := repo.Modules[fn.ModPath]
+		pkgDir := filepath.Join(opts.OutputDir, mod.Dir, strings.TrimPrefix(fn.PkgPath, mod.Name))
+		pkgName, err := goPackageName(filepath.Join(pkgDir, filepath.Base(fn.File)))
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", fn.Identity.Full(), err))
+			continue
+		}
+		resp, err := opts.LLMTranslator(ctx, &LLMTranslateRequest{
+			SourceLanguage: uniast.Golang,
+			TargetLanguage: uniast.Golang,
+			NodeType:       uniast.FUNC,
+			Identity:       fn.Identity,
+			Prompt:         buildTestPrompt(pkgName, fn.Content),
+		})
+		if err == nil && resp != nil && resp.Error != "" {
+			err = fmt.Errorf("%s", resp.Error)
+		}
+		if err == nil && (resp == nil || strings.TrimSpace(resp.TargetContent) == "") {
+			err = fmt.Errorf("empty response")
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: generate test: %v", fn.Identity.Full(), err))
+			continue
+		}
+
+		path := filepath.Join(pkgDir, pkgName+translateTestFileSuffix)
+		tf := files[path]
+		if tf == nil {
+			tf = &testFile{pkgName: pkgName, imports: map[string]bool{}, names: map[string]bool{}}
+			files[path] = tf
+		}
+		if err := tf.add(resp.TargetContent); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: invalid test: %v", fn.Identity.Full(), err))
+			continue
+		}
+		report.Generated++
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := os.WriteFile(path, files[path].render(), 0644); err != nil {
+			return nil, fmt.Errorf("write test file %s: %w", path, err)
+		}
+		rel, _ := filepath.Rel(opts.OutputDir, path)
+		report.Files = append(report.Files, rel)
+	}
+	if len(paths) == 0 {
+		return report, nil
+	}
+
+	out, _ := opts.Test(ctx, opts.OutputDir)
+	report.Passed, report.Failed, report.Errors = parseGoTestOutput(out, report.Errors)
+	return report, nil
+}
+
+// RunGoTests runs `go test -v ./...` in dir and returns its combined output
+func RunGoTests(ctx context.Context, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "test", "-v", "./...")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("go test failed: %w", err)
+	}
+	return string(out), nil
+}
+
+// parseGoTestOutput counts the top-level passed and failed tests of `go test -v`,
+// and appends the packages which failed to build to errs
+func parseGoTestOutput(out string, errs []string) (passed, failed int, _ []string) {
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "--- PASS: "):
+			passed++
+		case strings.HasPrefix(line, "--- FAIL: "):
+			failed++
+		case strings.HasPrefix(line, "FAIL\t") && strings.HasSuffix(line, "[build failed]"):
+			errs = append(errs, strings.TrimPrefix(line, "FAIL\t"))
+		}
+	}
+	return passed, failed, errs
+}
+
+// goPackageName returns the package name of the Go file
+func goPackageName(file string) (string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly)
+	if err != nil {
+		return "", err
+	}
+	return f.Name.Name, nil
+}
+
+// testFile merges the generated tests of the functions of a package
+type testFile struct {
+	pkgName string
+	imports map[string]bool // import spec, e.g. `"testing"` or `foo "example.com/foo"`
+	names   map[string]bool // declared top-level identifiers, a func, method, type, var or const declared by several tests is kept once
+	decls   []string
+}
+
+// add parses the generated test file content and keeps its imports and declarations
+func (f *testFile) add(content string) error {
+	src := content
+	if !strings.HasPrefix(strings.TrimSpace(src), "package ") {
+		src = "package " + f.pkgName + "\n\n" + src
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	source := func(start, end token.Pos) string {
+		return src[fset.Position(start).Offset:fset.Position(end).Offset]
+	}
+	var decls []string
+	for _, decl := range file.Decls {
+		start := decl.Pos()
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			var specs []ast.Spec
+			for _, spec := range d.Specs {
+				if f.declare(specNames(spec)...) {
+					specs = append(specs, spec)
+				}
+			}
+			if len(specs) == 0 {
+				continue
+			}
+			if len(specs) < len(d.Specs) {
+				// keep the specs not declared yet in a group of their own
+				var sb strings.Builder
+				sb.WriteString(d.Tok.String() + " (\n")
+				for _, spec := range specs {
+					specStart := spec.Pos()
+					if doc := specDoc(spec); doc != nil {
+						specStart = doc.Pos()
+					}
+					sb.WriteString(source(specStart, spec.End()) + "\n")
+				}
+				sb.WriteString(")")
+				decls = append(decls, sb.String())
+				continue
+			}
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.FuncDecl:
+			if !f.declare(funcDeclName(d)) {
+				continue
+			}
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+		decls = append(decls, source(start, decl.End()))
+	}
+	if len(decls) == 0 {
+		return fmt.Errorf("no test declared")
+	}
+	for _, imp := range file.Imports {
+		spec := imp.Path.Value
+		if imp.Name != nil {
+			spec = imp.Name.Name + " " + spec
+		}
+		f.imports[spec] = true
+	}
+	f.decls = append(f.decls, decls...)
+	return nil
+}
+
+// declare records the top-level names, it returns false if any of them is already declared.
+// The blank identifier and init can be declared several times.
+func (f *testFile) declare(names ...string) bool {
+	for _, name := range names {
+		if name != "_" && name != "init" && f.names[name] {
+			return false
+		}
+	}
+	for _, name := range names {
+		f.names[name] = true
+	}
+	return true
+}
+
+// specNames returns the names declared by a type, var or const spec
+func specNames(spec ast.Spec) []string {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return []string{s.Name.Name}
+	case *ast.ValueSpec:
+		names := make([]string, 0, len(s.Names))
+		for _, n := range s.Names {
+			names = append(names, n.Name)
+		}
+		return names
+	}
+	return nil
+}
+
+// specDoc returns the doc comment of a type, var or const spec
+func specDoc(spec ast.Spec) *ast.CommentGroup {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Doc
+	case *ast.ValueSpec:
+		return s.Doc
+	}
+	return nil
+}
+
+// funcDeclName returns the name of a func, or Recv.Name of a method
+func funcDeclName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+	recv := fd.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	switch r := recv.(type) {
+	case *ast.IndexExpr:
+		recv = r.X
+	case *ast.IndexListExpr:
+		recv = r.X
+	}
+	if id, ok := recv.(*ast.Ident); ok {
+		return id.Name + "." + fd.Name.Name
+	}
+	return fd.Name.Name
+}
+
+// render returns the gofmt-ed content of the test file
+func (f *testFile) render() []byte {
+	var sb strings.Builder
+	sb.WriteString("package " + f.pkgName + "\n\n")
+	if len(f.imports) > 0 {
+		imports := make([]string, 0, len(f.imports))
+		for spec := range f.imports {
+			imports = append(imports, spec)
+		}
+		sort.Strings(imports)
+		sb.WriteString("import (\n")
+		for _, spec := range imports {
+			sb.WriteString("\t" + spec + "\n")
+		}
+		sb.WriteString(")\n\n")
+	}
+	sb.WriteString(strings.Join(f.decls, "\n\n"))
+	sb.WriteString("\n")
+	if formatted, err := format.Source([]byte(sb.String())); err == nil {
+		return formatted
+	}
+	return []byte(sb.String())
+}
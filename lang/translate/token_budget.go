@@ -0,0 +1,73 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"math"
+	"unicode/utf8"
+)
+
+// charsPerToken is the average number of chars of a token, used to estimate tokens without a tokenizer
+const charsPerToken = 4
+
+// EstimateTokens returns the estimated number of LLM tokens of s
+func EstimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + charsPerToken - 1) / charsPerToken
+}
+
+// TokenBudget is the number of tokens left for the sections of a prompt.
+// A nil TokenBudget is unlimited.
+type TokenBudget struct {
+	remaining int
+}
+
+// NewTokenBudget returns a budget of tokens, nil (unlimited) if tokens <= 0
+func NewTokenBudget(tokens int) *TokenBudget {
+	if tokens <= 0 {
+		return nil
+	}
+	return &TokenBudget{remaining: tokens}
+}
+
+// Remaining returns the tokens left
+func (b *TokenBudget) Remaining() int {
+	if b == nil {
+		return math.MaxInt
+	}
+	return b.remaining
+}
+
+// Take consumes the tokens of s if they fit in the budget, and tells whether they did
+func (b *TokenBudget) Take(s string) bool {
+	if b == nil {
+		return true
+	}
+	n := EstimateTokens(s)
+	if n > b.remaining {
+		return false
+	}
+	b.remaining -= n
+	return true
+}
+
+// Consume consumes the tokens of s, which must be in the prompt whatever the budget
+func (b *TokenBudget) Consume(s string) {
+	if b == nil {
+		return
+	}
+	b.remaining = max(b.remaining-EstimateTokens(s), 0)
+}
@@ -18,11 +18,17 @@ package translate
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"path"
+	"sort"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
+	"golang.org/x/mod/module"
 )
 
 // Transformer defines the interface for AST transformation
@@ -37,30 +43,39 @@ type BaseTransformer struct {
 	nodeTranslator *NodeTranslator
 	structAdapter  *StructureAdapter
 	promptBuilder  *PromptBuilder
+	contextual     *ContextualTranslator
+	// err is the error of the initialization (e.g. loading TypeHintsFile), returned by Transform and ReTransform
+	err error
 }
 
 // NewTransformer creates a new BaseTransformer
 func NewTransformer(opts TranslateOptions) *BaseTransformer {
-	typeHints := NewTypeHints(opts.SourceLanguage, opts.TargetLanguage)
+	opts = opts.withLLMRouted()
+	typeHints, err := NewTypeHintsFromOptions(opts)
 	return &BaseTransformer{
+		err:            err,
 		opts:           opts,
 		nodeTranslator: NewNodeTranslator(opts, typeHints),
 		structAdapter:  NewStructureAdapter(opts.SourceLanguage, opts.TargetLanguage),
-		promptBuilder:  NewPromptBuilder(opts.SourceLanguage, opts.TargetLanguage, typeHints),
+		promptBuilder:  NewPromptBuilder(opts.SourceLanguage, opts.TargetLanguage, typeHints).WithMaxPromptLength(opts.MaxPromptLength),
+		contextual:     NewContextualTranslator(opts.ContextWindowPkgNodes),
 	}
 }
 
 // Transform converts source AST to target AST
 func (t *BaseTransformer) Transform(ctx context.Context, src *uniast.Repository) (*uniast.Repository, error) {
-	// 1. Determine target module name
-	targetModName := t.opts.TargetModuleName
-	if targetModName == "" {
-		// Try to derive from source repository name
-		targetModName = t.structAdapter.convertModuleName(src.Name)
+	if t.err != nil {
+		return nil, t.err
 	}
+	// work on a copy so that the lazily built graph and the maps of src are never written,
+	// and src can be transformed by several goroutines at once
+	src = src.Clone()
 
-	// Sanitize module name (remove invalid characters like colons)
-	targetModName = sanitizeModuleName(targetModName)
+	// 1. Determine target module name
+	targetModName, err := t.targetModuleName(src)
+	if err != nil {
+		return nil, err
+	}
 
 	// 2. Create target Repository with single module
 	targetRepo := t.structAdapter.AdaptRepository(src, targetModName)
@@ -76,6 +91,8 @@ func (t *BaseTransformer) Transform(ctx context.Context, src *uniast.Repository)
 			t.opts.Result.TranslatedIDs = make(map[string]struct{})
 		}
 		t.opts.Result.FailedNodes = nil
+		t.opts.Result.SkippedNodes = nil
+		t.opts.Result.FailedBatches = nil
 	}
 	maxRetry := t.opts.MaxRetryPerNode
 	if maxRetry < 1 {
@@ -95,14 +112,16 @@ func (t *BaseTransformer) Transform(ctx context.Context, src *uniast.Repository)
 	if t.opts.Result != nil {
 		t.opts.Result.TotalNodes = total
 	}
+	cacheHits := t.nodeTranslator.CacheHits()
 
 	// Global translate context for tracking all translated nodes
 	globalCtx := &TranslateContext{
-		SourceRepo:      src,
-		TargetRepo:      targetRepo,
-		TranslatedNodes: make(map[string]uniast.Identity),
-		Result:          t.opts.Result,
-		Progress:        progress,
+		SourceRepo:         src,
+		TargetRepo:         targetRepo,
+		TranslatedNodes:    make(map[string]uniast.Identity),
+		TranslatedContents: make(map[string]string),
+		Result:             t.opts.Result,
+		Progress:           progress,
 	}
 
 	// 3. Traverse all source modules and merge their packages into the single target module
@@ -122,18 +141,8 @@ func (t *BaseTransformer) Transform(ctx context.Context, src *uniast.Repository)
 		}
 		packagesMu.Unlock()
 
-		pkgCtx := &TranslateContext{
-			SourceRepo:      src,
-			TargetRepo:      targetRepo,
-			Module:          targetMod,
-			Package:         targetPkg,
-			TranslatedNodes: globalCtx.TranslatedNodes,
-			Result:          globalCtx.Result,
-			Progress:        globalCtx.Progress,
-		}
-		t.translateTypes(ctx, srcPkg, targetPkg, pkgCtx, maxRetry)
-		t.translateFunctions(ctx, srcPkg, targetPkg, pkgCtx, maxRetry)
-		t.translateVars(ctx, srcPkg, targetPkg, pkgCtx, maxRetry)
+		pkgCtx := globalCtx.packageContext(targetMod, targetPkg)
+		t.translatePackage(ctx, srcPkg, targetPkg, pkgCtx, maxRetry)
 
 		packagesMu.Lock()
 		targetMod.Packages[uniast.PkgPath(targetPkgPath)] = targetPkg
@@ -147,8 +156,9 @@ func (t *BaseTransformer) Transform(ctx context.Context, src *uniast.Repository)
 
 		if packageConcurrency <= 1 {
 			for pkgPath, srcPkg := range srcMod.Packages {
-				targetPkgPath := t.structAdapter.convertPackagePath(string(pkgPath))
-				runOnePackage(pkgPath, srcPkg, targetPkgPath)
+				for targetPkgPath, part := range t.packageParts(srcPkg) {
+					runOnePackage(pkgPath, part, targetPkgPath)
+				}
 			}
 			continue
 		}
@@ -161,11 +171,13 @@ func (t *BaseTransformer) Transform(ctx context.Context, src *uniast.Repository)
 		}
 		var work []pkgWork
 		for pkgPath, srcPkg := range srcMod.Packages {
-			work = append(work, pkgWork{
-				pkgPath:       pkgPath,
-				srcPkg:        srcPkg,
-				targetPkgPath: t.structAdapter.convertPackagePath(string(pkgPath)),
-			})
+			for targetPkgPath, part := range t.packageParts(srcPkg) {
+				work = append(work, pkgWork{
+					pkgPath:       pkgPath,
+					srcPkg:        part,
+					targetPkgPath: targetPkgPath,
+				})
+			}
 		}
 		sem := make(chan struct{}, packageConcurrency)
 		var wg sync.WaitGroup
@@ -197,9 +209,13 @@ func (t *BaseTransformer) Transform(ctx context.Context, src *uniast.Repository)
 		GenerateConfig:     t.opts.GenerateConfig,
 		ModuleName:         targetModName,
 		OutputDir:          t.opts.OutputDir,
+		GenerateDockerfile: t.opts.GenerateDockerfile,
+		SourceLanguage:     t.opts.SourceLanguage,
+		SourceRepo:         src,
+		LLMTranslator:      t.opts.LLMTranslator,
 	})
 
-	targetRepo, err := postProcessor.Process(targetRepo)
+	targetRepo, err = postProcessor.Process(targetRepo)
 	if err != nil {
 		return nil, fmt.Errorf("post-process failed: %w", err)
 	}
@@ -207,11 +223,381 @@ func (t *BaseTransformer) Transform(ctx context.Context, src *uniast.Repository)
 	if t.opts.Result != nil && progress != nil {
 		t.opts.Result.ProcessedNodes = progress.Done()
 	}
+	if t.opts.Result != nil {
+		t.opts.Result.CacheHits = t.nodeTranslator.CacheHits() - cacheHits
+		t.opts.Result.TranslationStats = newTranslationStats(src, t.opts.Result)
+	}
+
+	return targetRepo, nil
+}
+
+// ReTransform translates only the failed nodes of src and merges them into a copy of dst.
+// dst is expected to be the output of a previous Transform, so post-processing is not run again.
+func (t *BaseTransformer) ReTransform(ctx context.Context, src, dst *uniast.Repository, failed []FailedNodeInfo) (*uniast.Repository, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	targetRepo, err := copyRepository(dst)
+	if err != nil {
+		return nil, fmt.Errorf("copy target repository failed: %w", err)
+	}
+
+	targetMod := t.targetModule(targetRepo)
+	if targetMod == nil {
+		return nil, fmt.Errorf("target module not found in destination repository")
+	}
+
+	if t.opts.Result != nil {
+		if t.opts.Result.TranslatedIDs == nil {
+			t.opts.Result.TranslatedIDs = make(map[string]struct{})
+		}
+		t.opts.Result.FailedNodes = nil
+		t.opts.Result.SkippedNodes = nil
+		t.opts.Result.FailedBatches = nil
+		for id := range t.opts.AlreadyTranslatedIDs {
+			t.opts.Result.TranslatedIDs[id] = struct{}{}
+		}
+	}
+	maxRetry := t.opts.MaxRetryPerNode
+	if maxRetry < 1 {
+		maxRetry = 1
+	}
+
+	total := CountTranslatableNodes(src)
+	var progress *ProgressState
+	if t.opts.ProgressCallback != nil {
+		progress = &ProgressState{Total: total, done: len(t.opts.AlreadyTranslatedIDs), Callback: t.opts.ProgressCallback}
+	}
+	if t.opts.Result != nil {
+		t.opts.Result.TotalNodes = total
+	}
+	cacheHits := t.nodeTranslator.CacheHits()
+
+	globalCtx := &TranslateContext{
+		SourceRepo:         src,
+		TargetRepo:         targetRepo,
+		TranslatedNodes:    make(map[string]uniast.Identity),
+		TranslatedContents: make(map[string]string),
+		Result:             t.opts.Result,
+		Progress:           progress,
+	}
+
+	failedIDs := make(map[string]struct{}, len(failed))
+	for _, f := range failed {
+		failedIDs[f.NodeID] = struct{}{}
+	}
+
+	// Restore source => target mappings of previously translated nodes for dependency hints,
+	// and pick out the failed nodes of each package to retry
+	type pkgWork struct {
+		retry     *uniast.Package
+		targetPkg *uniast.Package
+	}
+	nodePkgs := t.nodePackages(src)
+	works := make(map[*uniast.Package]*pkgWork) // source package part => work
+	var work []*pkgWork
+	found := make(map[string]struct{}, len(failed))
+	isFailed := func(id uniast.Identity) bool {
+		if _, ok := failedIDs[id.Full()]; ok {
+			found[id.Full()] = struct{}{}
+			return true
+		}
+		return false
+	}
+	targetPackage := func(id uniast.Identity) *uniast.Package {
+		return targetMod.Packages[nodePkgs[id.Full()].target]
+	}
+	// retryPackage returns the package of the failed nodes to retry of the same source package part as id
+	retryPackage := func(id uniast.Identity) *uniast.Package {
+		np := nodePkgs[id.Full()]
+		w := works[np.source]
+		if w == nil {
+			w = &pkgWork{retry: uniast.NewPackage(np.source.PkgPath), targetPkg: targetMod.Packages[np.target]}
+			if w.targetPkg == nil {
+				w.targetPkg = t.structAdapter.AdaptPackage(np.source)
+				w.targetPkg.PkgPath = np.target
+				targetMod.Packages[np.target] = w.targetPkg
+			}
+			works[np.source] = w
+			work = append(work, w)
+		}
+		return w.retry
+	}
+	for id, srcType := range src.AllTypes {
+		if isFailed(id) {
+			retryPackage(id).Types[id.Name] = srcType
+		} else if targetPkg := targetPackage(id); targetPkg != nil {
+			if dt, ok := targetPkg.Types[t.nodeTranslator.convertTypeName(srcType.Name, srcType.Exported)]; ok {
+				globalCtx.AddTranslatedNode(id, dt.Identity)
+			}
+		}
+	}
+	for id, srcFunc := range src.AllFunctions {
+		if isFailed(id) {
+			retryPackage(id).Functions[id.Name] = srcFunc
+		} else if targetPkg := targetPackage(id); targetPkg != nil {
+			if df, ok := targetPkg.Functions[t.nodeTranslator.convertFunctionName(srcFunc.Name, srcFunc.Exported)]; ok {
+				globalCtx.AddTranslatedNode(id, df.Identity)
+			}
+		}
+	}
+	for id, srcVar := range src.AllVars {
+		if isFailed(id) {
+			retryPackage(id).Vars[id.Name] = srcVar
+		} else if targetPkg := targetPackage(id); targetPkg != nil {
+			if dv, ok := targetPkg.Vars[t.nodeTranslator.convertVarName(srcVar.Name, srcVar.IsExported)]; ok {
+				globalCtx.AddTranslatedNode(id, dv.Identity)
+			}
+		}
+	}
+
+	for _, w := range work {
+		pkgCtx := globalCtx.packageContext(targetMod, w.targetPkg)
+		t.translatePackage(ctx, w.retry, w.targetPkg, pkgCtx, maxRetry)
+	}
+
+	// Failed nodes that no longer exist in the source repository are reported again
+	if t.opts.Result != nil {
+		for _, f := range failed {
+			if _, ok := found[f.NodeID]; !ok {
+				t.opts.Result.FailedNodes = append(t.opts.Result.FailedNodes, FailedNodeInfo{
+					NodeID: f.NodeID, Err: "node not found in source repository",
+				})
+			}
+		}
+	}
 
+	if err := targetRepo.BuildGraph(); err != nil {
+		return nil, fmt.Errorf("build graph failed: %w", err)
+	}
+	if t.opts.Result != nil && progress != nil {
+		t.opts.Result.ProcessedNodes = progress.Done()
+	}
+	if t.opts.Result != nil {
+		t.opts.Result.CacheHits = t.nodeTranslator.CacheHits() - cacheHits
+		t.opts.Result.TranslationStats = newTranslationStats(src, t.opts.Result)
+	}
 	return targetRepo, nil
 }
 
-// sanitizeModuleName removes invalid characters from a module name
+// targetModuleName returns the name of the merged target module,
+// the specified TargetModuleName as is, or derived from the source repository name
+func (t *BaseTransformer) targetModuleName(src *uniast.Repository) (string, error) {
+	if t.opts.TargetModuleName != "" {
+		if err := checkModuleName(t.opts.TargetModuleName, t.opts.TargetLanguage); err != nil {
+			return "", err
+		}
+		return t.opts.TargetModuleName, nil
+	}
+	name := t.structAdapter.convertModuleName(src.Name)
+	if t.opts.TargetLanguage == uniast.Rust {
+		// a crate name is already a valid snake_case identifier, not a Go module path
+		return name, nil
+	}
+	// Sanitize the derived name (remove invalid characters like colons)
+	return sanitizeModuleName(name), nil
+}
+
+// targetModule finds the merged target module created by Transform in dst
+func (t *BaseTransformer) targetModule(dst *uniast.Repository) *uniast.Module {
+	if t.opts.TargetModuleName != "" {
+		return dst.Modules[t.opts.TargetModuleName]
+	}
+	for _, mod := range dst.Modules {
+		if !mod.IsExternal() {
+			return mod
+		}
+	}
+	return nil
+}
+
+// packageParts returns target package path => the part of srcPkg translated into it,
+// srcPkg is split by opts.PackageSplitThreshold if it is too large
+func (t *BaseTransformer) packageParts(srcPkg *uniast.Package) map[string]*uniast.Package {
+	targetPkgPath := t.structAdapter.convertPackagePath(string(srcPkg.PkgPath))
+	parts := t.structAdapter.SplitPackage(srcPkg, SplitHeuristic{Threshold: t.opts.PackageSplitThreshold})
+	if parts == nil {
+		return map[string]*uniast.Package{targetPkgPath: srcPkg}
+	}
+	ret := make(map[string]*uniast.Package, len(parts))
+	for sub, part := range parts {
+		ret[path.Join(targetPkgPath, sub)] = part
+	}
+	return ret
+}
+
+// sourceIdentities maps the nodes of dst back to the nodes of src they were translated from,
+// returns target Identity.Full() => source Identity.Full()
+func (t *BaseTransformer) sourceIdentities(src, dst *uniast.Repository) map[string]string {
+	ret := make(map[string]string)
+	targetMod := t.targetModule(dst)
+	if targetMod == nil {
+		return ret
+	}
+	nodePkgs := t.nodePackages(src)
+	targetPackage := func(id uniast.Identity) *uniast.Package {
+		return targetMod.Packages[nodePkgs[id.Full()].target]
+	}
+	for id, srcType := range src.AllTypes {
+		if targetPkg := targetPackage(id); targetPkg != nil {
+			if dt, ok := targetPkg.Types[t.nodeTranslator.convertTypeName(srcType.Name, srcType.Exported)]; ok {
+				ret[dt.Identity.Full()] = id.Full()
+			}
+		}
+	}
+	for id, srcFunc := range src.AllFunctions {
+		if targetPkg := targetPackage(id); targetPkg != nil {
+			if df, ok := targetPkg.Functions[t.nodeTranslator.convertFunctionName(srcFunc.Name, srcFunc.Exported)]; ok {
+				ret[df.Identity.Full()] = id.Full()
+			}
+		}
+	}
+	for id, srcVar := range src.AllVars {
+		if targetPkg := targetPackage(id); targetPkg != nil {
+			if dv, ok := targetPkg.Vars[t.nodeTranslator.convertVarName(srcVar.Name, srcVar.IsExported)]; ok {
+				ret[dv.Identity.Full()] = id.Full()
+			}
+		}
+	}
+	return ret
+}
+
+// nodePackage is the package a source node is translated in, see packageParts
+type nodePackage struct {
+	target uniast.PkgPath  // path of the target package
+	source *uniast.Package // the part of the source package translated into target
+}
+
+// nodePackages returns source Identity.Full() => the package each node of src is translated in
+func (t *BaseTransformer) nodePackages(src *uniast.Repository) map[string]nodePackage {
+	ret := make(map[string]nodePackage)
+	for _, mod := range src.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for partPath, part := range t.packageParts(pkg) {
+				np := nodePackage{target: uniast.PkgPath(partPath), source: part}
+				for _, ty := range part.Types {
+					ret[ty.Identity.Full()] = np
+				}
+				for _, fn := range part.Functions {
+					ret[fn.Identity.Full()] = np
+				}
+				for _, v := range part.Vars {
+					ret[v.Identity.Full()] = np
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// skippedNodeStub checks if the node should not be sent to the LLM, either filtered out by opts.NodeFilter
+// or too large. If so, the node is recorded as skipped and its stub content is returned.
+func (t *BaseTransformer) skippedNodeStub(id uniast.Identity, content string, tctx *TranslateContext) (string, bool) {
+	if stub, ok := t.filteredNodeStub(id, content, tctx); ok {
+		return stub, true
+	}
+	return t.largeNodeStub(id, content, tctx)
+}
+
+// addSkippedNodeStub adds the stub of a type, function or var to targetPkg if the node is skipped
+// (see skippedNodeStub), and reports whether it is
+func (t *BaseTransformer) addSkippedNodeStub(node interface{}, targetPkg *uniast.Package, tctx *TranslateContext) bool {
+	var src, dst uniast.Identity
+	var kind string
+	switch n := node.(type) {
+	case *uniast.Type:
+		stub, ok := t.skippedNodeStub(n.Identity, n.Content, tctx)
+		if !ok {
+			return false
+		}
+		targetType := t.nodeTranslator.buildTargetType(n, tctx, stub)
+		targetPkg.Types[targetType.Name] = targetType
+		src, dst, kind = n.Identity, targetType.Identity, "type"
+	case *uniast.Function:
+		stub, ok := t.skippedNodeStub(n.Identity, n.Content, tctx)
+		if !ok {
+			return false
+		}
+		targetFunc := t.nodeTranslator.buildTargetFunction(n, tctx, stub, "")
+		targetPkg.Functions[targetFunc.Name] = targetFunc
+		src, dst, kind = n.Identity, targetFunc.Identity, "func"
+	case *uniast.Var:
+		stub, ok := t.skippedNodeStub(n.Identity, n.Content, tctx)
+		if !ok {
+			return false
+		}
+		targetVar := t.nodeTranslator.buildTargetVar(n, tctx, stub)
+		targetPkg.Vars[targetVar.Name] = targetVar
+		src, dst, kind = n.Identity, targetVar.Identity, "var"
+	default:
+		return false
+	}
+	tctx.AddTranslatedNode(src, dst)
+	if tctx.Progress != nil {
+		tctx.Progress.ReportNodeDone(kind, src.Full())
+	}
+	return true
+}
+
+// filteredNodeStub checks if the node is filtered out by opts.NodeFilter.
+// If so, the node is recorded as skipped and its source is returned commented out.
+func (t *BaseTransformer) filteredNodeStub(id uniast.Identity, content string, tctx *TranslateContext) (string, bool) {
+	if t.opts.NodeFilter == nil || t.opts.NodeFilter(id) {
+		return "", false
+	}
+	if tctx.Result != nil {
+		tctx.Result.SkippedNodes = append(tctx.Result.SkippedNodes, SkippedNodeInfo{
+			NodeID: id.Full(), Reason: "filtered out", ContentLen: utf8.RuneCountInString(content),
+		})
+	}
+	return t.nodeTranslator.filteredNodeStub(content), true
+}
+
+// largeNodeStub checks if the node source exceeds opts.SkipLargeNodes.
+// If so, the node is recorded as skipped and its stub content is returned.
+func (t *BaseTransformer) largeNodeStub(id uniast.Identity, content string, tctx *TranslateContext) (string, bool) {
+	if t.opts.SkipLargeNodes <= 0 {
+		return "", false
+	}
+	n := utf8.RuneCountInString(content)
+	if n <= t.opts.SkipLargeNodes {
+		return "", false
+	}
+	if tctx.Result != nil {
+		tctx.Result.SkippedNodes = append(tctx.Result.SkippedNodes, SkippedNodeInfo{
+			NodeID: id.Full(), Reason: "source too large", ContentLen: n,
+		})
+	}
+	return t.nodeTranslator.largeNodeStub(n), true
+}
+
+// copyRepository deep copies a repository through JSON round-trip
+func copyRepository(repo *uniast.Repository) (*uniast.Repository, error) {
+	bs, err := json.Marshal(repo)
+	if err != nil {
+		return nil, err
+	}
+	var ret uniast.Repository
+	if err := json.Unmarshal(bs, &ret); err != nil {
+		return nil, err
+	}
+	ret.AllNodesSetRepo()
+	return &ret, nil
+}
+
+// checkModuleName checks the module name specified by the user is a valid Go module path when translating to Go,
+// the names of the other target languages are not checked. Unlike sanitizeModuleName, the name is never changed.
+func checkModuleName(name string, lang uniast.Language) error {
+	if lang != uniast.Golang {
+		return nil
+	}
+	if err := module.CheckImportPath(name); err != nil {
+		return fmt.Errorf("invalid target module name: %w", err)
+	}
+	return nil
+}
+
+// sanitizeModuleName removes invalid characters from a derived module name
 func sanitizeModuleName(name string) string {
 	// Handle filesystem paths - extract just the project name
 	if strings.HasPrefix(name, "/") || strings.Contains(name, "/Users/") || strings.Contains(name, "/home/") {
@@ -256,8 +642,27 @@ func sanitizeModuleName(name string) string {
 	return name
 }
 
+// nodeTranslated calls OnNodeTranslated, if any, with a node translated by the LLM before it is stored in its package
+func (t *BaseTransformer) nodeTranslated(srcID, dstID uniast.Identity, content string) {
+	if t.opts.OnNodeTranslated != nil {
+		t.opts.OnNodeTranslated(srcID, dstID, content)
+	}
+}
+
+// translatePackage translates all the nodes of srcPkg into targetPkg, the whole package at once
+// in WholePackageBatchSize mode, then the nodes left kind by kind
+func (t *BaseTransformer) translatePackage(ctx context.Context, srcPkg, targetPkg *uniast.Package, tctx *TranslateContext, maxRetry int) {
+	if t.opts.BatchSize == WholePackageBatchSize {
+		srcPkg = t.translateWholePackage(ctx, srcPkg, targetPkg, tctx)
+	}
+	t.translateTypes(ctx, srcPkg, targetPkg, tctx, maxRetry)
+	t.translateFunctions(ctx, srcPkg, targetPkg, tctx, maxRetry)
+	t.translateVars(ctx, srcPkg, targetPkg, tctx, maxRetry)
+}
+
 // translateTypes translates all types in a package. One node = one retry unit; failures are recorded, translation continues.
 func (t *BaseTransformer) translateTypes(ctx context.Context, srcPkg, targetPkg *uniast.Package, tctx *TranslateContext, maxRetry int) {
+	tctx.PackageContext = t.contextual.PackageContext(tctx.Module, targetPkg)
 	if t.opts.Parallel && t.opts.Concurrency > 1 {
 		t.translateTypesParallel(ctx, srcPkg, targetPkg, tctx, maxRetry)
 		return
@@ -272,6 +677,10 @@ func (t *BaseTransformer) translateTypesSequential(ctx context.Context, srcPkg,
 				continue
 			}
 		}
+		if t.addSkippedNodeStub(srcType, targetPkg, tctx) {
+			continue
+		}
+		start := time.Now()
 		var targetType *uniast.Type
 		var err error
 		for attempt := 0; attempt < maxRetry; attempt++ {
@@ -287,17 +696,18 @@ func (t *BaseTransformer) translateTypesSequential(ctx context.Context, srcPkg,
 				})
 			}
 			if tctx.Progress != nil {
-				tctx.Progress.ReportNodeDone("type", srcType.Identity.Full())
+				tctx.Progress.ReportNodeTranslated("type", srcType.Identity.Full(), time.Since(start))
 			}
 			continue
 		}
+		t.nodeTranslated(srcType.Identity, targetType.Identity, targetType.Content)
 		targetPkg.Types[targetType.Name] = targetType
 		tctx.AddTranslatedNode(srcType.Identity, targetType.Identity)
 		if tctx.Result != nil {
 			tctx.Result.TranslatedIDs[srcType.Identity.Full()] = struct{}{}
 		}
 		if tctx.Progress != nil {
-			tctx.Progress.ReportNodeDone("type", srcType.Identity.Full())
+			tctx.Progress.ReportNodeTranslated("type", srcType.Identity.Full(), time.Since(start))
 		}
 	}
 }
@@ -310,6 +720,9 @@ func (t *BaseTransformer) translateTypesParallel(ctx context.Context, srcPkg, ta
 				continue
 			}
 		}
+		if t.addSkippedNodeStub(srcType, targetPkg, tctx) {
+			continue
+		}
 		work = append(work, srcType)
 	}
 	if len(work) == 0 {
@@ -327,6 +740,7 @@ func (t *BaseTransformer) translateTypesParallel(ctx context.Context, srcPkg, ta
 		go func() {
 			defer wg.Done()
 			for srcType := range workCh {
+				start := time.Now()
 				var targetType *uniast.Type
 				var err error
 				for attempt := 0; attempt < maxRetry; attempt++ {
@@ -344,11 +758,12 @@ func (t *BaseTransformer) translateTypesParallel(ctx context.Context, srcPkg, ta
 						mu.Unlock()
 					}
 					if tctx.Progress != nil {
-						tctx.Progress.ReportNodeDone("type", srcType.Identity.Full())
+						tctx.Progress.ReportNodeTranslated("type", srcType.Identity.Full(), time.Since(start))
 					}
 					continue
 				}
 				mu.Lock()
+				t.nodeTranslated(srcType.Identity, targetType.Identity, targetType.Content)
 				targetPkg.Types[targetType.Name] = targetType
 				tctx.AddTranslatedNode(srcType.Identity, targetType.Identity)
 				if tctx.Result != nil {
@@ -356,7 +771,7 @@ func (t *BaseTransformer) translateTypesParallel(ctx context.Context, srcPkg, ta
 				}
 				mu.Unlock()
 				if tctx.Progress != nil {
-					tctx.Progress.ReportNodeDone("type", srcType.Identity.Full())
+					tctx.Progress.ReportNodeTranslated("type", srcType.Identity.Full(), time.Since(start))
 				}
 			}
 		}()
@@ -370,6 +785,10 @@ func (t *BaseTransformer) translateTypesParallel(ctx context.Context, srcPkg, ta
 
 // translateFunctions translates all functions in a package. One node = one retry unit; failures recorded, continue.
 func (t *BaseTransformer) translateFunctions(ctx context.Context, srcPkg, targetPkg *uniast.Package, tctx *TranslateContext, maxRetry int) {
+	tctx.PackageContext = t.contextual.PackageContext(tctx.Module, targetPkg)
+	if t.opts.BatchSize > 1 {
+		srcPkg = t.translateFunctionsBatch(ctx, srcPkg, targetPkg, tctx)
+	}
 	if t.opts.Parallel && t.opts.Concurrency > 1 {
 		t.translateFunctionsParallel(ctx, srcPkg, targetPkg, tctx, maxRetry)
 		return
@@ -384,6 +803,10 @@ func (t *BaseTransformer) translateFunctionsSequential(ctx context.Context, srcP
 				continue
 			}
 		}
+		if t.addSkippedNodeStub(srcFunc, targetPkg, tctx) {
+			continue
+		}
+		start := time.Now()
 		var targetFunc *uniast.Function
 		var err error
 		for attempt := 0; attempt < maxRetry; attempt++ {
@@ -399,17 +822,18 @@ func (t *BaseTransformer) translateFunctionsSequential(ctx context.Context, srcP
 				})
 			}
 			if tctx.Progress != nil {
-				tctx.Progress.ReportNodeDone("func", srcFunc.Identity.Full())
+				tctx.Progress.ReportNodeTranslated("func", srcFunc.Identity.Full(), time.Since(start))
 			}
 			continue
 		}
+		t.nodeTranslated(srcFunc.Identity, targetFunc.Identity, targetFunc.Content)
 		targetPkg.Functions[targetFunc.Name] = targetFunc
 		tctx.AddTranslatedNode(srcFunc.Identity, targetFunc.Identity)
 		if tctx.Result != nil {
 			tctx.Result.TranslatedIDs[srcFunc.Identity.Full()] = struct{}{}
 		}
 		if tctx.Progress != nil {
-			tctx.Progress.ReportNodeDone("func", srcFunc.Identity.Full())
+			tctx.Progress.ReportNodeTranslated("func", srcFunc.Identity.Full(), time.Since(start))
 		}
 	}
 }
@@ -422,6 +846,9 @@ func (t *BaseTransformer) translateFunctionsParallel(ctx context.Context, srcPkg
 				continue
 			}
 		}
+		if t.addSkippedNodeStub(srcFunc, targetPkg, tctx) {
+			continue
+		}
 		work = append(work, srcFunc)
 	}
 	if len(work) == 0 {
@@ -439,6 +866,7 @@ func (t *BaseTransformer) translateFunctionsParallel(ctx context.Context, srcPkg
 		go func() {
 			defer wg.Done()
 			for srcFunc := range workCh {
+				start := time.Now()
 				var targetFunc *uniast.Function
 				var err error
 				for attempt := 0; attempt < maxRetry; attempt++ {
@@ -456,11 +884,12 @@ func (t *BaseTransformer) translateFunctionsParallel(ctx context.Context, srcPkg
 						mu.Unlock()
 					}
 					if tctx.Progress != nil {
-						tctx.Progress.ReportNodeDone("func", srcFunc.Identity.Full())
+						tctx.Progress.ReportNodeTranslated("func", srcFunc.Identity.Full(), time.Since(start))
 					}
 					continue
 				}
 				mu.Lock()
+				t.nodeTranslated(srcFunc.Identity, targetFunc.Identity, targetFunc.Content)
 				targetPkg.Functions[targetFunc.Name] = targetFunc
 				tctx.AddTranslatedNode(srcFunc.Identity, targetFunc.Identity)
 				if tctx.Result != nil {
@@ -468,7 +897,7 @@ func (t *BaseTransformer) translateFunctionsParallel(ctx context.Context, srcPkg
 				}
 				mu.Unlock()
 				if tctx.Progress != nil {
-					tctx.Progress.ReportNodeDone("func", srcFunc.Identity.Full())
+					tctx.Progress.ReportNodeTranslated("func", srcFunc.Identity.Full(), time.Since(start))
 				}
 			}
 		}()
@@ -482,6 +911,10 @@ func (t *BaseTransformer) translateFunctionsParallel(ctx context.Context, srcPkg
 
 // translateVars translates all variables in a package. One node = one retry unit; failures recorded, continue.
 func (t *BaseTransformer) translateVars(ctx context.Context, srcPkg, targetPkg *uniast.Package, tctx *TranslateContext, maxRetry int) {
+	tctx.PackageContext = t.contextual.PackageContext(tctx.Module, targetPkg)
+	if t.opts.BatchSize > 1 {
+		srcPkg = t.translateVarsBatch(ctx, srcPkg, targetPkg, tctx)
+	}
 	if t.opts.Parallel && t.opts.Concurrency > 1 {
 		t.translateVarsParallel(ctx, srcPkg, targetPkg, tctx, maxRetry)
 		return
@@ -496,6 +929,10 @@ func (t *BaseTransformer) translateVarsSequential(ctx context.Context, srcPkg, t
 				continue
 			}
 		}
+		if t.addSkippedNodeStub(srcVar, targetPkg, tctx) {
+			continue
+		}
+		start := time.Now()
 		var targetVar *uniast.Var
 		var err error
 		for attempt := 0; attempt < maxRetry; attempt++ {
@@ -511,17 +948,18 @@ func (t *BaseTransformer) translateVarsSequential(ctx context.Context, srcPkg, t
 				})
 			}
 			if tctx.Progress != nil {
-				tctx.Progress.ReportNodeDone("var", srcVar.Identity.Full())
+				tctx.Progress.ReportNodeTranslated("var", srcVar.Identity.Full(), time.Since(start))
 			}
 			continue
 		}
+		t.nodeTranslated(srcVar.Identity, targetVar.Identity, targetVar.Content)
 		targetPkg.Vars[targetVar.Name] = targetVar
 		tctx.AddTranslatedNode(srcVar.Identity, targetVar.Identity)
 		if tctx.Result != nil {
 			tctx.Result.TranslatedIDs[srcVar.Identity.Full()] = struct{}{}
 		}
 		if tctx.Progress != nil {
-			tctx.Progress.ReportNodeDone("var", srcVar.Identity.Full())
+			tctx.Progress.ReportNodeTranslated("var", srcVar.Identity.Full(), time.Since(start))
 		}
 	}
 }
@@ -534,6 +972,9 @@ func (t *BaseTransformer) translateVarsParallel(ctx context.Context, srcPkg, tar
 				continue
 			}
 		}
+		if t.addSkippedNodeStub(srcVar, targetPkg, tctx) {
+			continue
+		}
 		work = append(work, srcVar)
 	}
 	if len(work) == 0 {
@@ -551,6 +992,7 @@ func (t *BaseTransformer) translateVarsParallel(ctx context.Context, srcPkg, tar
 		go func() {
 			defer wg.Done()
 			for srcVar := range workCh {
+				start := time.Now()
 				var targetVar *uniast.Var
 				var err error
 				for attempt := 0; attempt < maxRetry; attempt++ {
@@ -568,11 +1010,12 @@ func (t *BaseTransformer) translateVarsParallel(ctx context.Context, srcPkg, tar
 						mu.Unlock()
 					}
 					if tctx.Progress != nil {
-						tctx.Progress.ReportNodeDone("var", srcVar.Identity.Full())
+						tctx.Progress.ReportNodeTranslated("var", srcVar.Identity.Full(), time.Since(start))
 					}
 					continue
 				}
 				mu.Lock()
+				t.nodeTranslated(srcVar.Identity, targetVar.Identity, targetVar.Content)
 				targetPkg.Vars[targetVar.Name] = targetVar
 				tctx.AddTranslatedNode(srcVar.Identity, targetVar.Identity)
 				if tctx.Result != nil {
@@ -580,7 +1023,7 @@ func (t *BaseTransformer) translateVarsParallel(ctx context.Context, srcPkg, tar
 				}
 				mu.Unlock()
 				if tctx.Progress != nil {
-					tctx.Progress.ReportNodeDone("var", srcVar.Identity.Full())
+					tctx.Progress.ReportNodeTranslated("var", srcVar.Identity.Full(), time.Since(start))
 				}
 			}
 		}()
@@ -591,3 +1034,245 @@ func (t *BaseTransformer) translateVarsParallel(ctx context.Context, srcPkg, tar
 	close(workCh)
 	wg.Wait()
 }
+
+// maxBatchNodeChars is the max source size of a node to be translated in batch mode
+const maxBatchNodeChars = 600
+
+// batchable checks if the node is small enough to be translated in batch mode,
+// nodes already translated or to be stubbed are left to the one-by-one translation
+func (t *BaseTransformer) batchable(id uniast.Identity, content string, tctx *TranslateContext) bool {
+	if tctx.Result != nil && t.opts.AlreadyTranslatedIDs != nil {
+		if _, ok := t.opts.AlreadyTranslatedIDs[id.Full()]; ok {
+			return false
+		}
+	}
+	if t.opts.NodeFilter != nil && !t.opts.NodeFilter(id) {
+		return false
+	}
+	n := utf8.RuneCountInString(content)
+	return n <= maxBatchNodeChars && (t.opts.SkipLargeNodes <= 0 || n <= t.opts.SkipLargeNodes)
+}
+
+// translateBatches translates reqs in batches of opts.BatchSize, and calls onResult (serially) with the index
+// and response of each translated node. Nodes of failed batches or missing in the output are not reported,
+// the failed batches are recorded in tctx.Result.
+func (t *BaseTransformer) translateBatches(ctx context.Context, reqs []*LLMTranslateRequest, tctx *TranslateContext, onResult func(i int, resp *LLMTranslateResponse)) {
+	concurrency := 1
+	if t.opts.Parallel && t.opts.Concurrency > 1 {
+		concurrency = t.opts.Concurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for start := 0; start < len(reqs); start += t.opts.BatchSize {
+		end := min(start+t.opts.BatchSize, len(reqs))
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resps, err := t.nodeTranslator.TranslateBatch(ctx, reqs[start:end])
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if tctx.Result != nil {
+					failed := FailedBatchInfo{Err: err.Error()}
+					for _, req := range reqs[start:end] {
+						failed.NodeIDs = append(failed.NodeIDs, req.Identity.Full())
+					}
+					tctx.Result.FailedBatches = append(tctx.Result.FailedBatches, failed)
+				}
+				return
+			}
+			for i, resp := range resps {
+				if resp != nil {
+					onResult(start+i, resp)
+				}
+			}
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// translateFunctionsBatch translates the small functions of srcPkg in batch mode,
+// returns a package of the functions left to translate one by one
+func (t *BaseTransformer) translateFunctionsBatch(ctx context.Context, srcPkg, targetPkg *uniast.Package, tctx *TranslateContext) *uniast.Package {
+	rest := uniast.NewPackage(srcPkg.PkgPath)
+	var names []string
+	for name, srcFunc := range srcPkg.Functions {
+		if t.batchable(srcFunc.Identity, srcFunc.Content, tctx) {
+			names = append(names, name)
+		} else {
+			rest.Functions[name] = srcFunc
+		}
+	}
+	sort.Strings(names)
+	reqs := make([]*LLMTranslateRequest, len(names))
+	for i, name := range names {
+		reqs[i] = t.nodeTranslator.functionRequest(srcPkg.Functions[name], tctx)
+	}
+
+	done := make([]bool, len(names))
+	t.translateBatches(ctx, reqs, tctx, func(i int, resp *LLMTranslateResponse) {
+		srcFunc := srcPkg.Functions[names[i]]
+		if t.nodeTranslator.checkQuality(srcFunc.Content, resp.TargetContent) != nil {
+			return
+		}
+		targetFunc := t.nodeTranslator.buildTargetFunction(srcFunc, tctx, resp.TargetContent, resp.TargetSignature)
+		t.nodeTranslated(srcFunc.Identity, targetFunc.Identity, targetFunc.Content)
+		targetPkg.Functions[targetFunc.Name] = targetFunc
+		tctx.AddTranslatedNode(srcFunc.Identity, targetFunc.Identity)
+		if tctx.Result != nil {
+			tctx.Result.TranslatedIDs[srcFunc.Identity.Full()] = struct{}{}
+		}
+		if tctx.Progress != nil {
+			tctx.Progress.ReportNodeDone("func", srcFunc.Identity.Full())
+		}
+		done[i] = true
+	})
+	for i, name := range names {
+		if !done[i] {
+			rest.Functions[name] = srcPkg.Functions[name]
+		}
+	}
+	return rest
+}
+
+// translateVarsBatch translates the small vars of srcPkg in batch mode,
+// returns a package of the vars left to translate one by one
+func (t *BaseTransformer) translateVarsBatch(ctx context.Context, srcPkg, targetPkg *uniast.Package, tctx *TranslateContext) *uniast.Package {
+	rest := uniast.NewPackage(srcPkg.PkgPath)
+	var names []string
+	for name, srcVar := range srcPkg.Vars {
+		if t.batchable(srcVar.Identity, srcVar.Content, tctx) {
+			names = append(names, name)
+		} else {
+			rest.Vars[name] = srcVar
+		}
+	}
+	sort.Strings(names)
+	reqs := make([]*LLMTranslateRequest, len(names))
+	for i, name := range names {
+		reqs[i] = t.nodeTranslator.varRequest(srcPkg.Vars[name], tctx)
+	}
+
+	done := make([]bool, len(names))
+	t.translateBatches(ctx, reqs, tctx, func(i int, resp *LLMTranslateResponse) {
+		srcVar := srcPkg.Vars[names[i]]
+		if t.nodeTranslator.checkQuality(srcVar.Content, resp.TargetContent) != nil {
+			return
+		}
+		targetVar := t.nodeTranslator.buildTargetVar(srcVar, tctx, resp.TargetContent)
+		t.nodeTranslated(srcVar.Identity, targetVar.Identity, targetVar.Content)
+		targetPkg.Vars[targetVar.Name] = targetVar
+		tctx.AddTranslatedNode(srcVar.Identity, targetVar.Identity)
+		if tctx.Result != nil {
+			tctx.Result.TranslatedIDs[srcVar.Identity.Full()] = struct{}{}
+		}
+		if tctx.Progress != nil {
+			tctx.Progress.ReportNodeDone("var", srcVar.Identity.Full())
+		}
+		done[i] = true
+	})
+	for i, name := range names {
+		if !done[i] {
+			rest.Vars[name] = srcPkg.Vars[name]
+		}
+	}
+	return rest
+}
+
+// WholePackageBatchSize is the TranslateOptions.BatchSize translating each package in a single LLM call
+const WholePackageBatchSize = -1
+
+// wholePackageNode checks if the node can be translated with its whole package,
+// nodes already translated or to be stubbed are left to the one-by-one translation
+func (t *BaseTransformer) wholePackageNode(id uniast.Identity, content string, tctx *TranslateContext) bool {
+	if tctx.Result != nil && t.opts.AlreadyTranslatedIDs != nil {
+		if _, ok := t.opts.AlreadyTranslatedIDs[id.Full()]; ok {
+			return false
+		}
+	}
+	if t.opts.NodeFilter != nil && !t.opts.NodeFilter(id) {
+		return false
+	}
+	return t.opts.SkipLargeNodes <= 0 || utf8.RuneCountInString(content) <= t.opts.SkipLargeNodes
+}
+
+// translateWholePackage translates the nodes of srcPkg in a single LLM call,
+// returns a package of the nodes left to translate one by one
+func (t *BaseTransformer) translateWholePackage(ctx context.Context, srcPkg, targetPkg *uniast.Package, tctx *TranslateContext) *uniast.Package {
+	whole, rest := uniast.NewPackage(srcPkg.PkgPath), uniast.NewPackage(srcPkg.PkgPath)
+	for name, srcType := range srcPkg.Types {
+		if t.wholePackageNode(srcType.Identity, srcType.Content, tctx) {
+			whole.Types[name] = srcType
+		} else {
+			rest.Types[name] = srcType
+		}
+	}
+	for name, srcFunc := range srcPkg.Functions {
+		if t.wholePackageNode(srcFunc.Identity, srcFunc.Content, tctx) {
+			whole.Functions[name] = srcFunc
+		} else {
+			rest.Functions[name] = srcFunc
+		}
+	}
+	for name, srcVar := range srcPkg.Vars {
+		if t.wholePackageNode(srcVar.Identity, srcVar.Content, tctx) {
+			whole.Vars[name] = srcVar
+		} else {
+			rest.Vars[name] = srcVar
+		}
+	}
+	if whole.NodeCount() == 0 {
+		return srcPkg
+	}
+	resps, err := t.nodeTranslator.TranslatePackage(ctx, whole, tctx)
+	if err != nil {
+		return srcPkg
+	}
+
+	done := func(kind string, src, dst uniast.Identity) {
+		tctx.AddTranslatedNode(src, dst)
+		if tctx.Result != nil {
+			tctx.Result.TranslatedIDs[src.Full()] = struct{}{}
+		}
+		if tctx.Progress != nil {
+			tctx.Progress.ReportNodeDone(kind, src.Full())
+		}
+	}
+	for name, srcType := range whole.Types {
+		resp := resps[srcType.Name]
+		if resp == nil || t.nodeTranslator.checkQuality(srcType.Content, resp.TargetContent) != nil {
+			rest.Types[name] = srcType
+			continue
+		}
+		targetType := t.nodeTranslator.buildTargetType(srcType, tctx, resp.TargetContent)
+		t.nodeTranslated(srcType.Identity, targetType.Identity, targetType.Content)
+		targetPkg.Types[targetType.Name] = targetType
+		done("type", srcType.Identity, targetType.Identity)
+	}
+	for name, srcFunc := range whole.Functions {
+		resp := resps[srcFunc.Name]
+		if resp == nil || t.nodeTranslator.checkQuality(srcFunc.Content, resp.TargetContent) != nil {
+			rest.Functions[name] = srcFunc
+			continue
+		}
+		targetFunc := t.nodeTranslator.buildTargetFunction(srcFunc, tctx, resp.TargetContent, "")
+		t.nodeTranslated(srcFunc.Identity, targetFunc.Identity, targetFunc.Content)
+		targetPkg.Functions[targetFunc.Name] = targetFunc
+		done("func", srcFunc.Identity, targetFunc.Identity)
+	}
+	for name, srcVar := range whole.Vars {
+		resp := resps[srcVar.Name]
+		if resp == nil || t.nodeTranslator.checkQuality(srcVar.Content, resp.TargetContent) != nil {
+			rest.Vars[name] = srcVar
+			continue
+		}
+		targetVar := t.nodeTranslator.buildTargetVar(srcVar, tctx, resp.TargetContent)
+		t.nodeTranslated(srcVar.Identity, targetVar.Identity, targetVar.Content)
+		targetPkg.Vars[targetVar.Name] = targetVar
+		done("var", srcVar.Identity, targetVar.Identity)
+	}
+	return rest
+}
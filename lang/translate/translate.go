@@ -100,14 +100,55 @@ func TranslateAST(ctx context.Context, srcRepo *uniast.Repository, opts Translat
 	return transformer.Transform(ctx, srcRepo)
 }
 
+// ReTranslateFailedNodes retries only the failed nodes of a previous translation and merges them into a copy of dstRepo.
+// If opts.AlreadyTranslatedIDs is nil, it is populated with all source nodes not listed in failed.
+func ReTranslateFailedNodes(ctx context.Context, srcRepo, dstRepo *uniast.Repository, failed []FailedNodeInfo, opts TranslateOptions) (*uniast.Repository, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+	if srcRepo == nil || dstRepo == nil {
+		return nil, fmt.Errorf("both source and destination repositories are required")
+	}
+
+	if opts.SourceLanguage == uniast.Unknown {
+		opts.SourceLanguage = inferLanguage(srcRepo)
+	}
+
+	if opts.AlreadyTranslatedIDs == nil {
+		failedIDs := make(map[string]struct{}, len(failed))
+		for _, f := range failed {
+			failedIDs[f.NodeID] = struct{}{}
+		}
+		opts.AlreadyTranslatedIDs = make(map[string]struct{})
+		for id := range srcRepo.AllTypes {
+			opts.AlreadyTranslatedIDs[id.Full()] = struct{}{}
+		}
+		for id := range srcRepo.AllFunctions {
+			opts.AlreadyTranslatedIDs[id.Full()] = struct{}{}
+		}
+		for id := range srcRepo.AllVars {
+			opts.AlreadyTranslatedIDs[id.Full()] = struct{}{}
+		}
+		for id := range failedIDs {
+			delete(opts.AlreadyTranslatedIDs, id)
+		}
+	}
+
+	transformer := NewTransformer(opts)
+	return transformer.ReTransform(ctx, srcRepo, dstRepo, failed)
+}
+
 // validateOptions checks if the required options are provided
 func validateOptions(opts TranslateOptions) error {
 	if opts.TargetLanguage == uniast.Unknown {
 		return fmt.Errorf("TargetLanguage is required")
 	}
-	if opts.LLMTranslator == nil {
+	if opts.LLMTranslator == nil && opts.MultiModel == nil {
 		return fmt.Errorf("LLMTranslator callback is required")
 	}
+	if opts.TargetModuleName != "" {
+		return checkModuleName(opts.TargetModuleName, opts.TargetLanguage)
+	}
 	return nil
 }
 
@@ -173,28 +214,42 @@ func inferLanguage(repo *uniast.Repository) uniast.Language {
 // CountTranslatableNodes returns the number of top-level nodes (Types + Functions + Vars)
 // in internal modules only. Used for progress display and resume.
 func CountTranslatableNodes(repo *uniast.Repository) int {
-	if repo == nil || repo.Modules == nil {
+	if repo == nil {
 		return 0
 	}
-	var n int
-	for _, mod := range repo.Modules {
-		if mod.IsExternal() || mod.Packages == nil {
-			continue
-		}
-		for _, pkg := range mod.Packages {
-			if pkg == nil {
-				continue
-			}
-			if pkg.Types != nil {
-				n += len(pkg.Types)
-			}
-			if pkg.Functions != nil {
-				n += len(pkg.Functions)
-			}
-			if pkg.Vars != nil {
-				n += len(pkg.Vars)
-			}
+	return repo.TotalNodeCount()
+}
+
+// newTranslationStats counts the nodes of each kind in the internal modules of src by their outcome in result
+func newTranslationStats(src *uniast.Repository, result *TranslateResult) TranslationStats {
+	failed := make(map[string]struct{}, len(result.FailedNodes))
+	for _, f := range result.FailedNodes {
+		failed[f.NodeID] = struct{}{}
+	}
+	skipped := make(map[string]struct{}, len(result.SkippedNodes))
+	for _, s := range result.SkippedNodes {
+		skipped[s.NodeID] = struct{}{}
+	}
+	count := func(stats *TranslationKindStats, id uniast.Identity) {
+		stats.Total++
+		if _, ok := skipped[id.Full()]; ok {
+			stats.Skipped++
+		} else if _, ok := failed[id.Full()]; ok {
+			stats.Failed++
+		} else if _, ok := result.TranslatedIDs[id.Full()]; ok {
+			stats.Translated++
 		}
 	}
-	return n
+
+	var stats TranslationStats
+	for id := range src.AllTypes {
+		count(&stats.Types, id)
+	}
+	for id := range src.AllFunctions {
+		count(&stats.Functions, id)
+	}
+	for id := range src.AllVars {
+		count(&stats.Vars, id)
+	}
+	return stats
 }
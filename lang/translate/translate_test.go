@@ -18,9 +18,21 @@ package translate
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/cloudwego/abcoder/lang"
 	"github.com/cloudwego/abcoder/lang/uniast"
 )
 
@@ -58,6 +70,199 @@ func TestTranslateAST(t *testing.T) {
 	}
 }
 
+func TestTranslateAST_TargetModuleName(t *testing.T) {
+	opts := TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/MyOrg/my_project",
+		LLMTranslator:    mockLLMTranslator,
+	}
+	targetRepo, err := TranslateAST(context.Background(), createTestJavaRepo(), opts)
+	if err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+	// the specified name is used as is
+	if targetRepo.Modules["github.com/MyOrg/my_project"] == nil {
+		t.Errorf("expect module github.com/MyOrg/my_project, got %v", targetRepo.Modules)
+	}
+
+	for _, name := range []string{"github.com/my org/project", "/abs/path", "github.com//project"} {
+		opts.TargetModuleName = name
+		if _, err := TranslateAST(context.Background(), createTestJavaRepo(), opts); err == nil || !strings.Contains(err.Error(), "invalid target module name") {
+			t.Errorf("TranslateAST with module %q: expect invalid target module name error, got %v", name, err)
+		}
+	}
+
+	// the name is only checked as a Go module path when translating to Go
+	for _, lang := range []uniast.Language{uniast.Python, uniast.Rust} {
+		if err := validateOptions(TranslateOptions{TargetLanguage: lang, TargetModuleName: "my project", LLMTranslator: mockLLMTranslator}); err != nil {
+			t.Errorf("validateOptions() of %s error = %v", lang, err)
+		}
+	}
+}
+
+func TestReTranslateFailedNodes(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	pkg := srcRepo.Modules["com.example:test:1.0"].Packages["com.example.model"]
+	pkg.Types["Order"] = &uniast.Type{
+		Exported: true,
+		TypeKind: uniast.TypeKindStruct,
+		Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: "Order"},
+		Content:  "public class Order { private User user; }",
+	}
+	orderID := pkg.Types["Order"].Identity.Full()
+
+	// the first run fails to translate Order
+	result := &TranslateResult{}
+	opts := TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/example/test",
+		Result:           result,
+		LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			if req.Identity.Full() == orderID {
+				return nil, fmt.Errorf("mock failure")
+			}
+			return mockLLMTranslator(ctx, req)
+		},
+	}
+	ctx := context.Background()
+	dstRepo, err := TranslateAST(ctx, srcRepo, opts)
+	if err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+	if len(result.FailedNodes) != 1 || result.FailedNodes[0].NodeID != orderID {
+		t.Fatalf("expect Order to fail, got %+v", result.FailedNodes)
+	}
+	if stats := result.TranslationStats.Types; stats.Total != len(pkg.Types) || stats.Failed != 1 || stats.Translated != stats.Total-1 {
+		t.Errorf("unexpected type stats %+v", stats)
+	}
+
+	var calls []string
+	retryResult := &TranslateResult{}
+	opts.Result = retryResult
+	opts.LLMTranslator = func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+		calls = append(calls, req.Identity.Full())
+		return mockLLMTranslator(ctx, req)
+	}
+	got, err := ReTranslateFailedNodes(ctx, srcRepo, dstRepo, result.FailedNodes, opts)
+	if err != nil {
+		t.Fatalf("ReTranslateFailedNodes failed: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != orderID {
+		t.Errorf("expect only Order to be translated, got %v", calls)
+	}
+	if len(retryResult.FailedNodes) != 0 {
+		t.Errorf("expect no failed nodes, got %+v", retryResult.FailedNodes)
+	}
+	if _, ok := retryResult.TranslatedIDs[orderID]; !ok {
+		t.Error("Order should be recorded as translated")
+	}
+	if stats := retryResult.TranslationStats.Types; stats.Failed != 0 || stats.Translated != stats.Total {
+		t.Errorf("unexpected type stats after retry %+v", stats)
+	}
+	targetPkg := got.Modules["github.com/example/test"].Packages["model"]
+	if targetPkg == nil || targetPkg.Types["Order"] == nil || targetPkg.Types["User"] == nil {
+		t.Fatalf("expect User and Order in target package, got %+v", targetPkg)
+	}
+	if dstRepo.Modules["github.com/example/test"].Packages["model"].Types["Order"] != nil {
+		t.Error("dstRepo should not be modified")
+	}
+}
+
+func TestTranslateAST_SkipLargeNodes(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	pkg := srcRepo.Modules["com.example:test:1.0"].Packages["com.example.model"]
+	pkg.Types["Generated"] = &uniast.Type{
+		Exported: true,
+		TypeKind: uniast.TypeKindStruct,
+		Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: "Generated"},
+		Content:  "public class Generated { " + strings.Repeat("int f; ", 20) + "}",
+	}
+
+	for _, parallel := range []bool{false, true} {
+		var calls []string
+		result := &TranslateResult{}
+		opts := TranslateOptions{
+			SourceLanguage:   uniast.Java,
+			TargetLanguage:   uniast.Golang,
+			TargetModuleName: "github.com/example/test",
+			Parallel:         parallel,
+			Concurrency:      2,
+			SkipLargeNodes:   100,
+			Result:           result,
+			LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+				calls = append(calls, req.Identity.Name)
+				return mockLLMTranslator(ctx, req)
+			},
+		}
+		targetRepo, err := TranslateAST(context.Background(), srcRepo, opts)
+		if err != nil {
+			t.Fatalf("TranslateAST failed: %v", err)
+		}
+		if len(calls) != 1 || calls[0] != "User" {
+			t.Errorf("expect only User to be sent to LLM, got %v", calls)
+		}
+		if len(result.SkippedNodes) != 1 || result.SkippedNodes[0].NodeID != pkg.Types["Generated"].Identity.Full() ||
+			result.SkippedNodes[0].ContentLen != len(pkg.Types["Generated"].Content) {
+			t.Errorf("unexpected SkippedNodes: %+v", result.SkippedNodes)
+		}
+		if stats := result.TranslationStats.Types; stats.Skipped != 1 || stats.Translated != stats.Total-1 {
+			t.Errorf("unexpected type stats %+v", stats)
+		}
+		stub := targetRepo.Modules["github.com/example/test"].Packages["model"].Types["Generated"]
+		if stub == nil || !strings.HasPrefix(stub.Content, "// Skipped: source too large") {
+			t.Errorf("expect stub for Generated, got %+v", stub)
+		}
+	}
+}
+
+func TestTranslateAST_NodeFilter(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	pkg := srcRepo.Modules["com.example:test:1.0"].Packages["com.example.model"]
+	pkg.Types["UserProto"] = &uniast.Type{
+		Exported: true,
+		TypeKind: uniast.TypeKindStruct,
+		Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: "UserProto"},
+		Content:  "public class UserProto {\n    int id;\n}",
+	}
+
+	for _, parallel := range []bool{false, true} {
+		var calls []string
+		result := &TranslateResult{}
+		opts := TranslateOptions{
+			SourceLanguage:   uniast.Java,
+			TargetLanguage:   uniast.Golang,
+			TargetModuleName: "github.com/example/test",
+			Parallel:         parallel,
+			Concurrency:      2,
+			NodeFilter: func(id uniast.Identity) bool {
+				return !strings.HasSuffix(id.Name, "Proto")
+			},
+			Result: result,
+			LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+				calls = append(calls, req.Identity.Name)
+				return mockLLMTranslator(ctx, req)
+			},
+		}
+		targetRepo, err := TranslateAST(context.Background(), srcRepo, opts)
+		if err != nil {
+			t.Fatalf("TranslateAST failed: %v", err)
+		}
+		if len(calls) != 1 || calls[0] != "User" {
+			t.Errorf("expect only User to be sent to LLM, got %v", calls)
+		}
+		if len(result.SkippedNodes) != 1 || result.SkippedNodes[0].Reason != "filtered out" {
+			t.Errorf("unexpected SkippedNodes: %+v", result.SkippedNodes)
+		}
+		stub := targetRepo.Modules["github.com/example/test"].Packages["model"].Types["UserProto"]
+		want := "// Skipped: filtered out by node filter, original source:\n// public class UserProto {\n//     int id;\n// }"
+		if stub == nil || stub.Content != want {
+			t.Errorf("expect commented-out source for UserProto, got %+v", stub)
+		}
+	}
+}
+
 func TestValidateOptions(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -111,6 +316,10 @@ func TestTypeHints(t *testing.T) {
 		{uniast.Golang, uniast.Rust, "string", "String"},
 		{uniast.Golang, uniast.Rust, "[]T", "Vec<T>"},
 		{uniast.Python, uniast.Golang, "str", "string"},
+		{uniast.Golang, uniast.Java, "string", "String"},
+		{uniast.Golang, uniast.Java, "(T, error)", "T throws Exception"},
+		{uniast.Golang, uniast.Java, "chan T", "BlockingQueue<T>"},
+		{uniast.Golang, uniast.Java, "map[T]struct{}", "Set<T>"},
 	}
 
 	for _, tt := range tests {
@@ -143,6 +352,148 @@ func TestTypeHintsFormatForPrompt(t *testing.T) {
 	}
 }
 
+func TestCustomTypeHints(t *testing.T) {
+	hint, err := ParseTypeHintOverride(" ImmutableList<T> = []T ")
+	if err != nil || hint != (TypeHintOverride{Source: "ImmutableList<T>", Target: "[]T"}) {
+		t.Fatalf("ParseTypeHintOverride() = %+v, %v", hint, err)
+	}
+	for _, bad := range []string{"ImmutableList<T>", "=[]T", "List<T>="} {
+		if _, err := ParseTypeHintOverride(bad); err == nil {
+			t.Errorf("ParseTypeHintOverride(%q) should fail", bad)
+		}
+	}
+
+	tr := NewTransformer(TranslateOptions{
+		SourceLanguage:  uniast.Java,
+		TargetLanguage:  uniast.Golang,
+		CustomTypeHints: []TypeHintOverride{hint, {Source: "String", Target: "MyString"}},
+	})
+	if got, _ := tr.nodeTranslator.typeHints.GetMapping("ImmutableList<T>"); got != "[]T" {
+		t.Errorf("custom mapping = %q, want []T", got)
+	}
+	if got, _ := tr.nodeTranslator.typeHints.GetMapping("String"); got != "MyString" {
+		t.Errorf("overridden mapping = %q, want MyString", got)
+	}
+	prompt := tr.promptBuilder.BuildTypePrompt(&LLMTranslateRequest{SourceContent: "class A {}", Identity: uniast.NewIdentity("m", "p", "A")})
+	if !strings.Contains(prompt, "| `ImmutableList<T>` | `[]T` |") {
+		t.Errorf("system prompt should contain the custom type hint:\n%s", prompt)
+	}
+}
+
+func TestTypeHints_LoadFromFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	hints := NewTypeHints(uniast.Java, uniast.Golang)
+	yamlFile := write("hints.yaml", "java->go:\n  com.example.Money: decimal.Decimal\n  String: MyString\ngo->java:\n  Widget: Gadget\n")
+	if err := hints.LoadFromFile(yamlFile); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if got, _ := hints.GetMapping("com.example.Money"); got != "decimal.Decimal" {
+		t.Errorf("loaded mapping = %q, want decimal.Decimal", got)
+	}
+	if got, _ := hints.GetMapping("String"); got != "MyString" {
+		t.Errorf("overridden mapping = %q, want MyString", got)
+	}
+	if _, ok := hints.GetMapping("Widget"); ok {
+		t.Errorf("the mappings of another language pair should not be loaded")
+	}
+
+	jsonFile := write("hints.json", `{"java->go": {"Money": "decimal.Decimal"}}`)
+	if err := NewTypeHints(uniast.Java, uniast.Golang).LoadFromFile(jsonFile); err != nil {
+		t.Errorf("LoadFromFile(json) error = %v", err)
+	}
+	for name, content := range map[string]string{
+		"invalid.yaml": "java->go: [a, b\n",
+		"pair.yaml":    "java->cobol:\n  String: PIC\n",
+		"empty.yaml":   "java->go:\n  String: \"\"\n",
+	} {
+		if err := NewTypeHints(uniast.Java, uniast.Golang).LoadFromFile(write(name, content)); err == nil {
+			t.Errorf("LoadFromFile(%s) should fail", name)
+		}
+	}
+	if err := NewTypeHints(uniast.Java, uniast.Golang).LoadFromFile(filepath.Join(dir, "none.yaml")); err == nil {
+		t.Errorf("LoadFromFile() of a missing file should fail")
+	}
+
+	// --type-hint overrides the file, and a bad file fails the transform
+	tr := NewTransformer(TranslateOptions{
+		SourceLanguage:  uniast.Java,
+		TargetLanguage:  uniast.Golang,
+		TypeHintsFile:   yamlFile,
+		CustomTypeHints: []TypeHintOverride{{Source: "String", Target: "string"}},
+	})
+	if got, _ := tr.nodeTranslator.typeHints.GetMapping("String"); got != "string" {
+		t.Errorf("CustomTypeHints should override the file, got %q", got)
+	}
+	tr = NewTransformer(TranslateOptions{
+		SourceLanguage: uniast.Java,
+		TargetLanguage: uniast.Golang,
+		TypeHintsFile:  filepath.Join(dir, "pair.yaml"),
+		LLMTranslator:  mockLLMTranslator,
+	})
+	if _, err := tr.Transform(context.Background(), createTestJavaRepo()); err == nil || !strings.Contains(err.Error(), "unknown language pair") {
+		t.Errorf("Transform() error = %v, want unknown language pair", err)
+	}
+}
+
+func TestTranslateAST_PackageSplit(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	pkg := srcRepo.Modules["com.example:test:1.0"].Packages["com.example.model"]
+	for _, name := range []string{"UserService", "UserRepository", "AuthService"} {
+		pkg.Types[name] = &uniast.Type{
+			Exported: true,
+			TypeKind: uniast.TypeKindStruct,
+			Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: name},
+			Content:  "public class " + name + " {}",
+		}
+	}
+	pkg.Functions["AuthService.login"] = &uniast.Function{
+		Exported: true,
+		IsMethod: true,
+		Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: "AuthService.login"},
+		Content:  "public void login() {}",
+		Receiver: &uniast.Receiver{Type: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: "AuthService"}},
+	}
+
+	adapter := NewStructureAdapter(uniast.Java, uniast.Golang)
+	if parts := adapter.SplitPackage(pkg, SplitHeuristic{Threshold: 4}); parts != nil {
+		t.Errorf("expect no split under threshold, got %d parts", len(parts))
+	}
+	parts := adapter.SplitPackage(pkg, SplitHeuristic{Threshold: 3})
+	if len(parts) != 2 || len(parts["user"].Types) != 3 || len(parts["auth"].Types) != 1 ||
+		parts["auth"].Functions["AuthService.login"] == nil {
+		t.Fatalf("unexpected parts: %+v", parts)
+	}
+
+	targetRepo, err := TranslateAST(context.Background(), srcRepo, TranslateOptions{
+		SourceLanguage:        uniast.Java,
+		TargetLanguage:        uniast.Golang,
+		TargetModuleName:      "github.com/example/test",
+		PackageSplitThreshold: 3,
+		LLMTranslator:         mockLLMTranslator,
+	})
+	if err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+	pkgs := targetRepo.Modules["github.com/example/test"].Packages
+	if pkgs["model"] != nil {
+		t.Errorf("expect package model to be split")
+	}
+	if user := pkgs["model/user"]; user == nil || len(user.Types) != 3 {
+		t.Errorf("expect 3 types in model/user, got %+v", user)
+	}
+	if auth := pkgs["model/auth"]; auth == nil || len(auth.Types) != 1 || len(auth.Functions) != 1 {
+		t.Errorf("expect AuthService and its method in model/auth, got %+v", auth)
+	}
+}
+
 func TestStructureAdapter(t *testing.T) {
 	adapter := NewStructureAdapter(uniast.Java, uniast.Golang)
 
@@ -165,173 +516,824 @@ func TestStructureAdapter(t *testing.T) {
 	}
 }
 
-func TestNamingConversions(t *testing.T) {
+func TestStructureAdapter_Go2Java(t *testing.T) {
+	adapter := NewStructureAdapter(uniast.Golang, uniast.Java)
+
 	tests := []struct {
-		name     string
-		input    string
-		toPascal string
-		toCamel  string
-		toSnake  string
+		path string
+		want string
 	}{
-		{"simple", "hello", "Hello", "hello", "hello"},
-		{"camelCase", "helloWorld", "HelloWorld", "helloWorld", "hello_world"},
-		{"PascalCase", "HelloWorld", "HelloWorld", "helloWorld", "hello_world"},
-		{"snake_case", "hello_world", "HelloWorld", "helloWorld", "hello_world"},
+		{"github.com/example/project/model", "com.example.project.model"},
+		{"github.com/example/my-project/v2/model", "com.example.my_project.model"},
+		{"gopkg.in/yaml.v3", "com.gopkg_in.yaml_v3"},
+		{"example/2fa", "com.example._2fa"},
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := toPascalCase(tt.input); got != tt.toPascal {
-				t.Errorf("toPascalCase(%q) = %q, want %q", tt.input, got, tt.toPascal)
-			}
-			if got := toCamelCase(tt.input); got != tt.toCamel {
-				t.Errorf("toCamelCase(%q) = %q, want %q", tt.input, got, tt.toCamel)
-			}
-			if got := toSnakeCase(tt.input); got != tt.toSnake {
-				t.Errorf("toSnakeCase(%q) = %q, want %q", tt.input, got, tt.toSnake)
-			}
-		})
+		if got := adapter.convertPackagePath(tt.path); got != tt.want {
+			t.Errorf("convertPackagePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	if got := adapter.convertModuleName("github.com/example/project"); got != "com.example.project" {
+		t.Errorf("convertModuleName() = %q, want %q", got, "com.example.project")
+	}
+	if got := adapter.convertFilePath("model/user_service.go"); got != "model/UserService.java" {
+		t.Errorf("convertFilePath() = %q, want %q", got, "model/UserService.java")
 	}
 }
 
-func TestPromptBuilder(t *testing.T) {
-	hints := NewTypeHints(uniast.Java, uniast.Golang)
-	builder := NewPromptBuilder(uniast.Java, uniast.Golang, hints)
+func TestStructureAdapter_RustModuleName(t *testing.T) {
+	tests := []struct {
+		source uniast.Language
+		name   string
+		want   string
+	}{
+		{uniast.Java, "com.example.project", "project"},
+		{uniast.Java, "com.example.UserService", "user_service"},
+		{uniast.Java, "com.example:user-service:1.0-SNAPSHOT", "user_service"},
+		{uniast.Java, "/home/me/HTTPServer", "http_server"},
+		{uniast.Golang, "github.com/example/user-service", "user_service"},
+		{uniast.Python, "example.project", "example_project"},
+		{uniast.TypeScript, "@scope/my-app", "my_app"},
+		{uniast.Golang, "example/2fa", "_2fa"},
+		{uniast.Golang, "", "app"},
+	}
+	for _, tt := range tests {
+		adapter := NewStructureAdapter(tt.source, uniast.Rust)
+		if got := adapter.AdaptModule(&uniast.Module{Name: tt.name}).Name; got != tt.want {
+			t.Errorf("AdaptModule(%s %q).Name = %q, want %q", tt.source, tt.name, got, tt.want)
+		}
+	}
+
+	transformer := NewTransformer(TranslateOptions{SourceLanguage: uniast.Java, TargetLanguage: uniast.Rust})
+	if got, err := transformer.targetModuleName(&uniast.Repository{Name: "com.example:user_service:1.0"}); err != nil || got != "user_service" {
+		t.Errorf("targetModuleName() = %q, %v, want user_service", got, err)
+	}
+}
 
+func TestPromptBuilder_Go2Java(t *testing.T) {
+	builder := NewPromptBuilder(uniast.Golang, uniast.Java, NewTypeHints(uniast.Golang, uniast.Java))
 	req := &LLMTranslateRequest{
-		SourceLanguage: uniast.Java,
-		TargetLanguage: uniast.Golang,
+		SourceLanguage: uniast.Golang,
+		TargetLanguage: uniast.Java,
 		NodeType:       uniast.FUNC,
-		SourceContent:  "public void hello() { }",
-		Identity: uniast.Identity{
-			ModPath: "com.example",
-			PkgPath: "com.example.test",
-			Name:    "hello",
-		},
+		SourceContent:  "func (u *User) Load() (string, error) { return \"\", nil }",
 	}
-
-	// Test function prompt
 	prompt := builder.BuildFunctionPrompt(req)
-	if !strings.Contains(prompt, "java") {
-		t.Error("function prompt should contain 'java'")
+	for _, want := range []string{"exception", "CompletableFuture", "try/finally"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("function prompt should contain %q", want)
+		}
 	}
-	if !strings.Contains(prompt, "go") {
-		t.Error("function prompt should contain 'go'")
+	typePrompt := builder.BuildTypePrompt(req)
+	if !strings.Contains(typePrompt, "convert struct to class") {
+		t.Error("type prompt should contain struct to class hint")
 	}
-	if !strings.Contains(prompt, "public void hello()") {
-		t.Error("function prompt should contain source content")
+}
+
+func TestPromptBuilder_TypeParams(t *testing.T) {
+	builder := NewPromptBuilder(uniast.Golang, uniast.Java, NewTypeHints(uniast.Golang, uniast.Java))
+	prompt := builder.BuildTypePrompt(&LLMTranslateRequest{
+		SourceLanguage: uniast.Golang,
+		TargetLanguage: uniast.Java,
+		NodeType:       uniast.TYPE,
+		SourceContent:  "type Stack[T any] struct { items []T }",
+		TypeParams:     []uniast.TypeParam{{Name: "T", Constraint: "any"}},
+	})
+	if !strings.Contains(prompt, "## Type Parameters\n- `T`: `any`") {
+		t.Errorf("type prompt should list type parameters, got:\n%s", prompt)
 	}
+}
 
-	// Test type prompt
-	req.NodeType = uniast.TYPE
-	req.SourceContent = "public class User { }"
-	typePrompt := builder.BuildTypePrompt(req)
-	if !strings.Contains(typePrompt, "type/class") {
-		t.Error("type prompt should contain 'type/class'")
+func TestPromptBuilder_DocString(t *testing.T) {
+	builder := NewPromptBuilder(uniast.Golang, uniast.Java, NewTypeHints(uniast.Golang, uniast.Java))
+	req := &LLMTranslateRequest{
+		SourceLanguage: uniast.Golang,
+		TargetLanguage: uniast.Java,
+		NodeType:       uniast.FUNC,
+		SourceContent:  "func Load() error { return nil }",
+		DocString:      "Load loads the config.",
+	}
+	for _, prompt := range []string{builder.BuildFunctionPrompt(req), builder.BuildTypePrompt(req)} {
+		doc := strings.Index(prompt, "## Documentation\n")
+		src := strings.Index(prompt, "## Source Code\n")
+		if doc < 0 || src < doc || !strings.Contains(prompt[doc:src], "Load loads the config.") {
+			t.Errorf("prompt should contain the doc string before the source code, got:\n%s", prompt)
+		}
 	}
 
-	// Test var prompt
-	req.NodeType = uniast.VAR
-	req.SourceContent = "public static final int MAX = 100;"
-	varPrompt := builder.BuildVarPrompt(req)
-	if !strings.Contains(varPrompt, "variable/constant") {
-		t.Error("var prompt should contain 'variable/constant'")
+	req.DocString = ""
+	if prompt := builder.BuildFunctionPrompt(req); strings.Contains(prompt, "## Documentation") {
+		t.Errorf("prompt should not contain documentation without doc string, got:\n%s", prompt)
 	}
 }
 
-func TestNodeTranslator(t *testing.T) {
-	opts := TranslateOptions{
+func TestPromptBuilder_Tags(t *testing.T) {
+	builder := NewPromptBuilder(uniast.Java, uniast.Golang, NewTypeHints(uniast.Java, uniast.Golang))
+	prompt := builder.BuildTypePrompt(&LLMTranslateRequest{
 		SourceLanguage: uniast.Java,
 		TargetLanguage: uniast.Golang,
-		LLMTranslator:  mockLLMTranslator,
+		NodeType:       uniast.TYPE,
+		SourceContent:  "@RestController\npublic class UserController {}",
+		Tags:           map[string]string{uniast.TagSpring: "@RestController"},
+	})
+	if !strings.Contains(prompt, "## Source Annotations to Consider\n- `spring`: `@RestController`") {
+		t.Errorf("type prompt should list source annotations, got:\n%s", prompt)
 	}
+}
 
-	hints := NewTypeHints(uniast.Java, uniast.Golang)
-	translator := NewNodeTranslator(opts, hints)
-
-	// Create test context
-	srcRepo := createTestJavaRepo()
-	targetRepo := uniast.NewRepository("github.com/example/test")
-	targetMod := uniast.NewModule("github.com/example/test", "", uniast.Golang)
-	targetPkg := &uniast.Package{
-		PkgPath:   "model",
-		Functions: make(map[string]*uniast.Function),
-		Types:     make(map[string]*uniast.Type),
-		Vars:      make(map[string]*uniast.Var),
+func TestPromptBuilder_Constructor(t *testing.T) {
+	builder := NewPromptBuilder(uniast.Java, uniast.Golang, NewTypeHints(uniast.Java, uniast.Golang))
+	req := &LLMTranslateRequest{
+		SourceLanguage: uniast.Java,
+		TargetLanguage: uniast.Golang,
+		NodeType:       uniast.FUNC,
+		Identity:       uniast.Identity{ModPath: "m", PkgPath: "p", Name: "User.User"},
+		SourceContent:  "public User(String name) { this.name = name; }",
+		IsConstructor:  true,
 	}
-
-	tctx := &TranslateContext{
-		SourceRepo:      srcRepo,
-		TargetRepo:      &targetRepo,
-		Module:          targetMod,
-		Package:         targetPkg,
-		TranslatedNodes: make(map[string]uniast.Identity),
+	if prompt := builder.BuildFunctionPrompt(req); !strings.Contains(prompt, "This is a constructor of User: translate as a Go `NewUser(...) *User` function") {
+		t.Errorf("function prompt should contain the constructor rule, got:\n%s", prompt)
 	}
-
-	// Test TranslateType
-	srcType := &uniast.Type{
-		Exported: true,
-		TypeKind: uniast.TypeKindStruct,
-		Identity: uniast.Identity{
-			ModPath: "com.example:test:1.0",
-			PkgPath: "com.example.model",
-			Name:    "User",
-		},
-		FileLine: uniast.FileLine{
-			File: "User.java",
-			Line: 1,
-		},
-		Content: "public class User { private String name; }",
+	req.IsConstructor = false
+	if prompt := builder.BuildFunctionPrompt(req); strings.Contains(prompt, "This is a constructor") {
+		t.Errorf("function prompt should not contain the constructor rule, got:\n%s", prompt)
 	}
+}
 
-	ctx := context.Background()
-	targetType, err := translator.TranslateType(ctx, srcType, tctx)
-	if err != nil {
-		t.Fatalf("TranslateType failed: %v", err)
+func TestPromptBuilder_NodeKind(t *testing.T) {
+	builder := NewPromptBuilder(uniast.Java, uniast.Golang, NewTypeHints(uniast.Java, uniast.Golang))
+	req := &LLMTranslateRequest{
+		SourceLanguage: uniast.Java,
+		TargetLanguage: uniast.Golang,
+		NodeType:       uniast.TYPE,
+		NodeKind:       uniast.TypeEnum,
+		SourceContent:  "public enum Color { RED, GREEN }",
 	}
-	if targetType.Name != "User" {
-		t.Errorf("unexpected type name: %s", targetType.Name)
+	if req.NodeKind.Base() != req.NodeType {
+		t.Errorf("%s.Base() = %s, want %s", req.NodeKind, req.NodeKind.Base(), req.NodeType)
 	}
-
-	// Test TranslateFunction
-	srcFunc := &uniast.Function{
-		Exported: true,
-		IsMethod: false,
-		Identity: uniast.Identity{
-			ModPath: "com.example:test:1.0",
-			PkgPath: "com.example.service",
-			Name:    "getUser",
-		},
-		FileLine: uniast.FileLine{
-			File: "UserService.java",
-			Line: 10,
-		},
-		Content: "public User getUser(String id) { return null; }",
+	if prompt := builder.BuildTypePrompt(req); !strings.Contains(prompt, "This is an enum") || !strings.Contains(prompt, "iota") {
+		t.Errorf("type prompt should contain the enum rule, got:\n%s", prompt)
 	}
-
-	targetFunc, err := translator.TranslateFunction(ctx, srcFunc, tctx)
-	if err != nil {
-		t.Fatalf("TranslateFunction failed: %v", err)
+	req.NodeKind = uniast.TypeAlias
+	if prompt := builder.BuildTypePrompt(req); !strings.Contains(prompt, "`type A = B`") {
+		t.Errorf("type prompt should contain the alias rule, got:\n%s", prompt)
 	}
-	if targetFunc.Name != "GetUser" {
-		t.Errorf("unexpected function name: %s", targetFunc.Name)
+	req.NodeKind = uniast.TYPE
+	if prompt := builder.BuildTypePrompt(req); strings.Contains(prompt, "This is a") {
+		t.Errorf("type prompt should not contain a kind rule, got:\n%s", prompt)
+	}
+	req = &LLMTranslateRequest{NodeType: uniast.VAR, NodeKind: uniast.VarConst, SourceContent: "static final int MAX = 10;"}
+	if prompt := builder.BuildBatchPrompt([]*LLMTranslateRequest{req, req}); strings.Count(prompt, "This is a constant") != 2 {
+		t.Errorf("batch prompt should contain the const rule of each node, got:\n%s", prompt)
 	}
 }
 
-// createTestJavaRepo creates a test Java repository
-func createTestJavaRepo() *uniast.Repository {
-	repo := uniast.NewRepository("com.example:test:1.0")
+func TestPromptBuilder_ReceiverType(t *testing.T) {
+	builder := NewPromptBuilder(uniast.Golang, uniast.Rust, NewTypeHints(uniast.Golang, uniast.Rust))
+	req := &LLMTranslateRequest{
+		SourceLanguage: uniast.Golang,
+		TargetLanguage: uniast.Rust,
+		NodeType:       uniast.FUNC,
+		Identity:       uniast.Identity{ModPath: "m", PkgPath: "p", Name: "User.Save"},
+		SourceContent:  "func (u *User) Save() error { return nil }",
+	}
+	if prompt := builder.BuildFunctionPrompt(req); strings.Contains(prompt, "This is a method on") {
+		t.Errorf("function prompt should not mention a receiver, got:\n%s", prompt)
+	}
+	req.ReceiverType = "*User"
+	if prompt := builder.BuildFunctionPrompt(req); !strings.Contains(prompt, "This is a method on `*User`") {
+		t.Errorf("function prompt should mention the receiver type, got:\n%s", prompt)
+	}
+}
 
-	mod := uniast.NewModule("com.example:test:1.0", "src/main/java", uniast.Java)
+func TestConfigGenerator_Java(t *testing.T) {
+	g := NewConfigGenerator(uniast.Java, "demo")
+	repo := uniast.NewRepository("demo")
+	if _, err := g.Generate(&repo, t.TempDir()); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	files := g.GetFiles()
+	for _, f := range []string{"pom.xml", "build.gradle", "settings.gradle"} {
+		if _, ok := files[f]; !ok {
+			t.Errorf("expect %s to be generated", f)
+		}
+	}
+	if !strings.Contains(files["build.gradle"], "id 'java'") {
+		t.Error("build.gradle should apply the java plugin")
+	}
+}
 
-	pkg := &uniast.Package{
-		PkgPath:   "com.example.model",
+func TestConfigGenerator_Go(t *testing.T) {
+	g := NewConfigGenerator(uniast.Golang, "example.com/demo")
+	repo := uniast.NewRepository("demo")
+	if _, err := g.Generate(&repo, t.TempDir()); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	files := g.GetFiles()
+	for _, f := range []string{"go.mod", "go.sum", ".gitignore", "Makefile"} {
+		if _, ok := files[f]; !ok {
+			t.Errorf("expect %s to be generated", f)
+		}
+	}
+	if _, ok := files["Dockerfile"]; ok {
+		t.Error("Dockerfile should not be generated by default")
+	}
+	if !strings.Contains(files[".gitignore"], "vendor/") || !strings.Contains(files["Makefile"], "lint:\n\tgo vet ./...") {
+		t.Errorf("unexpected .gitignore or Makefile:\n%s\n%s", files[".gitignore"], files["Makefile"])
+	}
+
+	g = NewConfigGenerator(uniast.Golang, "example.com/demo")
+	g.SetGenerateDockerfile(true)
+	if _, err := g.Generate(&repo, t.TempDir()); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !strings.Contains(g.GetFiles()["Dockerfile"], "FROM golang:1.21 AS builder") {
+		t.Errorf("Dockerfile should use a multi-stage Go builder, got:\n%s", g.GetFiles()["Dockerfile"])
+	}
+
+	mod := uniast.NewModule("example.com/demo", ".", uniast.Golang)
+	mod.ExternalDependencies = map[string]string{"github.com/pkg/errors": "v0.9.1", "golang.org/x/sync": "v0.7.0"}
+	repo.Modules[mod.Name] = mod
+	g = NewConfigGenerator(uniast.Golang, "example.com/demo")
+	g.AddDependency("golang.org/x/sync v0.8.0")
+	if _, err := g.Generate(&repo, t.TempDir()); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	want := "require (\n\tgithub.com/pkg/errors v0.9.1\n\tgolang.org/x/sync v0.8.0\n)\n"
+	if goMod := g.GetFiles()["go.mod"]; !strings.Contains(goMod, want) {
+		t.Errorf("go.mod should require external dependencies, got:\n%s", goMod)
+	}
+}
+
+func TestTranslateAST_ExternalDependencies(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	srcRepo.Modules["com.example:test:1.0"].ExternalDependencies = map[string]string{
+		"redis.clients:jedis": "5.1.0",
+		"junit:junit":         "4.13.2",
+	}
+	outDir := t.TempDir()
+	if _, err := TranslateAST(context.Background(), srcRepo, TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/example/test",
+		LLMTranslator:    mockLLMTranslator,
+		GenerateConfig:   true,
+		OutputDir:        outDir,
+	}); err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+	goMod, err := os.ReadFile(filepath.Join(outDir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// jedis maps to go-redis, junit has no Go library
+	if !strings.Contains(string(goMod), "\tgithub.com/redis/go-redis/v9 v9.5.1\n") || strings.Contains(string(goMod), "junit") {
+		t.Errorf("go.mod should require the Go equivalent of jedis only, got:\n%s", goMod)
+	}
+
+	src := uniast.NewRepository("app")
+	mod := uniast.NewModule("example.com/app", ".", uniast.Golang)
+	mod.ExternalDependencies = map[string]string{"github.com/redis/go-redis/v9": "v9.5.1", "gopkg.in/yaml.v3": "v3.0.1"}
+	src.Modules[mod.Name] = mod
+	for lang, want := range map[uniast.Language][]string{
+		uniast.Java:   {"pom.xml", "<groupId>redis.clients</groupId>\n            <artifactId>jedis</artifactId>\n            <version>5.1.0</version>", "<artifactId>snakeyaml</artifactId>"},
+		uniast.Rust:   {"Cargo.toml", "\nredis = \"0.25\"\n", "\nserde_yaml = \"0.9\"\n"},
+		uniast.Python: {"requirements.txt", "\nPyYAML>=6.0\n", "\nredis>=5.0\n"},
+	} {
+		g := NewConfigGenerator(lang, "app")
+		g.SetSource(uniast.Golang, &src)
+		dst := uniast.NewRepository("app")
+		if _, err := g.Generate(&dst, t.TempDir()); err != nil {
+			t.Fatalf("Generate() for %s error = %v", lang, err)
+		}
+		content := g.GetFiles()[want[0]]
+		for _, w := range want[1:] {
+			if !strings.Contains(content, w) {
+				t.Errorf("%s for %s should contain %q, got:\n%s", want[0], lang, w, content)
+			}
+		}
+	}
+}
+
+func TestFrameworkIntegrator_TSRoutes(t *testing.T) {
+	src := uniast.NewRepository("express-app")
+	mod := uniast.NewModule("express-app", ".", uniast.TypeScript)
+	src.Modules["express-app"] = mod
+	pkg := uniast.NewPackage("src")
+	mod.Packages["src"] = pkg
+	pkg.Functions["registerRoutes"] = &uniast.Function{
+		Identity: uniast.NewIdentity("express-app", "src", "registerRoutes"),
+		Content: `export function registerRoutes(router: Router, fastify: FastifyInstance) {
+	router.get('/users', listUsers);
+	router.get("/users/:id", auth, userController.getUser);
+	router.delete('/users/:id', async function deleteUser(req, res) { res.send(204) });
+	fastify.post('/orders', { schema: orderSchema }, async (req, reply) => {
+		return reply.send({ ok: true, items: [1, 2] });
+	});
+	cache.get('/users', fallback);
+}`,
+	}
+
+	dst := uniast.NewRepository("express-app")
+	f := NewFrameworkIntegrator(uniast.Golang, "gin")
+	f.SetSource(uniast.TypeScript, &src)
+	if _, err := f.Integrate(&dst); err != nil {
+		t.Fatalf("Integrate() error = %v", err)
+	}
+
+	want := []RouteInfo{
+		{Method: "GET", Path: "/users", HandlerName: "listUsers", SourceType: "registerRoutes"},
+		{Method: "GET", Path: "/users/:id", HandlerName: "getUser", SourceType: "registerRoutes"},
+		{Method: "DELETE", Path: "/users/:id", HandlerName: "deleteUser", SourceType: "registerRoutes"},
+		{Method: "POST", Path: "/orders", HandlerName: "postOrders", SourceType: "registerRoutes"},
+	}
+	if len(f.routes) != len(want) {
+		t.Fatalf("routes = %+v, want %+v", f.routes, want)
+	}
+	for i := range want {
+		if f.routes[i] != want[i] {
+			t.Errorf("routes[%d] = %+v, want %+v", i, f.routes[i], want[i])
+		}
+	}
+	routes := f.GetFiles()["internal/router/routes.go"]
+	for _, line := range []string{`api.GET("/users/:id", getUserHandler)`, `api.POST("/orders", postOrdersHandler)`} {
+		if !strings.Contains(routes, line) {
+			t.Errorf("routes.go should contain %q, got:\n%s", line, routes)
+		}
+	}
+}
+
+func TestEntryPointHandler_PythonMain(t *testing.T) {
+	src := uniast.NewRepository("app")
+	mod := uniast.NewModule("app", ".", uniast.Python)
+	src.Modules["app"] = mod
+	pkg := uniast.NewPackage("app")
+	mod.Packages["app"] = pkg
+	pkg.Functions["run"] = &uniast.Function{
+		Identity: uniast.NewIdentity("app", "app", "run"),
+		Content: `def run():
+    print("run")
+
+if __name__ == '__main__':
+    args = parse()
+
+    run(args)
+print("done")`,
+	}
+	pkg.Functions["inline"] = &uniast.Function{
+		Identity: uniast.NewIdentity("app", "app", "inline"),
+		Content:  "if __name__ == \"__main__\": inline()",
+	}
+
+	h := NewEntryPointHandler(uniast.Golang)
+	eps := h.DetectEntryPoints(&src)
+	sort.Slice(eps, func(i, j int) bool { return eps[i].Name < eps[j].Name })
+	if len(eps) != 2 {
+		t.Fatalf("DetectEntryPoints() = %+v, want 2 python main", eps)
+	}
+	if eps[0].Type != EntryPointPythonMain || eps[0].Content != "inline()" {
+		t.Errorf("inline entry point = %+v", eps[0])
+	}
+	if eps[1].Type != EntryPointPythonMain || eps[1].Content != "args = parse()\n\nrun(args)" {
+		t.Errorf("block entry point = %+v", eps[1])
+	}
+
+	// the Python main block is detected from the source and converted to the Go main function
+	dst := uniast.NewRepository("app")
+	dst.Modules["app"] = uniast.NewModule("app", ".", uniast.Golang)
+	var gotReq *LLMTranslateRequest
+	h.SetSource(uniast.Python, &src)
+	h.SetLLMTranslator(func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+		gotReq = req
+		return &LLMTranslateResponse{TargetContent: "```go\nargs := Parse()\nRun(args)\n```"}, nil
+	})
+	eps = h.DetectEntryPoints(&dst)
+	if !h.HasMainEntry(eps) {
+		t.Fatalf("HasMainEntry() = false, entry points: %+v", eps)
+	}
+	if _, err := h.ConvertEntryPoints(&dst, eps[:1]); err != nil {
+		t.Fatalf("ConvertEntryPoints() error = %v", err)
+	}
+	if gotReq == nil || !strings.Contains(gotReq.Prompt, gotReq.SourceContent) {
+		t.Fatalf("unexpected LLM request: %+v", gotReq)
+	}
+	mainPkg := dst.Modules["app"].Packages["main"]
+	if mainPkg == nil || mainPkg.Functions["main"] == nil {
+		t.Fatal("main function should be generated")
+	}
+	if fn := mainPkg.Functions["main"]; fn.Content != "func main() {\n\targs := Parse()\n\tRun(args)\n}" || fn.File != "main.go" {
+		t.Errorf("main function = %q in %s", fn.Content, fn.File)
+	}
+}
+
+func TestEntryPointHandler_RustMain(t *testing.T) {
+	src := uniast.NewRepository("cli")
+	mod := uniast.NewModule("cli", ".", uniast.Rust)
+	src.Modules["cli"] = mod
+	pkg := uniast.NewPackage("cli")
+	mod.Packages["cli"] = pkg
+	pkg.Functions["main"] = &uniast.Function{
+		Identity: uniast.NewIdentity("cli", "cli", "main"),
+		Content: `fn main() -> Result<(), Box<dyn Error>> {
+    let args: Vec<String> = std::env::args().collect();
+    let config = parse(&args)?;
+    println!("{}", config.name);
+    Ok(())
+}`,
+	}
+	pkg.Functions["Config::main"] = &uniast.Function{
+		Identity: uniast.NewIdentity("cli", "cli", "Config::main"),
+		IsMethod: true,
+		Content:  "fn main(&self) {}",
+	}
+
+	dst := uniast.NewRepository("cli")
+	dst.Modules["cli"] = uniast.NewModule("cli", ".", uniast.Golang)
+	var gotReq *LLMTranslateRequest
+	h := NewEntryPointHandler(uniast.Golang)
+	h.SetSource(uniast.Rust, &src)
+	h.SetLLMTranslator(func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+		gotReq = req
+		return &LLMTranslateResponse{TargetContent: "config, err := Parse(os.Args)\nif err != nil {\n\tfmt.Fprintln(os.Stderr, err)\n\tos.Exit(1)\n}\nfmt.Println(config.Name)"}, nil
+	})
+	if _, err := h.ConvertEntryPoints(&dst, nil); err != nil {
+		t.Fatalf("ConvertEntryPoints() error = %v", err)
+	}
+	if gotReq == nil || gotReq.SourceLanguage != uniast.Rust || gotReq.Identity.Name != "main" {
+		t.Fatalf("unexpected LLM request: %+v", gotReq)
+	}
+	for _, note := range []string{gotReq.SourceContent, "os.Args", "fmt.Println", "Result<(), Box<dyn Error>>", "os.Exit(1)"} {
+		if !strings.Contains(gotReq.Prompt, note) {
+			t.Errorf("prompt should contain %q, got:\n%s", note, gotReq.Prompt)
+		}
+	}
+	mainPkg := dst.Modules["cli"].Packages["main"]
+	if mainPkg == nil || mainPkg.Functions["main"] == nil {
+		t.Fatal("main function should be generated")
+	}
+	if fn := mainPkg.Functions["main"]; !strings.HasPrefix(fn.Content, "func main() {\n\tconfig, err := Parse(os.Args)\n") {
+		t.Errorf("main function = %q", fn.Content)
+	}
+
+	// the main function of the translation is kept
+	gotReq = nil
+	if _, err := h.ConvertEntryPoints(&dst, nil); err != nil || gotReq != nil {
+		t.Errorf("ConvertEntryPoints() should keep the existing main, err = %v", err)
+	}
+
+	// fn main translated as a node of the crate package is not translated again, but moved to package main
+	dst = uniast.NewRepository("cli")
+	dstMod := uniast.NewModule("cli", ".", uniast.Golang)
+	dst.Modules["cli"] = dstMod
+	dstPkg := uniast.NewPackage("cli")
+	dstMod.Packages["cli"] = dstPkg
+	dstPkg.Functions["main"] = &uniast.Function{
+		Identity: uniast.NewIdentity("cli", "cli", "main"),
+		Content:  "func main() {\n\tfmt.Println(os.Args)\n}",
+	}
+	eps := h.DetectEntryPoints(&dst)
+	if _, err := h.ConvertEntryPoints(&dst, eps); err != nil || gotReq != nil {
+		t.Errorf("ConvertEntryPoints() should keep the translated main, err = %v", err)
+	}
+	if len(dstPkg.Functions) != 0 {
+		t.Errorf("main function should be moved out of the crate package, functions = %v", dstPkg.Functions)
+	}
+	if mainPkg := dstMod.Packages["main"]; mainPkg == nil || !mainPkg.IsMain || mainPkg.Functions["main"] == nil ||
+		mainPkg.Functions["main"].PkgPath != "main" || mainPkg.Functions["main"].File != "main.go" {
+		t.Errorf("main function should be moved to package main, packages = %v", dstMod.Packages)
+	}
+}
+
+func TestTransformer_RustMain(t *testing.T) {
+	src := uniast.NewRepository("cli")
+	mod := uniast.NewModule("cli", ".", uniast.Rust)
+	src.Modules["cli"] = mod
+	pkg := uniast.NewPackage("cli")
+	mod.Packages["cli"] = pkg
+	pkg.Functions["main"] = &uniast.Function{
+		Identity: uniast.NewIdentity("cli", "cli", "main"),
+		FileLine: uniast.FileLine{File: "src/main.rs", Line: 1},
+		Content: `fn main() {
+    let args: Vec<String> = std::env::args().collect();
+    println!("{}", args.len());
+}`,
+	}
+
+	var prompts []string
+	tr := NewTransformer(TranslateOptions{
+		SourceLanguage:     uniast.Rust,
+		TargetLanguage:     uniast.Golang,
+		TargetModuleName:   "github.com/example/cli",
+		GenerateEntryPoint: true,
+		LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			prompts = append(prompts, req.Prompt)
+			return &LLMTranslateResponse{TargetContent: "func main() {\n\tfmt.Println(len(os.Args))\n}"}, nil
+		},
+	})
+	dst, err := tr.Transform(context.Background(), &src)
+	if err != nil {
+		t.Fatalf("Transform() error = %v", err)
+	}
+	// fn main is translated once, as a node with the entry point notes
+	if len(prompts) != 1 {
+		t.Fatalf("LLM should be called once, got %d prompts", len(prompts))
+	}
+	for _, note := range []string{"os.Args", "os.Exit(code)", "placed in package main"} {
+		if !strings.Contains(prompts[0], note) {
+			t.Errorf("prompt should contain %q, got:\n%s", note, prompts[0])
+		}
+	}
+	dstMod := dst.Modules["github.com/example/cli"]
+	mainPkg := dstMod.Packages["main"]
+	if mainPkg == nil || !mainPkg.IsMain || mainPkg.Functions["main"] == nil {
+		t.Fatalf("main function should be in package main, packages = %v", dstMod.Packages)
+	}
+	if fn := mainPkg.Functions["main"]; fn.Content != "func main() {\n\tfmt.Println(len(os.Args))\n}" {
+		t.Errorf("main function = %q", fn.Content)
+	}
+	for pkgPath, pkg := range dstMod.Packages {
+		if pkgPath != "main" && pkg.Functions["main"] != nil {
+			t.Errorf("main function should not be kept in package %s", pkgPath)
+		}
+	}
+}
+
+func TestFrameworkIntegrator_Hertz(t *testing.T) {
+	src := uniast.NewRepository("express-app")
+	mod := uniast.NewModule("express-app", ".", uniast.TypeScript)
+	src.Modules["express-app"] = mod
+	pkg := uniast.NewPackage("src")
+	mod.Packages["src"] = pkg
+	pkg.Functions["registerRoutes"] = &uniast.Function{
+		Identity: uniast.NewIdentity("express-app", "src", "registerRoutes"),
+		Content: `export function registerRoutes(router: Router) {
+	router.get('/users/:id', getUser);
+	router.post('/users', createUser);
+}`,
+	}
+
+	dst := uniast.NewRepository("express-app")
+	p := NewPostProcessor(uniast.Golang, PostProcessOptions{
+		WebFramework:   "hertz",
+		GenerateConfig: true,
+		ModuleName:     "example.com/app",
+		OutputDir:      t.TempDir(),
+		SourceLanguage: uniast.TypeScript,
+		SourceRepo:     &src,
+	})
+	if _, err := p.Process(&dst); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	files := p.GetGeneratedFiles()
+	if !strings.Contains(files["cmd/main.go"], "server.Default(") {
+		t.Errorf("main.go should start a Hertz server, got:\n%s", files["cmd/main.go"])
+	}
+	routes := files["internal/router/routes.go"]
+	for _, line := range []string{
+		`func RegisterRoutes(h *server.Hertz) {`,
+		`api.GET("/users/:id", getUserHandler)`,
+		`api.POST("/users", createUserHandler)`,
+		`func createUserHandler(ctx context.Context, c *app.RequestContext) {`,
+	} {
+		if !strings.Contains(routes, line) {
+			t.Errorf("routes.go should contain %q, got:\n%s", line, routes)
+		}
+	}
+	if goMod := files["go.mod"]; !strings.Contains(goMod, "\tgithub.com/cloudwego/hertz v") {
+		t.Errorf("go.mod should require hertz, got:\n%s", goMod)
+	}
+}
+
+func TestPostProcessor_FrameworkDependencies(t *testing.T) {
+	for _, tt := range []struct {
+		lang      uniast.Language
+		framework string
+		file      string
+		want      []string
+	}{
+		{uniast.Golang, "gin", "go.mod", []string{"\tgithub.com/gin-gonic/gin v1.9.1\n"}},
+		{uniast.Golang, "echo", "go.mod", []string{"\tgithub.com/labstack/echo/v4 v"}},
+		{uniast.Rust, "actix", "Cargo.toml", []string{"\nactix-web = \"4\"\n", "\ntokio = "}},
+		{uniast.Rust, "axum", "Cargo.toml", []string{"\naxum = \"0.7\"\n", "\nserde = "}},
+		{uniast.Python, "fastapi", "requirements.txt", []string{"\nfastapi>=", "\nuvicorn>="}},
+		{uniast.Python, "flask", "requirements.txt", []string{"\nflask>=3.0.0\n"}},
+	} {
+		dst := uniast.NewRepository("app")
+		p := NewPostProcessor(tt.lang, PostProcessOptions{
+			WebFramework:   tt.framework,
+			GenerateConfig: true,
+			ModuleName:     "example.com/app",
+			OutputDir:      t.TempDir(),
+		})
+		if _, err := p.Process(&dst); err != nil {
+			t.Fatalf("Process() with %s error = %v", tt.framework, err)
+		}
+		content := p.GetGeneratedFiles()[tt.file]
+		for _, want := range tt.want {
+			if !strings.Contains(content, want) {
+				t.Errorf("%s for %s should contain %q, got:\n%s", tt.file, tt.framework, want, content)
+			}
+		}
+	}
+}
+
+func TestPostProcessor_GoInternalImports(t *testing.T) {
+	repo := uniast.NewRepository("app")
+	mod := uniast.NewModule("example.com/app", ".", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	loadID := uniast.NewIdentity(mod.Name, "example.com/app/service", "Load")
+	saveID := uniast.NewIdentity(mod.Name, "example.com/app/service", "Save")
+	mod.Packages["example.com/app/service"] = &uniast.Package{
+		PkgPath: "example.com/app/service",
+		Functions: map[string]*uniast.Function{
+			"Load": {Identity: loadID, FileLine: uniast.FileLine{File: "service/load.go"},
+				Content: "func Load() *config.Settings {\n\treturn util.Default(config.Path)\n}"},
+			"Health": {Identity: uniast.NewIdentity(mod.Name, "example.com/app/service", "Health"), FileLine: uniast.FileLine{File: "service/save.go"},
+				Content: "func Health() string {\n\tif health.Ok() {\n\t\treturn \"ok\"\n\t}\n\treturn \"/api/internal/health\"\n}"},
+			"Save": {Identity: saveID, FileLine: uniast.FileLine{File: "service/save.go"},
+				Content: "import (\n\tcfg \"github.com/other/lib/internal/config\"\n\t\"fmt\"\n)\n\nfunc Save(s cfg.Settings) {\n\tfmt.Println(s)\n}"},
+		},
+	}
+	mod.Files["service/load.go"] = &uniast.File{
+		Path:    "service/load.go",
+		Package: "example.com/app/service",
+		Imports: []uniast.Import{{Path: `"example.com/app/internal/util"`}, {Path: `"github.com/other/lib/internal/config"`}},
+	}
+	repo.Graph = map[string]*uniast.Node{
+		loadID.Full(): {Identity: loadID, Type: uniast.FUNC, Dependencies: []uniast.Relation{
+			{Identity: uniast.NewIdentity(mod.Name, "example.com/app/internal/util", "Default")},
+			{Identity: uniast.NewIdentity("github.com/other/lib", "github.com/other/lib/internal/config", "Settings")},
+		}},
+	}
+
+	NewPostProcessor(uniast.Golang, PostProcessOptions{ModuleName: mod.Name}).fixGoInternalImports(&repo)
+
+	if imports := mod.Files["service/load.go"].Imports; len(imports) != 1 || imports[0].Path != `"example.com/app/internal/util"` {
+		t.Errorf("file imports = %v, want only the internal package of the module", imports)
+	}
+	if deps := repo.Graph[loadID.Full()].Dependencies; len(deps) != 1 || deps[0].PkgPath != "example.com/app/internal/util" {
+		t.Errorf("dependencies = %v, want only the internal package of the module", deps)
+	}
+	for id, want := range map[string]string{
+		"Load": "// TODO: internal package github.com/other/lib/internal/config, replace with a public equivalent\n" +
+			"func Load() interface{} {\n\treturn util.Default(config.Path)\n}",
+		"Save": "// TODO: internal package github.com/other/lib/internal/config, replace with a public equivalent\n" +
+			"import (\n\t\"fmt\"\n)\n\nfunc Save(s interface{}) {\n\tfmt.Println(s)\n}",
+		// a string line in a body is not an import
+		"Health": "func Health() string {\n\tif health.Ok() {\n\t\treturn \"ok\"\n\t}\n\treturn \"/api/internal/health\"\n}",
+	} {
+		if got := mod.Packages["example.com/app/service"].Functions[id].Content; got != want {
+			t.Errorf("%s content = %q, want %q", id, got, want)
+		}
+	}
+
+	// only the type positions are replaced, the values and calls are kept
+	removed := []uniast.Import{{Path: `"github.com/other/lib/internal/config"`}}
+	todo := "// TODO: internal package github.com/other/lib/internal/config, replace with a public equivalent\n"
+	for content, want := range map[string]string{
+		"var items []config.Item":                                       todo + "var items []interface{}",
+		"type Cache struct {\n\tm map[string]*config.Item\n}":           todo + "type Cache struct {\n\tm map[string]interface{}\n}",
+		"func New() any {\n\treturn &config.Item{Name: config.Name}\n}": todo + "func New() any {\n\treturn &interface{}{Name: config.Name}\n}",
+		"func Get(v any) {\n\t_ = v.(config.Item)\n}":                   todo + "func Get(v any) {\n\t_ = v.(interface{})\n}",
+		"func Reset() {\n\tconfig.Reset(config.Default)\n}":             todo + "func Reset() {\n\tconfig.Reset(config.Default)\n}",
+		"func Len(s string) int {\n\treturn len(s)\n}":                  "func Len(s string) int {\n\treturn len(s)\n}",
+	} {
+		if got := replaceGoPackageRefs(content, removed); got != want {
+			t.Errorf("replaceGoPackageRefs(%q) = %q, want %q", content, got, want)
+		}
+	}
+
+	for _, tt := range []struct {
+		importer, path string
+		want           bool
+	}{
+		{"example.com/app/service", "example.com/app/internal/util", true},
+		{"example.com/app", "example.com/app/internal/util", true},
+		{"example.com/app/internal/util/sub", "example.com/app/internal/util", true},
+		{"example.com/app/service", "example.com/app/service/internal/x", true},
+		{"example.com/app/model", "example.com/app/service/internal/x", false},
+		{"example.com/app", "github.com/other/lib/internal/config", false},
+		{"example.com/app", "github.com/other/lib/config", true},
+	} {
+		if got := canImportGoPackage(tt.importer, tt.path); got != tt.want {
+			t.Errorf("canImportGoPackage(%q, %q) = %v, want %v", tt.importer, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNodeTranslator_Go2JavaMethodName(t *testing.T) {
+	translator := NewNodeTranslator(TranslateOptions{SourceLanguage: uniast.Golang, TargetLanguage: uniast.Java}, nil)
+	if got := translator.convertFunctionName("User.GetName", true); got != "User.getName" {
+		t.Errorf("convertFunctionName() = %q, want %q", got, "User.getName")
+	}
+}
+
+func TestNamingConversions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		toPascal string
+		toCamel  string
+		toSnake  string
+	}{
+		{"simple", "hello", "Hello", "hello", "hello"},
+		{"camelCase", "helloWorld", "HelloWorld", "helloWorld", "hello_world"},
+		{"PascalCase", "HelloWorld", "HelloWorld", "helloWorld", "hello_world"},
+		{"snake_case", "hello_world", "HelloWorld", "helloWorld", "hello_world"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toPascalCase(tt.input); got != tt.toPascal {
+				t.Errorf("toPascalCase(%q) = %q, want %q", tt.input, got, tt.toPascal)
+			}
+			if got := toCamelCase(tt.input); got != tt.toCamel {
+				t.Errorf("toCamelCase(%q) = %q, want %q", tt.input, got, tt.toCamel)
+			}
+			if got := toSnakeCase(tt.input); got != tt.toSnake {
+				t.Errorf("toSnakeCase(%q) = %q, want %q", tt.input, got, tt.toSnake)
+			}
+		})
+	}
+}
+
+func TestPromptBuilder(t *testing.T) {
+	hints := NewTypeHints(uniast.Java, uniast.Golang)
+	builder := NewPromptBuilder(uniast.Java, uniast.Golang, hints)
+
+	req := &LLMTranslateRequest{
+		SourceLanguage: uniast.Java,
+		TargetLanguage: uniast.Golang,
+		NodeType:       uniast.FUNC,
+		SourceContent:  "public void hello() { }",
+		Identity: uniast.Identity{
+			ModPath: "com.example",
+			PkgPath: "com.example.test",
+			Name:    "hello",
+		},
+	}
+
+	// Test function prompt
+	prompt := builder.BuildFunctionPrompt(req)
+	if !strings.Contains(prompt, "java") {
+		t.Error("function prompt should contain 'java'")
+	}
+	if !strings.Contains(prompt, "go") {
+		t.Error("function prompt should contain 'go'")
+	}
+	if !strings.Contains(prompt, "public void hello()") {
+		t.Error("function prompt should contain source content")
+	}
+
+	// Test type prompt
+	req.NodeType = uniast.TYPE
+	req.SourceContent = "public class User { }"
+	typePrompt := builder.BuildTypePrompt(req)
+	if !strings.Contains(typePrompt, "type/class") {
+		t.Error("type prompt should contain 'type/class'")
+	}
+
+	// Test var prompt
+	req.NodeType = uniast.VAR
+	req.SourceContent = "public static final int MAX = 100;"
+	varPrompt := builder.BuildVarPrompt(req)
+	if !strings.Contains(varPrompt, "variable/constant") {
+		t.Error("var prompt should contain 'variable/constant'")
+	}
+}
+
+func TestNodeTranslator(t *testing.T) {
+	opts := TranslateOptions{
+		SourceLanguage: uniast.Java,
+		TargetLanguage: uniast.Golang,
+		LLMTranslator:  mockLLMTranslator,
+	}
+
+	hints := NewTypeHints(uniast.Java, uniast.Golang)
+	translator := NewNodeTranslator(opts, hints)
+
+	// Create test context
+	srcRepo := createTestJavaRepo()
+	targetRepo := uniast.NewRepository("github.com/example/test")
+	targetMod := uniast.NewModule("github.com/example/test", "", uniast.Golang)
+	targetPkg := &uniast.Package{
+		PkgPath:   "model",
 		Functions: make(map[string]*uniast.Function),
 		Types:     make(map[string]*uniast.Type),
 		Vars:      make(map[string]*uniast.Var),
 	}
 
-	pkg.Types["User"] = &uniast.Type{
+	tctx := &TranslateContext{
+		SourceRepo:      srcRepo,
+		TargetRepo:      &targetRepo,
+		Module:          targetMod,
+		Package:         targetPkg,
+		TranslatedNodes: make(map[string]uniast.Identity),
+	}
+
+	// Test TranslateType
+	srcType := &uniast.Type{
 		Exported: true,
 		TypeKind: uniast.TypeKindStruct,
 		Identity: uniast.Identity{
@@ -339,11 +1341,1191 @@ func createTestJavaRepo() *uniast.Repository {
 			PkgPath: "com.example.model",
 			Name:    "User",
 		},
+		FileLine: uniast.FileLine{
+			File: "User.java",
+			Line: 1,
+		},
 		Content: "public class User { private String name; }",
 	}
 
-	mod.Packages["com.example.model"] = pkg
-	repo.Modules["com.example:test:1.0"] = mod
+	ctx := context.Background()
+	targetType, err := translator.TranslateType(ctx, srcType, tctx)
+	if err != nil {
+		t.Fatalf("TranslateType failed: %v", err)
+	}
+	if targetType.Name != "User" {
+		t.Errorf("unexpected type name: %s", targetType.Name)
+	}
 
-	return &repo
+	// Test TranslateFunction
+	srcFunc := &uniast.Function{
+		Exported: true,
+		IsMethod: false,
+		Identity: uniast.Identity{
+			ModPath: "com.example:test:1.0",
+			PkgPath: "com.example.service",
+			Name:    "getUser",
+		},
+		FileLine: uniast.FileLine{
+			File: "UserService.java",
+			Line: 10,
+		},
+		Content: "public User getUser(String id) { return null; }",
+	}
+
+	targetFunc, err := translator.TranslateFunction(ctx, srcFunc, tctx)
+	if err != nil {
+		t.Fatalf("TranslateFunction failed: %v", err)
+	}
+	if targetFunc.Name != "GetUser" {
+		t.Errorf("unexpected function name: %s", targetFunc.Name)
+	}
+}
+
+// createTestJavaRepo creates a test Java repository
+func createTestJavaRepo() *uniast.Repository {
+	repo := uniast.NewRepository("com.example:test:1.0")
+
+	mod := uniast.NewModule("com.example:test:1.0", "src/main/java", uniast.Java)
+
+	pkg := &uniast.Package{
+		PkgPath:   "com.example.model",
+		Functions: make(map[string]*uniast.Function),
+		Types:     make(map[string]*uniast.Type),
+		Vars:      make(map[string]*uniast.Var),
+	}
+
+	pkg.Types["User"] = &uniast.Type{
+		Exported: true,
+		TypeKind: uniast.TypeKindStruct,
+		Identity: uniast.Identity{
+			ModPath: "com.example:test:1.0",
+			PkgPath: "com.example.model",
+			Name:    "User",
+		},
+		Content: "public class User { private String name; }",
+	}
+
+	mod.Packages["com.example.model"] = pkg
+	repo.Modules["com.example:test:1.0"] = mod
+
+	return &repo
+}
+
+func TestTranslateComment(t *testing.T) {
+	javadoc := `/**
+ * Finds a user by id.
+ *
+ * @param id the user id
+ * @return the user, or null if absent
+ * @throws IOException if the store is unreachable
+ */`
+	got := TranslateComment(javadoc, uniast.Java, uniast.Golang)
+	want := `// Finds a user by id.
+//
+// Parameters:
+//   - id: the user id
+//
+// Returns the user, or null if absent
+//
+// It returns an error (IOException) if the store is unreachable`
+	if got != want {
+		t.Errorf("Java -> Go:\n%s\nwant:\n%s", got, want)
+	}
+
+	docstring := `"""Add two numbers.
+
+    Args:
+        a: first number
+        b: second number
+
+    Returns:
+        the sum
+    """`
+	got = TranslateComment(docstring, uniast.Python, uniast.Java)
+	want = `/**
+ * Add two numbers.
+ *
+ * @param a first number
+ * @param b second number
+ * @return the sum
+ */`
+	if got != want {
+		t.Errorf("Python -> Java:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = TranslateComment("// Load loads the config.", uniast.Golang, uniast.Python)
+	if got != `"""Load loads the config."""` {
+		t.Errorf("Go -> Python: %s", got)
+	}
+	if TranslateComment("  ", uniast.Java, uniast.Golang) != "" {
+		t.Error("empty comment should stay empty")
+	}
+}
+
+func TestNodeTranslator_DocComment(t *testing.T) {
+	var prompt string
+	translator := NewNodeTranslator(TranslateOptions{
+		SourceLanguage: uniast.Python,
+		TargetLanguage: uniast.Golang,
+		LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			prompt = req.Prompt
+			return &LLMTranslateResponse{TargetContent: "func AddNumbers() {}"}, nil
+		},
+	}, NewTypeHints(uniast.Python, uniast.Golang))
+
+	src := &uniast.Function{
+		Exported: true,
+		Identity: uniast.Identity{PkgPath: "calc", Name: "add_numbers"},
+		Content:  "def add_numbers(a, b):\n    \"\"\"Add two numbers.\"\"\"\n    return a + b",
+	}
+	srcRepo := uniast.NewRepository("r")
+	tctx := NewTranslateContext(&srcRepo, nil, uniast.NewModule("m", ".", uniast.Golang), uniast.NewPackage("calc"))
+	if _, err := translator.TranslateFunction(context.Background(), src, tctx); err != nil {
+		t.Fatalf("TranslateFunction failed: %v", err)
+	}
+	if !strings.Contains(prompt, "// AddNumbers add two numbers.") {
+		t.Errorf("prompt should contain the converted doc comment, got:\n%s", prompt)
+	}
+}
+
+func TestParseBuildErrors(t *testing.T) {
+	tests := []struct {
+		lang   uniast.Language
+		output string
+		want   []BuildError
+	}{
+		{uniast.Golang, "# example.com/demo/model\nmodel/user.go:12:2: could not import example.com/demo/util (no required module provides package)\n./main.go:3:5: undefined: foo\n", []BuildError{
+			{File: "model/user.go", Line: 12, Message: "could not import example.com/demo/util (no required module provides package)"},
+			{File: "main.go", Line: 3, Message: "undefined: foo"},
+		}},
+		{uniast.Rust, "error[E0412]: cannot find type `Foo` in this scope\n  --> src/lib.rs:7:12\n   |\nsrc/model.rs:3:5: error[E0425]: cannot find value `x` in this scope\nsrc/model.rs:4:5: warning: unused variable\n", []BuildError{
+			{File: "src/lib.rs", Line: 7, Message: "error[E0412]: cannot find type `Foo` in this scope"},
+			{File: "src/model.rs", Line: 3, Message: "error[E0425]: cannot find value `x` in this scope"},
+		}},
+		{uniast.Java, "[ERROR] /out/src/main/java/User.java:[12,5] cannot find symbol\nsrc/main/java/Order.java:8: error: cannot find symbol\n", []BuildError{
+			{File: "src/main/java/User.java", Line: 12, Message: "cannot find symbol"},
+			{File: "src/main/java/Order.java", Line: 8, Message: "cannot find symbol"},
+		}},
+	}
+	for _, tt := range tests {
+		got := ParseBuildErrors(tt.lang, "/out", tt.output)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("ParseBuildErrors(%s) = %v, want %v", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestValidateBuild(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	pkg := srcRepo.Modules["com.example:test:1.0"].Packages["com.example.model"]
+	pkg.Types["Order"] = &uniast.Type{
+		Exported: true,
+		TypeKind: uniast.TypeKindStruct,
+		Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: "Order"},
+		Content:  "public class Order { private User user; }",
+	}
+
+	var prompts []string
+	opts := TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/example/test",
+		LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			switch {
+			case req.Identity.Name == "User":
+				return &LLMTranslateResponse{TargetContent: "type User struct {\n\tname string\n}"}, nil
+			case req.BuildError != "":
+				prompts = append(prompts, req.Prompt)
+				return &LLMTranslateResponse{TargetContent: "type Order struct {\n\tuser *User\n}"}, nil
+			default:
+				return &LLMTranslateResponse{TargetContent: "type Order struct {\n\tuser *Usr\n}"}, nil
+			}
+		},
+	}
+	ctx := context.Background()
+	dstRepo, err := TranslateAST(ctx, srcRepo, opts)
+	if err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+
+	outputDir := t.TempDir()
+	goFile := filepath.Join(outputDir, "model", "model.go")
+	write := func(repo *uniast.Repository) error {
+		var contents []string
+		for _, typ := range repo.AllTypes {
+			contents = append(contents, typ.Content)
+		}
+		sort.Strings(contents)
+		if err := os.MkdirAll(filepath.Dir(goFile), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(goFile, []byte("package model\n\n"+strings.Join(contents, "\n\n")+"\n"), 0644)
+	}
+	var builds int
+	build := func(ctx context.Context, dir string) (string, error) {
+		builds++
+		data, err := os.ReadFile(goFile)
+		if err != nil {
+			return "", err
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			if strings.Contains(line, "Usr") {
+				return fmt.Sprintf("# model\nmodel/model.go:%d:8: undefined: Usr\n", i+1), fmt.Errorf("exit status 1")
+			}
+		}
+		return "", nil
+	}
+
+	got, errs, err := ValidateBuild(ctx, srcRepo, dstRepo, opts, ValidateBuildOptions{OutputDir: outputDir, Write: write, Build: build})
+	if err != nil {
+		t.Fatalf("ValidateBuild failed: %v", err)
+	}
+	if len(errs) != 0 || builds != 2 {
+		t.Errorf("expect the build to pass on the 2nd attempt, got %d builds, errors %v", builds, errs)
+	}
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "Previous translation caused build error: model/model.go:4: undefined: Usr. Fix it.") {
+		t.Errorf("expect only Order to be re-translated with the build error, got prompts %q", prompts)
+	}
+	if order := got.Modules["github.com/example/test"].Packages["model"].Types["Order"]; order == nil || strings.Contains(order.Content, "Usr") {
+		t.Errorf("expect Order to be fixed, got %+v", order)
+	}
+
+	// the build never passes, so it stops after MaxRetry re-translations
+	builds = 0
+	opts.LLMTranslator = func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+		return &LLMTranslateResponse{TargetContent: "type Order struct {\n\tuser *Usr\n}"}, nil
+	}
+	_, errs, err = ValidateBuild(ctx, srcRepo, dstRepo, opts, ValidateBuildOptions{OutputDir: outputDir, Write: write, Build: build, MaxRetry: 2})
+	if err != nil {
+		t.Fatalf("ValidateBuild failed: %v", err)
+	}
+	if builds != 3 || len(errs) != 1 {
+		t.Errorf("expect 3 builds and 1 error left, got %d builds, errors %v", builds, errs)
+	}
+}
+
+func TestPromptBuilder_Batch(t *testing.T) {
+	builder := NewPromptBuilder(uniast.Java, uniast.Golang, NewTypeHints(uniast.Java, uniast.Golang))
+	dep := DependencyHint{
+		SourceIdentity: uniast.Identity{ModPath: "m", PkgPath: "p", Name: "Status"},
+		TargetIdentity: uniast.Identity{ModPath: "m", PkgPath: "p", Name: "Status"},
+	}
+	prompt := builder.BuildBatchPrompt([]*LLMTranslateRequest{
+		{NodeType: uniast.VAR, Identity: uniast.Identity{Name: "MAX_SIZE"}, SourceContent: "static final int MAX_SIZE = 10;", Dependencies: []DependencyHint{dep}},
+		{NodeType: uniast.VAR, Identity: uniast.Identity{Name: "DEFAULT"}, SourceContent: "static final Status DEFAULT = Status.OK;", Dependencies: []DependencyHint{dep}},
+	})
+	for _, want := range []string{"Translate the following 2 java variables/constants to go", "## Node 1: `MAX_SIZE`", "## Node 2: `DEFAULT`", "### Node <number>"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("batch prompt should contain %q, got:\n%s", want, prompt)
+		}
+	}
+	if strings.Count(prompt, "`Status` -> `Status`") != 1 {
+		t.Errorf("dependencies of the batch should be deduplicated, got:\n%s", prompt)
+	}
+
+	got := ParseBatchResponse("### Node 1\n```go\nconst MaxSize = 10\n```\n### Node 3\nvar Default = StatusOK\n")
+	if len(got) != 3 || got[0] == nil || got[0].TargetContent != "const MaxSize = 10" || got[1] != nil || got[2] == nil || got[2].TargetContent != "var Default = StatusOK" {
+		t.Errorf("unexpected ParseBatchResponse result: %+v", got)
+	}
+}
+
+func TestTranslateAST_Batch(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	pkg := srcRepo.Modules["com.example:test:1.0"].Packages["com.example.model"]
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		pkg.Vars[name] = &uniast.Var{
+			IsExported: true,
+			IsConst:    true,
+			Identity:   uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: name},
+			Content:    "static final int " + name + " = 1;",
+		}
+	}
+	pkg.Vars["BIG"] = &uniast.Var{
+		Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: "BIG"},
+		Content:  "static final String BIG = \"" + strings.Repeat("x", maxBatchNodeChars) + "\";",
+	}
+
+	var mu sync.Mutex
+	var batches, singles []string
+	result := &TranslateResult{}
+	_, err := TranslateAST(context.Background(), srcRepo, TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/example/test",
+		BatchSize:        2,
+		Result:           result,
+		LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(req.Batch) == 0 {
+				singles = append(singles, req.Identity.Name)
+				return mockLLMTranslator(ctx, req)
+			}
+			var sb strings.Builder
+			var names []string
+			for i, r := range req.Batch {
+				names = append(names, r.Identity.Name)
+				// the LLM omits node D, which is translated alone later
+				if r.Identity.Name == "D" {
+					continue
+				}
+				fmt.Fprintf(&sb, "### Node %d\nconst %s = 1\n", i+1, r.Identity.Name)
+			}
+			batches = append(batches, strings.Join(names, ","))
+			return &LLMTranslateResponse{TargetContent: sb.String()}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+	sort.Strings(batches)
+	sort.Strings(singles)
+	if fmt.Sprint(batches) != "[A,B C,D E]" {
+		t.Errorf("expect small vars to be translated in batches of 2, got %v", batches)
+	}
+	if fmt.Sprint(singles) != "[BIG D User]" {
+		t.Errorf("expect BIG, D and the type User to be translated alone, got %v", singles)
+	}
+	if len(result.TranslatedIDs) != 7 {
+		t.Errorf("expect 7 nodes to be translated, got %d", len(result.TranslatedIDs))
+	}
+
+	// the nodes of a failed batch are recorded and translated one by one
+	batches, singles = nil, nil
+	result = &TranslateResult{}
+	_, err = TranslateAST(context.Background(), srcRepo, TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/example/test",
+		BatchSize:        2,
+		Result:           result,
+		LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(req.Batch) == 0 {
+				singles = append(singles, req.Identity.Name)
+				return mockLLMTranslator(ctx, req)
+			}
+			if req.Batch[0].Identity.Name == "C" {
+				return nil, fmt.Errorf("rate limited")
+			}
+			var sb strings.Builder
+			for i, r := range req.Batch {
+				fmt.Fprintf(&sb, "### Node %d\nconst %s = 1\n", i+1, r.Identity.Name)
+			}
+			return &LLMTranslateResponse{TargetContent: sb.String()}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+	sort.Strings(singles)
+	if fmt.Sprint(singles) != "[BIG C D User]" {
+		t.Errorf("expect the nodes of the failed batch to be translated alone, got %v", singles)
+	}
+	if len(result.FailedBatches) != 1 || fmt.Sprint(result.FailedBatches[0].NodeIDs) != "[com.example:test:1.0?com.example.model#C com.example:test:1.0?com.example.model#D]" ||
+		!strings.Contains(result.FailedBatches[0].Err, "rate limited") {
+		t.Errorf("failed batches = %+v", result.FailedBatches)
+	}
+	if len(result.TranslatedIDs) != 7 || len(result.FailedNodes) != 0 {
+		t.Errorf("expect 7 nodes to be translated, got %d (%d failed)", len(result.TranslatedIDs), len(result.FailedNodes))
+	}
+}
+
+func TestTranslateAST_ContentCache(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	mod := srcRepo.Modules["com.example:test:1.0"]
+	for _, pkgPath := range []string{"com.example.model", "com.example.util"} {
+		pkg := mod.Packages[uniast.PkgPath(pkgPath)]
+		if pkg == nil {
+			pkg = uniast.NewPackage(uniast.PkgPath(pkgPath))
+			mod.Packages[uniast.PkgPath(pkgPath)] = pkg
+		}
+		pkg.Functions["parseError"] = &uniast.Function{
+			Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: pkgPath, Name: "parseError"},
+			Content:  "static String parseError(Exception e) { return e.getMessage(); }",
+		}
+	}
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	result := &TranslateResult{}
+	targetRepo, err := TranslateAST(context.Background(), srcRepo, TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/example/test",
+		Parallel:         true,
+		Concurrency:      2,
+		Result:           result,
+		LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			mu.Lock()
+			calls[req.Identity.Name]++
+			mu.Unlock()
+			return mockLLMTranslator(ctx, req)
+		},
+	})
+	if err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+	if calls["parseError"] != 1 || result.CacheHits != 1 {
+		t.Errorf("expect parseError to be sent to LLM once with 1 cache hit, got %d calls and %d hits", calls["parseError"], result.CacheHits)
+	}
+	pkgs := targetRepo.Modules["github.com/example/test"].Packages
+	for _, pkgPath := range []uniast.PkgPath{"model", "util"} {
+		if pkgs[pkgPath] == nil || len(pkgs[pkgPath].Functions) != 1 {
+			t.Errorf("expect parseError translated in package %s, got %+v", pkgPath, pkgs[pkgPath])
+		}
+	}
+}
+
+func TestTranslateAST_ContentCacheOwner(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	pkg := srcRepo.Modules["com.example:test:1.0"].Packages["com.example.model"]
+	pkg.Types["Product"] = &uniast.Type{
+		Exported: true,
+		TypeKind: uniast.TypeKindStruct,
+		Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: "Product"},
+		Content:  "public class Product { private String name; }",
+	}
+	for _, typ := range []string{"User", "Product"} {
+		pkg.Functions[typ+".getName"] = &uniast.Function{
+			Exported: true,
+			IsMethod: true,
+			Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: typ + ".getName"},
+			Content:  "public String getName() { return name; }",
+		}
+	}
+
+	calls := make(map[string]int)
+	result := &TranslateResult{}
+	if _, err := TranslateAST(context.Background(), srcRepo, TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/example/test",
+		Result:           result,
+		LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			calls[req.Identity.Name]++
+			return mockLLMTranslator(ctx, req)
+		},
+	}); err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+	// the same body on different types is translated with its own receiver
+	if calls["User.getName"] != 1 || calls["Product.getName"] != 1 || result.CacheHits != 0 {
+		t.Errorf("expect each getName to be sent to LLM without cache hits, got calls %v and %d hits", calls, result.CacheHits)
+	}
+}
+
+func TestTranslateContext_PackageContext(t *testing.T) {
+	global := NewTranslateContext(nil, nil, nil, nil)
+	a := global.packageContext(nil, uniast.NewPackage("a"))
+	b := a.packageContext(nil, uniast.NewPackage("b"))
+	if a.mutex() != global.mutex() || b.mutex() != global.mutex() {
+		t.Fatalf("expect the package contexts to share the mutex of the global context")
+	}
+	id := uniast.NewIdentity("m", "a", "A")
+	a.AddTranslatedContent(id, "func A() {}")
+	if got, ok := b.GetTranslatedContent(id); !ok || got != "func A() {}" {
+		t.Errorf("GetTranslatedContent() = %q, %v, want the content added by another package", got, ok)
+	}
+
+	// packages translated at once write the shared maps concurrently, run with -race to check it
+	var wg sync.WaitGroup
+	for i, c := range []*TranslateContext{a, b} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.AddTranslatedNode(uniast.NewIdentity("m", "p", fmt.Sprintf("N%d_%d", i, j)), id)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(global.TranslatedNodes) != 200 {
+		t.Errorf("expect 200 translated nodes, got %d", len(global.TranslatedNodes))
+	}
+}
+
+func TestTranslateAST_OnNodeTranslated(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		type node struct {
+			src, dst uniast.Identity
+			content  string
+		}
+		// not locked: the callback must be serialized within a package
+		var nodes []node
+		result := &TranslateResult{}
+		targetRepo, err := TranslateAST(context.Background(), createTestJavaRepo(), TranslateOptions{
+			SourceLanguage:   uniast.Java,
+			TargetLanguage:   uniast.Golang,
+			TargetModuleName: "github.com/example/test",
+			Parallel:         parallel,
+			Concurrency:      4,
+			Result:           result,
+			LLMTranslator:    mockLLMTranslator,
+			OnNodeTranslated: func(srcID, dstID uniast.Identity, content string) {
+				nodes = append(nodes, node{srcID, dstID, content})
+			},
+		})
+		if err != nil {
+			t.Fatalf("TranslateAST failed: %v", err)
+		}
+		if len(nodes) == 0 || len(nodes) != len(result.TranslatedIDs) {
+			t.Fatalf("parallel=%v: OnNodeTranslated called %d times, want %d", parallel, len(nodes), len(result.TranslatedIDs))
+		}
+		for _, n := range nodes {
+			if _, ok := result.TranslatedIDs[n.src.Full()]; !ok {
+				t.Errorf("parallel=%v: %s is not a translated node", parallel, n.src.Full())
+			}
+			dst := targetRepo.GetNode(n.dst)
+			if dst == nil {
+				t.Errorf("parallel=%v: target node %s not found", parallel, n.dst.Full())
+			} else if n.content == "" || !strings.Contains(n.content, "Translated from java") {
+				t.Errorf("parallel=%v: content of %s = %q", parallel, n.src.Full(), n.content)
+			}
+		}
+	}
+}
+
+func TestTranslateAST_PackageContext(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	pkg := srcRepo.Modules["com.example:test:1.0"].Packages["com.example.model"]
+	pkg.Functions["newUser"] = &uniast.Function{
+		Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: "newUser"},
+		Content:  "static User newUser(String name) { return new User(name); }",
+	}
+
+	for _, window := range []int{0, 5} {
+		var mu sync.Mutex
+		contexts := make(map[string][]*uniast.Node)
+		prompts := make(map[string]string)
+		_, err := TranslateAST(context.Background(), srcRepo, TranslateOptions{
+			SourceLanguage:        uniast.Java,
+			TargetLanguage:        uniast.Golang,
+			TargetModuleName:      "github.com/example/test",
+			ContextWindowPkgNodes: window,
+			LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+				mu.Lock()
+				contexts[req.Identity.Name] = req.PackageContext
+				prompts[req.Identity.Name] = req.Prompt
+				mu.Unlock()
+				return &LLMTranslateResponse{TargetContent: "type User struct {\n\tname string\n}"}, nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("TranslateAST failed: %v", err)
+		}
+		if len(contexts["User"]) != 0 {
+			t.Errorf("window %d: expect no package context for the first type, got %d nodes", window, len(contexts["User"]))
+		}
+		if window == 0 {
+			if len(contexts["newUser"]) != 0 || strings.Contains(prompts["newUser"], "## Package Context") {
+				t.Errorf("expect no package context without ContextWindowPkgNodes, got:\n%s", prompts["newUser"])
+			}
+			continue
+		}
+		if ctx := contexts["newUser"]; len(ctx) != 1 || ctx[0].Identity.Name != "User" || ctx[0].Type != uniast.TYPE {
+			t.Fatalf("expect the translated type User as package context of newUser, got %v", ctx)
+		}
+		if !strings.Contains(prompts["newUser"], "## Package Context\n") || !strings.Contains(prompts["newUser"], "type User struct {") {
+			t.Errorf("expect the prompt of newUser to show User as package context, got:\n%s", prompts["newUser"])
+		}
+	}
+}
+
+func TestPromptBuilder_BuildContextSection(t *testing.T) {
+	repo := uniast.NewRepository("m")
+	mod := uniast.NewModule("m", ".", uniast.Golang)
+	repo.SetModule("m", mod)
+	pkg := uniast.NewPackage("p")
+	mod.Packages["p"] = pkg
+	pkg.Types["Repo"] = &uniast.Type{Identity: uniast.NewIdentity("m", "p", "Repo"), Content: "type Repo interface {\n\tGet(id int) string\n}"}
+	pkg.Functions["NewRepo"] = &uniast.Function{Identity: uniast.NewIdentity("m", "p", "NewRepo"), Content: "func NewRepo() Repo {\n\treturn nil\n}"}
+	pkg.Vars["big"] = &uniast.Var{Identity: uniast.NewIdentity("m", "p", "big"), Content: "var big = []int{\n" + strings.Repeat("\t1,\n", 20) + "}"}
+
+	nodes := NewContextualTranslator(2).PackageContext(mod, pkg)
+	if len(nodes) != 2 || nodes[0].Identity.Name != "Repo" || nodes[1].Identity.Name != "NewRepo" {
+		t.Fatalf("expect the type then the function within the window, got %v", nodes)
+	}
+	if NewContextualTranslator(0).PackageContext(mod, pkg) != nil {
+		t.Error("expect no package context for a window of 0")
+	}
+
+	builder := NewPromptBuilder(uniast.Java, uniast.Golang, NewTypeHints(uniast.Java, uniast.Golang))
+	section := builder.BuildContextSection(NewContextualTranslator(10).PackageContext(mod, pkg))
+	for _, want := range []string{
+		"## Package Context\n",
+		"- `Repo` (TYPE):\n```go\ntype Repo interface {\n\tGet(id int) string\n}\n```\n",
+		"- `NewRepo` (FUNC):\n```go\nfunc NewRepo() Repo\n```\n",
+		"\t1,\n...\n```\n",
+	} {
+		if !strings.Contains(section, want) {
+			t.Errorf("expect the context section to contain %q, got:\n%s", want, section)
+		}
+	}
+	if section := builder.BuildContextSection(nil); section != "" {
+		t.Errorf("expect an empty context section without siblings, got %q", section)
+	}
+}
+
+func TestQualityScorer(t *testing.T) {
+	scorer := NewQualityScorer()
+	src := `public String getUserName(int userId) {
+    User user = repository.findById(userId);
+    return user.getName();
+}`
+	good := `func (s *UserService) GetUserName(userID int) string {
+	user := s.repository.FindByID(userID)
+	return user.GetName()
+}`
+	report := scorer.Score(src, good, uniast.Java, uniast.Golang)
+	if report.Total < 90 || report.Syntax != 100 || report.Structure != 100 || report.NoMarkdown != 100 {
+		t.Errorf("expect a good translation to score high, got %+v", report)
+	}
+
+	bad := "```go\nfunc GetUserName(userID int) string {\n\treturn \"\"\n```"
+	report = scorer.Score(src, bad, uniast.Java, uniast.Golang)
+	if report.Total >= 60 || report.Syntax != 0 || report.NoMarkdown != 0 || report.Structure == 100 || report.Identifiers == 100 {
+		t.Errorf("expect a bad translation to score low, got %+v", report)
+	}
+
+	report = scorer.Score("package com.example;\n\nclass A {}", "type A struct{}", uniast.Java, uniast.Golang)
+	if report.PackageDecl != 0 {
+		t.Errorf("expect missing package declaration to be reported, got %+v", report)
+	}
+	if report := scorer.Score(src, " ", uniast.Java, uniast.Golang); report.Total != 0 {
+		t.Errorf("expect empty translation to score 0, got %+v", report)
+	}
+}
+
+func TestTranslateAST_MinQualityScore(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	var calls int
+	result := &TranslateResult{}
+	targetRepo, err := TranslateAST(context.Background(), srcRepo, TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/example/test",
+		MinQualityScore:  80,
+		MaxRetryPerNode:  2,
+		Result:           result,
+		LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			calls++
+			if calls == 1 {
+				return &LLMTranslateResponse{TargetContent: "```go\ntype User struct {\n```"}, nil
+			}
+			return &LLMTranslateResponse{TargetContent: "type User struct {\n\tname string\n}"}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+	if calls != 2 || len(result.FailedNodes) != 0 {
+		t.Errorf("expect the low quality translation to be retried, got %d calls, failed %+v", calls, result.FailedNodes)
+	}
+	if user := targetRepo.Modules["github.com/example/test"].Packages["model"].Types["User"]; user == nil || strings.Contains(user.Content, "```") {
+		t.Errorf("expect User to be the retried translation, got %+v", user)
+	}
+}
+
+func TestAnnotateSource(t *testing.T) {
+	src := "public int add(int a, int b) {\n\n    return a + b;\n}"
+	target := "// Add returns the sum\nfunc Add(a, b int) int {\n\treturn a + b\n}"
+	got := AnnotateSource(target, src, uniast.Java, uniast.Golang)
+	want := "// Original java: public int add(int a, int b) {\n// Original java:\n// Original java:     return a + b;\n// Original java: }\n\n" + target
+	if got != want {
+		t.Errorf("AnnotateSource() =\n%s\nwant\n%s", got, want)
+	}
+	if stripped := StripSourceAnnotations(got); stripped != target {
+		t.Errorf("StripSourceAnnotations() =\n%s\nwant\n%s", stripped, target)
+	}
+
+	got = AnnotateSource("def add(a, b):\n    return a + b", src, uniast.Java, uniast.Python)
+	if !strings.HasPrefix(got, "# Original java: public int add") {
+		t.Errorf("python annotations should be # comments:\n%s", got)
+	}
+
+	long := strings.Repeat("x++;\n", maxAnnotatedSourceLines+5)
+	got = AnnotateSource("x += 1", long, uniast.Java, uniast.Golang)
+	if n := strings.Count(got, "// Original java: x++;"); n != maxAnnotatedSourceLines {
+		t.Errorf("annotated %d lines, want %d", n, maxAnnotatedSourceLines)
+	}
+	if !strings.Contains(got, "// Original java: ... (5 more lines)\n") || StripSourceAnnotations(got) != "x += 1" {
+		t.Errorf("unexpected annotation of a long source:\n%s", got)
+	}
+
+	if got := AnnotateSource("x += 1", "  \n", uniast.Java, uniast.Golang); got != "x += 1" {
+		t.Errorf("empty source should not be annotated: %q", got)
+	}
+}
+
+func TestStripRepoSourceAnnotations(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	srcRepo.Modules["com.example:test:1.0"].Packages["com.example.model"].Functions["greet"] = &uniast.Function{
+		Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: "greet"},
+		Content:  "static String greet() { return \"hi\"; }",
+	}
+	targetRepo, err := TranslateAST(context.Background(), srcRepo, TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/example/test",
+		LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			content := "func Greet() string {\n\treturn \"hi\"\n}"
+			if req.NodeType == uniast.TYPE {
+				content = "type User struct {\n\tname string\n}"
+			}
+			// what --annotate-source does
+			return &LLMTranslateResponse{TargetContent: AnnotateSource(content, req.SourceContent, req.SourceLanguage, req.TargetLanguage)}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+
+	// --no-annotate strips the annotations of the translation before writing it
+	StripRepoSourceAnnotations(targetRepo)
+	dir := t.TempDir()
+	if err := lang.Write(context.Background(), targetRepo, lang.WriteOptions{OutputDir: dir}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	var written strings.Builder
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, ".go") {
+			bs, _ := os.ReadFile(path)
+			written.Write(bs)
+		}
+		return nil
+	})
+	if got := written.String(); strings.Contains(got, "Original java") || !strings.Contains(got, "func Greet() string") || !strings.Contains(got, "type User struct") {
+		t.Errorf("written code should have the translation without annotations, got:\n%s", got)
+	}
+}
+
+func TestTranslateAST_MultiModel(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	pkg := srcRepo.Modules["com.example:test:1.0"].Packages["com.example.model"]
+	pkg.Functions["newUser"] = &uniast.Function{
+		Identity: uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: "newUser"},
+		Content:  "static User newUser(String name) { return new User(name); }",
+	}
+
+	var mu sync.Mutex
+	calls := make(map[string][]string)
+	model := func(name, content string) LLMTranslateFunc {
+		return func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			mu.Lock()
+			calls[name] = append(calls[name], req.Identity.Name)
+			mu.Unlock()
+			return &LLMTranslateResponse{TargetContent: content}, nil
+		}
+	}
+	_, err := TranslateAST(context.Background(), srcRepo, TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/example/test",
+		LLMTranslator:    model("default", "func NewUser(name string) *User {\n\treturn &User{name: name}\n}"),
+		MultiModel: &MultiModelTranslator{
+			TypeModel: model("type", "type User struct {\n\tname string\n}"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+	if !reflect.DeepEqual(calls["type"], []string{"User"}) {
+		t.Errorf("expect the type model to translate User only, got %v", calls["type"])
+	}
+	if !reflect.DeepEqual(calls["default"], []string{"newUser"}) {
+		t.Errorf("expect the function without a model to fall back to LLMTranslator, got %v", calls["default"])
+	}
+
+	mm := &MultiModelTranslator{VarModel: model("var", "var x = 1")}
+	if _, err := mm.Translate(context.Background(), &LLMTranslateRequest{NodeType: uniast.FUNC}); err == nil {
+		t.Errorf("expect an error for a node kind without a model")
+	}
+	if err := validateOptions(TranslateOptions{TargetLanguage: uniast.Golang, MultiModel: mm}); err != nil {
+		t.Errorf("expect MultiModel to replace LLMTranslator, got %v", err)
+	}
+
+	// the transformer routes the models once, its NodeTranslator uses the routed translator as is
+	tr := NewTransformer(TranslateOptions{TargetLanguage: uniast.Golang, MultiModel: mm})
+	if tr.opts.MultiModel != nil || tr.nodeTranslator.opts.MultiModel != nil || tr.nodeTranslator.opts.LLMTranslator == nil {
+		t.Errorf("expect MultiModel to be routed into LLMTranslator once")
+	}
+}
+
+func TestPromptBuilder_Package(t *testing.T) {
+	pkg := uniast.NewPackage("com.example.model")
+	pkg.Types["User"] = &uniast.Type{Identity: uniast.Identity{Name: "User"}, Content: "public class User { private String name; }"}
+	pkg.Functions["User.getName"] = &uniast.Function{Identity: uniast.Identity{Name: "User.getName"}, Content: "public String getName() { return name; }"}
+	pkg.Vars["MAX"] = &uniast.Var{Identity: uniast.Identity{Name: "MAX"}, Content: "static final int MAX = 10;"}
+
+	builder := NewPromptBuilder(uniast.Java, uniast.Golang, NewTypeHints(uniast.Java, uniast.Golang))
+	prompt := builder.BuildPackagePrompt(pkg, uniast.Java, uniast.Golang)
+	for _, want := range []string{"Translate the whole java package `com.example.model` below to go", "## Type `User`\n```java\n", "## Function `User.getName`", "## Var `MAX`", "the 3 nodes", "### Node <name>"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("package prompt should contain %q, got:\n%s", want, prompt)
+		}
+	}
+	if strings.Index(prompt, "## Type `User`") > strings.Index(prompt, "## Function `User.getName`") {
+		t.Errorf("types should come before functions in the package prompt")
+	}
+	if builder.BuildPackagePrompt(uniast.NewPackage("empty"), uniast.Java, uniast.Golang) != "" {
+		t.Errorf("expect no prompt for an empty package")
+	}
+
+	got := ParsePackageResponse("### Node User\n```go\ntype User struct {\n\tname string\n}\n```\n### Node `User.getName`:\nfunc (u *User) GetName() string { return u.name }\n### Node MAX\n")
+	want := map[string]string{"User": "type User struct {\n\tname string\n}", "User.getName": "func (u *User) GetName() string { return u.name }"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePackageResponse() = %v, want %v", got, want)
+	}
+
+	// code comments starting with Node are part of the code
+	got = ParsePackageResponse("### Node List\nclass List:\n    # Node of the list\n    // Node head\n    pass\n")
+	want = map[string]string{"List": "class List:\n    # Node of the list\n    // Node head\n    pass"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePackageResponse() = %v, want %v", got, want)
+	}
+}
+
+func TestTranslateAST_WholePackage(t *testing.T) {
+	srcRepo := createTestJavaRepo()
+	pkg := srcRepo.Modules["com.example:test:1.0"].Packages["com.example.model"]
+	for _, name := range []string{"A", "B"} {
+		pkg.Vars[name] = &uniast.Var{
+			IsExported: true,
+			IsConst:    true,
+			Identity:   uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: name},
+			Content:    "static final int " + name + " = 1;",
+		}
+	}
+
+	var mu sync.Mutex
+	var packages, singles []string
+	result := &TranslateResult{}
+	_, err := TranslateAST(context.Background(), srcRepo, TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/example/test",
+		BatchSize:        WholePackageBatchSize,
+		Result:           result,
+		LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(req.Batch) == 0 {
+				singles = append(singles, req.Identity.Name)
+				return mockLLMTranslator(ctx, req)
+			}
+			var names []string
+			for _, r := range req.Batch {
+				names = append(names, r.Identity.Name)
+			}
+			packages = append(packages, strings.Join(names, ","))
+			// the LLM omits node B, which is translated alone later
+			return &LLMTranslateResponse{TargetContent: "### Node User\ntype User struct {\n\tname string\n}\n### Node A\nconst A = 1\n"}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+	if fmt.Sprint(packages) != "[User,A,B]" {
+		t.Errorf("expect the package to be translated in a single call, got %v", packages)
+	}
+	if fmt.Sprint(singles) != "[B]" {
+		t.Errorf("expect only B missing in the package output to be translated alone, got %v", singles)
+	}
+	if len(result.TranslatedIDs) != 3 {
+		t.Errorf("expect 3 nodes to be translated, got %d", len(result.TranslatedIDs))
+	}
+}
+
+func TestPromptLogger(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "prompts")
+	logger, err := NewPromptLogger(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = TranslateAST(context.Background(), createTestJavaRepo(), TranslateOptions{
+		SourceLanguage:   uniast.Java,
+		TargetLanguage:   uniast.Golang,
+		TargetModuleName: "github.com/example/test",
+		LLMTranslator:    logger.Wrap(mockLLMTranslator),
+	})
+	if err != nil {
+		t.Fatalf("TranslateAST failed: %v", err)
+	}
+
+	id := uniast.Identity{ModPath: "com.example:test:1.0", PkgPath: "com.example.model", Name: "User"}
+	hash := PromptLogHash(id)
+	if len(hash) != 8 {
+		t.Fatalf("PromptLogHash() = %q, want 8 hex chars", hash)
+	}
+	prompt, err := os.ReadFile(filepath.Join(dir, hash+"-prompt.txt"))
+	if err != nil || !strings.Contains(string(prompt), "class User") {
+		t.Errorf("expect the prompt of User to be logged, got %q, %v", prompt, err)
+	}
+	response, err := os.ReadFile(filepath.Join(dir, hash+"-response.txt"))
+	if err != nil || !strings.Contains(string(response), "// Translated from java") {
+		t.Errorf("expect the response of User to be logged, got %q, %v", response, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, PromptLogIndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index []PromptLogEntry
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatal(err)
+	}
+	want := PromptLogEntry{NodeID: id.Full(), Hash: hash, Prompt: hash + "-prompt.txt", Response: hash + "-response.txt"}
+	found := false
+	for _, e := range index {
+		found = found || e == want
+	}
+	if !found {
+		t.Errorf("expect %+v in the index, got %+v", want, index)
+	}
+}
+
+func TestProgressState_ETA(t *testing.T) {
+	var etas []time.Duration
+	p := &ProgressState{Total: 100, Callback: func(done, total int, currentKind, currentNodeID string, eta time.Duration) {
+		etas = append(etas, eta)
+	}}
+	p.ReportNodeDone("type", "stub")
+	if etas[0] != 0 {
+		t.Errorf("expect no ETA before any node is timed, got %v", etas[0])
+	}
+	p.ReportNodeTranslated("func", "a", 2*time.Second)
+	p.ReportNodeTranslated("func", "b", 4*time.Second)
+	if want := 97 * 3 * time.Second; etas[2] != want || p.ETA() != want {
+		t.Errorf("ETA = %v, %v, want %v", etas[2], p.ETA(), want)
+	}
+
+	// only the latest etaWindow durations are averaged
+	for i := 0; i < etaWindow; i++ {
+		p.ReportNodeTranslated("func", "c", time.Second)
+	}
+	if want := time.Duration(p.Total-p.Done()) * time.Second; p.ETA() != want {
+		t.Errorf("ETA = %v, want %v", p.ETA(), want)
+	}
+
+	var nilProgress *ProgressState
+	nilProgress.ReportNodeTranslated("func", "a", time.Second)
+	if nilProgress.ETA() != 0 {
+		t.Errorf("expect no ETA for nil progress")
+	}
+}
+
+func TestGoComplexity(t *testing.T) {
+	tests := []struct {
+		content string
+		want    int
+	}{
+		{"func f() {}", 1},
+		{"func f(a, b bool) int {\n\tif a && b {\n\t\treturn 1\n\t}\n\tfor i := range 3 {\n\t\tswitch i {\n\t\tcase 1:\n\t\tdefault:\n\t\t}\n\t}\n\treturn 0\n}", 5},
+		{"not go", 0},
+	}
+	for _, tt := range tests {
+		if got := GoComplexity(tt.content); got != tt.want {
+			t.Errorf("GoComplexity(%q) = %d, want %d", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateTests(t *testing.T) {
+	dir := t.TempDir()
+	complex := "func Sign(x int) int {\n\tif x > 0 {\n\t\treturn 1\n\t} else if x < 0 {\n\t\treturn -1\n\t}\n\tif x == 0 && true {\n\t\treturn 0\n\t}\n\treturn 0\n}"
+	if err := os.MkdirAll(filepath.Join(dir, "util"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "util", "sign.go"), []byte("package util\n\n"+complex+"\n\nfunc Id(x int) int { return x }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo := uniast.NewRepository("test")
+	mod := uniast.NewModule("example.com/m", ".", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	pkg := uniast.NewPackage("example.com/m/util")
+	mod.Packages[pkg.PkgPath] = pkg
+	for name, content := range map[string]string{"Sign": complex, "Id": "func Id(x int) int { return x }"} {
+		pkg.Functions[name] = &uniast.Function{
+			Identity: uniast.NewIdentity(mod.Name, pkg.PkgPath, name),
+			FileLine: uniast.FileLine{File: "util/sign.go"},
+			Content:  content,
+		}
+	}
+
+	var prompts []string
+	llm := func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+		prompts = append(prompts, req.Prompt)
+		return &LLMTranslateResponse{TargetContent: "package util\n\nimport \"testing\"\n\nfunc TestSign(t *testing.T) {\n\tif Sign(2) != 1 {\n\t\tt.Fail()\n\t}\n}\n"}, nil
+	}
+	report, err := GenerateTests(context.Background(), &repo, GenerateTestsOptions{
+		OutputDir:     dir,
+		LLMTranslator: llm,
+		Test: func(ctx context.Context, dir string) (string, error) {
+			return "=== RUN   TestSign\n--- PASS: TestSign (0.00s)\n    --- PASS: TestSign/sub (0.00s)\n--- FAIL: TestOther (0.00s)\nFAIL\n", nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "Generate a table-driven test for this Go function:") || !strings.Contains(prompts[0], "func Sign") {
+		t.Errorf("expect a single test prompt for Sign, got %q", prompts)
+	}
+	if report.Generated != 1 || report.Passed != 1 || report.Failed != 1 || len(report.Errors) != 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if want := []string{filepath.Join("util", "util_translate_test.go")}; !reflect.DeepEqual(report.Files, want) {
+		t.Errorf("Files = %v, want %v", report.Files, want)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "util", "util_translate_test.go"))
+	if err != nil || !strings.Contains(string(data), "package util\n\nimport (\n\t\"testing\"\n)") || !strings.Contains(string(data), "func TestSign(t *testing.T)") {
+		t.Errorf("unexpected test file %q, %v", data, err)
+	}
+}
+
+func TestTestFile_Add(t *testing.T) {
+	f := &testFile{pkgName: "util", imports: map[string]bool{}, names: map[string]bool{}}
+	helpers := "type fixture struct{ n int }\n\nfunc (f *fixture) get() int { return f.n }\n\nvar defaultFixture = fixture{n: 1}\n\nconst want = 1\n\nfunc newFixture() *fixture { return &fixture{} }\n\nfunc init() {}\n"
+	if err := f.add("import \"testing\"\n\n" + helpers + "\nvar _ = newFixture\n\nfunc TestA(t *testing.T) {}\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.add("import \"testing\"\n\n" + helpers + "\nvar _ = newFixture\n\nvar (\n\twant2 = 2\n\tdefaultFixture = fixture{}\n)\n\nfunc TestB(t *testing.T) {}\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.add("type fixture struct{}\n\nfunc newFixture() *fixture { return nil }\n"); err == nil {
+		t.Error("add() of the declared helpers only should fail")
+	}
+	out := string(f.render())
+	for decl, n := range map[string]int{
+		"type fixture struct":     1,
+		"func (f *fixture) get()": 1,
+		"defaultFixture =":        1,
+		"const want = 1":          1,
+		"func newFixture()":       1,
+		"var _ = newFixture":      2,
+		"func init()":             2,
+		"want2 = 2":               1,
+		"func TestA(":             1,
+		"func TestB(":             1,
+	} {
+		if got := strings.Count(out, decl); got != n {
+			t.Errorf("%q is declared %d times, want %d, got:\n%s", decl, got, n, out)
+		}
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "", out, 0); err != nil {
+		t.Errorf("render() is not valid Go: %v\n%s", err, out)
+	}
+}
+
+func TestNodeTranslator_ContextNodes(t *testing.T) {
+	srcRepo := uniast.NewRepository("test")
+	srcMod := uniast.NewModule("com.example:test:1.0", ".", uniast.Java)
+	srcRepo.Modules[srcMod.Name] = srcMod
+	srcPkg := uniast.NewPackage("com.example.util")
+	srcMod.Packages[srcPkg.PkgPath] = srcPkg
+	helper := &uniast.Function{
+		Identity: uniast.NewIdentity(srcMod.Name, string(srcPkg.PkgPath), "Util.helper"),
+		Content:  "int helper(int x) { return x + 1; }",
+	}
+	caller := &uniast.Function{
+		Identity:      uniast.NewIdentity(srcMod.Name, string(srcPkg.PkgPath), "Util.run"),
+		Content:       "int run() { return helper(1); }",
+		FunctionCalls: []uniast.Dependency{{Identity: helper.Identity}},
+	}
+	srcPkg.Functions[helper.Name] = helper
+	srcPkg.Functions[caller.Name] = caller
+
+	var prompts []string
+	translator := NewNodeTranslator(TranslateOptions{
+		SourceLanguage: uniast.Java,
+		TargetLanguage: uniast.Golang,
+		LLMTranslator: func(ctx context.Context, req *LLMTranslateRequest) (*LLMTranslateResponse, error) {
+			prompts = append(prompts, req.Prompt)
+			return &LLMTranslateResponse{TargetContent: "func helper(x int) int { return x + 1 }"}, nil
+		},
+	}, NewTypeHints(uniast.Java, uniast.Golang))
+	targetRepo := uniast.NewRepository("github.com/example/test")
+	targetMod := uniast.NewModule("github.com/example/test", ".", uniast.Golang)
+	tctx := NewTranslateContext(&srcRepo, &targetRepo, targetMod, uniast.NewPackage("github.com/example/test/util"))
+
+	for _, fn := range []*uniast.Function{helper, caller} {
+		dst, err := translator.TranslateFunction(context.Background(), fn, tctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tctx.AddTranslatedNode(fn.Identity, dst.Identity)
+	}
+	if strings.Contains(prompts[0], "## Related Translated Code") {
+		t.Errorf("prompt of helper should not have related code, got:\n%s", prompts[0])
+	}
+	if want := "## Related Translated Code\nThe dependencies below are already translated, call them as they are and do NOT redeclare them:\n- `util.helper`:\n```go\nfunc helper(x int) int { return x + 1 }\n```\n"; !strings.Contains(prompts[1], want) {
+		t.Errorf("prompt of run should have the translated helper, got:\n%s", prompts[1])
+	}
+}
+
+func TestTokenBudget(t *testing.T) {
+	if n := EstimateTokens("12345678a"); n != 3 {
+		t.Errorf("EstimateTokens() = %d, want 3", n)
+	}
+	var unlimited *TokenBudget
+	if !unlimited.Take(strings.Repeat("x", 1000)) || NewTokenBudget(0) != nil {
+		t.Error("a nil budget should be unlimited")
+	}
+	b := NewTokenBudget(3)
+	if !b.Take("12345678") || b.Remaining() != 1 {
+		t.Errorf("Take() should consume 2 tokens, remaining %d", b.Remaining())
+	}
+	if b.Take("12345678") || b.Remaining() != 1 {
+		t.Errorf("Take() should not consume beyond the budget, remaining %d", b.Remaining())
+	}
+	b.Consume("12345678")
+	if b.Remaining() != 0 {
+		t.Errorf("Consume() should exhaust the budget, remaining %d", b.Remaining())
+	}
+}
+
+func TestPromptBuilder_MaxPromptLength(t *testing.T) {
+	req := &LLMTranslateRequest{
+		SourceLanguage: uniast.Java,
+		TargetLanguage: uniast.Golang,
+		NodeType:       uniast.FUNC,
+		Identity:       uniast.Identity{ModPath: "m", PkgPath: "p", Name: "run"},
+		SourceContent:  "int run() {\n" + strings.Repeat("    helper(1);\n", 20) + "}",
+		Dependencies: []DependencyHint{{
+			SourceIdentity: uniast.Identity{Name: "helper"},
+			TargetIdentity: uniast.Identity{Name: "Helper"},
+		}},
+		ContextNodes: []ContextNodeHint{{Identity: uniast.Identity{Name: "Helper"}, TranslatedContent: "func Helper(x int) int { return x }"}},
+	}
+	newBuilder := func(tokens int) *PromptBuilder {
+		return NewPromptBuilder(uniast.Java, uniast.Golang, NewTypeHints(uniast.Java, uniast.Golang)).WithMaxPromptLength(tokens)
+	}
+	full := newBuilder(0).BuildFunctionPrompt(req)
+	for _, section := range []string{"## Type Mapping Reference", "## Already Translated Dependencies", "## Related Translated Code"} {
+		if !strings.Contains(full, section) {
+			t.Fatalf("unlimited prompt should contain %q, got:\n%s", section, full)
+		}
+	}
+
+	// only room for the instructions and the source: the other sections are cut
+	noHints := &PromptBuilder{source: uniast.Java, target: uniast.Golang, typeHints: &TypeHints{}}
+	source := noHints.BuildFunctionPrompt(&LLMTranslateRequest{SourceContent: req.SourceContent})
+	prompt := newBuilder(EstimateTokens(source) + 10).BuildFunctionPrompt(req)
+	if strings.Contains(prompt, "## Already Translated Dependencies") || strings.Contains(prompt, "## Related Translated Code") {
+		t.Errorf("dependencies and context should be cut first, got:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, req.SourceContent) || strings.Contains(prompt, "truncated") {
+		t.Errorf("the source should be kept whole, got:\n%s", prompt)
+	}
+	if EstimateTokens(prompt) > EstimateTokens(source)+10 {
+		t.Errorf("prompt of %d tokens exceeds the budget of %d", EstimateTokens(prompt), EstimateTokens(source)+10)
+	}
+
+	// not even room for the source: it is truncated
+	prompt = newBuilder(EstimateTokens(source) - 20).BuildFunctionPrompt(req)
+	if strings.Contains(prompt, req.SourceContent) || !strings.Contains(prompt, "Note: Source was truncated for context limit") {
+		t.Errorf("the source should be truncated, got:\n%s", prompt)
+	}
 }
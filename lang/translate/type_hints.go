@@ -18,9 +18,11 @@ package translate
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
+	"gopkg.in/yaml.v3"
 )
 
 // TypeHints provides type mapping hints for LLM translation
@@ -41,38 +43,61 @@ func NewTypeHints(source, target uniast.Language) *TypeHints {
 	return h
 }
 
+// NewTypeHintsFromOptions creates the TypeHints of the language pair of opts,
+// adding the mappings of opts.TypeHintsFile and then opts.CustomTypeHints
+func NewTypeHintsFromOptions(opts TranslateOptions) (*TypeHints, error) {
+	h := NewTypeHints(opts.SourceLanguage, opts.TargetLanguage)
+	if opts.TypeHintsFile != "" {
+		if err := h.LoadFromFile(opts.TypeHintsFile); err != nil {
+			return h, err
+		}
+	}
+	for _, o := range opts.CustomTypeHints {
+		h.AddMapping(o.Source, o.Target)
+	}
+	return h, nil
+}
+
 // loadMappings loads the type mappings for the source-target language pair
 func (h *TypeHints) loadMappings() {
-	key := fmt.Sprintf("%s->%s", h.source, h.target)
+	h.mappings = builtinTypeMappings(fmt.Sprintf("%s->%s", h.source, h.target))
+	if h.mappings == nil {
+		h.mappings = make(map[string]string)
+	}
+}
+
+// builtinTypeMappings returns the built-in type mappings of the language pair key, e.g. java->go,
+// or nil if the pair is unknown
+func builtinTypeMappings(key string) map[string]string {
 	switch key {
 	case "java->go":
-		h.mappings = javaToGoMappings()
+		return javaToGoMappings()
 	case "go->java":
-		h.mappings = goToJavaMappings()
+		return goToJavaMappings()
 	case "java->rust":
-		h.mappings = javaToRustMappings()
+		return javaToRustMappings()
 	case "rust->java":
-		h.mappings = rustToJavaMappings()
+		return rustToJavaMappings()
 	case "go->rust":
-		h.mappings = goToRustMappings()
+		return goToRustMappings()
 	case "rust->go":
-		h.mappings = rustToGoMappings()
+		return rustToGoMappings()
 	case "python->go":
-		h.mappings = pythonToGoMappings()
+		return pythonToGoMappings()
 	case "go->python":
-		h.mappings = goToPythonMappings()
+		return goToPythonMappings()
 	case "java->python":
-		h.mappings = javaToPythonMappings()
+		return javaToPythonMappings()
 	case "python->java":
-		h.mappings = pythonToJavaMappings()
+		return pythonToJavaMappings()
 	case "python->rust":
-		h.mappings = pythonToRustMappings()
+		return pythonToRustMappings()
 	case "rust->python":
-		h.mappings = rustToPythonMappings()
+		return rustToPythonMappings()
 	case "typescript->go", "ts->go":
-		h.mappings = typescriptToGoMappings()
+		return typescriptToGoMappings()
 	default:
-		h.mappings = make(map[string]string)
+		return nil
 	}
 }
 
@@ -98,6 +123,60 @@ func (h *TypeHints) AddMapping(sourceType, targetType string) {
 	h.mappings[sourceType] = targetType
 }
 
+// LoadFromFile adds the type mappings of the file at path, a YAML (or JSON) map
+// from language pairs to source => target types, e.g.
+//
+//	java->go:
+//	  com.example.Money: decimal.Decimal
+//
+// Only the mappings of the pair of h are added, the file may hold other pairs.
+func (h *TypeHints) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read type hints file: %w", err)
+	}
+	var pairs map[string]map[string]string
+	if err := yaml.Unmarshal(data, &pairs); err != nil {
+		return fmt.Errorf("invalid type hints file %s, want a map of language pairs like java->go to type mappings: %w", path, err)
+	}
+	key := fmt.Sprintf("%s->%s", h.source, h.target)
+	for pair, mappings := range pairs {
+		if builtinTypeMappings(strings.ToLower(strings.TrimSpace(pair))) == nil {
+			return fmt.Errorf("unknown language pair %q in type hints file %s, want e.g. java->go", pair, path)
+		}
+		for src, dst := range mappings {
+			if strings.TrimSpace(src) == "" || strings.TrimSpace(dst) == "" {
+				return fmt.Errorf("empty type in the %s mapping %q: %q of type hints file %s", pair, src, dst, path)
+			}
+		}
+	}
+	for pair, mappings := range pairs {
+		if strings.ToLower(strings.TrimSpace(pair)) != key {
+			continue
+		}
+		for src, dst := range mappings {
+			h.AddMapping(strings.TrimSpace(src), strings.TrimSpace(dst))
+		}
+	}
+	return nil
+}
+
+// TypeHintOverride is a user-defined type mapping added to (or overriding) the built-in ones
+type TypeHintOverride struct {
+	Source string // source language type, e.g. ImmutableList<T>
+	Target string // target language type, e.g. []T
+}
+
+// ParseTypeHintOverride parses a type mapping written as "Source=Target", e.g. "ImmutableList<T>=[]T"
+func ParseTypeHintOverride(s string) (TypeHintOverride, error) {
+	src, dst, ok := strings.Cut(s, "=")
+	src, dst = strings.TrimSpace(src), strings.TrimSpace(dst)
+	if !ok || src == "" || dst == "" {
+		return TypeHintOverride{}, fmt.Errorf("invalid type hint %q, want Source=Target", s)
+	}
+	return TypeHintOverride{Source: src, Target: dst}, nil
+}
+
 // Java -> Go type mappings
 func javaToGoMappings() map[string]string {
 	return map[string]string{
@@ -199,16 +278,35 @@ func goToJavaMappings() map[string]string {
 		"rune":    "char",
 
 		// Composite types
-		"[]T":         "List<T>",
-		"map[K]V":     "Map<K,V>",
-		"*T":          "T",
-		"error":       "Exception",
-		"interface{}": "Object",
-		"any":         "Object",
+		"[]T":             "List<T>",
+		"[N]T":            "T[]",
+		"[]byte":          "byte[]",
+		"map[K]V":         "Map<K,V>",
+		"map[T]struct{}":  "Set<T>",
+		"map[T]bool":      "Set<T>",
+		"*T":              "T",
+		"error":           "Exception",
+		"(T, error)":      "T throws Exception",
+		"(T, bool)":       "Optional<T>",
+		"interface{}":     "Object",
+		"any":             "Object",
+		"struct":          "class",
+		"interface":       "interface",
+		"chan T":          "BlockingQueue<T>",
+		"func(T) R":       "Function<T,R>",
+		"func()":          "Runnable",
+		"go f()":          "CompletableFuture.runAsync(f) / ExecutorService",
+		"defer f()":       "try { ... } finally { f(); }",
+		"sync.Mutex":      "ReentrantLock",
+		"sync.RWMutex":    "ReentrantReadWriteLock",
+		"sync.WaitGroup":  "CountDownLatch",
+		"context.Context": "(omit, or pass a cancellation token)",
 
 		// Common types
 		"time.Time":     "LocalDateTime",
 		"time.Duration": "Duration",
+		"*big.Int":      "BigInteger",
+		"*big.Float":    "BigDecimal",
 	}
 }
 
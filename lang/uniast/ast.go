@@ -19,7 +19,10 @@ package uniast
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"path"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -94,6 +97,7 @@ type Repository struct {
 	Path        string             // repo absolute path
 	Modules     map[string]*Module // module name => module
 	Graph       NodeGraph          // node id => node
+	Checksum    string             `json:",omitempty"` // sha256 of the JSON content excluding Checksum itself
 }
 
 func (r Repository) ID() string {
@@ -110,6 +114,439 @@ func (r Repository) InternalModules() []*Module {
 	return ret
 }
 
+// SplitByModule partitions the repository into one repository per internal module, keyed by module name.
+// Each sub-repository keeps the Name and Path of r, and holds the module with the external modules it references.
+// Modules and nodes are shared with r, while the Graph of each sub-repository is rebuilt.
+func (r *Repository) SplitByModule() map[string]*Repository {
+	ret := make(map[string]*Repository)
+	for name, mod := range r.Modules {
+		if mod == nil || mod.IsExternal() {
+			continue
+		}
+		sub := &Repository{
+			Name:        r.Name,
+			ASTVersion:  r.ASTVersion,
+			ToolVersion: r.ToolVersion,
+			Path:        r.Path,
+			Modules:     map[string]*Module{name: mod},
+		}
+		sub.BuildGraph()
+		for _, node := range sub.Graph {
+			for _, rels := range [][]Relation{node.Dependencies, node.Implements} {
+				for _, dep := range rels {
+					if ext := r.Modules[dep.ModPath]; ext != nil && ext.IsExternal() {
+						sub.Modules[dep.ModPath] = ext
+					}
+				}
+			}
+		}
+		if len(sub.Modules) > 1 {
+			sub.BuildGraph()
+		}
+		ret[name] = sub
+	}
+	return ret
+}
+
+// Merge merges the modules of src into dst, e.g. to analyze several repositories parsed separately as one, then rebuilds the graph of dst.
+// An internal module of src replaces the external module of the same name in dst, which is how dst referred to it.
+// An internal module whose name is already taken by an internal module of dst is renamed by prefixing it
+// with the path of src, see RenameModule. The modules of src are moved, src must not be used afterwards.
+func Merge(dst, src *Repository) error {
+	if dst.Modules == nil {
+		dst.Modules = make(map[string]*Module, len(src.Modules))
+	}
+	names := make([]string, 0, len(src.Modules))
+	for name := range src.Modules {
+		names = append(names, name)
+	}
+	// rename the conflicting modules first, RenameModule updates the references across src
+	slices.Sort(names)
+	for _, name := range names {
+		mod := src.Modules[name]
+		if mod == nil || mod.IsExternal() {
+			continue
+		}
+		if old := dst.Modules[name]; old == nil || old.IsExternal() {
+			continue
+		}
+		if src.Path == "" {
+			return fmt.Errorf("module %s already exists", name)
+		}
+		renamed := path.Join(filepath.ToSlash(src.Path), name)
+		if dst.Modules[renamed] != nil {
+			return fmt.Errorf("module %s already exists", renamed)
+		}
+		if err := src.RenameModule(name, renamed); err != nil {
+			return err
+		}
+	}
+	for name, mod := range src.Modules {
+		if mod == nil {
+			continue
+		}
+		if old := dst.Modules[name]; old != nil && mod.IsExternal() {
+			// keep the module of dst, either internal or the same external module
+			continue
+		}
+		dst.Modules[name] = mod
+	}
+	// keep the annotations of the merged nodes, BuildGraph carries them over
+	if dst.Graph == nil {
+		dst.Graph = make(NodeGraph)
+	}
+	for key, n := range src.Graph {
+		if n != nil && len(n.Annotations) > 0 && dst.Modules[n.ModPath] == src.Modules[n.ModPath] {
+			if old := dst.Graph[key]; old != nil && len(old.Annotations) > 0 {
+				continue
+			}
+			dst.Graph[key] = n
+		}
+	}
+	return dst.BuildGraph()
+}
+
+// FilterPackages returns a shallow copy of r holding only the packages of internal modules that satisfy pred.
+// Internal modules left without any package are dropped, external modules are kept as is.
+// Packages and nodes are shared with r while the Graph is rebuilt, so dependencies on excluded packages
+// remain as stub nodes of UNKNOWN type. The result can be filtered or split by SplitByModule again.
+func (r *Repository) FilterPackages(pred func(modPath string, pkgPath PkgPath, pkg *Package) bool) *Repository {
+	ret := &Repository{
+		Name:        r.Name,
+		ASTVersion:  r.ASTVersion,
+		ToolVersion: r.ToolVersion,
+		Path:        r.Path,
+		Modules:     make(map[string]*Module, len(r.Modules)),
+	}
+	for name, mod := range r.Modules {
+		if mod == nil {
+			continue
+		}
+		if mod.IsExternal() {
+			ret.Modules[name] = mod
+			continue
+		}
+		pkgs := make(map[PkgPath]*Package)
+		for path, pkg := range mod.Packages {
+			if pkg != nil && pred(name, path, pkg) {
+				pkgs[path] = pkg
+			}
+		}
+		if len(pkgs) == 0 {
+			continue
+		}
+		m := *mod
+		m.Packages = pkgs
+		if mod.Files != nil {
+			m.Files = make(map[string]*File, len(mod.Files))
+			for path, f := range mod.Files {
+				if f == nil || f.Package == "" || pkgs[f.Package] != nil {
+					m.Files[path] = f
+				}
+			}
+		}
+		ret.Modules[name] = &m
+	}
+	ret.BuildGraph()
+	return ret
+}
+
+// Clone returns a copy of the repository whose maps can be read and written independently of r:
+// the Modules, the Graph, and the Packages, Files and dependencies of each module
+// and the Functions, Types and Vars of each package are copied.
+// The nodes themselves (Function, Type, Var, File and graph Node) are shared, since they are read-only during translation,
+// so a node of the copied Graph still refers to r as its Repo.
+func (r *Repository) Clone() *Repository {
+	ret := *r
+	ret.Modules = make(map[string]*Module, len(r.Modules))
+	for name, mod := range r.Modules {
+		if mod == nil {
+			ret.Modules[name] = nil
+			continue
+		}
+		ret.Modules[name] = mod.clone()
+	}
+	ret.Graph = maps.Clone(r.Graph)
+	return &ret
+}
+
+// clone copies the maps of the module and its packages, see Repository.Clone
+func (m *Module) clone() *Module {
+	ret := *m
+	ret.Packages = make(map[PkgPath]*Package, len(m.Packages))
+	for path, pkg := range m.Packages {
+		if pkg == nil {
+			ret.Packages[path] = nil
+			continue
+		}
+		p := *pkg
+		p.Functions = maps.Clone(pkg.Functions)
+		p.Types = maps.Clone(pkg.Types)
+		p.Vars = maps.Clone(pkg.Vars)
+		ret.Packages[path] = &p
+	}
+	ret.Dependencies = maps.Clone(m.Dependencies)
+	ret.Files = maps.Clone(m.Files)
+	ret.ExternalDependencies = maps.Clone(m.ExternalDependencies)
+	ret.LoadErrors = slices.Clone(m.LoadErrors)
+	return &ret
+}
+
+// renameIdentities calls rename with the identity of every node of the repository and every reference to a node
+func (r *Repository) renameIdentities(rename func(id *Identity)) {
+	renameID := func(id *Identity) {
+		if id != nil {
+			rename(id)
+		}
+	}
+	renameDeps := func(deps []Dependency) {
+		for i := range deps {
+			renameID(&deps[i].Identity)
+		}
+	}
+	renameIds := func(ids []Identity) {
+		for i := range ids {
+			renameID(&ids[i])
+		}
+	}
+	for _, m := range r.Modules {
+		if m == nil {
+			continue
+		}
+		for _, p := range m.Packages {
+			if p == nil {
+				continue
+			}
+			for _, f := range p.Functions {
+				renameID(&f.Identity)
+				renameDeps(f.Params)
+				renameDeps(f.Results)
+				renameDeps(f.FunctionCalls)
+				renameDeps(f.MethodCalls)
+				renameDeps(f.Types)
+				renameDeps(f.GlobalVars)
+				if f.Receiver != nil {
+					renameID(&f.Receiver.Type)
+				}
+			}
+			for _, t := range p.Types {
+				renameID(&t.Identity)
+				renameDeps(t.SubStruct)
+				renameDeps(t.InlineStruct)
+				renameIds(t.Implements)
+				for name, id := range t.Methods {
+					renameID(&id)
+					t.Methods[name] = id
+				}
+			}
+			for _, v := range p.Vars {
+				renameID(&v.Identity)
+				renameID(v.Type)
+				renameDeps(v.Dependencies)
+				renameIds(v.Groups)
+			}
+		}
+	}
+}
+
+// RenameModule renames the module oldModPath to newModPath.
+// The module path of its nodes, the prefix oldModPath of its package paths and of the imports
+// are replaced with newModPath across the repository, then the graph is rebuilt.
+func (r *Repository) RenameModule(oldModPath, newModPath ModPath) error {
+	mod := r.Modules[oldModPath]
+	if mod == nil {
+		return fmt.Errorf("module %s not found", oldModPath)
+	}
+	if newModPath == oldModPath {
+		return nil
+	}
+	if newModPath == "" {
+		return fmt.Errorf("empty module path to rename %s", oldModPath)
+	}
+	if r.Modules[newModPath] != nil {
+		return fmt.Errorf("module %s already exists", newModPath)
+	}
+
+	renamePkg := func(pkgPath PkgPath) PkgPath {
+		if pkgPath == oldModPath || strings.HasPrefix(pkgPath, oldModPath+"/") {
+			return newModPath + strings.TrimPrefix(pkgPath, oldModPath)
+		}
+		return pkgPath
+	}
+	r.renameIdentities(func(id *Identity) {
+		if id.ModPath == oldModPath {
+			id.ModPath = newModPath
+			id.PkgPath = renamePkg(id.PkgPath)
+		}
+	})
+
+	pkgs := make(map[PkgPath]*Package, len(mod.Packages))
+	for path, pkg := range mod.Packages {
+		if pkg != nil {
+			pkg.PkgPath = renamePkg(pkg.PkgPath)
+		}
+		pkgs[renamePkg(path)] = pkg
+	}
+	mod.Packages = pkgs
+	for _, f := range mod.Files {
+		if f != nil {
+			f.Package = renamePkg(f.Package)
+		}
+	}
+	// imports of the renamed packages, the raw path may be quoted (e.g. Go)
+	for _, m := range r.Modules {
+		for _, f := range m.Files {
+			if f == nil {
+				continue
+			}
+			for i, imp := range f.Imports {
+				if path, err := strconv.Unquote(imp.Path); err == nil {
+					f.Imports[i].Path = strconv.Quote(renamePkg(path))
+				} else {
+					f.Imports[i].Path = renamePkg(imp.Path)
+				}
+			}
+		}
+	}
+	delete(r.Modules, oldModPath)
+	mod.Name = newModPath
+	r.Modules[newModPath] = mod
+	return r.BuildGraph()
+}
+
+// RenamePackage renames the package oldPkgPath of module modPath to newPkgPath.
+// The identities of its nodes, the package of its files and all references to its nodes
+// across the repository are updated, then the graph is rebuilt.
+// Nothing is changed if the package is missing or newPkgPath already exists in the module.
+func (r *Repository) RenamePackage(modPath ModPath, oldPkgPath, newPkgPath PkgPath) error {
+	mod := r.Modules[modPath]
+	if mod == nil {
+		return fmt.Errorf("module %s not found", modPath)
+	}
+	pkg := mod.Packages[oldPkgPath]
+	if pkg == nil {
+		return fmt.Errorf("package %s not found in module %s", oldPkgPath, modPath)
+	}
+	if newPkgPath == oldPkgPath {
+		return nil
+	}
+	if newPkgPath == "" {
+		return fmt.Errorf("empty package path to rename %s", oldPkgPath)
+	}
+	if mod.Packages[newPkgPath] != nil {
+		return fmt.Errorf("package %s already exists in module %s", newPkgPath, modPath)
+	}
+
+	r.renameIdentities(func(id *Identity) {
+		if id.ModPath == modPath && id.PkgPath == oldPkgPath {
+			id.PkgPath = newPkgPath
+		}
+	})
+
+	delete(mod.Packages, oldPkgPath)
+	pkg.PkgPath = newPkgPath
+	mod.Packages[newPkgPath] = pkg
+	for _, f := range mod.Files {
+		if f != nil && f.Package == oldPkgPath {
+			f.Package = newPkgPath
+		}
+	}
+	return r.BuildGraph()
+}
+
+// TotalNodeCount returns the number of top-level nodes (Types + Functions + Vars) in internal modules.
+func (r Repository) TotalNodeCount() int {
+	var n int
+	for _, mod := range r.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			if pkg != nil {
+				n += pkg.NodeCount()
+			}
+		}
+	}
+	return n
+}
+
+// AllFunctions iterates over all functions in internal modules, usage:
+//
+//	for id, fn := range repo.AllFunctions { ... }
+func (r *Repository) AllFunctions(yield func(Identity, *Function) bool) {
+	for _, pkg := range r.internalPackages() {
+		for _, f := range pkg.Functions {
+			if !yield(f.Identity, f) {
+				return
+			}
+		}
+	}
+}
+
+// AllTypes iterates over all types in internal modules
+func (r *Repository) AllTypes(yield func(Identity, *Type) bool) {
+	for _, pkg := range r.internalPackages() {
+		for _, t := range pkg.Types {
+			if !yield(t.Identity, t) {
+				return
+			}
+		}
+	}
+}
+
+// AllVars iterates over all vars in internal modules
+func (r *Repository) AllVars(yield func(Identity, *Var) bool) {
+	for _, pkg := range r.internalPackages() {
+		for _, v := range pkg.Vars {
+			if !yield(v.Identity, v) {
+				return
+			}
+		}
+	}
+}
+
+// AllNodes iterates over the graph nodes of all functions, types and vars in internal modules.
+// The graph is built if it hasn't been.
+func (r *Repository) AllNodes(yield func(Identity, *Node) bool) {
+	if len(r.Graph) == 0 {
+		r.BuildGraph()
+	}
+	visit := func(id Identity) bool {
+		n := r.Graph[id.Full()]
+		return n == nil || yield(id, n)
+	}
+	for id := range r.AllFunctions {
+		if !visit(id) {
+			return
+		}
+	}
+	for id := range r.AllTypes {
+		if !visit(id) {
+			return
+		}
+	}
+	for id := range r.AllVars {
+		if !visit(id) {
+			return
+		}
+	}
+}
+
+func (r *Repository) internalPackages() []*Package {
+	var ret []*Package
+	for _, mod := range r.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			if pkg != nil {
+				ret = append(ret, pkg)
+			}
+		}
+	}
+	return ret
+}
+
 // NOTICE: Repository.Path is set as name by default, if th name isn't a path, set path somewhere
 func NewRepository(name string) Repository {
 	ret := Repository{
@@ -126,9 +563,28 @@ type File struct {
 	Path    string
 	Imports []Import `json:",omitempty"`
 	Package PkgPath  `json:",omitempty"`
+	// tool-control comments out of any node (e.g. //go:generate, //nolint), formatted by NewDirective
+	Directives []string `json:",omitempty"`
+}
+
+// NewDirective formats a directive comment at the line of the file as "<line>:<comment>".
+// Line 0 means the directive precedes the package clause.
+func NewDirective(line int, comment string) string {
+	return strconv.Itoa(line) + ":" + comment
+}
+
+// ParseDirective splits a directive formatted by NewDirective, line is 0 if it has no line prefix
+func ParseDirective(directive string) (line int, comment string) {
+	if l, c, ok := strings.Cut(directive, ":"); ok {
+		if n, err := strconv.Atoi(l); err == nil {
+			return n, c
+		}
+	}
+	return 0, directive
 }
 
 type Import struct {
+	// nil or empty means no alias, "." means dot-import and "_" means blank-import
 	Alias *string `json:",omitempty"`
 	Path  string  // raw path
 }
@@ -200,6 +656,9 @@ type Module struct {
 	Files        map[string]*File     `json:",omitempty"`              // relative path => file info
 	LoadErrors   []packages.Error     `json:"load_errors,omitempty"`   // packages.Load error
 	CompressData *string              `json:"compress_data,omitempty"` // module compress info
+
+	// third-party library => version, as declared in the manifest (go.mod require, pom.xml dependencies, Cargo.toml [dependencies])
+	ExternalDependencies map[string]string `json:",omitempty"`
 }
 
 // func (r Repository) GetFileById(id Identity) *File {
@@ -262,6 +721,11 @@ func (m Module) IsExternal() bool {
 	return m.Dir == ""
 }
 
+// PackageCount returns the number of packages in the module.
+func (m Module) PackageCount() int {
+	return len(m.Packages)
+}
+
 func NewModule(name string, dir string, language Language) *Module {
 	var v string
 	sp := strings.Split(name, "@")
@@ -312,6 +776,27 @@ func NewPackage(pkgPath PkgPath) *Package {
 	return &ret
 }
 
+// NodeCount returns the number of top-level nodes (Types + Functions + Vars) in the package.
+func (p Package) NodeCount() int {
+	return len(p.Types) + len(p.Functions) + len(p.Vars)
+}
+
+// FileCount returns the number of distinct files where the nodes of the package are defined.
+func (p Package) FileCount() int {
+	files := make(map[string]struct{})
+	for _, f := range p.Functions {
+		files[f.File] = struct{}{}
+	}
+	for _, t := range p.Types {
+		files[t.File] = struct{}{}
+	}
+	for _, v := range p.Vars {
+		files[v.File] = struct{}{}
+	}
+	delete(files, "")
+	return len(files)
+}
+
 // PkgPath is the import path of a package, it is either absolute path or url
 type PkgPath = string
 
@@ -372,6 +857,22 @@ func (i Identity) CallName() string {
 	return i.Name
 }
 
+// return last segment of packagepath#name, used for concise logs
+func (i Identity) ShortID() string {
+	pkg := i.PkgPath
+	if idx := strings.LastIndex(pkg, "/"); idx >= 0 {
+		pkg = pkg[idx+1:]
+	} else if idx := strings.LastIndex(pkg, "::"); idx >= 0 {
+		pkg = pkg[idx+2:]
+	} else if idx := strings.LastIndex(pkg, "."); idx >= 0 {
+		pkg = pkg[idx+1:]
+	}
+	if pkg == "" {
+		return i.Name
+	}
+	return pkg + "#" + i.Name
+}
+
 func (i Identity) Full() string {
 	return i.ModPath + "?" + i.PkgPath + "#" + i.Name
 }
@@ -483,20 +984,34 @@ func (p Repository) GetFile(fp string) (*File, *Module) {
 	return nil, nil
 }
 
+// keys of language-specific metadata annotations (Tags) of a node
+const (
+	// TagSpring is the Spring annotations of a Java node, ex: "@RestController @RequestMapping(\"/api\")"
+	TagSpring = "spring"
+	// TagGoGenerate is the go:generate directives of a Go node, ex: "stringer -type=Status"
+	TagGoGenerate = "go:generate"
+	// TagGoGeneric is the instantiation of a generic Go type a type is expanded from, ex: "Result[User]"
+	TagGoGeneric = "go:generic"
+)
+
 // Function holds the information about a function
 type Function struct {
 	Exported bool
 
 	IsMethod          bool // If the function is a method
 	IsInterfaceMethod bool // If is a empty interface method stub
+	IsConstructor     bool `json:",omitempty"` // If is a constructor, e.g. Java <init>, Python __init__ or Rust new() -> Self
 	Identity               // unique identity in a repo
 	FileLine
-	Content string // Content of the function, including functiion signature and body
+	Content   string // Content of the function, including functiion signature and body
+	DocString string `json:",omitempty"` // leading doc comment of the function, without comment markers
 
 	Signature string       `json:",omitempty"`
 	Receiver  *Receiver    `json:",omitempty"` // Method receiver
 	Params    []Dependency `json:",omitempty"` // function parameters, key is the parameter name
 	Results   []Dependency `json:",omitempty"` // function results, key is the result name or type name
+	// ReceiverType is the receiver type of a method as written in the source, e.g. *User or List[T] (only set for Go now)
+	ReceiverType string `json:",omitempty"`
 
 	// call to in-the-project functions, key is {{pkgAlias.funcName}} or {{funcName}}
 	FunctionCalls []Dependency `json:",omitempty"`
@@ -508,6 +1023,9 @@ type Function struct {
 	Types      []Dependency `json:",omitempty"` // types used in the function
 	GlobalVars []Dependency `json:",omitempty"` // global vars used in the function
 
+	// language-specific metadata annotations, see Tag* keys
+	Tags map[string]string `json:",omitempty"`
+
 	// func llm compress result
 	CompressData *string `json:"compress_data,omitempty"`
 }
@@ -554,6 +1072,7 @@ const (
 	TypeKindInterface TypeKind = "interface"
 	TypeKindTypedef   TypeKind = "typedef"
 	TypeKindEnum      TypeKind = "enum"
+	TypeKindAlias     TypeKind = "alias"
 )
 
 func (t *TypeKind) UnmarshalJSON(data []byte) error {
@@ -589,7 +1108,8 @@ type Type struct {
 
 	Identity // unique id in a repo
 	FileLine
-	Content string // struct declaration content
+	Content   string // struct declaration content
+	DocString string `json:",omitempty"` // leading doc comment of the type, without comment markers
 
 	// field type, type name => type id
 	SubStruct []Dependency `json:",omitempty"`
@@ -603,12 +1123,40 @@ type Type struct {
 	// Implemented interfaces
 	Implements []Identity `json:",omitempty"`
 
+	// generic type parameters, ex: [T any, K comparable]
+	TypeParams []TypeParam `json:",omitempty"`
+
+	// language-specific metadata annotations, see Tag* keys
+	Tags map[string]string `json:",omitempty"`
+
 	// functions defined in fields, key is type name, val is the function Signature
 	// FieldFunctions map[string]string
 
 	CompressData *string `json:"compress_data,omitempty"` // struct llm compress result
 }
 
+// NodeKind returns the specific node kind of the type by its TypeKind, or TYPE if it is a plain typedef
+func (t *Type) NodeKind() NodeType {
+	switch t.TypeKind {
+	case TypeKindStruct:
+		return TypeStruct
+	case TypeKindInterface:
+		return TypeInterface
+	case TypeKindEnum:
+		return TypeEnum
+	case TypeKindAlias:
+		return TypeAlias
+	default:
+		return TYPE
+	}
+}
+
+// TypeParam is a generic type parameter of a type
+type TypeParam struct {
+	Name       string
+	Constraint string // raw constraint expression, ex: any, comparable, ~int | ~string
+}
+
 type Var struct {
 	IsExported bool
 
@@ -618,9 +1166,21 @@ type Var struct {
 	FileLine
 	Type         *Identity `json:",omitempty"`
 	Content      string
+	DocString    string       `json:",omitempty"` // leading doc comment of the var, without comment markers
 	Dependencies []Dependency `json:",omitempty"`
 	// Groups means the var is a group of vars, like Enum in Go
 	Groups []Identity `json:",omitempty"`
 
+	// language-specific metadata annotations, see Tag* keys
+	Tags map[string]string `json:",omitempty"`
+
 	CompressData *string `json:"compress_data,omitempty"`
 }
+
+// NodeKind returns VarConst for constants, or VAR
+func (v *Var) NodeKind() NodeType {
+	if v.IsConst {
+		return VarConst
+	}
+	return VAR
+}
@@ -18,8 +18,15 @@ package uniast
 
 import (
 	"encoding/json"
+	"errors"
+	"math/rand"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/quick"
 
 	"github.com/cloudwego/abcoder/lang/testutils"
 )
@@ -27,7 +34,7 @@ import (
 func TestRepository_BuildGraph(t *testing.T) {
 	astFile := testutils.GetTestAstFile("localsession")
 	r, err := LoadRepo(astFile)
-	if err != nil {
+	if err != nil && !IsVersionMismatch(err) {
 		t.Fatalf("failed to load repo: %v", err)
 	}
 	if err := r.BuildGraph(); err != nil {
@@ -42,3 +49,616 @@ func TestRepository_BuildGraph(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadRepoWithOptions_WithoutGraph(t *testing.T) {
+	astFile := testutils.GetTestAstFile("localsession")
+	full, err := LoadRepo(astFile)
+	if err != nil && !IsVersionMismatch(err) {
+		t.Fatalf("failed to load repo: %v", err)
+	}
+	r, err := LoadRepoWithOptions(astFile, LoadOptions{LoadWithoutGraph: true})
+	if err != nil && !IsVersionMismatch(err) {
+		t.Fatalf("failed to load repo without graph: %v", err)
+	}
+	if r.Graph == nil || len(r.Graph) != 0 {
+		t.Errorf("expect an empty graph, got %d nodes", len(r.Graph))
+	}
+	if r.Name != full.Name || len(r.Modules) != len(full.Modules) || r.TotalNodeCount() != full.TotalNodeCount() {
+		t.Errorf("expect the same modules as LoadRepo, got %d modules and %d nodes", len(r.Modules), r.TotalNodeCount())
+	}
+}
+
+func TestLoadRepo_Checksum(t *testing.T) {
+	r, err := LoadRepo(testutils.GetTestAstFile("localsession"))
+	if err != nil && !IsVersionMismatch(err) {
+		t.Fatalf("failed to load repo: %v", err)
+	}
+	r.ASTVersion = Version
+	if r.Checksum, err = r.ComputeChecksum(); err != nil {
+		t.Fatalf("failed to compute checksum: %v", err)
+	}
+	js, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("failed to marshal repo: %v", err)
+	}
+	dir := t.TempDir()
+
+	ok := dir + "/ok.json"
+	if err := os.WriteFile(ok, js, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRepo(ok); err != nil {
+		t.Fatalf("LoadRepo() with valid checksum error = %v", err)
+	}
+
+	r.ToolVersion = "tampered"
+	js, _ = json.Marshal(r)
+	bad := dir + "/bad.json"
+	if err := os.WriteFile(bad, js, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRepo(bad); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("LoadRepo() error = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestLoadRepo_Version(t *testing.T) {
+	r, err := LoadRepo(testutils.GetTestAstFile("localsession"))
+	if err != nil && !IsVersionMismatch(err) {
+		t.Fatalf("failed to load repo: %v", err)
+	}
+	dir := t.TempDir()
+
+	r.ASTVersion = Version
+	js, _ := json.Marshal(r)
+	cur := dir + "/cur.json"
+	if err := os.WriteFile(cur, js, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRepo(cur); err != nil {
+		t.Fatalf("LoadRepo() with current version error = %v", err)
+	}
+
+	r.ASTVersion = "v0.0.1"
+	js, _ = json.Marshal(r)
+	old := dir + "/old.json"
+	if err := os.WriteFile(old, js, 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadRepo(old)
+	var mismatch *ErrVersionMismatch
+	if !errors.As(err, &mismatch) || mismatch.Got != "v0.0.1" || mismatch.Want != Version {
+		t.Fatalf("LoadRepo() error = %v, want ErrVersionMismatch", err)
+	}
+	if loaded == nil || loaded.Name != r.Name {
+		t.Fatalf("LoadRepo() should return the repository along with ErrVersionMismatch")
+	}
+}
+
+func TestIdentity_ShortID(t *testing.T) {
+	tests := []struct {
+		id   Identity
+		want string
+	}{
+		{NewIdentity("github.com/cloudwego/localsession", "github.com/cloudwego/localsession/backup", "RecoverCtxOnDemands"), "backup#RecoverCtxOnDemands"},
+		{NewIdentity("github.com/cloudwego/localsession", "github.com/cloudwego/localsession", "Session.Get"), "localsession#Session.Get"},
+		{NewIdentity("gopkg.in/yaml.v3", "gopkg.in/yaml.v3", "Marshal"), "yaml.v3#Marshal"},
+		{NewIdentity("com.example:demo:1.0", "com.example.model", "User"), "model#User"},
+		{NewIdentity("demo", "crate::utils::io", "read"), "io#read"},
+		{NewIdentity("main", "main", "main"), "main#main"},
+		{NewIdentity("", "", "Orphan"), "Orphan"},
+	}
+	for _, tt := range tests {
+		if got := tt.id.ShortID(); got != tt.want {
+			t.Errorf("ShortID(%s) = %q, want %q", tt.id.Full(), got, tt.want)
+		}
+	}
+}
+
+// newCountTestRepo creates a repo of one internal module with nPkgs packages,
+// each has nNodes functions, types and vars spread across 10 files.
+func newCountTestRepo(nPkgs, nNodes int) *Repository {
+	repo := NewRepository("r")
+	repo.Modules["ext"] = NewModule("ext", "", Golang)
+	repo.Modules["ext"].Packages["ext"] = NewPackage("ext")
+	repo.Modules["ext"].Packages["ext"].Vars["x"] = &Var{}
+	mod := NewModule("m", ".", Golang)
+	repo.Modules["m"] = mod
+	for i := 0; i < nPkgs; i++ {
+		pkg := NewPackage("p" + strconv.Itoa(i))
+		for j := 0; j < nNodes; j++ {
+			fl := FileLine{File: "f" + strconv.Itoa(j%10) + ".go"}
+			name := strconv.Itoa(j)
+			pkg.Functions["F"+name] = &Function{FileLine: fl}
+			pkg.Types["T"+name] = &Type{FileLine: fl}
+			pkg.Vars["V"+name] = &Var{FileLine: fl}
+		}
+		mod.Packages[pkg.PkgPath] = pkg
+	}
+	return &repo
+}
+
+func TestCountMethods(t *testing.T) {
+	repo := newCountTestRepo(3, 20)
+	if got := repo.Modules["m"].PackageCount(); got != 3 {
+		t.Errorf("PackageCount() = %d, want 3", got)
+	}
+	pkg := repo.Modules["m"].Packages["p0"]
+	if got := pkg.NodeCount(); got != 60 {
+		t.Errorf("NodeCount() = %d, want 60", got)
+	}
+	if got := pkg.FileCount(); got != 10 {
+		t.Errorf("FileCount() = %d, want 10", got)
+	}
+	if got := NewPackage("empty").FileCount(); got != 0 {
+		t.Errorf("FileCount() of empty package = %d, want 0", got)
+	}
+	// external modules are not counted
+	if got := repo.TotalNodeCount(); got != 180 {
+		t.Errorf("TotalNodeCount() = %d, want 180", got)
+	}
+}
+
+func benchmarkFileCount(b *testing.B, nNodes int) {
+	pkg := newCountTestRepo(1, nNodes).Modules["m"].Packages["p0"]
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pkg.FileCount()
+	}
+}
+
+func BenchmarkPackage_FileCount_1K(b *testing.B)  { benchmarkFileCount(b, 1000) }
+func BenchmarkPackage_FileCount_10K(b *testing.B) { benchmarkFileCount(b, 10000) }
+
+func benchmarkTotalNodeCount(b *testing.B, nPkgs int) {
+	repo := newCountTestRepo(nPkgs, 10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		repo.TotalNodeCount()
+	}
+}
+
+func BenchmarkRepository_TotalNodeCount_1K(b *testing.B)  { benchmarkTotalNodeCount(b, 1000) }
+func BenchmarkRepository_TotalNodeCount_10K(b *testing.B) { benchmarkTotalNodeCount(b, 10000) }
+
+func TestRepository_AllNodes(t *testing.T) {
+	repo := NewRepository("r")
+	ext := NewModule("ext", "", Golang)
+	ext.Packages["e"] = NewPackage("e")
+	ext.Packages["e"].Functions["E"] = &Function{Identity: NewIdentity("ext", "e", "E")}
+	repo.Modules["ext"] = ext
+	mod := NewModule("m", ".", Golang)
+	repo.Modules["m"] = mod
+	for _, p := range []PkgPath{"p0", "p1"} {
+		pkg := NewPackage(p)
+		pkg.Functions["F"] = &Function{Identity: NewIdentity("m", p, "F")}
+		pkg.Types["T"] = &Type{Identity: NewIdentity("m", p, "T")}
+		pkg.Vars["V"] = &Var{Identity: NewIdentity("m", p, "V")}
+		mod.Packages[p] = pkg
+	}
+
+	var fns, types, vars int
+	for id, fn := range repo.AllFunctions {
+		if id != fn.Identity || id.ModPath != "m" {
+			t.Errorf("unexpected function %s", id.Full())
+		}
+		fns++
+	}
+	for range repo.AllTypes {
+		types++
+	}
+	for range repo.AllVars {
+		vars++
+	}
+	if fns != 2 || types != 2 || vars != 2 {
+		t.Errorf("AllFunctions/AllTypes/AllVars = %d/%d/%d, want 2/2/2", fns, types, vars)
+	}
+
+	seen := map[string]NodeType{}
+	for id, n := range repo.AllNodes {
+		if n.Identity != id {
+			t.Errorf("node %s yielded with identity %s", n.Full(), id.Full())
+		}
+		seen[id.Full()] = n.Type
+	}
+	if len(seen) != 6 {
+		t.Errorf("AllNodes yielded %d nodes, want 6", len(seen))
+	}
+	if seen[NewIdentity("m", "p0", "T").Full()] != TYPE {
+		t.Errorf("node type of T should be TYPE")
+	}
+
+	// break stops the iteration
+	n := 0
+	for range repo.AllNodes {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("iteration should stop after break, got %d", n)
+	}
+}
+
+// newCallGraphRepo builds a repo of n functions f0..f{n-1}, where calls[i] are the callees of fi
+func newCallGraphRepo(n int, calls map[int][]int) *Repository {
+	repo := NewRepository("r")
+	mod := NewModule("m", ".", Golang)
+	pkg := NewPackage("p")
+	mod.Packages["p"] = pkg
+	repo.Modules["m"] = mod
+	for i := 0; i < n; i++ {
+		name := "f" + strconv.Itoa(i)
+		fn := &Function{Identity: NewIdentity("m", "p", name)}
+		for _, j := range calls[i] {
+			fn.FunctionCalls = append(fn.FunctionCalls, Dependency{Identity: NewIdentity("m", "p", "f"+strconv.Itoa(j))})
+		}
+		pkg.Functions[name] = fn
+	}
+	repo.BuildGraph()
+	return &repo
+}
+
+func TestNodeGraph_Subgraph(t *testing.T) {
+	// f0 -> f1 -> f2, f3 -> f2
+	repo := newCallGraphRepo(4, map[int][]int{0: {1}, 1: {2}, 3: {2}})
+	id := func(i int) Identity { return NewIdentity("m", "p", "f"+strconv.Itoa(i)) }
+	tests := []struct {
+		seeds     []Identity
+		direction EdgeDirection
+		maxHops   int
+		want      []int
+	}{
+		{[]Identity{id(0)}, Forward, -1, []int{0, 1, 2}},
+		{[]Identity{id(0)}, Forward, 1, []int{0, 1}},
+		{[]Identity{id(0)}, Forward, 0, []int{0}},
+		{[]Identity{id(2)}, Forward, -1, []int{2}},
+		{[]Identity{id(2)}, Backward, -1, []int{0, 1, 2, 3}},
+		{[]Identity{id(2)}, Backward, 1, []int{1, 2, 3}},
+		{[]Identity{id(0)}, Both, 2, []int{0, 1, 2}},
+		{[]Identity{id(0)}, Both, 3, []int{0, 1, 2, 3}},
+		{[]Identity{id(0), id(3)}, Forward, 1, []int{0, 1, 2, 3}},
+		{[]Identity{NewIdentity("m", "p", "missing")}, Both, -1, nil},
+	}
+	for _, tt := range tests {
+		got := repo.Graph.Subgraph(tt.seeds, tt.direction, tt.maxHops)
+		if len(got) != len(tt.want) {
+			t.Errorf("Subgraph(%v, %d, %d) has %d nodes, want %v", tt.seeds, tt.direction, tt.maxHops, len(got), tt.want)
+			continue
+		}
+		for _, i := range tt.want {
+			if got[id(i).Full()] == nil {
+				t.Errorf("Subgraph(%v, %d, %d) misses f%d", tt.seeds, tt.direction, tt.maxHops, i)
+			}
+		}
+	}
+}
+
+func TestNodeGraph_Subgraph_Connected(t *testing.T) {
+	// property: the unlimited subgraph in both directions of a connected graph is the full graph, from any seed
+	property := func(seed int64, size uint8) bool {
+		rnd := rand.New(rand.NewSource(seed))
+		n := int(size)%50 + 1
+		calls := make(map[int][]int)
+		// a random spanning tree keeps the graph connected, with random edge directions
+		for i := 1; i < n; i++ {
+			j := rnd.Intn(i)
+			if rnd.Intn(2) == 0 {
+				calls[i] = append(calls[i], j)
+			} else {
+				calls[j] = append(calls[j], i)
+			}
+		}
+		for k := rnd.Intn(n + 1); k > 0; k-- {
+			i, j := rnd.Intn(n), rnd.Intn(n)
+			calls[i] = append(calls[i], j)
+		}
+		repo := newCallGraphRepo(n, calls)
+
+		start := NewIdentity("m", "p", "f"+strconv.Itoa(rnd.Intn(n)))
+		got := repo.Graph.Subgraph([]Identity{start}, Both, -1)
+		if len(got) != len(repo.Graph) {
+			return false
+		}
+		for key, node := range repo.Graph {
+			if got[key] != node {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRepository_SplitByModule(t *testing.T) {
+	repo := NewRepository("ws")
+	for _, m := range []string{"a", "b"} {
+		mod := NewModule(m, m, Golang)
+		mod.Packages[m] = NewPackage(m)
+		repo.Modules[m] = mod
+	}
+	repo.Modules["ext1"] = NewModule("ext1", "", Golang)
+	repo.Modules["ext2"] = NewModule("ext2", "", Golang)
+	// a.F calls ext1.X and b.G, b.T implements ext2.I
+	repo.Modules["a"].Packages["a"].Functions["F"] = &Function{
+		Identity:      NewIdentity("a", "a", "F"),
+		FileLine:      FileLine{File: "a.go", Line: 1},
+		Content:       "func F() {}",
+		FunctionCalls: []Dependency{{Identity: NewIdentity("ext1", "ext1", "X")}, {Identity: NewIdentity("b", "b", "G")}},
+	}
+	repo.Modules["b"].Packages["b"].Types["T"] = &Type{
+		Identity:   NewIdentity("b", "b", "T"),
+		FileLine:   FileLine{File: "b.go", Line: 1},
+		Content:    "type T struct{}",
+		Implements: []Identity{NewIdentity("ext2", "ext2", "I")},
+	}
+
+	subs := repo.SplitByModule()
+	if len(subs) != 2 {
+		t.Fatalf("SplitByModule() returns %d repos, want 2", len(subs))
+	}
+	wantMods := map[string][]string{"a": {"a", "ext1"}, "b": {"b", "ext2"}}
+	for name, want := range wantMods {
+		sub := subs[name]
+		if sub == nil {
+			t.Fatalf("missing sub repo %s", name)
+		}
+		if sub.Name != repo.Name || sub.Path != repo.Path {
+			t.Errorf("sub repo %s name/path = %s/%s, want %s/%s", name, sub.Name, sub.Path, repo.Name, repo.Path)
+		}
+		var got []string
+		for m := range sub.Modules {
+			got = append(got, m)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("sub repo %s modules = %v, want %v", name, got, want)
+		}
+		if err := ValidateRepository(sub); err != nil {
+			t.Errorf("ValidateRepository(%s) error = %v", name, err)
+		}
+	}
+	if subs["a"].GetNode(NewIdentity("b", "b", "T")) != nil {
+		t.Errorf("sub repo a should not contain nodes of module b")
+	}
+}
+
+func TestRepository_FilterPackages(t *testing.T) {
+	repo := NewRepository("ws")
+	mod := NewModule("m", ".", Golang)
+	repo.Modules["m"] = mod
+	repo.Modules["ext"] = NewModule("ext", "", Golang)
+	for _, p := range []PkgPath{"m/service", "m/dao"} {
+		mod.Packages[p] = NewPackage(p)
+		mod.Files[string(p)+"/x.go"] = &File{Path: string(p) + "/x.go", Package: p}
+	}
+	// service.F calls dao.G
+	mod.Packages["m/service"].Functions["F"] = &Function{
+		Identity:      NewIdentity("m", "m/service", "F"),
+		FileLine:      FileLine{File: "m/service/x.go", Line: 1},
+		Content:       "func F() {}",
+		FunctionCalls: []Dependency{{Identity: NewIdentity("m", "m/dao", "G")}},
+	}
+	mod.Packages["m/dao"].Functions["G"] = &Function{
+		Identity: NewIdentity("m", "m/dao", "G"),
+		FileLine: FileLine{File: "m/dao/x.go", Line: 1},
+		Content:  "func G() {}",
+	}
+	repo.BuildGraph()
+
+	view := repo.FilterPackages(func(_ string, pkg PkgPath, _ *Package) bool {
+		return strings.HasSuffix(string(pkg), "/service")
+	})
+	if got := len(view.Modules["m"].Packages); got != 1 || view.Modules["m"].Packages["m/service"] == nil {
+		t.Fatalf("view packages = %v, want only m/service", view.Modules["m"].Packages)
+	}
+	if len(view.Modules["m"].Files) != 1 || view.Modules["ext"] == nil {
+		t.Errorf("view files = %v, modules = %v", view.Modules["m"].Files, view.Modules)
+	}
+	// the original is untouched
+	if len(repo.Modules["m"].Packages) != 2 || len(repo.Modules["m"].Files) != 2 || repo.GetNode(NewIdentity("m", "m/dao", "G")).Type != FUNC {
+		t.Errorf("FilterPackages() must not mutate the repository")
+	}
+	// the dependency crossing the filter is kept as a stub
+	f := view.GetNode(NewIdentity("m", "m/service", "F"))
+	if f == nil || len(f.Dependencies) != 1 {
+		t.Fatalf("node F = %+v, want one dependency", f)
+	}
+	if g := view.GetNode(NewIdentity("m", "m/dao", "G")); g == nil || g.Type != UNKNOWN || len(g.References) != 1 {
+		t.Errorf("node G = %+v, want an untyped stub referenced by F", g)
+	}
+
+	// composable with SplitByModule and itself
+	if subs := view.SplitByModule(); len(subs) != 1 || subs["m"] == nil {
+		t.Errorf("SplitByModule() of the view = %v", subs)
+	}
+	if empty := view.FilterPackages(func(string, PkgPath, *Package) bool { return false }); empty.Modules["m"] != nil || len(empty.Graph) != 0 {
+		t.Errorf("filtering out all packages should leave no internal module")
+	}
+}
+
+func TestRepository_Clone(t *testing.T) {
+	repo := NewRepository("ws")
+	mod := NewModule("m", ".", Golang)
+	repo.Modules["m"] = mod
+	pkg := NewPackage("m/service")
+	mod.Packages[pkg.PkgPath] = pkg
+	mod.Files["service/x.go"] = &File{Path: "service/x.go", Package: pkg.PkgPath}
+	pkg.Functions["F"] = &Function{Identity: NewIdentity("m", "m/service", "F"), Content: "func F() {}"}
+	repo.BuildGraph()
+
+	clone := repo.Clone()
+	if clone.Name != repo.Name || clone.Modules["m"] == mod || clone.Modules["m"].Packages["m/service"] == pkg {
+		t.Fatalf("Clone() should copy the modules and packages")
+	}
+	if clone.Modules["m"].Packages["m/service"].Functions["F"] != pkg.Functions["F"] {
+		t.Errorf("Clone() should share the nodes")
+	}
+	if n := clone.GetNode(NewIdentity("m", "m/service", "F")); n == nil || n != repo.Graph[n.Identity.Full()] {
+		t.Errorf("Clone() should copy the graph sharing its nodes, got %+v", n)
+	}
+
+	// mutating the copy leaves the original untouched
+	clone.Modules["m"].Packages["m/service"].Functions["G"] = &Function{Identity: NewIdentity("m", "m/service", "G")}
+	clone.Modules["m"].Packages["m/dao"] = NewPackage("m/dao")
+	clone.Modules["m"].Files["dao/x.go"] = &File{Path: "dao/x.go"}
+	clone.Modules["ext"] = NewModule("ext", "", Golang)
+	delete(clone.Graph, NewIdentity("m", "m/service", "F").Full())
+	if len(pkg.Functions) != 1 || len(mod.Packages) != 1 || len(mod.Files) != 1 || len(repo.Modules) != 1 || len(repo.Graph) != 1 {
+		t.Errorf("mutating the clone must not mutate the repository")
+	}
+}
+
+func TestRepository_RenamePackage(t *testing.T) {
+	repo := NewRepository("ws")
+	mod := NewModule("m", ".", Golang)
+	repo.Modules["m"] = mod
+	for _, p := range []PkgPath{"com/example/service", "m/api"} {
+		mod.Packages[p] = NewPackage(p)
+		mod.Files[string(p)+"/x.go"] = &File{Path: string(p) + "/x.go", Package: p}
+	}
+	svc, api := mod.Packages["com/example/service"], mod.Packages["m/api"]
+	svc.Types["S"] = &Type{
+		Identity: NewIdentity("m", "com/example/service", "S"),
+		FileLine: FileLine{File: "com/example/service/x.go", Line: 1},
+		Content:  "type S struct{}",
+		Methods:  map[string]Identity{"Run": NewIdentity("m", "com/example/service", "S.Run")},
+	}
+	svc.Functions["S.Run"] = &Function{
+		Identity: NewIdentity("m", "com/example/service", "S.Run"),
+		FileLine: FileLine{File: "com/example/service/x.go", Line: 3},
+		Content:  "func (s S) Run() {}",
+		IsMethod: true,
+		Receiver: &Receiver{Type: NewIdentity("m", "com/example/service", "S")},
+	}
+	// api.H calls service.S.Run, api.V is of type service.S
+	api.Functions["H"] = &Function{
+		Identity:    NewIdentity("m", "m/api", "H"),
+		FileLine:    FileLine{File: "m/api/x.go", Line: 1},
+		Content:     "func H() {}",
+		MethodCalls: []Dependency{{Identity: NewIdentity("m", "com/example/service", "S.Run")}},
+	}
+	api.Vars["V"] = &Var{
+		Identity: NewIdentity("m", "m/api", "V"),
+		FileLine: FileLine{File: "m/api/x.go", Line: 3},
+		Content:  "var V service.S",
+		Type:     &Identity{ModPath: "m", PkgPath: "com/example/service", Name: "S"},
+	}
+	repo.BuildGraph()
+
+	if err := repo.RenamePackage("m", "m/api", "com/example/service"); err == nil {
+		t.Errorf("renaming to an existing package should fail")
+	}
+	if err := repo.RenamePackage("m", "m/none", "none"); err == nil {
+		t.Errorf("renaming a missing package should fail")
+	}
+	if err := repo.RenamePackage("m", "com/example/service", "service"); err != nil {
+		t.Fatalf("RenamePackage() error = %v", err)
+	}
+
+	if mod.Packages["com/example/service"] != nil || mod.Packages["service"] != svc || svc.PkgPath != "service" {
+		t.Fatalf("packages = %v, want service renamed", mod.Packages)
+	}
+	if f := mod.Files["com/example/service/x.go"]; f.Package != "service" {
+		t.Errorf("file package = %s, want service", f.Package)
+	}
+	run := NewIdentity("m", "service", "S.Run")
+	if svc.Functions["S.Run"].Identity != run || svc.Functions["S.Run"].Receiver.Type.PkgPath != "service" || svc.Types["S"].Methods["Run"] != run {
+		t.Errorf("the nodes of the package are not renamed")
+	}
+	if api.Functions["H"].MethodCalls[0].Identity != run || api.Vars["V"].Type.PkgPath != "service" {
+		t.Errorf("the references to the package are not renamed")
+	}
+	if n := repo.GetNode(NewIdentity("m", "com/example/service", "S")); n != nil {
+		t.Errorf("the graph still has the old node %v", n.Identity)
+	}
+	if n := repo.GetNode(run); n == nil || n.Type != FUNC || len(n.References) != 1 || n.References[0].Identity != api.Functions["H"].Identity {
+		t.Errorf("node S.Run = %+v, want a function referenced by H", n)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	newRepo := func(path string, mods ...*Module) *Repository {
+		repo := NewRepository(path)
+		repo.Path = path
+		for _, mod := range mods {
+			repo.Modules[mod.Name] = mod
+		}
+		return &repo
+	}
+	newMod := func(name, dir string, fns ...*Function) *Module {
+		mod := NewModule(name, dir, Golang)
+		pkg := NewPackage(name)
+		mod.Packages[name] = pkg
+		for _, fn := range fns {
+			pkg.Functions[fn.Name] = fn
+		}
+		return mod
+	}
+	g := NewIdentity("b", "b", "G")
+	// repo a calls b.G of the external module b
+	dst := newRepo("/src/a", newMod("a", ".", &Function{
+		Identity:      NewIdentity("a", "a", "F"),
+		Content:       "func F() { b.G() }",
+		FunctionCalls: []Dependency{{Identity: g}},
+	}), newMod("b", ""))
+	dst.BuildGraph()
+	// repo b holds b.G and a module a of its own
+	src := newRepo("/src/b", newMod("b", ".", &Function{Identity: g, Content: "func G() {}"}),
+		newMod("a", "tools", &Function{Identity: NewIdentity("a", "a", "X"), Content: "func X() {}"}))
+	src.BuildGraph()
+	src.GetNode(g).Annotations = map[string]string{"owner": "b"}
+
+	if err := Merge(dst, src); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if mod := dst.Modules["b"]; mod == nil || mod.IsExternal() {
+		t.Errorf("module b = %+v, want the internal module of repo b", mod)
+	}
+	if mod := dst.Modules["a"]; mod == nil || mod.Dir != "." {
+		t.Errorf("module a = %+v, want the module of repo a", mod)
+	}
+	x := NewIdentity("/src/b/a", "/src/b/a", "X")
+	if n := dst.GetNode(x); n == nil || n.Type != FUNC || dst.Modules["/src/b/a"].Dir != "tools" {
+		t.Errorf("the conflicting module a of repo b should be renamed to /src/b/a, got node %+v", n)
+	}
+	n := dst.GetNode(g)
+	if n == nil || n.Type != FUNC || len(n.References) != 1 || n.References[0].Identity != NewIdentity("a", "a", "F") {
+		t.Errorf("node b.G = %+v, want a function referenced by a.F", n)
+	} else if n.Annotations["owner"] != "b" {
+		t.Errorf("the annotations of b.G are lost: %v", n.Annotations)
+	}
+
+	// merging the same repo again conflicts with the renamed module
+	again := newRepo("/src/b", newMod("a", "tools"))
+	if err := Merge(dst, again); err == nil {
+		t.Errorf("Merge() should fail when the renamed module exists")
+	}
+}
+
+func TestNodeType_JSON(t *testing.T) {
+	for _, typ := range []NodeType{FUNC, TYPE, VAR, TypeStruct, TypeInterface, TypeEnum, TypeAlias, VarConst, UNKNOWN} {
+		bs, err := json.Marshal(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got NodeType
+		if err := json.Unmarshal(bs, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got != typ {
+			t.Errorf("%s round-trips to %s", bs, got)
+		}
+	}
+
+	// the lower-case aliases parse to the kinds, whose base is TYPE or VAR
+	for name, want := range map[string]NodeType{"function": FUNC, "variable": VAR, "struct": TypeStruct, "const": VarConst} {
+		if got := NewNodeType(name); got != want {
+			t.Errorf("NewNodeType(%q) = %s, want %s", name, got, want)
+		}
+	}
+	if NewNodeType("struct").Base() != TYPE || NewNodeType("const").Base() != VAR {
+		t.Errorf("expect the base of struct and const to be TYPE and VAR")
+	}
+}
@@ -15,6 +15,7 @@
 package uniast
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -159,63 +160,70 @@ func (r *Repository) AllNodesSetRepo() {
 }
 
 func (r *Repository) BuildGraph() error {
+	// annotations are not derived from the nodes, keep them across rebuilds
+	annotations := make(map[string]map[string]string)
+	for key, n := range r.Graph {
+		if n != nil && len(n.Annotations) > 0 {
+			annotations[key] = n.Annotations
+		}
+	}
 	r.Graph = make(map[string]*Node)
-	for _, mod := range r.Modules {
-		if mod.IsExternal() {
-			continue
-		}
-		for _, pkg := range mod.Packages {
-			for _, f := range pkg.Functions {
-				n := r.SetNode(f.Identity, FUNC)
-				for _, dep := range f.Params {
-					r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
-				}
-				for _, dep := range f.Results {
-					r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
-				}
-				for _, dep := range f.FunctionCalls {
-					r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
-				}
-				for _, dep := range f.MethodCalls {
-					r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
-				}
-				for _, dep := range f.Types {
-					r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
-				}
-				// NOTICE: We regard the receiver of a method as a dependency of the method
-				if f.Receiver != nil {
-					r.AddRelation(n, f.Receiver.Type, n.FileLine(), DEPENDENCY)
-				}
-				for _, dep := range f.GlobalVars {
-					r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
-				}
+	defer func() {
+		for key, a := range annotations {
+			if n, ok := r.Graph[key]; ok {
+				n.Annotations = a
 			}
+		}
+	}()
+	for _, f := range r.AllFunctions {
+		n := r.SetNode(f.Identity, FUNC)
+		for _, dep := range f.Params {
+			r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
+		}
+		for _, dep := range f.Results {
+			r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
+		}
+		for _, dep := range f.FunctionCalls {
+			r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
+		}
+		for _, dep := range f.MethodCalls {
+			r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
+		}
+		for _, dep := range f.Types {
+			r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
+		}
+		// NOTICE: We regard the receiver of a method as a dependency of the method
+		if f.Receiver != nil {
+			r.AddRelation(n, f.Receiver.Type, n.FileLine(), DEPENDENCY)
+		}
+		for _, dep := range f.GlobalVars {
+			r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
+		}
+	}
 
-			for _, t := range pkg.Types {
-				n := r.SetNode(t.Identity, TYPE)
-				for _, dep := range t.SubStruct {
-					r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
-				}
-				for _, dep := range t.InlineStruct {
-					r.AddRelation(n, dep.Identity, dep.FileLine, INHERIT)
-				}
-				for _, dep := range t.Implements {
-					r.AddRelation(n, dep, n.FileLine(), IMPLEMENT)
-				}
-			}
+	for _, t := range r.AllTypes {
+		n := r.SetNode(t.Identity, TYPE)
+		for _, dep := range t.SubStruct {
+			r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
+		}
+		for _, dep := range t.InlineStruct {
+			r.AddRelation(n, dep.Identity, dep.FileLine, INHERIT)
+		}
+		for _, dep := range t.Implements {
+			r.AddRelation(n, dep, n.FileLine(), IMPLEMENT)
+		}
+	}
 
-			for _, v := range pkg.Vars {
-				n := r.SetNode(v.Identity, VAR)
-				if v.Type != nil {
-					r.AddRelation(n, *v.Type, v.FileLine, DEPENDENCY)
-				}
-				for _, dep := range v.Dependencies {
-					r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
-				}
-				for _, dep := range v.Groups {
-					r.AddRelation(n, dep, n.FileLine(), GROUP)
-				}
-			}
+	for _, v := range r.AllVars {
+		n := r.SetNode(v.Identity, VAR)
+		if v.Type != nil {
+			r.AddRelation(n, *v.Type, v.FileLine, DEPENDENCY)
+		}
+		for _, dep := range v.Dependencies {
+			r.AddRelation(n, dep.Identity, dep.FileLine, DEPENDENCY)
+		}
+		for _, dep := range v.Groups {
+			r.AddRelation(n, dep, n.FileLine(), GROUP)
 		}
 	}
 	return nil
@@ -275,6 +283,20 @@ const (
 	TYPE
 	// Global Varable or Global Const
 	VAR
+
+	// The specific kinds of TYPE and VAR nodes, see Type.NodeKind and Var.NodeKind.
+	// Graph nodes are always FUNC, TYPE or VAR, use NodeType.Base to compare a kind with them.
+
+	// struct or class
+	TypeStruct
+	// interface or trait
+	TypeInterface
+	// enum
+	TypeEnum
+	// type alias, e.g. Go `type A = B` or TypeScript `type A = B`
+	TypeAlias
+	// global constant
+	VarConst
 )
 
 func (t NodeType) String() string {
@@ -285,11 +307,33 @@ func (t NodeType) String() string {
 		return "TYPE"
 	case VAR:
 		return "VAR"
+	case TypeStruct:
+		return "STRUCT"
+	case TypeInterface:
+		return "INTERFACE"
+	case TypeEnum:
+		return "ENUM"
+	case TypeAlias:
+		return "ALIAS"
+	case VarConst:
+		return "CONST"
 	default:
 		return "UNKNOWN"
 	}
 }
 
+// Base returns the node type of the kind t, e.g. TYPE for TypeEnum
+func (t NodeType) Base() NodeType {
+	switch t {
+	case TypeStruct, TypeInterface, TypeEnum, TypeAlias:
+		return TYPE
+	case VarConst:
+		return VAR
+	default:
+		return t
+	}
+}
+
 func (t NodeType) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + t.String() + `"`), nil
 }
@@ -304,14 +348,26 @@ func (t *NodeType) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// NewNodeType parses the node type named typ, case-insensitively.
+// The kind names like "struct" or "const" return the specific kinds, use NodeType.Base to get TYPE or VAR
 func NewNodeType(typ string) NodeType {
 	switch strings.ToLower(typ) {
 	case "func", "function":
 		return FUNC
-	case "type", "struct":
+	case "type":
 		return TYPE
-	case "var", "variable", "variant", "const":
+	case "var", "variable", "variant":
 		return VAR
+	case "struct", "class":
+		return TypeStruct
+	case "interface", "trait":
+		return TypeInterface
+	case "enum":
+		return TypeEnum
+	case "alias":
+		return TypeAlias
+	case "const":
+		return VarConst
 	default:
 		return UNKNOWN
 	}
@@ -333,10 +389,46 @@ type Node struct {
 	Inherits []Relation `json:",omitempty"`
 	// other nodes in the same definition group
 	Groups []Relation `json:",omitempty"`
+	// key-value metadata attached by downstream tools, see AnnotateNode
+	Annotations map[string]string `json:",omitempty"`
 	// the repo that this node belongs to
 	Repo *Repository `json:"-"`
 }
 
+const (
+	// AnnotationReviewNeeded marks a node to be reviewed by human, e.g. "true"
+	AnnotationReviewNeeded = "review_needed"
+	// AnnotationTranslationQuality is the estimated quality of the translated node, e.g. "0.7"
+	AnnotationTranslationQuality = "translation_quality"
+	// AnnotationNote is a free-form note about the node
+	AnnotationNote = "note"
+)
+
+// KnownAnnotationKeys are the annotation keys ValidateRepository doesn't warn about.
+// Tools using their own keys can add them here.
+var KnownAnnotationKeys = map[string]bool{
+	AnnotationReviewNeeded:       true,
+	AnnotationTranslationQuality: true,
+	AnnotationNote:               true,
+}
+
+// AnnotateNode sets the annotation key of the node id to value,
+// which is kept in the Graph of the repository.
+func (r *Repository) AnnotateNode(id Identity, key, value string) error {
+	if key == "" {
+		return fmt.Errorf("empty annotation key for node %s", id.Full())
+	}
+	node := r.GetNode(id)
+	if node == nil {
+		return fmt.Errorf("node %s not found", id.Full())
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[key] = value
+	return nil
+}
+
 func (n Node) GetDependency(id Identity) *Relation {
 	for i, dep := range n.Dependencies {
 		if dep.Identity == id {
@@ -462,6 +554,50 @@ func (n Node) CompressData() *string {
 	return nil
 }
 
+// Tags returns the language-specific metadata annotations of the node
+func (n Node) Tags() map[string]string {
+	if n.Repo == nil {
+		return nil
+	}
+	switch n.Type {
+	case FUNC:
+		if f := n.Repo.GetFunction(n.Identity); f != nil {
+			return f.Tags
+		}
+	case TYPE:
+		if f := n.Repo.GetType(n.Identity); f != nil {
+			return f.Tags
+		}
+	case VAR:
+		if f := n.Repo.GetVar(n.Identity); f != nil {
+			return f.Tags
+		}
+	}
+	return nil
+}
+
+// DocString returns the leading doc comment of the node, without comment markers
+func (n Node) DocString() string {
+	if n.Repo == nil {
+		return ""
+	}
+	switch n.Type {
+	case FUNC:
+		if f := n.Repo.GetFunction(n.Identity); f != nil {
+			return f.DocString
+		}
+	case TYPE:
+		if f := n.Repo.GetType(n.Identity); f != nil {
+			return f.DocString
+		}
+	case VAR:
+		if f := n.Repo.GetVar(n.Identity); f != nil {
+			return f.DocString
+		}
+	}
+	return ""
+}
+
 func (n Node) FileLine() FileLine {
 	if n.Repo == nil {
 		return FileLine{}
@@ -595,3 +731,57 @@ func (n Node) Signature() string {
 	}
 	return ""
 }
+
+// EdgeDirection is the direction of the edges to follow when traversing a NodeGraph
+type EdgeDirection int
+
+const (
+	// Forward follows the Dependencies of nodes
+	Forward EdgeDirection = iota
+	// Backward follows the References of nodes
+	Backward
+	// Both follows both the Dependencies and References of nodes
+	Both
+)
+
+// Subgraph returns the nodes reachable from seeds (included) within maxHops edges in the direction.
+// maxHops < 0 means no limit. Seeds and relations not in the graph are ignored.
+// NOTICE: the returned graph shares the nodes with g
+func (g NodeGraph) Subgraph(seeds []Identity, direction EdgeDirection, maxHops int) NodeGraph {
+	ret := make(NodeGraph)
+	var queue []*Node
+	for _, seed := range seeds {
+		key := seed.Full()
+		if node, ok := g[key]; ok && ret[key] == nil {
+			ret[key] = node
+			queue = append(queue, node)
+		}
+	}
+
+	visit := func(next []*Node, rels []Relation) []*Node {
+		for _, rel := range rels {
+			key := rel.Identity.Full()
+			if ret[key] != nil {
+				continue
+			}
+			if node, ok := g[key]; ok {
+				ret[key] = node
+				next = append(next, node)
+			}
+		}
+		return next
+	}
+	for hops := 0; len(queue) > 0 && (maxHops < 0 || hops < maxHops); hops++ {
+		var next []*Node
+		for _, node := range queue {
+			if direction == Forward || direction == Both {
+				next = visit(next, node.Dependencies)
+			}
+			if direction == Backward || direction == Both {
+				next = visit(next, node.References)
+			}
+		}
+		queue = next
+	}
+	return ret
+}
@@ -17,10 +17,35 @@
 package uniast
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 )
 
+// ErrChecksumMismatch is returned by LoadRepo when the loaded AST does not match its recorded checksum,
+// which usually means the AST file is truncated or corrupted.
+var ErrChecksumMismatch = errors.New("repository checksum mismatch")
+
+// ErrVersionMismatch is returned by LoadRepo along with the loaded repository
+// when the AST was written by another UniAST version, so that the caller can decide whether to re-parse.
+type ErrVersionMismatch struct {
+	Got  string // ASTVersion of the loaded AST
+	Want string // current Version
+}
+
+func (e *ErrVersionMismatch) Error() string {
+	return fmt.Sprintf("uniast version mismatch: got %q, want %q", e.Got, e.Want)
+}
+
+// IsVersionMismatch tells if err is (or wraps) an *ErrVersionMismatch
+func IsVersionMismatch(err error) bool {
+	var e *ErrVersionMismatch
+	return errors.As(err, &e)
+}
+
 func Append[T comparable](ids []T, id T) []T {
 	for _, i := range ids {
 		if i == id {
@@ -57,15 +82,68 @@ func InsertRelation(ids []Relation, id Relation) []Relation {
 	return append(ids, id)
 }
 
+// LoadRepo loads the repository AST from the JSON file.
+// If the AST version differs from Version, the repository is returned with an *ErrVersionMismatch.
 func LoadRepo(path string) (*Repository, error) {
+	return LoadRepoWithOptions(path, LoadOptions{})
+}
+
+// LoadOptions is the options for loading the repository AST
+type LoadOptions struct {
+	// LoadWithoutGraph skips decoding the Graph, which is left empty.
+	// The checksum isn't verified then, since it covers the graph.
+	LoadWithoutGraph bool
+}
+
+// skippedJSON discards the JSON value decoded into it
+type skippedJSON struct{}
+
+func (skippedJSON) UnmarshalJSON([]byte) error { return nil }
+
+// LoadRepoWithOptions loads the repository AST from the JSON file like LoadRepo
+func LoadRepoWithOptions(path string, opts LoadOptions) (*Repository, error) {
 	bs, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 	var repo Repository
-	if err := json.Unmarshal(bs, &repo); err != nil {
+	if opts.LoadWithoutGraph {
+		// the Graph field shadows the one of Repository
+		withoutGraph := struct {
+			*Repository
+			Graph skippedJSON
+		}{Repository: &repo}
+		if err := json.Unmarshal(bs, &withoutGraph); err != nil {
+			return nil, err
+		}
+		repo.Graph = NodeGraph{}
+	} else if err := json.Unmarshal(bs, &repo); err != nil {
 		return nil, err
 	}
+	if repo.Checksum != "" && !opts.LoadWithoutGraph {
+		sum, err := repo.ComputeChecksum()
+		if err != nil {
+			return nil, err
+		}
+		if sum != repo.Checksum {
+			return nil, fmt.Errorf("%w: %s, expect %s, got %s", ErrChecksumMismatch, path, repo.Checksum, sum)
+		}
+	}
 	repo.AllNodesSetRepo()
+	if repo.ASTVersion != Version {
+		return &repo, &ErrVersionMismatch{Got: repo.ASTVersion, Want: Version}
+	}
 	return &repo, nil
 }
+
+// ComputeChecksum returns the hex-encoded sha256 of the repository JSON, excluding the Checksum field itself.
+func (r *Repository) ComputeChecksum() (string, error) {
+	cp := *r
+	cp.Checksum = ""
+	bs, err := json.Marshal(cp)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(bs)
+	return hex.EncodeToString(h[:]), nil
+}
@@ -16,6 +16,7 @@ package uniast
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -25,6 +26,8 @@ type ValidationSeverity string
 const (
 	SeverityFatal       ValidationSeverity = "fatal"
 	SeverityRecoverable ValidationSeverity = "recoverable"
+	// SeverityWarning is for issues that don't fail the validation, see ValidationResult.Warnings
+	SeverityWarning ValidationSeverity = "warning"
 )
 
 // ValidationErrorItem is a single validation issue with optional node identity for reporting.
@@ -39,6 +42,8 @@ type ValidationResult struct {
 	Ok       bool
 	Errors   []ValidationErrorItem
 	Severity ValidationSeverity // overall: if any Fatal, result is Fatal; else Recoverable
+	// Warnings are issues not affecting Ok, e.g. unknown annotation keys
+	Warnings []ValidationErrorItem
 }
 
 // ValidationError collects multiple validation failures so callers can see all issues at once.
@@ -113,8 +118,9 @@ func ValidateRepositoryWithResult(repo *Repository) ValidationResult {
 		}
 	}
 
+	warnings := validateAnnotations(repo)
 	if len(items) == 0 {
-		return ValidationResult{Ok: true}
+		return ValidationResult{Ok: true, Warnings: warnings}
 	}
 	severity := SeverityFatal
 	for _, it := range items {
@@ -124,7 +130,28 @@ func ValidateRepositoryWithResult(repo *Repository) ValidationResult {
 		}
 		severity = SeverityRecoverable
 	}
-	return ValidationResult{Ok: false, Errors: items, Severity: severity}
+	return ValidationResult{Ok: false, Errors: items, Severity: severity, Warnings: warnings}
+}
+
+// validateAnnotations warns about the annotation keys of the graph nodes not in KnownAnnotationKeys
+func validateAnnotations(repo *Repository) []ValidationErrorItem {
+	var items []ValidationErrorItem
+	for key, n := range repo.Graph {
+		if n == nil {
+			continue
+		}
+		for k := range n.Annotations {
+			if !KnownAnnotationKeys[k] {
+				items = append(items, ValidationErrorItem{
+					Message:  fmt.Sprintf("node %s has unknown annotation key %q", key, k),
+					Severity: SeverityWarning,
+					NodeID:   key,
+				})
+			}
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Message < items[j].Message })
+	return items
 }
 
 func validateModuleWithResult(modName string, mod *Module) []ValidationErrorItem {
@@ -152,6 +179,14 @@ func validateModuleWithResult(modName string, mod *Module) []ValidationErrorItem
 		}
 		items = append(items, validatePackageWithResult(modName, pkgPath, pkg, mod.Language)...)
 	}
+	for path, f := range mod.Files {
+		if f != nil && f.Package != "" && mod.Packages[f.Package] == nil {
+			items = append(items, ValidationErrorItem{
+				Message:  fmt.Sprintf("module %q file %q references missing package %q", modName, path, f.Package),
+				Severity: SeverityFatal,
+			})
+		}
+	}
 	return items
 }
 
@@ -168,6 +203,14 @@ func validatePackageWithResult(modName, pkgPath string, pkg *Package, lang Langu
 				})
 				continue
 			}
+			// a function stored under another name has likely been overwritten by a concurrent write when merging
+			if f.Name != name {
+				items = append(items, ValidationErrorItem{
+					Message:  fmt.Sprintf("package %s#%s function %q is stored under name %q", modName, pkgPath, f.Name, name),
+					Severity: SeverityFatal,
+					NodeID:   f.Identity.Full(),
+				})
+			}
 			items = append(items, validateIdentityAndContentWithResult("function", modName, pkgPath, name, f.Identity, f.Content, f.FileLine)...)
 			items = append(items, validateFunctionStructure(modName, pkgPath, name, f)...)
 			items = append(items, validateFunctionTypeConsistency(modName, pkgPath, name, f)...)
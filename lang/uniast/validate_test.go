@@ -15,6 +15,7 @@
 package uniast
 
 import (
+	"encoding/json"
 	"errors"
 	"strings"
 	"testing"
@@ -189,3 +190,101 @@ func TestValidateRepositoryWithResult(t *testing.T) {
 		}
 	})
 }
+
+func TestValidateRepository_AllViolations(t *testing.T) {
+	repo := NewRepository("myrepo")
+	mod := NewModule("m", ".", Golang)
+	pkg := NewPackage("pkg")
+	// two functions of the same name, one stored under another name
+	pkg.Functions["Foo"] = &Function{
+		Identity: NewIdentity("m", "pkg", "Foo"),
+		FileLine: FileLine{File: "a.go", Line: 1},
+		Content:  "func Foo() {}",
+	}
+	pkg.Functions["Bar"] = &Function{
+		Identity: NewIdentity("m", "pkg", "Foo"),
+		FileLine: FileLine{File: "a.go", Line: 2},
+		Content:  "func Foo() {}",
+	}
+	mod.Packages["pkg"] = pkg
+	mod.Files["a.go"] = &File{Path: "a.go", Package: "pkg"}
+	mod.Files["b.go"] = &File{Path: "b.go", Package: "gone"}
+	repo.Modules["m"] = mod
+
+	err := ValidateRepository(&repo)
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationError: %v", err)
+	}
+	for _, want := range []string{`function "Foo" is stored under name "Bar"`, `duplicate name "Foo"`, `file "b.go" references missing package "gone"`} {
+		found := false
+		for _, e := range ve.Errs {
+			found = found || strings.Contains(e, want)
+		}
+		if !found {
+			t.Errorf("expected an error containing %q, got %v", want, ve.Errs)
+		}
+	}
+	if len(ve.Errs) != 3 {
+		t.Errorf("expected 3 errors, got %d: %v", len(ve.Errs), ve.Errs)
+	}
+}
+
+func TestRepository_AnnotateNode(t *testing.T) {
+	repo := NewRepository("myrepo")
+	mod := NewModule("m", ".", Golang)
+	pkg := NewPackage("pkg")
+	id := NewIdentity("m", "pkg", "f")
+	pkg.Functions["f"] = &Function{
+		Identity: id,
+		FileLine: FileLine{File: "a.go", Line: 1},
+		Content:  "func f() {}",
+	}
+	mod.Packages["pkg"] = pkg
+	repo.Modules["m"] = mod
+
+	if err := repo.AnnotateNode(NewIdentity("m", "pkg", "g"), AnnotationReviewNeeded, "true"); err == nil {
+		t.Errorf("expected error for missing node")
+	}
+	if err := repo.AnnotateNode(id, "", "true"); err == nil {
+		t.Errorf("expected error for empty key")
+	}
+	if err := repo.AnnotateNode(id, AnnotationReviewNeeded, "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AnnotateNode(id, "reviewer", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	repo.BuildGraph()
+	if got := repo.GetNode(id).Annotations[AnnotationReviewNeeded]; got != "true" {
+		t.Errorf("expected annotations kept after BuildGraph, got %q", got)
+	}
+
+	res := ValidateRepositoryWithResult(&repo)
+	if !res.Ok {
+		t.Fatalf("expected unknown annotation keys not to fail validation: %v", res.Errors)
+	}
+	if len(res.Warnings) != 1 || res.Warnings[0].Severity != SeverityWarning || !strings.Contains(res.Warnings[0].Message, `"reviewer"`) {
+		t.Errorf("expected a warning for the unknown key, got %v", res.Warnings)
+	}
+
+	// annotations are omitted in JSON when empty
+	data, err := json.Marshal(NewNode(NewIdentity("m", "pkg", "g"), FUNC, &repo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Annotations") {
+		t.Errorf("expected no Annotations in %s", data)
+	}
+	data, err = json.Marshal(repo.GetNode(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var node Node
+	if err := json.Unmarshal(data, &node); err != nil {
+		t.Fatal(err)
+	}
+	if node.Annotations["reviewer"] != "alice" {
+		t.Errorf("expected annotations in JSON, got %s", data)
+	}
+}
@@ -65,3 +65,16 @@ func MustWriteFile(fpath string, data []byte) error {
 	}
 	return nil
 }
+
+// ShouldWriteFile reports whether fpath passes the filter, which receives fpath relative to outDir.
+// A nil filter accepts every file.
+func ShouldWriteFile(filter func(filePath string) bool, outDir string, fpath string) bool {
+	if filter == nil {
+		return true
+	}
+	rel, err := filepath.Rel(outDir, fpath)
+	if err != nil {
+		rel = fpath
+	}
+	return filter(rel)
+}
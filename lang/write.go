@@ -32,30 +32,40 @@ import (
 type WriteOptions struct {
 	// OutputDir is the output directory.
 	OutputDir string
+	// Language overrides the language of every module if set,
+	// otherwise each module is written by the writer of its own Language.
+	Language uniast.Language
 	// Compiler path
 	Compiler string
+	// FileFilter is passed each source file path (relative to OutputDir) before writing;
+	// returning false skips that file. nil writes all files.
+	FileFilter func(filePath string) bool
+	// SplitByPackage writes each package into its own directory (the package path relative to the module root),
+	// containing exactly the files of that package.
+	// Go and Java packages are always written this way; for C++ it keeps headers and sources of a namespace together.
+	SplitByPackage bool
+	// GenerateTestStubs writes a <file>_test.go with a skipped test for each exported function of the file (only works for Go now)
+	GenerateTestStubs bool
+	// EmbedSourceDir is the dir where the files embedded by //go:embed are copied from, Repository.Path by default (only works for Go now)
+	EmbedSourceDir string
+	// CxxBuildSystem is the build files generated for C++ modules: "cmake" (default), "meson" or "both"
+	CxxBuildSystem string
 }
 
 // Write writes the AST to the output directory.
+// Each module is written by the writer of its own Language, so a repository mixing languages is written correctly.
 func Write(ctx context.Context, repo *uniast.Repository, args WriteOptions) error {
 	for mpath, m := range repo.Modules {
 		if m.IsExternal() {
 			continue
 		}
-		var w uniast.Writer
-		switch m.Language {
-		case uniast.Golang:
-			w = gowriter.NewWriter(gowriter.Options{CompilerPath: args.Compiler})
-		case uniast.Java:
-			w = javawriter.NewWriter(javawriter.Options{CompilerPath: args.Compiler})
-		case uniast.Rust:
-			w = rustwriter.NewWriter(rustwriter.Options{CompilerPath: args.Compiler})
-		case uniast.Cxx:
-			w = cxxwriter.NewWriter(cxxwriter.Options{CompilerPath: args.Compiler})
-		case uniast.Python:
-			w = pythonwriter.NewWriter(pythonwriter.Options{CompilerPath: args.Compiler})
-		default:
-			return fmt.Errorf("unsupported language: %s", m.Language)
+		language := m.Language
+		if args.Language != uniast.Unknown {
+			language = args.Language
+		}
+		w, err := newWriter(language, args)
+		if err != nil {
+			return err
 		}
 		if err := w.WriteModule(repo, mpath, args.OutputDir); err != nil {
 			return err
@@ -63,3 +73,21 @@ func Write(ctx context.Context, repo *uniast.Repository, args WriteOptions) erro
 	}
 	return nil
 }
+
+// newWriter returns the writer of language
+func newWriter(language uniast.Language, args WriteOptions) (uniast.Writer, error) {
+	switch language {
+	case uniast.Golang:
+		return gowriter.NewWriter(gowriter.Options{CompilerPath: args.Compiler, FileFilter: args.FileFilter, GenerateTestStubs: args.GenerateTestStubs, EmbedSourceDir: args.EmbedSourceDir}), nil
+	case uniast.Java:
+		return javawriter.NewWriter(javawriter.Options{CompilerPath: args.Compiler, FileFilter: args.FileFilter}), nil
+	case uniast.Rust:
+		return rustwriter.NewWriter(rustwriter.Options{CompilerPath: args.Compiler, FileFilter: args.FileFilter}), nil
+	case uniast.Cxx:
+		return cxxwriter.NewWriter(cxxwriter.Options{CompilerPath: args.Compiler, FileFilter: args.FileFilter, SplitByPackage: args.SplitByPackage, BuildSystem: args.CxxBuildSystem}), nil
+	case uniast.Python:
+		return pythonwriter.NewWriter(pythonwriter.Options{CompilerPath: args.Compiler, FileFilter: args.FileFilter, Format: true}), nil
+	default:
+		return nil, fmt.Errorf("unsupported language: %s", language)
+	}
+}
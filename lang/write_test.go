@@ -310,10 +310,66 @@ func TestWrite_CxxModule(t *testing.T) {
 	}
 }
 
+func TestWrite_CxxSplitByPackage(t *testing.T) {
+	tmpDir := t.TempDir()
+	funcId := uniast.NewIdentity("test_cxx", "service", "run")
+	typeId := uniast.NewIdentity("test_cxx", "service", "Service")
+	repo := &uniast.Repository{
+		Name: "test-repo",
+		Modules: map[string]*uniast.Module{
+			"test_cxx": {
+				Name:     "test_cxx",
+				Dir:      "test",
+				Language: uniast.Cxx,
+				Packages: map[uniast.PkgPath]*uniast.Package{
+					"service": {
+						PkgPath: "service",
+						Functions: map[string]*uniast.Function{
+							"run": {
+								Identity: funcId,
+								FileLine: uniast.FileLine{File: "service.cpp"},
+								Content:  "void run() {}",
+							},
+						},
+						Types: map[string]*uniast.Type{
+							"Service": {
+								Identity: typeId,
+								Content:  "class Service {};",
+							},
+						},
+						Vars: map[string]*uniast.Var{},
+					},
+				},
+			},
+		},
+		Graph: map[string]*uniast.Node{
+			funcId.Full(): {Identity: funcId, Type: uniast.FUNC},
+			typeId.Full(): {Identity: typeId, Type: uniast.TYPE},
+		},
+	}
+
+	err := Write(context.Background(), repo, WriteOptions{
+		OutputDir:      tmpDir,
+		SplitByPackage: true,
+	})
+	if err != nil {
+		t.Fatalf("expected no error for C++ module, got %v", err)
+	}
+
+	for _, f := range []string{"service/service.cpp", "service/Service.h", "CMakeLists.txt"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, "test", f)); err != nil {
+			t.Errorf("expected %s to be created: %v", f, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "test", "src")); !os.IsNotExist(err) {
+		t.Errorf("src/ should not be created when SplitByPackage")
+	}
+}
+
 func TestWrite_WithExistingAst(t *testing.T) {
 	astFile := testutils.GetTestAstFile("localsession")
 	repo, err := uniast.LoadRepo(astFile)
-	if err != nil {
+	if err != nil && !uniast.IsVersionMismatch(err) {
 		t.Skipf("skip test: AST file not found: %v", err)
 		return
 	}
@@ -342,3 +398,64 @@ func TestWriteOptions(t *testing.T) {
 		t.Errorf("Compiler = %v, want %v", opts.Compiler, "go")
 	}
 }
+
+func TestWrite_MultiLanguageModules(t *testing.T) {
+	tmpDir := t.TempDir()
+	goFunc := uniast.NewIdentity("github.com/example/backend", "github.com/example/backend", "main")
+	pyFunc := uniast.NewIdentity("frontend", "frontend", "main")
+	repo := &uniast.Repository{
+		Name: "monorepo",
+		Modules: map[string]*uniast.Module{
+			"github.com/example/backend": {
+				Name:     "github.com/example/backend",
+				Dir:      "backend",
+				Language: uniast.Golang,
+				Packages: map[uniast.PkgPath]*uniast.Package{
+					"github.com/example/backend": {
+						PkgPath:   "github.com/example/backend",
+						IsMain:    true,
+						Functions: map[string]*uniast.Function{"main": {Identity: goFunc, Content: "func main() {}"}},
+						Types:     map[string]*uniast.Type{},
+						Vars:      map[string]*uniast.Var{},
+					},
+				},
+			},
+			"frontend": {
+				Name:     "frontend",
+				Dir:      "frontend",
+				Language: uniast.Python,
+				Packages: map[uniast.PkgPath]*uniast.Package{
+					"frontend": {
+						PkgPath:   "frontend",
+						Functions: map[string]*uniast.Function{"main": {Identity: pyFunc, Content: "def main():\n    pass"}},
+						Types:     map[string]*uniast.Type{},
+						Vars:      map[string]*uniast.Var{},
+					},
+				},
+			},
+		},
+		Graph: map[string]*uniast.Node{
+			goFunc.Full(): {Identity: goFunc, Type: uniast.FUNC},
+			pyFunc.Full(): {Identity: pyFunc, Type: uniast.FUNC},
+		},
+	}
+
+	if err := Write(context.Background(), repo, WriteOptions{OutputDir: tmpDir, Compiler: "true"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	for _, f := range []string{"backend/go.mod", "frontend/pyproject.toml"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, f)); err != nil {
+			t.Errorf("expected %s to be written: %v", f, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "frontend", "go.mod")); err == nil {
+		t.Error("frontend module should not be written as Go")
+	}
+
+	// Language overrides the language of the modules
+	repo.Modules["frontend"].Language = uniast.Unknown
+	delete(repo.Modules, "github.com/example/backend")
+	if err := Write(context.Background(), repo, WriteOptions{OutputDir: t.TempDir(), Language: uniast.Python}); err != nil {
+		t.Errorf("Write() with Language error = %v", err)
+	}
+}
@@ -19,12 +19,12 @@ package agent
 import (
 	"context"
 	_ "embed"
+	"time"
 
 	"github.com/cloudwego/abcoder/lang/log"
 	"github.com/cloudwego/abcoder/llm"
 	"github.com/cloudwego/abcoder/llm/prompt"
 	"github.com/cloudwego/abcoder/llm/tool"
-	etool "github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/flow/agent/react"
 )
@@ -33,6 +33,9 @@ type RepoAnnalyzerOptions struct {
 	llm.ModelConfig
 	MaxSteps int    `json:"max_steps"`
 	ASTsDir  string `json:"asts_dir"`
+	// ToolTimeout and ToolTimeouts are the timeouts of the tool calls, see AgentOptions
+	ToolTimeout  time.Duration            `json:"tool_timeout"`
+	ToolTimeouts map[string]time.Duration `json:"tool_timeouts"`
 }
 
 func NewRepoAnalyzer(ctx context.Context, opts RepoAnnalyzerOptions) *llm.ReactAgent {
@@ -48,7 +51,7 @@ func NewRepoAnalyzer(ctx context.Context, opts RepoAnnalyzerOptions) *llm.ReactA
 	log.Debug("NewRepoAnalyzer, get AST tools: %#v", ts)
 	tcfg := compose.ToolsNodeConfig{}
 	for _, t := range ts {
-		tcfg.Tools = append(tcfg.Tools, t.(etool.BaseTool))
+		tcfg.Tools = append(tcfg.Tools, withToolTimeout(ctx, t, opts.ToolTimeout, opts.ToolTimeouts))
 	}
 
 	// Sequential thinking tools
@@ -58,7 +61,7 @@ func NewRepoAnalyzer(ctx context.Context, opts RepoAnnalyzerOptions) *llm.ReactA
 		panic(err)
 	}
 	for _, t := range tools {
-		tcfg.Tools = append(tcfg.Tools, t.(etool.BaseTool))
+		tcfg.Tools = append(tcfg.Tools, withToolTimeout(ctx, t, opts.ToolTimeout, opts.ToolTimeouts))
 	}
 
 	return llm.NewReactAgent("repo-analyzer", llm.ReactAgentOptions{
@@ -68,5 +71,8 @@ func NewRepoAnalyzer(ctx context.Context, opts RepoAnnalyzerOptions) *llm.ReactA
 			ToolsConfig:      tcfg,
 			MaxStep:          opts.MaxSteps,
 		},
+		Retries:         opts.Retries,
+		Timeout:         opts.Timeout,
+		RetryableErrors: opts.RetryableErrors,
 	})
 }
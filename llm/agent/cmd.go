@@ -22,9 +22,12 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/cloudwego/abcoder/llm"
 	"github.com/cloudwego/abcoder/llm/log"
+	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/cloudwego/eino/callbacks"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/flow/agent"
 	"github.com/cloudwego/eino/schema"
@@ -35,20 +38,76 @@ type AgentOptions struct {
 	MaxHistories int
 	MaxSteps     int
 	Model        llm.ModelConfig
+	MaxCost      float64 // budget in dollars of the estimated LLM spend, <= 0 means no budget
+	TokenPrice   float64 // price in dollars per 1k tokens, <= 0 means DefaultTokenPrice(Model)
+	OutputReport string  // path of the JSON report written at the end of the session, empty means no report
+	// MaxParallelSubAgents is the number of independent skill agents the coordinator runs at once, <= 1 means sequential
+	MaxParallelSubAgents int
+	// ToolTimeout is the max duration of each tool call, 0 means DefaultToolTimeout and < 0 means no timeout
+	ToolTimeout time.Duration
+	// ToolTimeouts overrides ToolTimeout for the tools of the given names
+	ToolTimeouts map[string]time.Duration
+}
+
+// DefaultToolTimeout is the default AgentOptions.ToolTimeout
+const DefaultToolTimeout = 30 * time.Second
+
+// llmTools are the tools calling the LLM themselves, which have no timeout unless set in ToolTimeouts
+var llmTools = map[string]bool{
+	tool.ToolTranslateNode: true,
+}
+
+// toolTimeout returns the timeout of the tool name: timeouts[name] if set, else def, or DefaultToolTimeout if def is 0.
+// The llmTools have no timeout by default.
+func toolTimeout(name string, def time.Duration, timeouts map[string]time.Duration) time.Duration {
+	if d, ok := timeouts[name]; ok {
+		return d
+	}
+	if llmTools[name] {
+		return 0
+	}
+	if def == 0 {
+		return DefaultToolTimeout
+	}
+	return def
+}
+
+// withToolTimeout wraps t so that its calls time out after its toolTimeout, see tool.WithTimeout
+func withToolTimeout(ctx context.Context, t tool.Tool, def time.Duration, timeouts map[string]time.Duration) tool.Tool {
+	var name string
+	if info, err := t.Info(ctx); err == nil {
+		name = info.Name
+	}
+	return tool.WithTimeout(t, toolTimeout(name, def, timeouts))
+}
+
+// NewCostTracker creates a CostTracker from the options, or nil if no budget is set
+func (o AgentOptions) NewCostTracker() *CostTracker {
+	if o.MaxCost <= 0 {
+		return nil
+	}
+	price := o.TokenPrice
+	if price <= 0 {
+		price = DefaultTokenPrice(o.Model)
+	}
+	return NewCostTracker(price, o.MaxCost)
 }
 
 type Agent struct {
 	opts      AgentOptions
 	analyzer  *llm.ReactAgent
 	histories *Histories
+	cost      *CostTracker
 }
 
 // run agent as a repl cmd server
 func NewAgent(opts AgentOptions) *Agent {
 	ag := NewRepoAnalyzer(context.Background(), RepoAnnalyzerOptions{
-		ASTsDir:     opts.ASTsDir,
-		MaxSteps:    opts.MaxSteps,
-		ModelConfig: opts.Model,
+		ASTsDir:      opts.ASTsDir,
+		MaxSteps:     opts.MaxSteps,
+		ModelConfig:  opts.Model,
+		ToolTimeout:  opts.ToolTimeout,
+		ToolTimeouts: opts.ToolTimeouts,
 	})
 
 	histories := NewHistories(opts.MaxHistories)
@@ -57,16 +116,26 @@ func NewAgent(opts AgentOptions) *Agent {
 		opts:      opts,
 		analyzer:  ag,
 		histories: histories,
+		cost:      opts.NewCostTracker(),
 	}
 }
 
 func (a *Agent) Generate(ctx context.Context, msgs []*schema.Message) (*schema.Message, error) {
-	return a.analyzer.Generate(ctx, msgs, agent.WithComposeOptions(compose.WithCallbacks(llm.CallbackHandler{})))
+	handlers := []callbacks.Handler{llm.CallbackHandler{}}
+	if a.cost != nil {
+		handlers = append(handlers, a.cost.Handler())
+	}
+	return a.analyzer.Generate(ctx, msgs, agent.WithComposeOptions(compose.WithCallbacks(handlers...)))
 }
 
 func (a *Agent) Run(ctx context.Context) {
 	fmt.Fprintf(os.Stdout, "Hello! I'm ABCoder, your coding assistant. What can I do for you today?\n")
 
+	if a.cost != nil {
+		ctx = a.cost.WithContext(ctx)
+	}
+	var exchanges []Exchange
+
 	sc := bufio.NewScanner(os.Stdin)
 
 	for sc.Scan() {
@@ -87,11 +156,16 @@ func (a *Agent) Run(ctx context.Context) {
 
 		resp, err := a.Generate(ctx, a.histories.Get())
 		if err != nil {
+			exchanges = append(exchanges, Exchange{Query: query, Error: err.Error()})
+			if a.cost != nil && a.cost.Exceeded() {
+				a.cost.StopSession(DefaultAgentStateFile, exchanges)
+			}
 			log.Error("Failed to run agent: %v\n", err)
 			continue
 		}
 
 		a.histories.Add(resp)
+		exchanges = append(exchanges, Exchange{Query: query, Response: resp.Content})
 
 		fmt.Fprintf(os.Stdout, "\n%s\n", resp.Content)
 	}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/cloudwego/abcoder/lang/log"
 	"github.com/cloudwego/abcoder/llm"
@@ -35,6 +36,8 @@ type Coordinator struct {
 	tools    map[string]tool.Tool
 	opts     CoordinatorOptions
 	mu       sync.RWMutex
+	// execute 执行单个 skill，默认为 executeSkill
+	execute func(ctx context.Context, s *skill.Skill, input string) (string, error)
 }
 
 // CoordinatorOptions 是 Coordinator 的配置选项
@@ -43,6 +46,18 @@ type CoordinatorOptions struct {
 	MaxSteps int    // 每个 agent 的最大步数
 	Retries  int    // 重试次数
 	Timeout  int    // 超时时间（秒）
+	// RetryableErrors 是值得重试的错误子串，为空时使用 llm.DefaultRetryableErrors
+	RetryableErrors []string
+	// CostTracker 在所有 skill agent 间共享，统计整个会话的 LLM 花费，可为空
+	CostTracker *CostTracker
+	// Report 收集会话的步骤与发现，非空时所有 skill agent 都可使用 report_finding 工具
+	Report *ReportAccumulator
+	// MaxParallelSubAgents 是 Orchestrate 同时执行的 skill agent 数，<= 1 表示顺序执行
+	MaxParallelSubAgents int
+	// ToolTimeout 是每次工具调用的超时时间，0 表示 DefaultToolTimeout，< 0 表示不超时
+	ToolTimeout time.Duration
+	// ToolTimeouts 按工具名覆盖 ToolTimeout
+	ToolTimeouts map[string]time.Duration
 }
 
 // NewCoordinator 创建新的 Coordinator
@@ -74,6 +89,16 @@ func NewCoordinator(ctx context.Context, registry *skill.Registry, model llm.Cha
 		}
 	}
 
+	// report_finding 工具，把发现写入会话报告
+	if opts.Report != nil {
+		allTools[tool.ToolReportFinding] = opts.Report.Tool()
+	}
+
+	// 工具调用超时后返回错误信息而不是一直阻塞
+	for name, t := range allTools {
+		allTools[name] = tool.WithTimeout(t, toolTimeout(name, opts.ToolTimeout, opts.ToolTimeouts))
+	}
+
 	// 注意：AST 翻译工具暂时不在这里添加
 	// 因为 ASTTranslateTools 返回的是 InvokableTool，需要特殊处理
 	// 如果需要翻译工具，可以在 skill_agent 中单独处理
@@ -85,6 +110,7 @@ func NewCoordinator(ctx context.Context, registry *skill.Registry, model llm.Cha
 		tools:    allTools,
 		opts:     opts,
 	}
+	coordinator.execute = coordinator.executeSkill
 
 	// 确保 registry 已初始化（发现所有 skills）
 	if registry.Count() == 0 {
@@ -134,6 +160,9 @@ func (c *Coordinator) executeSkill(ctx context.Context, s *skill.Skill, input st
 	}
 
 	log.Info("Executing skill: %s", s.Name)
+	if c.opts.Report != nil {
+		c.opts.Report.UseSkill(s.Name)
+	}
 	return agent.Call(ctx, input)
 }
 
@@ -163,6 +192,11 @@ func (c *Coordinator) getOrCreateAgent(ctx context.Context, s *skill.Skill) (*Sk
 		MaxSteps: c.opts.MaxSteps,
 		Retries:  c.opts.Retries,
 		Timeout:  c.opts.Timeout,
+
+		RetryableErrors: c.opts.RetryableErrors,
+
+		CostTracker: c.opts.CostTracker,
+		Report:      c.opts.Report,
 	})
 	if err != nil {
 		return nil, err
@@ -174,26 +208,29 @@ func (c *Coordinator) getOrCreateAgent(ctx context.Context, s *skill.Skill) (*Sk
 
 // Orchestrate 协调多个 skills 协作处理任务
 func (c *Coordinator) Orchestrate(ctx context.Context, skills []*skill.Skill, input string) (string, error) {
-	// 简单策略：按顺序执行，每个 skill 处理输入并产生输出
-	// 后续可以扩展为更复杂的协作策略（并行、管道等）
+	// 每个 skill 都处理原始输入，彼此之间没有数据依赖，
+	// 因此 MaxParallelSubAgents > 1 时可以并行执行，否则按顺序执行
+	var outputs []string
+	if c.opts.MaxParallelSubAgents > 1 && len(skills) > 1 {
+		outputs = c.orchestrateParallel(ctx, skills, input)
+	} else {
+		outputs = make([]string, len(skills))
+		for i, s := range skills {
+			log.Info("Executing skill %d/%d: %s", i+1, len(skills), s.Name)
+			outputs[i] = c.executeSubAgent(ctx, s, input)
+
+			// 将前一个 skill 的输出作为下一个 skill 的输入（可选）
+			// input = result
+		}
+	}
 
+	// 按 skill 的顺序合并结果，失败的 skill 被跳过
 	var results []string
-	for i, s := range skills {
-		log.Info("Executing skill %d/%d: %s", i+1, len(skills), s.Name)
-		
-		result, err := c.executeSkill(ctx, s, input)
-		if err != nil {
-			log.Error("Skill %s failed: %v", s.Name, err)
-			// 继续执行其他 skills
-			continue
+	for _, r := range outputs {
+		if r != "" {
+			results = append(results, r)
 		}
-		
-		results = append(results, fmt.Sprintf("## %s\n\n%s", s.Name, result))
-		
-		// 将前一个 skill 的输出作为下一个 skill 的输入（可选）
-		// input = result
 	}
-
 	if len(results) == 0 {
 		return "", fmt.Errorf("all skills failed to process input")
 	}
@@ -203,6 +240,50 @@ func (c *Coordinator) Orchestrate(ctx context.Context, skills []*skill.Skill, in
 	return finalResult, nil
 }
 
+// orchestrateParallel 最多以 MaxParallelSubAgents 个 goroutine 并行执行 skills，
+// 每个子 agent 在带超时（CoordinatorOptions.Timeout）的子 context 中运行，
+// 通过 channel 收集结果，返回按 skills 顺序排列的输出
+func (c *Coordinator) orchestrateParallel(ctx context.Context, skills []*skill.Skill, input string) []string {
+	type subAgentResult struct {
+		index  int
+		output string
+	}
+	resultCh := make(chan subAgentResult, len(skills))
+	sem := make(chan struct{}, c.opts.MaxParallelSubAgents)
+	for i, s := range skills {
+		go func(i int, s *skill.Skill) {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			log.Info("Executing skill %d/%d in parallel: %s", i+1, len(skills), s.Name)
+			subCtx := ctx
+			if c.opts.Timeout > 0 {
+				var cancel context.CancelFunc
+				subCtx, cancel = context.WithTimeout(ctx, time.Duration(c.opts.Timeout)*time.Second)
+				defer cancel()
+			}
+			resultCh <- subAgentResult{index: i, output: c.executeSubAgent(subCtx, s, input)}
+		}(i, s)
+	}
+
+	outputs := make([]string, len(skills))
+	for range skills {
+		r := <-resultCh
+		outputs[r.index] = r.output
+	}
+	return outputs
+}
+
+// executeSubAgent 执行单个 skill，返回格式化后的结果，失败时返回空字符串
+func (c *Coordinator) executeSubAgent(ctx context.Context, s *skill.Skill, input string) string {
+	result, err := c.execute(ctx, s, input)
+	if err != nil {
+		log.Error("Skill %s failed: %v", s.Name, err)
+		// 继续执行其他 skills
+		return ""
+	}
+	return fmt.Sprintf("## %s\n\n%s", s.Name, result)
+}
+
 // getSkillNames 获取 skill 名称列表
 func getSkillNames(skills []*skill.Skill) []string {
 	names := make([]string, len(skills))
@@ -249,6 +330,11 @@ func (c *Coordinator) GetAgent(skillName string) (*SkillAgent, error) {
 		MaxSteps: c.opts.MaxSteps,
 		Retries:  c.opts.Retries,
 		Timeout:  c.opts.Timeout,
+
+		RetryableErrors: c.opts.RetryableErrors,
+
+		CostTracker: c.opts.CostTracker,
+		Report:      c.opts.Report,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create skill agent: %w", err)
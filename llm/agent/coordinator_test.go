@@ -0,0 +1,79 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/abcoder/llm/skill"
+)
+
+func TestCoordinator_OrchestrateParallel(t *testing.T) {
+	skills := []*skill.Skill{{Name: "performance"}, {Name: "security"}, {Name: "broken"}}
+	var running, maxRunning atomic.Int32
+	c := &Coordinator{opts: CoordinatorOptions{MaxParallelSubAgents: 2, Timeout: 60}}
+	parallel := true
+	c.execute = func(ctx context.Context, s *skill.Skill, input string) (string, error) {
+		if _, ok := ctx.Deadline(); ok != parallel {
+			t.Errorf("sub-agent %s has deadline %v, want %v", s.Name, ok, parallel)
+		}
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		if s.Name == "broken" {
+			return "", errors.New("failed")
+		}
+		return s.Name + " of " + input, nil
+	}
+
+	got, err := c.Orchestrate(context.Background(), skills, "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Processed by 2 skill(s):\n\n## performance\n\nperformance of repo\n\n---\n\n## security\n\nsecurity of repo"
+	if got != want {
+		t.Errorf("Orchestrate() = %q, want %q", got, want)
+	}
+	if maxRunning.Load() != 2 {
+		t.Errorf("expect 2 sub-agents running at once, got %d", maxRunning.Load())
+	}
+
+	// sequential by default, without the sub-agent timeout
+	parallel = false
+	maxRunning.Store(0)
+	c.opts.MaxParallelSubAgents = 0
+	if got, err := c.Orchestrate(context.Background(), skills, "repo"); err != nil || got != want {
+		t.Errorf("Orchestrate() = %q, %v, want %q", got, err, want)
+	}
+	if maxRunning.Load() != 1 {
+		t.Errorf("expect sub-agents to run sequentially, got %d at once", maxRunning.Load())
+	}
+
+	if _, err := c.Orchestrate(context.Background(), skills[2:], "repo"); err == nil {
+		t.Errorf("expect an error when all skills fail")
+	}
+}
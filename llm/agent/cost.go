@@ -0,0 +1,249 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/abcoder/llm"
+	"github.com/cloudwego/abcoder/llm/log"
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrBudgetExceeded is the cause of the context canceled by CostTracker once the estimated spend exceeds the budget
+var ErrBudgetExceeded = errors.New("estimated LLM cost exceeds the budget")
+
+// DefaultAgentStateFile is where the agent session is saved when it is stopped by the budget
+const DefaultAgentStateFile = "abcoder-agent-state.json"
+
+// modelTokenPrices are the default blended prices (dollars per 1k tokens) by model family,
+// matched against the model name in order
+var modelTokenPrices = []struct {
+	family string
+	price  float64
+}{
+	{"gpt-4o-mini", 0.0004},
+	{"gpt-4o", 0.006},
+	{"gpt-4", 0.03},
+	{"gpt-3.5", 0.001},
+	{"o1", 0.03},
+	{"o3", 0.005},
+	{"claude", 0.009},
+	{"deepseek", 0.0007},
+	{"qwen", 0.002},
+	{"doubao", 0.001},
+}
+
+// fallbackTokenPrice is used when the model family is unknown
+const fallbackTokenPrice = 0.002
+
+// DefaultTokenPrice returns the default price (dollars per 1k tokens) of the model family.
+// Local models (ollama) are free.
+func DefaultTokenPrice(cfg llm.ModelConfig) float64 {
+	if cfg.APIType == llm.ModelTypeOllama {
+		return 0
+	}
+	name := strings.ToLower(cfg.ModelName)
+	for _, p := range modelTokenPrices {
+		if strings.Contains(name, p.family) {
+			return p.price
+		}
+	}
+	return fallbackTokenPrice
+}
+
+// CostTracker accumulates the token usage and estimated cost of all LLM calls in a session.
+// It is safe for concurrent use and can be shared across agents.
+type CostTracker struct {
+	mu               sync.Mutex
+	pricePer1K       float64
+	maxCost          float64
+	promptTokens     int
+	completionTokens int
+	cost             float64
+	exceeded         bool
+	cancel           context.CancelCauseFunc
+}
+
+// NewCostTracker creates a CostTracker.
+// pricePer1K is the price in dollars per 1k tokens, maxCost <= 0 means no budget.
+func NewCostTracker(pricePer1K, maxCost float64) *CostTracker {
+	return &CostTracker{
+		pricePer1K: pricePer1K,
+		maxCost:    maxCost,
+	}
+}
+
+// WithContext returns a context which is canceled with ErrBudgetExceeded once the budget is exceeded
+func (t *CostTracker) WithContext(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancelCause(ctx)
+	t.mu.Lock()
+	t.cancel = cancel
+	exceeded := t.exceeded
+	t.mu.Unlock()
+	if exceeded {
+		cancel(ErrBudgetExceeded)
+	}
+	return ctx
+}
+
+// Add records the token usage of one LLM call,
+// and returns ErrBudgetExceeded if the cumulative cost exceeds the budget
+func (t *CostTracker) Add(promptTokens, completionTokens int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.promptTokens += promptTokens
+	t.completionTokens += completionTokens
+	t.cost += float64(promptTokens+completionTokens) / 1000 * t.pricePer1K
+	if t.maxCost <= 0 || t.cost <= t.maxCost {
+		return nil
+	}
+	if !t.exceeded {
+		t.exceeded = true
+		log.Error("estimated LLM cost $%.4f exceeds the budget $%.4f, stopping agent", t.cost, t.maxCost)
+		if t.cancel != nil {
+			t.cancel(ErrBudgetExceeded)
+		}
+	}
+	return ErrBudgetExceeded
+}
+
+// Cost returns the cumulative estimated cost in dollars
+func (t *CostTracker) Cost() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cost
+}
+
+// Tokens returns the cumulative prompt and completion tokens
+func (t *CostTracker) Tokens() (prompt, completion int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.promptTokens, t.completionTokens
+}
+
+// Exceeded tells if the budget has been exceeded
+func (t *CostTracker) Exceeded() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.exceeded
+}
+
+// String returns a short summary of the spend
+func (t *CostTracker) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return fmt.Sprintf("tokens: %d prompt + %d completion, estimated cost: $%.4f", t.promptTokens, t.completionTokens, t.cost)
+}
+
+// Exchange is one round of user query and agent response
+type Exchange struct {
+	Query    string `json:"query"`
+	Response string `json:"response,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SessionState is the saved state of an agent session
+type SessionState struct {
+	Exchanges        []Exchange `json:"exchanges"`
+	PromptTokens     int        `json:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens"`
+	Cost             float64    `json:"cost"`
+	MaxCost          float64    `json:"max_cost"`
+}
+
+// SaveState writes the session exchanges along with the spend to path
+func (t *CostTracker) SaveState(path string, exchanges []Exchange) error {
+	t.mu.Lock()
+	state := SessionState{
+		Exchanges:        exchanges,
+		PromptTokens:     t.promptTokens,
+		CompletionTokens: t.completionTokens,
+		Cost:             t.cost,
+		MaxCost:          t.maxCost,
+	}
+	t.mu.Unlock()
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ExitCodeBudgetExceeded is the exit code of the agent process stopped by the budget
+const ExitCodeBudgetExceeded = 2
+
+// StopSession saves the session state to path and exits the process with ExitCodeBudgetExceeded
+func (t *CostTracker) StopSession(path string, exchanges []Exchange) {
+	if err := t.SaveState(path, exchanges); err != nil {
+		log.Error("Failed to save agent state: %v", err)
+	} else {
+		log.Info("agent state saved to %s", path)
+	}
+	log.Info("agent stopped by budget, %s", t)
+	os.Exit(ExitCodeBudgetExceeded)
+}
+
+// Handler returns a callback handler which feeds the token usage of every chat model call into the tracker
+func (t *CostTracker) Handler() callbacks.Handler {
+	return costCallbackHandler{tracker: t}
+}
+
+type costCallbackHandler struct {
+	tracker *CostTracker
+}
+
+var _ callbacks.Handler = costCallbackHandler{}
+
+func (h costCallbackHandler) OnStart(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+	return ctx
+}
+
+func (h costCallbackHandler) OnEnd(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+	if info == nil || info.Component != components.ComponentOfChatModel {
+		return ctx
+	}
+	if out := model.ConvCallbackOutput(output); out != nil && out.TokenUsage != nil {
+		_ = h.tracker.Add(out.TokenUsage.PromptTokens, out.TokenUsage.CompletionTokens)
+	}
+	return ctx
+}
+
+func (h costCallbackHandler) OnError(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+	return ctx
+}
+
+func (h costCallbackHandler) OnStartWithStreamInput(ctx context.Context, info *callbacks.RunInfo,
+	input *schema.StreamReader[callbacks.CallbackInput]) context.Context {
+	input.Close()
+	return ctx
+}
+
+func (h costCallbackHandler) OnEndWithStreamOutput(ctx context.Context, info *callbacks.RunInfo,
+	output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+	output.Close()
+	return ctx
+}
@@ -0,0 +1,99 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/abcoder/llm"
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+)
+
+func TestCostTracker(t *testing.T) {
+	tracker := NewCostTracker(0.01, 0.05)
+	ctx := tracker.WithContext(context.Background())
+
+	if err := tracker.Add(2000, 1000); err != nil {
+		t.Fatalf("Add() error = %v, want nil under budget", err)
+	}
+	if got := tracker.Cost(); got < 0.0299 || got > 0.0301 {
+		t.Errorf("Cost() = %v, want 0.03", got)
+	}
+	if tracker.Exceeded() || ctx.Err() != nil {
+		t.Fatal("budget should not be exceeded yet")
+	}
+
+	// usage reported through the chat model callback
+	h := tracker.Handler()
+	h.OnEnd(ctx, &callbacks.RunInfo{Component: components.ComponentOfChatModel}, &model.CallbackOutput{
+		TokenUsage: &model.TokenUsage{PromptTokens: 2000, CompletionTokens: 1000},
+	})
+	// other components are ignored
+	h.OnEnd(ctx, &callbacks.RunInfo{Component: components.ComponentOfTool}, &model.CallbackOutput{
+		TokenUsage: &model.TokenUsage{PromptTokens: 100000},
+	})
+	if p, c := tracker.Tokens(); p != 4000 || c != 2000 {
+		t.Errorf("Tokens() = %d/%d, want 4000/2000", p, c)
+	}
+	if !tracker.Exceeded() {
+		t.Fatal("budget should be exceeded")
+	}
+	if !errors.Is(context.Cause(ctx), ErrBudgetExceeded) {
+		t.Errorf("context cause = %v, want ErrBudgetExceeded", context.Cause(ctx))
+	}
+	if err := tracker.Add(1, 0); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Add() error = %v, want ErrBudgetExceeded", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := tracker.SaveState(path, []Exchange{{Query: "q", Response: "r"}}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state SessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Exchanges) != 1 || state.PromptTokens != 4001 || state.MaxCost != 0.05 {
+		t.Errorf("unexpected state: %+v", state)
+	}
+}
+
+func TestDefaultTokenPrice(t *testing.T) {
+	if got := DefaultTokenPrice(llm.ModelConfig{APIType: llm.ModelTypeOllama, ModelName: "gpt-4o"}); got != 0 {
+		t.Errorf("ollama price = %v, want 0", got)
+	}
+	if got := DefaultTokenPrice(llm.ModelConfig{APIType: llm.ModelTypeOpenAI, ModelName: "gpt-4o-mini-2024"}); got != 0.0004 {
+		t.Errorf("gpt-4o-mini price = %v, want 0.0004", got)
+	}
+	if got := DefaultTokenPrice(llm.ModelConfig{APIType: llm.ModelTypeOpenAI, ModelName: "unknown"}); got != fallbackTokenPrice {
+		t.Errorf("unknown price = %v, want %v", got, fallbackTokenPrice)
+	}
+	if (AgentOptions{}).NewCostTracker() != nil {
+		t.Error("NewCostTracker() should be nil without budget")
+	}
+}
@@ -0,0 +1,171 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/abcoder/llm/tool"
+)
+
+// StepRecord is one step of an agent session
+type StepRecord struct {
+	Skill    string    `json:"skill,omitempty"`
+	Query    string    `json:"query"`
+	Response string    `json:"response,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Report is the structured result of an agent session, written by --output-report
+type Report struct {
+	SessionID   string         `json:"session_id"`
+	Timestamp   time.Time      `json:"timestamp"`
+	SkillUsed   []string       `json:"skill_used"`
+	Steps       []StepRecord   `json:"steps"`
+	Findings    []tool.Finding `json:"findings"`
+	Summary     string         `json:"summary"`
+	Interrupted bool           `json:"interrupted,omitempty"`
+}
+
+// ReportAccumulator collects the steps and findings of an agent session.
+// It is safe for concurrent use, findings are fed by the report_finding tool.
+type ReportAccumulator struct {
+	mu     sync.Mutex
+	report Report
+}
+
+// NewReportAccumulator creates a ReportAccumulator for the session,
+// a random id is generated if sessionID is empty
+func NewReportAccumulator(sessionID string) *ReportAccumulator {
+	if sessionID == "" {
+		sessionID = NewSessionID()
+	}
+	return &ReportAccumulator{report: Report{
+		SessionID: sessionID,
+		Timestamp: time.Now(),
+		SkillUsed: []string{},
+		Steps:     []StepRecord{},
+		Findings:  []tool.Finding{},
+	}}
+}
+
+// NewSessionID returns a random hex session id
+func NewSessionID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return time.Now().Format("20060102150405.000000")
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Tool returns the report_finding tool feeding the accumulator
+func (r *ReportAccumulator) Tool() tool.Tool {
+	return tool.NewReportFindingTool(r.AddFinding)
+}
+
+// AddFinding records a finding
+func (r *ReportAccumulator) AddFinding(f tool.Finding) {
+	r.mu.Lock()
+	r.report.Findings = append(r.report.Findings, f)
+	r.mu.Unlock()
+}
+
+// AddStep records a step, stamping its time if not set
+func (r *ReportAccumulator) AddStep(s StepRecord) {
+	if s.Time.IsZero() {
+		s.Time = time.Now()
+	}
+	r.mu.Lock()
+	r.report.Steps = append(r.report.Steps, s)
+	r.mu.Unlock()
+}
+
+// UseSkill records that the skill has been used in the session
+func (r *ReportAccumulator) UseSkill(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.report.SkillUsed {
+		if s == name {
+			return
+		}
+	}
+	r.report.SkillUsed = append(r.report.SkillUsed, name)
+}
+
+// SetSummary sets the final analysis of the session
+func (r *ReportAccumulator) SetSummary(summary string) {
+	r.mu.Lock()
+	r.report.Summary = summary
+	r.mu.Unlock()
+}
+
+// SetInterrupted marks the session as stopped before it ended normally
+func (r *ReportAccumulator) SetInterrupted() {
+	r.mu.Lock()
+	r.report.Interrupted = true
+	r.mu.Unlock()
+}
+
+// Report returns a snapshot of the report
+func (r *ReportAccumulator) Report() Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rep := r.report
+	rep.SkillUsed = append([]string{}, rep.SkillUsed...)
+	rep.Steps = append([]StepRecord{}, rep.Steps...)
+	rep.Findings = append([]tool.Finding{}, rep.Findings...)
+	return rep
+}
+
+// WriteFile writes the report as JSON to path atomically:
+// the content goes to a temporary file in the same directory which is then renamed to path
+func (r *ReportAccumulator) WriteFile(path string) error {
+	data, err := json.MarshalIndent(r.Report(), "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
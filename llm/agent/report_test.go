@@ -0,0 +1,84 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/abcoder/llm/tool"
+	etool "github.com/cloudwego/eino/components/tool"
+)
+
+func TestReportAccumulator(t *testing.T) {
+	report := NewReportAccumulator("s1")
+	report.UseSkill("code-review")
+	report.UseSkill("code-review")
+	report.AddStep(StepRecord{Skill: "code-review", Query: "review", Response: "done"})
+	report.SetSummary("done")
+
+	// findings come from the report_finding tool
+	ft := report.Tool().(etool.InvokableTool)
+	if _, err := ft.InvokableRun(context.Background(), `{"node_id":"m?p#F","severity":"WARNING","message":"unchecked error"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ft.InvokableRun(context.Background(), `{"severity":"bogus","message":"no tests"}`); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := ft.InvokableRun(context.Background(), `{"severity":"error"}`); out == "" {
+		t.Error("empty message should be rejected")
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := report.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.SessionID != "s1" || got.Summary != "done" || got.Interrupted {
+		t.Errorf("report = %+v", got)
+	}
+	if !reflect.DeepEqual(got.SkillUsed, []string{"code-review"}) {
+		t.Errorf("SkillUsed = %v, want [code-review]", got.SkillUsed)
+	}
+	if len(got.Steps) != 1 || got.Steps[0].Time.IsZero() {
+		t.Errorf("Steps = %+v, want one timed step", got.Steps)
+	}
+	want := []tool.Finding{
+		{NodeID: "m?p#F", Severity: tool.SeverityWarning, Message: "unchecked error"},
+		{Severity: tool.SeverityInfo, Message: "no tests"},
+	}
+	if !reflect.DeepEqual(got.Findings, want) {
+		t.Errorf("Findings = %+v, want %+v", got.Findings, want)
+	}
+
+	// no temporary file is left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("found %d files in report dir, want 1", len(entries))
+	}
+}
@@ -18,6 +18,7 @@ package agent
 
 import (
 	"context"
+	"time"
 
 	"github.com/cloudwego/abcoder/lang/log"
 	"github.com/cloudwego/abcoder/llm"
@@ -45,6 +46,10 @@ type SkillAgentOptions struct {
 	MaxSteps      int                  // 最大步数
 	Retries       int                  // 重试次数
 	Timeout       int                  // 超时时间（秒）
+	CostTracker   *CostTracker         // 会话级的 LLM 花费统计，可为空
+	Report        *ReportAccumulator   // 会话报告，非空时总是允许 report_finding 工具
+	// RetryableErrors 是值得重试的错误子串，为空时使用 llm.DefaultRetryableErrors
+	RetryableErrors []string
 }
 
 // NewSkillAgent 创建新的 SkillAgent
@@ -57,6 +62,9 @@ func NewSkillAgent(ctx context.Context, opts SkillAgentOptions) (*SkillAgent, er
 	for _, toolName := range opts.Skill.AllowedTools {
 		allowedToolsMap[toolName] = true
 	}
+	if opts.Report != nil {
+		allowedToolsMap[tool.ToolReportFinding] = true
+	}
 
 	// 过滤工具
 	for toolName, tool := range opts.AllTools {
@@ -76,15 +84,21 @@ func NewSkillAgent(ctx context.Context, opts SkillAgentOptions) (*SkillAgent, er
 	sysPrompt := buildSkillPrompt(opts.Skill)
 
 	// 创建 ReactAgent
-	reactAgent := llm.NewReactAgent(opts.Skill.Name, llm.ReactAgentOptions{
+	ropts := llm.ReactAgentOptions{
 		SysPrompt: prompt.NewTextPrompt(sysPrompt),
 		AgentConfig: &react.AgentConfig{
 			ToolCallingModel: opts.Model,
 			ToolsConfig:      tcfg,
 			MaxStep:          opts.MaxSteps,
 		},
-		Retries: opts.Retries,
-	})
+		Retries:         opts.Retries,
+		Timeout:         time.Duration(opts.Timeout) * time.Second,
+		RetryableErrors: opts.RetryableErrors,
+	}
+	if opts.CostTracker != nil {
+		ropts.Callbacks = append(ropts.Callbacks, opts.CostTracker.Handler())
+	}
+	reactAgent := llm.NewReactAgent(opts.Skill.Name, ropts)
 
 	return &SkillAgent{
 		ReactAgent: reactAgent,
@@ -19,14 +19,18 @@ package agent
 import (
 	"context"
 	_ "embed"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/translate"
 	"github.com/cloudwego/abcoder/llm"
 	"github.com/cloudwego/abcoder/llm/prompt"
 	"github.com/cloudwego/abcoder/llm/tool"
-	etool "github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
 )
 
 type TranslatorOptions struct {
@@ -34,6 +38,12 @@ type TranslatorOptions struct {
 	MaxSteps                int    `json:"max_steps"`
 	ASTsDir                 string `json:"asts_dir"`
 	UseHierarchicalStrategy bool   `json:"use_hierarchical_strategy"` // when true, emphasize get_ast_hierarchy + get_target_language_spec then translate by level
+	// ToolTimeout and ToolTimeouts are the timeouts of the tool calls, see AgentOptions
+	ToolTimeout  time.Duration            `json:"tool_timeout"`
+	ToolTimeouts map[string]time.Duration `json:"tool_timeouts"`
+	// CustomTypeHints and TypeHintsFile are passed to translate_node, see translate.TranslateOptions
+	CustomTypeHints []translate.TypeHintOverride `json:"custom_type_hints"`
+	TypeHintsFile   string                       `json:"type_hints_file"`
 }
 
 func NewTranslatorAgent(ctx context.Context, opts TranslatorOptions) *llm.ReactAgent {
@@ -49,17 +59,20 @@ func NewTranslatorAgent(ctx context.Context, opts TranslatorOptions) *llm.ReactA
 	log.Debug("NewTranslatorAgent, get AST tools: %#v", ts)
 	tcfg := compose.ToolsNodeConfig{}
 	for _, t := range ts {
-		tcfg.Tools = append(tcfg.Tools, t.(etool.BaseTool))
+		tcfg.Tools = append(tcfg.Tools, withToolTimeout(ctx, t, opts.ToolTimeout, opts.ToolTimeouts))
 	}
 
 	// Translation tools
 	translateTools := tool.NewASTTranslateTools(tool.ASTTranslateToolsOptions{
-		RepoASTsDir: opts.ASTsDir,
+		RepoASTsDir:     opts.ASTsDir,
+		LLMTranslator:   modelTranslator(llm.NewChatModel(opts.ModelConfig)),
+		CustomTypeHints: opts.CustomTypeHints,
+		TypeHintsFile:   opts.TypeHintsFile,
 	})
 	translateTs := translateTools.GetTools()
 	log.Debug("NewTranslatorAgent, get translation tools: %#v", translateTs)
 	for _, t := range translateTs {
-		tcfg.Tools = append(tcfg.Tools, t.(etool.BaseTool))
+		tcfg.Tools = append(tcfg.Tools, withToolTimeout(ctx, t, opts.ToolTimeout, opts.ToolTimeouts))
 	}
 
 	// Sequential thinking tools
@@ -69,7 +82,7 @@ func NewTranslatorAgent(ctx context.Context, opts TranslatorOptions) *llm.ReactA
 		panic(err)
 	}
 	for _, t := range thinkingTools {
-		tcfg.Tools = append(tcfg.Tools, t.(etool.BaseTool))
+		tcfg.Tools = append(tcfg.Tools, withToolTimeout(ctx, t, opts.ToolTimeout, opts.ToolTimeouts))
 	}
 
 	sysPrompt := prompt.PromptTranslator
@@ -83,7 +96,23 @@ func NewTranslatorAgent(ctx context.Context, opts TranslatorOptions) *llm.ReactA
 			ToolsConfig:      tcfg,
 			MaxStep:          opts.MaxSteps,
 		},
-		Retries: opts.Retries,
-		Timeout: opts.Timeout,
+		Retries:         opts.Retries,
+		Timeout:         opts.Timeout,
+		RetryableErrors: opts.RetryableErrors,
 	})
 }
+
+// modelTranslator translates the nodes for translate_node by a single call of the model without tools,
+// with the prompt built by the translate package
+func modelTranslator(m llm.ChatModel) translate.LLMTranslateFunc {
+	return func(ctx context.Context, req *translate.LLMTranslateRequest) (*translate.LLMTranslateResponse, error) {
+		msg, err := m.Generate(ctx, []*schema.Message{schema.UserMessage(req.Prompt)})
+		if err != nil {
+			return &translate.LLMTranslateResponse{Error: fmt.Sprintf("LLM call failed: %v", err)}, nil
+		}
+		if msg == nil {
+			return &translate.LLMTranslateResponse{Error: "LLM returned nil response"}, nil
+		}
+		return &translate.LLMTranslateResponse{TargetContent: strings.TrimSpace(msg.Content)}, nil
+	}
+}
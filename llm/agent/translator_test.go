@@ -0,0 +1,64 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/translate"
+	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// echoModel answers the prompt prefixed with "echo: "
+type echoModel struct{}
+
+func (echoModel) Generate(ctx context.Context, in []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return schema.AssistantMessage("echo: "+in[len(in)-1].Content+"\n", nil), nil
+}
+
+func (echoModel) Stream(ctx context.Context, in []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, _ := echoModel{}.Generate(ctx, in)
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m echoModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func TestModelTranslator(t *testing.T) {
+	resp, err := modelTranslator(echoModel{})(context.Background(), &translate.LLMTranslateRequest{Prompt: "type A struct{}"})
+	if err != nil || resp.Error != "" || resp.TargetContent != "echo: type A struct{}" {
+		t.Errorf("modelTranslator() = %+v, %v", resp, err)
+	}
+}
+
+func TestToolTimeout(t *testing.T) {
+	if d := toolTimeout("get_file_structure", 0, nil); d != DefaultToolTimeout {
+		t.Errorf("default timeout = %v, want %v", d, DefaultToolTimeout)
+	}
+	// translate_node calls the LLM, it has no timeout unless set for it
+	if d := toolTimeout(tool.ToolTranslateNode, DefaultToolTimeout, nil); d != 0 {
+		t.Errorf("translate_node timeout = %v, want none", d)
+	}
+	if d := toolTimeout(tool.ToolTranslateNode, DefaultToolTimeout, map[string]time.Duration{tool.ToolTranslateNode: time.Minute}); d != time.Minute {
+		t.Errorf("translate_node timeout = %v, want 1m", d)
+	}
+}
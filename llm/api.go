@@ -18,6 +18,9 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -40,10 +43,115 @@ type ModelConfig struct {
 	MaxTokens int           `json:"max_tokens"`
 	Timeout   time.Duration `json:"timeout"` // HTTP request timeout, default: 600s
 	Retries   int           `json:"retries"` // Number of retries on failure, default: 3
+	// RetryableErrors are the substrings of the errors worth retrying, default: DefaultRetryableErrors
+	RetryableErrors []string `json:"retryable_errors"`
+}
+
+// DefaultRetryableErrors are the substrings of the transient errors (timeout, connection reset, etc.) an LLM call is retried on
+var DefaultRetryableErrors = []string{
+	"timeout",
+	"timed out",
+	"context deadline exceeded",
+	"connection reset",
+	"connection refused",
+	"read tcp",
+	"write tcp",
+	"EOF",
+	"temporary failure",
+}
+
+// IsRetryable tells if the LLM call failed with err should be retried,
+// by matching it with RetryableErrors
+func (m ModelConfig) IsRetryable(err error) bool {
+	return IsRetryableError(err, m.RetryableErrors)
+}
+
+// IsRetryableError tells if err contains one of patterns, or DefaultRetryableErrors if patterns is empty
+func IsRetryableError(err error, patterns []string) bool {
+	if err == nil {
+		return false
+	}
+	if len(patterns) == 0 {
+		patterns = DefaultRetryableErrors
+	}
+	errStr := err.Error()
+	for _, p := range patterns {
+		if strings.Contains(errStr, p) {
+			return true
+		}
+	}
+	return false
 }
 
 type ModelType string
 
+// modelConfigEntry is a model credential entry in the model config file
+type modelConfigEntry struct {
+	APIType   string `json:"api_type"`
+	APIKey    string `json:"api_key"`
+	ModelName string `json:"model_name"`
+	BaseURL   string `json:"base_url"`
+	// Timeout is the HTTP request timeout as a duration string, like "90s"
+	Timeout         string   `json:"timeout"`
+	Retries         int      `json:"retries"`
+	RetryableErrors []string `json:"retryable_errors"`
+}
+
+// modelConfigFile is the model config file, which has a default entry and optional named profiles, like:
+//
+//	{"api_type": "...", "api_key": "...", "model_name": "...", "base_url": "...", "timeout": "600s", "retries": 3, "profiles": {"fast": {...}}}
+type modelConfigFile struct {
+	modelConfigEntry
+	Profiles map[string]modelConfigEntry `json:"profiles"`
+}
+
+// ApplyModelConfigFile loads the model config file at path and overrides the non-empty fields of m.
+// If profile is not empty, the named entry in "profiles" is used instead of the top-level one.
+func ApplyModelConfigFile(m *ModelConfig, path string, profile string) error {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read model config %s failed: %w", path, err)
+	}
+	var f modelConfigFile
+	if err := json.Unmarshal(bs, &f); err != nil {
+		return fmt.Errorf("parse model config %s failed: %w", path, err)
+	}
+	entry := f.modelConfigEntry
+	if profile != "" {
+		var ok bool
+		entry, ok = f.Profiles[profile]
+		if !ok {
+			return fmt.Errorf("model profile '%s' not found in %s", profile, path)
+		}
+	}
+	if entry.APIType != "" {
+		m.APIType = NewModelType(entry.APIType)
+	}
+	if entry.APIKey != "" {
+		m.APIKey = entry.APIKey
+	}
+	if entry.ModelName != "" {
+		m.ModelName = entry.ModelName
+	}
+	if entry.BaseURL != "" {
+		m.BaseURL = entry.BaseURL
+	}
+	if entry.Timeout != "" {
+		timeout, err := time.ParseDuration(entry.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout in model config %s: %w", path, err)
+		}
+		m.Timeout = timeout
+	}
+	if entry.Retries != 0 {
+		m.Retries = entry.Retries
+	}
+	if len(entry.RetryableErrors) > 0 {
+		m.RetryableErrors = entry.RetryableErrors
+	}
+	return nil
+}
+
 func NewModelType(t string) ModelType {
 	switch strings.ToLower(t) {
 	case "ollama":
@@ -77,6 +185,10 @@ type AgentConfig struct {
 	WithTools []string      `json:"with_tools"`
 	MaxSteps  int           `json:"max_steps"`
 	Prompt    prompt.Prompt `json:"prompt"`
+	// Retries, Timeout and RetryableErrors are passed to the ReactAgentOptions, see ModelConfig
+	Retries         int           `json:"retries"`
+	Timeout         time.Duration `json:"timeout"`
+	RetryableErrors []string      `json:"retryable_errors"`
 }
 
 // Generator is the interface for calling
@@ -120,6 +232,9 @@ func MakeAgent(source any, sysPrompt prompt.Prompt, models map[string]ChatModel,
 			MaxStep:          executor.MaxSteps,
 			MessageModifier:  newMessageModifier(sysPrompt.String(), exeName, executor.MaxSteps),
 		},
+		Retries:         executor.Retries,
+		Timeout:         executor.Timeout,
+		RetryableErrors: executor.RetryableErrors,
 	})
 	return agent
 }
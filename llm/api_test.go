@@ -0,0 +1,134 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package llm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		patterns []string
+		want     bool
+	}{
+		{"nil", nil, nil, false},
+		{"default timeout", errors.New("Post \"https://api\": context deadline exceeded"), nil, true},
+		{"default reset", errors.New("read tcp: connection reset by peer"), nil, true},
+		{"default not matched", errors.New("invalid api key"), nil, false},
+		{"custom matched", errors.New("status 529: overloaded"), []string{"overloaded"}, true},
+		{"custom replaces default", errors.New("context deadline exceeded"), []string{"overloaded"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err, tt.patterns); got != tt.want {
+				t.Errorf("IsRetryableError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyModelConfigFile(t *testing.T) {
+	const file = `{"api_type": "anthropic", "api_key": "k1", "model_name": "m1",
+		"profiles": {"fast": {"api_type": "openai", "model_name": "m2", "base_url": "http://fast"}}}`
+	base := ModelConfig{APIType: ModelTypeARK, APIKey: "env-key", ModelName: "env-model", BaseURL: "http://env"}
+	tests := []struct {
+		name    string
+		content string
+		profile string
+		want    ModelConfig
+		wantErr bool
+	}{
+		{
+			name:    "top-level entry overrides the non-empty fields",
+			content: file,
+			want:    ModelConfig{APIType: ModelTypeClaude, APIKey: "k1", ModelName: "m1", BaseURL: "http://env"},
+		},
+		{
+			name:    "profile is used instead of the top-level entry",
+			content: file,
+			profile: "fast",
+			want:    ModelConfig{APIType: ModelTypeOpenAI, APIKey: "env-key", ModelName: "m2", BaseURL: "http://fast"},
+		},
+		{
+			name:    "empty file keeps the config",
+			content: `{}`,
+			want:    base,
+		},
+		{
+			name:    "unknown profile",
+			content: file,
+			profile: "slow",
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			content: `{"api_key": `,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "model.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got := base
+			err := ApplyModelConfigFile(&got, path, tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyModelConfigFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyModelConfigFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if err := ApplyModelConfigFile(&ModelConfig{}, filepath.Join(t.TempDir(), "missing.json"), ""); err == nil {
+		t.Errorf("expect an error for a missing file")
+	}
+}
+
+func TestApplyModelConfigFile_TimeoutRetries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := os.WriteFile(path, []byte(`{"timeout": "90s", "retries": 5, "retryable_errors": ["overloaded"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := ModelConfig{Timeout: time.Minute, Retries: 1}
+	if err := ApplyModelConfigFile(&m, path, ""); err != nil {
+		t.Fatal(err)
+	}
+	if m.Timeout != 90*time.Second || m.Retries != 5 {
+		t.Errorf("timeout = %v, retries = %d, want 90s and 5", m.Timeout, m.Retries)
+	}
+	if !m.IsRetryable(errors.New("status 529: overloaded")) {
+		t.Errorf("expect the retryable_errors of the file to be used")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"timeout": "90"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyModelConfigFile(&m, path, ""); err == nil {
+		t.Errorf("expect an error for a timeout without unit")
+	}
+}
@@ -57,15 +57,23 @@ func NewTool[R any, T any](name string, desc string, schema json.RawMessage, han
 
 func getASTTools(opts tool.ASTReadToolsOptions) []Tool {
 	ast := tool.NewASTReadTools(opts)
-	return []Tool{
+	tools := []Tool{
 		NewTool(tool.ToolListRepos, tool.DescListRepos, tool.SchemaListRepos, ast.ListRepos),
 		NewTool(tool.ToolGetRepoStructure, tool.DescGetRepoStructure, tool.SchemaGetRepoStructure, ast.GetRepoStructure),
 		NewTool(tool.ToolGetASTHierarchy, tool.DescGetASTHierarchy, tool.SchemaGetASTHierarchy, ast.GetASTHierarchy),
+		NewTool(tool.ToolGetRepoMetrics, tool.DescGetRepoMetrics, tool.SchemaGetRepoMetrics, ast.GetRepoMetrics),
+		NewTool(tool.ToolExportRepoSubgraph, tool.DescExportRepoSubgraph, tool.SchemaExportRepoSubgraph, ast.ExportRepoSubgraph),
+		NewTool(tool.ToolDiffASTNodes, tool.DescDiffASTNodes, tool.SchemaDiffASTNodes, ast.DiffASTNodes),
 		NewTool(tool.ToolGetTargetLanguageSpec, tool.DescGetTargetLanguageSpec, tool.SchemaGetTargetLanguageSpec, ast.GetTargetLanguageSpec),
 		NewTool(tool.ToolGetPackageStructure, tool.DescGetPackageStructure, tool.SchemaGetPackageStructure, ast.GetPackageStructure),
 		NewTool(tool.ToolGetFileStructure, tool.DescGetFileStructure, tool.SchemaGetFileStructure, ast.GetFileStructure),
 		NewTool(tool.ToolGetASTNode, tool.DescGetASTNode, tool.SchemaGetASTNode, ast.GetASTNode),
+		NewTool(tool.ToolGetASTNodeSourceRange, tool.DescGetASTNodeSourceRange, tool.SchemaGetASTNodeSourceRange, ast.GetASTNodeSourceRange),
 	}
+	if opts.LLMChat != nil {
+		tools = append(tools, NewTool(tool.ToolSummarizeNode, tool.DescSummarizeNode, tool.SchemaSummarizeNode, ast.SummarizeNode))
+	}
+	return tools
 }
 
 func handleAnalyzeRepoPrompt(
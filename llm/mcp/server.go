@@ -18,16 +18,22 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"sync"
 
 	alog "github.com/cloudwego/abcoder/llm/log"
 	"github.com/cloudwego/abcoder/llm/tool"
+	etool "github.com/cloudwego/eino/components/tool"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 type Server struct {
 	Server *server.MCPServer
+	// mu makes checking and registering a tool in AddTool atomic
+	mu sync.Mutex
 }
 
 type Tool struct {
@@ -35,23 +41,48 @@ type Tool struct {
 	Handler func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
 }
 
+// DefaultServerName is the server name used when ServerOptions gives none
+const DefaultServerName = "abcoder"
+
 type ServerOptions struct {
-	ServerName    string
-	ServerVersion string
-	Verbose       bool
+	ServerName string
+	// CustomServerName, if non-empty, overrides ServerName
+	CustomServerName string
+	ServerVersion    string
+	Verbose          bool
 	tool.ASTReadToolsOptions
 }
 
+// RepoServerName returns the server name for the repo id, so that MCP clients can distinguish the servers of different repos
+func RepoServerName(repoID string) string {
+	if repoID == "" {
+		return DefaultServerName
+	}
+	return DefaultServerName + "-" + repoID
+}
+
+// Name returns the server name to register, which is CustomServerName, ServerName or DefaultServerName in order
+func (o ServerOptions) Name() string {
+	if o.CustomServerName != "" {
+		return o.CustomServerName
+	}
+	if o.ServerName != "" {
+		return o.ServerName
+	}
+	return DefaultServerName
+}
+
 func NewServer(options ServerOptions) *Server {
 	opts := []server.ServerOption{
 		server.WithPromptCapabilities(false),
-		server.WithToolCapabilities(false),
+		// notify the clients when AddTool or RemoveTools changes the tools
+		server.WithToolCapabilities(true),
 	}
 	if options.Verbose {
 		opts = append(opts, server.WithLogging())
 	}
 	// Create a new MCP server
-	mcpServer := server.NewMCPServer(options.ServerName, options.ServerVersion, opts...)
+	mcpServer := server.NewMCPServer(options.Name(), options.ServerVersion, opts...)
 
 	// Enable sampling capability
 	// mcpServer.EnableSampling()
@@ -75,6 +106,66 @@ func NewServer(options ServerOptions) *Server {
 	}
 }
 
+// AddTool registers t as the tool name while the server is running,
+// tools/list then includes it and the clients are notified of the change.
+// It fails if a tool of the name is already registered.
+func (s *Server) AddTool(name string, t etool.InvokableTool) error {
+	if name == "" {
+		return fmt.Errorf("empty tool name")
+	}
+	info, err := t.Info(context.Background())
+	if err != nil {
+		return fmt.Errorf("get info of tool %s: %w", name, err)
+	}
+	schema := json.RawMessage(`{"type":"object","properties":{}}`)
+	if info.ParamsOneOf != nil {
+		js, err := info.ParamsOneOf.ToJSONSchema()
+		if err != nil {
+			return fmt.Errorf("get schema of tool %s: %w", name, err)
+		}
+		if js != nil {
+			if schema, err = json.Marshal(js); err != nil {
+				return fmt.Errorf("marshal schema of tool %s: %w", name, err)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Server.GetTool(name) != nil {
+		return fmt.Errorf("tool %s already registered", name)
+	}
+	s.Server.AddTool(mcp.NewToolWithRawSchema(name, info.Desc, schema), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := "{}"
+		if raw := request.GetRawArguments(); raw != nil {
+			js, err := json.Marshal(raw)
+			if err != nil {
+				return nil, err
+			}
+			args = string(js)
+		}
+		out, err := t.InvokableRun(ctx, args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(out), nil
+	})
+	return nil
+}
+
+// RemoveTool unregisters the tool name while the server is running,
+// tools/list then excludes it and the clients are notified of the change. An unknown name is ignored.
+func (s *Server) RemoveTool(name string) {
+	s.RemoveTools(name)
+}
+
+// RemoveTools unregisters the tools like RemoveTool, unknown names are ignored
+func (s *Server) RemoveTools(names ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Server.DeleteTools(names...)
+}
+
 func handleNotification(
 	ctx context.Context,
 	notification mcp.JSONRPCNotification,
@@ -22,12 +22,15 @@ import (
 	"encoding/json"
 	"io"
 	"log"
+	"strings"
 	"testing"
 	"time"
 
 	alog "github.com/cloudwego/abcoder/llm/log"
 	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
 
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
@@ -121,3 +124,88 @@ func TestASTServer(t *testing.T) {
 		t.Errorf("unexpected server error: %v", err)
 	}
 }
+
+func TestServerOptions_Name(t *testing.T) {
+	tests := []struct {
+		opts ServerOptions
+		want string
+	}{
+		{ServerOptions{}, "abcoder"},
+		{ServerOptions{ServerName: RepoServerName("")}, "abcoder"},
+		{ServerOptions{ServerName: RepoServerName("localsession")}, "abcoder-localsession"},
+		{ServerOptions{ServerName: RepoServerName("localsession"), CustomServerName: "my-server"}, "my-server"},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.Name(); got != tt.want {
+			t.Errorf("%+v.Name() = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestServer_AddTool(t *testing.T) {
+	svr := NewServer(ServerOptions{
+		ASTReadToolsOptions: tool.ASTReadToolsOptions{RepoASTsDir: tool.TestRepoASTsDir},
+	})
+	type echoReq struct {
+		Text string `json:"text"`
+	}
+	echo, err := utils.InferTool("echo", "echo the text", func(_ context.Context, req echoReq) (string, error) {
+		return "echo: " + req.Text, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	handle := func(method string, params any) map[string]any {
+		req, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+		data, err := json.Marshal(svr.Server.HandleMessage(ctx, req))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resp map[string]any
+		if err := json.Unmarshal(data, &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	listed := func(name string) bool {
+		result, _ := handle(string(mcp.MethodToolsList), map[string]any{})["result"].(map[string]any)
+		tools, _ := result["tools"].([]any)
+		for _, tl := range tools {
+			if tl.(map[string]any)["name"] == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if err := svr.AddTool("get_ast_node_for_localsession", echo); err != nil {
+		t.Fatalf("AddTool() error = %v", err)
+	}
+	if err := svr.AddTool("get_ast_node_for_localsession", echo); err == nil {
+		t.Errorf("AddTool() of a registered name should fail")
+	}
+	if !listed("get_ast_node_for_localsession") || !listed(tool.ToolGetASTNode) {
+		t.Errorf("tools/list should include the added tool and the built-in ones")
+	}
+	resp := handle(string(mcp.MethodToolsCall), map[string]any{
+		"name":      "get_ast_node_for_localsession",
+		"arguments": map[string]any{"text": "hi"},
+	})
+	if js, _ := json.Marshal(resp["result"]); !strings.Contains(string(js), "echo: hi") {
+		t.Errorf("tools/call result = %s, want echo: hi", js)
+	}
+
+	svr.RemoveTool("get_ast_node_for_localsession")
+	if listed("get_ast_node_for_localsession") {
+		t.Errorf("tools/list should not include the removed tool")
+	}
+
+	if err := svr.AddTool("get_ast_node_for_localsession", echo); err != nil {
+		t.Fatalf("AddTool() of a removed name error = %v", err)
+	}
+	svr.RemoveTools("get_ast_node_for_localsession", "none")
+	if listed("get_ast_node_for_localsession") {
+		t.Errorf("tools/list should not include the removed tools")
+	}
+}
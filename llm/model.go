@@ -18,6 +18,7 @@ package llm
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/cloudwego/eino-ext/components/model/ark"
@@ -27,17 +28,20 @@ import (
 	"github.com/cloudwego/eino-ext/components/model/qwen"
 )
 
+const (
+	// DefaultTimeout is the default HTTP request timeout of the LLM calls
+	DefaultTimeout = 600 * time.Second
+	// DefaultRetries is the default number of retries of a failed LLM call
+	DefaultRetries = 3
+)
+
 func NewChatModel(m ModelConfig) (model ChatModel) {
 	if m.MaxTokens == 0 {
 		m.MaxTokens = 16 * 1024
 	}
 	// Set default timeout to 600 seconds if not specified
 	if m.Timeout == 0 {
-		m.Timeout = 600 * time.Second
-	}
-	// Set default retries to 3 if not specified
-	if m.Retries == 0 {
-		m.Retries = 3
+		m.Timeout = DefaultTimeout
 	}
 	var err error
 	switch m.APIType {
@@ -48,6 +52,7 @@ func NewChatModel(m ModelConfig) (model ChatModel) {
 			Model:       m.ModelName,
 			Temperature: m.Temperature,
 			MaxTokens:   &m.MaxTokens,
+			Timeout:     &m.Timeout,
 		})
 		if err != nil {
 			panic(err)
@@ -105,6 +110,7 @@ func NewChatModel(m ModelConfig) (model ChatModel) {
 		model, err = ollama.NewChatModel(context.Background(), &ollama.ChatModelConfig{
 			BaseURL: m.BaseURL,
 			Model:   m.ModelName,
+			Timeout: m.Timeout,
 		})
 		if err != nil {
 			panic(err)
@@ -116,6 +122,7 @@ func NewChatModel(m ModelConfig) (model ChatModel) {
 			Model:       m.ModelName,
 			Temperature: m.Temperature,
 			MaxTokens:   m.MaxTokens,
+			HTTPClient:  &http.Client{Timeout: m.Timeout},
 		})
 	default:
 		panic("unsupported model type " + m.APIType)
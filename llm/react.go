@@ -19,7 +19,6 @@ package llm
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/cloudwego/abcoder/internal/utils"
@@ -46,6 +45,10 @@ type ReactAgentOptions struct {
 	*react.AgentConfig
 	Retries int           `json:"retries"` // Number of retries, default: 3
 	Timeout time.Duration `json:"timeout"` // Request timeout, default: 600s
+	// RetryableErrors are the substrings of the errors worth retrying, default: DefaultRetryableErrors
+	RetryableErrors []string `json:"retryable_errors"`
+	// Callbacks are extra handlers attached to every call besides the default logging one
+	Callbacks []callbacks.Handler `json:"-"`
 }
 
 func NewReactAgent(name string, opts ReactAgentOptions) *ReactAgent {
@@ -58,11 +61,11 @@ func NewReactAgent(name string, opts ReactAgentOptions) *ReactAgent {
 	}
 	retries := opts.Retries
 	if retries == 0 {
-		retries = 3 // Default: 3 retries
+		retries = DefaultRetries
 	}
 	timeout := opts.Timeout
 	if timeout == 0 {
-		timeout = 600 * time.Second // Default: 600 seconds
+		timeout = DefaultTimeout
 	}
 	return &ReactAgent{
 		opts:    opts,
@@ -115,24 +118,16 @@ func (p *ReactAgent) Call(ctx context.Context, input string) (string, error) {
 		attemptCtx, cancel := context.WithTimeout(ctx, p.timeout)
 		defer cancel()
 
-		out, err := p.Generate(attemptCtx, inputMsgs, agent.WithComposeOptions(compose.WithCallbacks(CallbackHandler{})))
+		handlers := append([]callbacks.Handler{CallbackHandler{}}, p.opts.Callbacks...)
+		out, err := p.Generate(attemptCtx, inputMsgs, agent.WithComposeOptions(compose.WithCallbacks(handlers...)))
 		if err == nil {
 			return out.Content, nil
 		}
 
 		lastErr = err
-		errStr := err.Error()
 
 		// Check if error is retryable (timeout, connection reset, etc.)
-		isRetryable := strings.Contains(errStr, "timeout") ||
-			strings.Contains(errStr, "connection reset") ||
-			strings.Contains(errStr, "connection refused") ||
-			strings.Contains(errStr, "operation timed out") ||
-			strings.Contains(errStr, "context deadline exceeded") ||
-			strings.Contains(errStr, "read tcp") ||
-			strings.Contains(errStr, "write tcp")
-
-		if !isRetryable {
+		if !IsRetryableError(err, p.opts.RetryableErrors) {
 			// Non-retryable error, return immediately
 			log.Error("Non-retryable error occurred: %v", err)
 			return "", utils.WrapError(err, "ReactAgent RoundTrip error")
@@ -0,0 +1,201 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ToolDiffASTNodes = "diff_ast_nodes"
+	DescDiffASTNodes = "show what changed in an AST node between two loaded repositories (e.g. before and after a translated node is written back), as a unified diff of the node codes. Use it to verify a change before committing it."
+
+	// diffContextLines is the number of unchanged lines around each hunk of the unified diff
+	diffContextLines = 3
+)
+
+var SchemaDiffASTNodes = GetJSONSchema(DiffASTNodesReq{})
+
+// DiffASTNodesReq is the request for diff_ast_nodes.
+type DiffASTNodesReq struct {
+	RepoNameBefore string `json:"repo_name_before" jsonschema:"description=the name of the repository before the change"`
+	RepoNameAfter  string `json:"repo_name_after" jsonschema:"description=the name of the repository after the change"`
+	NodeID         NodeID `json:"node_id" jsonschema:"description=the identity of the ast node to diff"`
+}
+
+// DiffASTNodesResp is the response for diff_ast_nodes.
+type DiffASTNodesResp struct {
+	Diff    string `json:"diff,omitempty" jsonschema:"description=the unified diff of the node codes, empty if not changed"`
+	Changed bool   `json:"changed" jsonschema:"description=whether the node codes are different"`
+	Error   string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+type diffOpKind byte
+
+const (
+	diffEqual  diffOpKind = ' '
+	diffDelete diffOpKind = '-'
+	diffInsert diffOpKind = '+'
+)
+
+type diffOp struct {
+	kind diffOpKind
+	line string
+}
+
+// splitDiffLines splits s into lines, an empty string has no lines.
+func splitDiffLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// myersDiff computes the shortest edit script transforming a into b, using the Myers O(ND) algorithm.
+func myersDiff(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	maxD := n + m
+	offset := maxD + 1
+	v := make([]int, 2*maxD+2)
+	var trace [][]int
+
+	// forward pass: find the furthest reaching D-path for each D, recording V
+	var d int
+search:
+	for d = 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// backtrack from (n, m) to (0, 0)
+	ops := make([]diffOp, 0, n+m)
+	x, y := n, m
+	for ; d > 0; d-- {
+		pv := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && pv[offset+k-1] < pv[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := pv[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{diffEqual, a[x]})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{diffInsert, b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{diffDelete, a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, diffOp{diffEqual, a[x]})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// UnifiedDiff returns the unified diff between before and after, or an empty string if they are equal.
+func UnifiedDiff(beforeName, afterName, before, after string) string {
+	ops := myersDiff(splitDiffLines(before), splitDiffLines(after))
+
+	// collect the ranges of ops to print, merging changes closer than 2*diffContextLines
+	type span struct{ start, end int }
+	var spans []span
+	for i, op := range ops {
+		if op.kind == diffEqual {
+			continue
+		}
+		start, end := max(i-diffContextLines, 0), min(i+diffContextLines+1, len(ops))
+		if len(spans) > 0 && start <= spans[len(spans)-1].end {
+			spans[len(spans)-1].end = end
+		} else {
+			spans = append(spans, span{start, end})
+		}
+	}
+	if len(spans) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", beforeName, afterName)
+	// aLine and bLine are the 1-based line numbers of ops[i] in before and after
+	aLine, bLine, i := 1, 1, 0
+	for _, s := range spans {
+		for ; i < s.start; i++ {
+			aLine, bLine = aLine+1, bLine+1
+		}
+		var aCount, bCount int
+		for _, op := range ops[s.start:s.end] {
+			if op.kind != diffInsert {
+				aCount++
+			}
+			if op.kind != diffDelete {
+				bCount++
+			}
+		}
+		aStart, bStart := aLine, bLine
+		if aCount == 0 {
+			aStart--
+		}
+		if bCount == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for ; i < s.end; i++ {
+			op := ops[i]
+			sb.WriteByte(byte(op.kind))
+			sb.WriteString(op.line)
+			sb.WriteByte('\n')
+			if op.kind != diffInsert {
+				aLine++
+			}
+			if op.kind != diffDelete {
+				bLine++
+			}
+		}
+	}
+	return sb.String()
+}
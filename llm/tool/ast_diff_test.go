@@ -0,0 +1,85 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"change", "a\nb\nc", "a\nB\nc", "--- x\n+++ y\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"added", "", "a\nb", "--- x\n+++ y\n@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"removed", "a", "", "--- x\n+++ y\n@@ -1,1 +0,0 @@\n-a\n"},
+		{
+			"two hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10",
+			"0\n1\n2\n3\n4\n5\n6\n7\n8\n9",
+			"--- x\n+++ y\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -7,4 +8,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff("x", "y", tt.before, tt.after); got != tt.want {
+				t.Errorf("UnifiedDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestASTTools_DiffASTNodes(t *testing.T) {
+	newRepo := func(name, content string) *uniast.Repository {
+		repo := uniast.NewRepository(name)
+		mod := uniast.NewModule("m", ".", uniast.Golang)
+		repo.Modules["m"] = mod
+		pkg := uniast.NewPackage("m/p")
+		mod.Packages["m/p"] = pkg
+		pkg.Functions["F"] = &uniast.Function{Identity: uniast.NewIdentity("m", "m/p", "F"), Content: content}
+		return &repo
+	}
+	tr := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: TestRepoASTsDir})
+	tr.repos.Store("before", newRepo("before", "func F() {\n\treturn\n}"))
+	tr.repos.Store("after", newRepo("after", "func F() {\n\tprintln()\n}"))
+
+	nid := NodeID{ModPath: "m", PkgPath: "m/p", Name: "F"}
+	got, err := tr.DiffASTNodes(context.Background(), DiffASTNodesReq{RepoNameBefore: "before", RepoNameAfter: "after", NodeID: nid})
+	if err != nil {
+		t.Fatalf("DiffASTNodes() error = %v", err)
+	}
+	want := "--- before/m?m/p#F\n+++ after/m?m/p#F\n@@ -1,3 +1,3 @@\n func F() {\n-\treturn\n+\tprintln()\n }\n"
+	if got.Error != "" || !got.Changed || got.Diff != want {
+		t.Errorf("unexpected resp: %+v", got)
+	}
+
+	got, _ = tr.DiffASTNodes(context.Background(), DiffASTNodesReq{RepoNameBefore: "before", RepoNameAfter: "before", NodeID: nid})
+	if got.Error != "" || got.Changed || got.Diff != "" {
+		t.Errorf("expect no change, got %+v", got)
+	}
+
+	got, _ = tr.DiffASTNodes(context.Background(), DiffASTNodesReq{RepoNameBefore: "before", RepoNameAfter: "after", NodeID: NodeID{ModPath: "m", PkgPath: "m/p", Name: "G"}})
+	if got.Error == "" {
+		t.Error("got.Error must be non-empty when node not found")
+	}
+}
@@ -17,6 +17,7 @@
 package tool
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -52,6 +53,7 @@ type HierarchyNode struct {
 	Kind     string           `json:"kind"`
 	Path     string           `json:"path,omitempty"`
 	Name     string           `json:"name,omitempty"`
+	Type     string           `json:"type,omitempty"` // node type of a level-4 node: FUNC, TYPE or VAR
 	Counts   *HierarchyCounts `json:"counts,omitempty"`
 	Children []*HierarchyNode `json:"children,omitempty"`
 }
@@ -77,11 +79,20 @@ type GetTargetLanguageSpecResp struct {
 	Error string `json:"error,omitempty"`
 }
 
-// BuildASTHierarchy builds the hierarchy tree from a repository. maxDepth 0-4; if < 0, treated as 4.
-func BuildASTHierarchy(repo *uniast.Repository, maxDepth int) *GetASTHierarchyResp {
-	if maxDepth < 0 || maxDepth > 4 {
-		maxDepth = 4
+// maxHierarchyDepth is the deepest level of the AST hierarchy, which lists the nodes of each file
+const maxHierarchyDepth = 4
+
+// clampHierarchyDepth clamps maxDepth to [0, 4], a negative or too large one means the whole hierarchy
+func clampHierarchyDepth(maxDepth int) int {
+	if maxDepth < 0 || maxDepth > maxHierarchyDepth {
+		return maxHierarchyDepth
 	}
+	return maxDepth
+}
+
+// BuildASTHierarchy builds the hierarchy tree from a repository. maxDepth is clamped to 0-4, see clampHierarchyDepth.
+func BuildASTHierarchy(repo *uniast.Repository, maxDepth int) *GetASTHierarchyResp {
+	maxDepth = clampHierarchyDepth(maxDepth)
 	root := &HierarchyNode{
 		Level: 0,
 		Kind:  "repository",
@@ -109,7 +120,7 @@ func BuildASTHierarchy(repo *uniast.Repository, maxDepth int) *GetASTHierarchyRe
 			Kind:  "module",
 			Path:  string(modPath),
 			Name:  mod.Name,
-			Counts: &HierarchyCounts{Packages: len(mod.Packages)},
+			Counts: &HierarchyCounts{Packages: mod.PackageCount()},
 		}
 		if maxDepth >= 2 {
 			for pkgPath, pkg := range mod.Packages {
@@ -127,12 +138,17 @@ func BuildASTHierarchy(repo *uniast.Repository, maxDepth int) *GetASTHierarchyRe
 					},
 				}
 				if maxDepth >= 3 {
+					var fileNodes map[string][]*HierarchyNode
+					if maxDepth >= 4 {
+						fileNodes = hierarchyNodesByFile(pkg)
+					}
 					for _, f := range files {
 						pkgNode.Children = append(pkgNode.Children, &HierarchyNode{
-							Level: 3,
-							Kind:  "file",
-							Path:  f.Path,
-							Name:  f.Path,
+							Level:    3,
+							Kind:     "file",
+							Path:     f.Path,
+							Name:     f.Path,
+							Children: fileNodes[f.Path],
 						})
 					}
 				}
@@ -144,6 +160,48 @@ func BuildASTHierarchy(repo *uniast.Repository, maxDepth int) *GetASTHierarchyRe
 	return &GetASTHierarchyResp{Hierarchy: root}
 }
 
+// hierarchyNodesByFile returns the level-4 nodes of the package grouped by file, in the order of appearance
+func hierarchyNodesByFile(pkg *uniast.Package) map[string][]*HierarchyNode {
+	type entry struct {
+		line int
+		node *HierarchyNode
+	}
+	entries := make(map[string][]entry)
+	add := func(id uniast.Identity, fl uniast.FileLine, typ uniast.NodeType) {
+		entries[fl.File] = append(entries[fl.File], entry{line: fl.Line, node: &HierarchyNode{
+			Level: 4,
+			Kind:  "node",
+			Name:  id.Name,
+			Type:  typ.String(),
+		}})
+	}
+	for _, t := range pkg.Types {
+		add(t.Identity, t.FileLine, uniast.TYPE)
+	}
+	for _, f := range pkg.Functions {
+		add(f.Identity, f.FileLine, uniast.FUNC)
+	}
+	for _, v := range pkg.Vars {
+		add(v.Identity, v.FileLine, uniast.VAR)
+	}
+
+	ret := make(map[string][]*HierarchyNode, len(entries))
+	for file, es := range entries {
+		sort.Slice(es, func(i, j int) bool {
+			if es[i].line != es[j].line {
+				return es[i].line < es[j].line
+			}
+			return es[i].node.Name < es[j].node.Name
+		})
+		nodes := make([]*HierarchyNode, len(es))
+		for i, e := range es {
+			nodes[i] = e.node
+		}
+		ret[file] = nodes
+	}
+	return ret
+}
+
 // GetTargetLanguageSpecContent returns the spec text for the given target language.
 func GetTargetLanguageSpecContent(targetLanguage string) *GetTargetLanguageSpecResp {
 	lang := uniast.NewLanguage(strings.TrimSpace(targetLanguage))
@@ -17,6 +17,7 @@
 package tool
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -32,9 +33,9 @@ func TestBuildASTHierarchy(t *testing.T) {
 	mod := uniast.NewModule("m", ".", uniast.Golang)
 	mod.Packages["pkg/a"] = uniast.NewPackage("pkg/a")
 	pkg := mod.Packages["pkg/a"]
-	pkg.Types["T"] = &uniast.Type{}
-	pkg.Functions["f"] = &uniast.Function{}
-	pkg.Vars["v"] = &uniast.Var{}
+	pkg.Types["T"] = &uniast.Type{Identity: uniast.NewIdentity("m", "pkg/a", "T"), FileLine: uniast.FileLine{File: "path/to/foo.go", Line: 3}}
+	pkg.Functions["f"] = &uniast.Function{Identity: uniast.NewIdentity("m", "pkg/a", "f"), FileLine: uniast.FileLine{File: "path/to/foo.go", Line: 5}}
+	pkg.Vars["v"] = &uniast.Var{Identity: uniast.NewIdentity("m", "pkg/a", "v"), FileLine: uniast.FileLine{File: "path/to/foo.go", Line: 1}}
 	f := uniast.NewFile("path/to/foo.go")
 	f.Package = "pkg/a"
 	if mod.Files == nil {
@@ -165,6 +166,30 @@ func TestBuildASTHierarchy(t *testing.T) {
 				if fileNode.Path != "path/to/foo.go" {
 					t.Errorf("file Path=path/to/foo.go, got %s", fileNode.Path)
 				}
+				if len(fileNode.Children) != 0 {
+					t.Errorf("maxDepth 3: file.Children should be empty, got len=%d", len(fileNode.Children))
+				}
+			},
+		},
+		{
+			name:     "one_module_one_package_depth4",
+			repo:     &oneModOnePkgRepo,
+			maxDepth: 4,
+			assert: func(t *testing.T, got *GetASTHierarchyResp) {
+				fileNode := got.Hierarchy.Children[0].Children[0].Children[0]
+				want := []HierarchyNode{
+					{Level: 4, Kind: "node", Name: "v", Type: "VAR"},
+					{Level: 4, Kind: "node", Name: "T", Type: "TYPE"},
+					{Level: 4, Kind: "node", Name: "f", Type: "FUNC"},
+				}
+				if len(fileNode.Children) != len(want) {
+					t.Fatalf("file.Children len=%d (nodes in line order), got %d", len(want), len(fileNode.Children))
+				}
+				for i, w := range want {
+					if c := fileNode.Children[i]; !reflect.DeepEqual(*c, w) {
+						t.Errorf("file.Children[%d]=%+v, got %+v", i, w, *c)
+					}
+				}
 			},
 		},
 		{
@@ -194,6 +219,9 @@ func TestBuildASTHierarchy(t *testing.T) {
 				if len(got.Hierarchy.Children) == 0 {
 					t.Error("maxDepth -1 treated as 4: should have module children")
 				}
+				if fileNode := got.Hierarchy.Children[0].Children[0].Children[0]; len(fileNode.Children) != 3 {
+					t.Errorf("maxDepth -1 treated as 4: file should have 3 node children, got %d", len(fileNode.Children))
+				}
 			},
 		},
 		{
@@ -207,6 +235,9 @@ func TestBuildASTHierarchy(t *testing.T) {
 				if len(got.Hierarchy.Children) == 0 {
 					t.Error("maxDepth 5 treated as 4: should have module children")
 				}
+				if fileNode := got.Hierarchy.Children[0].Children[0].Children[0]; len(fileNode.Children) != 3 {
+					t.Errorf("maxDepth 5 treated as 4: file should have 3 node children, got %d", len(fileNode.Children))
+				}
 			},
 		},
 	}
@@ -0,0 +1,194 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+const (
+	ToolGetRepoMetrics = "get_repo_metrics"
+	DescGetRepoMetrics = "get the size metrics of a repository: node/file counts, lines of code, average function length, top-10 most-depended-on nodes, dependency cycles and internal/external module counts. Use it before deep analysis to estimate the repo scale."
+
+	// topDependedNodesLimit is the max number of nodes returned in GetRepoMetricsResp.TopDependedNodes
+	topDependedNodesLimit = 10
+)
+
+var SchemaGetRepoMetrics = GetJSONSchema(GetRepoMetricsReq{})
+
+// GetRepoMetricsReq is the request for get_repo_metrics.
+type GetRepoMetricsReq struct {
+	RepoName string `json:"repo_name" jsonschema:"description=the name of the repository"`
+}
+
+// GetRepoMetricsResp is the response for get_repo_metrics.
+type GetRepoMetricsResp struct {
+	Functions        int               `json:"functions" jsonschema:"description=total number of functions and methods"`
+	Types            int               `json:"types" jsonschema:"description=total number of types"`
+	Vars             int               `json:"vars" jsonschema:"description=total number of global vars and consts"`
+	Files            int               `json:"files" jsonschema:"description=total number of source files"`
+	LinesOfCode      int               `json:"lines_of_code" jsonschema:"description=total lines of code of all nodes"`
+	AvgFunctionLines float64           `json:"avg_function_lines" jsonschema:"description=average lines of a function"`
+	TopDependedNodes []NodeDependCount `json:"top_depended_nodes,omitempty" jsonschema:"description=the most depended-on nodes, ordered by reference count"`
+	Cycles           int               `json:"cycles" jsonschema:"description=number of dependency cycles detected among nodes"`
+	InternalModules  int               `json:"internal_modules" jsonschema:"description=number of modules inside the repository"`
+	ExternalModules  int               `json:"external_modules" jsonschema:"description=number of third-party modules"`
+	Error            string            `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// NodeDependCount is a node with its incoming reference count.
+type NodeDependCount struct {
+	NodeID
+	References int `json:"references" jsonschema:"description=number of nodes referencing this node"`
+}
+
+// BuildRepoMetrics aggregates the size metrics of a repository.
+func BuildRepoMetrics(repo *uniast.Repository) (*GetRepoMetricsResp, error) {
+	graph, err := repoGraph(repo)
+	if err != nil {
+		return nil, err
+	}
+	resp := &GetRepoMetricsResp{}
+	var funcLines int
+	for _, mod := range repo.Modules {
+		if mod.IsExternal() {
+			resp.ExternalModules++
+			continue
+		}
+		resp.InternalModules++
+		resp.Files += len(mod.Files)
+	}
+	for _, f := range repo.AllFunctions {
+		n := strings.Count(f.Content, "\n")
+		funcLines += n
+		resp.LinesOfCode += n
+		resp.Functions++
+	}
+	for _, t := range repo.AllTypes {
+		resp.LinesOfCode += strings.Count(t.Content, "\n")
+		resp.Types++
+	}
+	for _, v := range repo.AllVars {
+		resp.LinesOfCode += strings.Count(v.Content, "\n")
+		resp.Vars++
+	}
+	if resp.Functions > 0 {
+		resp.AvgFunctionLines = float64(funcLines) / float64(resp.Functions)
+	}
+
+	for _, node := range graph {
+		if len(node.References) == 0 {
+			continue
+		}
+		resp.TopDependedNodes = append(resp.TopDependedNodes, NodeDependCount{
+			NodeID:     NewNodeID(node.Identity),
+			References: len(node.References),
+		})
+	}
+	sort.Slice(resp.TopDependedNodes, func(i, j int) bool {
+		a, b := resp.TopDependedNodes[i], resp.TopDependedNodes[j]
+		if a.References != b.References {
+			return a.References > b.References
+		}
+		return a.Identity().Full() < b.Identity().Full()
+	})
+	if len(resp.TopDependedNodes) > topDependedNodesLimit {
+		resp.TopDependedNodes = resp.TopDependedNodes[:topDependedNodesLimit]
+	}
+
+	resp.Cycles = countDependencyCycles(graph)
+	return resp, nil
+}
+
+// repoGraph returns the node graph of repo. A repo loaded without the graph is shared by the tool calls,
+// so the graph is built on a clone of it instead of the repo itself
+func repoGraph(repo *uniast.Repository) (uniast.NodeGraph, error) {
+	if len(repo.Graph) > 0 {
+		return repo.Graph, nil
+	}
+	clone := repo.Clone()
+	if err := clone.BuildGraph(); err != nil {
+		return nil, fmt.Errorf("build graph of repo '%s' failed: %w", repo.Name, err)
+	}
+	return clone.Graph, nil
+}
+
+// countDependencyCycles counts the strongly-connected components (Tarjan) which form a cycle:
+// either more than one node, or a single node depending on itself.
+func countDependencyCycles(graph uniast.NodeGraph) int {
+	var (
+		index   = 0
+		indices = make(map[string]int, len(graph))
+		lowlink = make(map[string]int, len(graph))
+		onStack = make(map[string]bool, len(graph))
+		stack   []string
+		cycles  int
+	)
+
+	var strongConnect func(key string)
+	strongConnect = func(key string) {
+		indices[key] = index
+		lowlink[key] = index
+		index++
+		stack = append(stack, key)
+		onStack[key] = true
+
+		selfLoop := false
+		for _, dep := range graph[key].Dependencies {
+			dk := dep.Identity.Full()
+			if dk == key {
+				selfLoop = true
+			}
+			if _, ok := graph[dk]; !ok {
+				continue
+			}
+			if _, visited := indices[dk]; !visited {
+				strongConnect(dk)
+				lowlink[key] = min(lowlink[key], lowlink[dk])
+			} else if onStack[dk] {
+				lowlink[key] = min(lowlink[key], indices[dk])
+			}
+		}
+
+		if lowlink[key] == indices[key] {
+			size := 0
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				size++
+				if top == key {
+					break
+				}
+			}
+			if size > 1 || selfLoop {
+				cycles++
+			}
+		}
+	}
+
+	for key := range graph {
+		if _, visited := indices[key]; !visited {
+			strongConnect(key)
+		}
+	}
+	return cycles
+}
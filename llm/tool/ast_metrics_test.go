@@ -0,0 +1,97 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestBuildRepoMetrics(t *testing.T) {
+	repo := uniast.NewRepository("r")
+	repo.Modules["ext"] = uniast.NewModule("ext", "", uniast.Golang)
+	mod := uniast.NewModule("m", ".", uniast.Golang)
+	repo.Modules["m"] = mod
+	mod.Files["a.go"] = &uniast.File{Path: "a.go", Package: "p"}
+
+	idA := uniast.NewIdentity("m", "p", "A")
+	idB := uniast.NewIdentity("m", "p", "B")
+	idC := uniast.NewIdentity("m", "p", "C")
+	idT := uniast.NewIdentity("m", "p", "T")
+	pkg := uniast.NewPackage("p")
+	mod.Packages["p"] = pkg
+	// A -> B -> A forms a cycle; C -> T; A -> T
+	pkg.Functions["A"] = &uniast.Function{Identity: idA, Content: "func A() {\n\tB()\n}", FunctionCalls: []uniast.Dependency{{Identity: idB}}, Types: []uniast.Dependency{{Identity: idT}}}
+	pkg.Functions["B"] = &uniast.Function{Identity: idB, Content: "func B() {\n\tA()\n}", FunctionCalls: []uniast.Dependency{{Identity: idA}}}
+	pkg.Functions["C"] = &uniast.Function{Identity: idC, Content: "func C() {\n\tvar t T\n\t_ = t\n}", Types: []uniast.Dependency{{Identity: idT}}}
+	pkg.Types["T"] = &uniast.Type{Identity: idT, Content: "type T struct{}"}
+	pkg.Vars["v"] = &uniast.Var{Identity: uniast.NewIdentity("m", "p", "v"), Content: "var v = 1"}
+
+	got, err := BuildRepoMetrics(&repo)
+	if err != nil {
+		t.Fatalf("BuildRepoMetrics() error = %v", err)
+	}
+	if len(repo.Graph) != 0 {
+		t.Errorf("expect the graph to be built without modifying the repo")
+	}
+	if got.Functions != 3 || got.Types != 1 || got.Vars != 1 || got.Files != 1 {
+		t.Errorf("counts = %d/%d/%d/%d, want 3/1/1/1", got.Functions, got.Types, got.Vars, got.Files)
+	}
+	if got.LinesOfCode != 7 {
+		t.Errorf("LinesOfCode = %d, want 7", got.LinesOfCode)
+	}
+	if got.AvgFunctionLines < 2.33 || got.AvgFunctionLines > 2.34 {
+		t.Errorf("AvgFunctionLines = %v, want ~2.33", got.AvgFunctionLines)
+	}
+	if got.InternalModules != 1 || got.ExternalModules != 1 {
+		t.Errorf("modules = %d internal / %d external, want 1/1", got.InternalModules, got.ExternalModules)
+	}
+	if got.Cycles != 1 {
+		t.Errorf("Cycles = %d, want 1", got.Cycles)
+	}
+	if len(got.TopDependedNodes) == 0 || got.TopDependedNodes[0].Name != "T" || got.TopDependedNodes[0].References != 2 {
+		t.Errorf("TopDependedNodes[0] should be T with 2 references, got %+v", got.TopDependedNodes)
+	}
+}
+
+func TestASTTools_GetRepoMetrics(t *testing.T) {
+	tr := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: TestRepoASTsDir})
+
+	got, err := tr.GetRepoMetrics(context.Background(), GetRepoMetricsReq{RepoName: "localsession"})
+	if err != nil {
+		t.Fatalf("GetRepoMetrics() error = %v", err)
+	}
+	if got.Error != "" {
+		t.Errorf("got.Error = %q, want empty", got.Error)
+	}
+	if got.Functions == 0 || got.LinesOfCode == 0 {
+		t.Errorf("expect non-empty metrics, got %+v", got)
+	}
+	if len(got.TopDependedNodes) > topDependedNodesLimit {
+		t.Errorf("TopDependedNodes len = %d, want <= %d", len(got.TopDependedNodes), topDependedNodesLimit)
+	}
+
+	got, err = tr.GetRepoMetrics(context.Background(), GetRepoMetricsReq{RepoName: "nonexistent_repo"})
+	if err != nil {
+		t.Fatalf("GetRepoMetrics() error = %v", err)
+	}
+	if got.Error == "" {
+		t.Error("got.Error must be non-empty when repo not found")
+	}
+}
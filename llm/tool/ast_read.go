@@ -19,6 +19,7 @@ package tool
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -56,25 +57,55 @@ var (
 type ASTReadToolsOptions struct {
 	// PatchOptions patch.Options
 	RepoASTsDir string
+	// Watch prints a log line to stderr each time a repo AST file is reloaded
+	Watch bool
+	// LLMChat enables the summarize_node tool, it is called with the prompt to summarize a node
+	LLMChat LLMChatFunc
 }
 
 type ASTReadTools struct {
-	opts  ASTReadToolsOptions
-	repos sync.Map
+	opts ASTReadToolsOptions
+	// mu guards updates of repos and files from the file watcher
+	mu    sync.RWMutex
+	repos sync.Map          // repo name => *uniast.Repository or *repoLoadError
+	files map[string]string // AST file path => repo name
 	tools map[string]tool.InvokableTool
+	// summaries caches the results of summarize_node, node id => *summaryCache
+	summaries sync.Map
+}
+
+// repoLoadError is stored in place of a repo whose AST file failed to load,
+// so that queries on it get a meaningful error instead of "not found"
+type repoLoadError struct {
+	file string
+	err  error
+}
+
+func (e *repoLoadError) Error() string {
+	return fmt.Sprintf("load AST file %s failed: %v", e.file, e.err)
 }
 
 func NewASTReadTools(opts ASTReadToolsOptions) *ASTReadTools {
 	ret := &ASTReadTools{
 		opts: opts,
 		// patcher: patch.NewPatcher(repo, opts.PatchOptions),
+		files: map[string]string{},
 		tools: map[string]tool.InvokableTool{},
 	}
 
 	// load all *.json repos from RepoASTsDir (strict: first load error panics)
-	if err := LoadReposIntoMap(opts.RepoASTsDir, &ret.repos, nil); err != nil {
+	files, err := filepath.Glob(filepath.Join(opts.RepoASTsDir, "*.json"))
+	if err != nil {
 		panic("Load Uniast JSON file failed: " + err.Error())
 	}
+	for _, f := range files {
+		repo, err := uniast.LoadRepo(f)
+		if err != nil && !uniast.IsVersionMismatch(err) {
+			panic("Load Uniast JSON file failed: " + err.Error())
+		}
+		ret.repos.Store(repo.Name, repo)
+		ret.files[f] = repo.Name
+	}
 
 	// add a file watch on the RepoASTsDir
 	abutil.WatchDir(opts.RepoASTsDir, func(op fsnotify.Op, file string) {
@@ -82,13 +113,9 @@ func NewASTReadTools(opts ASTReadToolsOptions) *ASTReadTools {
 			return
 		}
 		if op&fsnotify.Write != 0 || op&fsnotify.Create != 0 {
-			if repo, err := uniast.LoadRepo(file); err != nil {
-				log.Error("Load Uniast JSON file failed: %v", err)
-			} else {
-				ret.repos.Store(repo.Name, repo)
-			}
-		} else if op&fsnotify.Remove != 0 {
-			ret.repos.Delete(filepath.Base(file))
+			ret.reloadRepoFile(file)
+		} else if op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+			ret.removeRepoFile(file)
 		}
 	})
 
@@ -142,6 +169,16 @@ func NewASTReadTools(opts ASTReadToolsOptions) *ASTReadTools {
 	}
 	ret.tools[ToolGetASTNode] = tt
 
+	tt, err = utils.InferTool(ToolGetASTNodeSourceRange,
+		DescGetASTNodeSourceRange,
+		ret.GetASTNodeSourceRange, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolGetASTNodeSourceRange] = tt
+
 	tt, err = utils.InferTool(ToolGetASTHierarchy,
 		DescGetASTHierarchy,
 		ret.GetASTHierarchy, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
@@ -162,9 +199,103 @@ func NewASTReadTools(opts ASTReadToolsOptions) *ASTReadTools {
 	}
 	ret.tools[ToolGetTargetLanguageSpec] = tt
 
+	tt, err = utils.InferTool(ToolGetRepoMetrics,
+		DescGetRepoMetrics,
+		ret.GetRepoMetrics, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolGetRepoMetrics] = tt
+
+	tt, err = utils.InferTool(ToolExportRepoSubgraph,
+		DescExportRepoSubgraph,
+		ret.ExportRepoSubgraph, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolExportRepoSubgraph] = tt
+
+	tt, err = utils.InferTool(ToolDiffASTNodes,
+		DescDiffASTNodes,
+		ret.DiffASTNodes, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolDiffASTNodes] = tt
+
+	if opts.LLMChat != nil {
+		tt, err = utils.InferTool(ToolSummarizeNode,
+			DescSummarizeNode,
+			ret.SummarizeNode, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+				return abutil.MarshalJSONIndent(output)
+			}))
+		if err != nil {
+			panic(err)
+		}
+		ret.tools[ToolSummarizeNode] = tt
+	}
+
 	return ret
 }
 
+// reloadRepoFile (re)loads the repo AST file into repos.
+// If the file fails to load, a *repoLoadError is stored instead.
+func (t *ASTReadTools) reloadRepoFile(file string) {
+	repo, err := uniast.LoadRepo(file)
+	if uniast.IsVersionMismatch(err) {
+		log.Info("Load Uniast JSON file %s: %v", file, err)
+		err = nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	oldName, loaded := t.files[file]
+	if err != nil {
+		log.Error("Load Uniast JSON file failed: %v", err)
+		if !loaded {
+			oldName = strings.TrimSuffix(filepath.Base(file), ".json")
+			t.files[file] = oldName
+		}
+		t.repos.Store(oldName, &repoLoadError{file: file, err: err})
+		return
+	}
+	if loaded && oldName != repo.Name {
+		t.repos.Delete(oldName)
+	}
+	t.repos.Store(repo.Name, repo)
+	t.files[file] = repo.Name
+	if t.opts.Watch {
+		fmt.Fprintf(os.Stderr, "[abcoder] reloaded repo %s from %s\n", repo.Name, file)
+	}
+}
+
+// removeRepoFile removes the repo loaded from the AST file
+func (t *ASTReadTools) removeRepoFile(file string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	name, ok := t.files[file]
+	if !ok {
+		name = strings.TrimSuffix(filepath.Base(file), ".json")
+	}
+	delete(t.files, file)
+	for _, other := range t.files {
+		if other == name {
+			// still provided by another AST file
+			return
+		}
+	}
+	t.repos.Delete(name)
+	if t.opts.Watch {
+		fmt.Fprintf(os.Stderr, "[abcoder] removed repo %s of %s\n", name, file)
+	}
+}
+
 func (t *ASTReadTools) GetTools() []Tool {
 	ret := make([]Tool, 0, len(t.tools))
 	for _, tt := range t.tools {
@@ -186,6 +317,8 @@ type ListReposResp struct {
 
 func (t *ASTReadTools) ListRepos(ctx context.Context, req ListReposReq) (*ListReposResp, error) {
 	ret := ListReposResp{}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	t.repos.Range(func(key, value interface{}) bool {
 		ret.RepoNames = append(ret.RepoNames, key.(string))
 		return true
@@ -194,7 +327,8 @@ func (t *ASTReadTools) ListRepos(ctx context.Context, req ListReposReq) (*ListRe
 }
 
 type GetRepoStructReq struct {
-	RepoName string `json:"repo_name" jsonschema:"description=the name of the repository"`
+	RepoName     string `json:"repo_name" jsonschema:"description=the name of the repository"`
+	IncludeTests bool   `json:"include_tests,omitempty" jsonschema:"description=also list the test packages, e.g. pkg_test, default to false"`
 }
 
 type GetRepoStructResp struct {
@@ -219,19 +353,20 @@ type FileStruct struct {
 }
 
 type NodeStruct struct {
-	ModPath      uniast.ModPath `json:"mod_path,omitempty" jsonschema:"description=the module path"`
-	PkgPath      uniast.PkgPath `json:"pkg_path,omitempty" jsonschema:"description=the package path"`
-	Name         string         `json:"name" jsonschema:"description=the name of the node"`
-	Type         string         `json:"type,omitempty" jsonschema:"description=the type of the node"`
-	Signature    string         `json:"signature,omitempty" jsonschema:"description=the func signature of the node"`
-	File         string         `json:"file,omitempty" jsonschema:"description=the file path of the node"`
-	Line         int            `json:"line,omitempty" jsonschema:"description=the line of the node"`
-	Codes        string         `json:"codes,omitempty" jsonschema:"description=the codes of the node"`
-	Dependencies []NodeID       `json:"dependencies,omitempty" jsonschema:"description=the dependencies of the node"`
-	References   []NodeID       `json:"references,omitempty" jsonschema:"description=the references of the node"`
-	Implements   []NodeID       `json:"implements,omitempty" jsonschema:"description=the implements of the node"`
-	Groups       []NodeID       `json:"groups,omitempty" jsonschema:"description=the groups of the node"`
-	Inherits     []NodeID       `json:"inherits,omitempty" jsonschema:"description=the inherits of the node"`
+	ModPath      uniast.ModPath    `json:"mod_path,omitempty" jsonschema:"description=the module path"`
+	PkgPath      uniast.PkgPath    `json:"pkg_path,omitempty" jsonschema:"description=the package path"`
+	Name         string            `json:"name" jsonschema:"description=the name of the node"`
+	Type         string            `json:"type,omitempty" jsonschema:"description=the type of the node"`
+	Signature    string            `json:"signature,omitempty" jsonschema:"description=the func signature of the node"`
+	File         string            `json:"file,omitempty" jsonschema:"description=the file path of the node"`
+	Line         int               `json:"line,omitempty" jsonschema:"description=the line of the node"`
+	Codes        string            `json:"codes,omitempty" jsonschema:"description=the codes of the node"`
+	Dependencies []NodeID          `json:"dependencies,omitempty" jsonschema:"description=the dependencies of the node"`
+	References   []NodeID          `json:"references,omitempty" jsonschema:"description=the references of the node"`
+	Implements   []NodeID          `json:"implements,omitempty" jsonschema:"description=the implements of the node"`
+	Groups       []NodeID          `json:"groups,omitempty" jsonschema:"description=the groups of the node"`
+	Inherits     []NodeID          `json:"inherits,omitempty" jsonschema:"description=the inherits of the node"`
+	Annotations  map[string]string `json:"annotations,omitempty" jsonschema:"description=the key-value notes attached to the node by other tools"`
 }
 
 type NodeID struct {
@@ -257,6 +392,8 @@ func (n NodeID) Identity() uniast.Identity {
 }
 
 func (t *ASTReadTools) getRepoAST(repoName string) (*uniast.Repository, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	repo, ok := t.repos.Load(repoName)
 	if !ok {
 		candis := []string{}
@@ -271,13 +408,15 @@ func (t *ASTReadTools) getRepoAST(repoName string) (*uniast.Repository, error) {
 			if !ok {
 				return nil, fmt.Errorf("repo '%s' not found", candis[0])
 			}
-			return repo.(*uniast.Repository), nil
 		} else if len(candis) > 1 {
 			return nil, fmt.Errorf("repo '%s' is ambiguous, maybe you want one of %v", repoName, candis)
 		} else {
 			return nil, fmt.Errorf("repo '%s' not found", repoName)
 		}
 	}
+	if lerr, ok := repo.(*repoLoadError); ok {
+		return nil, fmt.Errorf("repo '%s' is unavailable: %w", repoName, lerr)
+	}
 	return repo.(*uniast.Repository), nil
 }
 
@@ -299,7 +438,10 @@ func (t *ASTReadTools) GetRepoStructure(_ context.Context, req GetRepoStructReq)
 		mm := ModuleStruct{
 			ModPath: mod.Name,
 		}
-		for p := range mod.Packages {
+		for p, pkg := range mod.Packages {
+			if pkg != nil && pkg.IsTest && !req.IncludeTests {
+				continue
+			}
 			pp := PackageStruct{
 				PkgPath: p,
 			}
@@ -517,6 +659,7 @@ func (t *ASTReadTools) GetASTNode(_ context.Context, params GetASTNodeReq) (*Get
 			Implements:   imps,
 			Inherits:     inhs,
 			Groups:       grps,
+			Annotations:  node.Annotations,
 		})
 	}
 
@@ -528,6 +671,20 @@ func (t *ASTReadTools) GetASTNode(_ context.Context, params GetASTNodeReq) (*Get
 	return resp, nil
 }
 
+// GetASTNodeSourceRange returns the file, line range and byte range of a node in its source file.
+func (t *ASTReadTools) GetASTNodeSourceRange(_ context.Context, req GetASTNodeSourceRangeReq) (*GetASTNodeSourceRangeResp, error) {
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &GetASTNodeSourceRangeResp{Error: err.Error()}, nil
+	}
+	id := req.NodeID.Identity()
+	node := repo.GetNode(id)
+	if node == nil {
+		return &GetASTNodeSourceRangeResp{Error: fmt.Sprintf("node '%s' not found", id.Full())}, nil
+	}
+	return BuildASTNodeSourceRange(repo, node), nil
+}
+
 // GetASTHierarchy returns the AST hierarchy (leveled directory) of the repository.
 func (t *ASTReadTools) GetASTHierarchy(_ context.Context, req GetASTHierarchyReq) (*GetASTHierarchyResp, error) {
 	repo, err := t.getRepoAST(req.RepoName)
@@ -541,3 +698,57 @@ func (t *ASTReadTools) GetASTHierarchy(_ context.Context, req GetASTHierarchyReq
 func (t *ASTReadTools) GetTargetLanguageSpec(_ context.Context, req GetTargetLanguageSpecReq) (*GetTargetLanguageSpecResp, error) {
 	return GetTargetLanguageSpecContent(req.TargetLanguage), nil
 }
+
+// GetRepoMetrics returns the size metrics of the repository.
+func (t *ASTReadTools) GetRepoMetrics(_ context.Context, req GetRepoMetricsReq) (*GetRepoMetricsResp, error) {
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &GetRepoMetricsResp{Error: err.Error()}, nil
+	}
+	resp, err := BuildRepoMetrics(repo)
+	if err != nil {
+		return &GetRepoMetricsResp{Error: err.Error()}, nil
+	}
+	return resp, nil
+}
+
+// ExportRepoSubgraph returns the package-level dependency graph of the repository in DOT format.
+func (t *ASTReadTools) ExportRepoSubgraph(_ context.Context, req ExportRepoSubgraphReq) (*ExportRepoSubgraphResp, error) {
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &ExportRepoSubgraphResp{Error: err.Error()}, nil
+	}
+	resp, err := BuildRepoSubgraph(repo, req.ModPath, req.MaxNodes)
+	if err != nil {
+		return &ExportRepoSubgraphResp{Error: err.Error()}, nil
+	}
+	return resp, nil
+}
+
+// DiffASTNodes returns the unified diff of a node between two loaded repositories.
+func (t *ASTReadTools) DiffASTNodes(_ context.Context, req DiffASTNodesReq) (*DiffASTNodesResp, error) {
+	before, err := t.getRepoAST(req.RepoNameBefore)
+	if err != nil {
+		return &DiffASTNodesResp{Error: err.Error()}, nil
+	}
+	after, err := t.getRepoAST(req.RepoNameAfter)
+	if err != nil {
+		return &DiffASTNodesResp{Error: err.Error()}, nil
+	}
+
+	id := req.NodeID.Identity()
+	var beforeCode, afterCode string
+	beforeNode, afterNode := before.GetNode(id), after.GetNode(id)
+	if beforeNode == nil && afterNode == nil {
+		return &DiffASTNodesResp{Error: fmt.Sprintf("node '%s' not found in either repository", id.Full())}, nil
+	}
+	if beforeNode != nil {
+		beforeCode = beforeNode.Content()
+	}
+	if afterNode != nil {
+		afterCode = afterNode.Content()
+	}
+
+	diff := UnifiedDiff(req.RepoNameBefore+"/"+id.Full(), req.RepoNameAfter+"/"+id.Full(), beforeCode, afterCode)
+	return &DiffASTNodesResp{Diff: diff, Changed: diff != ""}, nil
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+const (
+	ToolGetASTNodeSourceRange = "get_ast_node_source_range"
+	DescGetASTNodeSourceRange = "get the exact location of an AST node in its source file: the file path (relative to the repository), the start/end lines and the start/end byte offsets. Use it before writing a node back to know the range to replace."
+)
+
+var SchemaGetASTNodeSourceRange = GetJSONSchema(GetASTNodeSourceRangeReq{})
+
+// GetASTNodeSourceRangeReq is the request for get_ast_node_source_range.
+type GetASTNodeSourceRangeReq struct {
+	RepoName string `json:"repo_name" jsonschema:"description=the name of the repository"`
+	NodeID   NodeID `json:"node_id" jsonschema:"description=the identity of the ast node"`
+}
+
+// GetASTNodeSourceRangeResp is the response for get_ast_node_source_range.
+type GetASTNodeSourceRangeResp struct {
+	FilePath  string `json:"file_path,omitempty" jsonschema:"description=the source file of the node, relative to the repository"`
+	StartLine int    `json:"start_line" jsonschema:"description=the first line of the node, starting from 1"`
+	EndLine   int    `json:"end_line" jsonschema:"description=the last line of the node"`
+	StartByte int    `json:"start_byte" jsonschema:"description=the byte offset of the node start in the file, -1 if unknown"`
+	EndByte   int    `json:"end_byte" jsonschema:"description=the byte offset right after the node end in the file, -1 if unknown"`
+	Error     string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// BuildASTNodeSourceRange computes the source range of a node of repo.
+// If the parser recorded the byte offsets and the source file is readable under repo.Path,
+// the lines are computed from the offsets; otherwise the end line is counted from the node content.
+func BuildASTNodeSourceRange(repo *uniast.Repository, node *uniast.Node) *GetASTNodeSourceRangeResp {
+	fl := node.FileLine()
+	resp := &GetASTNodeSourceRangeResp{
+		FilePath:  fl.File,
+		StartLine: fl.Line,
+		EndLine:   fl.Line + strings.Count(strings.TrimRight(node.Content(), "\n"), "\n"),
+		StartByte: -1,
+		EndByte:   -1,
+	}
+	if fl.EndOffset <= fl.StartOffset || fl.StartOffset < 0 {
+		return resp
+	}
+	resp.StartByte, resp.EndByte = fl.StartOffset, fl.EndOffset
+
+	if fl.File == "" || repo.Path == "" {
+		return resp
+	}
+	data, err := os.ReadFile(filepath.Join(repo.Path, fl.File))
+	if err != nil || fl.EndOffset > len(data) {
+		return resp
+	}
+	// the offsets may cover the doc comment before the node line
+	resp.StartLine = 1 + bytes.Count(data[:fl.StartOffset], []byte("\n"))
+	resp.EndLine = 1 + bytes.Count(data[:fl.EndOffset], []byte("\n"))
+	if data[fl.EndOffset-1] == '\n' {
+		resp.EndLine--
+	}
+	return resp
+}
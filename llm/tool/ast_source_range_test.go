@@ -0,0 +1,79 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestASTTools_GetASTNodeSourceRange(t *testing.T) {
+	src := "package p\n\n// F does nothing\nfunc F() {\n\treturn\n}\n\nfunc G() {}\n"
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fContent := "// F does nothing\nfunc F() {\n\treturn\n}"
+	start := strings.Index(src, fContent)
+
+	repo := uniast.NewRepository("r")
+	repo.Path = dir
+	mod := uniast.NewModule("m", ".", uniast.Golang)
+	repo.Modules["m"] = mod
+	pkg := uniast.NewPackage("m/p")
+	mod.Packages["m/p"] = pkg
+	pkg.Functions["F"] = &uniast.Function{
+		Identity: uniast.NewIdentity("m", "m/p", "F"),
+		FileLine: uniast.FileLine{File: "p.go", Line: 4, StartOffset: start, EndOffset: start + len(fContent)},
+		Content:  fContent,
+	}
+	// no offsets recorded
+	pkg.Functions["G"] = &uniast.Function{
+		Identity: uniast.NewIdentity("m", "m/p", "G"),
+		FileLine: uniast.FileLine{File: "p.go", Line: 8},
+		Content:  "func G() {\n}",
+	}
+	repo.AllNodesSetRepo()
+
+	tr := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: TestRepoASTsDir})
+	tr.repos.Store("r", &repo)
+
+	got, err := tr.GetASTNodeSourceRange(context.Background(), GetASTNodeSourceRangeReq{RepoName: "r", NodeID: NodeID{ModPath: "m", PkgPath: "m/p", Name: "F"}})
+	if err != nil {
+		t.Fatalf("GetASTNodeSourceRange() error = %v", err)
+	}
+	want := GetASTNodeSourceRangeResp{FilePath: "p.go", StartLine: 3, EndLine: 6, StartByte: start, EndByte: start + len(fContent)}
+	if *got != want {
+		t.Errorf("GetASTNodeSourceRange() = %+v, want %+v", *got, want)
+	}
+
+	got, _ = tr.GetASTNodeSourceRange(context.Background(), GetASTNodeSourceRangeReq{RepoName: "r", NodeID: NodeID{ModPath: "m", PkgPath: "m/p", Name: "G"}})
+	want = GetASTNodeSourceRangeResp{FilePath: "p.go", StartLine: 8, EndLine: 9, StartByte: -1, EndByte: -1}
+	if *got != want {
+		t.Errorf("GetASTNodeSourceRange() without offsets = %+v, want %+v", *got, want)
+	}
+
+	got, _ = tr.GetASTNodeSourceRange(context.Background(), GetASTNodeSourceRangeReq{RepoName: "r", NodeID: NodeID{ModPath: "m", PkgPath: "m/p", Name: "H"}})
+	if got.Error == "" {
+		t.Error("got.Error must be non-empty when node not found")
+	}
+}
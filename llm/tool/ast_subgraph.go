@@ -0,0 +1,189 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+const (
+	ToolExportRepoSubgraph = "export_repo_subgraph"
+	DescExportRepoSubgraph = "export the package-level dependency graph of a repository (or one of its modules) as a GraphViz DOT string, for visualizing how packages depend on each other. Each package node is labeled with its name and node counts. Use 'max_nodes' to keep only the N most-connected packages."
+)
+
+var SchemaExportRepoSubgraph = GetJSONSchema(ExportRepoSubgraphReq{})
+
+// ExportRepoSubgraphReq is the request for export_repo_subgraph.
+type ExportRepoSubgraphReq struct {
+	RepoName string `json:"repo_name" jsonschema:"description=the name of the repository"`
+	ModPath  string `json:"mod_path,omitempty" jsonschema:"description=only export packages of this module, empty means all internal modules"`
+	MaxNodes int    `json:"max_nodes,omitempty" jsonschema:"description=keep only the N most-connected packages, 0 means no limit"`
+}
+
+// ExportRepoSubgraphResp is the response for export_repo_subgraph.
+type ExportRepoSubgraphResp struct {
+	DOT      string `json:"dot,omitempty" jsonschema:"description=the package dependency graph in GraphViz DOT format"`
+	Packages int    `json:"packages" jsonschema:"description=number of packages in the graph"`
+	Edges    int    `json:"edges" jsonschema:"description=number of package dependency edges in the graph"`
+	Pruned   int    `json:"pruned,omitempty" jsonschema:"description=number of packages pruned by max_nodes"`
+	Error    string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+type pkgGraphNode struct {
+	mod                uniast.ModPath
+	pkg                uniast.PkgPath
+	funcs, types, vars int
+	degree             int
+}
+
+type pkgGraphEdge struct {
+	from, to string
+}
+
+// BuildRepoSubgraph builds the package-level dependency graph of the repository in DOT format.
+// If modPath is not empty, only packages of that module are included.
+func BuildRepoSubgraph(repo *uniast.Repository, modPath string, maxNodes int) (*ExportRepoSubgraphResp, error) {
+	if modPath != "" {
+		if mod, ok := repo.Modules[modPath]; !ok || mod.IsExternal() {
+			return nil, fmt.Errorf("module '%s' not found", modPath)
+		}
+	}
+
+	pkgKey := func(mod uniast.ModPath, pkg uniast.PkgPath) string {
+		return mod + "?" + pkg
+	}
+	nodes := make(map[string]*pkgGraphNode)
+	for mp, mod := range repo.Modules {
+		if mod.IsExternal() || (modPath != "" && mp != modPath) {
+			continue
+		}
+		for pp, pkg := range mod.Packages {
+			nodes[pkgKey(mp, pp)] = &pkgGraphNode{
+				mod:   mp,
+				pkg:   pp,
+				funcs: len(pkg.Functions),
+				types: len(pkg.Types),
+				vars:  len(pkg.Vars),
+			}
+		}
+	}
+
+	graph, err := repoGraph(repo)
+	if err != nil {
+		return nil, err
+	}
+	// edge => number of node-level dependencies
+	edges := make(map[pkgGraphEdge]int)
+	for _, node := range graph {
+		from := pkgKey(node.Identity.ModPath, node.Identity.PkgPath)
+		if _, ok := nodes[from]; !ok {
+			continue
+		}
+		for _, dep := range node.Dependencies {
+			to := pkgKey(dep.Identity.ModPath, dep.Identity.PkgPath)
+			if to == from {
+				continue
+			}
+			if _, ok := nodes[to]; !ok {
+				continue
+			}
+			edges[pkgGraphEdge{from, to}]++
+		}
+	}
+	for e := range edges {
+		nodes[e.from].degree++
+		nodes[e.to].degree++
+	}
+
+	// prune to the most-connected packages
+	keys := make([]string, 0, len(nodes))
+	for k := range nodes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := nodes[keys[i]], nodes[keys[j]]
+		if a.degree != b.degree {
+			return a.degree > b.degree
+		}
+		return keys[i] < keys[j]
+	})
+	resp := &ExportRepoSubgraphResp{}
+	if maxNodes > 0 && len(keys) > maxNodes {
+		for _, k := range keys[maxNodes:] {
+			delete(nodes, k)
+		}
+		resp.Pruned = len(keys) - maxNodes
+		keys = keys[:maxNodes]
+	}
+	sort.Strings(keys)
+
+	var edgeList []pkgGraphEdge
+	for e := range edges {
+		if nodes[e.from] != nil && nodes[e.to] != nil {
+			edgeList = append(edgeList, e)
+		}
+	}
+	sort.Slice(edgeList, func(i, j int) bool {
+		if edgeList[i].from != edgeList[j].from {
+			return edgeList[i].from < edgeList[j].from
+		}
+		return edgeList[i].to < edgeList[j].to
+	})
+
+	// only show module path in labels when there are several modules
+	multiMod := false
+	for _, k := range keys {
+		if nodes[k].mod != nodes[keys[0]].mod {
+			multiMod = true
+			break
+		}
+	}
+
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "digraph %s {\n", dotQuote(repo.Name))
+	sb.WriteString("  rankdir=LR;\n  node [shape=box];\n")
+	for _, k := range keys {
+		n := nodes[k]
+		label := n.pkg
+		if multiMod {
+			label = n.mod + "\n" + n.pkg
+		}
+		label = fmt.Sprintf("%s\nfunc:%d type:%d var:%d", label, n.funcs, n.types, n.vars)
+		fmt.Fprintf(&sb, "  %s [label=%s];\n", dotQuote(k), dotQuote(label))
+	}
+	for _, e := range edgeList {
+		fmt.Fprintf(&sb, "  %s -> %s [weight=%d];\n", dotQuote(e.from), dotQuote(e.to), edges[e])
+	}
+	sb.WriteString("}\n")
+
+	resp.DOT = sb.String()
+	resp.Packages = len(keys)
+	resp.Edges = len(edgeList)
+	return resp, nil
+}
+
+// dotQuote quotes s as a DOT ID
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
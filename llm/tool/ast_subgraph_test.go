@@ -0,0 +1,96 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestBuildRepoSubgraph(t *testing.T) {
+	repo := uniast.NewRepository("r")
+	mod := uniast.NewModule("m", ".", uniast.Golang)
+	repo.Modules["m"] = mod
+	for _, p := range []string{"m/a", "m/b", "m/c"} {
+		mod.Packages[p] = uniast.NewPackage(p)
+	}
+	idA := uniast.NewIdentity("m", "m/a", "A")
+	idB := uniast.NewIdentity("m", "m/b", "B")
+	idC := uniast.NewIdentity("m", "m/c", "C")
+	idExt := uniast.NewIdentity("ext", "ext/x", "X")
+	// a -> b, a -> c, b -> c, a -> ext (ignored)
+	mod.Packages["m/a"].Functions["A"] = &uniast.Function{Identity: idA, FunctionCalls: []uniast.Dependency{{Identity: idB}, {Identity: idC}, {Identity: idExt}}}
+	mod.Packages["m/b"].Functions["B"] = &uniast.Function{Identity: idB, Types: []uniast.Dependency{{Identity: idC}}}
+	mod.Packages["m/c"].Types["C"] = &uniast.Type{Identity: idC}
+
+	got, err := BuildRepoSubgraph(&repo, "", 0)
+	if err != nil {
+		t.Fatalf("BuildRepoSubgraph() error = %v", err)
+	}
+	if len(repo.Graph) != 0 {
+		t.Errorf("expect the graph to be built without modifying the repo")
+	}
+	if got.Packages != 3 || got.Edges != 3 {
+		t.Errorf("packages/edges = %d/%d, want 3/3", got.Packages, got.Edges)
+	}
+	for _, want := range []string{
+		`digraph "r" {`,
+		`"m?m/a" -> "m?m/b"`,
+		`"m?m/b" -> "m?m/c"`,
+		`"m?m/c" [label="m/c\nfunc:0 type:1 var:0"]`,
+	} {
+		if !strings.Contains(got.DOT, want) {
+			t.Errorf("DOT should contain %s, got:\n%s", want, got.DOT)
+		}
+	}
+
+	// all packages have degree 2, ties are broken by name so c is pruned
+	got, err = BuildRepoSubgraph(&repo, "m", 2)
+	if err != nil {
+		t.Fatalf("BuildRepoSubgraph() error = %v", err)
+	}
+	if got.Packages != 2 || got.Pruned != 1 || got.Edges != 1 {
+		t.Errorf("packages/pruned/edges = %d/%d/%d, want 2/1/1", got.Packages, got.Pruned, got.Edges)
+	}
+
+	if _, err := BuildRepoSubgraph(&repo, "nonexistent", 0); err == nil {
+		t.Error("expect error for unknown module")
+	}
+}
+
+func TestASTTools_ExportRepoSubgraph(t *testing.T) {
+	tr := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: TestRepoASTsDir})
+
+	got, err := tr.ExportRepoSubgraph(context.Background(), ExportRepoSubgraphReq{RepoName: "localsession"})
+	if err != nil {
+		t.Fatalf("ExportRepoSubgraph() error = %v", err)
+	}
+	if got.Error != "" || got.Packages == 0 || !strings.HasPrefix(got.DOT, "digraph") {
+		t.Errorf("unexpected resp: %+v", got)
+	}
+
+	got, err = tr.ExportRepoSubgraph(context.Background(), ExportRepoSubgraphReq{RepoName: "nonexistent_repo"})
+	if err != nil {
+		t.Fatalf("ExportRepoSubgraph() error = %v", err)
+	}
+	if got.Error == "" {
+		t.Error("got.Error must be non-empty when repo not found")
+	}
+}
@@ -0,0 +1,119 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/llm/log"
+)
+
+const (
+	ToolSummarizeNode = "summarize_node"
+	DescSummarizeNode = "get a one-paragraph natural-language summary of what an AST node (function, type or var) does, along with a few keyword tags. Use it to plan before reading the full code of a node or translating/refactoring it."
+)
+
+var SchemaSummarizeNode = GetJSONSchema(SummarizeNodeReq{})
+
+// LLMChatFunc sends a single prompt to the LLM without tools and returns the answer.
+// It is provided by the caller since this package can't depend on the LLM clients.
+type LLMChatFunc func(ctx context.Context, prompt string) (string, error)
+
+// SummarizeNodeReq is the request for summarize_node.
+type SummarizeNodeReq struct {
+	RepoName string `json:"repo_name" jsonschema:"description=the name of the repository"`
+	NodeID   NodeID `json:"node_id" jsonschema:"description=the identity of the ast node"`
+}
+
+// SummarizeNodeResp is the response for summarize_node.
+type SummarizeNodeResp struct {
+	Summary string   `json:"summary,omitempty" jsonschema:"description=one paragraph describing the purpose of the node"`
+	Tags    []string `json:"tags,omitempty" jsonschema:"description=short keywords about the node, e.g. io, validation, http-handler"`
+	Error   string   `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// summaryCache is a cached summary of a node, valid as long as the node content is unchanged
+type summaryCache struct {
+	content string
+	resp    SummarizeNodeResp
+}
+
+// SummarizeNode asks the LLM to summarize a node. Summaries are cached for the session by node id.
+func (t *ASTReadTools) SummarizeNode(ctx context.Context, req SummarizeNodeReq) (*SummarizeNodeResp, error) {
+	if t.opts.LLMChat == nil {
+		return &SummarizeNodeResp{Error: "no LLM is configured for summarize_node"}, nil
+	}
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &SummarizeNodeResp{Error: err.Error()}, nil
+	}
+	id := req.NodeID.Identity()
+	node := repo.GetNode(id)
+	if node == nil {
+		return &SummarizeNodeResp{Error: fmt.Sprintf("node '%s' not found", id.Full())}, nil
+	}
+	content := node.Content()
+	if v, ok := t.summaries.Load(id.Full()); ok && v.(*summaryCache).content == content {
+		resp := v.(*summaryCache).resp
+		return &resp, nil
+	}
+
+	answer, err := t.opts.LLMChat(ctx, buildSummarizePrompt(node.Type, content))
+	if err != nil {
+		return &SummarizeNodeResp{Error: fmt.Sprintf("summarize node '%s' failed: %v", id.Full(), err)}, nil
+	}
+	resp := parseSummary(answer)
+	t.summaries.Store(id.Full(), &summaryCache{content: content, resp: resp})
+	log.Debug("summarize node %s: %v", id.Full(), resp)
+	return &resp, nil
+}
+
+func buildSummarizePrompt(typ uniast.NodeType, content string) string {
+	what := "code"
+	switch typ {
+	case uniast.FUNC:
+		what = "function"
+	case uniast.TYPE:
+		what = "type"
+	case uniast.VAR:
+		what = "variable"
+	}
+	return fmt.Sprintf("In one paragraph, describe what this %s does:\n\n```\n%s\n```\n\n"+
+		"Then add a last line `Tags: ` followed by at most 5 comma-separated lowercase keywords about it.", what, content)
+}
+
+// parseSummary splits the LLM answer into the summary paragraph and the trailing tags line
+func parseSummary(answer string) SummarizeNodeResp {
+	var resp SummarizeNodeResp
+	lines := strings.Split(strings.TrimSpace(answer), "\n")
+	if n := len(lines); n > 0 {
+		last := strings.TrimSpace(strings.Trim(lines[n-1], "*`"))
+		if len(last) > 5 && strings.EqualFold(last[:5], "tags:") {
+			for _, tag := range strings.Split(last[5:], ",") {
+				if tag = strings.TrimSpace(strings.Trim(tag, "*`")); tag != "" {
+					resp.Tags = append(resp.Tags, tag)
+				}
+			}
+			lines = lines[:n-1]
+		}
+	}
+	resp.Summary = strings.TrimSpace(strings.Join(lines, "\n"))
+	return resp
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestASTTools_SummarizeNode(t *testing.T) {
+	repo := uniast.NewRepository("r")
+	mod := uniast.NewModule("m", ".", uniast.Golang)
+	repo.Modules["m"] = mod
+	pkg := uniast.NewPackage("m/p")
+	mod.Packages["m/p"] = pkg
+	pkg.Functions["Add"] = &uniast.Function{
+		Identity: uniast.NewIdentity("m", "m/p", "Add"),
+		FileLine: uniast.FileLine{File: "p.go", Line: 1},
+		Content:  "func Add(a, b int) int { return a + b }",
+	}
+	repo.BuildGraph()
+
+	var prompts []string
+	tr := NewASTReadTools(ASTReadToolsOptions{
+		RepoASTsDir: TestRepoASTsDir,
+		LLMChat: func(_ context.Context, prompt string) (string, error) {
+			prompts = append(prompts, prompt)
+			return "Add returns the sum of two integers.\n\n**Tags: math, arithmetic**", nil
+		},
+	})
+	tr.repos.Store("r", &repo)
+	if tr.GetTool(ToolSummarizeNode) == nil {
+		t.Fatal("summarize_node should be registered when LLMChat is set")
+	}
+
+	req := SummarizeNodeReq{RepoName: "r", NodeID: NodeID{ModPath: "m", PkgPath: "m/p", Name: "Add"}}
+	for i := 0; i < 2; i++ {
+		got, err := tr.SummarizeNode(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := SummarizeNodeResp{Summary: "Add returns the sum of two integers.", Tags: []string{"math", "arithmetic"}}
+		if !reflect.DeepEqual(*got, want) {
+			t.Errorf("SummarizeNode() = %+v, want %+v", *got, want)
+		}
+	}
+	if len(prompts) != 1 {
+		t.Fatalf("LLM called %d times, want 1 thanks to the cache", len(prompts))
+	}
+	if !strings.Contains(prompts[0], "describe what this function does") || !strings.Contains(prompts[0], "return a + b") {
+		t.Errorf("unexpected prompt: %s", prompts[0])
+	}
+
+	// the cache is invalidated when the content changes
+	pkg.Functions["Add"].Content = "func Add(a, b int) int { return b + a }"
+	tr.SummarizeNode(context.Background(), req)
+	if len(prompts) != 2 {
+		t.Errorf("LLM called %d times, want 2 after the content changed", len(prompts))
+	}
+
+	if got, _ := tr.SummarizeNode(context.Background(), SummarizeNodeReq{RepoName: "r", NodeID: NodeID{ModPath: "m", PkgPath: "m/p", Name: "Sub"}}); got.Error == "" {
+		t.Error("got.Error must be non-empty when node not found")
+	}
+}
@@ -18,6 +18,9 @@ package tool
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/eino/components/tool"
@@ -230,6 +233,42 @@ func TestASTTools_GetRepoStructure(t *testing.T) {
 				if len(got.Modules) == 0 {
 					t.Error("got.Modules should be non-empty")
 				}
+				for _, m := range got.Modules {
+					for _, p := range m.Packages {
+						if strings.HasSuffix(p.PkgPath, ".test]") {
+							t.Errorf("test package %s should be omitted", p.PkgPath)
+						}
+					}
+				}
+			},
+		},
+		{
+			name: "include_tests",
+			fields: fields{
+				opts: ASTReadToolsOptions{
+					RepoASTsDir: TestRepoASTsDir,
+				},
+			},
+			args: args{
+				in0: context.Background(),
+				req: GetRepoStructReq{
+					RepoName:     "localsession",
+					IncludeTests: true,
+				},
+			},
+			wantErr: false,
+			check: func(t *testing.T, got *GetRepoStructResp) {
+				var tests int
+				for _, m := range got.Modules {
+					for _, p := range m.Packages {
+						if strings.HasSuffix(p.PkgPath, ".test]") {
+							tests++
+						}
+					}
+				}
+				if tests == 0 {
+					t.Error("test packages should be listed with IncludeTests")
+				}
 			},
 		},
 	}
@@ -692,3 +731,62 @@ func TestASTTools_GetTargetLanguageSpec(t *testing.T) {
 // 		})
 // 	}
 // }
+
+func TestASTTools_ReloadRepoFile(t *testing.T) {
+	dir := t.TempDir()
+	bs, err := os.ReadFile(filepath.Join(TestRepoASTsDir, "metainfo.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "metainfo.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tr := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir, Watch: true})
+	ctx := context.Background()
+	listRepos := func() []string {
+		resp, err := tr.ListRepos(ctx, ListReposReq{})
+		if err != nil {
+			t.Fatalf("ListRepos() error = %v", err)
+		}
+		return resp.RepoNames
+	}
+	if got := listRepos(); len(got) != 1 {
+		t.Fatalf("ListRepos() = %v, want 1 repo", got)
+	}
+
+	// a broken file is listed and reports its load error
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(broken, bs[:len(bs)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	tr.reloadRepoFile(broken)
+	if got := listRepos(); len(got) != 2 {
+		t.Fatalf("ListRepos() = %v, want 2 repos", got)
+	}
+	resp, err := tr.GetRepoStructure(ctx, GetRepoStructReq{RepoName: "broken"})
+	if err != nil {
+		t.Fatalf("GetRepoStructure() error = %v", err)
+	}
+	if !strings.Contains(resp.Error, "load AST file") {
+		t.Errorf("GetRepoStructure().Error = %q, want load error", resp.Error)
+	}
+
+	// once fixed, the sentinel is replaced by the real repo
+	if err := os.WriteFile(broken, bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tr.reloadRepoFile(broken)
+	if got := listRepos(); len(got) != 1 {
+		t.Fatalf("ListRepos() = %v, want 1 repo", got)
+	}
+
+	// the repo is still provided by metainfo.json
+	tr.removeRepoFile(broken)
+	if got := listRepos(); len(got) != 1 {
+		t.Fatalf("ListRepos() = %v, want 1 repo", got)
+	}
+	tr.removeRepoFile(filepath.Join(dir, "metainfo.json"))
+	if got := listRepos(); len(got) != 0 {
+		t.Fatalf("ListRepos() = %v, want no repo", got)
+	}
+}
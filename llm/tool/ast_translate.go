@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"unicode"
 
 	abutil "github.com/cloudwego/abcoder/internal/utils"
 	"github.com/cloudwego/abcoder/lang/translate"
@@ -53,6 +54,12 @@ var (
 
 type ASTTranslateToolsOptions struct {
 	RepoASTsDir string
+	// LLMTranslator, if set, is used by translate_node to translate the node into Go code.
+	// Otherwise translate_node only locates the node and leaves the translation to the caller.
+	LLMTranslator translate.LLMTranslateFunc
+	// CustomTypeHints and TypeHintsFile are added to the type mappings of the prompts, see translate.TranslateOptions
+	CustomTypeHints []translate.TypeHintOverride
+	TypeHintsFile   string
 }
 
 type ASTTranslateTools struct {
@@ -165,12 +172,19 @@ type TranslateNodeResp struct {
 // Supports multiple input formats:
 //   - Standard: modPath?pkgPath#name
 //   - LLM format: modPath/pkgPath/name or modPath/pkgPath/name.method
+//   - Java fully-qualified name: com.example.service.UserService.createUser, whose modPath is left empty
 func normalizeNodeID(nodeID string) string {
 	// If already in correct format, return as-is
 	if strings.Contains(nodeID, "?") && strings.Contains(nodeID, "#") {
 		return nodeID
 	}
 
+	// Example: com.example.service.UserService.createUser(Long)
+	// Should become: ?com.example.service#UserService.createUser(Long)
+	if pkgPath, name, ok := splitJavaQualifiedName(nodeID); ok {
+		return "?" + pkgPath + "#" + name
+	}
+
 	// Try to convert from / separator format to ? and # format
 	// Example: com.example.test:core-module:1.0.0-SNAPSHOT/com.example.core.service/UserService.deleteUser(Long)
 	// Should become: com.example.test:core-module:1.0.0-SNAPSHOT?com.example.core.service#UserService.deleteUser(Long)
@@ -208,6 +222,50 @@ func normalizeNodeID(nodeID string) string {
 	return nodeID
 }
 
+// splitJavaQualifiedName splits a fully-qualified Java name <package>.<Class>[.<member>] into
+// the package and the Class.member name, by the first segment starting with an upper-case letter.
+// The package must have at least two lower-case segments, e.g. com.example.
+func splitJavaQualifiedName(nodeID string) (pkgPath, name string, ok bool) {
+	if strings.ContainsAny(nodeID, "/?#: ") {
+		return "", "", false
+	}
+	// the parameter types of a method may be qualified too
+	qualified, params := nodeID, ""
+	if i := strings.Index(nodeID, "("); i >= 0 {
+		qualified, params = nodeID[:i], nodeID[i:]
+	}
+	segs := strings.Split(qualified, ".")
+	if len(segs) < 3 {
+		return "", "", false
+	}
+	for i, seg := range segs {
+		if seg == "" {
+			return "", "", false
+		}
+		if unicode.IsUpper(rune(seg[0])) {
+			if i < 2 {
+				return "", "", false
+			}
+			return strings.Join(segs[:i], "."), strings.Join(segs[i:], ".") + params, true
+		}
+	}
+	return "", "", false
+}
+
+// resolveModPath fills the empty ModPath of id with the module holding its package
+func resolveModPath(repo *uniast.Repository, id uniast.Identity) uniast.Identity {
+	if id.ModPath != "" {
+		return id
+	}
+	for name, mod := range repo.Modules {
+		if mod.Packages[id.PkgPath] != nil {
+			id.ModPath = name
+			break
+		}
+	}
+	return id
+}
+
 func (a *ASTTranslateTools) TranslateNode(ctx context.Context, req TranslateNodeReq) (*TranslateNodeResp, error) {
 	// Get repo - try to find by name, or use the first available repo if name doesn't match
 	var repo *uniast.Repository
@@ -237,13 +295,12 @@ func (a *ASTTranslateTools) TranslateNode(ctx context.Context, req TranslateNode
 	// Normalize node ID to handle various formats from LLM
 	normalizedID := normalizeNodeID(req.NodeID)
 	if normalizedID != req.NodeID {
-		log.Debug("Normalized node ID: %s -> %s", req.NodeID, normalizedID)
+		log.Debug("Normalized node ID: %s -> %s", req.NodeID, uniast.NewIdentityFromString(normalizedID).ShortID())
 	}
 
 	// Parse node ID using the correct format: {ModPath}?{PkgPath}#{Name}
-	id := uniast.NewIdentityFromString(normalizedID)
-	log.Debug("Looking for node: %s (parsed: ModPath=%s, PkgPath=%s, Name=%s, Full=%s)",
-		normalizedID, id.ModPath, id.PkgPath, id.Name, id.Full())
+	id := resolveModPath(repo, uniast.NewIdentityFromString(normalizedID))
+	log.Debug("Looking for node: %s", id.ShortID())
 	log.Debug("Graph size: %d", len(repo.Graph))
 
 	// This is a helper tool - actual translation will be done by LLM
@@ -262,6 +319,17 @@ func (a *ASTTranslateTools) TranslateNode(ctx context.Context, req TranslateNode
 		return nil, fmt.Errorf("node not found: %s", req.NodeID)
 	}
 
+	if a.opts.LLMTranslator != nil {
+		code, err := a.translateNode(ctx, repo, node)
+		if err != nil {
+			log.Error("Translate node %s failed: %v", req.NodeID, err)
+			return nil, fmt.Errorf("translate node %s failed: %v", req.NodeID, err)
+		}
+		return &TranslateNodeResp{
+			GoCode: code,
+		}, nil
+	}
+
 	// Return Java code for LLM to translate
 	// The actual translation will be done by the LLM based on the prompt
 	return &TranslateNodeResp{
@@ -270,6 +338,69 @@ func (a *ASTTranslateTools) TranslateNode(ctx context.Context, req TranslateNode
 	}, nil
 }
 
+// translateNode translates a single node of repo to Go code with opts.LLMTranslator
+func (a *ASTTranslateTools) translateNode(ctx context.Context, repo *uniast.Repository, node *uniast.Node) (string, error) {
+	srcMod := repo.GetModule(node.Identity.ModPath)
+	if srcMod == nil {
+		return "", fmt.Errorf("module not found: %s", node.Identity.ModPath)
+	}
+	opts := translate.TranslateOptions{
+		SourceLanguage:  srcMod.Language,
+		TargetLanguage:  uniast.Golang,
+		LLMTranslator:   a.opts.LLMTranslator,
+		CustomTypeHints: a.opts.CustomTypeHints,
+		TypeHintsFile:   a.opts.TypeHintsFile,
+	}
+	typeHints, err := translate.NewTypeHintsFromOptions(opts)
+	if err != nil {
+		return "", err
+	}
+	translator := translate.NewNodeTranslator(opts, typeHints)
+
+	// the node is translated alone, so the target repo only holds its package
+	dst := uniast.NewRepository(repo.Name)
+	dstMod := uniast.NewModule(srcMod.Name, "", uniast.Golang)
+	dstPkg := uniast.NewPackage(node.Identity.PkgPath)
+	dstMod.Packages[dstPkg.PkgPath] = dstPkg
+	dst.Modules[dstMod.Name] = dstMod
+	tctx := translate.NewTranslateContext(repo, &dst, dstMod, dstPkg)
+
+	switch node.Type {
+	case uniast.TYPE:
+		src := repo.GetType(node.Identity)
+		if src == nil {
+			return "", fmt.Errorf("type not found: %s", node.Identity.Full())
+		}
+		ret, err := translator.TranslateType(ctx, src, tctx)
+		if err != nil {
+			return "", err
+		}
+		return ret.Content, nil
+	case uniast.FUNC:
+		src := repo.GetFunction(node.Identity)
+		if src == nil {
+			return "", fmt.Errorf("function not found: %s", node.Identity.Full())
+		}
+		ret, err := translator.TranslateFunction(ctx, src, tctx)
+		if err != nil {
+			return "", err
+		}
+		return ret.Content, nil
+	case uniast.VAR:
+		src := repo.GetVar(node.Identity)
+		if src == nil {
+			return "", fmt.Errorf("var not found: %s", node.Identity.Full())
+		}
+		ret, err := translator.TranslateVar(ctx, src, tctx)
+		if err != nil {
+			return "", err
+		}
+		return ret.Content, nil
+	default:
+		return "", fmt.Errorf("unsupported node type: %s", node.Type)
+	}
+}
+
 type TranslateFileReq struct {
 	RepoName string `json:"repo_name"`
 	FilePath string `json:"file_path"`
@@ -328,7 +459,7 @@ func (a *ASTTranslateTools) TranslateRepo(ctx context.Context, req TranslateRepo
 	for _, mod := range javaRepo.Modules {
 		if !mod.IsExternal() {
 			moduleCount++
-			packageCount += len(mod.Packages)
+			packageCount += mod.PackageCount()
 		}
 	}
 
@@ -0,0 +1,146 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/translate"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestASTTranslateTools_TranslateNode(t *testing.T) {
+	repo := uniast.NewRepository("r")
+	mod := uniast.NewModule("m", ".", uniast.Java)
+	repo.Modules["m"] = mod
+	pkg := uniast.NewPackage("com.example")
+	mod.Packages[pkg.PkgPath] = pkg
+	idF := uniast.NewIdentity("m", "com.example", "Util.add")
+	idT := uniast.NewIdentity("m", "com.example", "Util")
+	pkg.Functions["Util.add"] = &uniast.Function{Identity: idF, IsMethod: true, Content: "int add(int a, int b) { return a + b; }"}
+	pkg.Types["Util"] = &uniast.Type{Identity: idT, Exported: true, Content: "public class Util {}"}
+
+	var gotReq *translate.LLMTranslateRequest
+	tr := NewASTTranslateTools(ASTTranslateToolsOptions{
+		RepoASTsDir: t.TempDir(),
+		LLMTranslator: func(ctx context.Context, req *translate.LLMTranslateRequest) (*translate.LLMTranslateResponse, error) {
+			gotReq = req
+			if req.NodeType == uniast.TYPE {
+				return &translate.LLMTranslateResponse{TargetContent: "type Util struct{}"}, nil
+			}
+			return &translate.LLMTranslateResponse{TargetContent: "func add(a, b int) int { return a + b }"}, nil
+		},
+	})
+	tr.repos.Store(repo.Name, &repo)
+	ctx := context.Background()
+
+	resp, err := tr.TranslateNode(ctx, TranslateNodeReq{RepoName: "r", NodeID: idF.Full()})
+	if err != nil {
+		t.Fatalf("TranslateNode() error = %v", err)
+	}
+	if resp.GoCode != "func add(a, b int) int { return a + b }" {
+		t.Errorf("GoCode = %q", resp.GoCode)
+	}
+	if gotReq == nil || gotReq.NodeType != uniast.FUNC || gotReq.SourceLanguage != uniast.Java || gotReq.TargetLanguage != uniast.Golang {
+		t.Fatalf("unexpected LLM request: %+v", gotReq)
+	}
+	if !strings.Contains(gotReq.Prompt, gotReq.SourceContent) {
+		t.Errorf("prompt should contain the source code, got:\n%s", gotReq.Prompt)
+	}
+
+	resp, err = tr.TranslateNode(ctx, TranslateNodeReq{RepoName: "r", NodeID: idT.Full()})
+	if err != nil {
+		t.Fatalf("TranslateNode() error = %v", err)
+	}
+	if resp.GoCode != "type Util struct{}" || gotReq.NodeType != uniast.TYPE {
+		t.Errorf("GoCode = %q, node type = %s", resp.GoCode, gotReq.NodeType)
+	}
+
+	// the fully-qualified Java name hallucinated by LLM is resolved to the module of its package
+	resp, err = tr.TranslateNode(ctx, TranslateNodeReq{RepoName: "r", NodeID: "com.example.Util.add"})
+	if err != nil {
+		t.Fatalf("TranslateNode() with a fully-qualified name error = %v", err)
+	}
+	if resp.GoCode != "func add(a, b int) int { return a + b }" || gotReq.Identity != idF {
+		t.Errorf("GoCode = %q, identity = %v", resp.GoCode, gotReq.Identity)
+	}
+
+	// the type hints of the options are shown in the prompt
+	hintsFile := filepath.Join(t.TempDir(), "hints.yaml")
+	if err := os.WriteFile(hintsFile, []byte("java->go:\n  com.example.Money: decimal.Decimal\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tr.opts.TypeHintsFile = hintsFile
+	tr.opts.CustomTypeHints = []translate.TypeHintOverride{{Source: "ImmutableList<T>", Target: "[]T"}}
+	if _, err := tr.TranslateNode(ctx, TranslateNodeReq{RepoName: "r", NodeID: idF.Full()}); err != nil {
+		t.Fatalf("TranslateNode() with type hints error = %v", err)
+	}
+	for _, hint := range []string{"com.example.Money", "decimal.Decimal", "ImmutableList<T>"} {
+		if !strings.Contains(gotReq.Prompt, hint) {
+			t.Errorf("prompt should contain the type hint %s, got:\n%s", hint, gotReq.Prompt)
+		}
+	}
+	tr.opts.TypeHintsFile = filepath.Join(t.TempDir(), "missing.yaml")
+	if _, err := tr.TranslateNode(ctx, TranslateNodeReq{RepoName: "r", NodeID: idF.Full()}); err == nil {
+		t.Error("TranslateNode() with a missing type hints file should fail")
+	}
+	tr.opts.TypeHintsFile = ""
+
+	// without LLMTranslator, the node is only located
+	tr.opts.LLMTranslator = nil
+	resp, err = tr.TranslateNode(ctx, TranslateNodeReq{RepoName: "r", NodeID: idF.Full()})
+	if err != nil {
+		t.Fatalf("TranslateNode() error = %v", err)
+	}
+	if resp.GoCode != "" || resp.Note == "" {
+		t.Errorf("TranslateNode() without LLMTranslator = %+v, want a note only", resp)
+	}
+}
+
+func Test_normalizeNodeID(t *testing.T) {
+	tests := []struct {
+		nodeID string
+		want   string
+	}{
+		// standard format
+		{"m?com.example#Util.add", "m?com.example#Util.add"},
+		// slash-separated
+		{"m/com.example/Util.add", "m?com.example#Util.add"},
+		{"com.example.test:core:1.0.0/com.example.core.service/UserService.deleteUser(Long)", "com.example.test:core:1.0.0?com.example.core.service#UserService.deleteUser(Long)"},
+		{"m/com.example.Util", "m?com.example#Util"},
+		{"m/Util", "m?#Util"},
+		// fully-qualified Java names
+		{"com.example.service.UserService.createUser", "?com.example.service#UserService.createUser"},
+		{"com.example.service.UserService", "?com.example.service#UserService"},
+		{"com.example.service.UserService.deleteUser(java.lang.Long)", "?com.example.service#UserService.deleteUser(java.lang.Long)"},
+		{"com.example.service.UserService.Status.ACTIVE", "?com.example.service#UserService.Status.ACTIVE"},
+		// not recognized
+		{"createUser", "createUser"},
+		{"UserService.createUser", "UserService.createUser"},
+		{"com.UserService.createUser", "com.UserService.createUser"},
+		{"com.example.service", "com.example.service"},
+	}
+	for _, tt := range tests {
+		if got := normalizeNodeID(tt.nodeID); got != tt.want {
+			t.Errorf("normalizeNodeID(%q) = %q, want %q", tt.nodeID, got, tt.want)
+		}
+	}
+}
@@ -97,7 +97,7 @@ func (t ASTWriteTools) WriteASTNode(_ context.Context, req WriteASTNodeReq) (*Wr
 	var typ uniast.NodeType
 	if node == nil {
 		file = req.File
-		typ = uniast.NewNodeType(req.Type)
+		typ = uniast.NewNodeType(req.Type).Base()
 	} else {
 		file = node.FileLine().File
 		typ = node.Type
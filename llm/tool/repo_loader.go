@@ -36,7 +36,7 @@ func LoadReposIntoMap(dir string, m *sync.Map, onLoadError func(file string, err
 	}
 	for _, f := range files {
 		repo, err := uniast.LoadRepo(f)
-		if err != nil {
+		if err != nil && !uniast.IsVersionMismatch(err) {
 			if onLoadError != nil {
 				onLoadError(f, err)
 				continue
@@ -0,0 +1,83 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudwego/abcoder/llm/log"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+const (
+	ToolReportFinding = "report_finding"
+	DescReportFinding = "record a finding of the analysis (a bug, risk, smell or notable fact) into the session report. Call it once for each finding, with the node it is about when there is one."
+)
+
+// Severities of a Finding
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityError    = "error"
+	SeverityCritical = "critical"
+)
+
+var SchemaReportFinding = GetJSONSchema(ReportFindingReq{})
+
+// Finding is an issue or fact reported by the agent
+type Finding struct {
+	NodeID   string `json:"node_id,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// ReportFindingReq is the request for report_finding.
+type ReportFindingReq struct {
+	NodeID   string `json:"node_id,omitempty" jsonschema:"description=the full identity of the ast node the finding is about (ModPath?PkgPath#Name), empty if not about a node"`
+	Severity string `json:"severity" jsonschema:"description=the severity of the finding,enum=info,enum=warning,enum=error,enum=critical"`
+	Message  string `json:"message" jsonschema:"description=the description of the finding"`
+}
+
+// ReportFindingResp is the response for report_finding.
+type ReportFindingResp struct {
+	Recorded bool   `json:"recorded"`
+	Error    string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// NewReportFindingTool creates the report_finding tool, which passes each finding to record
+func NewReportFindingTool(record func(Finding)) Tool {
+	tt, err := utils.InferTool(ToolReportFinding, DescReportFinding,
+		func(_ context.Context, req ReportFindingReq) (*ReportFindingResp, error) {
+			if strings.TrimSpace(req.Message) == "" {
+				return &ReportFindingResp{Error: "message is required"}, nil
+			}
+			severity := strings.ToLower(strings.TrimSpace(req.Severity))
+			switch severity {
+			case SeverityInfo, SeverityWarning, SeverityError, SeverityCritical:
+			default:
+				severity = SeverityInfo
+			}
+			log.Debug("report finding: [%s] %s %s", severity, req.NodeID, req.Message)
+			record(Finding{NodeID: req.NodeID, Severity: severity, Message: req.Message})
+			return &ReportFindingResp{Recorded: true}, nil
+		})
+	if err != nil {
+		panic(err)
+	}
+	return tt
+}
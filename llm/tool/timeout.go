@@ -0,0 +1,80 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/abcoder/llm/log"
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// TimeoutResp is the response of a tool call which timed out
+type TimeoutResp struct {
+	Error string `json:"error"`
+}
+
+// WithTimeout wraps the invokable tool t so that each call is canceled after timeout.
+// A call which times out returns a TimeoutResp instead of an error, so that the agent can go on without its result,
+// even if t ignores the canceled context (its call is then left running in background).
+// t is returned as is if it is not invokable or if timeout <= 0.
+func WithTimeout(t Tool, timeout time.Duration) Tool {
+	it, ok := t.(tool.InvokableTool)
+	if !ok || timeout <= 0 {
+		return t
+	}
+	return &timeoutTool{InvokableTool: it, timeout: timeout}
+}
+
+type timeoutTool struct {
+	tool.InvokableTool
+	timeout time.Duration
+}
+
+func (t *timeoutTool) InvokableRun(parent context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	ctx, cancel := context.WithTimeout(parent, t.timeout)
+	defer cancel()
+
+	type result struct {
+		out string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		out, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+		done <- result{out, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.out, res.err
+	case <-ctx.Done():
+		// the whole agent call is canceled or timed out, not just this tool call
+		if err := parent.Err(); err != nil {
+			return "", err
+		}
+		msg := fmt.Sprintf("tool call timed out after %s", t.timeout)
+		if info, err := t.Info(parent); err == nil {
+			log.Error("%s: %s", info.Name, msg)
+		}
+		out, err := json.Marshal(TimeoutResp{Error: msg})
+		return string(out), err
+	}
+}
@@ -0,0 +1,74 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+func TestWithTimeout(t *testing.T) {
+	type req struct {
+		Sleep int `json:"sleep"`
+	}
+	block := make(chan struct{})
+	defer close(block)
+	slow, err := utils.InferTool("slow", "sleeps for the given milliseconds, forever if negative",
+		func(_ context.Context, r req) (string, error) {
+			if r.Sleep < 0 {
+				<-block // ignores the context, like a hanging LSP
+			}
+			time.Sleep(time.Duration(r.Sleep) * time.Millisecond)
+			return "done", nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if WithTimeout(slow, 0) != Tool(slow) {
+		t.Error("WithTimeout(0) should not wrap the tool")
+	}
+	wrapped := WithTimeout(slow, 50*time.Millisecond).(tool.InvokableTool)
+	info, err := wrapped.Info(context.Background())
+	if err != nil || info.Name != "slow" {
+		t.Fatalf("Info() = %v, %v", info, err)
+	}
+
+	out, err := wrapped.InvokableRun(context.Background(), `{"sleep":1}`)
+	if err != nil || !strings.Contains(out, "done") {
+		t.Errorf("fast call = %q, %v", out, err)
+	}
+	out, err = wrapped.InvokableRun(context.Background(), `{"sleep":-1}`)
+	if err != nil {
+		t.Fatalf("hanging call error = %v", err)
+	}
+	if want := `{"error":"tool call timed out after 50ms"}`; out != want {
+		t.Errorf("hanging call = %q, want %q", out, want)
+	}
+
+	// the cancellation of the caller is still an error
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := wrapped.InvokableRun(ctx, `{"sleep":-1}`); err == nil {
+		t.Error("canceled call should return an error")
+	}
+}
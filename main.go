@@ -43,6 +43,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/cloudwego/abcoder/internal/pipeline"
 	"github.com/cloudwego/abcoder/lang"
@@ -64,14 +65,15 @@ Action:
    parse        parse the specific repo and write its UniAST (to stdout by default)
    write        write the specific UniAST back to codes
    translate    translate code from one language to another (e.g., java to go)
+   split        split the UniAST of a multi-module repo into one UniAST per internal module
    mcp          run as a MCP server for all repo ASTs (*.json) in the specific directory
    agent        run as an Agent for all repo ASTs (*.json) in the specific directory. WIP: only support code-analyzing at present.
    skills       manage skills (list, install, etc.)
    version      print the version of abcoder
 Language:
-   go           for golang codes
+   go           for golang codes, test files are parsed by default (--load-test-files), skip them with --no-need-test
    rust         for rust codes
-   cxx          for c codes (cpp support is on the way)
+   cxx          for c/c++ codes
    python       for python codes
    ts           for typescript codes
    js           for javascript codes
@@ -85,35 +87,108 @@ func main() {
 	flagVerbose := flags.Bool("verbose", false, "Verbose mode.")
 	flagOutput := flags.String("o", "", "Output path.")
 	flagLsp := flags.String("lsp", "", "Specify the language server path.")
-	javaHome := flags.String("java-home", "", "java home")
+	javaHome := flags.String("java-home", "", "java home directory or java executable for jdtls, auto-detected from JAVA_HOME, java -XshowSettings or common install paths if empty")
+	flagStats := flags.Bool("stats", false, "print parse statistics to stderr (only works for parse)")
+	flagWatch := flags.Bool("watch", false, "log to stderr when a repo AST file is reloaded (only works for mcp)")
+	flagOutputDir := flags.String("output-dir", ".", "directory to write the UniAST of each module to (only works for split)")
+	flagOutputASTVersion := flags.Bool("output-ast-version", false, "print the UniAST schema version and exit (only works for parse)")
+	flagOutputAppend := flags.String("output-append", "", "merge the parsed repo into this UniAST file, created if missing, conflicting module names are prefixed with the repo path (only works for parse)")
 
 	var opts lang.ParseOptions
+	flags.BoolVar(&opts.NoGraph, "no-graph", false, "skip building the dependency graph for faster parsing, the graph of the UniAST is left empty (only works for parse)")
 	flags.BoolVar(&opts.LoadExternalSymbol, "load-external-symbol", false, "load external symbols into results")
 	flags.BoolVar(&opts.NoNeedComment, "no-need-comment", false, "not need comment (only works for Go now)")
-	flags.BoolVar(&opts.NotNeedTest, "no-need-test", false, "not need parse test files (only works for Go now)")
-	flags.BoolVar(&opts.LoadByPackages, "load-by-packages", false, "load by packages (only works for Go now)")
+	flags.BoolVar(&opts.NotNeedTest, "no-need-test", false, "not need parse test files, kept for compatibility, see --load-test-files (only works for Go now)")
+	loadTestFiles := flags.Bool("load-test-files", false, "parse the test files too, which is the default, overriding --no-need-test (only works for Go now)")
+	flags.Var((*StringArray)(&opts.TestFileSuffix), "test-file-suffix", "suffix of the test files, default to _test.go, e.g. _integration_test.go to only parse the integration tests, support multiple values (only works for Go now)")
+	flags.StringVar(&opts.ModulePath, "module-path", "", "path of the module at the root of the repo, overriding the one of go.mod, required to parse code without go.mod (only works for Go now)")
+	flags.BoolVar(&opts.ResolveGenerics, "resolve-generics", false, "add a type for each concrete instantiation of a generic type, e.g. Result_User for Result[User], which may enlarge the AST a lot (only works for Go now)")
+	flags.BoolVar(&opts.LoadByPackages, "load-by-packages", false, "load by packages, --exclude then also skips the packages whose import path or relative dir matches it, with their sub packages (only works for Go now)")
+	flags.IntVar(&opts.FileConcurrency, "concurrency", 0, "max number of files parsed in parallel, 0 means GOMAXPROCS (only works for LSP-based languages now, rejected for Go, see --package-concurrency)")
+	flags.IntVar(&opts.PackageConcurrency, "package-concurrency", 0, "max number of packages loaded in parallel, 0 means 1 (only works for Go with --load-by-packages now)")
+	flags.BoolVar(&opts.PreserveDirectives, "preserve-directives", false, "keep //go:generate, //nolint, //go:embed and // Code generated comments out of nodes, and write them back (only works for Go now)")
 	flags.Var((*StringArray)(&opts.Excludes), "exclude", "exclude files or directories, support multiple values")
-	flags.StringVar(&opts.RepoID, "repo-id", "", "specify the repo id")
+	flags.Var((*StringArray)(&opts.Includes), "include", "only include files or directories, support multiple values (excludes are applied on top)")
+	flags.DurationVar(&opts.LSPTimeout, "lsp-timeout", lang.DefaultLSPTimeout, "max time to wait for the language server to initialize, 0 means no limit (only works for LSP-based languages)")
+	flags.StringVar(&opts.RepoID, "repo-id", "", "specify the repo id, also names the MCP server abcoder-<repo-id> (works for parse and mcp)")
 	flags.StringVar(&opts.TSConfig, "tsconfig", "", "tsconfig path (only works for TS now)")
 	flags.Var((*StringArray)(&opts.TSSrcDir), "ts-src-dir", "src-dir path (only works for TS now)")
 
 	var wopts lang.WriteOptions
 	flags.StringVar(&wopts.Compiler, "compiler", "", "destination compiler path.")
+	flags.StringVar(&wopts.CxxBuildSystem, "cxx-build-system", "", "build files generated for C++: cmake, meson or both, default cmake (works for write and translate to C++)")
+	flags.BoolVar(&wopts.GenerateTestStubs, "test-stubs", false, "write a <file>_test.go with a skipped test for each exported function (works for write and translate to Go)")
 
 	var aopts agent.AgentOptions
 	flags.IntVar(&aopts.MaxSteps, "agent-max-steps", 50, "specify the max steps that the agent can run for each time")
 	flags.IntVar(&aopts.MaxHistories, "agent-max-histories", 10, "specify the max histories that the agent can use")
+	flags.IntVar(&aopts.MaxParallelSubAgents, "agent-max-parallel", 1, "specify the max number of independent skill agents that the coordinator runs in parallel, 1 means sequential")
+	flags.Float64Var(&aopts.MaxCost, "max-cost", 0, "stop the agent with exit code 2 when the estimated LLM spend exceeds this budget in dollars, 0 means no budget")
+	flags.StringVar(&aopts.OutputReport, "output-report", "", "write the agent session (steps, findings and summary) as JSON to this file when the session ends")
+	flags.DurationVar(&aopts.ToolTimeout, "tool-timeout", agent.DefaultToolTimeout, "max duration of each tool call of the agent, the timed out call returns an error to the agent, negative means no timeout")
+	flags.Float64Var(&aopts.TokenPrice, "token-price", 0, "price in dollars per 1k tokens used to estimate the LLM spend, 0 means the default of the model family")
 
 	var skillName string
 	flags.StringVar(&skillName, "skill", "", "specify skill name to use (empty for auto-match)")
 
 	// Translation post-processing options
 	var webFramework string
-	flags.StringVar(&webFramework, "framework", "", "web framework for translation: gin, echo, actix, fastapi, flask, none (default: auto)")
+	flags.StringVar(&webFramework, "framework", "", "web framework for translation: gin, echo, hertz, actix, fastapi, flask, none (default: auto)")
 	var noEntryPoint bool
 	flags.BoolVar(&noEntryPoint, "no-entry", false, "skip entry point generation")
 	var noConfig bool
 	flags.BoolVar(&noConfig, "no-config", false, "skip project config generation (go.mod, Cargo.toml, etc.)")
+	var splitOutput bool
+	flags.BoolVar(&splitOutput, "split-output", false, "write each translated package into its own subdirectory mirroring the package path (only works for translate)")
+	var outputJSON bool
+	flags.BoolVar(&outputJSON, "output-json", false, "print the translated UniAST as JSON to stdout instead of writing the code, -o then only sets where the pipeline report is written (only works for translate)")
+	var sourceUniAST string
+	flags.StringVar(&sourceUniAST, "source-uniast", "", "translate the UniAST in this file instead of parsing the source repo, the path argument can then be omitted (only works for translate)")
+	var keepTemp bool
+	flags.BoolVar(&keepTemp, "keep-temp", false, "keep the source and target UniASTs in a temp dir of the run for debugging, and print its path at the end (only works for translate)")
+	var targetModule string
+	flags.StringVar(&targetModule, "target-module", "", "module name of the translated code, e.g. the module path in go.mod, derived from the source repo if empty (only works for translate)")
+	var verbosePrompt string
+	flags.StringVar(&verbosePrompt, "verbose-prompt", "", "write the prompt and response of each LLM call into this dir as <hash>-prompt.txt and <hash>-response.txt, indexed by index.json (only works for translate)")
+	var annotateSource bool
+	flags.BoolVar(&annotateSource, "annotate-source", false, "put the first lines of the original source as 'Original <lang>: <line>' comments above each translated node (only works for translate)")
+	var noAnnotate bool
+	flags.BoolVar(&noAnnotate, "no-annotate", false, "strip the 'Original <lang>: <line>' comments of --annotate-source from the nodes before writing them, e.g. of a resumed translation or a saved target UniAST (works for write and translate)")
+	var modelConfigPath, modelProfile string
+	flags.StringVar(&modelConfigPath, "model-config", "", "JSON file of LLM credentials overriding env vars, fallback to env ABCODER_MODEL_CONFIG (only works for translate)")
+	flags.StringVar(&modelProfile, "model-profile", "", "profile name in the model config file (only works for translate)")
+	var skipLargeNodes int
+	flags.IntVar(&skipLargeNodes, "skip-large-nodes", 0, "skip translating nodes whose source exceeds this many chars, 0 means no skip (only works for translate)")
+	var nodeFilterRegex string
+	flags.StringVar(&nodeFilterRegex, "node-filter-regex", "", "only translate nodes whose name matches this regexp, others are kept as commented-out stubs (only works for translate)")
+	var validateBuild bool
+	flags.BoolVar(&validateBuild, "validate-build", false, "build the translated code and re-translate the nodes causing build errors with the errors in the prompt (only works for translate to Go, Rust and Java)")
+	var buildRetry int
+	flags.IntVar(&buildRetry, "build-retry", translate.DefaultBuildRetry, "max number of re-translations when the build fails (only works for translate with --validate-build)")
+	var generateTests bool
+	flags.BoolVar(&generateTests, "test", false, "generate a table-driven test with the LLM for each translated function of cyclomatic complexity above 3, write them to <pkg>_translate_test.go and run go test, the pass/fail counts go into the pipeline report (only works for translate to Go)")
+	var reportFormat string
+	flags.StringVar(&reportFormat, "report-format", pipeline.ReportFormatJSON, "format of the pipeline report written to the output dir: json (abcoder-pipeline-report.json), text (a table in abcoder-pipeline-report.txt) or junit (JUnit XML in abcoder-pipeline-report.xml, a testcase per step) (only works for translate)")
+	var compareDir string
+	flags.StringVar(&compareDir, "compare", "", "baseline dir of a previous translation run, the unified diff of the written files against it goes to abcoder-translation-diff.patch in the output dir (only works for translate)")
+	var compareReport bool
+	flags.BoolVar(&compareReport, "compare-report", false, "with --compare, print the number of added, removed and changed files and lines and write them to abcoder-translation-diff-report.json (only works for translate)")
+	var contextLength int
+	flags.IntVar(&contextLength, "context-length", 0, "limit each prompt to about this many tokens (4 chars per token) by cutting the dependency and context sections first, then the type mapping, and the source only as a last resort, 0 means no limit (only works for translate)")
+	var minQualityScore float64
+	flags.Float64Var(&minQualityScore, "min-quality-score", 0, "retry translations whose heuristic quality score (0-100) is below this, 0 means no check (only works for translate)")
+	var maxRetryPerNode int
+	flags.IntVar(&maxRetryPerNode, "max-retry-per-node", 0, "max attempts to translate each node, 0 means 3 with --min-quality-score and 1 otherwise (only works for translate)")
+	var batchSize int
+	flags.IntVar(&batchSize, "batch-size", 20, "translate up to N small functions or vars of the same package in a single LLM call; 0 or 1 means one node per call, -1 translates each whole package in a single call (only works for translate)")
+	var maxPkgConcurrency int
+	flags.IntVar(&maxPkgConcurrency, "max-pkg-concurrency", 1, "max number of packages translated in parallel (1-16), the total LLM calls in flight is bounded by it times the node concurrency; overrides env TRANSLATE_PACKAGE_CONCURRENCY (only works for translate)")
+	var typeHints []string
+	flags.Var((*StringArray)(&typeHints), "type-hint", "add a type mapping shown to the LLM as Source=Target, e.g. ImmutableList<T>=[]T, overriding the built-in one, support multiple values (only works for translate)")
+	var typeHintsFile string
+	flags.StringVar(&typeHintsFile, "type-hints-file", "", "YAML or JSON file of type mappings by language pair, e.g. 'java->go: {com.example.Money: decimal.Decimal}', --type-hint overrides it (only works for translate)")
+	var packageSplitThreshold int
+	flags.IntVar(&packageSplitThreshold, "package-split-threshold", 0, "split a source package with more types than this into Go sub-packages grouped by type name prefix, e.g. service/user, 0 means no split (only works for translate to Go)")
 
 	flags.Usage = func() {
 		fmt.Fprint(os.Stderr, Usage)
@@ -132,6 +207,14 @@ func main() {
 		fmt.Fprintf(os.Stdout, "%s\n", version.Version)
 
 	case "parse":
+		// flags may come without Language and Path, e.g. abcoder parse --output-ast-version
+		if len(os.Args) > 2 && strings.HasPrefix(os.Args[2], "-") {
+			flags.Parse(os.Args[2:])
+		}
+		if *flagOutputASTVersion {
+			fmt.Fprintf(os.Stdout, "%s\n", uniast.Version)
+			return
+		}
 		language, uri := parseArgsAndFlags(flags, true, flagHelp, flagVerbose)
 
 		if flagVerbose != nil && *flagVerbose {
@@ -140,7 +223,14 @@ func main() {
 		}
 
 		opts.Language = language
+		if *loadTestFiles {
+			opts.NotNeedTest = false
+		}
 
+		if language == uniast.TypeScript && *flagOutputAppend != "" {
+			log.Error("--output-append is not supported for TypeScript yet\n")
+			os.Exit(1)
+		}
 		if language == uniast.TypeScript {
 			if err := parseTSProject(context.Background(), uri, opts, flagOutput); err != nil {
 				log.Error("Failed to parse: %v\n", err)
@@ -158,14 +248,26 @@ func main() {
 			lspOptions["java.home"] = *javaHome
 		}
 		opts.LspOptions = lspOptions
+		if flagStats != nil && *flagStats {
+			opts.Stats = &lang.ParseStats{}
+		}
 
 		out, err := lang.Parse(context.Background(), uri, opts)
 		if err != nil {
 			log.Error("Failed to parse: %v\n", err)
 			os.Exit(1)
 		}
+		if opts.Stats != nil {
+			fmt.Fprint(os.Stderr, opts.Stats)
+		}
 
-		if flagOutput != nil && *flagOutput != "" {
+		if *flagOutputAppend != "" {
+			if err := appendRepo(out, *flagOutputAppend); err != nil {
+				log.Error("Failed to append output: %v\n", err)
+				os.Exit(1)
+			}
+			log.Info("Parsed repo merged into %s\n", *flagOutputAppend)
+		} else if flagOutput != nil && *flagOutput != "" {
 			if err := utils.MustWriteFile(*flagOutput, out); err != nil {
 				log.Error("Failed to write output: %v\n", err)
 			}
@@ -181,11 +283,16 @@ func main() {
 		}
 
 		repo, err := uniast.LoadRepo(uri)
-		if err != nil {
+		if uniast.IsVersionMismatch(err) {
+			log.Info("%v, consider re-parsing the repo\n", err)
+		} else if err != nil {
 			log.Error("Failed to load repo: %v\n", err)
 			os.Exit(1)
 		}
 
+		if noAnnotate {
+			translate.StripRepoSourceAnnotations(repo)
+		}
 		if flagOutput != nil && *flagOutput != "" {
 			wopts.OutputDir = *flagOutput
 		} else {
@@ -197,6 +304,18 @@ func main() {
 			os.Exit(1)
 		}
 
+	case "split":
+		_, uri := parseArgsAndFlags(flags, false, flagHelp, flagVerbose)
+		if uri == "" {
+			log.Error("Argument Path is required\n")
+			os.Exit(1)
+		}
+
+		if err := splitRepo(uri, *flagOutputDir); err != nil {
+			log.Error("Failed to split: %v\n", err)
+			os.Exit(1)
+		}
+
 	case "mcp":
 		_, uri := parseArgsAndFlags(flags, false, flagHelp, flagVerbose)
 		if uri == "" {
@@ -204,13 +323,28 @@ func main() {
 			os.Exit(1)
 		}
 
+		topts := tool.ASTReadToolsOptions{
+			RepoASTsDir: uri,
+			Watch:       *flagWatch,
+		}
+		// summarize_node is only served when an LLM is configured
+		if apiType := llm.NewModelType(os.Getenv("API_TYPE")); apiType != llm.ModelTypeUnknown {
+			modelConfig := llm.ModelConfig{
+				APIType:   apiType,
+				APIKey:    os.Getenv("API_KEY"),
+				ModelName: os.Getenv("MODEL_NAME"),
+				BaseURL:   os.Getenv("BASE_URL"),
+			}
+			topts.LLMChat = func(ctx context.Context, prompt string) (string, error) {
+				return callLLMWithoutTools(ctx, modelConfig, prompt)
+			}
+		}
+
 		svr := mcp.NewServer(mcp.ServerOptions{
-			ServerName:    "abcoder",
-			ServerVersion: version.Version,
-			Verbose:       *flagVerbose,
-			ASTReadToolsOptions: tool.ASTReadToolsOptions{
-				RepoASTsDir: uri,
-			},
+			ServerName:          mcp.RepoServerName(opts.RepoID),
+			ServerVersion:       version.Version,
+			Verbose:             *flagVerbose,
+			ASTReadToolsOptions: topts,
 		})
 		if err := svr.ServeStdio(); err != nil {
 			log.Error("Failed to run MCP server: %v\n", err)
@@ -219,8 +353,17 @@ func main() {
 
 	case "translate":
 		srcLang, dstLang, uri := parseTranslateArgs(flags, flagHelp, flagVerbose)
+		if sourceUniAST == "" && uri != "" {
+			if stat, err := os.Stat(uri); err == nil && !stat.IsDir() && strings.HasSuffix(strings.ToLower(uri), ".json") {
+				log.Info("Loading %s as the source UniAST, pass it with --source-uniast instead\n", uri)
+				sourceUniAST = uri
+			}
+		}
 		if uri == "" {
-			log.Error("Argument Path is required\n")
+			uri = sourceUniAST
+		}
+		if uri == "" {
+			log.Error("Argument Path or --source-uniast is required\n")
 			os.Exit(1)
 		}
 
@@ -257,6 +400,62 @@ func main() {
 			os.Exit(1)
 		}
 
+		switch reportFormat {
+		case pipeline.ReportFormatJSON, pipeline.ReportFormatText, pipeline.ReportFormatJUnit:
+		default:
+			log.Error("--report-format must be json, text or junit, got %s\n", reportFormat)
+			os.Exit(1)
+		}
+		if outputJSON && validateBuild {
+			log.Error("--validate-build needs the code to be written, it can't be used with --output-json\n")
+			os.Exit(1)
+		}
+		if outputJSON && generateTests {
+			log.Error("--test needs the code to be written, it can't be used with --output-json\n")
+			os.Exit(1)
+		}
+		if generateTests && dstLang != uniast.Golang {
+			log.Error("--test only works for translate to Go\n")
+			os.Exit(1)
+		}
+		if annotateSource && noAnnotate {
+			log.Error("--annotate-source and --no-annotate can't be used together\n")
+			os.Exit(1)
+		}
+		if outputJSON && compareDir != "" {
+			log.Error("--compare needs the code to be written, it can't be used with --output-json\n")
+			os.Exit(1)
+		}
+		if compareReport && compareDir == "" {
+			log.Error("--compare-report needs --compare\n")
+			os.Exit(1)
+		}
+		if compareDir != "" {
+			if info, err := os.Stat(compareDir); err != nil || !info.IsDir() {
+				log.Error("--compare baseline %s is not a directory\n", compareDir)
+				os.Exit(1)
+			}
+		}
+		if maxRetryPerNode < 0 {
+			log.Error("--max-retry-per-node must not be negative, got %d\n", maxRetryPerNode)
+			os.Exit(1)
+		}
+		var customTypeHints []translate.TypeHintOverride
+		for _, th := range typeHints {
+			hint, err := translate.ParseTypeHintOverride(th)
+			if err != nil {
+				log.Error("Invalid --type-hint: %v\n", err)
+				os.Exit(1)
+			}
+			customTypeHints = append(customTypeHints, hint)
+		}
+		if typeHintsFile != "" {
+			if err := translate.NewTypeHints(srcLang, dstLang).LoadFromFile(typeHintsFile); err != nil {
+				log.Error("Invalid --type-hints-file: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
 		log.Info("Translating %s → %s\n", srcLang, dstLang)
 
 		if flagVerbose != nil && *flagVerbose {
@@ -270,19 +469,30 @@ func main() {
 			TargetLang:     dstLang,
 			SourceCodePath: uri,
 			OutputPath:     "",
+			StartTime:      time.Now(),
 			History:        nil,
 		}
+		// the temp UniASTs of the run are removed at the end unless --keep-temp is set
+		tempASTDir := filepath.Join(os.TempDir(), "abcoder-translate-asts", pipelineState.RunID)
+		os.MkdirAll(tempASTDir, 0755)
+		cleanupTempASTs := func() {
+			if keepTemp {
+				fmt.Fprintf(os.Stderr, "Temp UniASTs kept in: %s\n", tempASTDir)
+			} else {
+				os.RemoveAll(tempASTDir)
+			}
+		}
+		defer cleanupTempASTs()
 		reportPipelineFailureAndExit := func() {
 			if n := len(pipelineState.History); n > 0 {
 				last := pipelineState.History[n-1]
 				log.Info("Pipeline: last step=%s, attempt=%d, status=%s\n", last.StepName, last.Attempt, last.Status)
 			}
+			cleanupTempASTs()
 			os.Exit(1)
 		}
 
 		// Parse source project to UniAST
-		tempASTDir := filepath.Join(os.TempDir(), "abcoder-translate-asts")
-		os.MkdirAll(tempASTDir, 0755)
 		tempASTFile := filepath.Join(tempASTDir, fmt.Sprintf("%s-repo.json", srcLang))
 
 		parseOpts := lang.ParseOptions{
@@ -298,42 +508,42 @@ func main() {
 			lspOptions["java.home"] = *javaHome
 		}
 		parseOpts.LspOptions = lspOptions
+		parseOpts.LSPTimeout = opts.LSPTimeout
 		parseOpts.TSConfig = opts.TSConfig
 		parseOpts.TSSrcDir = opts.TSSrcDir
 
 		var srcRepo *uniast.Repository
-		usedExistingUniAST := false
+		// existingUniASTPath is the UniAST file translated instead of parsing the repo
 		var existingUniASTPath string
-		if stat, err := os.Stat(uri); err == nil {
-			var candidatePath string
-			if stat.IsDir() {
-				candidatePath = filepath.Join(uri, "uniast.json")
-			} else if !stat.IsDir() && strings.HasSuffix(strings.ToLower(uri), ".json") {
-				candidatePath = uri
-			}
-			if candidatePath != "" {
-				if s, err := os.Stat(candidatePath); err == nil && s != nil && !s.IsDir() {
-					loaded, err := uniast.LoadRepo(candidatePath)
-					if err == nil {
-						srcRepo = loaded
-						usedExistingUniAST = true
-						existingUniASTPath = candidatePath
-						log.Info("Using existing UniAST: %s, skip parsing\n", candidatePath)
-					} else {
-						// User explicitly passed a .json path; failed load is fatal (don't fall back to parsing a file as directory)
-						if candidatePath == uri {
-							pipelineState.History = append(pipelineState.History, pipeline.StepRecord{
-								StepName: "parse", Attempt: 1, Status: pipeline.StepFailed, Error: err.Error(), Time: time.Now(),
-							})
-							log.Error("Failed to load UniAST from %s: %v\n", candidatePath, err)
-							reportPipelineFailureAndExit()
-						}
-						log.Info("Failed to load existing UniAST, will parse: %v\n", err)
-					}
-				}
+		if sourceUniAST != "" {
+			loaded, err := uniast.LoadRepo(sourceUniAST)
+			// an outdated UniAST is still used since it was explicitly passed
+			if uniast.IsVersionMismatch(err) {
+				log.Info("%v, consider re-parsing the repo\n", err)
+				err = nil
+			}
+			if err != nil {
+				pipelineState.History = append(pipelineState.History, pipeline.StepRecord{
+					StepName: "parse", Attempt: 1, Status: pipeline.StepFailed, Error: err.Error(), Time: time.Now(),
+				})
+				log.Error("Failed to load UniAST from %s: %v\n", sourceUniAST, err)
+				reportPipelineFailureAndExit()
+			}
+			srcRepo = loaded
+			existingUniASTPath = sourceUniAST
+			log.Info("Using source UniAST: %s, skip parsing\n", sourceUniAST)
+		} else if stat, err := os.Stat(filepath.Join(uri, "uniast.json")); err == nil && !stat.IsDir() {
+			// the UniAST left in the repo by a previous parse, the repo is parsed again if it's broken or outdated
+			candidatePath := filepath.Join(uri, "uniast.json")
+			if loaded, err := uniast.LoadRepo(candidatePath); err == nil {
+				srcRepo = loaded
+				existingUniASTPath = candidatePath
+				log.Info("Using existing UniAST: %s, skip parsing\n", candidatePath)
+			} else {
+				log.Info("Failed to load existing UniAST, will parse: %v\n", err)
 			}
 		}
-		if !usedExistingUniAST {
+		if existingUniASTPath == "" {
 			if srcLang == uniast.TypeScript {
 				if err := parseTSProject(context.Background(), uri, parseOpts, &tempASTFile); err != nil {
 					pipelineState.History = append(pipelineState.History, pipeline.StepRecord{
@@ -344,7 +554,7 @@ func main() {
 				}
 				var err error
 				srcRepo, err = uniast.LoadRepo(tempASTFile)
-				if err != nil {
+				if err != nil && !uniast.IsVersionMismatch(err) {
 					pipelineState.History = append(pipelineState.History, pipeline.StepRecord{
 						StepName: "parse", Attempt: 1, Status: pipeline.StepFailed, Error: err.Error(), Time: time.Now(),
 					})
@@ -368,7 +578,7 @@ func main() {
 					reportPipelineFailureAndExit()
 				}
 				srcRepo, err = uniast.LoadRepo(tempASTFile)
-				if err != nil {
+				if err != nil && !uniast.IsVersionMismatch(err) {
 					pipelineState.History = append(pipelineState.History, pipeline.StepRecord{
 						StepName: "parse", Attempt: 1, Status: pipeline.StepFailed, Error: err.Error(), Time: time.Now(),
 					})
@@ -380,7 +590,7 @@ func main() {
 		pipelineState.History = append(pipelineState.History, pipeline.StepRecord{
 			StepName: "parse", Attempt: 1, Status: pipeline.StepOK, Time: time.Now(),
 		})
-		if usedExistingUniAST {
+		if existingUniASTPath != "" {
 			log.Info("%s UniAST: %s\n", srcLang, existingUniASTPath)
 		} else {
 			log.Info("%s UniAST generated and saved to: %s\n", srcLang, tempASTFile)
@@ -390,15 +600,20 @@ func main() {
 		outputDir := ""
 		if flagOutput != nil && *flagOutput != "" {
 			outputDir = *flagOutput
-		} else {
+			if outputJSON {
+				log.Info("No code is written with --output-json, %s only keeps the pipeline report\n", outputDir)
+			}
+		} else if !outputJSON {
 			outputDir = filepath.Base(uri) + "-" + string(dstLang)
 		}
 		pipelineState.OutputPath = outputDir
 
 		// Create output directory
-		if err := os.MkdirAll(outputDir, 0755); err != nil {
-			log.Error("Failed to create output directory: %v\n", err)
-			os.Exit(1)
+		if outputDir != "" {
+			if err := os.MkdirAll(outputDir, 0755); err != nil {
+				log.Error("Failed to create output directory: %v\n", err)
+				reportPipelineFailureAndExit()
+			}
 		}
 
 		// Setup LLM configuration
@@ -408,22 +623,43 @@ func main() {
 			ModelName: os.Getenv("MODEL_NAME"),
 			BaseURL:   os.Getenv("BASE_URL"),
 		}
+		if modelConfigPath == "" {
+			modelConfigPath = os.Getenv("ABCODER_MODEL_CONFIG")
+		}
+		if modelConfigPath != "" {
+			if err := llm.ApplyModelConfigFile(&modelConfig, modelConfigPath, modelProfile); err != nil {
+				log.Error("Failed to load model config: %v\n", err)
+				reportPipelineFailureAndExit()
+			}
+		} else if modelProfile != "" {
+			log.Error("--model-profile requires --model-config or env ABCODER_MODEL_CONFIG\n")
+			reportPipelineFailureAndExit()
+		}
 
 		if modelConfig.APIType == llm.ModelTypeUnknown {
 			log.Error("env API_TYPE is required for translation")
-			os.Exit(1)
+			reportPipelineFailureAndExit()
 		}
 		if modelConfig.APIKey == "" {
 			log.Error("env API_KEY is required for translation")
-			os.Exit(1)
+			reportPipelineFailureAndExit()
 		}
 		if modelConfig.ModelName == "" {
 			log.Error("env MODEL_NAME is required for translation")
-			os.Exit(1)
+			reportPipelineFailureAndExit()
 		}
 
 		// Create LLM translator callback
-		llmTranslator := createLLMTranslator(modelConfig)
+		llmTranslator := createLLMTranslator(modelConfig, annotateSource)
+		if verbosePrompt != "" {
+			promptLogger, err := translate.NewPromptLogger(verbosePrompt)
+			if err != nil {
+				log.Error("Failed to create prompt log dir: %v\n", err)
+				reportPipelineFailureAndExit()
+			}
+			llmTranslator = promptLogger.Wrap(llmTranslator)
+			log.Info("LLM prompts are logged into %s\n", verbosePrompt)
+		}
 
 		// Determine web framework if auto
 		framework := webFramework
@@ -454,14 +690,21 @@ func main() {
 				packageConcurrency = n
 			}
 		}
-		if packageConcurrency == 1 && translate.CountTranslatableNodes(srcRepo) > 500 {
+		pkgConcurrencySet := false
+		flags.Visit(func(f *flag.Flag) {
+			pkgConcurrencySet = pkgConcurrencySet || f.Name == "max-pkg-concurrency"
+		})
+		if pkgConcurrencySet {
+			packageConcurrency = min(max(maxPkgConcurrency, 1), 16)
+		} else if packageConcurrency == 1 && translate.CountTranslatableNodes(srcRepo) > 500 {
 			packageConcurrency = 4
 		}
+		log.Info("Translate concurrency: %d packages x %d nodes\n", packageConcurrency, concurrency)
 		translateResult := &translate.TranslateResult{}
 		translateOpts := translate.TranslateOptions{
 			SourceLanguage:           srcLang,
 			TargetLanguage:           dstLang,
-			TargetModuleName:         "", // Auto-derive from source
+			TargetModuleName:         targetModule, // Auto-derive from source if empty
 			OutputDir:                outputDir,
 			LLMTranslator:           llmTranslator,
 			Parallel:                 true,
@@ -469,17 +712,43 @@ func main() {
 			PackageConcurrency:       packageConcurrency,
 			MaxDependenciesInPrompt:  25,
 			MaxSourceChars:           12000,
+			MaxPromptLength:          contextLength,
 			WebFramework:             framework,
 			GenerateEntryPoint: !noEntryPoint,
-			GenerateConfig:     !noConfig,
+			GenerateConfig:     !noConfig && !outputJSON, // config files are written to the output dir
 			Result:             translateResult,
-			ProgressCallback: func(done, total int, currentKind, currentNodeID string) {
+			SkipLargeNodes:     skipLargeNodes,
+			BatchSize:          batchSize,
+			PackageSplitThreshold: packageSplitThreshold,
+			MinQualityScore:    minQualityScore,
+			ProgressCallback: func(done, total int, currentKind, currentNodeID string, eta time.Duration) {
 				if total > 0 {
 					pct := 100 * float64(done) / float64(total)
-					log.Info("Progress: %d/%d (%.1f%%) current: %s %s\n", done, total, pct, currentKind, currentNodeID)
+					remaining := ""
+					if eta > 0 {
+						remaining = fmt.Sprintf(" ~%v remaining", eta.Round(time.Second))
+					}
+					log.Info("Progress: %d/%d (%.1f%%)%s current: %s %s\n", done, total, pct, remaining, currentKind, currentNodeID)
 				}
 			},
 		}
+		translateOpts.MaxRetryPerNode = maxRetryPerNode
+		if maxRetryPerNode == 0 && minQualityScore > 0 {
+			// give the nodes flagged by the quality check a chance to be translated again
+			translateOpts.MaxRetryPerNode = 3
+		}
+		translateOpts.TypeHintsFile = typeHintsFile
+		translateOpts.CustomTypeHints = customTypeHints
+		if nodeFilterRegex != "" {
+			re, err := regexp.Compile(nodeFilterRegex)
+			if err != nil {
+				log.Error("Invalid --node-filter-regex: %v\n", err)
+				reportPipelineFailureAndExit()
+			}
+			translateOpts.NodeFilter = func(id uniast.Identity) bool {
+				return re.MatchString(id.Name)
+			}
+		}
 
 		// Transform source UniAST to target UniAST with LLM content translation
 		log.Info("Translating %s to %s using LLM (Parser → Transform → Writer flow)...\n", srcLang, dstLang)
@@ -496,17 +765,34 @@ func main() {
 		pipelineState.History = append(pipelineState.History, pipeline.StepRecord{
 			StepName: "transform", Attempt: 1, Status: pipeline.StepOK, Time: time.Now(),
 		})
+		for _, skipped := range translateResult.SkippedNodes {
+			log.Info("Skipped node %s: %s (%d chars), translate it manually\n", skipped.NodeID, skipped.Reason, skipped.ContentLen)
+		}
+		for _, batch := range translateResult.FailedBatches {
+			log.Info("Batch of %d nodes failed, translated them one by one: %s\n", len(batch.NodeIDs), batch.Err)
+		}
+		stats := translateResult.TranslationStats
+		for _, kind := range []struct {
+			name  string
+			stats translate.TranslationKindStats
+		}{{"types", stats.Types}, {"functions", stats.Functions}, {"vars", stats.Vars}} {
+			log.Info("Translated %d/%d %s (%d failed, %d skipped)\n", kind.stats.Translated, kind.stats.Total, kind.name, kind.stats.Failed, kind.stats.Skipped)
+		}
+
+		if noAnnotate {
+			translate.StripRepoSourceAnnotations(targetRepo)
+		}
 
 		// Save target UniAST to JSON file
 		targetASTFile := filepath.Join(tempASTDir, fmt.Sprintf("%s-repo.json", dstLang))
 		targetASTJSON, err := json.MarshalIndent(targetRepo, "", "  ")
 		if err != nil {
 			log.Error("Failed to marshal target AST: %v\n", err)
-			os.Exit(1)
+			reportPipelineFailureAndExit()
 		}
 		if err := utils.MustWriteFile(targetASTFile, targetASTJSON); err != nil {
 			log.Error("Failed to write target AST file: %v\n", err)
-			os.Exit(1)
+			reportPipelineFailureAndExit()
 		}
 		log.Info("Target UniAST saved to: %s\n", targetASTFile)
 
@@ -524,10 +810,17 @@ func main() {
 		// Snapshot target UniAST so rollback (e.g. on later failure) can restore; on Fatal validation we never reach here.
 		pipelineState.TargetUniAST = pipeline.NewSnapshot("target-uniast", targetRepo, targetASTJSON)
 
-		// Write target code using lang.Write
-		err = lang.Write(context.Background(), targetRepo, lang.WriteOptions{
-			OutputDir: outputDir,
-		})
+		// Write target code using lang.Write, or print the target UniAST instead
+		if outputJSON {
+			_, err = fmt.Fprintf(os.Stdout, "%s\n", targetASTJSON)
+		} else {
+			err = lang.Write(context.Background(), targetRepo, lang.WriteOptions{
+				OutputDir:         outputDir,
+				SplitByPackage:    splitOutput,
+				GenerateTestStubs: wopts.GenerateTestStubs,
+				CxxBuildSystem:    wopts.CxxBuildSystem,
+			})
+		}
 		if err != nil {
 			pipelineState.History = append(pipelineState.History, pipeline.StepRecord{
 				StepName: "write", Attempt: 1, Status: pipeline.StepFailed, Error: err.Error(), Time: time.Now(),
@@ -553,16 +846,33 @@ func main() {
 				}
 			}
 		}
-		// Persist pipeline report (StepHistory) for observability
-		if reportPath := filepath.Join(outputDir, "abcoder-pipeline-report.json"); outputDir != "" {
+		// Persist pipeline report (StepHistory) for observability, rewritten with the test results by --test
+		var testReport *translate.TestReport
+		writePipelineReport := func() {
+			if outputDir == "" {
+				return
+			}
 			report := struct {
-				RunID   string               `json:"run_id"`
-				History []pipeline.StepRecord `json:"history"`
-			}{RunID: pipelineState.RunID, History: pipelineState.History}
-			if reportJSON, err := json.MarshalIndent(report, "", "  "); err == nil {
-				_ = os.WriteFile(reportPath, reportJSON, 0644)
+				RunID   string                     `json:"run_id"`
+				History []pipeline.StepRecord      `json:"history"`
+				Stats   translate.TranslationStats `json:"stats"`
+				Tests   *translate.TestReport      `json:"tests,omitempty"`
+			}{RunID: pipelineState.RunID, History: pipelineState.History, Stats: translateResult.TranslationStats, Tests: testReport}
+			var data []byte
+			var err error
+			switch reportFormat {
+			case pipeline.ReportFormatText:
+				data = pipelineState.TextReport()
+			case pipeline.ReportFormatJUnit:
+				data, err = pipelineState.JUnitReport()
+			default:
+				data, err = json.MarshalIndent(report, "", "  ")
+			}
+			if err == nil {
+				_ = os.WriteFile(filepath.Join(outputDir, pipeline.ReportFileName(reportFormat)), data, 0644)
 			}
 		}
+		writePipelineReport()
 		// Lightweight checkpoint for future resume: translated_ids + source identifier
 		if outputDir != "" && translateResult.TranslatedIDs != nil {
 			ids := make([]string, 0, len(translateResult.TranslatedIDs))
@@ -578,38 +888,28 @@ func main() {
 				_ = os.WriteFile(filepath.Join(outputDir, "abcoder-translate-checkpoint.json"), checkpointJSON, 0644)
 			}
 		}
+		if outputJSON {
+			// no code to post-process
+			log.Info("Translation completed successfully!\n")
+			return
+		}
 
 		// Run target language specific post-processing
 		switch dstLang {
 		case uniast.Golang:
-			// Fix invalid imports in generated Go files
-			// First try to read module name from go.mod
-			moduleName := readGoModuleName(outputDir)
-			if moduleName == "" {
-				moduleName = translateOpts.TargetModuleName
-			}
-			if moduleName == "" {
-				moduleName = "github.com/example/" + filepath.Base(outputDir)
-			}
-			if err := fixGoImportsInFiles(outputDir, moduleName); err != nil {
-				log.Info("Failed to fix imports: %v\n", err)
-			}
-			// Run goimports to fix any remaining import issues and format code
-			if err := runGoimports(outputDir); err != nil {
-				log.Info("Failed to run goimports: %v\n", err)
-			}
-			// Run go mod tidy
-			if err := runGoModTidy(outputDir); err != nil {
-				log.Info("Failed to run go mod tidy: %v\n", err)
-			}
+			postProcessGoOutput(outputDir, translateOpts.TargetModuleName)
 			// Try to build
-			if err := runGoBuild(outputDir); err != nil {
-				log.Info("Go build failed: %v\n", err)
+			if !validateBuild {
+				if err := runGoBuild(outputDir); err != nil {
+					log.Info("Go build failed: %v\n", err)
+				}
 			}
 		case uniast.Rust:
 			// Run cargo check
-			if err := runCargoCheck(outputDir); err != nil {
-				log.Info("Cargo check failed: %v\n", err)
+			if !validateBuild {
+				if err := runCargoCheck(outputDir); err != nil {
+					log.Info("Cargo check failed: %v\n", err)
+				}
 			}
 		case uniast.Python:
 			// Python doesn't need compilation, but we can check syntax
@@ -622,9 +922,97 @@ func main() {
 			log.Info("C++ code generated. Run 'cmake . && make' or 'g++ -o main *.cpp' to compile.\n")
 		}
 
+		// Build the written code and re-translate the nodes causing build errors
+		if validateBuild {
+			validated, buildErrs, err := translate.ValidateBuild(context.Background(), srcRepo, targetRepo, translateOpts, translate.ValidateBuildOptions{
+				OutputDir: outputDir,
+				MaxRetry:  buildRetry,
+				Write: func(repo *uniast.Repository) error {
+					if err := lang.Write(context.Background(), repo, lang.WriteOptions{
+						OutputDir:         outputDir,
+						SplitByPackage:    splitOutput,
+						GenerateTestStubs: wopts.GenerateTestStubs,
+						CxxBuildSystem:    wopts.CxxBuildSystem,
+					}); err != nil {
+						return err
+					}
+					if dstLang == uniast.Golang {
+						postProcessGoOutput(outputDir, translateOpts.TargetModuleName)
+					}
+					return nil
+				},
+				RetryCallback: func(attempt int, errs []translate.BuildError, failed []translate.FailedNodeInfo) {
+					log.Info("Build failed with %d errors, re-translating %d nodes (attempt %d/%d)\n", len(errs), len(failed), attempt, buildRetry)
+				},
+			})
+			if err != nil {
+				log.Error("Failed to validate build: %v\n", err)
+			} else {
+				targetRepo = validated
+				for _, e := range buildErrs {
+					log.Info("Build error left: %s\n", e)
+				}
+				if len(buildErrs) == 0 {
+					log.Info("Build validation passed\n")
+				}
+				if targetASTJSON, err := json.MarshalIndent(targetRepo, "", "  "); err == nil {
+					_ = utils.MustWriteFile(targetASTFile, targetASTJSON)
+				}
+			}
+		}
+
+		// Generate and run LLM-written tests of the complex translated functions
+		if generateTests {
+			report, err := translate.GenerateTests(context.Background(), targetRepo, translate.GenerateTestsOptions{
+				OutputDir:     outputDir,
+				LLMTranslator: llmTranslator,
+			})
+			if err != nil {
+				pipelineState.History = append(pipelineState.History, pipeline.StepRecord{
+					StepName: "test", Attempt: 1, Status: pipeline.StepFailed, Error: err.Error(), Time: time.Now(),
+				})
+				log.Error("Failed to generate tests: %v\n", err)
+			} else {
+				testReport = report
+				rec := pipeline.StepRecord{StepName: "test", Attempt: 1, Status: pipeline.StepOK, Time: time.Now()}
+				if report.Failed > 0 {
+					rec.Status, rec.Error = pipeline.StepFailed, fmt.Sprintf("%d tests failed", report.Failed)
+				}
+				pipelineState.History = append(pipelineState.History, rec)
+				for _, e := range report.Errors {
+					log.Info("Test generation error: %s\n", e)
+				}
+				log.Info("Generated tests for %d functions: %d passed, %d failed\n", report.Generated, report.Passed, report.Failed)
+			}
+			writePipelineReport()
+		}
+
+		// Diff the written code against a previous run
+		if compareDir != "" {
+			patch, report, err := pipeline.CompareDirs(outputDir, compareDir)
+			if err != nil {
+				log.Error("Failed to compare with %s: %v\n", compareDir, err)
+			} else {
+				if err := os.WriteFile(filepath.Join(outputDir, pipeline.CompareDiffFile), []byte(patch), 0644); err != nil {
+					log.Error("Failed to write the translation diff: %v\n", err)
+				}
+				if compareReport {
+					log.Info("Compared with %s: %s\n", compareDir, report.Summary())
+					if data, err := json.MarshalIndent(report, "", "  "); err == nil {
+						_ = os.WriteFile(filepath.Join(outputDir, pipeline.CompareReportFile), data, 0644)
+					}
+				}
+			}
+		}
+
 		log.Info("Translation completed successfully!\n")
-		log.Info("Source UniAST: %s\n", tempASTFile)
-		log.Info("Target UniAST: %s\n", targetASTFile)
+		if keepTemp {
+			if existingUniASTPath != "" {
+				tempASTFile = existingUniASTPath
+			}
+			log.Info("Source UniAST: %s\n", tempASTFile)
+			log.Info("Target UniAST: %s\n", targetASTFile)
+		}
 		log.Info("%s code written to: %s\n", dstLang, outputDir)
 
 	case "agent":
@@ -708,6 +1096,7 @@ func parseArgsAndFlags(flags *flag.FlagSet, needLang bool, flagHelp *bool, flagV
 func parseTranslateArgs(flags *flag.FlagSet, flagHelp *bool, flagVerbose *bool) (srcLang uniast.Language, dstLang uniast.Language, uri string) {
 	if len(os.Args) < 5 {
 		fmt.Fprintf(os.Stderr, "Usage: abcoder translate <src-lang> <dst-lang> <path>\n")
+		fmt.Fprintf(os.Stderr, "   or: abcoder translate <src-lang> <dst-lang> --source-uniast <uniast-file>\n")
 		fmt.Fprintf(os.Stderr, "Example: abcoder translate java go ./my-java-project\n")
 		os.Exit(1)
 	}
@@ -724,10 +1113,12 @@ func parseTranslateArgs(flags *flag.FlagSet, flagHelp *bool, flagVerbose *bool)
 		os.Exit(1)
 	}
 
-	uri = os.Args[4]
-	if len(os.Args) > 5 {
-		flags.Parse(os.Args[5:])
+	// the path can be omitted if the source UniAST is passed by --source-uniast
+	args := os.Args[4:]
+	if !strings.HasPrefix(args[0], "-") {
+		uri, args = args[0], args[1:]
 	}
+	flags.Parse(args)
 
 	if flagHelp != nil && *flagHelp {
 		flags.Usage()
@@ -752,6 +1143,75 @@ func (s *StringArray) String() string {
 	return strings.Join(*s, ",")
 }
 
+// splitRepo writes the UniAST of each internal module of the repo AST file into outputDir
+func splitRepo(astFile, outputDir string) error {
+	repo, err := uniast.LoadRepo(astFile)
+	if err != nil && !uniast.IsVersionMismatch(err) {
+		return fmt.Errorf("load repo: %w", err)
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+	for name, sub := range repo.SplitByModule() {
+		if err := uniast.ValidateRepository(sub); err != nil {
+			log.Info("Module %s has validation issues: %v\n", name, err)
+		}
+		if sub.Checksum, err = sub.ComputeChecksum(); err != nil {
+			return err
+		}
+		out, err := json.Marshal(sub)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(outputDir, moduleFileName(name)+".json")
+		if err := utils.MustWriteFile(file, out); err != nil {
+			return err
+		}
+		log.Info("Module %s written to %s\n", name, file)
+	}
+	return nil
+}
+
+// appendRepo merges the parsed repo out into the UniAST file, which is created with out if missing
+func appendRepo(out []byte, file string) error {
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return utils.MustWriteFile(file, out)
+	}
+	combined, err := uniast.LoadRepo(file)
+	if uniast.IsVersionMismatch(err) {
+		log.Info("%v, the merged repos may differ in format\n", err)
+	} else if err != nil {
+		return fmt.Errorf("load %s: %w", file, err)
+	}
+	var repo uniast.Repository
+	if err := json.Unmarshal(out, &repo); err != nil {
+		return err
+	}
+	if err := uniast.Merge(combined, &repo); err != nil {
+		return fmt.Errorf("merge %s: %w", repo.Path, err)
+	}
+	combined.ASTVersion = uniast.Version
+	combined.ToolVersion = version.Version
+	if combined.Checksum, err = combined.ComputeChecksum(); err != nil {
+		return err
+	}
+	bs, err := json.Marshal(combined)
+	if err != nil {
+		return err
+	}
+	return utils.MustWriteFile(file, bs)
+}
+
+// moduleFileName replaces the chars of a module name which are unsafe in file names, e.g. / and :
+func moduleFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
 func parseTSProject(ctx context.Context, repoPath string, opts lang.ParseOptions, outputFlag *string) error {
 	if outputFlag == nil {
 		return fmt.Errorf("output path is required")
@@ -1115,8 +1575,9 @@ func callLLMWithoutTools(ctx context.Context, modelConfig llm.ModelConfig, promp
 	return response.Content, nil
 }
 
-// createLLMTranslator creates an LLM translator callback for the translate package
-func createLLMTranslator(modelConfig llm.ModelConfig) translate.LLMTranslateFunc {
+// createLLMTranslator creates an LLM translator callback for the translate package.
+// If annotateSource is set, the source of the node is put as comments above the translated code.
+func createLLMTranslator(modelConfig llm.ModelConfig, annotateSource bool) translate.LLMTranslateFunc {
 	return func(ctx context.Context, req *translate.LLMTranslateRequest) (*translate.LLMTranslateResponse, error) {
 		// Use the pre-built prompt from PromptBuilder
 		prompt := req.Prompt
@@ -1129,7 +1590,10 @@ func createLLMTranslator(modelConfig llm.ModelConfig) translate.LLMTranslateFunc
 		log.Debug("LLM Translation Request:\n  Node: %s\n  Type: %s\n", req.Identity.Name, req.NodeType)
 
 		// Call LLM with retry logic for transient errors
-		maxRetries := 3
+		maxRetries := modelConfig.Retries
+		if maxRetries <= 0 {
+			maxRetries = llm.DefaultRetries
+		}
 		var response string
 		var err error
 
@@ -1140,15 +1604,7 @@ func createLLMTranslator(modelConfig llm.ModelConfig) translate.LLMTranslateFunc
 			}
 
 			// Check if error is retryable (timeout, connection reset, etc.)
-			errStr := err.Error()
-			isRetryable := strings.Contains(errStr, "timeout") ||
-				strings.Contains(errStr, "timed out") ||
-				strings.Contains(errStr, "connection reset") ||
-				strings.Contains(errStr, "connection refused") ||
-				strings.Contains(errStr, "EOF") ||
-				strings.Contains(errStr, "temporary failure")
-
-			if !isRetryable || attempt == maxRetries {
+			if !modelConfig.IsRetryable(err) || attempt == maxRetries {
 				log.Error("LLM call failed after %d attempts: %v\n", attempt, err)
 				return &translate.LLMTranslateResponse{
 					Error: fmt.Sprintf("LLM call failed: %v", err),
@@ -1166,6 +1622,11 @@ func createLLMTranslator(modelConfig llm.ModelConfig) translate.LLMTranslateFunc
 
 		log.Debug("LLM Translation Response:\n  Content length: %d\n", len(content))
 
+		// the answer of a batch request is split into the nodes later
+		if annotateSource && len(req.Batch) == 0 {
+			content = translate.AnnotateSource(content, req.SourceContent, req.SourceLanguage, req.TargetLanguage)
+		}
+
 		return &translate.LLMTranslateResponse{
 			TargetContent: content,
 		}, nil
@@ -1376,17 +1837,6 @@ func formatGoFile(filePath string) error {
 	return nil
 }
 
-// generateGoMod generates a go.mod file
-func generateGoMod(outputDir, moduleName string) (string, error) {
-	sanitizedModule := sanitizeModuleName(moduleName, outputDir)
-
-	goModPath := filepath.Join(outputDir, "go.mod")
-	goVersion := detectGoVersion()
-
-	content := fmt.Sprintf("module %s\n\ngo %s\n", sanitizedModule, goVersion)
-	return sanitizedModule, os.WriteFile(goModPath, []byte(content), 0644)
-}
-
 // runGoModTidy runs go mod tidy in the output directory
 // fixGoImportsInFiles fixes invalid imports in generated Go files
 func fixGoImportsInFiles(outputDir, moduleName string) error {
@@ -2170,6 +2620,30 @@ func readGoModuleName(outputDir string) string {
 	return ""
 }
 
+// postProcessGoOutput fixes imports, formats code and tidies go.mod of the generated Go project
+func postProcessGoOutput(outputDir, fallbackModuleName string) {
+	// Fix invalid imports in generated Go files
+	// First try to read module name from go.mod
+	moduleName := readGoModuleName(outputDir)
+	if moduleName == "" {
+		moduleName = fallbackModuleName
+	}
+	if moduleName == "" {
+		moduleName = "github.com/example/" + filepath.Base(outputDir)
+	}
+	if err := fixGoImportsInFiles(outputDir, moduleName); err != nil {
+		log.Info("Failed to fix imports: %v\n", err)
+	}
+	// Run goimports to fix any remaining import issues and format code
+	if err := runGoimports(outputDir); err != nil {
+		log.Info("Failed to run goimports: %v\n", err)
+	}
+	// Run go mod tidy
+	if err := runGoModTidy(outputDir); err != nil {
+		log.Info("Failed to run go mod tidy: %v\n", err)
+	}
+}
+
 func runGoModTidy(outputDir string) error {
 	cmd := exec.Command("go", "mod", "tidy")
 	cmd.Dir = outputDir
@@ -2252,48 +2726,6 @@ func runCargoCheck(outputDir string) error {
 	return nil
 }
 
-// sanitizeModuleName ensures the module name is valid for go.mod
-func sanitizeModuleName(moduleName, outputDir string) string {
-	mod := strings.TrimSpace(moduleName)
-	if mod == "" {
-		mod = filepath.Base(outputDir)
-	}
-	// If module name is absolute path or starts with '/', convert to example.com/<basename>
-	if strings.HasPrefix(mod, "/") || strings.Contains(mod, string(os.PathSeparator)) {
-		mod = filepath.Base(mod)
-	}
-	// Replace spaces with underscore
-	mod = strings.ReplaceAll(mod, " ", "_")
-	// If no dot, prepend example.com/
-	if !strings.Contains(mod, ".") {
-		mod = "example.com/" + mod
-	}
-	return mod
-}
-
-// detectGoVersion extracts the major.minor version from `go version`
-func detectGoVersion() string {
-	// default fallback
-	goVersion := "1.21"
-	out, err := exec.Command("go", "version").Output()
-	if err != nil {
-		return goVersion
-	}
-	// Sample: "go version go1.22.5 darwin/arm64"
-	toks := strings.Fields(string(out))
-	for _, t := range toks {
-		if strings.HasPrefix(t, "go1.") {
-			ver := strings.TrimPrefix(t, "go")
-			parts := strings.Split(ver, ".")
-			if len(parts) >= 2 {
-				return parts[0] + "." + parts[1]
-			}
-			return ver
-		}
-	}
-	return goVersion
-}
-
 // buildStubGoCode builds a simple Go file content from Java code (fallback when LLM translation fails)
 func buildStubGoCode(goPkgPath string, javaCode string, filePath string) string {
 	pkgDir := filepath.Base(goPkgPath)
@@ -22,8 +22,12 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/cloudwego/abcoder/lang/log"
 	"github.com/cloudwego/abcoder/llm"
@@ -142,23 +146,45 @@ func runSkillAgent(ctx context.Context, astsDir, skillName string, aopts agent.A
 	// 创建 model
 	model := llm.NewChatModel(aopts.Model)
 
+	// 会话级花费统计，超出 --max-cost 时停止
+	cost := aopts.NewCostTracker()
+	if cost != nil {
+		ctx = cost.WithContext(ctx)
+	}
+	var exchanges []agent.Exchange
+
+	// 会话报告，--output-report 时在会话结束（包括中断）时写出
+	report, finishReport := startReport(aopts.OutputReport)
+	defer finishReport(false)
+	if report != nil {
+		report.UseSkill(skillName)
+	}
+
 	// 创建 coordinator（用于获取工具和创建 agent）
 	coordinator, err := agent.NewCoordinator(ctx, registry, model, agent.CoordinatorOptions{
 		ASTsDir:  astsDir,
 		MaxSteps: aopts.MaxSteps,
-		Retries:  3,
-		Timeout:  600,
+		Retries:  aopts.Model.Retries,
+		Timeout:  coordinatorTimeout(aopts.Model),
+
+		RetryableErrors: aopts.Model.RetryableErrors,
+
+		ToolTimeout:  aopts.ToolTimeout,
+		ToolTimeouts: aopts.ToolTimeouts,
+
+		CostTracker: cost,
+		Report:      report,
 	})
 	if err != nil {
 		log.Error("Failed to create coordinator: %v", err)
-		os.Exit(1)
+		exitSession(report, finishReport, skillName, err)
 	}
 
 	// 获取或创建指定 skill 的 agent
 	skillAgent, err := coordinator.GetAgent(skillName)
 	if err != nil {
 		log.Error("Failed to get skill agent: %v", err)
-		os.Exit(1)
+		exitSession(report, finishReport, skillName, err)
 	}
 
 	// 运行 REPL
@@ -176,15 +202,32 @@ func runSkillAgent(ctx context.Context, astsDir, skillName string, aopts agent.A
 
 		// 使用 skill agent 直接调用
 		resp, err := skillAgent.Call(ctx, query)
+		recordStep(report, skillName, query, resp, err)
 		if err != nil {
+			exchanges = append(exchanges, agent.Exchange{Query: query, Error: err.Error()})
+			if cost != nil && cost.Exceeded() {
+				finishReport(true)
+				cost.StopSession(agent.DefaultAgentStateFile, exchanges)
+			}
 			log.Error("Failed to run agent: %v\n", err)
 			continue
 		}
+		exchanges = append(exchanges, agent.Exchange{Query: query, Response: resp})
 
 		fmt.Fprintf(os.Stdout, "\n%s\n", resp)
 	}
 }
 
+// coordinatorTimeout 返回子 agent 的超时时间（秒）：模型配置的超时时间，未配置时为 llm.DefaultTimeout
+func coordinatorTimeout(m llm.ModelConfig) int {
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = llm.DefaultTimeout
+	}
+	// 向上取整，避免不足 1 秒的超时变为 0（不超时）
+	return int((timeout + time.Second - 1) / time.Second)
+}
+
 // runCoordinatorAgent 运行 coordinator agent（自动匹配 skills）
 func runCoordinatorAgent(ctx context.Context, astsDir string, aopts agent.AgentOptions) {
 	// 初始化 registry
@@ -197,16 +240,36 @@ func runCoordinatorAgent(ctx context.Context, astsDir string, aopts agent.AgentO
 	// 创建 model
 	model := llm.NewChatModel(aopts.Model)
 
+	// 会话级花费统计，超出 --max-cost 时停止
+	cost := aopts.NewCostTracker()
+	if cost != nil {
+		ctx = cost.WithContext(ctx)
+	}
+	var exchanges []agent.Exchange
+
+	// 会话报告，--output-report 时在会话结束（包括中断）时写出
+	report, finishReport := startReport(aopts.OutputReport)
+	defer finishReport(false)
+
 	// 创建 coordinator
 	coordinator, err := agent.NewCoordinator(ctx, registry, model, agent.CoordinatorOptions{
 		ASTsDir:  astsDir,
 		MaxSteps: aopts.MaxSteps,
-		Retries:  3,
-		Timeout:  600,
+		Retries:  aopts.Model.Retries,
+		Timeout:  coordinatorTimeout(aopts.Model),
+
+		RetryableErrors: aopts.Model.RetryableErrors,
+
+		ToolTimeout:  aopts.ToolTimeout,
+		ToolTimeouts: aopts.ToolTimeouts,
+
+		CostTracker:          cost,
+		Report:               report,
+		MaxParallelSubAgents: aopts.MaxParallelSubAgents,
 	})
 	if err != nil {
 		log.Error("Failed to create coordinator: %v", err)
-		os.Exit(1)
+		exitSession(report, finishReport, "", err)
 	}
 
 	// 运行 REPL
@@ -223,11 +286,70 @@ func runCoordinatorAgent(ctx context.Context, astsDir string, aopts agent.AgentO
 		}
 
 		resp, err := coordinator.Process(ctx, query)
+		recordStep(report, "", query, resp, err)
 		if err != nil {
+			exchanges = append(exchanges, agent.Exchange{Query: query, Error: err.Error()})
+			if cost != nil && cost.Exceeded() {
+				finishReport(true)
+				cost.StopSession(agent.DefaultAgentStateFile, exchanges)
+			}
 			log.Error("Failed to process: %v\n", err)
 			continue
 		}
+		exchanges = append(exchanges, agent.Exchange{Query: query, Response: resp})
 
 		fmt.Fprintf(os.Stdout, "\n%s\n", resp)
 	}
 }
+
+// startReport 创建会话报告，path 为空时返回 nil。
+// 返回的 finish 只会生效一次，写出报告；收到 SIGINT/SIGTERM 时也会以中断状态写出报告后退出
+func startReport(path string) (*agent.ReportAccumulator, func(interrupted bool)) {
+	if path == "" {
+		return nil, func(bool) {}
+	}
+	report := agent.NewReportAccumulator("")
+	var once sync.Once
+	finish := func(interrupted bool) {
+		once.Do(func() {
+			if interrupted {
+				report.SetInterrupted()
+			}
+			if err := report.WriteFile(path); err != nil {
+				log.Error("Failed to write agent report: %v", err)
+				return
+			}
+			log.Info("agent report written to %s", path)
+		})
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		finish(true)
+		os.Exit(130)
+	}()
+	return report, finish
+}
+
+// exitSession 把会话启动的失败记录到会话报告，写出报告后退出（os.Exit 不会执行 defer）
+func exitSession(report *agent.ReportAccumulator, finishReport func(interrupted bool), skillName string, err error) {
+	recordStep(report, skillName, "", "", err)
+	finishReport(false)
+	os.Exit(1)
+}
+
+// recordStep 把一轮问答记录到会话报告，成功的回答作为报告的总结
+func recordStep(report *agent.ReportAccumulator, skillName, query, resp string, err error) {
+	if report == nil {
+		return
+	}
+	step := agent.StepRecord{Skill: skillName, Query: query, Response: resp}
+	if err != nil {
+		step.Error = err.Error()
+	} else {
+		report.SetSummary(resp)
+	}
+	report.AddStep(step)
+}